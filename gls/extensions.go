@@ -0,0 +1,128 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gls
+
+// Compressed texture formats which are not part of the OpenGL 3.3 core
+// profile and so are not generated in consts.go. Their availability
+// depends on the extensions supported by the driver.
+const (
+	// GL_EXT_texture_compression_s3tc (BC1, BC2, BC3)
+	COMPRESSED_RGB_S3TC_DXT1_EXT  = 0x83F0
+	COMPRESSED_RGBA_S3TC_DXT1_EXT = 0x83F1
+	COMPRESSED_RGBA_S3TC_DXT3_EXT = 0x83F2
+	COMPRESSED_RGBA_S3TC_DXT5_EXT = 0x83F3
+
+	// GL_EXT_texture_sRGB
+	COMPRESSED_SRGB_S3TC_DXT1_EXT       = 0x8C4C
+	COMPRESSED_SRGB_ALPHA_S3TC_DXT1_EXT = 0x8C4D
+	COMPRESSED_SRGB_ALPHA_S3TC_DXT3_EXT = 0x8C4E
+	COMPRESSED_SRGB_ALPHA_S3TC_DXT5_EXT = 0x8C4F
+
+	// GL_ARB_texture_compression_bptc (BC6H, BC7)
+	COMPRESSED_RGBA_BPTC_UNORM         = 0x8E8C
+	COMPRESSED_SRGB_ALPHA_BPTC_UNORM   = 0x8E8D
+	COMPRESSED_RGB_BPTC_SIGNED_FLOAT   = 0x8E8E
+	COMPRESSED_RGB_BPTC_UNSIGNED_FLOAT = 0x8E8F
+
+	// GL_ARB_ES3_compatibility (ETC2, EAC)
+	COMPRESSED_R11_EAC                        = 0x9270
+	COMPRESSED_SIGNED_R11_EAC                 = 0x9271
+	COMPRESSED_RG11_EAC                       = 0x9272
+	COMPRESSED_SIGNED_RG11_EAC                = 0x9273
+	COMPRESSED_RGB8_ETC2                      = 0x9274
+	COMPRESSED_SRGB8_ETC2                     = 0x9275
+	COMPRESSED_RGB8_PUNCHTHROUGH_ALPHA1_ETC2  = 0x9276
+	COMPRESSED_SRGB8_PUNCHTHROUGH_ALPHA1_ETC2 = 0x9277
+	COMPRESSED_RGBA8_ETC2_EAC                 = 0x9278
+	COMPRESSED_SRGB8_ALPHA8_ETC2_EAC          = 0x9279
+
+	// GL_KHR_texture_compression_astc_ldr (first of 14 block sizes, 4x4 to 12x12)
+	COMPRESSED_RGBA_ASTC_4x4_KHR         = 0x93B0
+	COMPRESSED_SRGB8_ALPHA8_ASTC_4x4_KHR = 0x93D0
+)
+
+// Extensions returns the names of all the extensions supported by the
+// current OpenGL context. The result is cached after the first call.
+func (gs *GLS) Extensions() []string {
+
+	gs.loadExtensions()
+	names := make([]string, 0, len(gs.extensions))
+	for name := range gs.extensions {
+		names = append(names, name)
+	}
+	return names
+}
+
+// HasExtension returns if the specified extension (e.g. "GL_EXT_texture_compression_s3tc")
+// is supported by the current OpenGL context.
+func (gs *GLS) HasExtension(name string) bool {
+
+	gs.loadExtensions()
+	return gs.extensions[name]
+}
+
+// CompressedFormatSupported returns if the specified compressed texture
+// internal format can be uploaded with CompressedTexImage2D.
+func (gs *GLS) CompressedFormatSupported(iformat uint32) bool {
+
+	if gs.compressedFormats == nil {
+		gs.compressedFormats = make(map[uint32]bool)
+		var count int32
+		gs.GetIntegerv(NUM_COMPRESSED_TEXTURE_FORMATS, &count)
+		if count > 0 {
+			formats := make([]int32, count)
+			gs.GetIntegerv(COMPRESSED_TEXTURE_FORMATS, &formats[0])
+			for _, f := range formats {
+				gs.compressedFormats[uint32(f)] = true
+			}
+		}
+		// Core formats and formats of extensions which some drivers do not enumerate
+		for _, f := range []uint32{COMPRESSED_RED_RGTC1, COMPRESSED_SIGNED_RED_RGTC1, COMPRESSED_RG_RGTC2, COMPRESSED_SIGNED_RG_RGTC2} {
+			gs.compressedFormats[f] = true
+		}
+		if gs.HasExtension("GL_EXT_texture_compression_s3tc") {
+			for f := uint32(COMPRESSED_RGB_S3TC_DXT1_EXT); f <= COMPRESSED_RGBA_S3TC_DXT5_EXT; f++ {
+				gs.compressedFormats[f] = true
+			}
+			if gs.HasExtension("GL_EXT_texture_sRGB") {
+				for f := uint32(COMPRESSED_SRGB_S3TC_DXT1_EXT); f <= COMPRESSED_SRGB_ALPHA_S3TC_DXT5_EXT; f++ {
+					gs.compressedFormats[f] = true
+				}
+			}
+		}
+		if gs.HasExtension("GL_ARB_texture_compression_bptc") {
+			for f := uint32(COMPRESSED_RGBA_BPTC_UNORM); f <= COMPRESSED_RGB_BPTC_UNSIGNED_FLOAT; f++ {
+				gs.compressedFormats[f] = true
+			}
+		}
+		if gs.HasExtension("GL_ARB_ES3_compatibility") {
+			for f := uint32(COMPRESSED_R11_EAC); f <= COMPRESSED_SRGB8_ALPHA8_ETC2_EAC; f++ {
+				gs.compressedFormats[f] = true
+			}
+		}
+		if gs.HasExtension("GL_KHR_texture_compression_astc_ldr") {
+			for i := uint32(0); i < 14; i++ {
+				gs.compressedFormats[COMPRESSED_RGBA_ASTC_4x4_KHR+i] = true
+				gs.compressedFormats[COMPRESSED_SRGB8_ALPHA8_ASTC_4x4_KHR+i] = true
+			}
+		}
+		log.Debug("Compressed texture formats supported: %d", len(gs.compressedFormats))
+	}
+	return gs.compressedFormats[iformat]
+}
+
+// loadExtensions builds the cache of supported extension names
+func (gs *GLS) loadExtensions() {
+
+	if gs.extensions != nil {
+		return
+	}
+	gs.extensions = make(map[string]bool)
+	var count int32
+	gs.GetIntegerv(NUM_EXTENSIONS, &count)
+	for i := uint32(0); i < uint32(count); i++ {
+		gs.extensions[gs.GetStringi(EXTENSIONS, i)] = true
+	}
+}
@@ -47,6 +47,8 @@ type GLS struct {
 	polygonModeMode     uint32            // cached last set polygon mode mode
 	polygonOffsetFactor float32           // cached last set polygon offset factor
 	polygonOffsetUnits  float32           // cached last set polygon offset units
+	extensions          map[string]bool   // cached supported extensions
+	compressedFormats   map[uint32]bool   // cached supported compressed texture formats
 	gobuf               []byte            // conversion buffer with GO memory
 	cbuf                []byte            // conversion buffer with C memory
 }
@@ -178,6 +180,7 @@ func (gs *GLS) setDefaultState() {
 	gs.Enable(POLYGON_OFFSET_FILL)
 	gs.Enable(POLYGON_OFFSET_LINE)
 	gs.Enable(POLYGON_OFFSET_POINT)
+	gs.Enable(TEXTURE_CUBE_MAP_SEAMLESS)
 }
 
 // Stats copy the current values of the internal statistics structure
@@ -301,6 +304,19 @@ func (gs *GLS) CompileShader(shader uint32) {
 	C.glCompileShader(C.GLuint(shader))
 }
 
+// CompressedTexImage2D specifies a two-dimensional texture image in a compressed format.
+func (gs *GLS) CompressedTexImage2D(target uint32, level int32, iformat uint32, width int32, height int32, border int32, data []byte) {
+
+	C.glCompressedTexImage2D(C.GLenum(target),
+		C.GLint(level),
+		C.GLenum(iformat),
+		C.GLsizei(width),
+		C.GLsizei(height),
+		C.GLint(border),
+		C.GLsizei(len(data)),
+		ptr(data))
+}
+
 // CreateProgram creates an empty program object and returns
 // a non-zero value by which it can be referenced.
 func (gs *GLS) CreateProgram() uint32 {
@@ -487,6 +503,12 @@ func (gs *GLS) GetAttribLocation(program uint32, name string) int32 {
 	return int32(loc)
 }
 
+// GetIntegerv returns the value or values of the specified parameter.
+func (gs *GLS) GetIntegerv(pname uint32, params *int32) {
+
+	C.glGetIntegerv(C.GLenum(pname), (*C.GLint)(params))
+}
+
 // GetProgramiv returns the specified parameter from the specified program object.
 func (gs *GLS) GetProgramiv(program, pname uint32, params *int32) {
 
@@ -524,6 +546,14 @@ func (gs *GLS) GetString(name uint32) string {
 	return C.GoString((*C.char)(unsafe.Pointer(cs)))
 }
 
+// GetStringi returns the string at the specified index of the specified indexed
+// aspect of the current GL connection (such as EXTENSIONS).
+func (gs *GLS) GetStringi(name uint32, index uint32) string {
+
+	cs := C.glGetStringi(C.GLenum(name), C.GLuint(index))
+	return C.GoString((*C.char)(unsafe.Pointer(cs)))
+}
+
 // GetUniformLocation returns the location of a uniform variable for the specified program.
 func (gs *GLS) GetUniformLocation(program uint32, name string) int32 {
 
@@ -592,6 +622,12 @@ func (gs *GLS) TexParameteri(target uint32, pname uint32, param int32) {
 	C.glTexParameteri(C.GLenum(target), C.GLenum(pname), C.GLint(param))
 }
 
+// PixelStorei sets the specified pixel storage mode.
+func (gs *GLS) PixelStorei(pname uint32, param int32) {
+
+	C.glPixelStorei(C.GLenum(pname), C.GLint(param))
+}
+
 // PolygonMode controls the interpretation of polygons for rasterization.
 func (gs *GLS) PolygonMode(face, mode uint32) {
 
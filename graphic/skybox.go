@@ -14,14 +14,16 @@ import (
 )
 
 // Skybox is the Graphic that represents a skybox.
+// It is a cube rendered with a cube map texture which is always
+// centered on the camera and drawn behind every other object.
 type Skybox struct {
-	Graphic             // embedded graphic object
-	uniMVm  gls.Uniform // model view matrix uniform location cache
-	uniMVPm gls.Uniform // model view projection matrix uniform cache
-	uniNm   gls.Uniform // normal matrix uniform cache
+	Graphic                      // embedded graphic object
+	tex     *texture.TextureCube // cube map texture
+	uniMVPm gls.Uniform          // model view projection matrix uniform cache
 }
 
 // SkyboxData contains the data necessary to locate the textures for a Skybox in a concise manner.
+// The suffixes are for the faces in the order: +X, -X, +Y, -Y, +Z, -Z.
 type SkyboxData struct {
 	DirAndPrefix string
 	Extension    string
@@ -31,40 +33,55 @@ type SkyboxData struct {
 // NewSkybox creates and returns a pointer to a Skybox with the specified textures.
 func NewSkybox(data SkyboxData) (*Skybox, error) {
 
+	var files [6]string
+	for i := 0; i < 6; i++ {
+		files[i] = data.DirAndPrefix + data.Suffixes[i] + "." + data.Extension
+	}
+	tex, err := texture.NewTextureCube(files)
+	if err != nil {
+		return nil, err
+	}
+	return NewSkyboxFromCube(tex), nil
+}
+
+// NewSkyboxFromCube creates and returns a pointer to a Skybox with the specified
+// cube map texture. To also use the texture as environment map of materials
+// its reference count must be incremented with Incref.
+func NewSkyboxFromCube(tex *texture.TextureCube) *Skybox {
+
 	skybox := new(Skybox)
+	skybox.tex = tex
 
 	geom := geometry.NewCube(1)
 	skybox.Graphic.Init(geom, gls.TRIANGLES)
 	skybox.Graphic.SetCullable(false)
 
-	for i := 0; i < 6; i++ {
-		tex, err := texture.NewTexture2DFromImage(data.DirAndPrefix + data.Suffixes[i] + "." + data.Extension)
-		if err != nil {
-			return nil, err
-		}
-		matFace := material.NewStandard(math32.NewColor("white"))
-		matFace.AddTexture(tex)
-		matFace.SetSide(material.SideBack)
-		matFace.SetUseLights(material.UseLightNone)
-		matFace.SetEmissiveColor(&math32.Color{1, 1, 1})
-
-		// Disable writes to the depth buffer (call glDepthMask(GL_FALSE)).
-		// This will cause every other object to draw over the skybox, making it always appear behind everything else.
-		// It doesn't matter how small/big the skybox is as long as it's visible by the camera (within near/far planes).
-		matFace.SetDepthMask(false)
-
-		skybox.AddGroupMaterial(skybox, matFace, i)
-	}
+	mat := material.NewMaterial()
+	mat.SetShader("skybox")
+	mat.SetShaderUnique(true)
+	mat.SetEnvMap(tex)
+	mat.SetSide(material.SideBack)
+	mat.SetUseLights(material.UseLightNone)
+
+	// Disable writes to the depth buffer (call glDepthMask(GL_FALSE)).
+	// This will cause every other object to draw over the skybox, making it always appear behind everything else.
+	// The shader places the skybox at the far plane, so its size does not matter.
+	mat.SetDepthMask(false)
+	skybox.AddMaterial(skybox, mat, 0, 0)
 
 	// Creates uniforms
-	skybox.uniMVm.Init("ModelViewMatrix")
 	skybox.uniMVPm.Init("MVP")
-	skybox.uniNm.Init("NormalMatrix")
 
 	// The skybox should always be rendered first
 	skybox.SetRenderOrder(-100)
 
-	return skybox, nil
+	return skybox
+}
+
+// Texture returns the cube map texture of this skybox.
+func (skybox *Skybox) Texture() *texture.TextureCube {
+
+	return skybox.tex
 }
 
 // RenderSetup is called by the engine before drawing the skybox geometry
@@ -74,25 +91,14 @@ func (skybox *Skybox) RenderSetup(gs *gls.GLS, rinfo *core.RenderInfo) {
 
 	mvm := *skybox.ModelViewMatrix()
 
-	// Clear translation
+	// Clear translation so the skybox is always centered on the camera
 	mvm[12] = 0
 	mvm[13] = 0
 	mvm[14] = 0
-	// mvm.ExtractRotation(&rinfo.ViewMatrix) // TODO <- ExtractRotation does not work as expected?
-
-	// Transfer mvp uniform
-	location := skybox.uniMVm.Location(gs)
-	gs.UniformMatrix4fv(location, 1, false, &mvm[0])
 
 	// Calculates model view projection matrix and updates uniform
 	var mvpm math32.Matrix4
 	mvpm.MultiplyMatrices(&rinfo.ProjMatrix, &mvm)
-	location = skybox.uniMVPm.Location(gs)
+	location := skybox.uniMVPm.Location(gs)
 	gs.UniformMatrix4fv(location, 1, false, &mvpm[0])
-
-	// Calculates normal matrix and updates uniform
-	var nm math32.Matrix3
-	nm.GetNormalMatrix(&mvm)
-	location = skybox.uniNm.Location(gs)
-	gs.UniformMatrix3fv(location, 1, false, &nm[0])
 }
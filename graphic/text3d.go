@@ -0,0 +1,477 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package graphic
+
+import (
+	"strings"
+	"unsafe"
+
+	"github.com/sansebasko/engine/core"
+	"github.com/sansebasko/engine/geometry"
+	"github.com/sansebasko/engine/gls"
+	"github.com/sansebasko/engine/material"
+	"github.com/sansebasko/engine/math32"
+	"github.com/sansebasko/engine/text"
+	"github.com/sansebasko/engine/texture"
+	"golang.org/x/image/math/fixed"
+)
+
+// Text3D is a text in the 3D scene drawn from the signed distance field glyphs
+// of a font, so it keeps sharp outlines at any scale and angle.
+// The text lies in the XY plane of the node with its lines going down the Y axis.
+type Text3D struct {
+	Graphic                        // Embedded graphic
+	mat         *material.Material // text material
+	tex         *texture.Texture2D // font atlas texture
+	font        *text.Font         // font of the glyphs
+	text        string             // text being displayed
+	size        float32            // font size in world units
+	lineSpacing float32            // spacing between lines (in terms of font height)
+	align       TextAlign          // horizontal alignment
+	valign      TextVAlign         // vertical alignment
+	billboard   bool               // always faces the camera
+	width       float32            // width of the text in world units
+	height      float32            // height of the text in world units
+	min         math32.Vector2     // minimum corner of the text in the XY plane
+	max         math32.Vector2     // maximum corner of the text in the XY plane
+	uniMVPm     gls.Uniform        // model view projection matrix uniform location cache
+	uniText     gls.Uniform        // text parameters uniform location cache
+	udata       struct {           // Combined uniform data 3 * vec4
+		color        math32.Color4 // text color
+		outlineColor math32.Color4 // outline color
+		outlineWidth float32       // outline width in distance units
+		dummy        [3]float32    // complete 3 * vec4
+	}
+	outlineWidth float32 // outline width in world units
+}
+
+// TextAlign specifies the horizontal alignment of the lines of a Text3D
+// relative to the origin of its node.
+type TextAlign int
+
+// The horizontal alignments of Text3D
+const (
+	TextAlignLeft   = TextAlign(iota) // lines start at the origin
+	TextAlignCenter                   // lines are centered at the origin
+	TextAlignRight                    // lines end at the origin
+)
+
+// TextVAlign specifies the vertical alignment of a Text3D relative to the origin of its node.
+type TextVAlign int
+
+// The vertical alignments of Text3D
+const (
+	TextVAlignTop      = TextVAlign(iota) // the top of the first line is at the origin
+	TextVAlignMiddle                      // the text is vertically centered at the origin
+	TextVAlignBaseline                    // the baseline of the first line is at the origin
+	TextVAlignBottom                      // the bottom of the last line is at the origin
+)
+
+// sdfTexture is the texture of a signed distance field atlas shared by all texts
+type sdfTexture struct {
+	tex     *texture.Texture2D // texture with the atlas image
+	version int                // atlas version of the texture image
+}
+
+// sdfTextures maps the signed distance field atlases used by texts to their textures
+var sdfTextures = map[*text.Atlas]*sdfTexture{}
+
+// NewText3D creates and returns a pointer to a new text with the specified font,
+// text and font size in world units. The text is white, aligned to the left
+// and its first line baseline is at the origin.
+func NewText3D(font *text.Font, msg string, size float32) *Text3D {
+
+	t := new(Text3D)
+	t.font = font
+	t.text = msg
+	t.size = size
+	t.lineSpacing = 1
+	t.valign = TextVAlignBaseline
+	t.udata.color = math32.Color4{1, 1, 1, 1}
+	t.udata.outlineColor = math32.Color4{0, 0, 0, 1}
+
+	geom := geometry.NewGeometry()
+	geom.SetIndices(math32.NewArrayU32(0, 0))
+	geom.AddVBO(gls.NewVBO(math32.NewArrayF32(0, 0)).
+		AddAttrib(gls.VertexPosition).
+		AddAttrib(gls.VertexTexcoord),
+	)
+	t.Graphic.Init(geom, gls.TRIANGLES)
+
+	// The glyph quads are transparent and visible from both sides
+	t.mat = material.NewMaterial()
+	t.mat.SetShader("text_sdf")
+	t.mat.SetShaderUnique(true)
+	t.mat.SetTransparent(true)
+	t.mat.SetSide(material.SideDouble)
+	t.AddMaterial(t, t.mat, 0, 0)
+
+	t.uniMVPm.Init("MVP")
+	t.uniText.Init("Text")
+	t.update()
+	return t
+}
+
+// SetText sets the text
+func (t *Text3D) SetText(msg string) *Text3D {
+
+	t.text = msg
+	t.update()
+	return t
+}
+
+// Text returns the text
+func (t *Text3D) Text() string {
+
+	return t.text
+}
+
+// SetFont sets the font of the text
+func (t *Text3D) SetFont(font *text.Font) *Text3D {
+
+	t.font = font
+	t.update()
+	return t
+}
+
+// Font returns the font of the text
+func (t *Text3D) Font() *text.Font {
+
+	return t.font
+}
+
+// SetSize sets the font size in world units
+func (t *Text3D) SetSize(size float32) *Text3D {
+
+	t.size = size
+	t.update()
+	return t
+}
+
+// Size returns the font size in world units
+func (t *Text3D) Size() float32 {
+
+	return t.size
+}
+
+// SetLineSpacing sets the spacing between lines (in terms of font height)
+func (t *Text3D) SetLineSpacing(spacing float32) *Text3D {
+
+	t.lineSpacing = spacing
+	t.update()
+	return t
+}
+
+// LineSpacing returns the spacing between lines
+func (t *Text3D) LineSpacing() float32 {
+
+	return t.lineSpacing
+}
+
+// SetAlign sets the horizontal and vertical alignments of the text relative to its origin
+func (t *Text3D) SetAlign(align TextAlign, valign TextVAlign) *Text3D {
+
+	t.align = align
+	t.valign = valign
+	t.update()
+	return t
+}
+
+// Align returns the horizontal and vertical alignments of the text
+func (t *Text3D) Align() (TextAlign, TextVAlign) {
+
+	return t.align, t.valign
+}
+
+// SetColor sets the text color
+func (t *Text3D) SetColor(color *math32.Color4) *Text3D {
+
+	t.udata.color = *color
+	return t
+}
+
+// Color returns the text color
+func (t *Text3D) Color() math32.Color4 {
+
+	return t.udata.color
+}
+
+// SetOutline sets the width in world units and the color of the outline drawn
+// around the glyphs. The width is limited by the distances kept in the font atlas
+// to about an eighth of the font size. A zero width removes the outline.
+func (t *Text3D) SetOutline(width float32, color *math32.Color4) *Text3D {
+
+	t.outlineWidth = width
+	t.udata.outlineColor = *color
+	t.updateOutline()
+	return t
+}
+
+// Outline returns the width in world units and the color of the outline
+func (t *Text3D) Outline() (float32, math32.Color4) {
+
+	return t.outlineWidth, t.udata.outlineColor
+}
+
+// SetBillboard sets if the text always faces the camera, ignoring the rotation of its node.
+// Billboard texts are not frustum culled.
+func (t *Text3D) SetBillboard(state bool) *Text3D {
+
+	t.billboard = state
+	t.SetCullable(!state)
+	return t
+}
+
+// Billboard returns if the text always faces the camera
+func (t *Text3D) Billboard() bool {
+
+	return t.billboard
+}
+
+// Width returns the width of the text in world units
+func (t *Text3D) Width() float32 {
+
+	return t.width
+}
+
+// Height returns the height of the text in world units
+func (t *Text3D) Height() float32 {
+
+	return t.height
+}
+
+// Bounds returns the rectangle of the text in the XY plane of its node
+func (t *Text3D) Bounds() *math32.Box2 {
+
+	return math32.NewBox2(&t.min, &t.max)
+}
+
+// scale returns the world units of an atlas pixel
+func (t *Text3D) scale() float32 {
+
+	return t.size / text.SDFPointSize
+}
+
+// update lays out the glyphs of the text and rewrites their quads
+func (t *Text3D) update() {
+
+	atlas := t.font.SDFAtlas()
+	scale := t.scale()
+	lineHeight := float32(atlas.Ascent+atlas.Descent) * t.lineSpacing
+	lines := strings.Split(t.text, "\n")
+
+	// Measures the lines in atlas pixels
+	widths := make([]float32, len(lines))
+	for i, s := range lines {
+		prev := rune(-1)
+		var pen float32
+		for _, r := range s {
+			if prev >= 0 {
+				pen += fixedToFloat(atlas.Kern(prev, r))
+			}
+			pen += fixedToFloat(atlas.Glyph(r).Advance)
+			prev = r
+		}
+		widths[i] = pen
+	}
+	var maxWidth float32
+	for _, w := range widths {
+		maxWidth = math32.Max(maxWidth, w)
+	}
+	height := lineHeight*float32(len(lines)-1) + float32(atlas.Ascent+atlas.Descent)
+	t.width = maxWidth * scale
+	t.height = height * scale
+
+	// Vertical position of the origin from the top of the text
+	var top float32
+	switch t.valign {
+	case TextVAlignMiddle:
+		top = height / 2
+	case TextVAlignBaseline:
+		top = float32(atlas.Ascent)
+	case TextVAlignBottom:
+		top = height
+	}
+	t.min.Y = (top - height) * scale
+	t.max.Y = top * scale
+	t.min.X = math32.Infinity
+	t.max.X = -math32.Infinity
+
+	// Positions are in world units and texture coordinates in atlas pixels
+	geom := t.GetGeometry()
+	vbo := geom.VBO(gls.VertexPosition)
+	positions := (*vbo.Buffer())[:0]
+	indices := geom.Indices()[:0]
+	count := uint32(0)
+	for i, s := range lines {
+		var left float32
+		switch t.align {
+		case TextAlignCenter:
+			left = -widths[i] / 2
+		case TextAlignRight:
+			left = -widths[i]
+		}
+		t.min.X = math32.Min(t.min.X, left*scale)
+		t.max.X = math32.Max(t.max.X, (left+widths[i])*scale)
+		baseline := float32(atlas.Ascent) + lineHeight*float32(i) - top
+		prev := rune(-1)
+		pen := left
+		for _, r := range s {
+			if prev >= 0 {
+				pen += fixedToFloat(atlas.Kern(prev, r))
+			}
+			prev = r
+			g := atlas.Glyph(r)
+			x0 := pen + float32(g.OffsetX)
+			pen += fixedToFloat(g.Advance)
+			if g.Width == 0 {
+				continue
+			}
+			y0 := baseline + float32(g.OffsetY)
+			x1 := (x0 + float32(g.Width)) * scale
+			y1 := -(y0 + float32(g.Height)) * scale
+			x0 *= scale
+			y0 *= -scale
+			u0 := float32(g.X)
+			v0 := float32(g.Y)
+			u1 := u0 + float32(g.Width)
+			v1 := v0 + float32(g.Height)
+			positions.Append(
+				x0, y0, 0, u0, v0,
+				x0, y1, 0, u0, v1,
+				x1, y1, 0, u1, v1,
+				x1, y0, 0, u1, v0,
+			)
+			indices.Append(count, count+1, count+2, count, count+2, count+3)
+			count += 4
+		}
+	}
+	vbo.SetBuffer(positions)
+	geom.SetIndices(indices)
+	t.SetRenderable(count > 0)
+	t.setTexture(atlasTexture(atlas))
+	t.updateOutline()
+}
+
+// updateOutline converts the outline width to distance units of the atlas
+func (t *Text3D) updateOutline() {
+
+	spread := float32(t.font.SDFAtlas().Spread())
+	width := math32.Min(t.outlineWidth/t.scale(), spread)
+	t.udata.outlineWidth = width / (2 * spread)
+}
+
+// setTexture sets the font atlas texture of the text
+func (t *Text3D) setTexture(tex *texture.Texture2D) {
+
+	if tex == t.tex {
+		return
+	}
+	if t.tex != nil {
+		t.mat.RemoveTexture(t.tex)
+		t.tex.Dispose()
+	}
+	t.tex = tex.Incref()
+	t.mat.AddTexture(t.tex)
+}
+
+// modelViewMatrix sets the specified matrix with the model view matrix of
+// the text for the specified view matrix, removing its rotation if it is a billboard.
+func (t *Text3D) modelViewMatrix(view *math32.Matrix4, mvm *math32.Matrix4) {
+
+	mw := t.MatrixWorld()
+	mvm.MultiplyMatrices(view, &mw)
+	if !t.billboard {
+		return
+	}
+	var position math32.Vector3
+	var quaternion math32.Quaternion
+	var scale math32.Vector3
+	mvm.Decompose(&position, &quaternion, &scale)
+	quaternion.SetIdentity()
+	mvm.Compose(&position, &quaternion, &scale)
+}
+
+// RenderSetup is called by the engine before drawing the text
+func (t *Text3D) RenderSetup(gs *gls.GLS, rinfo *core.RenderInfo) {
+
+	var mvm, mvpm math32.Matrix4
+	t.modelViewMatrix(&rinfo.ViewMatrix, &mvm)
+	mvpm.MultiplyMatrices(&rinfo.ProjMatrix, &mvm)
+	location := t.uniMVPm.Location(gs)
+	gs.UniformMatrix4fv(location, 1, false, &mvpm[0])
+
+	location = t.uniText.Location(gs)
+	const vec4count = 3
+	gs.Uniform4fvUP(location, vec4count, unsafe.Pointer(&t.udata))
+}
+
+// Raycast checks intersections between the bounds of the text and the specified
+// raycaster and if any found appends it to the specified intersects array.
+func (t *Text3D) Raycast(rc *core.Raycaster, intersects *[]core.Intersect) {
+
+	if t.min.X > t.max.X {
+		return
+	}
+
+	// Copy and convert ray to camera coordinates
+	var ray math32.Ray
+	ray.Copy(&rc.Ray).ApplyMatrix4(&rc.ViewMatrix)
+
+	// Transforms the corners of the text bounds to camera coordinates
+	var mvm math32.Matrix4
+	t.modelViewMatrix(&rc.ViewMatrix, &mvm)
+	corners := [4]math32.Vector3{
+		{t.min.X, t.max.Y, 0},
+		{t.min.X, t.min.Y, 0},
+		{t.max.X, t.min.Y, 0},
+		{t.max.X, t.max.Y, 0},
+	}
+	for i := range corners {
+		corners[i].ApplyMatrix4(&mvm)
+	}
+	var point math32.Vector3
+	if !ray.IntersectTriangle(&corners[0], &corners[1], &corners[2], false, &point) &&
+		!ray.IntersectTriangle(&corners[0], &corners[2], &corners[3], false, &point) {
+		return
+	}
+
+	// Checks if distance is between the bounds of the raycaster
+	origin := ray.Origin()
+	distance := origin.DistanceTo(&point)
+	if distance < rc.Near || distance > rc.Far {
+		return
+	}
+	*intersects = append(*intersects, core.Intersect{
+		Distance: distance,
+		Point:    point,
+		Object:   t,
+	})
+}
+
+// atlasTexture returns the texture shared by all texts for the specified
+// font atlas, updating its image if glyphs were added to the atlas.
+func atlasTexture(atlas *text.Atlas) *texture.Texture2D {
+
+	st := sdfTextures[atlas]
+	if st == nil {
+		st = &sdfTexture{tex: texture.NewTexture2DFromRGBA(atlas.Image), version: atlas.Version()}
+		st.tex.SetMagFilter(gls.LINEAR)
+		st.tex.SetMinFilter(gls.LINEAR)
+		sdfTextures[atlas] = st
+		return st.tex
+	}
+	if st.version != atlas.Version() {
+		st.tex.SetFromRGBA(atlas.Image)
+		st.version = atlas.Version()
+	}
+	return st.tex
+}
+
+// fixedToFloat converts a 26.6 fixed point number to float32
+func fixedToFloat(v fixed.Int26_6) float32 {
+
+	return float32(v) / 64
+}
@@ -0,0 +1,511 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gui
+
+import (
+	"fmt"
+	"strings"
+)
+
+// The accessibility tree of a panel describes the widgets of its panel tree by their
+// roles, names, values and states, for screen readers and automated UI tests.
+// Panels without a role, such as plain container panels, are omitted from the tree
+// and their described descendants become children of their nearest described ancestor.
+// Widgets do not expose their internal panels; containers such as lists, trees, menus,
+// tab bars and windows expose their items and contents. Invisible panels are omitted.
+
+// AccessRole is the accessibility role of a panel, which describes its purpose
+type AccessRole int
+
+// The accessibility roles
+const (
+	RoleNone = AccessRole(iota) // no role: the panel is omitted from the accessibility tree
+	RoleGroup
+	RoleText
+	RoleImage
+	RoleButton
+	RoleToggleButton
+	RoleCheckBox
+	RoleRadioButton
+	RoleTextBox
+	RoleSlider
+	RoleSpinButton
+	RoleProgressBar
+	RoleScrollBar
+	RoleComboBox
+	RoleList
+	RoleListItem
+	RoleTree
+	RoleTreeItem
+	RoleTable
+	RoleMenuBar
+	RoleMenu
+	RoleMenuItem
+	RoleSeparator
+	RoleTabList
+	RoleTab
+	RoleWindow
+	RoleDialog
+)
+
+// roleNames contains the names of the accessibility roles
+var roleNames = [...]string{
+	"none", "group", "text", "image", "button", "togglebutton", "checkbox", "radiobutton",
+	"textbox", "slider", "spinbutton", "progressbar", "scrollbar", "combobox", "list",
+	"listitem", "tree", "treeitem", "table", "menubar", "menu", "menuitem", "separator",
+	"tablist", "tab", "window", "dialog",
+}
+
+// String returns the name of the role
+func (r AccessRole) String() string {
+
+	if r < 0 || int(r) >= len(roleNames) {
+		return fmt.Sprintf("role(%d)", int(r))
+	}
+	return roleNames[r]
+}
+
+// AccessState is a bit mask of accessibility states
+type AccessState int
+
+// The accessibility states
+const (
+	StateFocusable = AccessState(1 << iota) // receives the key focus by keyboard navigation
+	StateFocused                            // has the key focus
+	StateDisabled                           // does not respond to user input
+	StateChecked                            // check box, radio button or toggle button is checked
+	StateSelected                           // list item, tree item or tab is selected
+	StateExpanded                           // shows its sub items or content
+	StateCollapsed                          // hides its sub items or content
+	StateReadOnly                           // value cannot be edited
+	StateMultiLine                          // text box has multiple lines
+)
+
+// stateNames contains the names of the accessibility states in bit order
+var stateNames = [...]string{
+	"focusable", "focused", "disabled", "checked", "selected",
+	"expanded", "collapsed", "readonly", "multiline",
+}
+
+// String returns the names of the states of the mask separated by spaces
+func (s AccessState) String() string {
+
+	var names []string
+	for i, name := range stateNames {
+		if s&(1<<uint(i)) != 0 {
+			names = append(names, name)
+		}
+	}
+	return strings.Join(names, " ")
+}
+
+// AccessNode is a node of an accessibility tree
+type AccessNode struct {
+	Panel    IPanel        // described panel
+	Role     AccessRole    // role of the panel
+	Name     string        // accessible name, normally the text of the panel
+	Value    string        // current value of text boxes, range widgets and combo boxes
+	State    AccessState   // states of the panel
+	Children []*AccessNode // described children
+}
+
+// Accessible is the interface of the panels which complete the description
+// of their accessibility tree nodes, such as custom widgets.
+// The node contains the default description of the panel type when Access is called.
+type Accessible interface {
+	Access(node *AccessNode)
+}
+
+// AccessTree returns the accessibility tree of the specified panel.
+// The root node always describes the panel, even if it has no role.
+func AccessTree(ipan IPanel) *AccessNode {
+
+	node, children := accessDescribe(ipan)
+	node.Children = append(node.Children, accessNodes(children)...)
+	if a, ok := ipan.(Accessible); ok {
+		a.Access(node)
+	}
+	return node
+}
+
+// AccessTree returns the accessibility tree of the root panel
+func (r *Root) AccessTree() *AccessNode {
+
+	return AccessTree(r)
+}
+
+// Has returns if the node has all the specified states
+func (n *AccessNode) Has(state AccessState) bool {
+
+	return n.State&state == state
+}
+
+// Find returns the first node of the subtree of this node, in depth first order,
+// with the specified role and name, or nil if not found.
+// RoleNone matches any role and an empty name matches any name.
+func (n *AccessNode) Find(role AccessRole, name string) *AccessNode {
+
+	if (role == RoleNone || n.Role == role) && (name == "" || n.Name == name) {
+		return n
+	}
+	for _, child := range n.Children {
+		if found := child.Find(role, name); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+// FindAll returns the nodes of the subtree of this node with the specified role in depth first order
+func (n *AccessNode) FindAll(role AccessRole) []*AccessNode {
+
+	var nodes []*AccessNode
+	if n.Role == role {
+		nodes = append(nodes, n)
+	}
+	for _, child := range n.Children {
+		nodes = append(nodes, child.FindAll(role)...)
+	}
+	return nodes
+}
+
+// String returns a textual description of the subtree of this node
+// with one indented line per node, such as:
+//
+//	button "OK" [focusable focused]
+func (n *AccessNode) String() string {
+
+	var sb strings.Builder
+	n.write(&sb, 0)
+	return sb.String()
+}
+
+// write writes the description of the subtree of this node at the specified indentation level
+func (n *AccessNode) write(sb *strings.Builder, level int) {
+
+	sb.WriteString(strings.Repeat("  ", level))
+	sb.WriteString(n.Role.String())
+	if n.Name != "" {
+		fmt.Fprintf(sb, " %q", n.Name)
+	}
+	if n.Value != "" {
+		fmt.Fprintf(sb, " value=%q", n.Value)
+	}
+	if n.State != 0 {
+		fmt.Fprintf(sb, " [%s]", n.State)
+	}
+	sb.WriteByte('\n')
+	for _, child := range n.Children {
+		child.write(sb, level+1)
+	}
+}
+
+// accessNodes returns the nodes of the specified visible panels, replacing
+// the nodes of the panels without role by their children
+func accessNodes(panels []IPanel) []*AccessNode {
+
+	var nodes []*AccessNode
+	for _, ipan := range panels {
+		if ipan == nil || !ipan.GetPanel().Visible() {
+			continue
+		}
+		node := AccessTree(ipan)
+		if node.Role == RoleNone {
+			nodes = append(nodes, node.Children...)
+		} else {
+			nodes = append(nodes, node)
+		}
+	}
+	return nodes
+}
+
+// accessDescribe returns the node describing the specified panel, without its
+// children, and the panels whose nodes are its children
+func accessDescribe(ipan IPanel) (*AccessNode, []IPanel) {
+
+	p := ipan.GetPanel()
+	node := &AccessNode{Panel: ipan}
+	children := panelChildren(p)
+	expanded := func(state bool) {
+		if state {
+			node.State |= StateExpanded
+		} else {
+			node.State |= StateCollapsed
+		}
+	}
+	switch w := ipan.(type) {
+	case *Label:
+		node.Role = RoleText
+		node.Name = w.Text()
+		if w.isIcon() {
+			node.Role = RoleImage
+			node.Name = ""
+		}
+	case *ImageLabel:
+		node.Role = RoleText
+		node.Name = w.Text()
+		children = nil
+	case *Image:
+		node.Role = RoleImage
+	case *Button:
+		node.Role = RoleButton
+		if w.toggle {
+			node.Role = RoleToggleButton
+			if w.selected {
+				node.State |= StateChecked
+			}
+		}
+		node.Name = w.Label.Text()
+		children = nil
+	case *ImageButton:
+		node.Role = RoleButton
+		node.Name = accessText(w)
+		children = nil
+	case *CheckRadio:
+		node.Role = RoleCheckBox
+		if !w.check {
+			node.Role = RoleRadioButton
+		}
+		if w.Value() {
+			node.State |= StateChecked
+		}
+		node.Name = w.Label.Text()
+		children = nil
+	case *Edit:
+		node.Role = RoleTextBox
+		node.Name = w.PlaceHolder()
+		node.Value = w.Text()
+		children = nil
+	case *TextArea:
+		node.Role = RoleTextBox
+		node.Value = w.Text()
+		node.State |= StateMultiLine
+		if w.ReadOnly() {
+			node.State |= StateReadOnly
+		}
+		children = nil
+	case *Slider:
+		node.Role = RoleSlider
+		if w.label != nil {
+			node.Name = w.label.Text()
+		}
+		node.Value = fmt.Sprintf("%g", w.Value())
+		children = nil
+	case *Spinner:
+		node.Role = RoleSpinButton
+		node.Value = w.edit.Text()
+		children = nil
+	case *ProgressBar:
+		node.Role = RoleProgressBar
+		if !w.Indeterminate() {
+			node.Value = fmt.Sprintf("%g", w.Value())
+		}
+		children = nil
+	case *ScrollBar:
+		node.Role = RoleScrollBar
+		node.Value = fmt.Sprintf("%g", w.Value())
+		children = nil
+	case *DropDown:
+		node.Role = RoleComboBox
+		if w.selItem != nil {
+			node.Value = w.selItem.Text()
+		}
+		expanded(w.list.Visible())
+		children = []IPanel{w.list}
+	case *Tree:
+		node.Role = RoleTree
+		children = nil
+		node.Children = accessTreeItems(w.topItems())
+	case *TreeNode:
+		node.Role = RoleTreeItem
+		node.Name = w.label.Text()
+		expanded(w.expanded)
+		children = nil
+		if w.expanded {
+			node.Children = accessTreeItems(w.items)
+		}
+	case *List:
+		node.Role = RoleList
+		children = w.items
+	case *ListItem:
+		node.Role = RoleListItem
+		node.Name = accessText(w.item)
+		if w.selected {
+			node.State |= StateSelected
+		}
+		// Exposes the item only if it contains more than text
+		children = nil
+		if item := AccessTree(w.item); item.Role != RoleText && item.Role != RoleImage {
+			node.Children = accessNodes([]IPanel{w.item})
+		}
+	case *Table:
+		node.Role = RoleTable
+		node.Value = fmt.Sprintf("%d", w.RowCount())
+		children = nil
+	case *Menu:
+		node.Role = RoleMenu
+		if w.bar {
+			node.Role = RoleMenuBar
+		}
+		children = nil
+		for _, mi := range w.items {
+			children = append(children, mi)
+		}
+	case *MenuItem:
+		if w.label == nil {
+			node.Role = RoleSeparator
+			return node, nil
+		}
+		node.Role = RoleMenuItem
+		node.Name = w.label.Text()
+		if w.disabled {
+			node.State |= StateDisabled
+		}
+		children = nil
+		if w.submenu != nil {
+			expanded(w.submenu.Visible())
+			children = []IPanel{w.submenu}
+		}
+	case *TabBar:
+		node.Role = RoleTabList
+		children = nil
+		for _, tab := range w.tabs {
+			node.Children = append(node.Children, tab.accessNode())
+		}
+	case *Folder:
+		node.Role = RoleGroup
+		node.Name = w.label.Text()
+		expanded(w.contentPanel.GetPanel().Visible())
+		children = []IPanel{w.contentPanel}
+	case *MessageBox:
+		node.Role = RoleDialog
+		children = w.Window.accessChildren(node)
+	case *FileDialog:
+		node.Role = RoleDialog
+		children = w.Window.accessChildren(node)
+	case *Window:
+		node.Role = RoleWindow
+		children = w.accessChildren(node)
+	}
+	// Common states and overrides
+	if p.accessRole != RoleNone {
+		node.Role = p.accessRole
+	}
+	if p.accessName != "" {
+		node.Name = p.accessName
+	}
+	if p.focusable {
+		node.State |= StateFocusable
+	}
+	if r := p.root; r != nil && r.keyFocus != nil && r.keyFocus.GetPanel() == p {
+		node.State |= StateFocused
+	}
+	if !p.Enabled() {
+		node.State |= StateDisabled
+	}
+	return node, children
+}
+
+// accessChildren sets the name of the specified node to the title of
+// the window and returns the panels whose nodes are its children
+func (w *Window) accessChildren(node *AccessNode) []IPanel {
+
+	children := []IPanel{&w.client}
+	if w.title != nil && w.title.Visible() {
+		node.Name = w.title.label.Text()
+		if w.title.closeButtonVisible {
+			children = append(children, w.title.closeButton)
+		}
+	}
+	return children
+}
+
+// accessNode returns the accessibility node of the tab with the content of the selected tab as its child
+func (tab *Tab) accessNode() *AccessNode {
+
+	node := &AccessNode{Panel: &tab.header, Role: RoleTab, Name: tab.label.Text()}
+	if tab.header.accessName != "" {
+		node.Name = tab.header.accessName
+	}
+	if tab.selected {
+		node.State |= StateSelected
+		node.Children = accessNodes([]IPanel{tab.content})
+	}
+	if !tab.header.Enabled() {
+		node.State |= StateDisabled
+	}
+	return node
+}
+
+// accessTreeItems returns the tree item nodes of the specified tree items
+func accessTreeItems(items []IPanel) []*AccessNode {
+
+	var nodes []*AccessNode
+	for _, item := range items {
+		node := AccessTree(item)
+		if node.Role != RoleTreeItem {
+			node = &AccessNode{Panel: item, Role: RoleTreeItem, Name: accessText(item), State: node.State}
+		}
+		if litem, ok := item.GetPanel().Parent().(*ListItem); ok && litem.selected {
+			node.State |= StateSelected
+		}
+		nodes = append(nodes, node)
+	}
+	return nodes
+}
+
+// accessText returns the text of the visible labels of the specified panel and of its descendants
+func accessText(ipan IPanel) string {
+
+	var texts []string
+	var walk func(ipan IPanel)
+	walk = func(ipan IPanel) {
+		p := ipan.GetPanel()
+		if !p.Visible() {
+			return
+		}
+		if p.accessName != "" {
+			texts = append(texts, p.accessName)
+			return
+		}
+		switch w := ipan.(type) {
+		case *Label:
+			if text := w.Text(); text != "" && !w.isIcon() {
+				texts = append(texts, text)
+			}
+			return
+		case *ImageLabel:
+			if text := w.Text(); text != "" {
+				texts = append(texts, text)
+			}
+			return
+		}
+		for _, child := range p.Children() {
+			if ichild, ok := child.(IPanel); ok {
+				walk(ichild)
+			}
+		}
+	}
+	walk(ipan)
+	return strings.Join(texts, " ")
+}
+
+// isIcon returns if the label shows an icon of the icon font instead of text
+func (l *Label) isIcon() bool {
+
+	return l.font == StyleDefault().FontIcon
+}
+
+// panelChildren returns the child panels of the specified panel
+func panelChildren(p *Panel) []IPanel {
+
+	var children []IPanel
+	for _, child := range p.Children() {
+		if ichild, ok := child.(IPanel); ok {
+			children = append(children, ichild)
+		}
+	}
+	return children
+}
@@ -12,6 +12,7 @@ import (
 	"strconv"
 	"strings"
 
+	"github.com/sansebasko/engine/core"
 	"github.com/sansebasko/engine/gui/assets/icon"
 	"github.com/sansebasko/engine/math32"
 	"github.com/sansebasko/engine/window"
@@ -25,6 +26,11 @@ type Builder struct {
 	attribs  map[string]AttribCheckFunc // map of attribute name with check functions
 	layouts  map[string]IBuilderLayout  // map of layout type to layout builder
 	imgpath  string                     // base path for image panels files
+	model    *DataModel                 // data model for bindings
+	handlers map[string]core.Callback   // map of handler name to event handler
+	path     string                     // path of the last parsed description file
+	watch    *builderWatch              // state of the description file watch
+	built    map[string][]IPanel        // panels built while watching by top level name
 }
 
 // IBuilderLayout is the interface for all layout builders
@@ -52,6 +58,7 @@ const (
 	TypeCheckBox    = "checkbox"
 	TypeRadioButton = "radiobutton"
 	TypeEdit        = "edit"
+	TypeTextArea    = "textarea"
 	TypeVList       = "vlist"
 	TypeHList       = "hlist"
 	TypeDropDown    = "dropdown"
@@ -68,20 +75,32 @@ const (
 	TypeChart       = "chart"
 	TypeTable       = "table"
 	TypeTabBar      = "tabbar"
+	TypeSpinner     = "spinner"
+	TypeProgressBar = "progressbar"
+	TypeColorPicker = "colorpicker"
+	TypeMessageBox  = "messagebox"
+	TypeFileDialog  = "filedialog"
 	TypeHBoxLayout  = "hbox"
 	TypeVBoxLayout  = "vbox"
 	TypeGridLayout  = "grid"
 	TypeDockLayout  = "dock"
+	TypeFlexLayout  = "flex"
 )
 
 // Common attribute names
 const (
+	AttribAccessName     = "accessname"    // string
 	AttribAlignv         = "alignv"        // Align
 	AttribAlignh         = "alignh"        // Align
+	AttribAlignContent   = "aligncontent"  // FlexAlign FlexLayout
+	AttribAlignItems     = "alignitems"    // FlexAlign FlexLayout
+	AttribAlignSelf      = "alignself"     // FlexAlign FlexLayout
 	AttribAspectHeight   = "aspectheight"  // float32
 	AttribAspectWidth    = "aspectwidth"   // float32
+	AttribBasis          = "basis"         // FlexLength FlexLayout
 	AttribBgColor        = "bgcolor"       // Color4
 	AttribBorders        = "borders"       // RectBounds
+	AttribButtons        = "buttons"       // []string MessageBox
 	AttribBorderColor    = "bordercolor"   // Color4
 	AttribChecked        = "checked"       // bool
 	AttribColor          = "color"         // Color4
@@ -90,24 +109,32 @@ const (
 	AttribColumns        = "columns"       // []map[string]interface{} Table
 	AttribContent        = "content"       // map[string]interface{} Table
 	AttribCountStepx     = "countstepx"    // float32
+	AttribDialogMode     = "dialogmode"    // FileDialogMode FileDialog
+	AttribDir            = "dir"           // string FileDialog
+	AttribDirection      = "direction"     // FlexDirection FlexLayout
 	AttribEdge           = "edge"          // int
 	AttribEnabled        = "enabled"       // bool
 	AttribExpand         = "expand"        // float32
 	AttribExpandh        = "expandh"       // bool
 	AttribExpandv        = "expandv"       // bool
+	AttribFilters        = "filters"       // []string FileDialog
 	AttribFirstx         = "firstx"        // float32
+	AttribFocusable      = "focusable"     // bool
 	AttribFontColor      = "fontcolor"     // Color4
 	AttribFontDPI        = "fontdpi"       // float32
 	AttribFontSize       = "fontsize"      // float32
 	AttribFormat         = "format"        // string
 	AttribGroup          = "group"         // string
+	AttribGrow           = "grow"          // float32 FlexLayout
 	AttribHeader         = "header"        // string
 	AttribHeight         = "height"        // float32
 	AttribHidden         = "hidden"        // bool Table
 	AttribId             = "id"            // string
 	AttribIcon           = "icon"          // string
+	AttribIndeterminate  = "indeterminate" // bool ProgressBar
 	AttribImageFile      = "imagefile"     // string
 	AttribImageLabel     = "imagelabel"    // []map[string]interface{}
+	AttribJustify        = "justify"       // FlexAlign FlexLayout
 	AttribItems          = "items"         // []map[string]interface{}
 	AttribLayout         = "layout"        // map[string]interface{}
 	AttribLayoutParams   = "layoutparams"  // map[string]interface{}
@@ -115,14 +142,24 @@ const (
 	AttribLines          = "lines"         // int
 	AttribMargin         = "margin"        // float32
 	AttribMargins        = "margins"       // RectBounds
-	AttribMinwidth       = "minwidth"      // float32 Table
+	AttribMaxHeight      = "maxheight"     // FlexLength FlexLayout
+	AttribMaxWidth       = "maxwidth"      // FlexLength FlexLayout
+	AttribMinHeight      = "minheight"     // FlexLength FlexLayout
+	AttribMinwidth       = "minwidth"      // float32 Table, FlexLength FlexLayout
 	AttribAutoHeight     = "autoheight"    // bool
 	AttribAutoWidth      = "autowidth"     // bool
+	AttribMsgType        = "msgtype"       // MessageBoxType MessageBox
 	AttribName           = "name"          // string
+	AttribOnChange       = "onchange"      // string
+	AttribOnClick        = "onclick"       // string
+	AttribOrder          = "order"         // int FlexLayout
 	AttribPaddings       = "paddings"      // RectBounds
 	AttribPanel0         = "panel0"        // map[string]interface{}
 	AttribPanel1         = "panel1"        // map[string]interface{}
 	AttribParentInternal = "parent_"       // string (internal attribute)
+	AttribBindInternal   = "bind_"         // map[string]string (internal attribute)
+	AttribTrInternal     = "tr_"           // map[string]string (internal attribute)
+	AttribPickColor      = "pickcolor"     // Color4 ColorPicker
 	AttribPinned         = "pinned"        // bool
 	AttribPlaceHolder    = "placeholder"   // string
 	AttribPosition       = "position"      // []float32
@@ -130,24 +167,31 @@ const (
 	AttribRangeMin       = "rangemin"      // float32
 	AttribRangeMax       = "rangemax"      // float32
 	AttribRender         = "render"        // bool
+	AttribReorderable    = "reorderable"   // bool List, Tree, Table, TabBar
 	AttribResizeBorders  = "resizeborders" // Resizable
 	AttribResize         = "resize"        // bool Table
+	AttribRows           = "rows"          // []map[string]interface{} Table
 	AttribScaleFactor    = "scalefactor"   // float32
 	AttribScalex         = "scalex"        // map[string]interface{}
 	AttribScaley         = "scaley"        // map[string]interface{}
 	AttribShortcut       = "shortcut"      // []int
+	AttribShrink         = "shrink"        // float32 FlexLayout
 	AttribShowHeader     = "showheader"    // bool
 	AttribSortType       = "sorttype"      // TableSortType Table
 	AttribSpacing        = "spacing"       // float32
 	AttribSplit          = "split"         // float32
+	AttribStep           = "step"          // float32 Spinner
 	AttribStepx          = "stepx"         // float32
 	AttribText           = "text"          // string
+	AttribTabIndex       = "tabindex"      // int
 	AttribTitle          = "title"         // string
+	AttribTooltip        = "tooltip"       // string
 	AttribType           = "type"          // string
 	AttribUserData       = "userdata"      // interface{}
 	AttribWidth          = "width"         // float32
 	AttribValue          = "value"         // float32
 	AttribVisible        = "visible"       // bool
+	AttribWrap           = "wrap"          // FlexWrapMode FlexLayout
 )
 
 // maps align name with align parameter
@@ -177,6 +221,33 @@ var mapEdgeName = map[string]Edge{
 	"center": DockCenter,
 }
 
+// maps flex direction name (flex layout) with direction
+var mapFlexDirection = map[string]FlexDirection{
+	"row":            FlexRow,
+	"row-reverse":    FlexRowReverse,
+	"column":         FlexColumn,
+	"column-reverse": FlexColumnReverse,
+}
+
+// maps flex wrap mode name (flex layout) with wrap mode
+var mapFlexWrap = map[string]FlexWrapMode{
+	"nowrap":       FlexNoWrap,
+	"wrap":         FlexWrap,
+	"wrap-reverse": FlexWrapReverse,
+}
+
+// maps flex align name (flex layout) with alignment
+var mapFlexAlign = map[string]FlexAlign{
+	"auto":          FlexAlignAuto,
+	"start":         FlexAlignStart,
+	"end":           FlexAlignEnd,
+	"center":        FlexAlignCenter,
+	"stretch":       FlexAlignStretch,
+	"space-between": FlexAlignSpaceBetween,
+	"space-around":  FlexAlignSpaceAround,
+	"space-evenly":  FlexAlignSpaceEvenly,
+}
+
 // maps table sort type to value
 var mapTableSortType = map[string]TableSortType{
 	"none":   TableSortNone,
@@ -184,6 +255,21 @@ var mapTableSortType = map[string]TableSortType{
 	"number": TableSortNumber,
 }
 
+// maps message box type name to value
+var mapMessageBoxType = map[string]MessageBoxType{
+	"info":     MessageBoxInfo,
+	"warning":  MessageBoxWarning,
+	"error":    MessageBoxError,
+	"question": MessageBoxQuestion,
+}
+
+// maps file dialog mode name to value
+var mapFileDialogMode = map[string]FileDialogMode{
+	"open": FileDialogOpen,
+	"save": FileDialogSave,
+	"dir":  FileDialogDir,
+}
+
 // NewBuilder creates and returns a pointer to a new gui Builder object
 func NewBuilder() *Builder {
 
@@ -196,6 +282,7 @@ func NewBuilder() *Builder {
 		TypeImageLabel:  buildImageLabel,
 		TypeButton:      buildButton,
 		TypeEdit:        buildEdit,
+		TypeTextArea:    buildTextArea,
 		TypeCheckBox:    buildCheckBox,
 		TypeRadioButton: buildRadioButton,
 		TypeVList:       buildVList,
@@ -212,6 +299,11 @@ func NewBuilder() *Builder {
 		TypeChart:       buildChart,
 		TypeTable:       buildTable,
 		TypeTabBar:      buildTabBar,
+		TypeSpinner:     buildSpinner,
+		TypeProgressBar: buildProgressBar,
+		TypeColorPicker: buildColorPicker,
+		TypeMessageBox:  buildMessageBox,
+		TypeFileDialog:  buildFileDialog,
 	}
 	// Sets map of layout type name to layout function
 	b.layouts = map[string]IBuilderLayout{
@@ -219,15 +311,22 @@ func NewBuilder() *Builder {
 		TypeVBoxLayout: &BuilderLayoutVBox{},
 		TypeGridLayout: &BuilderLayoutGrid{},
 		TypeDockLayout: &BuilderLayoutDock{},
+		TypeFlexLayout: &BuilderLayoutFlex{},
 	}
 	// Sets map of attribute name to check function
 	b.attribs = map[string]AttribCheckFunc{
+		AttribAccessName:    AttribCheckString,
 		AttribAlignv:        AttribCheckAlign,
+		AttribAlignContent:  AttribCheckFlexAlign,
+		AttribAlignItems:    AttribCheckFlexAlign,
+		AttribAlignSelf:     AttribCheckFlexAlign,
+		AttribBasis:         AttribCheckFlexLength,
 		AttribAlignh:        AttribCheckAlign,
 		AttribAspectWidth:   AttribCheckFloat,
 		AttribAspectHeight:  AttribCheckFloat,
 		AttribHeight:        AttribCheckFloat,
 		AttribBgColor:       AttribCheckColor,
+		AttribButtons:       AttribCheckStringList,
 		AttribBorders:       AttribCheckBorderSizes,
 		AttribBorderColor:   AttribCheckColor,
 		AttribChecked:       AttribCheckBool,
@@ -237,23 +336,31 @@ func NewBuilder() *Builder {
 		AttribColumns:       AttribCheckListMap,
 		AttribContent:       AttribCheckMap,
 		AttribCountStepx:    AttribCheckFloat,
+		AttribDialogMode:    AttribCheckFileDialogMode,
+		AttribDir:           AttribCheckString,
+		AttribDirection:     AttribCheckFlexDirection,
 		AttribEdge:          AttribCheckEdge,
 		AttribEnabled:       AttribCheckBool,
 		AttribExpand:        AttribCheckFloat,
 		AttribExpandh:       AttribCheckBool,
 		AttribExpandv:       AttribCheckBool,
+		AttribFilters:       AttribCheckStringList,
 		AttribFirstx:        AttribCheckFloat,
+		AttribFocusable:     AttribCheckBool,
 		AttribFontColor:     AttribCheckColor,
 		AttribFontDPI:       AttribCheckFloat,
 		AttribFontSize:      AttribCheckFloat,
 		AttribFormat:        AttribCheckString,
 		AttribGroup:         AttribCheckString,
+		AttribGrow:          AttribCheckFloat,
 		AttribHeader:        AttribCheckString,
 		AttribHidden:        AttribCheckBool,
 		AttribIcon:          AttribCheckIcons,
 		AttribId:            AttribCheckString,
+		AttribIndeterminate: AttribCheckBool,
 		AttribImageFile:     AttribCheckString,
 		AttribImageLabel:    AttribCheckMap,
+		AttribJustify:       AttribCheckFlexAlign,
 		AttribItems:         AttribCheckListMap,
 		AttribLayout:        AttribCheckLayout,
 		AttribLayoutParams:  AttribCheckMap,
@@ -261,13 +368,21 @@ func NewBuilder() *Builder {
 		AttribLines:         AttribCheckInt,
 		AttribMargin:        AttribCheckFloat,
 		AttribMargins:       AttribCheckBorderSizes,
-		AttribMinwidth:      AttribCheckFloat,
+		AttribMaxHeight:     AttribCheckFlexLength,
+		AttribMaxWidth:      AttribCheckFlexLength,
+		AttribMinHeight:     AttribCheckFlexLength,
+		AttribMinwidth:      AttribCheckFlexLength,
 		AttribAutoHeight:    AttribCheckBool,
 		AttribAutoWidth:     AttribCheckBool,
+		AttribMsgType:       AttribCheckMessageBoxType,
 		AttribName:          AttribCheckString,
+		AttribOnChange:      AttribCheckString,
+		AttribOnClick:       AttribCheckString,
+		AttribOrder:         AttribCheckInt,
 		AttribPaddings:      AttribCheckBorderSizes,
 		AttribPanel0:        AttribCheckMap,
 		AttribPanel1:        AttribCheckMap,
+		AttribPickColor:     AttribCheckColor,
 		AttribPinned:        AttribCheckBool,
 		AttribPlaceHolder:   AttribCheckString,
 		AttribPosition:      AttribCheckPosition,
@@ -275,23 +390,30 @@ func NewBuilder() *Builder {
 		AttribRangeMin:      AttribCheckFloat,
 		AttribRangeMax:      AttribCheckFloat,
 		AttribRender:        AttribCheckBool,
+		AttribReorderable:   AttribCheckBool,
 		AttribResizeBorders: AttribCheckResizeBorders,
 		AttribResize:        AttribCheckBool,
+		AttribRows:          AttribCheckListMap,
 		AttribScaleFactor:   AttribCheckFloat,
 		AttribScalex:        AttribCheckMap,
 		AttribScaley:        AttribCheckMap,
 		AttribShortcut:      AttribCheckMenuShortcut,
+		AttribShrink:        AttribCheckFloat,
 		AttribShowHeader:    AttribCheckBool,
 		AttribSortType:      AttribCheckTableSortType,
 		AttribSpacing:       AttribCheckFloat,
 		AttribSplit:         AttribCheckFloat,
+		AttribStep:          AttribCheckFloat,
 		AttribStepx:         AttribCheckFloat,
 		AttribText:          AttribCheckString,
+		AttribTabIndex:      AttribCheckInt,
 		AttribTitle:         AttribCheckString,
+		AttribTooltip:       AttribCheckString,
 		AttribType:          AttribCheckStringLower,
 		AttribUserData:      AttribCheckInterface,
 		AttribValue:         AttribCheckFloat,
 		AttribVisible:       AttribCheckBool,
+		AttribWrap:          AttribCheckFlexWrap,
 		AttribWidth:         AttribCheckFloat,
 	}
 	return b
@@ -370,6 +492,26 @@ func (b *Builder) ParseString(desc string) error {
 					if !ok {
 						return nil, fmt.Errorf("Invalid attribute:%s", ks)
 					}
+					// Saves data binding instead of the attribute value
+					if path, ok := bindingPath(vi); ok {
+						bindings, _ := ms[AttribBindInternal].(map[string]string)
+						if bindings == nil {
+							bindings = make(map[string]string)
+							ms[AttribBindInternal] = bindings
+						}
+						bindings[ks] = path
+						delete(ms, ks)
+						continue
+					}
+					// Saves the message key of localized attributes
+					if key, ok := localizeKey(vi); ok {
+						keys, _ := ms[AttribTrInternal].(map[string]string)
+						if keys == nil {
+							keys = make(map[string]string)
+							ms[AttribTrInternal] = keys
+						}
+						keys[ks] = key
+					}
 					// Checks attribute
 					err = acf(b, ms, ks)
 					if err != nil {
@@ -415,7 +557,12 @@ func (b *Builder) ParseFile(filepath string) error {
 	}
 
 	// Parses file data
-	return b.ParseString(string(data))
+	err = b.ParseString(string(data))
+	if err != nil {
+		return err
+	}
+	b.path = filepath
+	return nil
 }
 
 // Names returns a sorted list of names of top level previously parsed objects.
@@ -443,8 +590,24 @@ func (b *Builder) Names() []string {
 // from a previously parsed description
 // If the descriptions contains a single object with no name,
 // It should be specified the empty string to build this object.
+// If the builder is watching its description file, the built panel
+// is rebuilt in place when its description changes.
 func (b *Builder) Build(name string) (IPanel, error) {
 
+	pan, err := b.buildName(name)
+	if err != nil {
+		return nil, err
+	}
+	// Saves the panel to be rebuilt when the description file changes
+	if b.watch != nil {
+		b.built[name] = append(b.built[name], pan)
+	}
+	return pan, nil
+}
+
+// buildName builds the top level object with the specified name
+func (b *Builder) buildName(name string) (IPanel, error) {
+
 	// Only one object
 	if name == "" {
 		return b.build(b.am, nil)
@@ -502,11 +665,17 @@ func (b *Builder) build(am map[string]interface{}, iparent IPanel) (IPanel, erro
 		return nil, fmt.Errorf("Invalid type:%v", typename)
 	}
 
-	// Builds panel
+	// Translates localized attributes and builds panel
+	translate(am)
 	pan, err := builder(b, am)
 	if err != nil {
 		return nil, err
 	}
+	// Sets event handlers and data bindings
+	err = b.bind(am, pan)
+	if err != nil {
+		return nil, err
+	}
 	// Adds built panel to parent
 	if iparent != nil {
 		iparent.GetPanel().Add(pan)
@@ -579,6 +748,22 @@ func (b *Builder) SetAttribs(am map[string]interface{}, ipan IPanel) error {
 		panel.SetUserData(am[AttribUserData])
 	}
 
+	if am[AttribTooltip] != nil {
+		panel.SetTooltip(am[AttribTooltip].(string))
+	}
+
+	if am[AttribFocusable] != nil {
+		panel.SetFocusable(am[AttribFocusable].(bool))
+	}
+
+	if am[AttribTabIndex] != nil {
+		panel.SetTabIndex(am[AttribTabIndex].(int))
+	}
+
+	if am[AttribAccessName] != nil {
+		panel.SetAccessName(am[AttribAccessName].(string))
+	}
+
 	// Sets optional layout (must pass IPanel not *Panel)
 	err := b.setLayout(am, ipan)
 	if err != nil {
@@ -679,6 +864,46 @@ func AttribCheckTableSortType(b *Builder, am map[string]interface{}, fname strin
 	return nil
 }
 
+// AttribCheckMessageBoxType checks and converts attribute message box type
+func AttribCheckMessageBoxType(b *Builder, am map[string]interface{}, fname string) error {
+
+	// If attribute not found, ignore
+	v := am[fname]
+	if v == nil {
+		return nil
+	}
+	vs, ok := v.(string)
+	if !ok {
+		return b.err(am, fname, "Invalid attribute")
+	}
+	mtype, ok := mapMessageBoxType[strings.ToLower(vs)]
+	if !ok {
+		return b.err(am, fname, "Invalid message box type")
+	}
+	am[fname] = mtype
+	return nil
+}
+
+// AttribCheckFileDialogMode checks and converts attribute file dialog mode
+func AttribCheckFileDialogMode(b *Builder, am map[string]interface{}, fname string) error {
+
+	// If attribute not found, ignore
+	v := am[fname]
+	if v == nil {
+		return nil
+	}
+	vs, ok := v.(string)
+	if !ok {
+		return b.err(am, fname, "Invalid attribute")
+	}
+	mode, ok := mapFileDialogMode[strings.ToLower(vs)]
+	if !ok {
+		return b.err(am, fname, "Invalid file dialog mode")
+	}
+	am[fname] = mode
+	return nil
+}
+
 // AttribCheckResizeBorders checks and converts attribute with list of window resizable borders
 func AttribCheckResizeBorders(b *Builder, am map[string]interface{}, fname string) error {
 
@@ -763,6 +988,99 @@ func AttribCheckAlign(b *Builder, am map[string]interface{}, fname string) error
 	return nil
 }
 
+// AttribCheckFlexDirection checks and converts attribute with name of flex layout direction
+func AttribCheckFlexDirection(b *Builder, am map[string]interface{}, fname string) error {
+
+	v := am[fname]
+	if v == nil {
+		return nil
+	}
+	vs, ok := v.(string)
+	if !ok {
+		return b.err(am, fname, "Invalid flex direction")
+	}
+	direction, ok := mapFlexDirection[strings.ToLower(vs)]
+	if !ok {
+		return b.err(am, fname, "Invalid flex direction")
+	}
+	am[fname] = direction
+	return nil
+}
+
+// AttribCheckFlexWrap checks and converts attribute with name of flex layout
+// wrap mode or bool which is true for wrapping
+func AttribCheckFlexWrap(b *Builder, am map[string]interface{}, fname string) error {
+
+	v := am[fname]
+	if v == nil {
+		return nil
+	}
+	switch vt := v.(type) {
+	case bool:
+		if vt {
+			am[fname] = FlexWrap
+		} else {
+			am[fname] = FlexNoWrap
+		}
+		return nil
+	case string:
+		if wrap, ok := mapFlexWrap[strings.ToLower(vt)]; ok {
+			am[fname] = wrap
+			return nil
+		}
+	}
+	return b.err(am, fname, "Invalid flex wrap mode")
+}
+
+// AttribCheckFlexAlign checks and converts attribute with name of flex layout alignment
+func AttribCheckFlexAlign(b *Builder, am map[string]interface{}, fname string) error {
+
+	v := am[fname]
+	if v == nil {
+		return nil
+	}
+	vs, ok := v.(string)
+	if !ok {
+		return b.err(am, fname, "Invalid flex alignment")
+	}
+	align, ok := mapFlexAlign[strings.ToLower(vs)]
+	if !ok {
+		return b.err(am, fname, "Invalid flex alignment")
+	}
+	am[fname] = align
+	return nil
+}
+
+// AttribCheckFlexLength checks and converts attribute with size in pixels to float32
+// and attribute with percentage or automatic size, such as "50%" or "auto", to FlexLength
+func AttribCheckFlexLength(b *Builder, am map[string]interface{}, fname string) error {
+
+	v := am[fname]
+	if v == nil {
+		return nil
+	}
+	switch vt := v.(type) {
+	case int:
+		am[fname] = float32(vt)
+		return nil
+	case float64:
+		am[fname] = float32(vt)
+		return nil
+	case string:
+		l, err := ParseFlexLength(vt)
+		if err != nil {
+			return b.err(am, fname, err.Error())
+		}
+		if l.Unit == FlexPixels {
+			am[fname] = l.Value
+		} else {
+			am[fname] = l
+		}
+		return nil
+	}
+	return b.err(am, fname, fmt.Sprintf("Invalid flex length:%T", v))
+}
+
 // AttribCheckMenuShortcut checks and converts attribute describing menu shortcut key
 func AttribCheckMenuShortcut(b *Builder, am map[string]interface{}, fname string) error {
 
@@ -834,6 +1152,34 @@ func AttribCheckListMap(b *Builder, am map[string]interface{}, fname string) err
 	return nil
 }
 
+// AttribCheckStringList checks and converts attribute to []string.
+// A single string is converted to a list with one string.
+func AttribCheckStringList(b *Builder, am map[string]interface{}, fname string) error {
+
+	v := am[fname]
+	if v == nil {
+		return nil
+	}
+	if vs, ok := v.(string); ok {
+		am[fname] = []string{vs}
+		return nil
+	}
+	li, ok := v.([]interface{})
+	if !ok {
+		return b.err(am, fname, "Not a list")
+	}
+	ls := make([]string, 0, len(li))
+	for i := 0; i < len(li); i++ {
+		vs, ok := li[i].(string)
+		if !ok {
+			return b.err(am, fname, "Item is not a string")
+		}
+		ls = append(ls, vs)
+	}
+	am[fname] = ls
+	return nil
+}
+
 // AttribCheckMap checks and converts attribute to map[string]interface{}
 func AttribCheckMap(b *Builder, am map[string]interface{}, fname string) error {
 
@@ -875,7 +1221,7 @@ func AttribCheckIcons(b *Builder, am map[string]interface{}, fname string) error
 		if err != nil {
 			return b.err(am, fname, fmt.Sprintf("Invalid icon codepoint value/name:%v", parts[i]))
 		}
-		text += string(val)
+		text += string(rune(val))
 	}
 	am[fname] = text
 	return nil
@@ -0,0 +1,391 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gui
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"github.com/sansebasko/engine/core"
+	"github.com/sansebasko/engine/util/i18n"
+)
+
+// Attribute values in the form "{{path}}" bind the attribute to the value
+// at the specified path of the builder data model instead of setting it.
+// The attributes which can be bound depend on the type of the panel:
+//
+//	text:          Label, Button, CheckBox, RadioButton, Slider, ImageLabel
+//	               and MessageBox (model to panel)
+//	               Edit and TextArea (both directions)
+//	value:         Slider and Spinner (float), CheckBox and RadioButton (bool),
+//	               DropDown (selected item position) (both directions)
+//	               ProgressBar (float, model to panel)
+//	checked:       CheckBox and RadioButton (both directions)
+//	indeterminate: ProgressBar (model to panel)
+//	pickcolor:     ColorPicker ("#rrggbb[aa]" string, both directions)
+//	rows:          Table (slice of maps or structs, model to panel)
+//	visible, enabled, tooltip: any panel (model to panel)
+//
+// Event attributes such as "onclick: save" subscribe the handler registered
+// in the builder with the specified name to the corresponding panel event.
+//
+// Attribute values in the form "tr:key" are set to the message with the specified
+// key translated to the current locale and are localized if possible (see Localize).
+
+// bindingRegexp matches attribute values which are data bindings
+var bindingRegexp = regexp.MustCompile(`^\{\{\s*([\w.]*)\s*\}\}$`)
+
+// maps event attribute name to the event subscribed
+var mapEventAttrib = map[string]string{
+	AttribOnClick:  OnClick,
+	AttribOnChange: OnChange,
+}
+
+// modelBinding binds an attribute of a panel to a data model value
+type modelBinding struct {
+	model    *DataModel        // bound data model
+	path     string            // path of the bound value
+	apply    func(interface{}) // sets the model value to the panel
+	updating bool              // the model or the panel is being updated by this binding
+}
+
+// SetModel sets the data model used by the bindings of the next built panels
+func (b *Builder) SetModel(m *DataModel) {
+
+	b.model = m
+}
+
+// Model returns the current data model
+func (b *Builder) Model() *DataModel {
+
+	return b.model
+}
+
+// AddHandler adds an event handler which can be referenced by name by the event
+// attributes of the description (for example "onclick: save").
+// If the handler name already exists it is replaced.
+func (b *Builder) AddHandler(name string, cb core.Callback) {
+
+	if b.handlers == nil {
+		b.handlers = make(map[string]core.Callback)
+	}
+	b.handlers[name] = cb
+}
+
+// Unbind removes the data model subscriptions of the bindings of the specified panel
+// and stops the localization of its attributes and of the attributes of its descendants.
+// It should be called for the built panels which are disposed before the model.
+func (b *Builder) Unbind(ipan IPanel) {
+
+	if b.model != nil {
+		unsubscribeModel(b.model, ipan)
+	}
+	Unlocalize(ipan)
+}
+
+// unsubscribeModel removes the data model subscriptions of the
+// specified panel and of its descendants
+func unsubscribeModel(model *DataModel, ipan IPanel) {
+
+	model.UnsubscribeAllID(ipan)
+	for _, child := range ipan.GetPanel().Children() {
+		if ichild, ok := child.(IPanel); ok {
+			unsubscribeModel(model, ichild)
+		}
+	}
+}
+
+// bindingPath returns the model path of the specified attribute value if it is a binding
+func bindingPath(v interface{}) (string, bool) {
+
+	s, ok := v.(string)
+	if !ok {
+		return "", false
+	}
+	parts := bindingRegexp.FindStringSubmatch(s)
+	if parts == nil {
+		return "", false
+	}
+	return parts[1], true
+}
+
+// bind sets the event handlers and data bindings of the specified panel from its description
+func (b *Builder) bind(am map[string]interface{}, ipan IPanel) error {
+
+	// Subscribe event handlers
+	for attrib, evname := range mapEventAttrib {
+		v := am[attrib]
+		if v == nil {
+			continue
+		}
+		name, ok := v.(string)
+		if !ok {
+			return b.err(am, attrib, "Handler name must be a string")
+		}
+		cb := b.handlers[name]
+		if cb == nil {
+			return b.err(am, attrib, "Handler not found:"+name)
+		}
+		ipan.GetPanel().Subscribe(evname, cb)
+	}
+
+	// Localizes attributes
+	localizeAttribs(am, ipan)
+
+	// Sets data bindings
+	bi := am[AttribBindInternal]
+	if bi == nil {
+		return nil
+	}
+	if b.model == nil {
+		return b.err(am, AttribBindInternal, "No data model for bindings")
+	}
+	for attrib, path := range bi.(map[string]string) {
+		err := b.bindAttrib(am, ipan, attrib, path)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// localizeAttribs localizes the attributes of the specified panel
+// whose description values are message keys, if possible
+func localizeAttribs(am map[string]interface{}, ipan IPanel) {
+
+	keys, _ := am[AttribTrInternal].(map[string]string)
+	for attrib, key := range keys {
+		if localizeSetter(ipan, attrib) != nil {
+			Localize(ipan, attrib, key)
+		}
+	}
+}
+
+// translate sets the attributes of the specified description and of its
+// nested descriptions whose values are message keys to their messages
+func translate(v interface{}) {
+
+	switch vt := v.(type) {
+	case map[string]interface{}:
+		for k, vi := range vt {
+			if k != AttribParentInternal {
+				translate(vi)
+			}
+		}
+		keys, _ := vt[AttribTrInternal].(map[string]string)
+		for attrib, key := range keys {
+			vt[attrib] = i18n.Tr(key)
+		}
+	case []map[string]interface{}:
+		for _, item := range vt {
+			translate(item)
+		}
+	case []interface{}:
+		for _, item := range vt {
+			translate(item)
+		}
+	}
+}
+
+// bindAttrib binds the specified attribute of the panel to the model value at the specified path
+func (b *Builder) bindAttrib(am map[string]interface{}, ipan IPanel, attrib, path string) error {
+
+	bd := &modelBinding{model: b.model, path: path}
+	var read func() interface{} // returns the panel value for two way bindings
+
+	switch attrib {
+	case AttribText:
+		switch p := ipan.(type) {
+		case *Edit:
+			bd.apply = func(v interface{}) { p.SetText(modelString(v)) }
+			read = func() interface{} { return p.Text() }
+		case *TextArea:
+			bd.apply = func(v interface{}) { p.SetText(modelString(v)) }
+			read = func() interface{} { return p.Text() }
+		case *Label:
+			bd.apply = func(v interface{}) { p.SetText(modelString(v)) }
+		case *Button:
+			bd.apply = func(v interface{}) { p.Label.SetText(modelString(v)) }
+		case *CheckRadio:
+			bd.apply = func(v interface{}) { p.Label.SetText(modelString(v)) }
+		case *Slider:
+			bd.apply = func(v interface{}) { p.SetText(modelString(v)) }
+		case *ImageLabel:
+			bd.apply = func(v interface{}) { p.SetText(modelString(v)) }
+		case *MessageBox:
+			bd.apply = func(v interface{}) { p.SetMessage(modelString(v)) }
+		}
+	case AttribValue:
+		switch p := ipan.(type) {
+		case *Spinner:
+			bd.apply = func(v interface{}) {
+				f, _ := modelFloat(v)
+				p.SetValue(float32(f))
+			}
+			read = func() interface{} { return p.Value() }
+		case *ProgressBar:
+			bd.apply = func(v interface{}) {
+				f, _ := modelFloat(v)
+				p.SetValue(float32(f))
+			}
+		case *Slider:
+			bd.apply = func(v interface{}) {
+				f, _ := modelFloat(v)
+				p.SetValue(float32(f))
+			}
+			read = func() interface{} { return p.Value() }
+		case *CheckRadio:
+			bd.apply = func(v interface{}) {
+				state, _ := modelBool(v)
+				p.SetValue(state)
+			}
+			read = func() interface{} { return p.Value() }
+		case *DropDown:
+			bd.apply = func(v interface{}) {
+				f, _ := modelFloat(v)
+				if pos := int(f); pos >= 0 && pos < p.Len() && pos != p.SelectedPos() {
+					p.SelectPos(pos)
+				}
+			}
+			read = func() interface{} { return p.SelectedPos() }
+		}
+	case AttribChecked:
+		if p, ok := ipan.(*CheckRadio); ok {
+			bd.apply = func(v interface{}) {
+				state, _ := modelBool(v)
+				p.SetValue(state)
+			}
+			read = func() interface{} { return p.Value() }
+		}
+	case AttribIndeterminate:
+		if p, ok := ipan.(*ProgressBar); ok {
+			bd.apply = func(v interface{}) {
+				state, _ := modelBool(v)
+				p.SetIndeterminate(state)
+			}
+		}
+	case AttribPickColor:
+		if p, ok := ipan.(*ColorPicker); ok {
+			bd.apply = func(v interface{}) {
+				if c, ok := parseHexColor(modelString(v)); ok {
+					p.SetColor4(&c)
+				}
+			}
+			read = func() interface{} { return p.Hex() }
+		}
+	case AttribRows:
+		if p, ok := ipan.(*Table); ok {
+			bd.apply = func(v interface{}) { p.SetRows(modelRows(v)) }
+		}
+	case AttribVisible:
+		bd.apply = func(v interface{}) {
+			state, _ := modelBool(v)
+			ipan.GetPanel().SetVisible(state)
+		}
+	case AttribEnabled:
+		bd.apply = func(v interface{}) {
+			state, _ := modelBool(v)
+			ipan.GetPanel().SetEnabled(state)
+		}
+	case AttribTooltip:
+		bd.apply = func(v interface{}) { ipan.GetPanel().SetTooltip(modelString(v)) }
+	}
+	if bd.apply == nil {
+		return b.err(am, attrib, fmt.Sprintf("Attribute cannot be bound for type:%T", ipan))
+	}
+
+	// Sets the initial value
+	v, err := b.model.Get(path)
+	if err != nil {
+		return b.err(am, attrib, err.Error())
+	}
+	bd.set(v)
+
+	// Updates the panel when the model changes
+	b.model.SubscribeID(OnModelChange, ipan, func(evname string, ev interface{}) {
+		if bd.updating || !modelPathsOverlap(ev.(*ModelEvent).Path, bd.path) {
+			return
+		}
+		v, err := bd.model.Get(bd.path)
+		if err != nil {
+			log.Error("Binding path:%s error:%v", bd.path, err)
+			return
+		}
+		bd.set(v)
+	})
+
+	// Updates the model when the panel changes
+	if read != nil {
+		ipan.GetPanel().Subscribe(OnChange, func(evname string, ev interface{}) {
+			if bd.updating {
+				return
+			}
+			bd.updating = true
+			err := bd.model.Set(bd.path, read())
+			bd.updating = false
+			if err != nil {
+				log.Error("Binding path:%s error:%v", bd.path, err)
+			}
+		})
+	}
+	return nil
+}
+
+// set sets the specified model value to the panel
+func (bd *modelBinding) set(v interface{}) {
+
+	bd.updating = true
+	bd.apply(v)
+	bd.updating = false
+}
+
+// modelPathsOverlap returns if a change of the value at one of the specified
+// paths may change the value at the other path
+func modelPathsOverlap(p1, p2 string) bool {
+
+	if p1 == "" || p2 == "" || p1 == p2 {
+		return true
+	}
+	return strings.HasPrefix(p1, p2+".") || strings.HasPrefix(p2, p1+".")
+}
+
+// modelRows converts a model slice of maps or structs to table rows
+func modelRows(v interface{}) []map[string]interface{} {
+
+	rows := []map[string]interface{}{}
+	sv := indirect(reflect.ValueOf(v))
+	if sv.Kind() != reflect.Slice && sv.Kind() != reflect.Array {
+		return rows
+	}
+	for i := 0; i < sv.Len(); i++ {
+		row := make(map[string]interface{})
+		ev := indirect(sv.Index(i))
+		switch ev.Kind() {
+		case reflect.Map:
+			if ev.Type().Key().Kind() != reflect.String {
+				break
+			}
+			for _, key := range ev.MapKeys() {
+				row[key.String()] = ev.MapIndex(key).Interface()
+			}
+		case reflect.Struct:
+			// Fields can be referenced by the column ids with their
+			// original name or in lower case
+			for fi := 0; fi < ev.NumField(); fi++ {
+				f := ev.Field(fi)
+				if !f.CanInterface() {
+					continue
+				}
+				name := ev.Type().Field(fi).Name
+				row[name] = f.Interface()
+				row[strings.ToLower(name)] = f.Interface()
+			}
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
@@ -198,3 +198,93 @@ func (bl *BuilderLayoutDock) BuildParams(b *Builder, am map[string]interface{})
 	params := DockLayoutParams{Edge: edge.(Edge)}
 	return &params, nil
 }
+
+//
+// BuilderLayoutFlex is builder for Flex layout
+//
+type BuilderLayoutFlex struct{}
+
+// BuildLayout builds and returns a FlexLayout with the specified attributes
+func (bl *BuilderLayoutFlex) BuildLayout(b *Builder, am map[string]interface{}) (ILayout, error) {
+
+	// Creates layout with optional direction
+	direction := FlexRow
+	if dir := am[AttribDirection]; dir != nil {
+		direction = dir.(FlexDirection)
+	}
+	l := NewFlexLayout(direction)
+
+	// Sets optional wrap mode
+	if wrap := am[AttribWrap]; wrap != nil {
+		l.SetWrap(wrap.(FlexWrapMode))
+	}
+
+	// Sets optional alignments
+	if align := am[AttribJustify]; align != nil {
+		l.SetJustify(align.(FlexAlign))
+	}
+	if align := am[AttribAlignItems]; align != nil {
+		l.SetAlignItems(align.(FlexAlign))
+	}
+	if align := am[AttribAlignContent]; align != nil {
+		l.SetAlignContent(align.(FlexAlign))
+	}
+
+	// Sets optional spacings
+	if sp := am[AttribSpacing]; sp != nil {
+		l.SetSpacing(sp.(float32))
+	}
+	if sp := am[AttribLineSpacing]; sp != nil {
+		l.SetLineSpacing(sp.(float32))
+	}
+
+	// Sets optional auto size flags
+	if aw := am[AttribAutoWidth]; aw != nil {
+		l.SetAutoWidth(aw.(bool))
+	}
+	if ah := am[AttribAutoHeight]; ah != nil {
+		l.SetAutoHeight(ah.(bool))
+	}
+	return l, nil
+}
+
+// BuildParams builds and returns a pointer to FlexLayoutParams with the specified attributes
+func (bl *BuilderLayoutFlex) BuildParams(b *Builder, am map[string]interface{}) (interface{}, error) {
+
+	// Creates layout parameters with default values
+	params := FlexLayoutParams{Shrink: 1}
+
+	// Sets optional grow and shrink factors
+	if grow := am[AttribGrow]; grow != nil {
+		params.Grow = grow.(float32)
+	}
+	if shrink := am[AttribShrink]; shrink != nil {
+		params.Shrink = shrink.(float32)
+	}
+
+	// Sets optional basis and size limits
+	lengths := map[string]*FlexLength{
+		AttribBasis:     &params.Basis,
+		AttribMinwidth:  &params.MinWidth,
+		AttribMaxWidth:  &params.MaxWidth,
+		AttribMinHeight: &params.MinHeight,
+		AttribMaxHeight: &params.MaxHeight,
+	}
+	for attrib, l := range lengths {
+		switch v := am[attrib].(type) {
+		case float32:
+			*l = FlexPx(v)
+		case FlexLength:
+			*l = v
+		}
+	}
+
+	// Sets optional alignment and order
+	if align := am[AttribAlignSelf]; align != nil {
+		params.AlignSelf = align.(FlexAlign)
+	}
+	if order := am[AttribOrder]; order != nil {
+		params.Order = order.(int)
+	}
+	return &params, nil
+}
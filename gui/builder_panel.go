@@ -220,6 +220,28 @@ func buildEdit(b *Builder, am map[string]interface{}) (IPanel, error) {
 	return edit, nil
 }
 
+// buildTextArea builds a gui object of type: "TextArea"
+func buildTextArea(b *Builder, am map[string]interface{}) (IPanel, error) {
+
+	// Builds text area and set attributes
+	var width, height float32
+	if aw := am[AttribWidth]; aw != nil {
+		width = aw.(float32)
+	}
+	if ah := am[AttribHeight]; ah != nil {
+		height = ah.(float32)
+	}
+	ta := NewTextArea(width, height)
+	if am[AttribText] != nil {
+		ta.SetText(am[AttribText].(string))
+	}
+	err := b.SetAttribs(am, ta)
+	if err != nil {
+		return nil, err
+	}
+	return ta, nil
+}
+
 // buildCheckBox builds a gui object of type: CheckBox
 func buildCheckBox(b *Builder, am map[string]interface{}) (IPanel, error) {
 
@@ -281,6 +303,9 @@ func buildVList(b *Builder, am map[string]interface{}) (IPanel, error) {
 	if err != nil {
 		return nil, err
 	}
+	if v := am[AttribReorderable]; v != nil {
+		list.SetReorderable(v.(bool))
+	}
 
 	// Builds children
 	if am[AttribItems] != nil {
@@ -306,6 +331,9 @@ func buildHList(b *Builder, am map[string]interface{}) (IPanel, error) {
 	if err != nil {
 		return nil, err
 	}
+	if v := am[AttribReorderable]; v != nil {
+		list.SetReorderable(v.(bool))
+	}
 
 	// Builds children
 	if am[AttribItems] != nil {
@@ -409,7 +437,8 @@ func buildMenu(b *Builder, am map[string]interface{}) (IPanel, error) {
 				if err != nil {
 					return nil, err
 				}
-				menu.AddMenu(itext, subm.(*Menu))
+				mi := menu.AddMenu(itext, subm.(*Menu))
+				localizeAttribs(item, mi)
 				continue
 			}
 			// Item is a separator
@@ -419,6 +448,7 @@ func buildMenu(b *Builder, am map[string]interface{}) (IPanel, error) {
 			}
 			// Item must be a menu option
 			mi := menu.AddOption(itext)
+			localizeAttribs(item, mi)
 			// Set item optional icon(s)
 			if icon := item[AttribIcon]; icon != nil {
 				mi.SetIcon(icon.(string))
@@ -538,6 +568,9 @@ func buildTree(b *Builder, am map[string]interface{}) (IPanel, error) {
 	if err != nil {
 		return nil, err
 	}
+	if v := am[AttribReorderable]; v != nil {
+		tree.SetReorderable(v.(bool))
+	}
 
 	// Internal function to build tree nodes recursively
 	var buildItems func(am map[string]interface{}, pnode *TreeNode) error
@@ -755,7 +788,11 @@ func buildTable(b *Builder, am map[string]interface{}) (IPanel, error) {
 			tc.Width = iv.(float32)
 		}
 		if iv := am[AttribMinwidth]; iv != nil {
-			tc.Minwidth = iv.(float32)
+			minwidth, ok := iv.(float32)
+			if !ok {
+				return nil, b.err(am, AttribMinwidth, "Not a number")
+			}
+			tc.Minwidth = minwidth
 		}
 		if iv := am[AttribHidden]; iv != nil {
 			tc.Hidden = iv.(bool)
@@ -803,6 +840,16 @@ func buildTable(b *Builder, am map[string]interface{}) (IPanel, error) {
 		table.ShowHeader(show.(bool))
 	}
 
+	// Sets optional reorderable attribute
+	if v := am[AttribReorderable]; v != nil {
+		table.SetReorderable(v.(bool))
+	}
+
+	// Sets optional rows
+	if rows := am[AttribRows]; rows != nil {
+		table.SetRows(rows.([]map[string]interface{}))
+	}
+
 	return table, nil
 }
 
@@ -815,6 +862,9 @@ func buildTabBar(b *Builder, am map[string]interface{}) (IPanel, error) {
 	if err != nil {
 		return nil, err
 	}
+	if v := am[AttribReorderable]; v != nil {
+		tabbar.SetReorderable(v.(bool))
+	}
 	v := am[AttribItems]
 
 	// For each tab
@@ -827,6 +877,7 @@ func buildTabBar(b *Builder, am map[string]interface{}) (IPanel, error) {
 				text = v.(string)
 			}
 			tab := tabbar.AddTab(text)
+			localizeAttribs(item, tab.label)
 			// Sets optional icon
 			if v := item[AttribIcon]; v != nil {
 				tab.SetIcon(v.(string))
@@ -861,3 +912,139 @@ func buildTabBar(b *Builder, am map[string]interface{}) (IPanel, error) {
 
 	return tabbar, nil
 }
+
+// buildSpinner builds a gui object of type: Spinner
+func buildSpinner(b *Builder, am map[string]interface{}) (IPanel, error) {
+
+	// Default range and step
+	min := float32(0)
+	max := float32(100)
+	step := float32(1)
+	if v := am[AttribRangeMin]; v != nil {
+		min = v.(float32)
+	}
+	if v := am[AttribRangeMax]; v != nil {
+		max = v.(float32)
+	}
+	if v := am[AttribStep]; v != nil {
+		step = v.(float32)
+	}
+	spinner := NewSpinner(0, min, max, step)
+	err := b.SetAttribs(am, spinner)
+	if err != nil {
+		return nil, err
+	}
+
+	// Sets optional format
+	if v := am[AttribFormat]; v != nil {
+		spinner.SetFormat(v.(string))
+	}
+	// Sets optional value
+	if v := am[AttribValue]; v != nil {
+		spinner.SetValue(v.(float32))
+	}
+	return spinner, nil
+}
+
+// buildProgressBar builds a gui object of type: ProgressBar
+func buildProgressBar(b *Builder, am map[string]interface{}) (IPanel, error) {
+
+	pb := NewProgressBar(0, 0)
+	err := b.SetAttribs(am, pb)
+	if err != nil {
+		return nil, err
+	}
+
+	// Sets optional format
+	if v := am[AttribFormat]; v != nil {
+		pb.SetFormat(v.(string))
+	}
+	// Sets optional value
+	if v := am[AttribValue]; v != nil {
+		pb.SetValue(v.(float32))
+	}
+	// Sets optional indeterminate state
+	if v := am[AttribIndeterminate]; v != nil {
+		pb.SetIndeterminate(v.(bool))
+	}
+	return pb, nil
+}
+
+// buildColorPicker builds a gui object of type: ColorPicker
+func buildColorPicker(b *Builder, am map[string]interface{}) (IPanel, error) {
+
+	cp := NewColorPicker(0, 0)
+	err := b.SetAttribs(am, cp)
+	if err != nil {
+		return nil, err
+	}
+
+	// Sets optional initial color
+	if v := am[AttribPickColor]; v != nil {
+		cp.SetColor4(v.(*math32.Color4))
+	}
+	return cp, nil
+}
+
+// buildMessageBox builds a gui object of type: MessageBox.
+// The message box must be shown by its Show method.
+func buildMessageBox(b *Builder, am map[string]interface{}) (IPanel, error) {
+
+	kind := MessageBoxInfo
+	if v := am[AttribMsgType]; v != nil {
+		kind = v.(MessageBoxType)
+	}
+	var title, message string
+	if v := am[AttribTitle]; v != nil {
+		title = v.(string)
+	}
+	if v := am[AttribText]; v != nil {
+		message = v.(string)
+	}
+	var buttons []string
+	if v := am[AttribButtons]; v != nil {
+		buttons = v.([]string)
+	}
+	mb := NewMessageBox(kind, title, message, buttons...)
+	err := b.SetAttribs(am, mb)
+	if err != nil {
+		return nil, err
+	}
+	return mb, nil
+}
+
+// buildFileDialog builds a gui object of type: FileDialog.
+// The file dialog must be shown by its Show method.
+func buildFileDialog(b *Builder, am map[string]interface{}) (IPanel, error) {
+
+	mode := FileDialogOpen
+	if v := am[AttribDialogMode]; v != nil {
+		mode = v.(FileDialogMode)
+	}
+	var title string
+	if v := am[AttribTitle]; v != nil {
+		title = v.(string)
+	}
+	fd := NewFileDialog(mode, title)
+	err := b.SetAttribs(am, fd)
+	if err != nil {
+		return nil, err
+	}
+
+	// Sets optional directory
+	if v := am[AttribDir]; v != nil {
+		err := fd.SetDir(v.(string))
+		if err != nil {
+			return nil, b.err(am, AttribDir, err.Error())
+		}
+	}
+	// Sets optional filters
+	if v := am[AttribFilters]; v != nil {
+		fd.SetFilters(v.([]string)...)
+	}
+	// Sets optional file name
+	if v := am[AttribText]; v != nil {
+		fd.SetFileName(v.(string))
+	}
+	return fd, nil
+}
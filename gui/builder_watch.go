@@ -0,0 +1,368 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gui
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"time"
+)
+
+// BuilderReloadFunc is the type of the function called by a Builder watching its
+// description file for each rebuilt top level panel with its name and the new panel,
+// or with the error which prevented the reload and a nil panel.
+type BuilderReloadFunc func(name string, ipan IPanel, err error)
+
+// builderWatch contains the state of the description file watched by a Builder
+type builderWatch struct {
+	root    *Root             // root panel whose timers poll the file
+	timerID int               // id of the polling timer
+	modTime time.Time         // modification time of the last parsed file
+	size    int64             // size of the last parsed file
+	cb      BuilderReloadFunc // optional reload callback
+}
+
+// Watch starts watching the description file last parsed by ParseFile.
+// The file is polled at the specified interval using the timers of the specified
+// root panel, so the panels are always rebuilt from the GUI thread.
+// When the file changes it is parsed again and the panels built by Build after this
+// call whose descriptions changed are rebuilt and replace the previous panels
+// at the same position of the same parent. The state of the children of the previous
+// panels, such as edit texts, selections and scroll positions, is copied to the
+// rebuilt children with the same names and types.
+// Errors do not change the current panels and are passed to the optional
+// callback, which is also called for each rebuilt panel, or logged.
+func (b *Builder) Watch(root *Root, interval time.Duration, cb BuilderReloadFunc) error {
+
+	if b.path == "" {
+		return fmt.Errorf("No description file parsed")
+	}
+	fi, err := os.Stat(b.path)
+	if err != nil {
+		return err
+	}
+	b.Unwatch()
+	b.watch = &builderWatch{root: root, modTime: fi.ModTime(), size: fi.Size(), cb: cb}
+	b.built = make(map[string][]IPanel)
+	b.watch.timerID = root.SetInterval(interval, nil, func(arg interface{}) {
+		b.Reload()
+	})
+	return nil
+}
+
+// Unwatch stops watching the description file and forgets the built panels
+func (b *Builder) Unwatch() {
+
+	if b.watch == nil {
+		return
+	}
+	b.watch.root.ClearTimeout(b.watch.timerID)
+	b.watch = nil
+	b.built = nil
+}
+
+// Reload checks if the watched description file was modified since it was last parsed
+// and if so parses it again and rebuilds the built panels whose descriptions changed.
+// It is called periodically while watching but can also be called directly.
+// Panels which are not children of another panel when the file changes are
+// no longer tracked. Returns the first error found.
+func (b *Builder) Reload() error {
+
+	w := b.watch
+	if w == nil {
+		return fmt.Errorf("Description file not being watched")
+	}
+	// The file may not exist temporarily while it is being saved
+	fi, err := os.Stat(b.path)
+	if err != nil {
+		return err
+	}
+	if fi.ModTime().Equal(w.modTime) && fi.Size() == w.size {
+		return nil
+	}
+	w.modTime = fi.ModTime()
+	w.size = fi.Size()
+
+	// Keeps the current panels if the file cannot be parsed
+	prev := b.am
+	err = b.ParseFile(b.path)
+	if err != nil {
+		return b.reloadError("", err)
+	}
+
+	// Rebuilds the panels whose descriptions changed in name order
+	var names []string
+	for name := range b.built {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var first error
+	for _, name := range names {
+		if builderDescEqual(builderDesc(prev, name), builderDesc(b.am, name)) {
+			continue
+		}
+		var pans []IPanel
+		for _, old := range b.built[name] {
+			pan, err := b.rebuild(name, old)
+			if err != nil {
+				if first == nil {
+					first = err
+				}
+				b.reloadError(name, err)
+				pans = append(pans, old)
+				continue
+			}
+			if pan == nil {
+				continue
+			}
+			pans = append(pans, pan)
+			if w.cb != nil {
+				w.cb(name, pan, nil)
+			}
+		}
+		b.built[name] = pans
+	}
+	return first
+}
+
+// reloadError reports the specified reload error and returns it
+func (b *Builder) reloadError(name string, err error) error {
+
+	if b.watch.cb != nil {
+		b.watch.cb(name, nil, err)
+	} else {
+		log.Error("Reloading:%s name:%s error:%v", b.path, name, err)
+	}
+	return err
+}
+
+// rebuild builds the top level object with the specified name and replaces the
+// specified previously built panel by it. Returns nil if the old panel has no parent.
+func (b *Builder) rebuild(name string, old IPanel) (IPanel, error) {
+
+	parent, ok := old.GetPanel().Parent().(IPanel)
+	if !ok {
+		return nil, nil
+	}
+	pan, err := b.buildName(name)
+	if err != nil {
+		return nil, err
+	}
+
+	// Keeps the position and size set by the application if not described
+	am := builderDesc(b.am, name)
+	op := old.GetPanel()
+	np := pan.GetPanel()
+	if am[AttribPosition] == nil {
+		pos := op.Position()
+		np.SetPosition(pos.X, pos.Y)
+	}
+	if am[AttribWidth] == nil && am[AttribHeight] == nil {
+		np.SetSize(op.Width(), op.Height())
+	}
+
+	// Removes focus from the old panels
+	if root := op.Root(); root != nil {
+		if root.keyFocus != nil && panelContains(old, root.keyFocus) {
+			root.SetKeyFocus(nil)
+		}
+		if root.mouseFocus != nil && panelContains(old, root.mouseFocus) {
+			root.SetMouseFocus(nil)
+		}
+		if root.scrollFocus != nil && panelContains(old, root.scrollFocus) {
+			root.SetScrollFocus(nil)
+		}
+		if root.modalPanel != nil && root.modalPanel.GetPanel() == op {
+			root.SetModal(pan)
+		}
+	}
+
+	// Replaces the old panel
+	pp := parent.GetPanel()
+	idx := pp.ChildIndex(old)
+	pp.Remove(old)
+	pp.AddAt(idx, pan)
+
+	// Copies the state of the old named panels after the new panel
+	// was laid out by its parent
+	olds := make(map[string]IPanel)
+	builderWalk(old, func(ipan IPanel) {
+		if name := ipan.GetPanel().Name(); name != "" {
+			olds[name] = ipan
+		}
+	})
+	builderWalk(pan, func(ipan IPanel) {
+		if src := olds[ipan.GetPanel().Name()]; src != nil {
+			builderCopyState(ipan, src)
+		}
+	})
+
+	// Disposes the old panels (Unbind also unbinds the descendants)
+	b.Unbind(old)
+	op.DisposeChildren(true)
+	old.Dispose()
+	return pan, nil
+}
+
+// builderDesc returns the description of the top level object with the specified name
+func builderDesc(am map[string]interface{}, name string) map[string]interface{} {
+
+	if name == "" {
+		return am
+	}
+	desc, _ := am[name].(map[string]interface{})
+	return desc
+}
+
+// builderDescEqual returns if the specified description values are equal
+// ignoring the references to the parent descriptions
+func builderDescEqual(v1, v2 interface{}) bool {
+
+	switch t1 := v1.(type) {
+	case map[string]interface{}:
+		t2, ok := v2.(map[string]interface{})
+		if !ok || (t1 == nil) != (t2 == nil) || len(t1) != len(t2) {
+			return false
+		}
+		for k, e1 := range t1 {
+			if k == AttribParentInternal {
+				continue
+			}
+			e2, ok := t2[k]
+			if !ok || !builderDescEqual(e1, e2) {
+				return false
+			}
+		}
+		return true
+	case []interface{}:
+		t2, ok := v2.([]interface{})
+		if !ok || len(t1) != len(t2) {
+			return false
+		}
+		for i := range t1 {
+			if !builderDescEqual(t1[i], t2[i]) {
+				return false
+			}
+		}
+		return true
+	case []map[string]interface{}:
+		t2, ok := v2.([]map[string]interface{})
+		if !ok || len(t1) != len(t2) {
+			return false
+		}
+		for i := range t1 {
+			if !builderDescEqual(t1[i], t2[i]) {
+				return false
+			}
+		}
+		return true
+	}
+	return reflect.DeepEqual(v1, v2)
+}
+
+// builderWalk calls the specified function for the specified panel
+// and all its descendant panels
+func builderWalk(ipan IPanel, f func(IPanel)) {
+
+	f(ipan)
+	for _, child := range ipan.GetPanel().Children() {
+		if ichild, ok := child.(IPanel); ok {
+			builderWalk(ichild, f)
+		}
+	}
+}
+
+// builderCopyState copies the state modified by the user of the
+// specified panel to the specified panel of the same type
+func builderCopyState(dst, src IPanel) {
+
+	switch s := src.(type) {
+	case *Edit:
+		if d, ok := dst.(*Edit); ok {
+			d.SetText(s.Text())
+			d.SetSelection(s.Selection())
+		}
+	case *TextArea:
+		if d, ok := dst.(*TextArea); ok {
+			d.SetText(s.Text())
+			d.SetSelection(s.Selection())
+			d.setScrollOffset(s.scrollOffset())
+		}
+	case *Scroller:
+		if d, ok := dst.(*Scroller); ok {
+			d.setScrollOffset(s.scrollOffset())
+		}
+	case *Slider:
+		if d, ok := dst.(*Slider); ok {
+			d.SetValue(s.Value())
+		}
+	case *CheckRadio:
+		if d, ok := dst.(*CheckRadio); ok {
+			d.SetValue(s.Value())
+		}
+	case *Spinner:
+		if d, ok := dst.(*Spinner); ok {
+			d.SetValue(s.Value())
+		}
+	case *ProgressBar:
+		if d, ok := dst.(*ProgressBar); ok {
+			d.SetValue(s.Value())
+		}
+	case *ColorPicker:
+		if d, ok := dst.(*ColorPicker); ok {
+			c := s.Color4()
+			d.SetColor4(&c)
+		}
+	case *FileDialog:
+		if d, ok := dst.(*FileDialog); ok {
+			d.SetDir(s.Dir())
+			d.SetFileName(s.FileName())
+		}
+	case *DropDown:
+		if d, ok := dst.(*DropDown); ok {
+			if pos := s.SelectedPos(); pos >= 0 && pos < d.Len() {
+				d.SelectPos(pos)
+			}
+		}
+	case *List:
+		if d, ok := dst.(*List); ok && s.Model() != nil {
+			d.SetModel(s.Model())
+		}
+		if d, ok := dst.(*List); ok && d.Len() == s.Len() {
+			for _, pos := range s.SelectedPos() {
+				d.SelectPos(pos, true)
+			}
+			d.SetFirst(s.First())
+		}
+	case *Table:
+		if d, ok := dst.(*Table); ok {
+			// Rows set by the application are copied if not described
+			if s.Model() != nil {
+				d.SetModel(s.Model())
+			} else if d.RowCount() == 0 && s.RowCount() > 0 {
+				rows := make([]map[string]interface{}, 0, s.RowCount())
+				for ri := 0; ri < s.RowCount(); ri++ {
+					rows = append(rows, s.Row(ri))
+				}
+				d.SetRows(rows)
+			}
+			if d.RowCount() == s.RowCount() {
+				d.SelectRows(s.SelectedRows()...)
+			}
+		}
+	case *TabBar:
+		if d, ok := dst.(*TabBar); ok {
+			if pos := s.Selected(); pos >= 0 && pos < d.TabCount() {
+				d.SetSelected(pos)
+			}
+		}
+	case *Splitter:
+		if d, ok := dst.(*Splitter); ok {
+			d.SetSplit(s.Split())
+		}
+	}
+}
@@ -73,7 +73,10 @@ func NewButton(text string) *Button {
 	b.Subscribe(OnCursorEnter, b.onCursor)
 	b.Subscribe(OnCursorLeave, b.onCursor)
 	b.Subscribe(OnEnable, func(name string, ev interface{}) { b.update() })
+	b.Subscribe(OnFocus, func(name string, ev interface{}) { b.update() })
+	b.Subscribe(OnFocusLost, func(name string, ev interface{}) { b.update() })
 	b.Subscribe(OnResize, func(name string, ev interface{}) { b.recalc() })
+	b.SetFocusable(true)
 
 	// Creates label
 	b.Label = NewLabel(text)
@@ -273,7 +276,7 @@ func (b *Button) onMouse(evname string, ev interface{}) {
 func (b *Button) onKey(evname string, ev interface{}) {
 
 	kev := ev.(*window.KeyEvent)
-	if evname == OnKeyDown && kev.Keycode == window.KeyEnter {
+	if evname == OnKeyDown && activateKey(kev.Keycode) {
 		if !b.toggle {
 			b.Press()
 		} else if !b.selected {
@@ -285,7 +288,7 @@ func (b *Button) onKey(evname string, ev interface{}) {
 		b.root.StopPropagation(Stop3D)
 		return
 	}
-	if evname == OnKeyUp && kev.Keycode == window.KeyEnter {
+	if evname == OnKeyUp && activateKey(kev.Keycode) {
 		if !b.toggle {
 			b.selected = false
 		}
@@ -311,6 +314,10 @@ func (b *Button) update() {
 		b.applyStyle(&b.styles.Over)
 		return
 	}
+	if b.root != nil && b.root.HasKeyFocus(b) {
+		b.applyStyle(&b.styles.Focus)
+		return
+	}
 	b.applyStyle(&b.styles.Normal)
 }
 
@@ -14,6 +14,7 @@ import (
 	"github.com/sansebasko/engine/math32"
 	"github.com/sansebasko/engine/renderer/shaders"
 	"math"
+	"time"
 )
 
 func init() {
@@ -47,6 +48,23 @@ type Chart struct {
 	labelsX    []*Label     // Array of scale X labels
 	labelsY    []*Label     // Array of scale Y labels
 	graphs     []*Graph     // Array of line graphs
+	right      float32      // Right margin in pixels
+	autoX      bool         // Auto range flag for X values
+	rolling    int          // Maximum number of values kept by the graphs (0 for no limit)
+	vminX      float32      // Minimum visible X value
+	vmaxX      float32      // Maximum visible X value
+	vminY      float32      // Minimum visible Y value
+	vmaxY      float32      // Maximum visible Y value
+	zoomX      bool         // The visible X range was set by zooming or panning
+	zoomY      bool         // The visible Y range was set by zooming or panning
+	y2         *chartAxisY  // Optional right Y axis
+	legend     *Panel       // Optional legend panel
+	inspect    *Label       // Optional label which shows the data point under the cursor
+	zoomPan    bool         // Zoom and pan with the mouse enabled
+	pan        *chartPan    // Current pan with the mouse
+	clickTime  time.Time    // Time of the last click for double click detection
+	cursorX    float32      // Last cursor x position in window coordinates
+	cursorY    float32      // Last cursor y position in window coordinates
 }
 
 const (
@@ -80,7 +98,17 @@ func (ch *Chart) Init(width float32, height float32) {
 	ch.formatY = "%v"
 	ch.fontSizeX = 14
 	ch.fontSizeY = 14
+	ch.vminX = 0
+	ch.vmaxX = 1
+	ch.vminY = ch.minY
+	ch.vmaxY = ch.maxY
 	ch.Subscribe(OnResize, ch.onResize)
+	ch.Subscribe(OnCursor, ch.onCursor)
+	ch.Subscribe(OnCursorEnter, ch.onCursor)
+	ch.Subscribe(OnCursorLeave, ch.onCursor)
+	ch.Subscribe(OnMouseDown, ch.onMouse)
+	ch.Subscribe(OnMouseUp, ch.onMouse)
+	ch.Subscribe(OnScroll, ch.onScroll)
 }
 
 // SetTitle sets the chart title text and font size.
@@ -291,17 +319,67 @@ func (ch *Chart) RangeY() (minY, maxY float32) {
 	return ch.minY, ch.maxY
 }
 
-// AddLineGraph adds a line graph to the chart
+// AddLineGraph adds a line graph with evenly spaced X values to the chart
 func (ch *Chart) AddLineGraph(color *math32.Color, data []float32) *Graph {
 
-	graph := newGraph(ch, color, data)
+	return ch.addGraph(newGraph(ch, graphLine, color, nil, data))
+}
+
+// AddXYGraph adds a line graph with the specified X and Y values to the chart.
+// It panics if the number of X and Y values is different.
+func (ch *Chart) AddXYGraph(color *math32.Color, x, y []float32) *Graph {
+
+	return ch.addGraph(newGraph(ch, graphLine, color, x, y))
+}
+
+// AddScatterGraph adds a graph which draws markers at the specified X and Y values to the chart.
+// It panics if the number of X and Y values is different.
+func (ch *Chart) AddScatterGraph(color *math32.Color, x, y []float32) *Graph {
+
+	return ch.addGraph(newGraph(ch, graphScatter, color, x, y))
+}
+
+// AddBarGraph adds a bar graph with evenly spaced X values to the chart.
+// The bars of several bar graphs are drawn side by side unless they are stacked.
+func (ch *Chart) AddBarGraph(color *math32.Color, data []float32) *Graph {
+
+	return ch.addGraph(newGraph(ch, graphBar, color, nil, data))
+}
+
+// AddAreaGraph adds a graph with evenly spaced X values which fills
+// the area under its line to the chart
+func (ch *Chart) AddAreaGraph(color *math32.Color, data []float32) *Graph {
+
+	return ch.addGraph(newGraph(ch, graphArea, color, nil, data))
+}
+
+// AddHistogram adds a bar graph with the histogram of the specified values
+// using the specified number of bins to the chart
+func (ch *Chart) AddHistogram(color *math32.Color, values []float32, bins int) *Graph {
+
+	graph := newGraph(ch, graphBar, color, nil, nil)
+	graph.setHistogram(values, bins)
+	return ch.addGraph(graph)
+}
+
+// addGraph adds the specified graph to the chart
+func (ch *Chart) addGraph(graph *Graph) *Graph {
+
 	ch.graphs = append(ch.graphs, graph)
 	ch.Add(graph)
+	graph.trim()
 	ch.recalc()
 	ch.updateGraphs()
+	ch.updateLegend()
 	return graph
 }
 
+// Graphs returns the graphs of the chart
+func (ch *Chart) Graphs() []*Graph {
+
+	return ch.graphs
+}
+
 // RemoveGraph removes and disposes of the specified graph from the chart
 func (ch *Chart) RemoveGraph(g *Graph) {
 
@@ -315,10 +393,14 @@ func (ch *Chart) RemoveGraph(g *Graph) {
 			break
 		}
 	}
-	if !ch.autoY {
-		return
+	// Graphs stacked on the removed graph are stacked on its base
+	for _, graph := range ch.graphs {
+		if graph.stack == g {
+			graph.stack = g.stack
+		}
 	}
 	ch.updateGraphs()
+	ch.updateLegend()
 }
 
 // updateLabelsX updates the X scale labels text
@@ -327,14 +409,16 @@ func (ch *Chart) updateLabelsX() {
 	if ch.scaleX == nil {
 		return
 	}
-	pstep := (ch.ContentWidth() - ch.left) / float32(len(ch.labelsX))
-	value := ch.firstX
+	_, _, width, _ := ch.plotArea()
+	pstep := width / float32(len(ch.labelsX))
+	vstep := (ch.vmaxX - ch.vminX) / float32(len(ch.labelsX))
+	value := ch.vminX
 	for i := 0; i < len(ch.labelsX); i++ {
 		label := ch.labelsX[i]
 		label.SetText(fmt.Sprintf(ch.formatX, value))
 		px := ch.left + float32(i)*pstep
 		label.SetPosition(px, ch.ContentHeight()-ch.bottom)
-		value += ch.stepX
+		value += vstep
 	}
 }
 
@@ -345,15 +429,11 @@ func (ch *Chart) updateLabelsY() {
 		return
 	}
 
-	th := float32(0)
-	if ch.title != nil {
-		th = ch.title.height
-	}
-
+	_, _, _, height := ch.plotArea()
 	nlines := ch.scaleY.lines
-	vstep := (ch.maxY - ch.minY) / float32(nlines-1)
-	pstep := (ch.ContentHeight() - th - ch.top - ch.bottom) / float32(nlines-1)
-	value := ch.minY
+	vstep := (ch.vmaxY - ch.vminY) / float32(nlines-1)
+	pstep := height / float32(nlines-1)
+	value := ch.vminY
 	for i := 0; i < nlines; i++ {
 		label := ch.labelsY[i]
 		label.SetText(fmt.Sprintf(ch.formatY, value))
@@ -365,37 +445,121 @@ func (ch *Chart) updateLabelsY() {
 		label.SetPosition(px, py-label.Height()/2)
 		value += vstep
 	}
+	ch.updateLabelsY2()
 }
 
 // calcRangeY calculates the minimum and maximum y values for all graphs
 func (ch *Chart) calcRangeY() {
 
-	if !ch.autoY || len(ch.graphs) == 0 {
+	if !ch.autoY {
 		return
 	}
+	if minY, maxY, ok := ch.dataRangeY(false); ok {
+		ch.minY = minY
+		ch.maxY = maxY
+	}
+}
+
+// dataRangeY returns the minimum and maximum y values of the graphs
+// of the left or right axis including the base of the bars and areas
+func (ch *Chart) dataRangeY(right bool) (float32, float32, bool) {
+
 	minY := float32(math.MaxFloat32)
 	maxY := -float32(math.MaxFloat32)
+	found := false
 	for g := 0; g < len(ch.graphs); g++ {
 		graph := ch.graphs[g]
+		if graph.right != right {
+			continue
+		}
+		fill := graph.kind == graphBar || graph.kind == graphArea
 		for x := 0; x < len(graph.data); x++ {
-			vy := graph.data[x]
-			if vy < minY {
-				minY = vy
+			base := graph.base(x)
+			values := []float32{base + graph.data[x]}
+			if fill {
+				values = append(values, base)
+			}
+			for _, vy := range values {
+				if vy < minY {
+					minY = vy
+				}
+				if vy > maxY {
+					maxY = vy
+				}
+			}
+			found = true
+		}
+	}
+	if !found {
+		return 0, 0, false
+	}
+	if minY == maxY {
+		minY--
+		maxY++
+	}
+	return minY, maxY, true
+}
+
+// calcRangeX calculates the visible range of x values
+func (ch *Chart) calcRangeX() {
+
+	if ch.zoomX {
+		return
+	}
+	lines := float32(1)
+	if ch.scaleX != nil {
+		lines = float32(ch.scaleX.lines)
+	}
+	ch.vminX = ch.firstX
+	ch.vmaxX = ch.firstX + lines*ch.stepX
+
+	// Auto range shows all the graphs values
+	if ch.autoX {
+		minX := float32(math.MaxFloat32)
+		maxX := -float32(math.MaxFloat32)
+		for _, graph := range ch.graphs {
+			for i := 0; i < len(graph.data); i++ {
+				vx := graph.x(i)
+				if vx < minX {
+					minX = vx
+				}
+				if vx > maxX {
+					maxX = vx
+				}
+			}
+		}
+		if minX < maxX {
+			ch.vminX = minX
+			ch.vmaxX = maxX
+		}
+		return
+	}
+
+	// The rolling window follows the last values of the evenly spaced graphs
+	if ch.rolling > 0 {
+		for _, graph := range ch.graphs {
+			if graph.dataX != nil {
+				continue
 			}
-			if vy > maxY {
-				maxY = vy
+			if end := graph.x(len(graph.data)); end > ch.vmaxX {
+				ch.vminX += end - ch.vmaxX
+				ch.vmaxX = end
 			}
 		}
 	}
-	ch.minY = minY
-	ch.maxY = maxY
 }
 
 // updateGraphs should be called when the range the scales change or
 // any graph data changes
 func (ch *Chart) updateGraphs() {
 
+	ch.calcRangeX()
 	ch.calcRangeY()
+	ch.calcRangeY2()
+	if !ch.zoomY {
+		ch.vminY = ch.minY
+		ch.vmaxY = ch.maxY
+	}
 	ch.updateLabelsX()
 	ch.updateLabelsY()
 	for i := 0; i < len(ch.graphs); i++ {
@@ -404,6 +568,18 @@ func (ch *Chart) updateGraphs() {
 	}
 }
 
+// plotArea returns the position and size in pixels of the chart area where the graphs are drawn
+func (ch *Chart) plotArea() (x, y, width, height float32) {
+
+	y = ch.top
+	if ch.title != nil {
+		y += ch.title.Height()
+	}
+	width = ch.ContentWidth() - ch.left - ch.right
+	height = ch.ContentHeight() - y - ch.bottom
+	return ch.left, y, width, height
+}
+
 // onResize process OnResize events for this chart
 func (ch *Chart) onResize(evname string, ev interface{}) {
 
@@ -437,6 +613,7 @@ func (ch *Chart) recalc() {
 		g.recalc()
 		ch.SetTopChild(g)
 	}
+	ch.recalcLegend()
 }
 
 //
@@ -491,12 +668,9 @@ func newChartScaleX(chart *Chart, lines int, color *math32.Color) *chartScaleX {
 // recalc recalculates the position and size of this scale inside its parent
 func (sx *chartScaleX) recalc() {
 
-	py := sx.chart.top
-	if sx.chart.title != nil {
-		py += sx.chart.title.Height()
-	}
-	sx.SetPosition(sx.chart.left, py)
-	sx.SetSize(sx.chart.ContentWidth()-sx.chart.left, sx.chart.ContentHeight()-py-sx.chart.bottom)
+	px, py, width, height := sx.chart.plotArea()
+	sx.SetPosition(px, py)
+	sx.SetSize(width, height)
 }
 
 // RenderSetup is called by the renderer before drawing this graphic
@@ -576,12 +750,9 @@ func newChartScaleY(chart *Chart, lines int, color *math32.Color) *chartScaleY {
 // recalc recalculates the position and size of this scale inside its parent
 func (sy *chartScaleY) recalc() {
 
-	py := sy.chart.top
-	if sy.chart.title != nil {
-		py += sy.chart.title.Height()
-	}
-	sy.SetPosition(sy.chart.left, py)
-	sy.SetSize(sy.chart.ContentWidth()-sy.chart.left, sy.chart.ContentHeight()-py-sy.chart.bottom)
+	px, py, width, height := sy.chart.plotArea()
+	sy.SetPosition(px, py)
+	sy.SetSize(width, height)
 }
 
 // RenderSetup is called by the renderer before drawing this graphic
@@ -610,22 +781,40 @@ func (sy *chartScaleY) RenderSetup(gs *gls.GLS, rinfo *core.RenderInfo) {
 type Graph struct {
 	Panel                   // Embedded panel
 	chart     *Chart        // Container chart
+	kind      int           // Graph kind
 	color     math32.Color  // Line color
+	name      string        // Name shown in the legend
 	data      []float32     // Data y
+	dataX     []float32     // Data x (nil for evenly spaced x values)
+	offset    int           // Number of values removed by the rolling window
+	right     bool          // Uses the right Y axis
+	stack     *Graph        // Graph this graph is stacked on
+	barWidth  float32       // Bar width in X units (0 for automatic)
+	marker    float32       // Marker size in pixels
 	mat       chartMaterial // Chart material
 	vbo       *gls.VBO
 	positions math32.ArrayF32
 	uniBounds gls.Uniform // Bounds uniform location cache
 }
 
-// newGraph creates and returns a pointer to a new graph for the specified chart
-func newGraph(chart *Chart, color *math32.Color, data []float32) *Graph {
+// Graph kinds
+const (
+	graphLine = iota
+	graphScatter
+	graphBar
+	graphArea
+)
+
+// newGraph creates and returns a pointer to a new graph of the specified kind for the specified chart
+func newGraph(chart *Chart, kind int, color *math32.Color, dataX, data []float32) *Graph {
 
 	lg := new(Graph)
 	lg.uniBounds.Init("Bounds")
 	lg.chart = chart
+	lg.kind = kind
 	lg.color = *color
-	lg.data = data
+	lg.setData(dataX, data)
+	lg.marker = 6
 
 	// Creates geometry and adds VBO with positions
 	geom := geometry.NewGeometry()
@@ -634,12 +823,14 @@ func newGraph(chart *Chart, color *math32.Color, data []float32) *Graph {
 	geom.AddVBO(lg.vbo)
 
 	// Initializes the panel with this graphic
-	gr := graphic.NewGraphic(geom, gls.LINE_STRIP)
+	mode := uint32(gls.TRIANGLES)
+	if kind == graphLine {
+		mode = gls.LINE_STRIP
+	}
+	gr := graphic.NewGraphic(geom, mode)
 	lg.mat.Init(&lg.color)
 	gr.AddMaterial(lg, &lg.mat, 0, 0)
 	lg.Panel.InitializeGraphic(lg.chart.ContentWidth(), lg.chart.ContentHeight(), gr)
-
-	lg.SetData(data)
 	return lg
 }
 
@@ -647,13 +838,64 @@ func newGraph(chart *Chart, color *math32.Color, data []float32) *Graph {
 func (lg *Graph) SetColor(color *math32.Color) {
 
 	lg.color = *color
+	lg.mat.color = *color
+	lg.chart.updateLegend()
+}
+
+// Color returns the color of the graph
+func (lg *Graph) Color() math32.Color {
+
+	return lg.color
+}
+
+// SetOpacity sets the opacity of the graph from 0 (transparent) to 1 (opaque)
+func (lg *Graph) SetOpacity(opacity float32) {
+
+	lg.mat.opacity = opacity
+}
+
+// SetName sets the name of the graph shown in the chart legend
+func (lg *Graph) SetName(name string) {
+
+	lg.name = name
+	lg.chart.updateLegend()
+}
+
+// Name returns the name of the graph
+func (lg *Graph) Name() string {
+
+	return lg.name
 }
 
-// SetData sets the graph data
+// SetData sets the graph data. The values are copied.
 func (lg *Graph) SetData(data []float32) {
 
-	lg.data = data
-	lg.updateData()
+	lg.setData(nil, data)
+	lg.offset = 0
+	lg.trim()
+	lg.chart.updateGraphs()
+}
+
+// SetDataXY sets the X and Y values of the graph data. The values are copied.
+// It panics if the number of X and Y values is different.
+func (lg *Graph) SetDataXY(x, y []float32) {
+
+	lg.setData(x, y)
+	lg.offset = 0
+	lg.trim()
+	lg.chart.updateGraphs()
+}
+
+// Data returns the Y values of the graph data
+func (lg *Graph) Data() []float32 {
+
+	return lg.data
+}
+
+// DataX returns the X values of the graph data or nil for evenly spaced X values
+func (lg *Graph) DataX() []float32 {
+
+	return lg.dataX
 }
 
 // SetLineWidth sets the graph line width
@@ -662,39 +904,59 @@ func (lg *Graph) SetLineWidth(width float32) {
 	lg.mat.SetLineWidth(width)
 }
 
-// updateData regenerates the lines for the current data
-func (lg *Graph) updateData() {
+// SetMarkerSize sets the size in pixels of the markers of scatter graphs
+func (lg *Graph) SetMarkerSize(size float32) {
 
-	lines := 1
-	if lg.chart.scaleX != nil {
-		lines = lg.chart.scaleX.lines
-	}
-	step := 1.0 / (float32(lines) * lg.chart.countStepX)
+	lg.marker = size
+	lg.updateData()
+}
 
-	positions := math32.NewArrayF32(0, 0)
-	rangeY := lg.chart.maxY - lg.chart.minY
-	for i := 0; i < len(lg.data); i++ {
-		px := float32(i) * step
-		vy := lg.data[i]
-		py := -1 + ((vy - lg.chart.minY) / rangeY)
-		positions.Append(px, py, 0)
+// SetBarWidth sets the width of the bars of bar graphs in X units.
+// The default width 0 uses 80% of the space between values.
+func (lg *Graph) SetBarWidth(width float32) {
+
+	lg.barWidth = width
+	lg.chart.updateGraphs()
+}
+
+// SetRightAxis sets whether the graph uses the right Y axis of the chart
+func (lg *Graph) SetRightAxis(right bool) {
+
+	lg.right = right
+	lg.chart.updateGraphs()
+}
+
+// SetStack stacks the values of this bar or area graph on the values of the
+// specified graph, or unstacks it if nil
+func (lg *Graph) SetStack(base *Graph) {
+
+	for g := base; g != nil; g = g.stack {
+		if g == lg {
+			panic("Graph stacked on itself")
+		}
 	}
-	lg.vbo.SetBuffer(positions)
-	lg.SetChanged(true)
+	lg.stack = base
+	lg.chart.updateGraphs()
+}
+
+// SetHistogram sets the data of a histogram graph to the histogram
+// of the specified values using the specified number of bins
+func (lg *Graph) SetHistogram(values []float32, bins int) {
+
+	lg.setHistogram(values, bins)
+	lg.chart.updateGraphs()
 }
 
 // recalc recalculates the position and width of the this panel
 func (lg *Graph) recalc() {
 
-	py := lg.chart.top
-	if lg.chart.title != nil {
-		py += lg.chart.title.Height()
-	}
-	px := lg.chart.left
-	w := lg.chart.ContentWidth() - lg.chart.left
-	h := lg.chart.ContentHeight() - py - lg.chart.bottom
+	px, py, w, h := lg.chart.plotArea()
 	lg.SetPosition(px, py)
 	lg.SetSize(w, h)
+	// The size of the markers depends on the panel size
+	if lg.kind == graphScatter {
+		lg.updateData()
+	}
 }
 
 // RenderSetup is called by the renderer before drawing this graphic
@@ -722,6 +984,7 @@ func (lg *Graph) RenderSetup(gs *gls.GLS, rinfo *core.RenderInfo) {
 type chartMaterial struct {
 	material.Material              // Embedded material
 	color             math32.Color // emissive color
+	opacity           float32      // opacity
 	uniColor          gls.Uniform  // color uniform location cache
 }
 
@@ -732,12 +995,13 @@ func (cm *chartMaterial) Init(color *math32.Color) {
 	cm.SetShaderUnique(true)
 	cm.uniColor.Init("MatColor")
 	cm.color = *color
+	cm.opacity = 1
 }
 
 func (cm *chartMaterial) RenderSetup(gs *gls.GLS) {
 
 	cm.Material.RenderSetup(gs)
-	gs.Uniform3f(cm.uniColor.Location(gs), cm.color.R, cm.color.G, cm.color.B)
+	gs.Uniform4f(cm.uniColor.Location(gs), cm.color.R, cm.color.G, cm.color.B, cm.opacity)
 }
 
 //
@@ -749,10 +1013,10 @@ const shaderChartVertex = `
 
 // Input uniforms
 uniform mat4 ModelMatrix;
-uniform vec3 MatColor;
+uniform vec4 MatColor;
 
 // Outputs for fragment shader
-out vec3 Color;
+out vec4 Color;
 
 void main() {
 
@@ -770,7 +1034,7 @@ void main() {
 //
 const shaderChartFrag = `
 // Input uniforms from vertex shader
-in vec3 Color;
+in vec4 Color;
 
 // Input uniforms
 uniform vec4 Bounds;
@@ -792,6 +1056,6 @@ void main() {
         discard;
     }
 
-    FragColor = vec4(Color, 1.0);
+    FragColor = Color;
 }
 `
@@ -0,0 +1,276 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gui
+
+import (
+	"math"
+
+	"github.com/sansebasko/engine/math32"
+)
+
+// SetRangeXauto sets the state of the auto range of the x scale.
+// If set, the x scale shows all the values of the graphs.
+func (ch *Chart) SetRangeXauto(auto bool) {
+
+	ch.autoX = auto
+	ch.updateGraphs()
+}
+
+// SetRollingWindow sets the maximum number of values kept by each graph
+// for streaming data appended with Graph.Append and Graph.AppendXY.
+// The oldest values are removed when the count is exceeded and the x scale
+// follows the last values of the graphs with evenly spaced x values.
+// A count of 0 keeps all the values.
+func (ch *Chart) SetRollingWindow(count int) {
+
+	ch.rolling = count
+	for _, graph := range ch.graphs {
+		graph.trim()
+	}
+	ch.updateGraphs()
+}
+
+// RollingWindow returns the maximum number of values kept by each graph
+func (ch *Chart) RollingWindow() int {
+
+	return ch.rolling
+}
+
+// Append appends the specified values to the data of a graph with evenly spaced x values
+func (lg *Graph) Append(values ...float32) {
+
+	if lg.dataX != nil {
+		panic("Append to graph with x values")
+	}
+	lg.data = append(lg.data, values...)
+	lg.trim()
+	lg.chart.updateGraphs()
+}
+
+// AppendXY appends a value with the specified x and y to the data of a graph
+func (lg *Graph) AppendXY(x, y float32) {
+
+	if lg.dataX == nil && len(lg.data) > 0 {
+		panic("AppendXY to graph with evenly spaced x values")
+	}
+	lg.dataX = append(lg.dataX, x)
+	lg.data = append(lg.data, y)
+	lg.trim()
+	lg.chart.updateGraphs()
+}
+
+// setData sets copies of the specified X values, or nil for evenly spaced
+// X values, and Y values as the data of this graph
+func (lg *Graph) setData(dataX, data []float32) {
+
+	if dataX != nil && len(dataX) != len(data) {
+		panic("Graph X and Y values of different lengths")
+	}
+	lg.data = append([]float32(nil), data...)
+	lg.dataX = nil
+	if dataX != nil {
+		lg.dataX = append([]float32{}, dataX...)
+	}
+}
+
+// trim removes the oldest values of this graph exceeding the chart rolling window
+func (lg *Graph) trim() {
+
+	count := lg.chart.rolling
+	if count <= 0 || len(lg.data) <= count {
+		return
+	}
+	// The values are moved to the start of the slices so they don't grow forever
+	drop := len(lg.data) - count
+	lg.data = lg.data[:copy(lg.data, lg.data[drop:])]
+	if lg.dataX != nil {
+		lg.dataX = lg.dataX[:copy(lg.dataX, lg.dataX[drop:])]
+	}
+	lg.offset += drop
+}
+
+// x returns the x value of the data value at the specified index
+func (lg *Graph) x(i int) float32 {
+
+	if lg.dataX != nil {
+		return lg.dataX[i]
+	}
+	return lg.chart.firstX + float32(lg.offset+i)*lg.chart.stepX/lg.chart.countStepX
+}
+
+// base returns the y value where the value at the specified index
+// of a stacked graph starts
+func (lg *Graph) base(i int) float32 {
+
+	var base float32
+	for g := lg.stack; g != nil; g = g.stack {
+		if i < len(g.data) {
+			base += g.data[i]
+		}
+	}
+	return base
+}
+
+// rangeY returns the visible range of the y axis used by this graph
+func (lg *Graph) rangeY() (float32, float32) {
+
+	if lg.right && lg.chart.y2 != nil {
+		return lg.chart.y2.vmin, lg.chart.y2.vmax
+	}
+	return lg.chart.vminY, lg.chart.vmaxY
+}
+
+// setHistogram sets the data of this graph to the histogram of the specified values
+func (lg *Graph) setHistogram(values []float32, bins int) {
+
+	if bins < 1 {
+		bins = 1
+	}
+	// NaN and infinite values are not counted
+	min := float32(math.MaxFloat32)
+	max := -float32(math.MaxFloat32)
+	count := 0
+	for _, v := range values {
+		if !finite(v) {
+			continue
+		}
+		if v < min {
+			min = v
+		}
+		if v > max {
+			max = v
+		}
+		count++
+	}
+	if count == 0 {
+		min, max = 0, 1
+	} else if min == max {
+		min -= 0.5
+		max += 0.5
+	}
+	width := (max - min) / float32(bins)
+	lg.dataX = make([]float32, bins)
+	lg.data = make([]float32, bins)
+	for i := range lg.dataX {
+		lg.dataX[i] = min + (float32(i)+0.5)*width
+	}
+	for _, v := range values {
+		if !finite(v) {
+			continue
+		}
+		bin := int((v - min) / width)
+		if bin >= bins {
+			bin = bins - 1
+		} else if bin < 0 {
+			bin = 0
+		}
+		lg.data[bin]++
+	}
+	lg.barWidth = width
+	lg.offset = 0
+}
+
+// bar returns the left x and width in x units of the bar at the specified index.
+// The bars of the graphs which are not stacked are drawn side by side.
+func (lg *Graph) bar(i int) (float32, float32) {
+
+	width := lg.barWidth
+	if width == 0 {
+		// Uses 80% of the smallest space between the values
+		space := lg.chart.stepX / lg.chart.countStepX
+		if lg.dataX != nil {
+			space = lg.chart.vmaxX - lg.chart.vminX
+			for j := 1; j < len(lg.dataX); j++ {
+				if d := lg.dataX[j] - lg.dataX[j-1]; d > 0 && d < space {
+					space = d
+				}
+			}
+		}
+		width = 0.8 * space
+	}
+
+	// Finds the slot of the bars of this graph among the bars of the unstacked graphs
+	root := lg
+	for root.stack != nil {
+		root = root.stack
+	}
+	slot := 0
+	slots := 0
+	for _, g := range lg.chart.graphs {
+		if g.kind != graphBar || g.stack != nil {
+			continue
+		}
+		if g == root {
+			slot = slots
+		}
+		slots++
+	}
+	if slots == 0 {
+		slots = 1
+	}
+	w := width / float32(slots)
+	return lg.x(i) - width/2 + float32(slot)*w, w
+}
+
+// updateData regenerates the lines or triangles for the current data
+func (lg *Graph) updateData() {
+
+	ch := lg.chart
+	minY, maxY := lg.rangeY()
+	rangeX := ch.vmaxX - ch.vminX
+	rangeY := maxY - minY
+	if rangeX == 0 || rangeY == 0 {
+		return
+	}
+	// Converts values to the panel coordinates: x from 0 to 1 and y from -1 to 0
+	nx := func(vx float32) float32 { return (vx - ch.vminX) / rangeX }
+	ny := func(vy float32) float32 { return -1 + (vy-minY)/rangeY }
+	quad := func(positions *math32.ArrayF32, x0, y0, x1, y1 float32) {
+		positions.Append(x0, y0, 0, x1, y0, 0, x1, y1, 0)
+		positions.Append(x0, y0, 0, x1, y1, 0, x0, y1, 0)
+	}
+
+	positions := math32.NewArrayF32(0, 0)
+	switch lg.kind {
+	case graphLine:
+		for i := 0; i < len(lg.data); i++ {
+			positions.Append(nx(lg.x(i)), ny(lg.data[i]), 0)
+		}
+	case graphScatter:
+		hx := float32(0)
+		hy := float32(0)
+		if lg.width > 0 && lg.height > 0 {
+			hx = lg.marker / 2 / lg.width
+			hy = lg.marker / 2 / lg.height
+		}
+		for i := 0; i < len(lg.data); i++ {
+			px := nx(lg.x(i))
+			py := ny(lg.data[i])
+			quad(&positions, px-hx, py-hy, px+hx, py+hy)
+		}
+	case graphBar:
+		for i := 0; i < len(lg.data); i++ {
+			x, w := lg.bar(i)
+			base := lg.base(i)
+			quad(&positions, nx(x), ny(base), nx(x+w), ny(base+lg.data[i]))
+		}
+	case graphArea:
+		for i := 0; i+1 < len(lg.data); i++ {
+			x0, x1 := nx(lg.x(i)), nx(lg.x(i+1))
+			b0, b1 := lg.base(i), lg.base(i+1)
+			t0, t1 := ny(b0+lg.data[i]), ny(b1+lg.data[i+1])
+			positions.Append(x0, ny(b0), 0, x1, ny(b1), 0, x1, t1, 0)
+			positions.Append(x0, ny(b0), 0, x1, t1, 0, x0, t0, 0)
+		}
+	}
+	lg.vbo.SetBuffer(positions)
+	lg.SetChanged(true)
+}
+
+// finite returns if the specified value is not NaN or infinite
+func finite(v float32) bool {
+
+	return !math.IsNaN(float64(v)) && !math.IsInf(float64(v), 0)
+}
@@ -0,0 +1,523 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gui
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/sansebasko/engine/math32"
+	"github.com/sansebasko/engine/window"
+)
+
+// When zoom and pan are enabled, the mouse wheel over the graphs zooms the x scale
+// around the cursor and over the y scales zooms the y scales, and dragging the graphs
+// with the left mouse button pans the x scale and dragging the y scales pans the y scales.
+// Double clicking the chart returns to the default ranges.
+
+// chartAxisY describes the optional right Y axis of a chart
+type chartAxisY struct {
+	min    float32  // Minimum Y value
+	max    float32  // Maximum Y value
+	vmin   float32  // Minimum visible Y value
+	vmax   float32  // Maximum visible Y value
+	auto   bool     // Auto range flag
+	format string   // String format for the labels
+	labels []*Label // Array of labels
+}
+
+// chartPan describes the current pan of a chart with the mouse
+type chartPan struct {
+	x, y   float32 // Initial cursor position
+	scaleY bool    // Pans the y scales
+	vminX  float32 // Initial visible x range
+	vmaxX  float32
+	vminY  float32 // Initial visible y range
+	vmaxY  float32
+	vminY2 float32 // Initial visible right y range
+	vmaxY2 float32
+}
+
+// ChartPoint describes a data value of a chart graph
+type ChartPoint struct {
+	Graph *Graph  // Graph
+	Index int     // Index of the value in the graph data
+	X     float32 // X value
+	Y     float32 // Y value
+}
+
+const (
+	chartPointDist   = 8                      // Maximum distance in pixels to find data points under the cursor
+	chartDoubleClick = 400 * time.Millisecond // Maximum time between the clicks of a double click
+	chartZoomFactor  = 0.8                    // Zoom factor for each mouse wheel step
+)
+
+// SetScaleY2 sets a right Y scale with the specified labels string format.
+// The right scale labels are shown at the lines of the Y scale and the graphs
+// are assigned to the right scale with Graph.SetRightAxis.
+func (ch *Chart) SetScaleY2(format string) {
+
+	if ch.y2 == nil {
+		ch.y2 = &chartAxisY{min: -10, max: 10}
+		if ch.right == 0 {
+			ch.right = 40
+		}
+	}
+	ch.y2.format = format
+	ch.recalc()
+	ch.updateGraphs()
+}
+
+// ClearScaleY2 removes the right Y scale if it was previously set
+func (ch *Chart) ClearScaleY2() {
+
+	if ch.y2 == nil {
+		return
+	}
+	for _, label := range ch.y2.labels {
+		ch.Remove(label)
+		label.Dispose()
+	}
+	ch.y2 = nil
+	ch.right = 0
+	ch.recalc()
+	ch.updateGraphs()
+}
+
+// SetMarginY2 sets the right y scale margin
+func (ch *Chart) SetMarginY2(right float32) {
+
+	ch.right = right
+	ch.recalc()
+}
+
+// SetRangeY2 sets the minimum and maximum values of the right y scale
+func (ch *Chart) SetRangeY2(min float32, max float32) {
+
+	if ch.y2 == nil || ch.y2.auto {
+		return
+	}
+	ch.y2.min = min
+	ch.y2.max = max
+	ch.updateGraphs()
+}
+
+// SetRangeY2auto sets the state of the auto range of the right y scale
+func (ch *Chart) SetRangeY2auto(auto bool) {
+
+	if ch.y2 == nil {
+		return
+	}
+	ch.y2.auto = auto
+	ch.updateGraphs()
+}
+
+// RangeY2 returns the current right y range
+func (ch *Chart) RangeY2() (minY, maxY float32) {
+
+	if ch.y2 == nil {
+		return 0, 0
+	}
+	return ch.y2.min, ch.y2.max
+}
+
+// calcRangeY2 calculates the minimum and maximum values of the right y scale
+func (ch *Chart) calcRangeY2() {
+
+	if ch.y2 == nil {
+		return
+	}
+	if ch.y2.auto {
+		if minY, maxY, ok := ch.dataRangeY(true); ok {
+			ch.y2.min = minY
+			ch.y2.max = maxY
+		}
+	}
+	if !ch.zoomY {
+		ch.y2.vmin = ch.y2.min
+		ch.y2.vmax = ch.y2.max
+	}
+}
+
+// updateLabelsY2 updates the right Y scale labels text and positions
+func (ch *Chart) updateLabelsY2() {
+
+	if ch.y2 == nil || ch.scaleY == nil {
+		return
+	}
+	nlines := ch.scaleY.lines
+	for len(ch.y2.labels) < nlines {
+		l := NewLabel("")
+		l.SetColor4(math32.NewColor4("black"))
+		l.SetFontSize(ch.fontSizeY)
+		ch.Add(l)
+		ch.y2.labels = append(ch.y2.labels, l)
+	}
+	for len(ch.y2.labels) > nlines {
+		l := ch.y2.labels[len(ch.y2.labels)-1]
+		ch.Remove(l)
+		l.Dispose()
+		ch.y2.labels = ch.y2.labels[:len(ch.y2.labels)-1]
+	}
+
+	px, _, width, height := ch.plotArea()
+	vstep := (ch.y2.vmax - ch.y2.vmin) / float32(nlines-1)
+	pstep := height / float32(nlines-1)
+	value := ch.y2.vmin
+	for i := 0; i < nlines; i++ {
+		label := ch.y2.labels[i]
+		label.SetText(fmt.Sprintf(ch.y2.format, value))
+		py := ch.ContentHeight() - ch.bottom - float32(i)*pstep
+		label.SetPosition(px+width+4, py-label.Height()/2)
+		value += vstep
+	}
+}
+
+// SetLegend sets whether the chart shows a legend with the names of its graphs
+func (ch *Chart) SetLegend(show bool) {
+
+	if !show {
+		if ch.legend != nil {
+			ch.Remove(ch.legend)
+			ch.legend.DisposeChildren(true)
+			ch.legend.Dispose()
+			ch.legend = nil
+		}
+		return
+	}
+	if ch.legend == nil {
+		ch.legend = NewPanel(0, 0)
+		ch.legend.SetBorders(1, 1, 1, 1)
+		ch.legend.SetPaddings(2, 4, 2, 4)
+		ch.legend.SetBordersColor4(math32.NewColor4("gray"))
+		ch.legend.SetColor4(&math32.Color4{1, 1, 1, 0.8})
+		ch.Add(ch.legend)
+	}
+	ch.updateLegend()
+}
+
+// updateLegend rebuilds the legend items for the current graphs
+func (ch *Chart) updateLegend() {
+
+	if ch.legend == nil {
+		return
+	}
+	ch.legend.DisposeChildren(true)
+	for _, graph := range ch.graphs {
+		if graph.name == "" {
+			continue
+		}
+		swatch := NewPanel(10, 10)
+		swatch.SetColor(&graph.color)
+		ch.legend.Add(swatch)
+		label := NewLabel(graph.name)
+		label.SetColor4(math32.NewColor4("black"))
+		label.SetFontSize(ch.fontSizeY)
+		ch.legend.Add(label)
+	}
+	ch.recalcLegend()
+}
+
+// recalcLegend sets the positions of the legend items and
+// places the legend at the top right corner of the graphs
+func (ch *Chart) recalcLegend() {
+
+	if ch.legend == nil {
+		return
+	}
+	items := ch.legend.Children()
+	ch.legend.SetVisible(len(items) > 0)
+	var width, py float32
+	for i := 0; i+1 < len(items); i += 2 {
+		swatch := items[i].(*Panel)
+		label := items[i+1].(*Label)
+		swatch.SetPosition(0, py+(label.Height()-swatch.Height())/2)
+		label.SetPosition(swatch.Width()+4, py)
+		if w := label.Position().X + label.Width(); w > width {
+			width = w
+		}
+		py += label.Height()
+	}
+	ch.legend.SetContentSize(width, py)
+	px, y, w, _ := ch.plotArea()
+	ch.legend.SetPosition(px+w-ch.legend.Width()-4, y+4)
+	ch.SetTopChild(ch.legend)
+	if ch.inspect != nil {
+		ch.SetTopChild(ch.inspect)
+	}
+}
+
+// SetInspection sets whether the chart shows the data value under the mouse cursor
+func (ch *Chart) SetInspection(enable bool) {
+
+	if !enable {
+		if ch.inspect != nil {
+			ch.Remove(ch.inspect)
+			ch.inspect.Dispose()
+			ch.inspect = nil
+		}
+		return
+	}
+	if ch.inspect == nil {
+		ch.inspect = NewLabel("")
+		ch.inspect.SetColor4(math32.NewColor4("black"))
+		ch.inspect.SetBgColor4(&math32.Color4{1, 1, 0.85, 1})
+		ch.inspect.SetBorders(1, 1, 1, 1)
+		ch.inspect.SetBordersColor4(math32.NewColor4("gray"))
+		ch.inspect.SetPaddings(1, 3, 1, 3)
+		ch.inspect.SetVisible(false)
+		ch.Add(ch.inspect)
+	}
+}
+
+// SetZoomPan sets whether the chart scales can be zoomed and panned with the mouse
+func (ch *Chart) SetZoomPan(enable bool) {
+
+	ch.zoomPan = enable
+	if !enable && ch.pan != nil {
+		ch.pan = nil
+		ch.root.SetMouseFocus(nil)
+	}
+}
+
+// ResetView returns the visible ranges of the chart scales to their
+// ranges before being zoomed or panned
+func (ch *Chart) ResetView() {
+
+	ch.zoomX = false
+	ch.zoomY = false
+	ch.updateGraphs()
+}
+
+// ViewRange returns the currently visible ranges of the x and left y scales
+func (ch *Chart) ViewRange() (minX, maxX, minY, maxY float32) {
+
+	return ch.vminX, ch.vmaxX, ch.vminY, ch.vmaxY
+}
+
+// PointAt returns the graph data value nearest to the specified window position
+// and if it was found. The bars of bar graphs are found if the position is over them.
+func (ch *Chart) PointAt(x, y float32) (ChartPoint, bool) {
+
+	cx, cy := ch.ContentCoords(x, y)
+	px, py, width, height := ch.plotArea()
+	cx -= px
+	cy -= py
+	if cx < 0 || cx > width || cy < 0 || cy > height || ch.vmaxX == ch.vminX {
+		return ChartPoint{}, false
+	}
+	var found ChartPoint
+	best := float32(chartPointDist * chartPointDist)
+	// The last graphs are drawn over the first
+	for gi := len(ch.graphs) - 1; gi >= 0; gi-- {
+		graph := ch.graphs[gi]
+		minY, maxY := graph.rangeY()
+		if maxY == minY || !graph.Visible() {
+			continue
+		}
+		gx := func(vx float32) float32 { return (vx - ch.vminX) / (ch.vmaxX - ch.vminX) * width }
+		gy := func(vy float32) float32 { return (1 - (vy-minY)/(maxY-minY)) * height }
+		for i := 0; i < len(graph.data); i++ {
+			vy := graph.base(i) + graph.data[i]
+			if graph.kind == graphBar {
+				bx, bw := graph.bar(i)
+				y0, y1 := gy(graph.base(i)), gy(vy)
+				if cx >= gx(bx) && cx <= gx(bx+bw) && cy >= math32.Min(y0, y1) && cy <= math32.Max(y0, y1) {
+					return ChartPoint{graph, i, graph.x(i), graph.data[i]}, true
+				}
+				continue
+			}
+			dx := gx(graph.x(i)) - cx
+			dy := gy(vy) - cy
+			if d := dx*dx + dy*dy; d < best {
+				best = d
+				found = ChartPoint{graph, i, graph.x(i), graph.data[i]}
+			}
+		}
+	}
+	return found, found.Graph != nil
+}
+
+// updateInspect shows the data value under the cursor in the inspection label
+func (ch *Chart) updateInspect() {
+
+	if ch.inspect == nil {
+		return
+	}
+	p, ok := ch.PointAt(ch.cursorX, ch.cursorY)
+	if !ok || ch.pan != nil {
+		ch.inspect.SetVisible(false)
+		return
+	}
+	formatY := ch.formatY
+	if p.Graph.right && ch.y2 != nil {
+		formatY = ch.y2.format
+	}
+	text := fmt.Sprintf(ch.formatX+", "+formatY, p.X, p.Y)
+	if p.Graph.name != "" {
+		text = p.Graph.name + ": " + text
+	}
+	ch.inspect.SetText(text)
+	// Shows the label above and to the right of the cursor inside the chart
+	cx, cy := ch.ContentCoords(ch.cursorX, ch.cursorY)
+	x := cx + 10
+	if x+ch.inspect.Width() > ch.ContentWidth() {
+		x = cx - 10 - ch.inspect.Width()
+	}
+	y := cy - 10 - ch.inspect.Height()
+	if y < 0 {
+		y = cy + 16
+	}
+	ch.inspect.SetPosition(x, y)
+	ch.inspect.SetVisible(true)
+	ch.SetTopChild(ch.inspect)
+}
+
+// onCursor process subscribed cursor events
+func (ch *Chart) onCursor(evname string, ev interface{}) {
+
+	switch evname {
+	case OnCursorEnter:
+		if ch.zoomPan {
+			ch.root.SetScrollFocus(ch)
+		}
+	case OnCursorLeave:
+		if ch.root.scrollFocus == ch {
+			ch.root.SetScrollFocus(nil)
+		}
+		if ch.inspect != nil {
+			ch.inspect.SetVisible(false)
+		}
+	case OnCursor:
+		cev := ev.(*window.CursorEvent)
+		ch.cursorX = cev.Xpos
+		ch.cursorY = cev.Ypos
+		if ch.pan != nil {
+			ch.panTo(cev.Xpos, cev.Ypos)
+			ch.root.StopPropagation(Stop3D)
+			return
+		}
+		ch.updateInspect()
+	}
+}
+
+// onMouse process subscribed mouse events
+func (ch *Chart) onMouse(evname string, ev interface{}) {
+
+	if !ch.zoomPan {
+		return
+	}
+	mev := ev.(*window.MouseEvent)
+	switch evname {
+	case OnMouseDown:
+		if mev.Button != window.MouseButtonLeft {
+			return
+		}
+		now := time.Now()
+		if now.Sub(ch.clickTime) < chartDoubleClick {
+			ch.clickTime = time.Time{}
+			ch.ResetView()
+			break
+		}
+		ch.clickTime = now
+		scaleY, ok := ch.zoomArea(mev.Xpos, mev.Ypos)
+		if !ok {
+			return
+		}
+		ch.pan = &chartPan{x: mev.Xpos, y: mev.Ypos, scaleY: scaleY,
+			vminX: ch.vminX, vmaxX: ch.vmaxX, vminY: ch.vminY, vmaxY: ch.vmaxY}
+		if ch.y2 != nil {
+			ch.pan.vminY2 = ch.y2.vmin
+			ch.pan.vmaxY2 = ch.y2.vmax
+		}
+		ch.root.SetMouseFocus(ch)
+		if ch.inspect != nil {
+			ch.inspect.SetVisible(false)
+		}
+	case OnMouseUp:
+		if ch.pan == nil {
+			return
+		}
+		ch.pan = nil
+		ch.root.SetMouseFocus(nil)
+	default:
+		return
+	}
+	ch.root.StopPropagation(Stop3D)
+}
+
+// onScroll process subscribed scroll events
+func (ch *Chart) onScroll(evname string, ev interface{}) {
+
+	if !ch.zoomPan {
+		return
+	}
+	scaleY, ok := ch.zoomArea(ch.cursorX, ch.cursorY)
+	if !ok {
+		return
+	}
+	sev := ev.(*window.ScrollEvent)
+	factor := float32(math.Pow(chartZoomFactor, float64(sev.Yoffset)))
+	cx, cy := ch.ContentCoords(ch.cursorX, ch.cursorY)
+	px, py, width, height := ch.plotArea()
+	if scaleY {
+		// Zooms all the y scales around the cursor
+		f := 1 - (cy-py)/height
+		ch.vminY, ch.vmaxY = chartZoom(ch.vminY, ch.vmaxY, f, factor)
+		if ch.y2 != nil {
+			ch.y2.vmin, ch.y2.vmax = chartZoom(ch.y2.vmin, ch.y2.vmax, f, factor)
+		}
+		ch.zoomY = true
+	} else {
+		ch.vminX, ch.vmaxX = chartZoom(ch.vminX, ch.vmaxX, (cx-px)/width, factor)
+		ch.zoomX = true
+	}
+	ch.updateGraphs()
+	ch.updateInspect()
+	ch.root.StopPropagation(Stop3D)
+}
+
+// zoomArea returns if the specified window position is over the graphs
+// or over the y scales (scaleY true) of this chart where it can be zoomed or panned
+func (ch *Chart) zoomArea(x, y float32) (scaleY bool, ok bool) {
+
+	cx, cy := ch.ContentCoords(x, y)
+	px, py, width, height := ch.plotArea()
+	if cy < py || cy > py+height || cx < 0 || cx > ch.ContentWidth() {
+		return false, false
+	}
+	return cx < px || cx > px+width, true
+}
+
+// panTo pans the chart scales for the specified cursor position
+func (ch *Chart) panTo(x, y float32) {
+
+	p := ch.pan
+	_, _, width, height := ch.plotArea()
+	if p.scaleY {
+		f := (y - p.y) / height
+		d := f * (p.vmaxY - p.vminY)
+		ch.vminY, ch.vmaxY = p.vminY+d, p.vmaxY+d
+		if ch.y2 != nil {
+			d = f * (p.vmaxY2 - p.vminY2)
+			ch.y2.vmin, ch.y2.vmax = p.vminY2+d, p.vmaxY2+d
+		}
+		ch.zoomY = true
+	} else {
+		d := (x - p.x) / width * (p.vmaxX - p.vminX)
+		ch.vminX, ch.vmaxX = p.vminX-d, p.vmaxX-d
+		ch.zoomX = true
+	}
+	ch.updateGraphs()
+}
+
+// chartZoom returns the specified range zoomed by the specified factor
+// around the value at the specified fraction of the range
+func chartZoom(min, max, f, factor float32) (float32, float32) {
+
+	center := min + f*(max-min)
+	return center - (center-min)*factor, center + (max-center)*factor
+}
@@ -90,6 +90,9 @@ func newCheckRadio(check bool, text string) *CheckRadio {
 	cb.Panel.Subscribe(OnCursorLeave, cb.onCursor)
 	cb.Panel.Subscribe(OnMouseDown, cb.onMouse)
 	cb.Panel.Subscribe(OnEnable, func(evname string, ev interface{}) { cb.update() })
+	cb.Panel.Subscribe(OnFocus, func(evname string, ev interface{}) { cb.update() })
+	cb.Panel.Subscribe(OnFocusLost, func(evname string, ev interface{}) { cb.update() })
+	cb.Panel.SetFocusable(true)
 
 	// Creates label
 	cb.Label = NewLabel(text)
@@ -284,7 +287,7 @@ func (cb *CheckRadio) onCursor(evname string, ev interface{}) {
 func (cb *CheckRadio) onKey(evname string, ev interface{}) {
 
 	kev := ev.(*window.KeyEvent)
-	if evname == OnKeyDown && kev.Keycode == window.KeyEnter {
+	if evname == OnKeyDown && activateKey(kev.Keycode) {
 		cb.toggleState()
 		cb.update()
 		cb.Dispatch(OnClick, nil)
@@ -311,6 +314,10 @@ func (cb *CheckRadio) update() {
 		cb.applyStyle(&cb.styles.Over)
 		return
 	}
+	if cb.root != nil && cb.root.HasKeyFocus(cb) {
+		cb.applyStyle(&cb.styles.Focus)
+		return
+	}
 	cb.applyStyle(&cb.styles.Normal)
 }
 
@@ -0,0 +1,487 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gui
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/sansebasko/engine/math32"
+	"github.com/sansebasko/engine/window"
+)
+
+/***************************************
+
+ ColorPicker
+ +-----------------------+---+---+
+ |                       |   |   |
+ |  saturation/value     | H | A |
+ |                       |   |   |
+ +-----------------------+---+---+
+ | swatch | #rrggbbaa            |
+ +--------------------------------+
+ | R [   ] G [   ] B [   ] A [   ]|
+ +--------------------------------+
+
+**/
+
+// ColorPicker is the GUI element for choosing RGBA colors.
+// The color can be picked in a saturation/value square, a hue bar and an alpha bar,
+// typed as hexadecimal text or set by spinners of its red, green, blue and alpha
+// components from 0 to 255. It dispatches OnChange when its color changes.
+type ColorPicker struct {
+	Panel                       // Embedded panel
+	sv        *Image            // saturation/value square
+	hue       *Image            // hue bar
+	alpha     *Image            // alpha bar
+	svMark    Panel             // marker of the saturation/value square
+	hueMark   Panel             // marker of the hue bar
+	alphaMark Panel             // marker of the alpha bar
+	swatch    Panel             // shows the current color
+	hex       *Edit             // hexadecimal text of the color
+	labels    [4]*Label         // labels of the component spinners
+	spins     [4]*Spinner       // spinners of the red, green, blue and alpha components
+	styles    *ColorPickerStyle // pointer to style
+	h, s, v   float32           // current hue, saturation and value from 0 to 1
+	a         float32           // current alpha from 0 to 1
+	svHue     float32           // hue of the current saturation/value image
+	dragging  *Image            // image being dragged or nil
+	setting   bool              // sub widgets being set by the color picker
+}
+
+// ColorPickerStyle contains the styling of a ColorPicker
+type ColorPickerStyle struct {
+	PanelStyle
+	MarkerColor math32.Color4 // border color of the markers
+	Spacing     float32       // space between the internal panels
+	BarWidth    float32       // width of the hue and alpha bars
+}
+
+const (
+	colorPickerSVSize  = 64  // size of the saturation/value image
+	colorPickerBarSize = 128 // height of the hue and alpha images
+	colorPickerMark    = 8   // size of the saturation/value marker
+)
+
+// NewColorPicker creates and returns a pointer to a new color picker
+// with the specified initial dimensions and an opaque white color.
+func NewColorPicker(width, height float32) *ColorPicker {
+
+	cp := new(ColorPicker)
+	cp.styles = &StyleDefault().ColorPicker
+	cp.a = 1
+	cp.v = 1
+	cp.svHue = -1
+
+	cp.Panel.Initialize(width, height)
+	cp.Panel.Subscribe(OnResize, func(evname string, ev interface{}) { cp.recalc() })
+
+	// Initialize images
+	cp.sv = NewImageFromRGBA(image.NewRGBA(image.Rect(0, 0, colorPickerSVSize, colorPickerSVSize)))
+	cp.hue = NewImageFromRGBA(image.NewRGBA(image.Rect(0, 0, 1, colorPickerBarSize)))
+	cp.alpha = NewImageFromRGBA(image.NewRGBA(image.Rect(0, 0, 1, colorPickerBarSize)))
+	rgba := cp.hue.RGBA()
+	for y := 0; y < colorPickerBarSize; y++ {
+		r, g, b := hsv2rgb(float32(y)/colorPickerBarSize, 1, 1)
+		rgba.Set(0, y, color.RGBA{f2byte(r), f2byte(g), f2byte(b), 255})
+	}
+	cp.hue.UpdateData()
+	for _, img := range []*Image{cp.sv, cp.hue, cp.alpha} {
+		img.Subscribe(OnMouseDown, cp.onMouse)
+		img.Subscribe(OnMouseUp, cp.onMouse)
+		img.Subscribe(OnCursor, cp.onCursor)
+		cp.Panel.Add(img)
+	}
+
+	// Initialize markers
+	cp.svMark.Initialize(colorPickerMark, colorPickerMark)
+	cp.sv.Add(&cp.svMark)
+	cp.hueMark.Initialize(0, 0)
+	cp.hue.Add(&cp.hueMark)
+	cp.alphaMark.Initialize(0, 0)
+	cp.alpha.Add(&cp.alphaMark)
+
+	// Initialize swatch and hexadecimal edit
+	cp.swatch.Initialize(0, 0)
+	cp.Panel.Add(&cp.swatch)
+	cp.hex = NewEdit(0, "#rrggbbaa")
+	cp.hex.MaxLength = 9
+	cp.hex.Subscribe(OnChange, cp.onHex)
+	cp.hex.Subscribe(OnKeyDown, func(evname string, ev interface{}) {
+		kev := ev.(*window.KeyEvent)
+		if kev.Keycode == window.KeyEnter || kev.Keycode == window.KeyKPEnter {
+			cp.refresh(nil)
+		}
+	})
+	cp.hex.Subscribe(OnMouseOut, func(evname string, ev interface{}) { cp.refresh(nil) })
+	cp.Panel.Add(cp.hex)
+
+	// Initialize component spinners
+	for i, name := range []string{"R", "G", "B", "A"} {
+		cp.labels[i] = NewLabel(name)
+		cp.Panel.Add(cp.labels[i])
+		cp.spins[i] = NewSpinner(0, 0, 255, 1)
+		cp.spins[i].Subscribe(OnChange, cp.onSpinner)
+		cp.Panel.Add(cp.spins[i])
+	}
+
+	cp.refresh(nil)
+	cp.update()
+	return cp
+}
+
+// SetStyles set the color picker style overriding the default style
+func (cp *ColorPicker) SetStyles(cs *ColorPickerStyle) *ColorPicker {
+
+	cp.styles = cs
+	cp.update()
+	return cp
+}
+
+// SetColor4 sets the current color of the color picker
+func (cp *ColorPicker) SetColor4(c *math32.Color4) *ColorPicker {
+
+	cp.setRGBA(c.R, c.G, c.B, c.A, nil)
+	return cp
+}
+
+// SetColor sets the current color of the color picker with opaque alpha
+func (cp *ColorPicker) SetColor(c *math32.Color) *ColorPicker {
+
+	cp.setRGBA(c.R, c.G, c.B, 1, nil)
+	return cp
+}
+
+// Color4 returns the current color of the color picker
+func (cp *ColorPicker) Color4() math32.Color4 {
+
+	r, g, b := hsv2rgb(cp.h, cp.s, cp.v)
+	return math32.Color4{r, g, b, cp.a}
+}
+
+// Hex returns the current color as hexadecimal text in the "#rrggbb"
+// format or "#rrggbbaa" if the color is not opaque.
+func (cp *ColorPicker) Hex() string {
+
+	c := cp.Color4()
+	text := fmt.Sprintf("#%02x%02x%02x", f2byte(c.R), f2byte(c.G), f2byte(c.B))
+	if f2byte(c.A) != 255 {
+		text += fmt.Sprintf("%02x", f2byte(c.A))
+	}
+	return text
+}
+
+// setRGBA sets the current color from the specified components
+// and updates the sub widgets other than the specified source.
+func (cp *ColorPicker) setRGBA(r, g, b, a float32, src interface{}) {
+
+	h, s, v := rgb2hsv(r, g, b)
+	// Keeps the current hue and saturation if they are undefined for the color
+	if v == 0 {
+		h, s = cp.h, cp.s
+	} else if s == 0 {
+		h = cp.h
+	}
+	cp.setHSVA(h, s, v, a, src)
+}
+
+// setHSVA sets the current color from the specified components
+// and updates the sub widgets other than the specified source.
+func (cp *ColorPicker) setHSVA(h, s, v, a float32, src interface{}) {
+
+	h = math32.Clamp(h, 0, 1)
+	s = math32.Clamp(s, 0, 1)
+	v = math32.Clamp(v, 0, 1)
+	a = math32.Clamp(a, 0, 1)
+	if h == cp.h && s == cp.s && v == cp.v && a == cp.a {
+		return
+	}
+	cp.h, cp.s, cp.v, cp.a = h, s, v, a
+	cp.refresh(src)
+	cp.Dispatch(OnChange, nil)
+}
+
+// refresh updates the sub widgets other than the specified source from the current color
+func (cp *ColorPicker) refresh(src interface{}) {
+
+	c := cp.Color4()
+	cp.setting = true
+	if src != cp.hex {
+		if text := cp.Hex(); text != cp.hex.Text() {
+			cp.hex.SetText(text)
+		}
+	}
+	for i, comp := range []float32{c.R, c.G, c.B, c.A} {
+		if src != cp.spins[i] {
+			cp.spins[i].SetValue(float32(f2byte(comp)))
+		}
+	}
+	cp.setting = false
+	cp.swatch.SetColor4(&c)
+
+	// Saturation/value image is only generated when the hue changes
+	if cp.h != cp.svHue {
+		cp.svHue = cp.h
+		rgba := cp.sv.RGBA()
+		for y := 0; y < colorPickerSVSize; y++ {
+			for x := 0; x < colorPickerSVSize; x++ {
+				r, g, b := hsv2rgb(cp.h, float32(x)/(colorPickerSVSize-1), 1-float32(y)/(colorPickerSVSize-1))
+				rgba.Set(x, y, color.RGBA{f2byte(r), f2byte(g), f2byte(b), 255})
+			}
+		}
+		cp.sv.UpdateData()
+	}
+
+	// Alpha image goes from the opaque color at the top to transparent at the bottom
+	rgba := cp.alpha.RGBA()
+	for y := 0; y < colorPickerBarSize; y++ {
+		a := 1 - float32(y)/(colorPickerBarSize-1)
+		rgba.Set(0, y, color.NRGBA{f2byte(c.R), f2byte(c.G), f2byte(c.B), f2byte(a)})
+	}
+	cp.alpha.UpdateData()
+	cp.recalcMarkers()
+}
+
+// onHex process the changes of the hexadecimal text typed by the user
+func (cp *ColorPicker) onHex(evname string, ev interface{}) {
+
+	if cp.setting {
+		return
+	}
+	// Incomplete or invalid texts are kept until corrected or the edit loses the focus
+	c, ok := parseHexColor(cp.hex.Text())
+	if !ok {
+		return
+	}
+	cp.setRGBA(c.R, c.G, c.B, c.A, cp.hex)
+}
+
+// onSpinner process the changes of the component spinners
+func (cp *ColorPicker) onSpinner(evname string, ev interface{}) {
+
+	if cp.setting {
+		return
+	}
+	var src interface{}
+	var comps [4]float32
+	for i, sp := range cp.spins {
+		comps[i] = sp.Value() / 255
+		if sp.Value() != float32(f2byte(cp.component(i))) {
+			src = sp
+		}
+	}
+	cp.setRGBA(comps[0], comps[1], comps[2], comps[3], src)
+}
+
+// component returns the current value of the specified component from 0 to 1
+func (cp *ColorPicker) component(i int) float32 {
+
+	c := cp.Color4()
+	return [4]float32{c.R, c.G, c.B, c.A}[i]
+}
+
+// onMouse process subscribed mouse events over the images
+func (cp *ColorPicker) onMouse(evname string, ev interface{}) {
+
+	mev := ev.(*window.MouseEvent)
+	if mev.Button != window.MouseButtonLeft {
+		return
+	}
+	switch evname {
+	case OnMouseDown:
+		for _, img := range []*Image{cp.sv, cp.hue, cp.alpha} {
+			if img.InsideBorders(mev.Xpos, mev.Ypos) {
+				cp.dragging = img
+				cp.root.SetMouseFocus(img)
+				cp.pick(mev.Xpos, mev.Ypos)
+				break
+			}
+		}
+	case OnMouseUp:
+		cp.dragging = nil
+		cp.root.SetMouseFocus(nil)
+	}
+	cp.root.StopPropagation(Stop3D)
+}
+
+// onCursor process subscribed cursor events over the images
+func (cp *ColorPicker) onCursor(evname string, ev interface{}) {
+
+	if cp.dragging == nil {
+		return
+	}
+	cev := ev.(*window.CursorEvent)
+	cp.pick(cev.Xpos, cev.Ypos)
+	cp.root.StopPropagation(Stop3D)
+}
+
+// pick sets the color from the specified position over the dragged image
+func (cp *ColorPicker) pick(x, y float32) {
+
+	img := cp.dragging
+	px := math32.Clamp((x-img.pospix.X)/img.Width(), 0, 1)
+	py := math32.Clamp((y-img.pospix.Y)/img.Height(), 0, 1)
+	switch img {
+	case cp.sv:
+		cp.setHSVA(cp.h, px, 1-py, cp.a, nil)
+	case cp.hue:
+		// Hue 1 is the same as hue 0 and would be shown at the top of the hue bar
+		cp.setHSVA(math32.Min(py, 0.999), cp.s, cp.v, cp.a, nil)
+	case cp.alpha:
+		cp.setHSVA(cp.h, cp.s, cp.v, 1-py, nil)
+	}
+}
+
+// update updates the color picker visual state
+func (cp *ColorPicker) update() {
+
+	cs := cp.styles
+	cp.Panel.ApplyStyle(&cs.PanelStyle)
+	transparent := math32.Color4{0, 0, 0, 0}
+	for _, mark := range []*Panel{&cp.svMark, &cp.hueMark, &cp.alphaMark} {
+		mark.SetBorders(1, 1, 1, 1)
+		mark.SetBordersColor4(&cs.MarkerColor)
+		mark.SetColor4(&transparent)
+	}
+	cp.swatch.SetBorders(1, 1, 1, 1)
+	cp.swatch.SetBordersColor4(&cs.MarkerColor)
+	cp.recalc()
+}
+
+// recalc recalculates the dimensions and positions of the internal panels.
+func (cp *ColorPicker) recalc() {
+
+	sp := cp.styles.Spacing
+	bw := cp.styles.BarWidth
+	width := cp.ContentWidth()
+	rowh := cp.hex.Height()
+
+	// Saturation/value square and bars
+	sqw := math32.Max(width-2*(bw+sp), 0)
+	sqh := math32.Max(cp.ContentHeight()-2*(rowh+sp), 0)
+	cp.sv.SetPosition(0, 0)
+	cp.sv.SetSize(sqw, sqh)
+	cp.hue.SetPosition(sqw+sp, 0)
+	cp.hue.SetSize(bw, sqh)
+	cp.alpha.SetPosition(sqw+bw+2*sp, 0)
+	cp.alpha.SetSize(bw, sqh)
+
+	// Swatch and hexadecimal edit
+	py := sqh + sp
+	cp.swatch.SetPosition(0, py)
+	cp.swatch.SetSize(2*rowh, rowh)
+	hx := 2*rowh + sp
+	cp.hex.setWidth(width - hx)
+	cp.hex.SetPosition(hx, py)
+
+	// Component labels and spinners
+	py += rowh + sp
+	gw := (width - 3*sp) / 4
+	for i, l := range cp.labels {
+		px := float32(i) * (gw + sp)
+		l.SetPosition(px, py+(rowh-l.Height())/2)
+		lw := l.Width() + 2
+		cp.spins[i].SetPosition(px+lw, py)
+		cp.spins[i].SetWidth(math32.Max(gw-lw, 0))
+	}
+	cp.recalcMarkers()
+}
+
+// recalcMarkers recalculates the positions of the markers from the current color
+func (cp *ColorPicker) recalcMarkers() {
+
+	const half = colorPickerMark / 2
+	cp.svMark.SetPosition(cp.s*cp.sv.Width()-half, (1-cp.v)*cp.sv.Height()-half)
+	cp.hueMark.SetSize(cp.hue.Width(), 4)
+	cp.hueMark.SetPosition(0, cp.h*cp.hue.Height()-2)
+	cp.alphaMark.SetSize(cp.alpha.Width(), 4)
+	cp.alphaMark.SetPosition(0, (1-cp.a)*cp.alpha.Height()-2)
+}
+
+// parseHexColor parses a color in the "#rrggbb" or "#rrggbbaa" formats.
+// The leading '#' is optional.
+func parseHexColor(text string) (math32.Color4, bool) {
+
+	text = strings.TrimPrefix(strings.TrimSpace(text), "#")
+	if len(text) != 6 && len(text) != 8 {
+		return math32.Color4{}, false
+	}
+	v, err := strconv.ParseUint(text, 16, 32)
+	if err != nil {
+		return math32.Color4{}, false
+	}
+	if len(text) == 6 {
+		v = v<<8 | 0xFF
+	}
+	return math32.Color4{
+		float32(v>>24&0xFF) / 255,
+		float32(v>>16&0xFF) / 255,
+		float32(v>>8&0xFF) / 255,
+		float32(v&0xFF) / 255,
+	}, true
+}
+
+// f2byte converts a color component from 0 to 1 to a byte
+func f2byte(v float32) uint8 {
+
+	return uint8(math.Round(float64(math32.Clamp(v, 0, 1)) * 255))
+}
+
+// hsv2rgb converts the specified hue, saturation and value from 0 to 1 to RGB
+func hsv2rgb(h, s, v float32) (float32, float32, float32) {
+
+	h6 := h * 6
+	i := math32.Floor(h6)
+	f := h6 - i
+	p := v * (1 - s)
+	q := v * (1 - s*f)
+	t := v * (1 - s*(1-f))
+	switch int(i) % 6 {
+	case 0:
+		return v, t, p
+	case 1:
+		return q, v, p
+	case 2:
+		return p, v, t
+	case 3:
+		return p, q, v
+	case 4:
+		return t, p, v
+	default:
+		return v, p, q
+	}
+}
+
+// rgb2hsv converts the specified RGB components to hue, saturation and value from 0 to 1
+func rgb2hsv(r, g, b float32) (float32, float32, float32) {
+
+	max := math32.Max(r, math32.Max(g, b))
+	min := math32.Min(r, math32.Min(g, b))
+	d := max - min
+	if max == 0 {
+		return 0, 0, 0
+	}
+	s := d / max
+	if d == 0 {
+		return 0, s, max
+	}
+	var h float32
+	switch max {
+	case r:
+		h = (g - b) / d
+		if h < 0 {
+			h += 6
+		}
+	case g:
+		h = (b-r)/d + 2
+	default:
+		h = (r-g)/d + 4
+	}
+	return h / 6, s, max
+}
@@ -0,0 +1,30 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gui
+
+import (
+	"github.com/sansebasko/engine/window"
+)
+
+// The root panel dispatches the text composition events of input methods
+// (OnCompositionStart, OnCompositionUpdate and OnCompositionCommit) of its window
+// to the panel with the key focus. Edit and TextArea show the text being composed
+// underlined at their caret and input the committed text.
+// The GLFW window does not dispatch these events: GLFW 3.2 has no API for the text
+// being composed by input methods and only reports the text they commit, and the
+// characters composed with dead keys by the keyboard layout, as OnChar events.
+// Other window implementations, or wrappers of the GLFW window which integrate with
+// the input method of their platform, dispatch them and implement window.IComposer
+// to place the candidate window of the input method next to the caret.
+
+// setCompositionRect sets the rectangle, in the pixel coordinates of the window
+// cursor events, of the caret of the text being edited, next to which input
+// methods show their candidate window, if the window can place it.
+func (r *Root) setCompositionRect(x, y, width, height int) {
+
+	if c, ok := r.win.(window.IComposer); ok {
+		c.SetCompositionRect(x, y, width, height)
+	}
+}
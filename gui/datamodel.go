@@ -0,0 +1,283 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gui
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/sansebasko/engine/core"
+)
+
+// DataModel wraps a Go value (normally a pointer to a struct or a map)
+// whose fields can be bound to GUI widgets by a Builder.
+// Values are referenced by paths of dot separated names of struct fields
+// (case insensitive), map keys or slice indices, for example "player.name"
+// or "inventory.0.count".
+// The model dispatches OnModelChange events when its values are changed
+// through Set or when Changed is called after they were modified directly.
+type DataModel struct {
+	core.Dispatcher               // Embedded event dispatcher
+	data            reflect.Value // model data
+}
+
+// ModelEvent is the event dispatched by a DataModel when its values change
+type ModelEvent struct {
+	Path string // path of the changed value (empty if all values may have changed)
+}
+
+// OnModelChange is the event dispatched by a DataModel when its values change
+const OnModelChange = "gui.OnModelChange"
+
+// NewDataModel creates and returns a pointer to a new data model for the specified value.
+// The value must be a pointer to a struct or a map for its fields to be set by the model.
+func NewDataModel(data interface{}) *DataModel {
+
+	m := new(DataModel)
+	m.Dispatcher.Initialize()
+	m.data = reflect.ValueOf(data)
+	return m
+}
+
+// Data returns the value wrapped by this model
+func (m *DataModel) Data() interface{} {
+
+	return m.data.Interface()
+}
+
+// Get returns the value at the specified path
+func (m *DataModel) Get(path string) (interface{}, error) {
+
+	v, err := m.lookup(path)
+	if err != nil {
+		return nil, err
+	}
+	if !v.CanInterface() {
+		return nil, fmt.Errorf("Path:%s is not accessible", path)
+	}
+	return v.Interface(), nil
+}
+
+// Set sets the value at the specified path converting it to the type
+// of the destination if necessary and dispatches OnModelChange.
+func (m *DataModel) Set(path string, value interface{}) error {
+
+	// Finds the container of the value
+	var parent reflect.Value
+	var key string
+	var err error
+	if idx := strings.LastIndex(path, "."); idx >= 0 {
+		parent, err = m.lookup(path[:idx])
+		key = path[idx+1:]
+	} else {
+		parent = indirect(m.data)
+		key = path
+	}
+	if err != nil {
+		return err
+	}
+
+	// Sets map element creating the map if it is nil
+	if parent.Kind() == reflect.Map {
+		if parent.IsNil() {
+			if !parent.CanSet() {
+				return fmt.Errorf("Path:%s nil map cannot be set", path)
+			}
+			parent.Set(reflect.MakeMap(parent.Type()))
+		}
+		mkey, err := modelMapKey(parent, key)
+		if err != nil {
+			return fmt.Errorf("Path:%s %v", path, err)
+		}
+		etype := parent.Type().Elem()
+		if prev := parent.MapIndex(mkey); prev.IsValid() && etype.Kind() == reflect.Interface && !prev.IsNil() {
+			etype = prev.Elem().Type()
+		}
+		cv, err := convertModelValue(value, etype)
+		if err != nil {
+			return fmt.Errorf("Path:%s %v", path, err)
+		}
+		parent.SetMapIndex(mkey, cv)
+		m.Changed(path)
+		return nil
+	}
+
+	// Sets struct field or slice element
+	dst, err := modelChild(parent, key)
+	if err != nil {
+		return fmt.Errorf("Path:%s %v", path, err)
+	}
+	if !dst.CanSet() {
+		return fmt.Errorf("Path:%s cannot be set", path)
+	}
+	cv, err := convertModelValue(value, dst.Type())
+	if err != nil {
+		return fmt.Errorf("Path:%s %v", path, err)
+	}
+	dst.Set(cv)
+	m.Changed(path)
+	return nil
+}
+
+// Changed dispatches OnModelChange for the specified path.
+// It should be called after values of the model data were changed directly.
+// An empty path indicates that any value may have changed.
+func (m *DataModel) Changed(path string) {
+
+	m.Dispatch(OnModelChange, &ModelEvent{Path: path})
+}
+
+// lookup returns the value at the specified path
+func (m *DataModel) lookup(path string) (reflect.Value, error) {
+
+	v := indirect(m.data)
+	if path == "" {
+		return v, nil
+	}
+	for _, name := range strings.Split(path, ".") {
+		child, err := modelChild(v, name)
+		if err != nil {
+			return reflect.Value{}, fmt.Errorf("Path:%s %v", path, err)
+		}
+		v = indirect(child)
+	}
+	return v, nil
+}
+
+// modelChild returns the struct field, map element or slice element
+// with the specified name of the specified value
+func modelChild(v reflect.Value, name string) (reflect.Value, error) {
+
+	switch v.Kind() {
+	case reflect.Struct:
+		f := v.FieldByNameFunc(func(fname string) bool { return strings.EqualFold(fname, name) })
+		if !f.IsValid() || !f.CanInterface() {
+			return reflect.Value{}, fmt.Errorf("field:%s not found", name)
+		}
+		return f, nil
+	case reflect.Map:
+		key, err := modelMapKey(v, name)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		e := v.MapIndex(key)
+		if !e.IsValid() {
+			return reflect.Value{}, fmt.Errorf("key:%s not found", name)
+		}
+		return e, nil
+	case reflect.Slice, reflect.Array:
+		i, err := strconv.Atoi(name)
+		if err != nil || i < 0 || i >= v.Len() {
+			return reflect.Value{}, fmt.Errorf("invalid index:%s", name)
+		}
+		return v.Index(i), nil
+	}
+	return reflect.Value{}, fmt.Errorf("%s is not a struct, map or slice", v.Kind())
+}
+
+// modelMapKey returns the key with the specified name for the specified map
+func modelMapKey(v reflect.Value, name string) (reflect.Value, error) {
+
+	ktype := v.Type().Key()
+	if ktype.Kind() != reflect.String {
+		return reflect.Value{}, fmt.Errorf("map keys are not strings")
+	}
+	return reflect.ValueOf(name).Convert(ktype), nil
+}
+
+// indirect returns the value pointed to by the specified pointers and interfaces
+func indirect(v reflect.Value) reflect.Value {
+
+	for (v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface) && !v.IsNil() {
+		v = v.Elem()
+	}
+	return v
+}
+
+// convertModelValue converts the specified value to the specified type
+func convertModelValue(value interface{}, t reflect.Type) (reflect.Value, error) {
+
+	if value == nil {
+		return reflect.Zero(t), nil
+	}
+	v := reflect.ValueOf(value)
+	if v.Type().AssignableTo(t) {
+		return v, nil
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return reflect.ValueOf(modelString(value)).Convert(t), nil
+	case reflect.Bool:
+		if b, ok := modelBool(value); ok {
+			return reflect.ValueOf(b).Convert(t), nil
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if f, ok := modelFloat(value); ok {
+			return reflect.ValueOf(int64(math.Floor(f + 0.5))).Convert(t), nil
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if f, ok := modelFloat(value); ok && f >= 0 {
+			return reflect.ValueOf(uint64(math.Floor(f + 0.5))).Convert(t), nil
+		}
+	case reflect.Float32, reflect.Float64:
+		if f, ok := modelFloat(value); ok {
+			return reflect.ValueOf(f).Convert(t), nil
+		}
+	}
+	if v.Type().ConvertibleTo(t) {
+		return v.Convert(t), nil
+	}
+	return reflect.Value{}, fmt.Errorf("cannot convert %T to %s", value, t)
+}
+
+// modelString returns the text representation of the specified value
+func modelString(value interface{}) string {
+
+	if value == nil {
+		return ""
+	}
+	return fmt.Sprint(value)
+}
+
+// modelFloat returns the specified numeric, bool or string value as a float64
+func modelFloat(value interface{}) (float64, bool) {
+
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), true
+	case reflect.Bool:
+		if v.Bool() {
+			return 1, true
+		}
+		return 0, true
+	case reflect.String:
+		f, err := strconv.ParseFloat(strings.TrimSpace(v.String()), 64)
+		return f, err == nil
+	}
+	return 0, false
+}
+
+// modelBool returns the specified bool, numeric or string value as a bool
+func modelBool(value interface{}) (bool, bool) {
+
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.Bool:
+		return v.Bool(), true
+	case reflect.String:
+		b, err := strconv.ParseBool(strings.TrimSpace(v.String()))
+		return b, err == nil
+	}
+	f, ok := modelFloat(value)
+	return f != 0, ok
+}
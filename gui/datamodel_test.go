@@ -0,0 +1,61 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gui
+
+import (
+	"testing"
+)
+
+// Tests setting values of struct fields and map elements, including
+// elements of nil maps, which are created if they can be set.
+func TestDataModelSet(t *testing.T) {
+
+	var data struct {
+		Name  string
+		Count int
+		Attrs map[string]interface{}
+	}
+	var global map[string]float32
+	m := NewDataModel(&data)
+	gm := NewDataModel(&global)
+	changed := ""
+	m.Subscribe(OnModelChange, func(evname string, ev interface{}) {
+		changed = ev.(*ModelEvent).Path
+	})
+
+	tests := []struct {
+		model *DataModel
+		path  string
+		value interface{}
+	}{
+		{m, "name", "player"},
+		{m, "count", "12"},
+		{m, "attrs.color", "red"},
+		{m, "attrs.size", 3},
+		{gm, "scale", 1.5},
+	}
+	for _, test := range tests {
+		if err := test.model.Set(test.path, test.value); err != nil {
+			t.Errorf("%s: %v", test.path, err)
+		}
+	}
+	if data.Name != "player" || data.Count != 12 || changed != "attrs.size" {
+		t.Errorf("fields %q %d, changed %q", data.Name, data.Count, changed)
+	}
+	if data.Attrs["color"] != "red" || data.Attrs["size"] != 3 {
+		t.Errorf("nil map of field: %v", data.Attrs)
+	}
+	if global["scale"] != 1.5 {
+		t.Errorf("nil map of pointer: %v", global)
+	}
+
+	// Nil maps which are not addressable cannot be created
+	if err := NewDataModel(map[string]int(nil)).Set("a", 1); err == nil {
+		t.Error("nil map value: no error")
+	}
+	if err := m.Set("count", "x"); err == nil {
+		t.Error("conversion: no error")
+	}
+}
@@ -0,0 +1,345 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gui
+
+import (
+	"github.com/sansebasko/engine/math32"
+	"github.com/sansebasko/engine/window"
+)
+
+// The root panel implements a drag and drop system for its panels.
+// When the mouse is moved with the left button pressed over a panel with a drag source
+// function, or over one of its children, the function is called to get the payload to drag.
+// While dragging, a preview of the payload follows the cursor in the root overlay and
+// the drop target panels under the cursor receive the OnDragEnter, OnDragOver and
+// OnDragLeave events. A drop target accepts the payload at the cursor position by
+// calling Accept from its OnDragOver subscriber and may also show an indicator of
+// where the payload will be dropped. When the mouse button is released over a drop
+// target which accepted the payload it receives OnDrop. In all cases the drag source
+// panel receives OnDragEnd. The Escape key cancels the drag.
+
+// DragSourceFunc is the type of the functions called by the root panel to
+// get the payload to drag from the mouse position where the drag started.
+// Returns nil if nothing should be dragged from that position.
+type DragSourceFunc func(x, y float32) *DragPayload
+
+// DragPayload describes the data being dragged
+type DragPayload struct {
+	Type    string      // type of the data checked by the drop targets
+	Data    interface{} // dragged data
+	Text    string      // text shown by the default preview
+	Preview IPanel      // optional panel shown under the cursor instead of the default preview
+	Source  IPanel      // drag source panel (set by the root panel)
+}
+
+// DragEvent is the event dispatched with OnDragEnter, OnDragOver, OnDragLeave,
+// OnDrop and OnDragEnd
+type DragEvent struct {
+	Payload   *DragPayload // dragged payload
+	X         float32      // cursor position in window pixels
+	Y         float32      // cursor position in window pixels
+	Target    IPanel       // current drop target or nil
+	Dropped   bool         // OnDragEnd: the payload was dropped on a target
+	accepted  bool         // payload accepted by the target at the cursor position
+	indicator bool         // indicator rectangle set
+	rect      Rect         // indicator rectangle in window pixels
+}
+
+// DragStyle contains the styling of the default drag preview
+type DragStyle BasicStyle
+
+// DragStyles contains the styles of the default drag preview for each drag state
+// and the color of the drop position indicators
+type DragStyles struct {
+	Normal    DragStyle     // not over a drop target
+	Accept    DragStyle     // over a drop target which accepts the payload
+	Reject    DragStyle     // over a drop target which rejects the payload
+	Indicator math32.Color4 // color of the drop position indicator
+}
+
+// ReorderEvent is the event dispatched with OnReorder by the List, Tree, Table
+// and TabBar widgets when the user moves one of their items by dragging it
+type ReorderEvent struct {
+	Item   IPanel    // moved list or tree item (nil for table rows and tabs)
+	From   int       // previous position
+	To     int       // new position
+	Parent *TreeNode // Tree: new parent node or nil for the top level
+}
+
+const (
+	dragThreshold     = 4  // distance the mouse must move with the button pressed to start dragging
+	dragPreviewOffset = 12 // distance of the preview from the cursor
+	dragIndicatorSize = 2  // thickness of the drop position indicators
+)
+
+// dragState contains the state of the pending or current drag
+type dragState struct {
+	source  IPanel       // drag source panel
+	x0, y0  float32      // position where the mouse button was pressed
+	payload *DragPayload // dragged payload (nil while pending)
+	ev      DragEvent    // last event dispatched to the current target
+	preview IPanel       // panel which follows the cursor
+	label   *Label       // default preview label
+	mark    *Panel       // drop position indicator
+}
+
+// Accept informs that the drop target accepts the payload at the cursor position.
+// It should be called by the subscribers of OnDragOver.
+func (ev *DragEvent) Accept() {
+
+	ev.accepted = true
+}
+
+// Accepted returns if the drop target accepted the payload at the cursor position
+func (ev *DragEvent) Accepted() bool {
+
+	return ev.accepted
+}
+
+// SetIndicator sets the rectangle in window pixels where the drop position
+// indicator is shown while the payload is accepted at the cursor position.
+// It should be called by the subscribers of OnDragOver.
+func (ev *DragEvent) SetIndicator(x, y, width, height float32) {
+
+	ev.indicator = true
+	ev.rect = Rect{X: x, Y: y, Width: width, Height: height}
+}
+
+// indicateEdge sets the drop indicator at the top or bottom edge of the specified panel,
+// or at its left or right edge if horizontal is true
+func (ev *DragEvent) indicateEdge(pan *Panel, horizontal, after bool) {
+
+	if horizontal {
+		x := pan.pospix.X
+		if after {
+			x += pan.width
+		}
+		ev.SetIndicator(x-dragIndicatorSize/2, pan.pospix.Y, dragIndicatorSize, pan.height)
+		return
+	}
+	y := pan.pospix.Y
+	if after {
+		y += pan.height
+	}
+	ev.SetIndicator(pan.pospix.X, y-dragIndicatorSize/2, pan.width, dragIndicatorSize)
+}
+
+// dragFrom returns if the specified drag event payload was dragged from the specified panel
+func dragFrom(ev *DragEvent, ipan IPanel) bool {
+
+	return ev.Payload.Source != nil && ev.Payload.Source.GetPanel() == ipan.GetPanel()
+}
+
+// dragText returns the text of the specified panel to show in drag previews
+func dragText(ipan IPanel) string {
+
+	switch p := ipan.(type) {
+	case *TreeNode:
+		return p.label.Text()
+	case interface{ Text() string }:
+		return p.Text()
+	}
+	return ""
+}
+
+// DragPayload returns the payload being dragged or nil if not dragging
+func (r *Root) DragPayload() *DragPayload {
+
+	if r.drag == nil {
+		return nil
+	}
+	return r.drag.payload
+}
+
+// CancelDrag cancels the current drag if any.
+// The drop target receives OnDragLeave and the drag source OnDragEnd.
+func (r *Root) CancelDrag() {
+
+	if r.drag == nil || r.drag.payload == nil {
+		r.drag = nil
+		return
+	}
+	r.endDrag(false)
+}
+
+// dragPending is called by the root panel after dispatching mouse button down events
+// to the specified foremost panel to start a pending drag from it or from its
+// nearest ancestor with a drag source function.
+func (r *Root) dragPending(target IPanel, x, y float32) {
+
+	for ipan := target; ipan != nil; {
+		if ipan.GetPanel().dragSource != nil {
+			r.drag = &dragState{source: ipan, x0: x, y0: y}
+			return
+		}
+		ipan, _ = ipan.GetPanel().Parent().(IPanel)
+	}
+}
+
+// dragEvent is called by the root panel with the mouse and cursor events while
+// a drag is pending or in progress. Returns true if the event was consumed.
+func (r *Root) dragEvent(x, y float32, evname string, ev interface{}) bool {
+
+	d := r.drag
+	switch evname {
+	case OnCursor:
+		if d.payload == nil {
+			if math32.Abs(x-d.x0) < dragThreshold && math32.Abs(y-d.y0) < dragThreshold {
+				return false
+			}
+			if !r.startDrag() {
+				r.drag = nil
+				return false
+			}
+		}
+		r.dragMove(x, y)
+		return true
+	case OnMouseUp:
+		if d.payload == nil {
+			r.drag = nil
+			return false
+		}
+		if ev.(*window.MouseEvent).Button != window.MouseButtonLeft {
+			return true
+		}
+		r.dragMove(x, y)
+		r.endDrag(d.ev.Target != nil && d.ev.accepted)
+		return true
+	case OnMouseDown:
+		return d.payload != nil
+	}
+	return false
+}
+
+// startDrag gets the payload from the pending drag source and shows its preview.
+// Returns false if the source has nothing to drag.
+func (r *Root) startDrag() bool {
+
+	d := r.drag
+	d.payload = d.source.GetPanel().dragSource(d.x0, d.y0)
+	if d.payload == nil {
+		return false
+	}
+	d.payload.Source = d.source
+	o := r.overlayLayer()
+	o.hideTooltip()
+
+	// Creates the preview and the drop position indicator
+	d.preview = d.payload.Preview
+	if d.preview == nil {
+		d.label = NewLabel(d.payload.Text)
+		d.preview = d.label
+	}
+	d.mark = NewPanel(0, 0)
+	d.mark.SetVisible(false)
+	for _, ipan := range []IPanel{d.mark, d.preview} {
+		ipan.GetPanel().SetBounded(false)
+		ipan.GetPanel().SetEnabled(false)
+		o.Add(ipan)
+	}
+	r.SetTopChild(o)
+	return true
+}
+
+// dragMove updates the drop target and the preview for the specified cursor position
+func (r *Root) dragMove(x, y float32) {
+
+	d := r.drag
+	target := r.dropTargetAt(x, y)
+	if d.ev.Target != nil && (target == nil || target.GetPanel() != d.ev.Target.GetPanel()) {
+		d.ev.Target.GetPanel().Dispatch(OnDragLeave, &d.ev)
+		d.ev.Target = nil
+	}
+	enter := target != nil && d.ev.Target == nil
+	d.ev = DragEvent{Payload: d.payload, X: x, Y: y, Target: target}
+	if enter {
+		target.GetPanel().Dispatch(OnDragEnter, &d.ev)
+	}
+	if target != nil {
+		d.ev.accepted = false
+		d.ev.indicator = false
+		target.GetPanel().Dispatch(OnDragOver, &d.ev)
+	}
+
+	// Updates the preview and the indicator
+	s := &StyleDefault().Drag
+	if d.label != nil {
+		ds := &s.Normal
+		if target != nil && d.ev.accepted {
+			ds = &s.Accept
+		} else if target != nil {
+			ds = &s.Reject
+		}
+		d.label.ApplyStyle(&ds.PanelStyle)
+		d.label.SetColor4(&ds.FgColor)
+	}
+	d.preview.GetPanel().SetPosition(x+dragPreviewOffset, y+dragPreviewOffset)
+	if d.ev.accepted && d.ev.indicator {
+		d.mark.SetPosition(d.ev.rect.X, d.ev.rect.Y)
+		d.mark.SetSize(d.ev.rect.Width, d.ev.rect.Height)
+		d.mark.SetColor4(&s.Indicator)
+		d.mark.SetVisible(true)
+	} else {
+		d.mark.SetVisible(false)
+	}
+}
+
+// endDrag finishes the current drag, dispatching OnDrop to the current target
+// if the payload was dropped, and removes the preview
+func (r *Root) endDrag(dropped bool) {
+
+	d := r.drag
+	r.drag = nil
+	o := r.overlayLayer()
+	o.Remove(d.preview)
+	o.Remove(d.mark)
+	d.mark.Dispose()
+	if d.label != nil {
+		d.label.Dispose()
+	}
+	if target := d.ev.Target; target != nil {
+		if dropped {
+			target.GetPanel().Dispatch(OnDrop, &d.ev)
+		}
+		target.GetPanel().Dispatch(OnDragLeave, &d.ev)
+	}
+	d.ev.Dropped = dropped
+	d.source.GetPanel().Dispatch(OnDragEnd, &d.ev)
+}
+
+// dropTargetAt returns the drop target at the specified position which is the
+// foremost panel at the position or its nearest ancestor which is a drop target
+func (r *Root) dropTargetAt(x, y float32) IPanel {
+
+	var found IPanel
+	var check func(ipan IPanel)
+	check = func(ipan IPanel) {
+		pan := ipan.GetPanel()
+		if !pan.Visible() || !pan.Enabled() {
+			return
+		}
+		if pan.InsideBorders(x, y) && r.canDispatch(ipan) &&
+			(found == nil || pan.Position().Z <= found.GetPanel().Position().Z) {
+			found = ipan
+		}
+		for _, child := range pan.Children() {
+			if ichild, ok := child.(IPanel); ok {
+				check(ichild)
+			}
+		}
+	}
+	for _, child := range r.Children() {
+		if ichild, ok := child.(IPanel); ok {
+			check(ichild)
+		}
+	}
+	for ipan := found; ipan != nil; {
+		if ipan.GetPanel().dropTarget {
+			return ipan
+		}
+		ipan, _ = ipan.GetPanel().Parent().(IPanel)
+	}
+	return nil
+}
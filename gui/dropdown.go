@@ -43,10 +43,14 @@ func NewDropDown(width float32, item *ImageLabel) *DropDown {
 
 	dd.Panel.Initialize(width, 0)
 	dd.Panel.Subscribe(OnKeyDown, dd.onKeyEvent)
+	dd.Panel.Subscribe(OnKeyRepeat, dd.onKeyEvent)
+	dd.Panel.Subscribe(OnFocus, dd.onFocus)
+	dd.Panel.Subscribe(OnFocusLost, dd.onFocus)
 	dd.Panel.Subscribe(OnMouseDown, dd.onMouse)
 	dd.Panel.Subscribe(OnCursorEnter, dd.onCursor)
 	dd.Panel.Subscribe(OnCursorLeave, dd.onCursor)
 	dd.Panel.Subscribe(OnResize, func(name string, ev interface{}) { dd.recalc() })
+	dd.Panel.SetFocusable(true)
 
 	// ListItem
 	dd.Panel.Add(dd.litem)
@@ -66,6 +70,7 @@ func NewDropDown(width float32, item *ImageLabel) *DropDown {
 	dd.list.Subscribe(OnMouseDown, dd.onListMouse)
 	dd.list.Subscribe(OnMouseOut, dd.onListMouse)
 	dd.list.Subscribe(OnChange, dd.onListChangeEvent)
+	dd.list.Subscribe(OnKeyDown, dd.onListKey)
 	dd.list.Subscribe(OnCursor, func(evname string, ev interface{}) { dd.root.StopPropagation(StopAll) })
 	dd.Panel.Add(dd.list)
 
@@ -149,9 +154,47 @@ func (dd *DropDown) onKeyEvent(evname string, ev interface{}) {
 		if dd.list.Visible() {
 			dd.list.SetVisible(false)
 		}
+		return
+	case window.KeyEnter, window.KeySpace:
+		dd.open()
+	case window.KeyDown:
+		if kev.Mods == window.ModAlt {
+			dd.open()
+			break
+		}
+		dd.list.selNext(true, true)
+	case window.KeyUp:
+		dd.list.selPrev(true, true)
 	default:
 		return
 	}
+	dd.root.StopPropagation(Stop3D)
+}
+
+// onListKey receives the key events of the list after the list processed them
+func (dd *DropDown) onListKey(evname string, ev interface{}) {
+
+	if ev.(*window.KeyEvent).Keycode == window.KeyEscape {
+		dd.list.SetVisible(false)
+	}
+	// Returns the key focus to the dropdown when the list is closed
+	if !dd.list.Visible() {
+		dd.root.SetKeyFocus(dd)
+	}
+}
+
+// onFocus receives the subscribed focus events of the dropdown
+func (dd *DropDown) onFocus(evname string, ev interface{}) {
+
+	dd.focus = evname == OnFocus
+	dd.update()
+}
+
+// open shows the list and sets the key focus to it
+func (dd *DropDown) open() {
+
+	dd.list.SetVisible(true)
+	dd.root.SetKeyFocus(dd.list)
 }
 
 // onMouse receives subscribed mouse events over the dropdown
@@ -163,8 +206,7 @@ func (dd *DropDown) onMouse(evname string, ev interface{}) {
 			dd.clickOut = false
 			return
 		}
-		dd.list.SetVisible(true)
-		dd.root.SetKeyFocus(dd.list)
+		dd.open()
 		return
 	}
 }
@@ -14,14 +14,17 @@ import (
 
 // Edit represents a text edit box GUI element
 type Edit struct {
-	Label              // Embedded label
-	MaxLength   int    // Maximum number of characters
-	width       int    // edit width in pixels
-	placeHolder string // place holder string
-	text        string // current edit text
-	col         int    // current column
-	focus       bool   // key focus flag
+	Label                  // Embedded label
+	MaxLength   int        // Maximum number of characters
+	width       int        // edit width in pixels
+	placeHolder string     // place holder string
+	buf         editBuffer // text, cursor, selection and undo history
+	focus       bool       // key focus flag
 	cursorOver  bool
+	dragging    bool   // selecting text with the mouse
+	composing   bool   // an input method is composing text
+	preedit     string // text being composed by the input method
+	preeditPos  int    // position of the input method cursor in the composed text
 	blinkID     int
 	caretOn     bool
 	styles      *EditStyles
@@ -36,6 +39,7 @@ type EditStyle struct {
 	BgAlpha     float32
 	FgColor     math32.Color4
 	HolderColor math32.Color4
+	SelColor    math32.Color4
 }
 
 // EditStyles contains an EditStyle for each valid GUI state
@@ -59,19 +63,25 @@ func NewEdit(width int, placeHolder string) *Edit {
 	ed.placeHolder = placeHolder
 
 	ed.styles = &StyleDefault().Edit
-	ed.text = ""
 	ed.MaxLength = 80
-	ed.col = 0
 	ed.focus = false
 
 	ed.Label.initialize("", StyleDefault().Font)
 	ed.Label.Subscribe(OnKeyDown, ed.onKey)
 	ed.Label.Subscribe(OnKeyRepeat, ed.onKey)
 	ed.Label.Subscribe(OnChar, ed.onChar)
+	ed.Label.Subscribe(OnCompositionStart, ed.onComposition)
+	ed.Label.Subscribe(OnCompositionUpdate, ed.onComposition)
+	ed.Label.Subscribe(OnCompositionCommit, ed.onComposition)
 	ed.Label.Subscribe(OnMouseDown, ed.onMouse)
+	ed.Label.Subscribe(OnMouseUp, ed.onMouse)
+	ed.Label.Subscribe(OnCursor, ed.onCursor)
 	ed.Label.Subscribe(OnCursorEnter, ed.onCursor)
 	ed.Label.Subscribe(OnCursorLeave, ed.onCursor)
 	ed.Label.Subscribe(OnEnable, func(evname string, ev interface{}) { ed.update() })
+	ed.Label.Subscribe(OnScale, func(evname string, ev interface{}) { ed.update() })
+	ed.Label.Subscribe(OnFocus, func(evname string, ev interface{}) { ed.setFocus() })
+	ed.Label.SetFocusable(true)
 
 	ed.update()
 	return ed
@@ -81,7 +91,7 @@ func NewEdit(width int, placeHolder string) *Edit {
 func (ed *Edit) SetText(text string) *Edit {
 
 	// Remove new lines from text
-	ed.text = strings.Replace(text, "\n", "", -1)
+	ed.buf.setText(strings.Replace(text, "\n", "", -1))
 	ed.update()
 	return ed
 }
@@ -89,7 +99,20 @@ func (ed *Edit) SetText(text string) *Edit {
 // Text returns the current edited text
 func (ed *Edit) Text() string {
 
-	return ed.text
+	return ed.buf.String()
+}
+
+// SetPlaceHolder sets the text shown when the edit is empty and not focused
+func (ed *Edit) SetPlaceHolder(text string) {
+
+	ed.placeHolder = text
+	ed.update()
+}
+
+// PlaceHolder returns the text shown when the edit is empty and not focused
+func (ed *Edit) PlaceHolder() string {
+
+	return ed.placeHolder
 }
 
 // SetFontSize sets label font size (overrides Label.SetFontSize)
@@ -112,6 +135,8 @@ func (ed *Edit) SetStyles(es *EditStyles) {
 func (ed *Edit) LostKeyFocus() {
 
 	ed.focus = false
+	ed.composing = false
+	ed.preedit = ""
 	ed.update()
 	ed.root.ClearTimeout(ed.blinkID)
 }
@@ -120,8 +145,8 @@ func (ed *Edit) LostKeyFocus() {
 // specified  column if possible
 func (ed *Edit) CursorPos(col int) {
 
-	if col <= text.StrCount(ed.text) {
-		ed.col = col
+	if col <= ed.buf.len() {
+		ed.buf.setPos(col, false)
 		ed.redraw(ed.focus)
 	}
 }
@@ -129,27 +154,19 @@ func (ed *Edit) CursorPos(col int) {
 // CursorLeft moves the edit cursor one character left if possible
 func (ed *Edit) CursorLeft() {
 
-	if ed.col > 0 {
-		ed.col--
-		ed.redraw(ed.focus)
-	}
+	ed.moveCursor(ed.Label.layout.Move(ed.buf.pos, -1), false)
 }
 
 // CursorRight moves the edit cursor one character right if possible
 func (ed *Edit) CursorRight() {
 
-	if ed.col < text.StrCount(ed.text) {
-		ed.col++
-		ed.redraw(ed.focus)
-	}
+	ed.moveCursor(ed.Label.layout.Move(ed.buf.pos, 1), false)
 }
 
-// CursorBack deletes the character at left of the cursor if possible
+// CursorBack deletes the selected text or the character at left of the cursor if possible
 func (ed *Edit) CursorBack() {
 
-	if ed.col > 0 {
-		ed.col--
-		ed.text = text.StrRemove(ed.text, ed.col)
+	if ed.buf.erase(false) {
 		ed.redraw(ed.focus)
 		ed.Dispatch(OnChange, nil)
 	}
@@ -158,51 +175,133 @@ func (ed *Edit) CursorBack() {
 // CursorHome moves the edit cursor to the beginning of the text
 func (ed *Edit) CursorHome() {
 
-	ed.col = 0
-	ed.redraw(ed.focus)
+	ed.moveCursor(0, false)
 }
 
 // CursorEnd moves the edit cursor to the end of the text
 func (ed *Edit) CursorEnd() {
 
-	ed.col = text.StrCount(ed.text)
-	ed.redraw(ed.focus)
+	ed.moveCursor(ed.buf.len(), false)
 }
 
-// CursorDelete deletes the character at the right of the cursor if possible
+// CursorDelete deletes the selected text or the character at the right of the cursor if possible
 func (ed *Edit) CursorDelete() {
 
-	if ed.col < text.StrCount(ed.text) {
-		ed.text = text.StrRemove(ed.text, ed.col)
+	if ed.buf.erase(true) {
 		ed.redraw(ed.focus)
 		ed.Dispatch(OnChange, nil)
 	}
 }
 
 // CursorInput inserts the specified string at the current cursor position
+// replacing the selected text if any
 func (ed *Edit) CursorInput(s string) {
 
-	if text.StrCount(ed.text) >= ed.MaxLength {
+	ed.input(s, true)
+}
+
+// SetSelection selects the text between the specified start and end columns
+// and moves the cursor to the end column
+func (ed *Edit) SetSelection(start, end int) {
+
+	ed.buf.setSelection(start, end)
+	ed.redraw(ed.focus)
+}
+
+// Selection returns the start and end columns of the selected text.
+// Both are equal to the cursor column if there is no selection.
+func (ed *Edit) Selection() (start, end int) {
+
+	return ed.buf.selection()
+}
+
+// SelectedText returns the selected text
+func (ed *Edit) SelectedText() string {
+
+	return ed.buf.selectedText()
+}
+
+// SelectAll selects all the text
+func (ed *Edit) SelectAll() {
+
+	ed.buf.selectAll()
+	ed.redraw(ed.focus)
+}
+
+// Copy copies the selected text to the clipboard
+func (ed *Edit) Copy() {
+
+	if ed.buf.hasSelection() && ed.root != nil {
+		setClipboardString(ed.root.Window(), ed.buf.selectedText())
+	}
+}
+
+// Cut copies the selected text to the clipboard and deletes it
+func (ed *Edit) Cut() {
+
+	if ed.buf.hasSelection() {
+		ed.Copy()
+		ed.CursorBack()
+	}
+}
+
+// Paste inserts the text from the clipboard at the cursor position
+// replacing the selected text if any.
+// Line breaks are removed from the inserted text.
+func (ed *Edit) Paste() {
+
+	if ed.root == nil {
 		return
 	}
+	s := clipboardString(ed.root.Window())
+	s = strings.Replace(s, "\r", "", -1)
+	ed.input(strings.Replace(s, "\n", "", -1), false)
+}
+
+// Undo undoes the last change to the text
+func (ed *Edit) Undo() {
+
+	if ed.buf.undoChange() {
+		ed.redraw(ed.focus)
+		ed.Dispatch(OnChange, nil)
+	}
+}
+
+// Redo redoes the last undone change to the text
+func (ed *Edit) Redo() {
+
+	if ed.buf.redoChange() {
+		ed.redraw(ed.focus)
+		ed.Dispatch(OnChange, nil)
+	}
+}
+
+// moveCursor moves the cursor to the specified column
+// extending the selection if specified
+func (ed *Edit) moveCursor(col int, extend bool) {
+
+	ed.buf.setPos(col, extend)
+	ed.redraw(ed.focus)
+}
+
+// input inserts the specified string at the cursor position if the
+// resulting text does not exceed the maximum length and the edit width
+func (ed *Edit) input(s string, typed bool) {
 
 	// Set new text with included input
-	var newText string
-	if ed.col < text.StrCount(ed.text) {
-		newText = text.StrInsert(ed.text, s, ed.col)
-	} else {
-		newText = ed.text + s
+	newText := ed.buf.replaced(s)
+	if text.StrCount(newText) > ed.MaxLength {
+		return
 	}
 
 	// Checks if new text exceeds edit width
+	ed.Label.setFontAttributes()
 	width, _ := ed.Label.font.MeasureText(newText)
-	if float32(width)+editMarginX+float32(1) >= ed.Label.ContentWidth() {
+	if float32(width)/ed.Label.scale+editMarginX+float32(1) >= ed.Label.ContentWidth() {
 		return
 	}
 
-	ed.text = newText
-	ed.col++
-
+	ed.buf.insert(s, typed)
 	ed.Dispatch(OnChange, nil)
 	ed.redraw(ed.focus)
 }
@@ -214,28 +313,110 @@ func (ed *Edit) redraw(caret bool) {
 	if !caret {
 		line = -1
 	}
-	ed.Label.setTextCaret(ed.text, editMarginX, ed.width, line, ed.col)
+	msg := ed.buf.String()
+	col := ed.buf.pos
+	var sel *textSelection
+	if ed.preedit != "" {
+		// Shows the text being composed underlined at the cursor
+		runes := []rune(msg)
+		msg = string(runes[:col]) + ed.preedit + string(runes[col:])
+		count := text.StrCount(ed.preedit)
+		sel = &textSelection{col1: col, col2: col + count, underline: true}
+		if ed.preeditPos >= 0 && ed.preeditPos <= count {
+			col += ed.preeditPos
+		} else {
+			col += count
+		}
+	} else if ed.focus && ed.buf.hasSelection() {
+		start, end := ed.buf.selection()
+		sel = &textSelection{col1: start, col2: end, color: ed.styles.Focus.SelColor}
+	}
+	ed.Label.setTextCaret(msg, editMarginX, ed.width, line, col, sel)
+	if ed.focus {
+		ed.setCompositionRect(col)
+	}
+}
+
+// setCompositionRect sets the rectangle of the caret at the specified column in
+// the window, next to which input methods show their candidate window
+func (ed *Edit) setCompositionRect(col int) {
+
+	if ed.root == nil {
+		return
+	}
+	x, _ := ed.Label.layout.Caret(col)
+	scale := guiScale.value
+	px := ed.pospix.X + ed.content.X + float32(editMarginX) + float32(x)/ed.Label.scale
+	py := ed.pospix.Y + ed.content.Y
+	ed.root.setCompositionRect(int(px*scale), int(py*scale), 1, int(ed.content.Height*scale))
+}
+
+// colAt returns the column nearest to the specified screen x coordinate
+func (ed *Edit) colAt(x float32) int {
+
+	return ed.Label.layout.Hit(int((x-ed.pospix.X-editMarginX)*ed.Label.scale), 0)
 }
 
 // onKey receives subscribed key events
 func (ed *Edit) onKey(evname string, ev interface{}) {
 
+	// Keys are handled by the input method while it composes text
+	if ed.composing {
+		return
+	}
 	kev := ev.(*window.KeyEvent)
-	switch kev.Keycode {
-	case window.KeyLeft:
-		ed.CursorLeft()
-	case window.KeyRight:
-		ed.CursorRight()
-	case window.KeyHome:
-		ed.CursorHome()
-	case window.KeyEnd:
-		ed.CursorEnd()
-	case window.KeyBackspace:
-		ed.CursorBack()
-	case window.KeyDelete:
-		ed.CursorDelete()
+	switch editShortcutOf(kev) {
+	case editSelectAll:
+		ed.SelectAll()
+	case editCopy:
+		ed.Copy()
+	case editCut:
+		ed.Cut()
+	case editPaste:
+		ed.Paste()
+	case editUndo:
+		ed.Undo()
+	case editRedo:
+		ed.Redo()
 	default:
-		return
+		shift := kev.Mods&window.ModShift != 0
+		word := kev.Mods&window.ModControl != 0
+		switch kev.Keycode {
+		case window.KeyLeft:
+			if word {
+				ed.moveCursor(ed.buf.wordLeft(ed.buf.pos), shift)
+			} else if ed.buf.hasSelection() && !shift {
+				start, _ := ed.buf.selection()
+				ed.moveCursor(start, false)
+			} else {
+				ed.moveCursor(ed.Label.layout.Move(ed.buf.pos, -1), shift)
+			}
+		case window.KeyRight:
+			if word {
+				ed.moveCursor(ed.buf.wordRight(ed.buf.pos), shift)
+			} else if ed.buf.hasSelection() && !shift {
+				_, end := ed.buf.selection()
+				ed.moveCursor(end, false)
+			} else {
+				ed.moveCursor(ed.Label.layout.Move(ed.buf.pos, 1), shift)
+			}
+		case window.KeyHome:
+			ed.moveCursor(0, shift)
+		case window.KeyEnd:
+			ed.moveCursor(ed.buf.len(), shift)
+		case window.KeyBackspace:
+			if word && !ed.buf.hasSelection() {
+				ed.buf.setPos(ed.buf.wordLeft(ed.buf.pos), true)
+			}
+			ed.CursorBack()
+		case window.KeyDelete:
+			if word && !ed.buf.hasSelection() {
+				ed.buf.setPos(ed.buf.wordRight(ed.buf.pos), true)
+			}
+			ed.CursorDelete()
+		default:
+			return
+		}
 	}
 	ed.root.StopPropagation(Stop3D)
 }
@@ -247,7 +428,32 @@ func (ed *Edit) onChar(evname string, ev interface{}) {
 	ed.CursorInput(string(cev.Char))
 }
 
-// onMouseEvent receives subscribed mouse down events
+// onComposition receives subscribed text composition events of input methods
+func (ed *Edit) onComposition(evname string, ev interface{}) {
+
+	cev := ev.(*window.CompositionEvent)
+	switch evname {
+	case OnCompositionStart, OnCompositionUpdate:
+		// The composed text replaces the selected text
+		if !ed.composing && ed.buf.hasSelection() {
+			ed.CursorBack()
+		}
+		ed.composing = true
+		ed.preedit = cev.Text
+		ed.preeditPos = cev.Cursor
+		ed.redraw(ed.focus)
+	case OnCompositionCommit:
+		ed.composing = false
+		ed.preedit = ""
+		if cev.Text != "" {
+			ed.input(cev.Text, true)
+		}
+		ed.redraw(ed.focus)
+	}
+	ed.root.StopPropagation(Stop3D)
+}
+
+// onMouseEvent receives subscribed mouse events
 func (ed *Edit) onMouse(evname string, ev interface{}) {
 
 	e := ev.(*window.MouseEvent)
@@ -255,29 +461,61 @@ func (ed *Edit) onMouse(evname string, ev interface{}) {
 		return
 	}
 
-	// Set key focus to this panel
-	ed.root.SetKeyFocus(ed)
-
-	// Find clicked column
-	var nchars int
-	for nchars = 1; nchars <= text.StrCount(ed.text); nchars++ {
-		width, _ := ed.Label.font.MeasureText(text.StrPrefix(ed.text, nchars))
-		posx := e.Xpos - ed.pospix.X
-		if posx < editMarginX+float32(width) {
-			break
+	// Stops selecting text
+	if evname == OnMouseUp {
+		if ed.dragging {
+			ed.dragging = false
+			ed.root.SetMouseFocus(nil)
 		}
+		return
 	}
+
+	// Set key focus to this panel
+	ed.setFocus()
+
+	// Moves the cursor to the clicked column, extending the selection if shift is pressed,
+	// and starts selecting text while the mouse button is down
+	ed.moveCursor(ed.colAt(e.Xpos), e.Mods&window.ModShift != 0)
+	ed.dragging = true
+	ed.root.SetMouseFocus(ed)
+	ed.root.StopPropagation(Stop3D)
+}
+
+// setFocus sets the key focus to this edit and starts blinking the caret
+func (ed *Edit) setFocus() {
+
+	ed.root.SetKeyFocus(ed)
 	if !ed.focus {
 		ed.focus = true
 		ed.blinkID = ed.root.SetInterval(750*time.Millisecond, nil, ed.blink)
+		ed.update()
+	}
+}
+
+// setWidth sets the total width of this edit used by
+// the widgets which contain edits
+func (ed *Edit) setWidth(width float32) {
+
+	w := int(width - ed.MinWidth())
+	if w < 0 {
+		w = 0
+	}
+	if w != ed.width {
+		ed.width = w
+		ed.update()
 	}
-	ed.CursorPos(nchars - 1)
-	ed.root.StopPropagation(Stop3D)
 }
 
 // onCursor receives subscribed cursor events
 func (ed *Edit) onCursor(evname string, ev interface{}) {
 
+	if evname == OnCursor {
+		if ed.dragging {
+			cev := ev.(*window.CursorEvent)
+			ed.moveCursor(ed.colAt(cev.Xpos), true)
+		}
+		return
+	}
 	if evname == OnCursorEnter {
 		ed.root.SetCursorText()
 		ed.cursorOver = true
@@ -336,9 +574,9 @@ func (ed *Edit) applyStyle(s *EditStyle) {
 	ed.Label.SetBgColor4(&s.BgColor)
 	//ed.Label.SetBgAlpha(s.BgAlpha)
 
-	if !ed.focus && len(ed.text) == 0 && len(ed.placeHolder) > 0 {
+	if !ed.focus && ed.buf.len() == 0 && len(ed.placeHolder) > 0 {
 		ed.Label.SetColor4(&s.HolderColor)
-		ed.Label.setTextCaret(ed.placeHolder, editMarginX, ed.width, -1, ed.col)
+		ed.Label.setTextCaret(ed.placeHolder, editMarginX, ed.width, -1, ed.buf.pos, nil)
 	} else {
 		ed.Label.SetColor4(&s.FgColor)
 		ed.redraw(ed.focus)
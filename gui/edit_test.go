@@ -0,0 +1,142 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gui
+
+import (
+	"testing"
+
+	"github.com/sansebasko/engine/window"
+)
+
+// Tests that the cursor keys move the caret of an edit in visual order
+// through the bidirectional text, with the caret position X increasing to
+// the right and decreasing to the left.
+func TestEditCaretOrder(t *testing.T) {
+
+	tests := []struct {
+		text  string
+		right bool
+		cols  []int
+	}{
+		{"abc", true, []int{0, 1, 2, 3, 3}},
+		{"abc אבג 123", true, []int{0, 1, 2, 3, 8, 9, 10, 7, 6, 5, 4, 11}},
+		{"אבג", false, []int{0, 1, 2, 3, 3}},
+		{"سلام", false, []int{0, 1, 2, 3, 4, 4}},
+		{"سلام", true, []int{0, 0}},
+	}
+	for _, test := range tests {
+		ed := NewEdit(300, "")
+		ed.SetText(test.text)
+		ed.CursorHome()
+		prev := -1
+		for i, col := range test.cols {
+			if ed.buf.pos != col {
+				t.Errorf("%q: caret %d at column %d, expected %d", test.text, i, ed.buf.pos, col)
+				break
+			}
+			x, _ := ed.Label.layout.Caret(ed.buf.pos)
+			if i > 0 && ed.buf.pos != test.cols[i-1] && (test.right && x < prev || !test.right && x > prev) {
+				t.Errorf("%q: caret %d at position %d after %d", test.text, i, x, prev)
+			}
+			prev = x
+			if test.right {
+				ed.CursorRight()
+			} else {
+				ed.CursorLeft()
+			}
+		}
+	}
+}
+
+// clipboardWindow is a test window with a clipboard
+type clipboardWindow struct {
+	testWindow
+	clipboard string
+}
+
+func (w *clipboardWindow) ClipboardString() string     { return w.clipboard }
+func (w *clipboardWindow) SetClipboardString(s string) { w.clipboard = s }
+
+// Tests copying and pasting text between an edit and a text area with the
+// clipboard of the window, and that windows without clipboard are ignored.
+func TestEditClipboard(t *testing.T) {
+
+	win := &clipboardWindow{}
+	for _, w := range []window.IWindow{win, testWindow{}} {
+		r := newTestRoot()
+		r.win = w
+		ed := NewEdit(100, "")
+		ta := NewTextArea(100, 100)
+		r.Add(ed)
+		r.Add(ta)
+		ed.SetText("one two")
+		ed.SetSelection(4, 7)
+		ed.Copy()
+		ta.SetText("line\n")
+		ta.SetSelection(5, 5)
+		ta.Paste()
+		ta.SetSelection(0, 7)
+		ta.Cut()
+		ed.SelectAll()
+		ed.Paste()
+		if w == win {
+			if ed.Text() != "linetw" || ta.Text() != "o" || win.clipboard != "line\ntw" {
+				t.Errorf("edit %q, text area %q, clipboard %q", ed.Text(), ta.Text(), win.clipboard)
+			}
+		} else if ed.Text() != "" || ta.Text() != "" {
+			t.Errorf("without clipboard: edit %q, text area %q", ed.Text(), ta.Text())
+		}
+	}
+}
+
+// composerWindow is a test window which places the candidate window of input methods
+type composerWindow struct {
+	testWindow
+	rect [4]int
+}
+
+func (w *composerWindow) SetCompositionRect(x, y, width, height int) {
+	w.rect = [4]int{x, y, width, height}
+}
+
+// Tests that the composition events of the window are shown as preedit text by
+// the widget with the key focus, which inputs the committed text and places the
+// candidate window of the input method at its caret.
+func TestComposition(t *testing.T) {
+
+	win := &composerWindow{}
+	r := newTestRoot()
+	r.win = win
+	ed := NewEdit(100, "")
+	ta := NewTextArea(100, 100)
+	ta.SetPosition(0, 50)
+	r.Add(ed)
+	r.Add(ta)
+	r.UpdateMatrixWorld()
+	tests := []struct {
+		name string
+		ipan IPanel
+		text func() string
+	}{
+		{"edit", ed, ed.Text},
+		{"text area", ta, ta.Text},
+	}
+	for _, test := range tests {
+		r.SetKeyFocus(test.ipan)
+		win.rect = [4]int{}
+		r.onChar(window.OnCompositionStart, &window.CompositionEvent{W: win, Text: "n"})
+		r.onChar(window.OnCompositionUpdate, &window.CompositionEvent{W: win, Text: "ni", Cursor: 2})
+		if test.text() != "" || win.rect[3] == 0 {
+			t.Errorf("%s: text %q while composing, composition rect %v", test.name, test.text(), win.rect)
+		}
+		r.onChar(window.OnCompositionCommit, &window.CompositionEvent{W: win, Text: "你"})
+		if test.text() != "你" {
+			t.Errorf("%s: committed text %q", test.name, test.text())
+		}
+	}
+	if win.rect[1] < 50 {
+		t.Errorf("composition rect %v outside of the text area", win.rect)
+	}
+}
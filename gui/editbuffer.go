@@ -0,0 +1,308 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gui
+
+import (
+	"unicode"
+
+	"github.com/sansebasko/engine/window"
+)
+
+// editBuffer contains the text, caret position, selection and
+// undo/redo history shared by the text editing widgets.
+// All positions are in runes.
+type editBuffer struct {
+	text    []rune      // current text
+	pos     int         // caret position
+	anchor  int         // selection anchor (equal to pos if there is no selection)
+	undo    []editState // undo history
+	redo    []editState // redo history
+	typing  bool        // last change was typed and can be merged with the next typed text
+	maxUndo int         // maximum number of undo states
+}
+
+// editState is a snapshot of an editBuffer saved in its history
+type editState struct {
+	text   []rune
+	pos    int
+	anchor int
+}
+
+// editMaxUndo is the default maximum number of undo states
+const editMaxUndo = 100
+
+// setText sets the text, moves the caret to its end and clears the history
+func (b *editBuffer) setText(s string) {
+
+	b.text = []rune(s)
+	b.pos = len(b.text)
+	b.anchor = b.pos
+	b.undo = b.undo[0:0]
+	b.redo = b.redo[0:0]
+	b.typing = false
+}
+
+// String returns the current text
+func (b *editBuffer) String() string {
+
+	return string(b.text)
+}
+
+// len returns the number of runes of the text
+func (b *editBuffer) len() int {
+
+	return len(b.text)
+}
+
+// selection returns the start and end positions of the selection
+func (b *editBuffer) selection() (start, end int) {
+
+	if b.anchor < b.pos {
+		return b.anchor, b.pos
+	}
+	return b.pos, b.anchor
+}
+
+// hasSelection returns if there is any selected text
+func (b *editBuffer) hasSelection() bool {
+
+	return b.anchor != b.pos
+}
+
+// selectedText returns the currently selected text
+func (b *editBuffer) selectedText() string {
+
+	start, end := b.selection()
+	return string(b.text[start:end])
+}
+
+// setPos moves the caret to the specified position.
+// If extend is true the selection is extended up to the new position,
+// otherwise the selection is cleared.
+func (b *editBuffer) setPos(pos int, extend bool) {
+
+	if pos < 0 {
+		pos = 0
+	}
+	if pos > len(b.text) {
+		pos = len(b.text)
+	}
+	b.pos = pos
+	if !extend {
+		b.anchor = pos
+	}
+	b.typing = false
+}
+
+// setSelection selects the text between the specified positions
+// leaving the caret at the end position.
+func (b *editBuffer) setSelection(start, end int) {
+
+	b.setPos(start, false)
+	b.setPos(end, true)
+}
+
+// selectAll selects all the text
+func (b *editBuffer) selectAll() {
+
+	b.setSelection(0, len(b.text))
+}
+
+// replaced returns the text which would result from
+// replacing the current selection with the specified string.
+func (b *editBuffer) replaced(s string) string {
+
+	start, end := b.selection()
+	return string(b.text[:start]) + s + string(b.text[end:])
+}
+
+// insert replaces the current selection with the specified string
+// and moves the caret to its end.
+// Consecutive typed insertions are merged into a single undo state.
+func (b *editBuffer) insert(s string, typed bool) {
+
+	if !typed || !b.typing || b.hasSelection() {
+		b.save()
+	}
+	start, end := b.selection()
+	runes := []rune(s)
+	text := make([]rune, 0, len(b.text)-(end-start)+len(runes))
+	text = append(text, b.text[:start]...)
+	text = append(text, runes...)
+	text = append(text, b.text[end:]...)
+	b.text = text
+	b.pos = start + len(runes)
+	b.anchor = b.pos
+	// Starts a new undo state after each word
+	b.typing = typed && len(runes) > 0 && !unicode.IsSpace(runes[len(runes)-1])
+}
+
+// erase deletes the selected text if any, otherwise the character
+// after the caret if forward is true or before the caret if false.
+// Returns if the text was changed.
+func (b *editBuffer) erase(forward bool) bool {
+
+	if !b.hasSelection() {
+		if forward {
+			if b.pos >= len(b.text) {
+				return false
+			}
+			b.anchor = b.pos + 1
+		} else {
+			if b.pos == 0 {
+				return false
+			}
+			b.anchor = b.pos - 1
+		}
+	}
+	b.insert("", false)
+	return true
+}
+
+// save saves the current state in the undo history and clears the redo history
+func (b *editBuffer) save() {
+
+	b.undo = append(b.undo, b.state())
+	maxUndo := b.maxUndo
+	if maxUndo <= 0 {
+		maxUndo = editMaxUndo
+	}
+	if len(b.undo) > maxUndo {
+		b.undo = append(b.undo[0:0], b.undo[len(b.undo)-maxUndo:]...)
+	}
+	b.redo = b.redo[0:0]
+}
+
+// state returns a snapshot of the current state
+func (b *editBuffer) state() editState {
+
+	text := make([]rune, len(b.text))
+	copy(text, b.text)
+	return editState{text, b.pos, b.anchor}
+}
+
+// restore restores the specified state
+func (b *editBuffer) restore(st editState) {
+
+	b.text = st.text
+	b.pos = st.pos
+	b.anchor = st.anchor
+	b.typing = false
+}
+
+// undoChange restores the previous state from the undo history.
+// Returns if there was a state to restore.
+func (b *editBuffer) undoChange() bool {
+
+	if len(b.undo) == 0 {
+		return false
+	}
+	b.redo = append(b.redo, b.state())
+	b.restore(b.undo[len(b.undo)-1])
+	b.undo = b.undo[:len(b.undo)-1]
+	return true
+}
+
+// redoChange restores the last undone state from the redo history.
+// Returns if there was a state to restore.
+func (b *editBuffer) redoChange() bool {
+
+	if len(b.redo) == 0 {
+		return false
+	}
+	b.undo = append(b.undo, b.state())
+	b.restore(b.redo[len(b.redo)-1])
+	b.redo = b.redo[:len(b.redo)-1]
+	return true
+}
+
+// wordLeft returns the position of the start of the word before the specified position
+func (b *editBuffer) wordLeft(pos int) int {
+
+	for pos > 0 && !isWordRune(b.text[pos-1]) {
+		pos--
+	}
+	for pos > 0 && isWordRune(b.text[pos-1]) {
+		pos--
+	}
+	return pos
+}
+
+// wordRight returns the position of the end of the word after the specified position
+func (b *editBuffer) wordRight(pos int) int {
+
+	for pos < len(b.text) && !isWordRune(b.text[pos]) {
+		pos++
+	}
+	for pos < len(b.text) && isWordRune(b.text[pos]) {
+		pos++
+	}
+	return pos
+}
+
+// isWordRune returns if the specified rune is part of a word
+func isWordRune(r rune) bool {
+
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_'
+}
+
+// editShortcut identifies the editing keyboard shortcuts common to the text widgets
+type editShortcut int
+
+const (
+	editNone = editShortcut(iota)
+	editSelectAll
+	editCopy
+	editCut
+	editPaste
+	editUndo
+	editRedo
+)
+
+// editShortcutOf returns the editing shortcut of the specified key event if any.
+// Both the Control and the Super (macOS Command) modifiers are accepted.
+func editShortcutOf(kev *window.KeyEvent) editShortcut {
+
+	if kev.Mods&(window.ModControl|window.ModSuper) == 0 {
+		return editNone
+	}
+	switch kev.Keycode {
+	case window.KeyA:
+		return editSelectAll
+	case window.KeyC:
+		return editCopy
+	case window.KeyX:
+		return editCut
+	case window.KeyV:
+		return editPaste
+	case window.KeyZ:
+		if kev.Mods&window.ModShift != 0 {
+			return editRedo
+		}
+		return editUndo
+	case window.KeyY:
+		return editRedo
+	}
+	return editNone
+}
+
+// clipboardString returns the contents of the clipboard of the specified
+// window or an empty string if the window has no clipboard
+func clipboardString(win window.IWindow) string {
+
+	if cb, ok := win.(window.IClipboard); ok {
+		return cb.ClipboardString()
+	}
+	return ""
+}
+
+// setClipboardString sets the contents of the clipboard of the specified
+// window if it has a clipboard
+func setClipboardString(win window.IWindow, s string) {
+
+	if cb, ok := win.(window.IClipboard); ok {
+		cb.SetClipboardString(s)
+	}
+}
@@ -28,7 +28,25 @@ const (
 	OnChild        = "gui.OnChild"                    // child added to or removed from panel
 	OnRadioGroup   = "gui.OnRadioGroup"               // radio button from a group changed state
 	OnRightClick   = "gui.OnRightClick"               // Widget clicked by mouse right button
+	OnPopupClose   = "gui.OnPopupClose"               // context menu or popover closed by the root overlay
+	OnDragEnter    = "gui.OnDragEnter"                // dragged payload entered drop target (DragEvent)
+	OnDragOver     = "gui.OnDragOver"                 // dragged payload moved over drop target (DragEvent)
+	OnDragLeave    = "gui.OnDragLeave"                // dragged payload left drop target or drag ended (DragEvent)
+	OnDrop         = "gui.OnDrop"                     // payload dropped on drop target which accepted it (DragEvent)
+	OnDragEnd      = "gui.OnDragEnd"                  // drag from drag source ended or was cancelled (DragEvent)
+	OnReorder      = "gui.OnReorder"                  // List, Tree, Table or TabBar items reordered by dragging (ReorderEvent)
+	OnDialogClose  = "gui.OnDialogClose"              // MessageBox or FileDialog closed (no parameters)
+	OnFocus        = "gui.OnFocus"                    // panel received the key focus (no parameters)
+	OnFocusLost    = "gui.OnFocusLost"                // panel lost the key focus (no parameters)
+	OnScale        = "gui.OnScale"                    // GUI scale factor changed and the panel text was rasterized again (no parameters)
 	OnBeforeRender = "util.application.OnAfterRender" // dispatched just before rendering the scene/gui
 	OnAfterRender  = "util.application.OnAfterRender" // dispatched just after rendering the scene/gui
 	OnQuit         = "util.application.OnQuit"        // the user tries to close the window or the application.Quit() method is called
 )
+
+// Text composition events of input methods (window.CompositionEvent)
+const (
+	OnCompositionStart  = window.OnCompositionStart  // input method starts composing text
+	OnCompositionUpdate = window.OnCompositionUpdate // input method changes the text being composed
+	OnCompositionCommit = window.OnCompositionCommit // input method commits the composed text
+)
@@ -0,0 +1,586 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gui
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/sansebasko/engine/gui/assets/icon"
+	"github.com/sansebasko/engine/math32"
+	"github.com/sansebasko/engine/window"
+)
+
+/***************************************
+
+ FileDialog
+ +--------------------------------------+
+ | Title                                |
+ +--------------------------------------+
+ | [^] [directory                     ] |
+ | +----------------------------------+ |
+ | | folder                           | |
+ | | file                             | |
+ | +----------------------------------+ |
+ | [name                   ] [filter v] |
+ |                    [ OK ] [ Cancel ] |
+ +--------------------------------------+
+
+**/
+
+// FileDialog is a modal window for choosing files or directories of the local file system.
+// Directories are entered by double clicking them, by the Enter key or by the OK button
+// while they are selected. It dispatches OnDialogClose when closed.
+type FileDialog struct {
+	Window                          // Embedded window
+	dialog                          // modal dialog state
+	mode         FileDialogMode     // dialog mode
+	upButton     *Button            // goes to the parent directory
+	dirEdit      *Edit              // current directory
+	list         *List              // entries of the current directory
+	nameEdit     *Edit              // file name
+	filter       *DropDown          // file filters
+	okButton     *Button            // accepts the file name or enters the selected directory
+	cancelButton *Button            // closes the dialog without a path
+	styles       *FileDialogStyle   // pointer to style
+	dir          string             // current directory
+	entries      []os.FileInfo      // entries of the list
+	filters      []fileDialogFilter // file filters
+	showHidden   bool               // shows hidden entries
+	path         string             // chosen path or empty
+	clickTime    time.Time          // time of the last click in the list
+	clickPos     int                // position of the last clicked entry
+}
+
+// FileDialogMode is the mode of a file dialog
+type FileDialogMode int
+
+// The modes of file dialogs
+const (
+	FileDialogOpen FileDialogMode = iota // chooses an existing file
+	FileDialogSave                       // chooses the name of a file to write, confirming overwrites
+	FileDialogDir                        // chooses a directory
+)
+
+// FileDialogStyle contains the styling of a FileDialog.
+// The frame and title of a file dialog are styled by the Window style.
+type FileDialogStyle struct {
+	Spacing     float32       // space around and between the internal panels
+	ButtonWidth float32       // minimum width of the OK and Cancel buttons
+	FilterWidth float32       // width of the filter drop down
+	FolderColor math32.Color4 // icon color of the directories
+	FileColor   math32.Color4 // icon color of the files
+}
+
+// fileDialogFilter is a filter of the files shown by a file dialog
+type fileDialogFilter struct {
+	desc     string   // description
+	patterns []string // file name patterns
+}
+
+// fileDialogDoubleClick is the maximum interval between clicks of a double click
+const fileDialogDoubleClick = 400 * time.Millisecond
+
+// NewFileDialog creates and returns a pointer to a new file dialog with the specified
+// mode and title showing the current working directory.
+// The file dialog must be shown by Show.
+func NewFileDialog(mode FileDialogMode, title string) *FileDialog {
+
+	fd := new(FileDialog)
+	fd.styles = &StyleDefault().FileDialog
+	fd.mode = mode
+	fd.clickPos = -1
+	fd.Window.initialize(480, 360)
+	fd.SetTitle(title)
+	fd.SetCloseButton(false)
+	fd.SetResizable(true)
+	fd.Subscribe(OnKeyDown, fd.onKey)
+	fd.client.Subscribe(OnResize, func(evname string, ev interface{}) { fd.layout() })
+
+	fd.upButton = NewButton("")
+	fd.upButton.SetIcon(icon.ArrowUpward)
+	fd.upButton.Subscribe(OnClick, func(evname string, ev interface{}) {
+		fd.changeDir(filepath.Dir(fd.dir))
+	})
+	fd.Window.Add(fd.upButton)
+
+	fd.dirEdit = NewEdit(0, "")
+	fd.dirEdit.MaxLength = 1024
+	fd.dirEdit.Subscribe(OnKeyDown, func(evname string, ev interface{}) {
+		kev := ev.(*window.KeyEvent)
+		if kev.Keycode == window.KeyEnter || kev.Keycode == window.KeyKPEnter {
+			fd.changeDir(fd.dirEdit.Text())
+			fd.root.StopPropagation(Stop3D)
+		}
+	})
+	fd.Window.Add(fd.dirEdit)
+
+	fd.list = NewVList(0, 0)
+	fd.list.Subscribe(OnChange, fd.onSelect)
+	fd.list.Subscribe(OnMouseDown, fd.onListMouse)
+	fd.list.Subscribe(OnKeyDown, func(evname string, ev interface{}) {
+		kev := ev.(*window.KeyEvent)
+		if kev.Keycode == window.KeyEnter || kev.Keycode == window.KeyKPEnter {
+			fd.activate()
+			fd.root.StopPropagation(Stop3D)
+		}
+	})
+	fd.Window.Add(fd.list)
+
+	fd.nameEdit = NewEdit(0, "")
+	fd.nameEdit.MaxLength = 1024
+	fd.nameEdit.Subscribe(OnKeyDown, func(evname string, ev interface{}) {
+		kev := ev.(*window.KeyEvent)
+		if kev.Keycode == window.KeyEnter || kev.Keycode == window.KeyKPEnter {
+			fd.accept()
+			fd.root.StopPropagation(Stop3D)
+		}
+	})
+	fd.Window.Add(fd.nameEdit)
+
+	fd.filter = NewDropDown(0, NewImageLabel(""))
+	fd.filter.Subscribe(OnChange, func(evname string, ev interface{}) { fd.reload() })
+	fd.Window.Add(fd.filter)
+
+	fd.okButton = NewButton("OK")
+	fd.okButton.Subscribe(OnClick, func(evname string, ev interface{}) { fd.accept() })
+	fd.Window.Add(fd.okButton)
+	fd.cancelButton = NewButton("Cancel")
+	fd.cancelButton.Subscribe(OnClick, func(evname string, ev interface{}) { fd.Close("") })
+	fd.Window.Add(fd.cancelButton)
+
+	// Name and filter are not used to choose directories
+	fd.nameEdit.SetVisible(mode != FileDialogDir)
+	fd.filter.SetVisible(false)
+
+	if wd, err := os.Getwd(); err == nil {
+		fd.SetDir(wd)
+	}
+	fd.update()
+	return fd
+}
+
+// SetStyles set the file dialog style overriding the default style
+func (fd *FileDialog) SetStyles(fs *FileDialogStyle) *FileDialog {
+
+	fd.styles = fs
+	fd.update()
+	return fd
+}
+
+// Mode returns the mode of the file dialog
+func (fd *FileDialog) Mode() FileDialogMode {
+
+	return fd.mode
+}
+
+// SetDir sets the current directory of the file dialog.
+// Returns an error if the directory cannot be read.
+func (fd *FileDialog) SetDir(dir string) error {
+
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return err
+	}
+	infos, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	fd.dir = dir
+	fd.dirEdit.SetText(dir)
+	fd.setEntries(infos)
+	return nil
+}
+
+// Dir returns the current directory of the file dialog
+func (fd *FileDialog) Dir() string {
+
+	return fd.dir
+}
+
+// SetFileName sets the file name shown in the name edit
+func (fd *FileDialog) SetFileName(name string) *FileDialog {
+
+	fd.nameEdit.SetText(name)
+	return fd
+}
+
+// FileName returns the file name shown in the name edit
+func (fd *FileDialog) FileName() string {
+
+	return fd.nameEdit.Text()
+}
+
+// SetFilters sets the filters of the files shown by the file dialog.
+// Each filter has an optional description and a list of file name patterns
+// separated by semicolons, as in "Images|*.png;*.jpg". The first filter is selected.
+// Without filters all the files are shown.
+func (fd *FileDialog) SetFilters(filters ...string) *FileDialog {
+
+	fd.filters = nil
+	for fd.filter.Len() > 0 {
+		fd.filter.RemoveAt(0)
+	}
+	for _, f := range filters {
+		var ff fileDialogFilter
+		patterns := f
+		if i := strings.Index(f, "|"); i >= 0 {
+			ff.desc = strings.TrimSpace(f[:i])
+			patterns = f[i+1:]
+		}
+		for _, p := range strings.Split(patterns, ";") {
+			if p = strings.TrimSpace(p); p != "" {
+				ff.patterns = append(ff.patterns, p)
+			}
+		}
+		if ff.desc == "" {
+			ff.desc = strings.Join(ff.patterns, ";")
+		} else {
+			ff.desc = fmt.Sprintf("%s (%s)", ff.desc, strings.Join(ff.patterns, ";"))
+		}
+		fd.filters = append(fd.filters, ff)
+		fd.filter.Add(NewImageLabel(ff.desc))
+	}
+	fd.filter.SetVisible(len(fd.filters) > 0 && fd.mode != FileDialogDir)
+	if len(fd.filters) > 0 {
+		fd.filter.SelectPos(0)
+	}
+	fd.reload()
+	fd.layout()
+	return fd
+}
+
+// SetShowHidden sets whether the entries with names starting with a dot are shown
+func (fd *FileDialog) SetShowHidden(state bool) *FileDialog {
+
+	fd.showHidden = state
+	fd.reload()
+	return fd
+}
+
+// Path returns the path chosen by the user or an empty string if the dialog
+// was cancelled or was not closed yet.
+func (fd *FileDialog) Path() string {
+
+	return fd.path
+}
+
+// Show shows the file dialog centered in the specified root as its modal panel
+func (fd *FileDialog) Show(root *Root) {
+
+	fd.path = ""
+	fd.dialog.show(root, fd)
+	if fd.mode == FileDialogDir {
+		fd.root.SetKeyFocus(fd.list)
+	} else {
+		fd.nameEdit.setFocus()
+	}
+}
+
+// Close closes the file dialog with the specified path and dispatches OnDialogClose
+func (fd *FileDialog) Close(path string) {
+
+	if !fd.dialog.close(fd) {
+		return
+	}
+	fd.path = path
+	fd.Dispatch(OnDialogClose, nil)
+}
+
+// reload reads the entries of the current directory again
+func (fd *FileDialog) reload() {
+
+	if fd.dir == "" {
+		return
+	}
+	infos, err := ioutil.ReadDir(fd.dir)
+	if err != nil {
+		return
+	}
+	fd.setEntries(infos)
+}
+
+// changeDir changes the current directory showing an error message if it cannot be read
+func (fd *FileDialog) changeDir(dir string) {
+
+	if err := fd.SetDir(dir); err != nil {
+		fd.dirEdit.SetText(fd.dir)
+		if fd.root != nil {
+			ShowMessageBox(fd.root, MessageBoxError, fd.titleText(), err.Error(), nil)
+		}
+	}
+}
+
+// titleText returns the title of the dialog
+func (fd *FileDialog) titleText() string {
+
+	if fd.title == nil {
+		return ""
+	}
+	return fd.title.label.Text()
+}
+
+// setEntries sets the entries of the list from the specified directory entries,
+// with the directories first and the files accepted by the current filter
+func (fd *FileDialog) setEntries(infos []os.FileInfo) {
+
+	fd.entries = fd.entries[:0]
+	for _, info := range infos {
+		name := info.Name()
+		if !fd.showHidden && strings.HasPrefix(name, ".") {
+			continue
+		}
+		if !info.IsDir() && (fd.mode == FileDialogDir || !fd.accepts(name)) {
+			continue
+		}
+		fd.entries = append(fd.entries, info)
+	}
+	sort.SliceStable(fd.entries, func(i, j int) bool {
+		di, dj := fd.entries[i].IsDir(), fd.entries[j].IsDir()
+		if di != dj {
+			return di
+		}
+		return strings.ToLower(fd.entries[i].Name()) < strings.ToLower(fd.entries[j].Name())
+	})
+
+	fd.list.Clear()
+	for _, info := range fd.entries {
+		item := NewImageLabel(info.Name())
+		if info.IsDir() {
+			item.SetIcon(icon.Folder)
+			item.icon.SetColor4(&fd.styles.FolderColor)
+		} else {
+			item.SetIcon(icon.InsertDriveFile)
+			item.icon.SetColor4(&fd.styles.FileColor)
+		}
+		fd.list.Add(item)
+	}
+	fd.clickPos = -1
+}
+
+// accepts returns if the specified file name is accepted by the current filter
+func (fd *FileDialog) accepts(name string) bool {
+
+	pos := fd.filter.SelectedPos()
+	if pos < 0 || pos >= len(fd.filters) {
+		return true
+	}
+	for _, p := range fd.filters[pos].patterns {
+		if ok, _ := filepath.Match(p, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// selected returns the selected entry of the list or nil
+func (fd *FileDialog) selected() os.FileInfo {
+
+	sel := fd.list.Selected()
+	if len(sel) == 0 {
+		return nil
+	}
+	pos := fd.list.ItemPosition(sel[0])
+	if pos < 0 || pos >= len(fd.entries) {
+		return nil
+	}
+	return fd.entries[pos]
+}
+
+// onSelect shows the name of the selected file in the name edit
+func (fd *FileDialog) onSelect(evname string, ev interface{}) {
+
+	info := fd.selected()
+	if info != nil && !info.IsDir() {
+		fd.nameEdit.SetText(info.Name())
+	}
+}
+
+// onListMouse enters the directory or accepts the file double clicked in the list
+func (fd *FileDialog) onListMouse(evname string, ev interface{}) {
+
+	mev := ev.(*window.MouseEvent)
+	if mev.Button != window.MouseButtonLeft {
+		return
+	}
+	pos := fd.list.itemPosAt(mev.Xpos, mev.Ypos)
+	now := time.Now()
+	if pos >= 0 && pos == fd.clickPos && now.Sub(fd.clickTime) < fileDialogDoubleClick {
+		fd.clickPos = -1
+		fd.activate()
+		return
+	}
+	fd.clickPos = pos
+	fd.clickTime = now
+}
+
+// activate enters the selected directory or accepts the selected file
+func (fd *FileDialog) activate() {
+
+	info := fd.selected()
+	if info == nil {
+		return
+	}
+	if info.IsDir() {
+		fd.changeDir(filepath.Join(fd.dir, info.Name()))
+		return
+	}
+	fd.accept()
+}
+
+// accept enters the selected or typed directory or closes the dialog with the chosen path
+func (fd *FileDialog) accept() {
+
+	// Chooses a directory
+	if fd.mode == FileDialogDir {
+		path := fd.dir
+		if info := fd.selected(); info != nil {
+			path = filepath.Join(fd.dir, info.Name())
+		}
+		fd.Close(path)
+		return
+	}
+
+	// Enters the selected directory if no file name was typed
+	name := strings.TrimSpace(fd.nameEdit.Text())
+	if name == "" {
+		if info := fd.selected(); info != nil && info.IsDir() {
+			fd.changeDir(filepath.Join(fd.dir, info.Name()))
+		}
+		return
+	}
+	path := name
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(fd.dir, name)
+	}
+	info, err := os.Stat(path)
+	// Enters a typed directory
+	if err == nil && info.IsDir() {
+		fd.nameEdit.SetText("")
+		fd.changeDir(path)
+		return
+	}
+
+	if fd.mode == FileDialogOpen {
+		if err != nil {
+			ShowMessageBox(fd.root, MessageBoxError, fd.titleText(),
+				fmt.Sprintf("%s\nFile not found.", name), nil)
+			return
+		}
+		fd.Close(path)
+		return
+	}
+
+	// Adds the extension of the current filter to saved files without extension
+	if err != nil && filepath.Ext(path) == "" {
+		if pos := fd.filter.SelectedPos(); pos >= 0 && pos < len(fd.filters) {
+			ext := filepath.Ext(fd.filters[pos].patterns[0])
+			if ext != "" && !strings.ContainsAny(ext, "*?[") {
+				path += ext
+				info, err = os.Stat(path)
+			}
+		}
+	}
+	if err != nil {
+		fd.Close(path)
+		return
+	}
+	ShowMessageBox(fd.root, MessageBoxQuestion, fd.titleText(),
+		fmt.Sprintf("%s already exists.\nDo you want to replace it?", filepath.Base(path)),
+		func(button int) {
+			if button == 0 {
+				fd.Close(path)
+			}
+		}, "Yes", "No")
+}
+
+// onKey process subscribed key events
+func (fd *FileDialog) onKey(evname string, ev interface{}) {
+
+	kev := ev.(*window.KeyEvent)
+	if kev.Keycode != window.KeyEscape {
+		return
+	}
+	fd.Close("")
+	fd.root.StopPropagation(Stop3D)
+}
+
+// update updates the file dialog visual state
+func (fd *FileDialog) update() {
+
+	fd.Window.update()
+	for i, info := range fd.entries {
+		item, ok := fd.list.ItemAt(i).(*ImageLabel)
+		if !ok || item.icon == nil {
+			continue
+		}
+		if info.IsDir() {
+			item.icon.SetColor4(&fd.styles.FolderColor)
+		} else {
+			item.icon.SetColor4(&fd.styles.FileColor)
+		}
+	}
+	fd.recalc()
+}
+
+// recalc recalculates the dimensions and positions of the internal panels
+func (fd *FileDialog) recalc() {
+
+	fd.Window.recalc()
+	fd.layout()
+}
+
+// layout sets the positions and sizes of the panels in the client area
+func (fd *FileDialog) layout() {
+
+	if fd.cancelButton == nil {
+		return
+	}
+	sp := fd.styles.Spacing
+	width := fd.client.ContentWidth()
+	height := fd.client.ContentHeight()
+
+	// Up button and directory
+	rowh := fd.dirEdit.Height()
+	fd.upButton.SetPosition(sp, sp)
+	fd.upButton.SetSize(rowh, rowh)
+	dx := sp + rowh + sp
+	fd.dirEdit.setWidth(width - dx - sp)
+	fd.dirEdit.SetPosition(dx, sp)
+
+	// Buttons
+	bw := math32.Max(fd.styles.ButtonWidth, math32.Max(fd.okButton.Width(), fd.cancelButton.Width()))
+	fd.okButton.SetWidth(bw)
+	fd.cancelButton.SetWidth(bw)
+	by := height - sp - fd.okButton.Height()
+	fd.cancelButton.SetPosition(width-sp-bw, by)
+	fd.okButton.SetPosition(width-2*(sp+bw), by)
+
+	// Name and filter
+	bottom := by - sp
+	if fd.nameEdit.Visible() {
+		ny := bottom - rowh
+		nw := width - 2*sp
+		if fd.filter.Visible() {
+			fw := fd.styles.FilterWidth
+			nw -= fw + sp
+			fd.filter.SetPosition(width-sp-fw, ny)
+			fd.filter.SetWidth(fw)
+		}
+		fd.nameEdit.setWidth(nw)
+		fd.nameEdit.SetPosition(sp, ny)
+		bottom = ny - sp
+	}
+
+	// List fills the remaining area
+	ly := sp + rowh + sp
+	fd.list.SetPosition(sp, ly)
+	fd.list.SetSize(math32.Max(width-2*sp, 0), math32.Max(bottom-ly, 0))
+}
@@ -0,0 +1,652 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gui
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/sansebasko/engine/math32"
+)
+
+// FlexLayout is a panel layout which arranges the panel children in lines along
+// a main axis, similar to the CSS flexible box layout.
+// The main axis is horizontal for the FlexRow and FlexRowReverse directions and
+// vertical for the FlexColumn and FlexColumnReverse directions.
+// The children are placed in a single line or wrapped into several lines when the
+// main size of the panel is not enough, depending on the wrap mode.
+// The main sizes of the children in each line grow or shrink to fill the line
+// accordingly to their grow and shrink factors, limited by their minimum and
+// maximum sizes, and the remaining space is distributed by the justify alignment.
+// The children are aligned in the cross axis of their lines by the align items
+// alignment or by their own alignments and the lines are aligned in the cross axis
+// of the panel by the align content alignment.
+//
+// The layout parameters of each child are set by SetLayoutParams() with a
+// pointer to FlexLayoutParams. Children without parameters neither grow nor
+// have size limits and shrink with a factor of 1.
+//
+// The layout is calculated by Compute() from the sizes of the items, without
+// accessing the panels, so it can also be used to test layouts.
+type FlexLayout struct {
+	pan          IPanel              // parent panel
+	direction    FlexDirection       // direction of the main axis
+	wrap         FlexWrapMode        // wrap mode
+	justify      FlexAlign           // alignment of the items in the main axis of the lines
+	alignItems   FlexAlign           // default alignment of the items in the cross axis of the lines
+	alignContent FlexAlign           // alignment of the lines in the cross axis
+	spacing      float32             // space between the items of a line
+	lineSpacing  float32             // space between lines
+	autoWidth    bool                // panel content width is set to the width of the items
+	autoHeight   bool                // panel content height is set to the height of the items
+	sizes        map[*Panel]flexSize // sizes of the children set by the last recalculation
+}
+
+// flexSize contains the size of a child before and after the last recalculation
+type flexSize struct {
+	natural math32.Vector2 // size of the child without growing, shrinking or stretching
+	set     math32.Vector2 // size set by the layout
+}
+
+// FlexDirection is the direction of the main axis of a flex layout
+type FlexDirection int
+
+// The flex layout directions
+const (
+	FlexRow           = FlexDirection(iota) // from left to right
+	FlexRowReverse                          // from right to left
+	FlexColumn                              // from top to bottom
+	FlexColumnReverse                       // from bottom to top
+)
+
+// FlexWrapMode specifies if the items of a flex layout are wrapped into several lines
+type FlexWrapMode int
+
+// The flex layout wrap modes
+const (
+	FlexNoWrap      = FlexWrapMode(iota) // single line
+	FlexWrap                             // lines from the start of the cross axis
+	FlexWrapReverse                      // lines from the end of the cross axis
+)
+
+// FlexAlign is the alignment of the items or lines of a flex layout
+type FlexAlign int
+
+// The flex layout alignments.
+// FlexAlignAuto is only valid for the alignment of an individual item.
+// FlexAlignStretch is equivalent to FlexAlignStart for the justify alignment and
+// the space distribution alignments are equivalent to FlexAlignStart for items.
+const (
+	FlexAlignAuto         = FlexAlign(iota) // alignment of the layout
+	FlexAlignStart                          // at the start of the axis
+	FlexAlignEnd                            // at the end of the axis
+	FlexAlignCenter                         // at the center of the axis
+	FlexAlignStretch                        // stretched to fill the axis
+	FlexAlignSpaceBetween                   // free space between each pair of adjacent items
+	FlexAlignSpaceAround                    // free space around each item
+	FlexAlignSpaceEvenly                    // free space evenly before, between and after the items
+)
+
+// FlexUnit is the unit of a flex length
+type FlexUnit int
+
+// The flex length units
+const (
+	FlexAuto    = FlexUnit(iota) // size determined by the item or no limit
+	FlexPixels                   // size in pixels
+	FlexPercent                  // percentage of the content size of the panel
+)
+
+// FlexLength is a size of a flex layout item.
+// The zero value is an automatic size.
+type FlexLength struct {
+	Value float32
+	Unit  FlexUnit
+}
+
+// FlexPx returns a flex length in pixels
+func FlexPx(value float32) FlexLength {
+
+	return FlexLength{Value: value, Unit: FlexPixels}
+}
+
+// FlexPct returns a flex length in percentage of the content size of the panel
+func FlexPct(value float32) FlexLength {
+
+	return FlexLength{Value: value, Unit: FlexPercent}
+}
+
+// ParseFlexLength parses a flex length from a string with a number of
+// pixels, such as "120" or "120px", a percentage, such as "50%", or "auto".
+func ParseFlexLength(s string) (FlexLength, error) {
+
+	s = strings.TrimSpace(strings.ToLower(s))
+	if s == "auto" || s == "" {
+		return FlexLength{}, nil
+	}
+	unit := FlexPixels
+	if strings.HasSuffix(s, "%") {
+		unit = FlexPercent
+		s = s[:len(s)-1]
+	} else {
+		s = strings.TrimSuffix(s, "px")
+	}
+	v, err := strconv.ParseFloat(strings.TrimSpace(s), 32)
+	if err != nil {
+		return FlexLength{}, fmt.Errorf("Invalid flex length:%s", s)
+	}
+	return FlexLength{Value: float32(v), Unit: unit}, nil
+}
+
+// String returns the textual representation of the flex length
+func (l FlexLength) String() string {
+
+	switch l.Unit {
+	case FlexPixels:
+		return strconv.FormatFloat(float64(l.Value), 'g', -1, 32) + "px"
+	case FlexPercent:
+		return strconv.FormatFloat(float64(l.Value), 'g', -1, 32) + "%"
+	}
+	return "auto"
+}
+
+// resolve returns the size in pixels of the length relative to the specified
+// reference size or the specified automatic size
+func (l FlexLength) resolve(ref, auto float32) float32 {
+
+	switch l.Unit {
+	case FlexPixels:
+		return l.Value
+	case FlexPercent:
+		return l.Value * ref / 100
+	}
+	return auto
+}
+
+// FlexLayoutParams specify the layout parameters of each individual child
+type FlexLayoutParams struct {
+	Grow      float32    // share of the free space of the line added to the main size (0 - no grow)
+	Shrink    float32    // share of the overflow of the line removed from the main size, weighted by the basis (0 - no shrink)
+	Basis     FlexLength // initial main size (auto - current size of the item)
+	MinWidth  FlexLength // minimum width (auto - 0)
+	MaxWidth  FlexLength // maximum width (auto - no limit)
+	MinHeight FlexLength // minimum height (auto - 0)
+	MaxHeight FlexLength // maximum height (auto - no limit)
+	AlignSelf FlexAlign  // alignment in the cross axis of the line (auto - align items of the layout)
+	Order     int        // items are placed in increasing order and then in the order of the children
+}
+
+// FlexItem is an item of the computation of a flex layout.
+// Its rectangle contains the current size of the item when the layout is computed
+// and is set to its computed position and size in the content area of the panel.
+type FlexItem struct {
+	Rect                     // position and size of the item
+	Params *FlexLayoutParams // layout parameters or nil for the default parameters
+}
+
+// flexDefault are the layout parameters of the children without layout parameters
+var flexDefault = FlexLayoutParams{Shrink: 1}
+
+// flexState contains the state of an item during the computation of a flex layout
+type flexState struct {
+	item      *FlexItem
+	params    *FlexLayoutParams
+	basis     float32 // flex base size
+	main      float32 // main size
+	cross     float32 // cross size
+	minMain   float32
+	maxMain   float32
+	minCross  float32
+	maxCross  float32
+	frozen    bool    // main size is final
+	violation float32 // adjustment of the main size by its limits
+	pos       float32 // position in the main axis
+	crossPos  float32 // position in the cross axis
+}
+
+// NewFlexLayout creates and returns a pointer to a new flex layout with the specified
+// direction, no wrapping, items justified at the start and stretched in the cross axis.
+func NewFlexLayout(direction FlexDirection) *FlexLayout {
+
+	fl := new(FlexLayout)
+	fl.direction = direction
+	fl.justify = FlexAlignStart
+	fl.alignItems = FlexAlignStretch
+	fl.alignContent = FlexAlignStretch
+	return fl
+}
+
+// SetDirection sets the direction of the main axis and updates the layout
+func (fl *FlexLayout) SetDirection(direction FlexDirection) {
+
+	fl.direction = direction
+	fl.Recalc(fl.pan)
+}
+
+// Direction returns the direction of the main axis
+func (fl *FlexLayout) Direction() FlexDirection {
+
+	return fl.direction
+}
+
+// SetWrap sets the wrap mode and updates the layout
+func (fl *FlexLayout) SetWrap(wrap FlexWrapMode) {
+
+	fl.wrap = wrap
+	fl.Recalc(fl.pan)
+}
+
+// Wrap returns the wrap mode
+func (fl *FlexLayout) Wrap() FlexWrapMode {
+
+	return fl.wrap
+}
+
+// SetJustify sets the alignment of the items in the main axis of
+// the lines and updates the layout
+func (fl *FlexLayout) SetJustify(align FlexAlign) {
+
+	fl.justify = align
+	fl.Recalc(fl.pan)
+}
+
+// Justify returns the alignment of the items in the main axis of the lines
+func (fl *FlexLayout) Justify() FlexAlign {
+
+	return fl.justify
+}
+
+// SetAlignItems sets the alignment of the items in the cross axis of
+// the lines and updates the layout.
+// The alignment of an individual item can be set by its layout parameters.
+func (fl *FlexLayout) SetAlignItems(align FlexAlign) {
+
+	fl.alignItems = align
+	fl.Recalc(fl.pan)
+}
+
+// AlignItems returns the alignment of the items in the cross axis of the lines
+func (fl *FlexLayout) AlignItems() FlexAlign {
+
+	return fl.alignItems
+}
+
+// SetAlignContent sets the alignment of the lines in the cross axis
+// of wrapping layouts and updates the layout
+func (fl *FlexLayout) SetAlignContent(align FlexAlign) {
+
+	fl.alignContent = align
+	fl.Recalc(fl.pan)
+}
+
+// AlignContent returns the alignment of the lines in the cross axis
+func (fl *FlexLayout) AlignContent() FlexAlign {
+
+	return fl.alignContent
+}
+
+// SetSpacing sets the space in pixels between the items of a line and updates the layout
+func (fl *FlexLayout) SetSpacing(spacing float32) {
+
+	fl.spacing = spacing
+	fl.Recalc(fl.pan)
+}
+
+// Spacing returns the space in pixels between the items of a line
+func (fl *FlexLayout) Spacing() float32 {
+
+	return fl.spacing
+}
+
+// SetLineSpacing sets the space in pixels between lines and updates the layout
+func (fl *FlexLayout) SetLineSpacing(spacing float32) {
+
+	fl.lineSpacing = spacing
+	fl.Recalc(fl.pan)
+}
+
+// LineSpacing returns the space in pixels between lines
+func (fl *FlexLayout) LineSpacing() float32 {
+
+	return fl.lineSpacing
+}
+
+// SetAutoWidth sets if the panel content width is set to the width
+// needed by its children and updates the layout
+func (fl *FlexLayout) SetAutoWidth(state bool) {
+
+	fl.autoWidth = state
+	fl.Recalc(fl.pan)
+}
+
+// SetAutoHeight sets if the panel content height is set to the height
+// needed by its children and updates the layout
+func (fl *FlexLayout) SetAutoHeight(state bool) {
+
+	fl.autoHeight = state
+	fl.Recalc(fl.pan)
+}
+
+// Recalc recalculates and sets the position and sizes of all children
+func (fl *FlexLayout) Recalc(ipan IPanel) {
+
+	// Saves the received panel
+	fl.pan = ipan
+	if fl.pan == nil {
+		return
+	}
+	parent := ipan.GetPanel()
+
+	// Get the items of the visible children
+	var panels []*Panel
+	for _, obj := range parent.Children() {
+		pan := obj.(IPanel).GetPanel()
+		if pan.Visible() {
+			panels = append(panels, pan)
+		}
+	}
+	if len(panels) == 0 {
+		return
+	}
+	// The natural sizes of the children are their current sizes,
+	// unless they were set by the last recalculation
+	natural := make([]math32.Vector2, len(panels))
+	for i, pan := range panels {
+		natural[i] = math32.Vector2{X: pan.Width(), Y: pan.Height()}
+		if size, ok := fl.sizes[pan]; ok && size.set == natural[i] {
+			natural[i] = size.natural
+		}
+	}
+	items := make([]FlexItem, len(panels))
+	initItems := func() {
+		for i, pan := range panels {
+			params, _ := pan.layoutParams.(*FlexLayoutParams)
+			items[i] = FlexItem{Rect: Rect{Width: natural[i].X, Height: natural[i].Y}, Params: params}
+		}
+	}
+	initItems()
+
+	// Sets the content size of the panel to the size needed by its children
+	width := parent.ContentWidth()
+	height := parent.ContentHeight()
+	if fl.autoWidth || fl.autoHeight {
+		cwidth, cheight := fl.Compute(width, height, items)
+		if fl.autoWidth {
+			width = cwidth
+		}
+		if fl.autoHeight {
+			height = cheight
+		}
+		parent.setContentSize(width, height, false)
+		width = parent.ContentWidth()
+		height = parent.ContentHeight()
+		initItems()
+	}
+
+	// Sets the positions and sizes of the children
+	fl.Compute(width, height, items)
+	fl.sizes = make(map[*Panel]flexSize, len(panels))
+	for i, pan := range panels {
+		it := &items[i]
+		pan.SetPosition(it.X, it.Y)
+		if it.Width != pan.Width() || it.Height != pan.Height() {
+			pan.SetSize(it.Width, it.Height)
+		}
+		fl.sizes[pan] = flexSize{natural[i], math32.Vector2{X: pan.Width(), Y: pan.Height()}}
+	}
+}
+
+// Compute computes the positions and sizes of the specified items in the
+// content area of a panel with the specified content width and height.
+// Returns the width and height needed by the items without growing or shrinking.
+func (fl *FlexLayout) Compute(width, height float32, items []FlexItem) (float32, float32) {
+
+	if len(items) == 0 {
+		return 0, 0
+	}
+	row := fl.direction == FlexRow || fl.direction == FlexRowReverse
+	mainSize, crossSize := width, height
+	if !row {
+		mainSize, crossSize = height, width
+	}
+
+	// Resolves the sizes and limits of the items in the order of the items
+	states := make([]*flexState, len(items))
+	for i := range items {
+		states[i] = fl.newState(&items[i], width, height, mainSize, row)
+	}
+	sort.SliceStable(states, func(i, j int) bool {
+		return states[i].params.Order < states[j].params.Order
+	})
+
+	// Collects the items into lines
+	var lines [][]*flexState
+	var line []*flexState
+	var used float32
+	for _, s := range states {
+		if fl.wrap != FlexNoWrap && len(line) > 0 && used+fl.spacing+s.main > mainSize {
+			lines = append(lines, line)
+			line = nil
+		}
+		if len(line) == 0 {
+			used = s.main
+		} else {
+			used += fl.spacing + s.main
+		}
+		line = append(line, s)
+	}
+	lines = append(lines, line)
+
+	// Calculates the natural size of the items and the cross sizes of the lines
+	var naturalMain, naturalCross float32
+	lineCross := make([]float32, len(lines))
+	for i, line := range lines {
+		var lmain float32
+		for _, s := range line {
+			lmain += s.main
+			if s.cross > lineCross[i] {
+				lineCross[i] = s.cross
+			}
+		}
+		lmain += fl.spacing * float32(len(line)-1)
+		naturalMain = math32.Max(naturalMain, lmain)
+		naturalCross += lineCross[i]
+	}
+	naturalCross += fl.lineSpacing * float32(len(lines)-1)
+	if fl.wrap == FlexNoWrap {
+		lineCross[0] = crossSize
+	}
+
+	// Resolves the main sizes and positions of the items of each line
+	for _, line := range lines {
+		fl.resolveLine(line, mainSize)
+		free := mainSize - fl.spacing*float32(len(line)-1)
+		for _, s := range line {
+			free -= s.main
+		}
+		pos, gap := flexDistribute(free, len(line), fl.justify)
+		for _, s := range line {
+			s.pos = pos
+			pos += s.main + fl.spacing + gap
+		}
+	}
+
+	// Aligns the lines in the cross axis
+	free := crossSize - fl.lineSpacing*float32(len(lines)-1)
+	for _, c := range lineCross {
+		free -= c
+	}
+	linePos, lineGap := float32(0), float32(0)
+	if fl.wrap != FlexNoWrap {
+		if fl.alignContent == FlexAlignStretch && free > 0 {
+			for i := range lineCross {
+				lineCross[i] += free / float32(len(lines))
+			}
+		} else {
+			linePos, lineGap = flexDistribute(free, len(lines), fl.alignContent)
+		}
+	}
+
+	// Aligns the items in the cross axis of their lines
+	for i, line := range lines {
+		for _, s := range line {
+			align := s.params.AlignSelf
+			if align == FlexAlignAuto {
+				align = fl.alignItems
+			}
+			switch align {
+			case FlexAlignStretch:
+				s.cross = math32.Max(s.minCross, math32.Min(lineCross[i], s.maxCross))
+				s.crossPos = 0
+			case FlexAlignEnd:
+				s.crossPos = lineCross[i] - s.cross
+			case FlexAlignCenter:
+				s.crossPos = (lineCross[i] - s.cross) / 2
+			default:
+				s.crossPos = 0
+			}
+			s.crossPos += linePos
+		}
+		linePos += lineCross[i] + fl.lineSpacing + lineGap
+	}
+
+	// Sets the rectangles of the items
+	for _, s := range states {
+		pos, crossPos := s.pos, s.crossPos
+		if fl.direction == FlexRowReverse || fl.direction == FlexColumnReverse {
+			pos = mainSize - pos - s.main
+		}
+		if fl.wrap == FlexWrapReverse {
+			crossPos = crossSize - crossPos - s.cross
+		}
+		if row {
+			s.item.Rect = Rect{X: pos, Y: crossPos, Width: s.main, Height: s.cross}
+		} else {
+			s.item.Rect = Rect{X: crossPos, Y: pos, Width: s.cross, Height: s.main}
+		}
+	}
+	if row {
+		return naturalMain, naturalCross
+	}
+	return naturalCross, naturalMain
+}
+
+// newState returns the state of the specified item with its sizes
+// resolved for a panel with the specified content size
+func (fl *FlexLayout) newState(it *FlexItem, width, height, mainSize float32, row bool) *flexState {
+
+	s := &flexState{item: it, params: it.Params}
+	if s.params == nil {
+		s.params = &flexDefault
+	}
+	p := s.params
+	inf := math32.Inf(1)
+	minWidth := p.MinWidth.resolve(width, 0)
+	maxWidth := math32.Max(minWidth, p.MaxWidth.resolve(width, inf))
+	minHeight := p.MinHeight.resolve(height, 0)
+	maxHeight := math32.Max(minHeight, p.MaxHeight.resolve(height, inf))
+	if row {
+		s.main, s.cross = it.Width, it.Height
+		s.minMain, s.maxMain, s.minCross, s.maxCross = minWidth, maxWidth, minHeight, maxHeight
+	} else {
+		s.main, s.cross = it.Height, it.Width
+		s.minMain, s.maxMain, s.minCross, s.maxCross = minHeight, maxHeight, minWidth, maxWidth
+	}
+	s.cross = math32.Max(s.minCross, math32.Min(s.cross, s.maxCross))
+	s.basis = p.Basis.resolve(mainSize, s.main)
+	s.main = math32.Max(s.minMain, math32.Min(s.basis, s.maxMain))
+	return s
+}
+
+// resolveLine grows or shrinks the main sizes of the items of a line to fill
+// the specified main size, freezing the items which reach their limits
+func (fl *FlexLayout) resolveLine(line []*flexState, mainSize float32) {
+
+	free := mainSize - fl.spacing*float32(len(line)-1)
+	for _, s := range line {
+		free -= s.main
+	}
+	grow := free > 0
+	for _, s := range line {
+		s.frozen = free == 0 || grow && s.params.Grow <= 0 || !grow && s.params.Shrink <= 0
+	}
+	for iter := 0; iter < len(line); iter++ {
+		remaining := mainSize - fl.spacing*float32(len(line)-1)
+		var factors float32
+		for _, s := range line {
+			if s.frozen {
+				remaining -= s.main
+				continue
+			}
+			remaining -= s.basis
+			factors += s.factor(grow)
+		}
+		if factors <= 0 {
+			return
+		}
+		var total float32
+		for _, s := range line {
+			if s.frozen {
+				continue
+			}
+			target := s.basis + remaining*s.factor(grow)/factors
+			s.main = math32.Max(s.minMain, math32.Min(math32.Max(target, 0), s.maxMain))
+			s.violation = s.main - target
+			total += s.violation
+		}
+		// Freezes the items which violate their limits in the direction of the total violation
+		done := true
+		for _, s := range line {
+			if s.frozen || s.violation == 0 {
+				continue
+			}
+			done = false
+			if total == 0 || total > 0 && s.violation > 0 || total < 0 && s.violation < 0 {
+				s.frozen = true
+			}
+		}
+		if done {
+			return
+		}
+	}
+}
+
+// factor returns the grow or the shrink factor of the item
+func (s *flexState) factor(grow bool) float32 {
+
+	if grow {
+		return s.params.Grow
+	}
+	return s.params.Shrink * s.basis
+}
+
+// flexDistribute returns the offset of the first of the specified number of
+// items and the additional space between items which distribute the specified
+// free space accordingly to the specified alignment
+func flexDistribute(free float32, count int, align FlexAlign) (float32, float32) {
+
+	n := float32(count)
+	switch align {
+	case FlexAlignEnd:
+		return free, 0
+	case FlexAlignCenter:
+		return free / 2, 0
+	case FlexAlignSpaceBetween:
+		if free > 0 && count > 1 {
+			return 0, free / (n - 1)
+		}
+	case FlexAlignSpaceAround:
+		if free > 0 {
+			return free / n / 2, free / n
+		}
+	case FlexAlignSpaceEvenly:
+		if free > 0 {
+			return free / (n + 1), free / (n + 1)
+		}
+	}
+	return 0, 0
+}
@@ -0,0 +1,127 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gui
+
+import (
+	"testing"
+)
+
+// Tests the computation of flex layouts in a 300x100 content area
+// with three items of 50x20, 100x30 and 50x40 pixels.
+func TestFlexLayoutCompute(t *testing.T) {
+
+	tests := []struct {
+		name   string
+		setup  func(fl *FlexLayout, params []*FlexLayoutParams)
+		width  float32
+		height float32
+		rects  []Rect
+	}{
+		{"row stretch", func(fl *FlexLayout, p []*FlexLayoutParams) {}, 300, 100,
+			[]Rect{{0, 0, 50, 100}, {50, 0, 100, 100}, {150, 0, 50, 100}}},
+		{"row reverse", func(fl *FlexLayout, p []*FlexLayoutParams) {
+			fl.SetDirection(FlexRowReverse)
+			fl.SetAlignItems(FlexAlignStart)
+		}, 300, 100,
+			[]Rect{{250, 0, 50, 20}, {150, 0, 100, 30}, {100, 0, 50, 40}}},
+		{"column center", func(fl *FlexLayout, p []*FlexLayoutParams) {
+			fl.SetDirection(FlexColumn)
+			fl.SetAlignItems(FlexAlignCenter)
+			fl.SetJustify(FlexAlignEnd)
+		}, 300, 100,
+			[]Rect{{125, 10, 50, 20}, {100, 30, 100, 30}, {125, 60, 50, 40}}},
+		{"grow with max", func(fl *FlexLayout, p []*FlexLayoutParams) {
+			fl.SetSpacing(10)
+			p[0].Grow = 1
+			p[1].Grow = 1
+			p[1].MaxWidth = FlexPx(100)
+			p[2].Grow = 3
+		}, 300, 100,
+			[]Rect{{0, 0, 70, 100}, {80, 0, 100, 100}, {190, 0, 110, 100}}},
+		{"shrink weighted by basis", func(fl *FlexLayout, p []*FlexLayoutParams) {
+			p[0].Basis = FlexPct(50)
+			p[1].Basis = FlexPx(150)
+			p[2].Shrink = 0
+		}, 300, 100,
+			[]Rect{{0, 0, 125, 100}, {125, 0, 125, 100}, {250, 0, 50, 100}}},
+		{"wrap and align content", func(fl *FlexLayout, p []*FlexLayoutParams) {
+			fl.SetWrap(FlexWrap)
+			fl.SetAlignItems(FlexAlignStart)
+			fl.SetAlignContent(FlexAlignSpaceBetween)
+			fl.SetLineSpacing(10)
+		}, 180, 100,
+			[]Rect{{0, 0, 50, 20}, {50, 0, 100, 30}, {0, 60, 50, 40}}},
+		{"wrap reverse and space evenly", func(fl *FlexLayout, p []*FlexLayoutParams) {
+			fl.SetWrap(FlexWrapReverse)
+			fl.SetJustify(FlexAlignSpaceEvenly)
+			fl.SetAlignContent(FlexAlignStart)
+			p[0].AlignSelf = FlexAlignEnd
+		}, 180, 100,
+			[]Rect{{10, 70, 50, 20}, {70, 70, 100, 30}, {65, 30, 50, 40}}},
+		{"order and min height", func(fl *FlexLayout, p []*FlexLayoutParams) {
+			fl.SetAlignItems(FlexAlignStart)
+			p[0].Order = 1
+			p[1].MinHeight = FlexPct(50)
+		}, 300, 100,
+			[]Rect{{150, 0, 50, 20}, {0, 0, 100, 50}, {100, 0, 50, 40}}},
+	}
+	for _, test := range tests {
+		fl := NewFlexLayout(FlexRow)
+		params := []*FlexLayoutParams{{Shrink: 1}, {Shrink: 1}, {Shrink: 1}}
+		test.setup(fl, params)
+		items := []FlexItem{
+			{Rect: Rect{Width: 50, Height: 20}, Params: params[0]},
+			{Rect: Rect{Width: 100, Height: 30}, Params: params[1]},
+			{Rect: Rect{Width: 50, Height: 40}, Params: params[2]},
+		}
+		fl.Compute(test.width, test.height, items)
+		for i, it := range items {
+			if it.Rect != test.rects[i] {
+				t.Errorf("%s: item %d is %v, expected %v", test.name, i, it.Rect, test.rects[i])
+			}
+		}
+	}
+}
+
+// Tests the size needed by the items of a flex layout
+func TestFlexLayoutNaturalSize(t *testing.T) {
+
+	fl := NewFlexLayout(FlexRow)
+	fl.SetWrap(FlexWrap)
+	fl.SetSpacing(5)
+	fl.SetLineSpacing(10)
+	items := []FlexItem{
+		{Rect: Rect{Width: 50, Height: 20}},
+		{Rect: Rect{Width: 100, Height: 30}},
+		{Rect: Rect{Width: 50, Height: 40}},
+	}
+	width, height := fl.Compute(160, 0, items)
+	if width != 155 || height != 80 {
+		t.Errorf("natural size is %vx%v, expected 155x80", width, height)
+	}
+}
+
+// Tests the parsing of flex lengths
+func TestParseFlexLength(t *testing.T) {
+
+	tests := []struct {
+		text   string
+		length FlexLength
+	}{
+		{"auto", FlexLength{}},
+		{"120", FlexPx(120)},
+		{" 12.5px", FlexPx(12.5)},
+		{"50%", FlexPct(50)},
+	}
+	for _, test := range tests {
+		l, err := ParseFlexLength(test.text)
+		if err != nil || l != test.length {
+			t.Errorf("%q parsed as %v (%v), expected %v", test.text, l, err, test.length)
+		}
+	}
+	if _, err := ParseFlexLength("wide"); err == nil {
+		t.Errorf("invalid length parsed")
+	}
+}
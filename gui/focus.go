@@ -0,0 +1,164 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gui
+
+import (
+	"sort"
+
+	"github.com/sansebasko/engine/math32"
+	"github.com/sansebasko/engine/window"
+)
+
+// The Tab and Shift+Tab keys move the key focus of the root panel to the next and
+// previous panel of the focus order, unless the focused panel uses the key and stops
+// its propagation to the GUI. The focus order contains the enabled and visible
+// focusable panels inside the modal panel, if any. Panels with positive tab indices
+// come first in increasing order of their indices, followed by the other panels in
+// document order: the order of a depth first traversal of the panel tree.
+// When the focus is moved by the keyboard, the overlay shows a focus ring around
+// the focused panel until a mouse button is pressed.
+
+// FocusRingStyle contains the styling of the focus ring
+type FocusRingStyle struct {
+	Border      RectBounds    // sizes of the ring borders
+	BorderColor math32.Color4 // color of the ring
+	Offset      float32       // distance between the ring and the borders of the focused panel
+}
+
+// FocusNext moves the key focus to the next panel of the focus order
+func (r *Root) FocusNext() {
+
+	r.moveFocus(1)
+}
+
+// FocusPrev moves the key focus to the previous panel of the focus order
+func (r *Root) FocusPrev() {
+
+	r.moveFocus(-1)
+}
+
+// FocusOrder returns the panels which receive the key focus
+// by keyboard navigation in the order they are visited.
+func (r *Root) FocusOrder() []IPanel {
+
+	var indexed, order []IPanel
+	var walk func(ipan IPanel)
+	walk = func(ipan IPanel) {
+		p := ipan.GetPanel()
+		if !p.Visible() {
+			return
+		}
+		if p.focusable && p.tabIndex >= 0 && p.Enabled() {
+			if p.tabIndex > 0 {
+				indexed = append(indexed, ipan)
+			} else {
+				order = append(order, ipan)
+			}
+		}
+		for _, child := range p.Children() {
+			if ichild, ok := child.(IPanel); ok {
+				walk(ichild)
+			}
+		}
+	}
+	if r.modalPanel != nil {
+		walk(r.modalPanel)
+	} else {
+		walk(r)
+	}
+	sort.SliceStable(indexed, func(i, j int) bool {
+		return indexed[i].GetPanel().tabIndex < indexed[j].GetPanel().tabIndex
+	})
+	return append(indexed, order...)
+}
+
+// SetFocusVisible sets if the focus ring is shown around the panel with the key focus.
+// It is shown when the focus is moved by the keyboard and hidden when a mouse button is pressed.
+func (r *Root) SetFocusVisible(state bool) {
+
+	r.focusVisible = state
+	if state {
+		r.overlayLayer()
+	}
+}
+
+// FocusVisible returns if the focus ring is shown around the panel with the key focus
+func (r *Root) FocusVisible() bool {
+
+	return r.focusVisible
+}
+
+// focusKey moves the key focus if the specified key event is of the Tab key
+func (r *Root) focusKey(kev *window.KeyEvent) {
+
+	if kev.Keycode != window.KeyTab {
+		return
+	}
+	switch kev.Mods {
+	case 0:
+		r.FocusNext()
+	case window.ModShift:
+		r.FocusPrev()
+	default:
+		return
+	}
+	r.stopPropagation |= Stop3D
+}
+
+// moveFocus moves the key focus to the panel of the focus order in the specified
+// direction from the focused panel, or from its nearest ancestor in the focus order.
+func (r *Root) moveFocus(dir int) {
+
+	order := r.FocusOrder()
+	if len(order) == 0 {
+		return
+	}
+	cur := -1
+	for ipan := r.keyFocus; ipan != nil && cur < 0; {
+		for i, curr := range order {
+			if curr.GetPanel() == ipan.GetPanel() {
+				cur = i
+				break
+			}
+		}
+		ipan, _ = ipan.GetPanel().Parent().(IPanel)
+	}
+	next := 0
+	if cur >= 0 {
+		next = (cur + dir + len(order)) % len(order)
+	} else if dir < 0 {
+		next = len(order) - 1
+	}
+	r.SetKeyFocus(order[next])
+	r.SetFocusVisible(true)
+}
+
+// updateFocusRing shows the focus ring around the panel with the
+// key focus if the focus is visible or hides it otherwise
+func (o *overlay) updateFocusRing() {
+
+	r := o.root
+	if !r.focusVisible || r.keyFocus == nil || !o.shown(r.keyFocus) {
+		o.ring.SetVisible(false)
+		return
+	}
+	s := &StyleDefault().FocusRing
+	o.ring.ApplyStyle(&PanelStyle{Border: s.Border, BorderColor: s.BorderColor})
+	// Surrounds the borders of the focused panel
+	pan := r.keyFocus.GetPanel()
+	x := pan.pospix.X - o.pospix.X + pan.marginSizes.Left - s.Offset - s.Border.Left
+	y := pan.pospix.Y - o.pospix.Y + pan.marginSizes.Top - s.Offset - s.Border.Top
+	width := pan.width - pan.marginSizes.Left - pan.marginSizes.Right + 2*s.Offset + s.Border.Left + s.Border.Right
+	height := pan.height - pan.marginSizes.Top - pan.marginSizes.Bottom + 2*s.Offset + s.Border.Top + s.Border.Bottom
+	o.ring.SetPosition(x, y)
+	o.ring.SetSize(width, height)
+	o.ring.SetVisible(true)
+}
+
+// activateKey returns if the specified key activates the focused button or check box
+func activateKey(key window.Key) bool {
+
+	return key == window.KeyEnter || key == window.KeySpace
+}
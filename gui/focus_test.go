@@ -0,0 +1,199 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gui
+
+import (
+	"testing"
+
+	"github.com/sansebasko/engine/window"
+)
+
+// testWindow is a window of 400x300 pixels for the tests of root panels
+type testWindow struct {
+	window.IWindow
+}
+
+func (testWindow) Scale() (float64, float64)               { return 1, 1 }
+func (testWindow) ContentScale() (float64, float64)        { return 1, 1 }
+func (testWindow) FramebufferSize() (int, int)             { return 400, 300 }
+func (testWindow) CancelDispatch()                         {}
+func (testWindow) SetStandardCursor(window.StandardCursor) {}
+
+// newTestRoot returns a root panel of the test window which does not
+// subscribe to the window events; the tests call its event handlers.
+func newTestRoot() *Root {
+
+	r := new(Root)
+	r.win = testWindow{}
+	r.root = r
+	r.Panel.Initialize(0, 0)
+	r.TimerManager.Initialize()
+	r.scale = guiScale.value
+	r.SetSize(400, 300)
+	return r
+}
+
+// Tests the focus order of panels with and without tab indices, of hidden
+// and disabled panels and the focus moved by the Tab and Shift+Tab keys.
+func TestFocusOrder(t *testing.T) {
+
+	r := newTestRoot()
+	b1 := NewButton("One")
+	b2 := NewButton("Two")
+	ed := NewEdit(100, "")
+	cb := NewCheckBox("Check")
+	hidden := NewPanel(10, 10)
+	hidden.Add(NewButton("Hidden"))
+	hidden.SetVisible(false)
+	dis := NewButton("Disabled")
+	dis.SetEnabled(false)
+	skip := NewButton("Skipped")
+	skip.SetTabIndex(-1)
+	r.Add(b1)
+	r.Add(b2)
+	r.Add(hidden)
+	r.Add(ed)
+	r.Add(dis)
+	r.Add(skip)
+	r.Add(cb)
+	cb.SetTabIndex(2)
+	b2.SetTabIndex(1)
+
+	expected := []IPanel{b2, cb, b1, ed}
+	order := r.FocusOrder()
+	if len(order) != len(expected) {
+		t.Fatalf("focus order of %d panels, expected %d", len(order), len(expected))
+	}
+	for i := range order {
+		if order[i] != expected[i] {
+			t.Errorf("panel %d of the focus order is %q", i, accessText(order[i]))
+		}
+	}
+
+	tests := []struct {
+		mods     window.ModifierKey
+		expected IPanel
+	}{
+		{0, b2},
+		{0, cb},
+		{0, b1},
+		{0, ed},
+		{0, b2},
+		{window.ModShift, ed},
+		{window.ModShift, b1},
+	}
+	for i, test := range tests {
+		r.onKey(OnKeyDown, &window.KeyEvent{Keycode: window.KeyTab, Mods: test.mods})
+		if r.KeyFocus() != test.expected {
+			t.Errorf("key %d focused %q, expected %q", i, accessText(r.KeyFocus()), accessText(test.expected))
+		}
+	}
+	if !r.FocusVisible() {
+		t.Error("focus ring not visible after Tab")
+	}
+	r.onMouse(OnMouseDown, &window.MouseEvent{Xpos: 399, Ypos: 299, Button: window.MouseButtonLeft})
+	if r.FocusVisible() {
+		t.Error("focus ring visible after a mouse button press")
+	}
+}
+
+// Tests that the focus order is limited to the modal panel
+func TestFocusModal(t *testing.T) {
+
+	r := newTestRoot()
+	b := NewButton("Outside")
+	r.Add(b)
+	w := NewWindow(100, 100)
+	mb1 := NewButton("Modal 1")
+	mb2 := NewButton("Modal 2")
+	w.Add(mb1)
+	w.Add(mb2)
+	r.Add(w)
+	r.SetModal(w)
+
+	expected := []IPanel{mb1, mb2, mb1}
+	for i, ipan := range expected {
+		r.FocusNext()
+		if r.KeyFocus() != ipan {
+			t.Errorf("Tab %d focused %q, expected %q", i, accessText(r.KeyFocus()), accessText(ipan))
+		}
+	}
+	r.SetModal(nil)
+	if order := r.FocusOrder(); len(order) != 3 || order[0] != b {
+		t.Errorf("focus order of %d panels without modal panel, expected 3", len(order))
+	}
+}
+
+// Tests the roles, names, values and states of the accessibility tree nodes of basic widgets
+func TestAccessTree(t *testing.T) {
+
+	r := newTestRoot()
+	b1 := NewButton("One")
+	r.Add(b1)
+	hidden := NewButton("Hidden")
+	hidden.SetVisible(false)
+	r.Add(hidden)
+	dis := NewButton("Disabled")
+	dis.SetEnabled(false)
+	r.Add(dis)
+	named := NewButton("x")
+	named.SetAccessName("Close")
+	r.Add(named)
+	ed := NewEdit(100, "name")
+	ed.SetText("text")
+	r.Add(ed)
+	cb := NewCheckBox("Check")
+	cb.SetValue(true)
+	r.Add(cb)
+	r.Add(NewLabel("Label"))
+	w := NewWindow(100, 100)
+	w.Add(NewButton("Inside"))
+	r.Add(w)
+	r.SetKeyFocus(b1)
+
+	tree := r.AccessTree()
+	tests := []struct {
+		role    AccessRole
+		name    string
+		value   string
+		state   AccessState
+		missing bool
+	}{
+		{RoleButton, "One", "", StateFocusable | StateFocused, false},
+		{RoleButton, "Hidden", "", 0, true},
+		{RoleButton, "Disabled", "", StateDisabled, false},
+		{RoleButton, "Close", "", StateFocusable, false},
+		{RoleButton, "x", "", 0, true},
+		{RoleTextBox, "name", "text", StateFocusable, false},
+		{RoleCheckBox, "Check", "", StateChecked, false},
+		{RoleText, "Label", "", 0, false},
+		{RoleWindow, "", "", 0, false},
+	}
+	for _, test := range tests {
+		n := tree.Find(test.role, test.name)
+		if test.missing {
+			if n != nil {
+				t.Errorf("%v %q found, expected missing", test.role, test.name)
+			}
+			continue
+		}
+		if n == nil {
+			t.Errorf("%v %q not found", test.role, test.name)
+			continue
+		}
+		if n.Value != test.value {
+			t.Errorf("%v %q value %q, expected %q", test.role, test.name, n.Value, test.value)
+		}
+		if !n.Has(test.state) {
+			t.Errorf("%v %q states %q, expected %q", test.role, test.name, n.State, test.state)
+		}
+	}
+	if tree.Find(RoleWindow, "").Find(RoleButton, "Inside") == nil {
+		t.Error("window node does not contain its button")
+	}
+	if n := len(tree.FindAll(RoleButton)); n != 4 {
+		t.Errorf("%d button nodes, expected 4", n)
+	}
+}
@@ -6,6 +6,7 @@ package gui
 
 import (
 	"github.com/sansebasko/engine/math32"
+	"github.com/sansebasko/engine/window"
 )
 
 // Folder represents a folder GUI element.
@@ -69,6 +70,8 @@ func (f *Folder) Initialize(text string, width float32, contentPanel IPanel) {
 	f.Panel.Subscribe(OnMouseDown, f.onMouse)
 	f.Panel.Subscribe(OnCursorEnter, f.onCursor)
 	f.Panel.Subscribe(OnCursorLeave, f.onCursor)
+	f.Panel.Subscribe(OnKeyDown, f.onKey)
+	f.Panel.SetFocusable(true)
 
 	f.alignRight = true
 	f.update()
@@ -106,19 +109,35 @@ func (f *Folder) onMouse(evname string, ev interface{}) {
 
 	switch evname {
 	case OnMouseDown:
-		cont := f.contentPanel.GetPanel()
-		if !cont.Visible() {
-			cont.SetVisible(true)
-		} else {
-			cont.SetVisible(false)
-		}
-		f.update()
-		f.recalc()
+		f.toggle()
 	default:
 		return
 	}
 }
 
+// onKey receives key events for the folder panel with the key focus
+func (f *Folder) onKey(evname string, ev interface{}) {
+
+	if !activateKey(ev.(*window.KeyEvent).Keycode) {
+		return
+	}
+	f.toggle()
+	f.root.StopPropagation(Stop3D)
+}
+
+// toggle shows or hides the content panel
+func (f *Folder) toggle() {
+
+	cont := f.contentPanel.GetPanel()
+	if !cont.Visible() {
+		cont.SetVisible(true)
+	} else {
+		cont.SetVisible(false)
+	}
+	f.update()
+	f.recalc()
+}
+
 // onCursor receives cursor events over the folder panel
 func (f *Folder) onCursor(evname string, ev interface{}) {
 
@@ -74,7 +74,10 @@ func NewImageButton(normalImgPath string) (*ImageButton, error) {
 	b.Panel.Subscribe(OnCursorEnter, b.onCursor)
 	b.Panel.Subscribe(OnCursorLeave, b.onCursor)
 	b.Panel.Subscribe(OnEnable, func(name string, ev interface{}) { b.update() })
+	b.Panel.Subscribe(OnFocus, func(name string, ev interface{}) { b.update() })
+	b.Panel.Subscribe(OnFocusLost, func(name string, ev interface{}) { b.update() })
 	b.Panel.Subscribe(OnResize, func(name string, ev interface{}) { b.recalc() })
+	b.Panel.SetFocusable(true)
 
 	b.recalc()
 	b.update()
@@ -202,14 +205,14 @@ func (b *ImageButton) onMouse(evname string, ev interface{}) {
 func (b *ImageButton) onKey(evname string, ev interface{}) {
 
 	kev := ev.(*window.KeyEvent)
-	if evname == OnKeyDown && kev.Keycode == window.KeyEnter {
+	if evname == OnKeyDown && activateKey(kev.Keycode) {
 		b.pressed = true
 		b.update()
 		b.Dispatch(OnClick, nil)
 		b.root.StopPropagation(Stop3D)
 		return
 	}
-	if evname == OnKeyUp && kev.Keycode == window.KeyEnter {
+	if evname == OnKeyUp && activateKey(kev.Keycode) {
 		b.pressed = false
 		b.update()
 		b.root.StopPropagation(Stop3D)
@@ -243,6 +246,10 @@ func (b *ImageButton) update() {
 		return
 	}
 	b.image.SetTexture(b.stateImages[ButtonNormal])
+	if b.root != nil && b.root.HasKeyFocus(b) {
+		b.applyStyle(&b.styles.Focus)
+		return
+	}
 	b.applyStyle(&b.styles.Normal)
 }
 
@@ -24,6 +24,11 @@ type ItemScroller struct {
 	focus          bool                // has keyboard focus
 	cursorOver     bool                // mouse is over the list
 	autoButtonSize bool                // scroll button size is adjusted relative to content/view
+	model          ListModel           // data model of virtual scrollers
+	mfirst         int                 // model position of the first item of virtual scrollers
+	mfree          []IPanel            // recycled item panels of virtual scrollers
+	mcount         int                 // model item count when the scroller was last updated
+	mdirty         bool                // the visible items must be fetched again from the model
 	scrollBarEvent bool
 }
 
@@ -69,6 +74,8 @@ func (s *ItemScroller) Clear() {
 	s.hscroll = nil
 	s.vscroll = nil
 	s.items = s.items[0:0]
+	s.mfree = nil
+	s.mdirty = true
 	s.update()
 	s.recalc()
 }
@@ -76,6 +83,9 @@ func (s *ItemScroller) Clear() {
 // Len return the number of items in the scroller
 func (s *ItemScroller) Len() int {
 
+	if s.model != nil {
+		return s.model.Len()
+	}
 	return len(s.items)
 }
 
@@ -89,6 +99,9 @@ func (s *ItemScroller) Add(item IPanel) {
 func (s *ItemScroller) InsertAt(pos int, item IPanel) {
 
 	// Validates position
+	if s.model != nil {
+		panic("ItemScroller.InsertAt(): Items provided by the model")
+	}
 	if pos < 0 || pos > len(s.items) {
 		panic("ItemScroller.InsertAt(): Invalid position")
 	}
@@ -118,6 +131,9 @@ func (s *ItemScroller) InsertAt(pos int, item IPanel) {
 func (s *ItemScroller) RemoveAt(pos int) IPanel {
 
 	// Validates position
+	if s.model != nil {
+		panic("ItemScroller.RemoveAt(): Items provided by the model")
+	}
 	if pos < 0 || pos >= len(s.items) {
 		panic("ItemScroller.RemoveAt(): Invalid position")
 	}
@@ -149,22 +165,24 @@ func (s *ItemScroller) Remove(item IPanel) {
 }
 
 // ItemAt returns the item at the specified position.
-// Returns nil if the position is invalid.
+// Returns nil if the position is invalid or, for virtual scrollers,
+// if the item is not visible.
 func (s *ItemScroller) ItemAt(pos int) IPanel {
 
-	if pos < 0 || pos >= len(s.items) {
+	i := s.itemIndex(pos)
+	if i < 0 {
 		return nil
 	}
-	return s.items[pos]
+	return s.items[i]
 }
 
 // ItemPosition returns the position of the specified item in
 // the scroller of -1 if not found
 func (s *ItemScroller) ItemPosition(item IPanel) int {
 
-	for pos := 0; pos < len(s.items); pos++ {
-		if s.items[pos] == item {
-			return pos
+	for i := 0; i < len(s.items); i++ {
+		if s.items[i] == item {
+			return s.itemPos(i)
 		}
 	}
 	return -1
@@ -213,6 +231,17 @@ func (s *ItemScroller) ItemVisible(pos int) bool {
 	if pos < s.first {
 		return false
 	}
+	if s.model != nil {
+		i := s.itemIndex(pos)
+		if i < 0 {
+			return false
+		}
+		item := s.items[i].GetPanel()
+		if s.vert {
+			return item.Position().Y+item.Height() <= s.height
+		}
+		return item.Position().X+item.Width() <= s.width
+	}
 
 	// Vertical scroller
 	if s.vert {
@@ -340,7 +369,7 @@ func (s *ItemScroller) onResize(evname string, ev interface{}) {
 // autoSize resizes the scroller if necessary
 func (s *ItemScroller) autoSize() {
 
-	if s.maxAutoWidth == 0 && s.maxAutoHeight == 0 {
+	if s.model != nil || s.maxAutoWidth == 0 && s.maxAutoHeight == 0 {
 		return
 	}
 
@@ -371,6 +400,10 @@ func (s *ItemScroller) autoSize() {
 // recalc recalculates the positions and visibilities of all the items
 func (s *ItemScroller) recalc() {
 
+	if s.model != nil {
+		s.recalcModel()
+		return
+	}
 	if s.vert {
 		s.vRecalc()
 	} else {
@@ -512,6 +545,10 @@ func (s *ItemScroller) hRecalc() {
 // maxFirst returns the maximum position of the first visible item
 func (s *ItemScroller) maxFirst() int {
 
+	if s.model != nil {
+		return s.maxFirstModel()
+	}
+
 	// Vertical scroller
 	if s.vert {
 		var height float32
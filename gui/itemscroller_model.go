@@ -0,0 +1,276 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gui
+
+// A scroller or list with a model is virtual: its items are not stored in the
+// scroller but fetched from the model only when they are visible, and the panels of
+// the items which are scrolled out of view are given back to the model to be reused
+// for other items. This allows lists with millions of items.
+// All the items of virtual scrollers are assumed to have the same height
+// (width for horizontal scrollers) as the first visible item.
+
+// ListModel is the interface for the data models of virtual scrollers and lists
+type ListModel interface {
+	// Len returns the current number of items
+	Len() int
+	// Item returns the panel showing the item at the specified position.
+	// If recycled is not nil it is a panel previously returned by Item which is
+	// no longer visible and should be updated and returned if possible.
+	Item(pos int, recycled IPanel) IPanel
+}
+
+// SetModel sets the data model of this scroller, making it a virtual scroller,
+// or returns it to a normal scroller if the model is nil.
+// All current items are removed from the scroller.
+// ModelChanged must be called when the items of the model change.
+func (s *ItemScroller) SetModel(m ListModel) {
+
+	s.setModel(m)
+}
+
+// Model returns the data model of this virtual scroller or nil
+func (s *ItemScroller) Model() ListModel {
+
+	return s.model
+}
+
+// ModelChanged updates this virtual scroller after items of its model changed.
+// The visible items are fetched again from the model and if the last item was
+// visible the scroller is scrolled to keep it visible, so appended items are shown.
+func (s *ItemScroller) ModelChanged() {
+
+	if s.model == nil {
+		return
+	}
+	tail := s.mfirst+len(s.items) >= s.mcount
+	s.mdirty = true
+	if max := s.maxFirst(); tail || s.first > max {
+		s.first = max
+	}
+	s.recalc()
+}
+
+// setModel sets the data model of this scroller and returns the removed item panels
+func (s *ItemScroller) setModel(m ListModel) []IPanel {
+
+	removed := append(s.items, s.mfree...)
+	for _, item := range removed {
+		s.Panel.Remove(item)
+	}
+	s.items = nil
+	s.mfree = nil
+	s.model = m
+	s.first = 0
+	s.mfirst = 0
+	s.mdirty = true
+	s.recalc()
+	return removed
+}
+
+// itemIndex returns the index in the items array of the item
+// at the specified position or -1 if not found
+func (s *ItemScroller) itemIndex(pos int) int {
+
+	if s.model != nil {
+		pos -= s.mfirst
+	}
+	if pos < 0 || pos >= len(s.items) {
+		return -1
+	}
+	return pos
+}
+
+// itemPos returns the position of the item at the specified index in the items array
+func (s *ItemScroller) itemPos(i int) int {
+
+	if s.model != nil {
+		return s.mfirst + i
+	}
+	return i
+}
+
+// showItem scrolls a virtual scroller to show the item at the specified position
+func (s *ItemScroller) showItem(pos int) {
+
+	if s.ItemVisible(pos) || len(s.items) == 0 {
+		return
+	}
+	first := pos
+	if pos > s.first {
+		// Shows the item as the last visible item
+		if size := s.itemSize(s.items[0]); size > 0 {
+			first = pos - int(s.viewSize()/size) + 1
+		}
+	}
+	if max := s.maxFirst(); first > max {
+		first = max
+	}
+	if first < 0 {
+		first = 0
+	}
+	s.first = first
+	s.recalc()
+}
+
+// itemSize returns the size of the specified item in the scroll direction
+func (s *ItemScroller) itemSize(item IPanel) float32 {
+
+	if s.vert {
+		return item.TotalHeight()
+	}
+	return item.GetPanel().Width()
+}
+
+// viewSize returns the size of this scroller in the scroll direction
+func (s *ItemScroller) viewSize() float32 {
+
+	if s.vert {
+		return s.height
+	}
+	return s.width
+}
+
+// maxFirstModel returns the maximum position of the first visible item of virtual scrollers
+func (s *ItemScroller) maxFirstModel() int {
+
+	if len(s.items) == 0 {
+		return 0
+	}
+	size := s.itemSize(s.items[0])
+	if size <= 0 {
+		return 0
+	}
+	max := s.model.Len() - int(s.viewSize()/size)
+	if max < 0 {
+		return 0
+	}
+	return max
+}
+
+// recalcModel is the virtual scroller version of recalc which fetches
+// the visible items from the model, recycling the panels of the others
+func (s *ItemScroller) recalcModel() {
+
+	count := s.model.Len()
+	if s.first >= count {
+		s.first = 0
+		if count > 0 {
+			s.first = count - 1
+		}
+	}
+
+	// The items before the first visible are recycled and the others
+	// are kept if they are still visible
+	old := s.items
+	for i, item := range old {
+		if s.mdirty || s.mfirst+i < s.first {
+			s.mfree = append(s.mfree, item)
+			old[i] = nil
+		}
+	}
+	s.items = nil
+	view := s.viewSize()
+	var total float32
+	for pos := s.first; pos < count && total <= view; pos++ {
+		var item IPanel
+		if i := pos - s.mfirst; i >= 0 && i < len(old) && old[i] != nil {
+			item = old[i]
+			old[i] = nil
+		} else {
+			item = s.fetchItem(pos, old)
+		}
+		s.items = append(s.items, item)
+		total += s.itemSize(item)
+	}
+	for _, item := range old {
+		if item != nil {
+			s.mfree = append(s.mfree, item)
+		}
+	}
+	for _, item := range s.mfree {
+		item.GetPanel().SetVisible(false)
+	}
+	s.mfirst = s.first
+	s.mcount = count
+	s.mdirty = false
+
+	// Sets the scroll bar
+	scroll := s.first > 0 || total > view
+	if s.vert {
+		s.setVScrollBar(scroll)
+	} else {
+		s.setHScrollBar(scroll)
+	}
+	var bar *ScrollBar
+	var width, height float32
+	if scroll {
+		if s.vert {
+			bar = s.vscroll
+			width = s.ContentWidth() - bar.Width()
+		} else {
+			bar = s.hscroll
+			height = s.ContentHeight() - bar.Height()
+		}
+		if s.autoButtonSize && len(s.items) > 0 {
+			bar.SetButtonSize(view * view / (float32(count) * s.itemSize(s.items[0])))
+		}
+	} else {
+		width = s.ContentWidth()
+		height = s.ContentHeight()
+	}
+
+	// Sets the positions of the visible items
+	var pos float32
+	for _, ipan := range s.items {
+		item := ipan.GetPanel()
+		item.SetVisible(true)
+		if s.vert {
+			item.SetPosition(0, pos)
+			if s.adjustItem {
+				item.SetWidth(width)
+			}
+		} else {
+			item.SetPosition(pos, 0)
+			if s.adjustItem {
+				item.SetHeight(height)
+			}
+		}
+		pos += s.itemSize(ipan)
+	}
+	if bar != nil {
+		if !s.scrollBarEvent {
+			bar.SetValue(float32(s.first) / float32(s.maxFirst()))
+		}
+		s.Panel.SetTopChild(bar)
+	}
+	s.scrollBarEvent = false
+}
+
+// fetchItem gets the item at the specified position from the model recycling
+// a free item panel or the last of the specified previously visible items
+func (s *ItemScroller) fetchItem(pos int, old []IPanel) IPanel {
+
+	var recycled IPanel
+	if n := len(s.mfree); n > 0 {
+		recycled = s.mfree[n-1]
+		s.mfree = s.mfree[:n-1]
+	} else {
+		for i := len(old) - 1; i >= 0; i-- {
+			if old[i] != nil {
+				recycled = old[i]
+				old[i] = nil
+				break
+			}
+		}
+	}
+	item := s.model.Item(pos, recycled)
+	if item != recycled {
+		if recycled != nil {
+			s.Panel.Remove(recycled)
+		}
+		s.Panel.Add(item)
+	}
+	return item
+}
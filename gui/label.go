@@ -5,22 +5,79 @@
 package gui
 
 import (
+	"sort"
+	"unsafe"
+
+	"github.com/sansebasko/engine/core"
 	"github.com/sansebasko/engine/gls"
+	"github.com/sansebasko/engine/material"
 	"github.com/sansebasko/engine/math32"
 	"github.com/sansebasko/engine/text"
 	"github.com/sansebasko/engine/texture"
 )
 
-// Label is a panel which contains a texture with text.
-// The content size of the label panel is the exact size of the texture.
+// Label is a panel which contains text.
+// The text is laid out by its font, optionally wrapped and aligned to a width,
+// and drawn as quads of the glyphs cached in the font atlases, so changing it
+// only rewrites the vertices of the label.
+// The content size of the label panel is the size of the text layout.
+// The text is rasterized at the font resolution multiplied by the GUI scale factor,
+// so the pixels of the text layout are framebuffer pixels and not GUI units.
 type Label struct {
-	Panel                    // Embedded Panel
-	font  *text.Font         // TrueType font face
-	tex   *texture.Texture2D // Texture with text
-	style *LabelStyle        // The style of the panel and font attributes
-	text  string             // Text being displayed
+	Panel                     // Embedded Panel
+	font   *text.Font         // TrueType font face
+	glyphs labelGlyphs        // Quads of the text glyphs
+	style  *LabelStyle        // The style of the panel and font attributes
+	text   string             // Text being displayed
+	spans  []text.Span        // Rich text spans being displayed or nil
+	opts   text.LayoutOptions // Layout options of the text
+	layout *text.Layout       // Current layout of the text
+	scale  float32            // GUI scale factor of the layout, in its pixels per GUI unit
+}
+
+// labelGlyphs contains the quads of the glyphs and rectangles of a label,
+// drawn after its panel quad in runs of quads with the same atlas and color.
+type labelGlyphs struct {
+	label *Label      // label which contains the glyphs
+	quads []labelQuad // quads being drawn
+	runs  []*labelRun // runs of quads in the geometry
+	pool  []*labelRun // runs created by the label, in use or not
+}
+
+// labelQuad is a glyph or solid rectangle of a label
+type labelQuad struct {
+	x0, y0, x1, y1 float32        // position in pixels from the top left of the content
+	u0, v0, u1, v1 float32        // texture coordinates in pixels of the atlas image
+	atlas          *text.Atlas    // atlas of the quad
+	color          *math32.Color4 // color of the quad or nil for the text color
+	layer          int            // quads of lower layers are drawn first
+}
+
+// labelRun draws consecutive quads of a label with the same atlas and color.
+// It embeds the label to be the graphic of its material.
+type labelRun struct {
+	*Label                       // label which contains the quads
+	mat       *material.Material // quads material
+	atlasTex  *texture.Texture2D // font atlas texture
+	color     *math32.Color4     // color of the quads or nil for the text color
+	count     int                // number of quads of the run
+	uniMatrix gls.Uniform        // model matrix uniform location cache
+	uniText   gls.Uniform        // text parameters uniform location cache
+	udata     struct {           // Combined uniform data 2 * vec4
+		bounds math32.Vector4 // visible area in pixels from the top left of the text
+		color  math32.Color4  // text color
+	}
 }
 
+// glyphTexture is the texture of a font atlas shared by all labels
+type glyphTexture struct {
+	tex     *texture.Texture2D // texture with the atlas image
+	version int                // atlas version of the texture image
+}
+
+// glyphTextures maps the font atlases used by labels to their textures
+var glyphTextures = map[*text.Atlas]*glyphTexture{}
+
 // LabelStyle contains all the styling attributes of a Label.
 // It's essentially a BasicStyle combined with FontAttributes.
 type LabelStyle struct {
@@ -55,7 +112,8 @@ func NewLabelWithFont(msg string, font *text.Font) *Label {
 func (l *Label) initialize(msg string, font *text.Font) {
 
 	l.font = font
-	l.Panel.Initialize(0, 0)
+	l.Panel.initialize(0, 0, newPanelGeometry())
+	l.glyphs.initialize(l)
 
 	// TODO: Remove this hack in an elegant way e.g. set the label style depending of if it's an icon or text label and have two defaults (one for icon labels one for text tabels)
 	if font != StyleDefault().FontIcon {
@@ -67,43 +125,137 @@ func (l *Label) initialize(msg string, font *text.Font) {
 	l.style = &styleCopy
 
 	l.SetText(msg)
+	l.Subscribe(OnScale, l.onScale)
 }
 
-// SetText sets and draws the label text using the font.
+// SetText sets the label text and lays out its glyphs using the font.
 func (l *Label) SetText(text string) {
 
-	// Need at least a character to get dimensions
 	l.text = text
-	if text == "" {
-		text = " "
+	l.spans = nil
+	l.relayout()
+}
+
+// Text returns the label text, without markup.
+func (l *Label) Text() string {
+
+	return l.text
+}
+
+// SetMarkup sets the label text from the specified rich text markup
+// (see text.ParseMarkup) and lays out its glyphs using the font.
+func (l *Label) SetMarkup(markup string) {
+
+	l.SetSpans(text.ParseMarkup(markup))
+}
+
+// SetSpans sets the label text from the specified rich text spans
+// and lays out its glyphs using the font.
+func (l *Label) SetSpans(spans []text.Span) {
+
+	l.text = ""
+	for _, s := range spans {
+		l.text += s.Text
 	}
+	l.spans = spans
+	l.relayout()
+}
+
+// Spans returns the rich text spans of the label or nil if it has plain text.
+func (l *Label) Spans() []text.Span {
+
+	return l.spans
+}
+
+// SetLayoutOptions sets the options used to lay out the text, such as the width
+// to which it is wrapped, its alignment and the maximum number of lines.
+func (l *Label) SetLayoutOptions(opts *text.LayoutOptions) *Label {
+
+	l.opts = *opts
+	l.relayout()
+	return l
+}
+
+// LayoutOptions returns the options used to lay out the text.
+func (l *Label) LayoutOptions() text.LayoutOptions {
+
+	return l.opts
+}
+
+// SetWrapWidth sets the width in pixels to which the lines of the text are wrapped.
+// The label has this width unless it is 0, which disables wrapping.
+func (l *Label) SetWrapWidth(width float32) *Label {
+
+	l.opts.Width = int(width)
+	l.opts.Wrap = width > 0
+	l.relayout()
+	return l
+}
+
+// SetAlign sets the horizontal alignment of the lines of the text.
+func (l *Label) SetAlign(align text.Align) *Label {
+
+	l.opts.Align = align
+	l.relayout()
+	return l
+}
+
+// TextLayout returns the current layout of the text, which
+// can be used to find the characters at positions of the label.
+// Positions are in pixels from the top left of the label content,
+// which are TextScale pixels per GUI unit.
+func (l *Label) TextLayout() *text.Layout {
+
+	return l.layout
+}
+
+// TextScale returns the number of pixels of the text layout per GUI unit,
+// which is the GUI scale factor when the text was last laid out.
+func (l *Label) TextScale() float32 {
+
+	return l.scale
+}
+
+// onScale is called when the GUI scale factor changes
+func (l *Label) onScale(evname string, ev interface{}) {
+
+	l.relayout()
+}
+
+// setFontAttributes sets the attributes of the font
+// to rasterize the text at the current GUI scale factor
+func (l *Label) setFontAttributes() {
+
+	l.scale = guiScale.value
+	fa := l.style.FontAttributes
+	fa.DPI *= float64(l.scale)
+	l.font.SetAttributes(&fa)
+}
+
+// relayout lays out the text with the current font attributes and options
+func (l *Label) relayout() {
 
 	// Set font properties
-	l.font.SetAttributes(&l.style.FontAttributes)
-	l.font.SetColor(&l.style.FgColor)
-
-	// Create an image with the text
-	textImage := l.font.DrawText(text)
-
-	// Create texture if it doesn't exist yet
-	if l.tex == nil {
-		l.tex = texture.NewTexture2DFromRGBA(textImage)
-		l.tex.SetMagFilter(gls.NEAREST)
-		l.tex.SetMinFilter(gls.NEAREST)
-		l.Panel.Material().AddTexture(l.tex)
-		// Otherwise update texture with new image
+	l.setFontAttributes()
+
+	// The width of the options is in GUI units
+	opts := l.opts
+	opts.Width = int(float32(opts.Width) * l.scale)
+
+	// Need at least a character to get dimensions
+	if l.spans != nil && l.text != "" {
+		l.layout = l.font.LayoutSpans(l.spans, &opts)
+	} else if l.text != "" {
+		l.layout = l.font.Layout(l.text, &opts)
 	} else {
-		l.tex.SetFromRGBA(textImage)
+		l.layout = l.font.Layout(" ", &opts)
 	}
+	l.glyphs.begin()
+	l.glyphs.addLayout(l.layout, 0)
+	l.glyphs.update()
 
 	// Update label panel dimensions
-	l.Panel.SetContentSize(float32(textImage.Rect.Dx()), float32(textImage.Rect.Dy()))
-}
-
-// Text returns the label text.
-func (l *Label) Text() string {
-
-	return l.text
+	l.Panel.SetContentSize(float32(l.layout.Width)/l.scale, float32(l.layout.Height)/l.scale)
 }
 
 // SetColor sets the text color.
@@ -111,7 +263,7 @@ func (l *Label) Text() string {
 func (l *Label) SetColor(color *math32.Color) *Label {
 
 	l.style.FgColor.FromColor(color, 1.0)
-	l.SetText(l.text)
+	l.SetChanged(true)
 	return l
 }
 
@@ -119,7 +271,7 @@ func (l *Label) SetColor(color *math32.Color) *Label {
 func (l *Label) SetColor4(color4 *math32.Color4) *Label {
 
 	l.style.FgColor = *color4
-	l.SetText(l.text)
+	l.SetChanged(true)
 	return l
 }
 
@@ -135,7 +287,7 @@ func (l *Label) SetBgColor(color *math32.Color) *Label {
 
 	l.style.BgColor.FromColor(color, 1.0)
 	l.Panel.SetColor4(&l.style.BgColor)
-	l.SetText(l.text)
+	l.relayout()
 	return l
 }
 
@@ -144,7 +296,7 @@ func (l *Label) SetBgColor4(color *math32.Color4) *Label {
 
 	l.style.BgColor = *color
 	l.Panel.SetColor4(&l.style.BgColor)
-	l.SetText(l.text)
+	l.relayout()
 	return l
 }
 
@@ -158,7 +310,7 @@ func (l *Label) BgColor() math32.Color4 {
 func (l *Label) SetFont(f *text.Font) {
 
 	l.font = f
-	l.SetText(l.text)
+	l.relayout()
 }
 
 // Font returns the font.
@@ -171,7 +323,7 @@ func (l *Label) Font() *text.Font {
 func (l *Label) SetFontSize(size float64) *Label {
 
 	l.style.PointSize = size
-	l.SetText(l.text)
+	l.relayout()
 	return l
 }
 
@@ -185,7 +337,7 @@ func (l *Label) FontSize() float64 {
 func (l *Label) SetFontDPI(dpi float64) *Label {
 
 	l.style.DPI = dpi
-	l.SetText(l.text)
+	l.relayout()
 	return l
 }
 
@@ -199,7 +351,7 @@ func (l *Label) FontDPI() float64 {
 func (l *Label) SetLineSpacing(spacing float64) *Label {
 
 	l.style.LineSpacing = spacing
-	l.SetText(l.text)
+	l.relayout()
 	return l
 }
 
@@ -209,33 +361,313 @@ func (l *Label) LineSpacing() float64 {
 	return l.style.LineSpacing
 }
 
-// setTextCaret sets the label text and draws a caret at the
-// specified line and column.
-// It is normally used by the Edit widget.
-func (l *Label) setTextCaret(msg string, mx, width, line, col int) {
+// textSelection describes a range of selected text drawn by setTextCaret
+type textSelection struct {
+	line1, col1 int           // start of the selection
+	line2, col2 int           // end of the selection
+	color       math32.Color4 // selection background color
+	underline   bool          // underlines the text with the text color instead
+}
 
-	// Set font properties
-	l.font.SetAttributes(&l.style.FontAttributes)
-	l.font.SetColor(&l.style.FgColor)
-
-	// Create canvas and draw text
-	_, height := l.font.MeasureText(msg)
-	canvas := text.NewCanvas(width, height, &l.style.BgColor)
-	canvas.DrawTextCaret(mx, 0, msg, l.font, line, col)
-
-	// Creates texture if if doesnt exist.
-	if l.tex == nil {
-		l.tex = texture.NewTexture2DFromRGBA(canvas.RGBA)
-		l.Panel.Material().AddTexture(l.tex)
-		// Otherwise update texture with new image
-	} else {
-		l.tex.SetFromRGBA(canvas.RGBA)
+// setTextCaret sets the label plain text and draws a caret at the specified line and
+// column and the optional text selection, with the text starting at the specified
+// position X in a label content of the specified width, both in GUI units.
+// It is normally used by the Edit and TextArea widgets.
+func (l *Label) setTextCaret(msg string, mx, width, line, col int, sel *textSelection) {
+
+	l.text = msg
+	l.spans = nil
+	l.setFontAttributes()
+	l.layout = l.font.Layout(msg, &text.LayoutOptions{})
+	mx = int(float32(mx)*l.scale + 0.5)
+	layout := l.layout
+	atlas := l.font.Atlas()
+	l.glyphs.begin()
+
+	// Underlines the selected text, such as the text being composed by an input method
+	if sel != nil && sel.underline {
+		size := atlas.Ascent + atlas.Descent
+		thickness := (size + 8) / 16
+		if thickness < 1 {
+			thickness = 1
+		}
+		start := layout.Index(sel.line1, sel.col1)
+		end := layout.Index(sel.line2, sel.col2)
+		for _, r := range layout.Selection(start, end) {
+			for i := range layout.Lines {
+				if lt := &layout.Lines[i]; lt.Top == r.Min.Y {
+					y := lt.Baseline + (size+6)/12
+					l.glyphs.addRect(atlas, mx+r.Min.X, y, mx+r.Max.X, y+thickness, nil, 1)
+					break
+				}
+			}
+		}
+	} else if sel != nil {
+		// Draws the selection, with the selected line breaks shown as spaces
+		start := layout.Index(sel.line1, sel.col1)
+		end := layout.Index(sel.line2, sel.col2)
+		for _, r := range layout.Selection(start, end) {
+			l.glyphs.addRect(atlas, mx+r.Min.X, r.Min.Y, mx+r.Max.X, r.Max.Y, &sel.color, 0)
+		}
+		space := atlas.Glyph(' ').Advance.Round()
+		for ln := sel.line1; ln < sel.line2 && ln < len(layout.Lines); ln++ {
+			lt := &layout.Lines[ln]
+			x, _ := layout.Caret(lt.End)
+			l.glyphs.addRect(atlas, mx+x, lt.Top, mx+x+space, lt.Bottom, &sel.color, 0)
+		}
 	}
-	// Set texture filtering parameters for text
-	l.tex.SetMagFilter(gls.NEAREST)
-	l.tex.SetMinFilter(gls.NEAREST)
+	l.glyphs.addLayout(layout, mx)
+
+	// The caret is one GUI unit wide and a little taller than the font size
+	if line >= 0 && line < len(layout.Lines) && col <= layout.Lines[line].End-layout.Lines[line].Start {
+		x, _ := layout.Caret(layout.Index(line, col))
+		unit := int(l.scale + 0.5)
+		if unit < 1 {
+			unit = 1
+		}
+		size := int(float32(l.style.PointSize) * l.scale)
+		top := layout.Lines[line].Baseline - size + 2*unit
+		l.glyphs.addRect(atlas, mx+x, top, mx+x+unit, top+size+2*unit, nil, 2)
+	}
+	l.glyphs.update()
 
 	// Updates label panel dimensions
-	l.Panel.SetContentSize(float32(width), float32(height))
-	l.text = msg
+	l.Panel.SetContentSize(float32(width), float32(layout.Height)/l.scale)
+}
+
+// Dispose releases the resources of the label
+func (l *Label) Dispose() {
+
+	// The runs materials are only disposed by the graphic while they are in use
+	for _, run := range l.glyphs.pool[len(l.glyphs.runs):] {
+		run.mat.Dispose()
+	}
+	l.Panel.Dispose()
+}
+
+// initialize initializes the glyphs of the specified label
+func (lg *labelGlyphs) initialize(l *Label) {
+
+	lg.label = l
+}
+
+// begin removes all the quads before adding new ones
+func (lg *labelGlyphs) begin() {
+
+	lg.quads = lg.quads[:0]
+}
+
+// addLayout adds the quads of the glyphs and underlines of the
+// specified text layout moved by the specified offset X.
+func (lg *labelGlyphs) addLayout(layout *text.Layout, dx int) {
+
+	for i := range layout.Glyphs {
+		g := &layout.Glyphs[i]
+		if g.Glyph.Width == 0 {
+			continue
+		}
+		q := labelQuad{
+			x0:    float32(dx + g.X),
+			y0:    float32(g.Y),
+			u0:    float32(g.Glyph.X),
+			v0:    float32(g.Glyph.Y),
+			atlas: g.Atlas,
+			color: layout.Spans[g.Span].Color,
+			layer: 1,
+		}
+		q.x1 = q.x0 + float32(g.Glyph.Width)
+		q.y1 = q.y0 + float32(g.Glyph.Height)
+		q.u1 = q.u0 + float32(g.Glyph.Width)
+		q.v1 = q.v0 + float32(g.Glyph.Height)
+		lg.quads = append(lg.quads, q)
+		// Bold glyphs are drawn again one pixel to the right
+		if g.Bold {
+			q.x0++
+			q.x1++
+			lg.quads = append(lg.quads, q)
+		}
+	}
+	for _, u := range layout.Underlines {
+		atlas := lg.label.font.Atlas()
+		lg.addRect(atlas, dx+u.Rect.Min.X, u.Rect.Min.Y, dx+u.Rect.Max.X, u.Rect.Max.Y, layout.Spans[u.Span].Color, 1)
+	}
+}
+
+// addRect adds a solid rectangle drawn with the specified atlas and color
+func (lg *labelGlyphs) addRect(atlas *text.Atlas, x0, y0, x1, y1 int, color *math32.Color4, layer int) {
+
+	if x1 <= x0 || y1 <= y0 {
+		return
+	}
+	g := atlas.Solid()
+	u := float32(g.X) + float32(g.Width)/2
+	v := float32(g.Y) + float32(g.Height)/2
+	lg.quads = append(lg.quads, labelQuad{
+		x0: float32(x0), y0: float32(y0), x1: float32(x1), y1: float32(y1),
+		u0: u, v0: v, u1: u, v1: v,
+		atlas: atlas, color: color, layer: layer,
+	})
+}
+
+// update rewrites the vertices and indices of the quads after the panel quad,
+// grouped in runs of the same layer, atlas and color, and sets the range of
+// the geometry drawn by the material of each run.
+func (lg *labelGlyphs) update() {
+
+	l := lg.label
+	geom := l.GetGeometry()
+	vbo := geom.VBO(gls.VertexPosition)
+	positions := (*vbo.Buffer())[:20]
+	indices := geom.Indices()[:6]
+
+	// Groups the quads keeping the order of the layers
+	type runKey struct {
+		layer int
+		atlas *text.Atlas
+		color math32.Color4
+		fg    bool
+	}
+	keyOf := func(q *labelQuad) runKey {
+		k := runKey{layer: q.layer, atlas: q.atlas, fg: q.color == nil}
+		if q.color != nil {
+			k.color = *q.color
+		}
+		return k
+	}
+	var keys []runKey
+	groups := make(map[runKey][]int)
+	for i := range lg.quads {
+		k := keyOf(&lg.quads[i])
+		if groups[k] == nil {
+			keys = append(keys, k)
+		}
+		groups[k] = append(groups[k], i)
+	}
+	sort.SliceStable(keys, func(i, j int) bool { return keys[i].layer < keys[j].layer })
+
+	// Positions are in pixels from the top left of the text
+	// and texture coordinates are in pixels of the atlas image.
+	lg.runs = lg.runs[:0]
+	base := uint32(4)
+	for _, k := range keys {
+		run := lg.run(len(lg.runs))
+		run.color = lg.quads[groups[k][0]].color
+		run.count = len(groups[k])
+		run.setTexture(atlasTexture(k.atlas))
+		for _, i := range groups[k] {
+			q := &lg.quads[i]
+			positions.Append(
+				q.x0, q.y0, 0, q.u0, q.v0,
+				q.x0, q.y1, 0, q.u0, q.v1,
+				q.x1, q.y1, 0, q.u1, q.v1,
+				q.x1, q.y0, 0, q.u1, q.v0,
+			)
+			indices.Append(base, base+1, base+2, base, base+2, base+3)
+			base += 4
+		}
+		lg.runs = append(lg.runs, run)
+	}
+	vbo.SetBuffer(positions)
+	geom.SetIndices(indices)
+
+	// A material without count would draw the whole geometry
+	l.ClearMaterials()
+	l.AddMaterial(&l.Panel, l.Panel.mat, 0, 6)
+	start := 6
+	for _, run := range lg.runs {
+		l.AddMaterial(run, run.mat, start, 6*run.count)
+		start += 6 * run.count
+	}
+	l.SetChanged(true)
+}
+
+// run returns the run with the specified index in the pool, creating it if necessary
+func (lg *labelGlyphs) run(i int) *labelRun {
+
+	if i < len(lg.pool) {
+		return lg.pool[i]
+	}
+	run := new(labelRun)
+	run.Label = lg.label
+	run.mat = material.NewMaterial()
+	run.mat.SetShader("text")
+	run.mat.SetShaderUnique(true)
+	run.uniMatrix.Init("ModelMatrix")
+	run.uniText.Init("Text")
+	lg.pool = append(lg.pool, run)
+	return run
+}
+
+// setTexture sets the font atlas texture of the run
+func (run *labelRun) setTexture(tex *texture.Texture2D) {
+
+	if tex == run.atlasTex {
+		return
+	}
+	if run.atlasTex != nil {
+		run.mat.RemoveTexture(run.atlasTex)
+		run.atlasTex.Dispose()
+	}
+	run.atlasTex = tex.Incref()
+	run.mat.AddTexture(run.atlasTex)
+}
+
+// RenderSetup is called by the renderer before drawing the quads of the run
+func (run *labelRun) RenderSetup(gl *gls.GLS, rinfo *core.RenderInfo) {
+
+	p := &run.Label.Panel
+	if p.width == 0 || p.height == 0 {
+		return
+	}
+
+	// The panel model matrix transforms its quad from (0,0) to (1,-1).
+	// Scales it to transform pixels of the text layout from the top left of the panel content.
+	var mm, tm math32.Matrix4
+	var quat math32.Quaternion
+	quat.SetIdentity()
+	p.SetModelMatrix(gl, &mm)
+	scale := run.Label.scale
+	tm.Compose(
+		&math32.Vector3{p.content.X / p.width, -p.content.Y / p.height, 0},
+		&quat,
+		&math32.Vector3{1 / (p.width * scale), -1 / (p.height * scale), 1},
+	)
+	mm.Multiply(&tm)
+	location := run.uniMatrix.Location(gl)
+	gl.UniformMatrix4fv(location, 1, false, &mm[0])
+
+	// The quads are clipped by the panel bounds and content area
+	b := &p.udata.bounds
+	run.udata.bounds = math32.Vector4{
+		math32.Max(b.X*p.width-p.content.X, 0) * scale,
+		math32.Max(b.Y*p.height-p.content.Y, 0) * scale,
+		math32.Min(b.Z*p.width-p.content.X, p.content.Width) * scale,
+		math32.Min(b.W*p.height-p.content.Y, p.content.Height) * scale,
+	}
+	run.udata.color = run.Label.style.FgColor
+	if run.color != nil {
+		run.udata.color = *run.color
+	}
+	location = run.uniText.Location(gl)
+	const vec4count = 2
+	gl.Uniform4fvUP(location, vec4count, unsafe.Pointer(&run.udata))
+}
+
+// atlasTexture returns the texture shared by all labels for the specified
+// font atlas, updating its image if glyphs were added to the atlas.
+func atlasTexture(atlas *text.Atlas) *texture.Texture2D {
+
+	gt := glyphTextures[atlas]
+	if gt == nil {
+		gt = &glyphTexture{tex: texture.NewTexture2DFromRGBA(atlas.Image), version: atlas.Version()}
+		gt.tex.SetMagFilter(gls.NEAREST)
+		gt.tex.SetMinFilter(gls.NEAREST)
+		glyphTextures[atlas] = gt
+		return gt.tex
+	}
+	if gt.version != atlas.Version() {
+		gt.tex.SetFromRGBA(atlas.Image)
+		gt.version = atlas.Version()
+	}
+	return gt.tex
 }
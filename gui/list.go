@@ -10,13 +10,17 @@ import (
 
 // List represents a list GUI element
 type List struct {
-	ItemScroller             // Embedded scroller
-	styles       *ListStyles // Pointer to styles
-	single       bool        // Single selection flag (default is true)
-	focus        bool        // has keyboard focus
-	dropdown     bool        // this is used as dropdown
-	keyNext      window.Key  // Code of key to select next item
-	keyPrev      window.Key  // Code of key to select previous item
+	ItemScroller              // Embedded scroller
+	styles       *ListStyles  // Pointer to styles
+	single       bool         // Single selection flag (default is true)
+	focus        bool         // has keyboard focus
+	dropdown     bool         // this is used as dropdown
+	keyNext      window.Key   // Code of key to select next item
+	keyPrev      window.Key   // Code of key to select previous item
+	reorder      bool         // items can be reordered by dragging
+	dragSub      bool         // subscribed to drag and drop events
+	msel         map[int]bool // selected model positions of virtual lists
+	mhigh        int          // highlighted model position of virtual lists
 }
 
 // ListItem encapsulates each item inserted into the list
@@ -27,6 +31,7 @@ type ListItem struct {
 	highlighted bool    // Item highlighted flag
 	padLeft     float32 // Additional left padding
 	list        *List   // Pointer to list
+	pos         int     // Model position of the item of virtual lists
 }
 
 // ListStyles encapsulates a set of styles for the list and item.
@@ -84,6 +89,9 @@ func (li *List) initialize(vert bool, width, height float32) {
 	li.ItemScroller.Subscribe(OnMouseDown, li.onMouseEvent)
 	li.ItemScroller.Subscribe(OnKeyDown, li.onKeyEvent)
 	li.ItemScroller.Subscribe(OnKeyRepeat, li.onKeyEvent)
+	li.ItemScroller.Subscribe(OnFocus, li.onFocus)
+	li.ItemScroller.Subscribe(OnFocusLost, li.onFocus)
+	li.ItemScroller.SetFocusable(true)
 
 	if vert {
 		li.keyNext = window.KeyDown
@@ -126,10 +134,7 @@ func (li *List) Add(item IPanel) {
 // Returs true if the item was successfully inserted
 func (li *List) InsertAt(pos int, item IPanel) {
 
-	litem := newListItem(li, item)
-	li.ItemScroller.InsertAt(pos, litem)
-	litem.Panel.Subscribe(OnMouseDown, litem.onMouse)
-	litem.Panel.Subscribe(OnCursorEnter, litem.onCursor)
+	li.ItemScroller.InsertAt(pos, li.newItem(item))
 }
 
 // RemoveAt removes the list item from the specified position
@@ -172,15 +177,16 @@ func (li *List) ItemAt(pos int) IPanel {
 // the list or -1 if not found
 func (li *List) ItemPosition(item IPanel) int {
 
-	for pos := 0; pos < len(li.items); pos++ {
-		if li.items[pos].(*ListItem).item == item {
-			return pos
+	for i := 0; i < len(li.items); i++ {
+		if li.items[i].(*ListItem).item == item {
+			return li.itemPos(i)
 		}
 	}
 	return -1
 }
 
-// Selected returns list with the currently selected items
+// Selected returns list with the currently selected items.
+// For virtual lists only the visible selected items are returned.
 func (li *List) Selected() []IPanel {
 
 	sel := []IPanel{}
@@ -210,6 +216,10 @@ func (li *List) SetSelected(item IPanel, state bool) {
 // SelectPos selects or unselects the item at the specified position
 func (li *List) SelectPos(pos int, state bool) {
 
+	if li.model != nil {
+		li.selectModelPos(pos, state)
+		return
+	}
 	if pos < 0 || pos >= len(li.items) {
 		return
 	}
@@ -222,6 +232,46 @@ func (li *List) SelectPos(pos int, state bool) {
 	li.Dispatch(OnChange, nil)
 }
 
+// MoveItem moves the item at the specified source position to the specified destination position
+func (li *List) MoveItem(src, dest int) {
+
+	if li.model != nil || src < 0 || src >= len(li.items) || dest < 0 || dest >= len(li.items) || src == dest {
+		return
+	}
+	litem := li.items[src]
+	if src < dest {
+		copy(li.items[src:], li.items[src+1:dest+1])
+	} else {
+		copy(li.items[dest+1:], li.items[dest:src])
+	}
+	li.items[dest] = litem
+	li.recalc()
+}
+
+// SetReorderable sets if the items of the list can be reordered by the user
+// by dragging them. OnReorder is dispatched after an item is moved.
+func (li *List) SetReorderable(state bool) {
+
+	li.reorder = state
+	li.SetDropTarget(state)
+	if !state {
+		li.SetDragSource(nil)
+		return
+	}
+	li.SetDragSource(li.dragItem)
+	if !li.dragSub {
+		li.dragSub = true
+		li.Subscribe(OnDragOver, li.onDrag)
+		li.Subscribe(OnDrop, li.onDrag)
+	}
+}
+
+// Reorderable returns if the items of the list can be reordered by dragging them
+func (li *List) Reorderable() bool {
+
+	return li.reorder
+}
+
 // SetItemPadLeftAt sets the additional left padding for this item
 // It is used mainly by the tree control
 func (li *List) SetItemPadLeftAt(pos int, pad float32) {
@@ -237,6 +287,10 @@ func (li *List) SetItemPadLeftAt(pos int, pad float32) {
 // selNext selects or highlights the next item, if possible
 func (li *List) selNext(sel bool, update bool) *ListItem {
 
+	if li.model != nil {
+		li.selModel(sel, 1)
+		return nil
+	}
 	// Checks for empty list
 	if len(li.items) == 0 {
 		return nil
@@ -293,6 +347,10 @@ func (li *List) selNext(sel bool, update bool) *ListItem {
 // selPrev selects or highlights the next item, if possible
 func (li *List) selPrev(sel bool, update bool) *ListItem {
 
+	if li.model != nil {
+		li.selModel(sel, -1)
+		return nil
+	}
 	// Check for empty list
 	if len(li.items) == 0 {
 		return nil
@@ -344,9 +402,50 @@ func (li *List) selPrev(sel bool, update bool) *ListItem {
 	return newItem
 }
 
+// selEdge selects or highlights the first or the last item
+func (li *List) selEdge(sel bool, last bool) {
+
+	count := li.Len()
+	if count == 0 {
+		return
+	}
+	pos := 0
+	if last {
+		pos = count - 1
+	}
+	if li.model != nil {
+		if sel {
+			li.msel = map[int]bool{pos: true}
+		} else {
+			li.mhigh = pos
+		}
+		li.showItem(pos)
+	} else {
+		for i, item := range li.items {
+			if sel {
+				item.(*ListItem).SetSelected(i == pos)
+			} else {
+				item.(*ListItem).SetHighlighted(i == pos)
+			}
+		}
+		if last {
+			li.SetFirst(li.maxFirst())
+		} else {
+			li.SetFirst(0)
+		}
+	}
+	li.update()
+	if sel {
+		li.Dispatch(OnChange, nil)
+	}
+}
+
 // selected returns the position of first selected item
 func (li *List) selected() (pos int) {
 
+	if li.model != nil {
+		return li.selectedModel()
+	}
 	for pos, item := range li.items {
 		if item.(*ListItem).selected {
 			return pos
@@ -366,6 +465,14 @@ func (li *List) highlighted() (pos int) {
 	return -1
 }
 
+// onFocus receives the subscribed focus events of the list
+func (li *List) onFocus(evname string, ev interface{}) {
+
+	li.focus = evname == OnFocus
+	li.ItemScroller.focus = li.focus
+	li.ItemScroller.update()
+}
+
 // onMouseEvent receives subscribed mouse events for the list
 func (li *List) onMouseEvent(evname string, ev interface{}) {
 
@@ -373,6 +480,83 @@ func (li *List) onMouseEvent(evname string, ev interface{}) {
 	li.root.StopPropagation(StopAll)
 }
 
+// itemPosAt returns the position of the visible item at the specified
+// window position or -1 if not found
+func (li *List) itemPosAt(x, y float32) int {
+
+	for pos := li.first; pos < len(li.items); pos++ {
+		pan := li.items[pos].GetPanel()
+		if !pan.Visible() {
+			break
+		}
+		if pan.ContainsPosition(x, y) {
+			return pos
+		}
+	}
+	return -1
+}
+
+// dropPos returns the position where an item dropped
+// at the specified window position is inserted
+func (li *List) dropPos(x, y float32) int {
+
+	pos := li.first
+	for ; pos < len(li.items); pos++ {
+		pan := li.items[pos].GetPanel()
+		if !pan.Visible() {
+			break
+		}
+		if li.vert && y < pan.pospix.Y+pan.height/2 || !li.vert && x < pan.pospix.X+pan.width/2 {
+			break
+		}
+	}
+	return pos
+}
+
+// dragItem is the drag source function of reorderable lists
+func (li *List) dragItem(x, y float32) *DragPayload {
+
+	pos := li.itemPosAt(x, y)
+	if pos < 0 || li.model != nil {
+		return nil
+	}
+	item := li.items[pos].(*ListItem).item
+	return &DragPayload{Type: "gui.ListItem", Data: item, Text: dragText(item)}
+}
+
+// onDrag receives the drag and drop events of reorderable lists
+func (li *List) onDrag(evname string, ev interface{}) {
+
+	dev := ev.(*DragEvent)
+	item, ok := dev.Payload.Data.(IPanel)
+	if !li.reorder || !ok || !dragFrom(dev, li) {
+		return
+	}
+	from := li.ItemPosition(item)
+	if from < 0 {
+		return
+	}
+	to := li.dropPos(dev.X, dev.Y)
+	switch evname {
+	case OnDragOver:
+		dev.Accept()
+		if to > li.first && (to == len(li.items) || !li.items[to].GetPanel().Visible()) {
+			dev.indicateEdge(li.items[to-1].GetPanel(), !li.vert, true)
+		} else if to < len(li.items) {
+			dev.indicateEdge(li.items[to].GetPanel(), !li.vert, false)
+		}
+	case OnDrop:
+		if to > from {
+			to--
+		}
+		if to == from {
+			return
+		}
+		li.MoveItem(from, to)
+		li.Dispatch(OnReorder, &ReorderEvent{Item: item, From: from, To: to})
+	}
+}
+
 // onKeyEvent receives subscribed key events for the list
 func (li *List) onKeyEvent(evname string, ev interface{}) {
 
@@ -384,6 +568,8 @@ func (li *List) onKeyEvent(evname string, ev interface{}) {
 			li.selNext(true, true)
 		case li.keyPrev:
 			li.selPrev(true, true)
+		case window.KeyHome, window.KeyEnd:
+			li.selEdge(true, kev.Keycode == window.KeyEnd)
 		case window.KeyEnter:
 			li.SetVisible(false)
 		default:
@@ -400,6 +586,8 @@ func (li *List) onKeyEvent(evname string, ev interface{}) {
 			li.selNext(true, true)
 		case li.keyPrev:
 			li.selPrev(true, true)
+		case window.KeyHome, window.KeyEnd:
+			li.selEdge(true, kev.Keycode == window.KeyEnd)
 		default:
 			return
 		}
@@ -413,7 +601,13 @@ func (li *List) onKeyEvent(evname string, ev interface{}) {
 		li.selNext(false, true)
 	case li.keyPrev:
 		li.selPrev(false, true)
+	case window.KeyHome, window.KeyEnd:
+		li.selEdge(false, kev.Keycode == window.KeyEnd)
 	case window.KeySpace:
+		if li.model != nil {
+			li.SelectPos(li.mhigh, !li.msel[li.mhigh])
+			break
+		}
 		pos := li.highlighted()
 		if pos >= 0 {
 			litem := li.items[pos].(*ListItem)
@@ -433,6 +627,9 @@ func (li *List) setSelection(litem *ListItem, state bool, force bool, dispatch b
 	if litem.selected == state && !force {
 		return
 	}
+	if li.model != nil && li.single {
+		li.msel = make(map[int]bool)
+	}
 	litem.SetSelected(state)
 
 	// If single selection, deselects all other items
@@ -455,10 +652,24 @@ func (li *List) update() {
 
 	// Update the list items styles
 	for _, item := range li.items {
-		item.(*ListItem).update()
+		litem := item.(*ListItem)
+		if li.model != nil {
+			litem.selected = li.msel[litem.pos]
+			litem.highlighted = litem.pos == li.mhigh
+		}
+		litem.update()
 	}
 }
 
+// newItem creates and returns a new list item for the specified item
+func (li *List) newItem(item IPanel) *ListItem {
+
+	litem := newListItem(li, item)
+	litem.Panel.Subscribe(OnMouseDown, litem.onMouse)
+	litem.Panel.Subscribe(OnCursorEnter, litem.onCursor)
+	return litem
+}
+
 //
 // ListItem methods
 //
@@ -474,7 +685,7 @@ func newListItem(list *List, item IPanel) *ListItem {
 	litem.SetContentHeight(item.GetPanel().Height())
 	// If this list item is resized, sends event to its child panel
 	litem.Subscribe(OnResize, func(evname string, ev interface{}) {
-		item.GetPanel().Dispatch(OnListItemResize, nil)
+		litem.item.GetPanel().Dispatch(OnListItemResize, nil)
 	})
 	litem.update()
 	return litem
@@ -503,6 +714,13 @@ func (litem *ListItem) onCursor(evname string, ev interface{}) {
 func (litem *ListItem) SetSelected(state bool) {
 
 	litem.selected = state
+	if list := litem.list; list.model != nil {
+		if state {
+			list.msel[litem.pos] = true
+		} else {
+			delete(list.msel, litem.pos)
+		}
+	}
 	//litem.item.SetSelected2(state)
 }
 
@@ -510,6 +728,13 @@ func (litem *ListItem) SetSelected(state bool) {
 func (litem *ListItem) SetHighlighted(state bool) {
 
 	litem.highlighted = state
+	if list := litem.list; list.model != nil {
+		if state {
+			list.mhigh = litem.pos
+		} else if list.mhigh == litem.pos {
+			list.mhigh = -1
+		}
+	}
 	//litem.item.SetHighlighted2(state)
 }
 
@@ -0,0 +1,171 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gui
+
+import (
+	"sort"
+)
+
+// listModel adapts the data model of a virtual list to its scroller
+// wrapping the panels of the model items in recycled list items
+type listModel struct {
+	list  *List     // virtual list
+	model ListModel // list data model
+}
+
+// SetModel sets the data model of this list, making it a virtual list,
+// or returns it to a normal list if the model is nil.
+// All current items and the selection are removed from the list.
+// The positions used by the methods and events of virtual lists are model positions.
+// ModelChanged must be called when the items of the model change.
+func (li *List) SetModel(m ListModel) {
+
+	li.msel = make(map[int]bool)
+	li.mhigh = -1
+	var removed []IPanel
+	if m != nil {
+		removed = li.setModel(listModel{li, m})
+	} else {
+		removed = li.setModel(nil)
+	}
+	for _, item := range removed {
+		litem := item.(*ListItem)
+		litem.Remove(litem.item)
+		litem.Dispose()
+	}
+}
+
+// Model returns the data model of this virtual list or nil
+func (li *List) Model() ListModel {
+
+	if lm, ok := li.model.(listModel); ok {
+		return lm.model
+	}
+	return nil
+}
+
+// ModelChanged updates this virtual list after items of its model changed.
+// The selection of items beyond the new item count is removed.
+func (li *List) ModelChanged() {
+
+	if li.model == nil {
+		return
+	}
+	count := li.model.Len()
+	for pos := range li.msel {
+		if pos >= count {
+			delete(li.msel, pos)
+		}
+	}
+	if li.mhigh >= count {
+		li.mhigh = -1
+	}
+	li.ItemScroller.ModelChanged()
+}
+
+// SelectedPos returns the sorted positions of the currently selected items
+func (li *List) SelectedPos() []int {
+
+	sel := []int{}
+	if li.model != nil {
+		for pos := range li.msel {
+			sel = append(sel, pos)
+		}
+		sort.Ints(sel)
+		return sel
+	}
+	for pos, item := range li.items {
+		if item.(*ListItem).selected {
+			sel = append(sel, pos)
+		}
+	}
+	return sel
+}
+
+// selectedModel returns the position of the first selected item of virtual lists
+func (li *List) selectedModel() int {
+
+	first := -1
+	for pos := range li.msel {
+		if first < 0 || pos < first {
+			first = pos
+		}
+	}
+	return first
+}
+
+// selectModelPos selects or unselects the item of virtual lists at the specified position
+func (li *List) selectModelPos(pos int, state bool) {
+
+	if pos < 0 || pos >= li.Len() || li.msel[pos] == state {
+		return
+	}
+	if state {
+		li.msel[pos] = true
+	} else {
+		delete(li.msel, pos)
+	}
+	li.update()
+	li.Dispatch(OnChange, nil)
+}
+
+// selModel selects or highlights the item of virtual lists next to the currently
+// selected or highlighted item in the specified direction (1 or -1)
+func (li *List) selModel(sel bool, dir int) {
+
+	cur := li.mhigh
+	if sel {
+		cur = li.selectedModel()
+	}
+	next := 0
+	if cur >= 0 {
+		next = cur + dir
+	}
+	if next < 0 || next >= li.Len() {
+		return
+	}
+	if sel {
+		li.msel = map[int]bool{next: true}
+	} else {
+		li.mhigh = next
+	}
+	li.showItem(next)
+	li.update()
+	if sel {
+		li.Dispatch(OnChange, nil)
+	}
+}
+
+// Len returns the number of items of the list model
+func (lm listModel) Len() int {
+
+	return lm.model.Len()
+}
+
+// Item returns the list item showing the item of the list model at the specified position
+func (lm listModel) Item(pos int, recycled IPanel) IPanel {
+
+	var litem *ListItem
+	var old IPanel
+	if recycled != nil {
+		litem = recycled.(*ListItem)
+		old = litem.item
+	}
+	item := lm.model.Item(pos, old)
+	if litem == nil {
+		litem = lm.list.newItem(item)
+	} else if item != old {
+		litem.Remove(old)
+		litem.item = item
+		litem.Add(item)
+	}
+	litem.SetContentWidth(item.GetPanel().Width())
+	litem.SetContentHeight(item.GetPanel().Height())
+	litem.pos = pos
+	litem.selected = lm.list.msel[pos]
+	litem.highlighted = pos == lm.list.mhigh
+	litem.update()
+	return litem
+}
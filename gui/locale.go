@@ -0,0 +1,175 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/sansebasko/engine/util/i18n"
+)
+
+// Panel attributes can be localized by the message keys of the catalogs of the
+// default localizer (i18n.Default). When its locale changes, the localized
+// attributes are translated to the new locale and the layouts of the panels
+// containing them are recalculated. The attributes which can be localized
+// depend on the type of the panel:
+//
+//	text:        Label, Button, CheckBox, RadioButton, Slider, ImageLabel,
+//	             MenuItem and MessageBox
+//	title:       Window, MessageBox and FileDialog
+//	placeholder: Edit
+//	tooltip:     any panel
+//	accessname:  any panel
+//
+// In builder descriptions, attribute values in the form "tr:key" are translated
+// when the panel is built and are localized if the attribute supports it.
+
+// LocalizePrefix is the prefix of the attribute values of builder descriptions
+// which are message keys
+const LocalizePrefix = "tr:"
+
+// localized maps the panels with localized attributes to their message keys by attribute
+var localized = map[IPanel]map[string]string{}
+
+func init() {
+
+	i18n.Default.Subscribe(i18n.OnLocaleChange, func(evname string, ev interface{}) {
+		relocalize()
+	})
+}
+
+// Localize sets the specified attribute of the panel to the message with the
+// specified key, translated to the current locale, and keeps it translated
+// when the locale changes. Returns an error if the attribute cannot be localized.
+func Localize(ipan IPanel, attrib, key string) error {
+
+	set := localizeSetter(ipan, attrib)
+	if set == nil {
+		return fmt.Errorf("Attribute cannot be localized for type:%T", ipan)
+	}
+	keys := localized[ipan]
+	if keys == nil {
+		keys = make(map[string]string)
+		localized[ipan] = keys
+	}
+	keys[attrib] = key
+	set(i18n.Tr(key))
+	return nil
+}
+
+// Unlocalize stops the localization of the attributes of the specified panel
+// and of its descendants. It should be called for localized panels which are
+// disposed before the application ends.
+func Unlocalize(ipan IPanel) {
+
+	delete(localized, ipan)
+	for _, child := range ipan.GetPanel().Children() {
+		if ichild, ok := child.(IPanel); ok {
+			Unlocalize(ichild)
+		}
+	}
+}
+
+// localizeKey returns the message key of the specified attribute value if it is localized
+func localizeKey(v interface{}) (string, bool) {
+
+	s, ok := v.(string)
+	if !ok || !strings.HasPrefix(s, LocalizePrefix) {
+		return "", false
+	}
+	return strings.TrimSpace(s[len(LocalizePrefix):]), true
+}
+
+// localizeSetter returns the function which sets the specified
+// attribute of the panel or nil if it cannot be localized
+func localizeSetter(ipan IPanel, attrib string) func(string) {
+
+	switch attrib {
+	case AttribText:
+		switch p := ipan.(type) {
+		case *Label:
+			return p.SetText
+		case *Button:
+			return p.Label.SetText
+		case *CheckRadio:
+			return p.Label.SetText
+		case *Slider:
+			return func(text string) { p.SetText(text) }
+		case *ImageLabel:
+			return p.SetText
+		case *MenuItem:
+			return func(text string) { p.SetText(text) }
+		case *MessageBox:
+			return func(text string) { p.SetMessage(text) }
+		}
+	case AttribTitle:
+		switch p := ipan.(type) {
+		case *Window:
+			return p.SetTitle
+		case *MessageBox:
+			return p.SetTitle
+		case *FileDialog:
+			return p.SetTitle
+		}
+	case AttribPlaceHolder:
+		if p, ok := ipan.(*Edit); ok {
+			return p.SetPlaceHolder
+		}
+	case AttribTooltip:
+		return ipan.GetPanel().SetTooltip
+	case AttribAccessName:
+		return ipan.GetPanel().SetAccessName
+	}
+	return nil
+}
+
+// relocalize translates the localized attributes of all the panels
+// to the current locale and recalculates the layouts containing them
+func relocalize() {
+
+	tops := make(map[IPanel]bool)
+	for ipan, keys := range localized {
+		for attrib, key := range keys {
+			if set := localizeSetter(ipan, attrib); set != nil {
+				set(i18n.Tr(key))
+			}
+		}
+		tops[topPanel(ipan)] = true
+	}
+	for ipan := range tops {
+		reflow(ipan)
+	}
+}
+
+// topPanel returns the top ancestor panel of the specified panel
+func topPanel(ipan IPanel) IPanel {
+
+	for {
+		ipar, ok := ipan.GetPanel().Parent().(IPanel)
+		if !ok {
+			return ipan
+		}
+		ipan = ipar
+	}
+}
+
+// reflow recalculates the specified panel and its layout
+// after recalculating its descendants
+func reflow(ipan IPanel) {
+
+	p := ipan.GetPanel()
+	for _, child := range p.Children() {
+		if ichild, ok := child.(IPanel); ok {
+			reflow(ichild)
+		}
+	}
+	if r, ok := ipan.(interface{ recalc() }); ok {
+		r.recalc()
+	}
+	if p.layout != nil {
+		p.layout.Recalc(ipan)
+	}
+}
@@ -143,6 +143,7 @@ func NewMenuBar() *Menu {
 	m := NewMenu()
 	m.bar = true
 	m.Panel.Subscribe(OnMouseOut, m.onMouse)
+	m.Panel.SetFocusable(true)
 	return m
 }
 
@@ -228,6 +229,23 @@ func (m *Menu) onKey(evname string, ev interface{}) {
 
 	sel := m.selectedPos()
 	kev := ev.(*window.KeyEvent)
+	// Home and End select the first and last enabled items and the arrow
+	// keys of the menu direction select them in menus without selected item
+	first, last := m.nextItem(-1), m.prevItem(len(m.items))
+	switch {
+	case kev.Keycode == window.KeyHome:
+		m.setSelectedPos(first)
+		return
+	case kev.Keycode == window.KeyEnd:
+		m.setSelectedPos(last)
+		return
+	case sel < 0 && (kev.Keycode == window.KeyDown || m.bar && kev.Keycode == window.KeyRight):
+		m.setSelectedPos(first)
+		return
+	case sel < 0 && (kev.Keycode == window.KeyUp || m.bar && kev.Keycode == window.KeyLeft):
+		m.setSelectedPos(last)
+		return
+	}
 	switch kev.Keycode {
 	// Select next enabled menu item
 	case window.KeyDown:
@@ -0,0 +1,298 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gui
+
+import (
+	"github.com/sansebasko/engine/gui/assets/icon"
+	"github.com/sansebasko/engine/math32"
+	"github.com/sansebasko/engine/window"
+)
+
+/***************************************
+
+ MessageBox
+ +--------------------------------+
+ | Title                          |
+ +--------------------------------+
+ |  +----+                        |
+ |  |icon|  message               |
+ |  +----+                        |
+ |              +------+ +------+ |
+ |              |button| |button| |
+ |              +------+ +------+ |
+ +--------------------------------+
+
+**/
+
+// MessageBox is a modal window which shows a message and a row of buttons.
+// It is closed when one of its buttons is clicked and dispatches OnDialogClose.
+type MessageBox struct {
+	Window                   // Embedded window
+	dialog                   // modal dialog state
+	kind    MessageBoxType   // message box type
+	icon    *Label           // type icon
+	message *Label           // message text
+	buttons []*Button        // buttons
+	styles  *MessageBoxStyle // pointer to style
+	result  int              // index of the clicked button or -1
+}
+
+// MessageBoxType is the type of a message box which defines its icon
+type MessageBoxType int
+
+// The types of message boxes
+const (
+	MessageBoxInfo MessageBoxType = iota
+	MessageBoxWarning
+	MessageBoxError
+	MessageBoxQuestion
+)
+
+// MessageBoxStyle contains the styling of a MessageBox.
+// The frame and title of a message box are styled by the Window style.
+type MessageBoxStyle struct {
+	Spacing       float32       // space around and between the icon, message and buttons
+	MinWidth      float32       // minimum width of the client area
+	ButtonWidth   float32       // minimum width of the buttons
+	IconSize      float32       // font size of the icon
+	InfoColor     math32.Color4 // icon color of MessageBoxInfo
+	WarningColor  math32.Color4 // icon color of MessageBoxWarning
+	ErrorColor    math32.Color4 // icon color of MessageBoxError
+	QuestionColor math32.Color4 // icon color of MessageBoxQuestion
+}
+
+// NewMessageBox creates and returns a pointer to a new message box of the specified type
+// with the specified title, message and buttons. If no buttons are specified,
+// the message box has an "OK" button.
+// The message box must be shown by Show.
+func NewMessageBox(kind MessageBoxType, title, message string, buttons ...string) *MessageBox {
+
+	mb := new(MessageBox)
+	mb.styles = &StyleDefault().MessageBox
+	mb.kind = kind
+	mb.result = -1
+	mb.Window.initialize(0, 0)
+	mb.SetTitle(title)
+	mb.SetCloseButton(false)
+	mb.Subscribe(OnKeyDown, mb.onKey)
+
+	mb.icon = NewIcon(messageBoxIcons[kind])
+	mb.Window.Add(mb.icon)
+	mb.message = NewLabel(message)
+	mb.Window.Add(mb.message)
+	if len(buttons) == 0 {
+		buttons = []string{"OK"}
+	}
+	for i, text := range buttons {
+		b := NewButton(text)
+		idx := i
+		b.Subscribe(OnClick, func(evname string, ev interface{}) { mb.Close(idx) })
+		mb.Window.Add(b)
+		mb.buttons = append(mb.buttons, b)
+	}
+
+	mb.update()
+	return mb
+}
+
+// ShowMessageBox shows a new message box in the specified root and calls the
+// specified function, if not nil, with the index of the clicked button or -1
+// when the message box is closed. The message box is disposed after it is closed.
+func ShowMessageBox(root *Root, kind MessageBoxType, title, message string, cb func(button int), buttons ...string) *MessageBox {
+
+	mb := NewMessageBox(kind, title, message, buttons...)
+	mb.Subscribe(OnDialogClose, func(evname string, ev interface{}) {
+		if cb != nil {
+			cb(mb.result)
+		}
+		// Disposes after the event which closed the message box is processed
+		root.SetTimeout(0, nil, func(arg interface{}) { mb.Dispose() })
+	})
+	mb.Show(root)
+	return mb
+}
+
+// messageBoxIcons maps the message box types to their icons
+var messageBoxIcons = map[MessageBoxType]string{
+	MessageBoxInfo:     icon.Info,
+	MessageBoxWarning:  icon.Warning,
+	MessageBoxError:    icon.Error,
+	MessageBoxQuestion: icon.Help,
+}
+
+// SetStyles set the message box style overriding the default style
+func (mb *MessageBox) SetStyles(ms *MessageBoxStyle) *MessageBox {
+
+	mb.styles = ms
+	mb.update()
+	return mb
+}
+
+// SetMessage sets the message text of the message box
+func (mb *MessageBox) SetMessage(message string) *MessageBox {
+
+	mb.message.SetText(message)
+	mb.recalc()
+	return mb
+}
+
+// Message returns the message text of the message box
+func (mb *MessageBox) Message() string {
+
+	return mb.message.Text()
+}
+
+// Button returns the button at the specified index or nil
+func (mb *MessageBox) Button(idx int) *Button {
+
+	if idx < 0 || idx >= len(mb.buttons) {
+		return nil
+	}
+	return mb.buttons[idx]
+}
+
+// Result returns the index of the button which closed the message box
+// or -1 if it was closed by the Escape key or was not closed yet.
+func (mb *MessageBox) Result() int {
+
+	return mb.result
+}
+
+// Show shows the message box centered in the specified root as its modal panel.
+// The Enter key clicks the first button and the Escape key closes the message box.
+func (mb *MessageBox) Show(root *Root) {
+
+	mb.result = -1
+	mb.recalc()
+	mb.dialog.show(root, mb)
+}
+
+// Close closes the message box with the specified result and dispatches OnDialogClose
+func (mb *MessageBox) Close(result int) {
+
+	if !mb.dialog.close(mb) {
+		return
+	}
+	mb.result = result
+	mb.Dispatch(OnDialogClose, nil)
+}
+
+// onKey process subscribed key events
+func (mb *MessageBox) onKey(evname string, ev interface{}) {
+
+	kev := ev.(*window.KeyEvent)
+	switch kev.Keycode {
+	case window.KeyEnter, window.KeyKPEnter:
+		mb.Close(0)
+	case window.KeyEscape:
+		mb.Close(-1)
+	default:
+		return
+	}
+	mb.root.StopPropagation(Stop3D)
+}
+
+// update updates the message box visual state
+func (mb *MessageBox) update() {
+
+	ms := mb.styles
+	mb.Window.update()
+	colors := [...]math32.Color4{ms.InfoColor, ms.WarningColor, ms.ErrorColor, ms.QuestionColor}
+	mb.icon.SetColor4(&colors[mb.kind])
+	mb.icon.SetFontSize(float64(ms.IconSize))
+	mb.recalc()
+}
+
+// recalc sets the size of the message box from its contents
+// and the positions of its internal panels.
+func (mb *MessageBox) recalc() {
+
+	if mb.message == nil {
+		mb.Window.recalc()
+		return
+	}
+	sp := mb.styles.Spacing
+
+	// Buttons width
+	bwidth := float32(0)
+	bheight := float32(0)
+	for _, b := range mb.buttons {
+		if b.Width() < mb.styles.ButtonWidth {
+			b.SetWidth(mb.styles.ButtonWidth)
+		}
+		bwidth += b.Width() + sp
+		bheight = math32.Max(bheight, b.Height())
+	}
+	bwidth -= sp
+
+	// Client area size
+	mx := sp + mb.icon.Width() + sp
+	width := math32.Max(mx+mb.message.Width(), sp+bwidth) + sp
+	width = math32.Max(width, mb.styles.MinWidth)
+	by := sp + math32.Max(mb.icon.Height(), mb.message.Height()) + sp
+	height := by + bheight + sp
+
+	// Icon and message are vertically centered above the buttons
+	mb.icon.SetPosition(sp, (by-mb.icon.Height())/2)
+	mb.message.SetPosition(mx, (by-mb.message.Height())/2)
+
+	// Buttons are aligned to the right
+	bx := width - sp - bwidth
+	for _, b := range mb.buttons {
+		b.SetPosition(bx, by)
+		bx += b.Width() + sp
+	}
+
+	title := float32(0)
+	if mb.title != nil {
+		title = mb.title.Height()
+	}
+	mb.SetContentSize(width+mb.client.MinWidth(), height+mb.client.MinHeight()+title)
+	mb.Window.recalc()
+}
+
+// dialog contains the state of the modal dialogs shown by a root
+type dialog struct {
+	droot     *Root  // root where the dialog is shown or nil
+	prevModal IPanel // modal panel of the root before the dialog was shown
+}
+
+// show adds the specified dialog panel centered in the specified root
+// and sets it as the root modal panel with the key focus.
+func (d *dialog) show(root *Root, ipan IPanel) {
+
+	if d.droot != nil {
+		return
+	}
+	pan := ipan.GetPanel()
+	d.droot = root
+	d.prevModal = root.modalPanel
+	root.Add(ipan)
+	// Dialogs larger than the root are shown from its top left corner
+	pan.SetPosition(
+		math32.Round(math32.Max(root.ContentWidth()-pan.Width(), 0)/2),
+		math32.Round(math32.Max(root.ContentHeight()-pan.Height(), 0)/2))
+	root.SetModal(ipan)
+	root.SetKeyFocus(ipan)
+}
+
+// close removes the specified dialog panel from its root and restores
+// the previous modal panel. Returns false if the dialog is not shown.
+func (d *dialog) close(ipan IPanel) bool {
+
+	root := d.droot
+	if root == nil {
+		return false
+	}
+	d.droot = nil
+	if root.keyFocus != nil && panelContains(ipan, root.keyFocus) {
+		root.SetKeyFocus(nil)
+	}
+	root.SetMouseFocus(nil)
+	root.SetModal(d.prevModal)
+	d.prevModal = nil
+	root.Remove(ipan)
+	return true
+}
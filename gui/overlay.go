@@ -0,0 +1,420 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gui
+
+import (
+	"time"
+
+	"github.com/sansebasko/engine/window"
+)
+
+// The overlay is a layer managed by the root panel which is always shown above all
+// the other panels. It shows the tooltips of the panels under the cursor, the context
+// menus of right clicked panels, popovers anchored to other panels and the focus ring.
+
+// TooltipStyle contains the styling of the tooltips
+type TooltipStyle BasicStyle
+
+// PopoverPlacement specifies the side of its anchor panel where a popover is shown
+type PopoverPlacement int
+
+// The possible popover placements.
+// If there is no space in the window for the popover on the specified side,
+// it is shown on the opposite side if there is space there.
+const (
+	PlaceBelow = PopoverPlacement(iota) // Popover is placed below the anchor panel
+	PlaceAbove                          // Popover is placed above the anchor panel
+	PlaceRight                          // Popover is placed at the right of the anchor panel
+	PlaceLeft                           // Popover is placed at the left of the anchor panel
+)
+
+const (
+	tooltipDelay   = 600 * time.Millisecond // default delay to show tooltips
+	tooltipOffsetY = 20                     // vertical distance of tooltips from the cursor
+)
+
+// overlay is the layer of the root panel above all other panels
+type overlay struct {
+	Panel                   // embedded panel
+	tooltip  *Label         // tooltip label
+	ring     *Panel         // focus ring around the panel with the key focus
+	tipPanel IPanel         // panel under the cursor whose tooltip is pending or shown
+	tipTimer int            // timer id of pending tooltip (0 if none)
+	tipDelay time.Duration  // delay to show tooltips
+	tipX     float32        // cursor position when tooltip is shown
+	tipY     float32        // cursor position when tooltip is shown
+	popups   []*overlayItem // open context menus and popovers
+	menus    map[*Menu]bool // menus subscribed to close when clicked
+}
+
+// overlayItem describes an open context menu or popover
+type overlayItem struct {
+	ipan      IPanel           // popup panel
+	anchor    IPanel           // anchor panel of popovers
+	placement PopoverPlacement // placement of popovers
+	x, y      float32          // position of context menus
+	autoClose bool             // closes when clicked outside
+}
+
+// SetTooltipDelay sets the time the cursor must rest over a panel
+// before its tooltip is shown
+func (r *Root) SetTooltipDelay(delay time.Duration) {
+
+	r.overlayLayer().tipDelay = delay
+}
+
+// ShowContextMenu shows the specified menu at the specified position,
+// moving it if necessary to stay inside the window.
+// The menu is closed when one of its options is clicked, when the mouse
+// is pressed outside of it or when the Escape key is pressed.
+func (r *Root) ShowContextMenu(m *Menu, x, y float32) {
+
+	o := r.overlayLayer()
+	o.close(m)
+	m.autoOpen = true
+	m.setSelectedPos(-1)
+	if !o.menus[m] {
+		o.menus[m] = true
+		m.Subscribe(OnClick, func(evname string, ev interface{}) {
+			o.close(m)
+		})
+	}
+	o.open(&overlayItem{ipan: m, x: x, y: y, autoClose: true})
+	r.SetKeyFocus(m)
+}
+
+// ShowPopover shows the specified panel anchored to the specified panel at
+// the specified side. The popover follows the anchor and is moved if necessary
+// to stay inside the window. It is closed when the anchor is hidden or removed,
+// when the Escape key is pressed and, if autoClose is true, when the mouse is
+// pressed outside of it.
+func (r *Root) ShowPopover(ipan, anchor IPanel, placement PopoverPlacement, autoClose bool) {
+
+	o := r.overlayLayer()
+	o.close(ipan)
+	o.open(&overlayItem{ipan: ipan, anchor: anchor, placement: placement, autoClose: autoClose})
+}
+
+// ClosePopup closes the specified context menu or popover if it is open
+// and dispatches OnPopupClose to it
+func (r *Root) ClosePopup(ipan IPanel) {
+
+	if r.overlay != nil {
+		r.overlay.close(ipan)
+	}
+}
+
+// IsPopupOpen returns if the specified context menu or popover is open
+func (r *Root) IsPopupOpen(ipan IPanel) bool {
+
+	return r.overlay != nil && r.overlay.find(ipan) >= 0
+}
+
+// overlayLayer returns the overlay of this root panel creating it if necessary.
+// The overlay is also created again if it was removed from the root panel,
+// for example by RemoveAll or DisposeChildren, which also remove or dispose
+// its tooltip and focus ring.
+func (r *Root) overlayLayer() *overlay {
+
+	tipDelay := tooltipDelay
+	if r.overlay != nil {
+		if r.overlay.Parent() == r.GetPanel() {
+			return r.overlay
+		}
+		if r.overlay.tipTimer != 0 {
+			r.ClearTimeout(r.overlay.tipTimer)
+		}
+		tipDelay = r.overlay.tipDelay
+	}
+	o := new(overlay)
+	o.Panel.Initialize(0, 0)
+	o.SetRenderable(false)
+	o.SetBounded(false)
+	o.tipDelay = tipDelay
+	o.menus = make(map[*Menu]bool)
+	o.ring = NewPanel(0, 0)
+	o.ring.SetBounded(false)
+	o.ring.SetEnabled(false)
+	o.ring.SetVisible(false)
+	o.Add(o.ring)
+	o.tooltip = NewLabel("")
+	o.tooltip.SetBounded(false)
+	o.tooltip.SetEnabled(false)
+	o.tooltip.SetVisible(false)
+	o.Add(o.tooltip)
+	r.overlay = o
+	r.Add(o)
+	return o
+}
+
+// open adds the specified popup to the overlay
+func (o *overlay) open(item *overlayItem) {
+
+	o.hideTooltip()
+	item.ipan.GetPanel().SetBounded(false)
+	item.ipan.GetPanel().SetVisible(true)
+	o.popups = append(o.popups, item)
+	o.Add(item.ipan)
+	o.place(item)
+	o.root.SetTopChild(o)
+}
+
+// find returns the position of the specified panel in the open popups or -1
+func (o *overlay) find(ipan IPanel) int {
+
+	for i, item := range o.popups {
+		if item.ipan.GetPanel() == ipan.GetPanel() {
+			return i
+		}
+	}
+	return -1
+}
+
+// close closes the specified popup if it is open
+func (o *overlay) close(ipan IPanel) {
+
+	idx := o.find(ipan)
+	if idx < 0 {
+		return
+	}
+	copy(o.popups[idx:], o.popups[idx+1:])
+	o.popups[len(o.popups)-1] = nil
+	o.popups = o.popups[:len(o.popups)-1]
+	if m, ok := ipan.(*Menu); ok {
+		m.setSelectedPos(-1)
+	}
+	r := o.root
+	if r.keyFocus != nil && panelContains(ipan, r.keyFocus) {
+		r.SetKeyFocus(nil)
+	}
+	if r.mouseFocus != nil && panelContains(ipan, r.mouseFocus) {
+		r.SetMouseFocus(nil)
+	}
+	o.Remove(ipan)
+	ipan.GetPanel().Dispatch(OnPopupClose, nil)
+}
+
+// place sets the position of the specified popup keeping it inside the window
+func (o *overlay) place(item *overlayItem) {
+
+	pan := item.ipan.GetPanel()
+	width, height := pan.Width(), pan.Height()
+	rwidth, rheight := o.root.Width(), o.root.Height()
+	var x, y float32
+	if item.anchor != nil {
+		a := item.anchor.GetPanel()
+		ax, ay := a.pospix.X, a.pospix.Y
+		// Uses the opposite side if there is only space there
+		placement := item.placement
+		switch placement {
+		case PlaceBelow:
+			if ay+a.height+height > rheight && ay-height >= 0 {
+				placement = PlaceAbove
+			}
+		case PlaceAbove:
+			if ay-height < 0 && ay+a.height+height <= rheight {
+				placement = PlaceBelow
+			}
+		case PlaceRight:
+			if ax+a.width+width > rwidth && ax-width >= 0 {
+				placement = PlaceLeft
+			}
+		case PlaceLeft:
+			if ax-width < 0 && ax+a.width+width <= rwidth {
+				placement = PlaceRight
+			}
+		}
+		switch placement {
+		case PlaceBelow:
+			x, y = ax, ay+a.height
+		case PlaceAbove:
+			x, y = ax, ay-height
+		case PlaceRight:
+			x, y = ax+a.width, ay
+		case PlaceLeft:
+			x, y = ax-width, ay
+		}
+	} else {
+		// Opens the context menu to the left or up of the position if there is no space
+		x, y = item.x, item.y
+		if x+width > rwidth && x-width >= 0 {
+			x -= width
+		}
+		if y+height > rheight && y-height >= 0 {
+			y -= height
+		}
+	}
+	pan.SetPosition(overlayClamp(x, width, rwidth), overlayClamp(y, height, rheight))
+}
+
+// update is called by the root panel for every frame to update the open popups
+func (o *overlay) update() {
+
+	// Keeps the overlay above the other children of the root panel
+	children := o.root.Children()
+	if children[len(children)-1] != o {
+		o.root.SetTopChild(o)
+	}
+	o.updateFocusRing()
+	// Hides the tooltip of removed or hidden panels
+	if o.tipPanel != nil && !o.shown(o.tipPanel) {
+		o.hideTooltip()
+	}
+	// Closes the popovers whose anchors were removed or hidden and updates positions
+	for i := len(o.popups) - 1; i >= 0; i-- {
+		item := o.popups[i]
+		if item.anchor != nil && !o.shown(item.anchor) {
+			o.close(item.ipan)
+			continue
+		}
+		o.place(item)
+	}
+}
+
+// shown returns if the specified panel and all its ancestors
+// up to the root panel are visible
+func (o *overlay) shown(ipan IPanel) bool {
+
+	for n := ipan.GetPanel().GetNode(); n != nil; {
+		if n == o.root.GetNode() {
+			return true
+		}
+		if !n.Visible() || n.Parent() == nil {
+			return false
+		}
+		n = n.Parent().GetNode()
+	}
+	return false
+}
+
+// hover is called by the root panel with the foremost panel under the cursor
+// or nil to show the tooltip of the panel or of its nearest ancestor with a tooltip
+func (o *overlay) hover(target IPanel, x, y float32) {
+
+	var tp IPanel
+	for ipan := target; ipan != nil; {
+		if ipan.GetPanel().tooltip != "" {
+			tp = ipan
+			break
+		}
+		ipan, _ = ipan.GetPanel().Parent().(IPanel)
+	}
+	if tp == o.tipPanel {
+		if o.tipTimer != 0 {
+			o.tipX, o.tipY = x, y
+		}
+		return
+	}
+	o.hideTooltip()
+	o.tipPanel = tp
+	if tp == nil {
+		return
+	}
+	o.tipX, o.tipY = x, y
+	o.tipTimer = o.root.SetTimeout(o.tipDelay, nil, func(arg interface{}) {
+		o.tipTimer = 0
+		o.showTooltip()
+	})
+}
+
+// showTooltip shows the tooltip of the current tooltip panel near the cursor
+func (o *overlay) showTooltip() {
+
+	s := &StyleDefault().Tooltip
+	o.tooltip.ApplyStyle(&s.PanelStyle)
+	o.tooltip.SetColor4(&s.FgColor)
+	o.tooltip.SetText(o.tipPanel.GetPanel().tooltip)
+	width, height := o.tooltip.Width(), o.tooltip.Height()
+	rwidth, rheight := o.root.Width(), o.root.Height()
+	x := o.tipX
+	y := o.tipY + tooltipOffsetY
+	if y+height > rheight {
+		y = o.tipY - height - tooltipOffsetY/2
+	}
+	o.tooltip.SetPosition(overlayClamp(x, width, rwidth), overlayClamp(y, height, rheight))
+	o.tooltip.SetVisible(true)
+	o.root.SetTopChild(o)
+}
+
+// hideTooltip hides the current tooltip or cancels the pending tooltip
+func (o *overlay) hideTooltip() {
+
+	if o.tipTimer != 0 {
+		o.root.ClearTimeout(o.tipTimer)
+		o.tipTimer = 0
+	}
+	o.tipPanel = nil
+	o.tooltip.SetVisible(false)
+}
+
+// mouseDown is called by the root panel before dispatching mouse button events
+// and closes the popups which do not contain the mouse position
+func (o *overlay) mouseDown(x, y float32) {
+
+	o.hideTooltip()
+	for i := len(o.popups) - 1; i >= 0; i-- {
+		item := o.popups[i]
+		if item.autoClose && !overlayInside(item.ipan, x, y) {
+			o.close(item.ipan)
+		}
+	}
+}
+
+// contextMenu is called by the root panel after dispatching right mouse button
+// events to show the context menu of the specified panel or of its nearest ancestor
+func (o *overlay) contextMenu(target IPanel, x, y float32) {
+
+	for ipan := target; ipan != nil; {
+		if m := ipan.GetPanel().contextMenu; m != nil {
+			o.root.ShowContextMenu(m, x, y)
+			return
+		}
+		ipan, _ = ipan.GetPanel().Parent().(IPanel)
+	}
+}
+
+// onKey is called by the root panel before dispatching key events.
+// Returns true if the event closed the last open popup.
+func (o *overlay) onKey(kev *window.KeyEvent) bool {
+
+	o.hideTooltip()
+	if kev.Keycode != window.KeyEscape || len(o.popups) == 0 {
+		return false
+	}
+	o.close(o.popups[len(o.popups)-1].ipan)
+	return true
+}
+
+// overlayInside returns if the specified panel or any of
+// its visible descendants contains the specified position
+func overlayInside(ipan IPanel, x, y float32) bool {
+
+	pan := ipan.GetPanel()
+	if !pan.Visible() {
+		return false
+	}
+	if pan.InsideBorders(x, y) {
+		return true
+	}
+	for _, child := range pan.Children() {
+		if ichild, ok := child.(IPanel); ok && overlayInside(ichild, x, y) {
+			return true
+		}
+	}
+	return false
+}
+
+// overlayClamp returns the specified position adjusted so a panel with the
+// specified size stays inside the specified window size if possible
+func overlayClamp(pos, size, max float32) float32 {
+
+	if pos+size > max {
+		pos = max - size
+	}
+	if pos < 0 {
+		pos = 0
+	}
+	return pos
+}
@@ -0,0 +1,44 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gui
+
+import (
+	"testing"
+
+	"github.com/sansebasko/engine/window"
+)
+
+// Tests that tooltips are shown after the children of the root panel,
+// including its overlay, were removed or disposed.
+func TestOverlayTooltip(t *testing.T) {
+
+	r := newTestRoot()
+	r.SetTooltipDelay(0)
+	tests := []struct {
+		name   string
+		remove func()
+	}{
+		{"initial", func() {}},
+		{"RemoveAll", func() { r.RemoveAll(true) }},
+		{"DisposeChildren", func() { r.DisposeChildren(true) }},
+	}
+	for _, test := range tests {
+		test.remove()
+		b := NewButton("Button")
+		b.SetTooltip("tip")
+		r.Add(b)
+		r.UpdateMatrixWorld()
+		r.onCursor(window.OnCursor, &window.CursorEvent{Xpos: 5, Ypos: 5})
+		r.onFrame(window.OnFrame, nil)
+		o := r.overlay
+		if o == nil || o.Parent() != r.GetPanel() || o.tooltip.Parent() != o.GetPanel() || !o.tooltip.Visible() || o.tooltip.Text() != "tip" {
+			t.Errorf("%s: tooltip not shown", test.name)
+		}
+		if o != nil && o.tipDelay != 0 {
+			t.Errorf("%s: tooltip delay %v", test.name, o.tipDelay)
+		}
+		r.onCursor(window.OnCursor, &window.CursorEvent{Xpos: 300, Ypos: 200})
+	}
+}
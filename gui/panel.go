@@ -81,6 +81,14 @@ type Panel struct {
 	cursorEnter      bool               // mouse enter dispatched
 	layout           ILayout            // current layout for children
 	layoutParams     interface{}        // current layout parameters used by container panel
+	tooltip          string             // tooltip text shown when the cursor rests over the panel
+	contextMenu      *Menu              // context menu shown when the panel is right clicked
+	dragSource       DragSourceFunc     // function which returns the payload dragged from the panel
+	dropTarget       bool               // panel receives the drag and drop events of dragged payloads
+	focusable        bool               // panel receives the key focus by keyboard navigation
+	tabIndex         int                // position in the focus order (0 for document order)
+	accessRole       AccessRole         // accessibility role overriding the role of the panel type
+	accessName       string             // accessibility name overriding the name of the panel type
 	uniMatrix        gls.Uniform        // model matrix uniform location cache
 	uniPanel         gls.Uniform        // panel parameters uniform location cache
 	udata            struct {           // Combined uniform data 8 * vec4
@@ -132,33 +140,19 @@ func NewPanel(width, height float32) *Panel {
 // Initialize initializes this panel and is normally used by other types which embed a panel.
 func (p *Panel) Initialize(width, height float32) {
 
-	p.width = width
-	p.height = height
-
 	// If necessary, creates panel quad geometry
 	if panelQuadGeometry == nil {
-
-		// Builds array with vertex positions and texture coordinates
-		positions := math32.NewArrayF32(0, 20)
-		positions.Append(
-			0, 0, 0, 0, 1,
-			0, -1, 0, 0, 0,
-			1, -1, 0, 1, 0,
-			1, 0, 0, 1, 1,
-		)
-		// Builds array of indices
-		indices := math32.NewArrayU32(0, 6)
-		indices.Append(0, 1, 2, 0, 2, 3)
-
-		// Creates geometry
-		geom := geometry.NewGeometry()
-		geom.SetIndices(indices)
-		geom.AddVBO(gls.NewVBO(positions).
-			AddAttrib(gls.VertexPosition).
-			AddAttrib(gls.VertexTexcoord),
-		)
-		panelQuadGeometry = geom
+		panelQuadGeometry = newPanelGeometry()
 	}
+	p.initialize(width, height, panelQuadGeometry.Incref())
+}
+
+// initialize initializes this panel with the specified geometry
+// whose first 6 indices must be the panel quad.
+func (p *Panel) initialize(width, height float32, geom *geometry.Geometry) {
+
+	p.width = width
+	p.height = height
 
 	// Initialize material
 	p.mat = material.NewMaterial()
@@ -166,8 +160,8 @@ func (p *Panel) Initialize(width, height float32) {
 	p.mat.SetShaderUnique(true)
 
 	// Initialize graphic
-	p.Graphic = graphic.NewGraphic(panelQuadGeometry.Incref(), gls.TRIANGLES)
-	p.AddMaterial(p, p.mat, 0, 0)
+	p.Graphic = graphic.NewGraphic(geom, gls.TRIANGLES)
+	p.AddMaterial(p, p.mat, 0, 6)
 
 	// Initialize uniforms location caches
 	p.uniMatrix.Init("ModelMatrix")
@@ -180,6 +174,31 @@ func (p *Panel) Initialize(width, height float32) {
 	p.resize(width, height, true)
 }
 
+// newPanelGeometry creates and returns a new geometry with the panel quad
+func newPanelGeometry() *geometry.Geometry {
+
+	// Builds array with vertex positions and texture coordinates
+	positions := math32.NewArrayF32(0, 20)
+	positions.Append(
+		0, 0, 0, 0, 1,
+		0, -1, 0, 0, 0,
+		1, -1, 0, 1, 0,
+		1, 0, 0, 1, 1,
+	)
+	// Builds array of indices
+	indices := math32.NewArrayU32(0, 6)
+	indices.Append(0, 1, 2, 0, 2, 3)
+
+	// Creates geometry
+	geom := geometry.NewGeometry()
+	geom.SetIndices(indices)
+	geom.AddVBO(gls.NewVBO(positions).
+		AddAttrib(gls.VertexPosition).
+		AddAttrib(gls.VertexTexcoord),
+	)
+	return geom
+}
+
 // InitializeGraphic initializes this panel with a different graphic
 func (p *Panel) InitializeGraphic(width, height float32, gr *graphic.Graphic) {
 
@@ -211,8 +230,8 @@ func (p *Panel) SetRoot(root *Root) {
 
 	p.root = root
 	for i := 0; i < len(p.Children()); i++ {
-		cpan := p.Children()[i].(IPanel).GetPanel()
-		cpan.SetRoot(root)
+		// Uses the interface so panels overriding SetRoot are also informed
+		p.Children()[i].(IPanel).SetRoot(root)
 	}
 }
 
@@ -529,6 +548,26 @@ func (p *Panel) Add(ichild IPanel) *Panel {
 	if p.root != nil {
 		ichild.SetRoot(p.root)
 		p.root.setZ(0, deltaZunb)
+		rescaleAdded(ichild)
+	}
+	if p.layout != nil {
+		p.layout.Recalc(p)
+	}
+	p.Dispatch(OnChild, nil)
+	return p
+}
+
+// AddAt adds the specified child panel to this panel at the specified
+// position of its children list, before the child currently at this position
+func (p *Panel) AddAt(idx int, ichild IPanel) *Panel {
+
+	p.Node.AddAt(idx, ichild)
+	node := ichild.GetPanel()
+	node.SetParent(p)
+	if p.root != nil {
+		ichild.SetRoot(p.root)
+		p.root.setZ(0, deltaZunb)
+		rescaleAdded(ichild)
 	}
 	if p.layout != nil {
 		p.layout.Recalc(p)
@@ -550,6 +589,111 @@ func (p *Panel) Remove(ichild IPanel) bool {
 	return res
 }
 
+// SetTooltip sets the text shown by the root panel overlay when the cursor
+// rests over this panel. An empty text removes the tooltip.
+func (p *Panel) SetTooltip(text string) {
+
+	p.tooltip = text
+}
+
+// Tooltip returns the tooltip text of this panel
+func (p *Panel) Tooltip() string {
+
+	return p.tooltip
+}
+
+// SetContextMenu sets the menu shown by the root panel overlay
+// when this panel or one of its children without a context menu
+// is right clicked. Passing nil removes the context menu.
+func (p *Panel) SetContextMenu(m *Menu) {
+
+	p.contextMenu = m
+}
+
+// ContextMenu returns the context menu of this panel
+func (p *Panel) ContextMenu() *Menu {
+
+	return p.contextMenu
+}
+
+// SetDragSource sets the function called by the root panel to get the payload
+// to drag when the mouse is dragged with the left button pressed over this panel
+// or one of its children without a drag source function.
+// Passing nil removes the drag source function.
+func (p *Panel) SetDragSource(f DragSourceFunc) {
+
+	p.dragSource = f
+}
+
+// SetDropTarget sets if this panel receives the drag and drop events
+// of the payloads dragged over it or over its children which are not drop targets
+func (p *Panel) SetDropTarget(state bool) {
+
+	p.dropTarget = state
+}
+
+// DropTarget returns if this panel is a drop target
+func (p *Panel) DropTarget() bool {
+
+	return p.dropTarget
+}
+
+// SetFocusable sets if this panel receives the key focus when the
+// focus is moved by keyboard navigation with the Tab key
+func (p *Panel) SetFocusable(state bool) {
+
+	p.focusable = state
+}
+
+// Focusable returns if this panel receives the key focus by keyboard navigation
+func (p *Panel) Focusable() bool {
+
+	return p.focusable
+}
+
+// SetTabIndex sets the position of this panel in the focus order.
+// Focusable panels with positive indices are visited first in increasing
+// order of their indices, followed by the panels with index 0 in document order.
+// Panels with negative indices are skipped by keyboard navigation.
+func (p *Panel) SetTabIndex(index int) {
+
+	p.tabIndex = index
+}
+
+// TabIndex returns the position of this panel in the focus order
+func (p *Panel) TabIndex() int {
+
+	return p.tabIndex
+}
+
+// SetAccessRole sets the accessibility role of this panel,
+// overriding the role of its type. Passing RoleNone restores
+// the role of the panel type.
+func (p *Panel) SetAccessRole(role AccessRole) {
+
+	p.accessRole = role
+}
+
+// AccessRole returns the accessibility role set for this panel
+func (p *Panel) AccessRole() AccessRole {
+
+	return p.accessRole
+}
+
+// SetAccessName sets the accessibility name of this panel, overriding the name
+// derived from its contents. It is normally used to name panels without text,
+// such as edits, images and icon buttons.
+func (p *Panel) SetAccessName(name string) {
+
+	p.accessName = name
+}
+
+// AccessName returns the accessibility name set for this panel
+func (p *Panel) AccessName() string {
+
+	return p.accessName
+}
+
 // Bounded returns this panel bounded state
 func (p *Panel) Bounded() bool {
 
@@ -967,15 +1111,10 @@ func (p *Panel) RenderSetup(gl *gls.GLS, rinfo *core.RenderInfo) {
 // SetModelMatrix calculates and sets the specified matrix with the model matrix for this panel
 func (p *Panel) SetModelMatrix(gl *gls.GLS, mm *math32.Matrix4) {
 
-	// Get scale of window (for HiDPI support)
-	sX64, sY64 := p.Root().Window().Scale()
-	sX := float32(sX64)
-	sY := float32(sY64)
-
-	// Get the current viewport width and height
+	// Get the current viewport width and height in GUI units
 	_, _, width, height := gl.GetViewport()
-	fwidth := float32(width) / sX
-	fheight := float32(height) / sY
+	fwidth := float32(width) / guiScale.value
+	fheight := float32(height) / guiScale.value
 
 	// Scale the quad for the viewport so it has fixed dimensions in pixels.
 	p.wclip = 2 * float32(p.width) / fwidth
@@ -994,3 +1133,21 @@ func (p *Panel) SetModelMatrix(gl *gls.GLS, mm *math32.Matrix4) {
 	quat.SetIdentity()
 	mm.Compose(&p.posclip, &quat, &scale)
 }
+
+// panelContains returns if the specified panel is the specified ancestor
+// panel or one of its descendants
+func panelContains(ancestor, ipan IPanel) bool {
+
+	target := ancestor.GetPanel().GetNode()
+	for n := ipan.GetPanel().GetNode(); n != nil; {
+		if n == target {
+			return true
+		}
+		parent := n.Parent()
+		if parent == nil {
+			break
+		}
+		n = parent.GetNode()
+	}
+	return false
+}
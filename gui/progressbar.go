@@ -0,0 +1,254 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gui
+
+import (
+	"fmt"
+	"time"
+)
+
+/***************************************
+
+ ProgressBar
+ +--------------------------------+
+ |+-------------+                 |
+ ||   bar      label              |
+ |+-------------+                 |
+ +--------------------------------+
+
+**/
+
+// ProgressBar is the GUI element which shows the progress of an operation.
+// A determinate progress bar shows a value from 0 to 1 and an optional text
+// with the value. An indeterminate progress bar shows an animated bar moving
+// back and forth while the progress is unknown.
+type ProgressBar struct {
+	Panel                            // Embedded panel
+	bar           Panel              // progress bar panel
+	label         *Label             // value text
+	styles        *ProgressBarStyles // pointer to styles
+	value         float32            // current value from 0 to 1
+	format        string             // format of the value text
+	indeterminate bool               // indeterminate state
+	phase         float32            // animation phase from 0 to 2
+	timerID       int                // animation timer id
+	timerRoot     *Root              // root of the animation timer or nil
+}
+
+// ProgressBarStyle contains the styling of a ProgressBar.
+// The FgColor is the color of the bar.
+type ProgressBarStyle BasicStyle
+
+// ProgressBarStyles contains a ProgressBarStyle for each valid GUI state
+type ProgressBarStyles struct {
+	Normal   ProgressBarStyle
+	Disabled ProgressBarStyle
+}
+
+const (
+	progressInterval = 30 * time.Millisecond // interval of the indeterminate animation
+	progressSpeed    = 0.03                  // phase advance of the indeterminate animation per interval
+	progressBarWidth = 0.3                   // relative width of the indeterminate bar
+)
+
+// NewProgressBar creates and returns a pointer to a new progress bar
+// with the specified initial dimensions.
+func NewProgressBar(width, height float32) *ProgressBar {
+
+	pb := new(ProgressBar)
+	pb.styles = &StyleDefault().ProgressBar
+	pb.format = "%.0f%%"
+
+	pb.Panel.Initialize(width, height)
+	pb.Panel.Subscribe(OnResize, func(evname string, ev interface{}) { pb.recalc() })
+	pb.Panel.Subscribe(OnEnable, func(evname string, ev interface{}) { pb.update() })
+
+	pb.bar.Initialize(0, 0)
+	pb.Panel.Add(&pb.bar)
+	pb.label = NewLabel("")
+	pb.Panel.Add(pb.label)
+
+	pb.setText()
+	pb.recalc()
+	pb.update()
+	return pb
+}
+
+// SetStyles set the progress bar styles overriding the default style
+func (pb *ProgressBar) SetStyles(ps *ProgressBarStyles) *ProgressBar {
+
+	pb.styles = ps
+	pb.update()
+	return pb
+}
+
+// SetValue sets the value of the progress bar from 0 to 1
+func (pb *ProgressBar) SetValue(value float32) *ProgressBar {
+
+	if value < 0 {
+		value = 0
+	} else if value > 1 {
+		value = 1
+	}
+	if value == pb.value {
+		return pb
+	}
+	pb.value = value
+	pb.setText()
+	pb.recalc()
+	pb.Dispatch(OnChange, nil)
+	return pb
+}
+
+// Value returns the current value of the progress bar
+func (pb *ProgressBar) Value() float32 {
+
+	return pb.value
+}
+
+// SetFormat sets the fmt format of the text showing the value as a percentage
+// (default = "%.0f%%"). An empty format hides the text.
+func (pb *ProgressBar) SetFormat(format string) *ProgressBar {
+
+	pb.format = format
+	pb.setText()
+	pb.recalc()
+	return pb
+}
+
+// Format returns the format of the text showing the value
+func (pb *ProgressBar) Format() string {
+
+	return pb.format
+}
+
+// SetIndeterminate sets the indeterminate state of the progress bar.
+// An indeterminate progress bar is animated while it is in a GUI root
+// and does not show its value.
+func (pb *ProgressBar) SetIndeterminate(state bool) *ProgressBar {
+
+	pb.indeterminate = state
+	pb.phase = 0
+	pb.setText()
+	pb.animate()
+	pb.recalc()
+	return pb
+}
+
+// Indeterminate returns the indeterminate state of the progress bar
+func (pb *ProgressBar) Indeterminate() bool {
+
+	return pb.indeterminate
+}
+
+// SetRoot satisfies the IPanel interface and starts the animation
+// of indeterminate progress bars when they are added to a GUI root.
+func (pb *ProgressBar) SetRoot(root *Root) {
+
+	pb.Panel.SetRoot(root)
+	pb.animate()
+}
+
+// Dispose stops the animation and releases the resources of the progress bar
+func (pb *ProgressBar) Dispose() {
+
+	pb.stopAnimation()
+	pb.Panel.Dispose()
+}
+
+// animate starts or stops the animation timer according to the current state
+func (pb *ProgressBar) animate() {
+
+	if !pb.indeterminate || pb.root != pb.timerRoot {
+		pb.stopAnimation()
+	}
+	if !pb.indeterminate || pb.root == nil || pb.timerRoot != nil {
+		return
+	}
+	pb.timerRoot = pb.root
+	pb.timerID = pb.root.SetInterval(progressInterval, nil, pb.onTimer)
+}
+
+// stopAnimation stops the animation timer if it is running
+func (pb *ProgressBar) stopAnimation() {
+
+	if pb.timerRoot != nil {
+		pb.timerRoot.ClearTimeout(pb.timerID)
+		pb.timerRoot = nil
+	}
+}
+
+// onTimer advances the animation of the indeterminate progress bar
+func (pb *ProgressBar) onTimer(arg interface{}) {
+
+	// Stops when the progress bar was removed from the GUI
+	if pb.Parent() == nil {
+		pb.stopAnimation()
+		return
+	}
+	pb.phase += progressSpeed
+	if pb.phase >= 2 {
+		pb.phase -= 2
+	}
+	pb.recalc()
+}
+
+// setText sets the text of the label from the current value
+func (pb *ProgressBar) setText() {
+
+	if pb.format == "" || pb.indeterminate {
+		pb.label.SetVisible(false)
+		return
+	}
+	pb.label.SetText(fmt.Sprintf(pb.format, pb.value*100))
+	pb.label.SetVisible(true)
+}
+
+// update updates the progress bar visual state
+func (pb *ProgressBar) update() {
+
+	if !pb.Enabled() {
+		pb.applyStyle(&pb.styles.Disabled)
+		return
+	}
+	pb.applyStyle(&pb.styles.Normal)
+}
+
+// applyStyle applies the specified progress bar style
+func (pb *ProgressBar) applyStyle(ps *ProgressBarStyle) {
+
+	pb.Panel.ApplyStyle(&ps.PanelStyle)
+	pb.bar.SetColor4(&ps.FgColor)
+	pb.recalc()
+}
+
+// recalc recalculates the dimensions and positions of the internal panels.
+func (pb *ProgressBar) recalc() {
+
+	width := pb.ContentWidth()
+	if pb.label.Visible() {
+		if pb.ContentHeight() < pb.label.Height() {
+			pb.SetContentHeight(pb.label.Height())
+		}
+		lx := (width - pb.label.Width()) / 2
+		ly := (pb.ContentHeight() - pb.label.Height()) / 2
+		pb.label.SetPosition(lx, ly)
+	}
+	height := pb.ContentHeight()
+	if !pb.indeterminate {
+		pb.bar.SetPosition(0, 0)
+		pb.bar.SetSize(width*pb.value, height)
+		return
+	}
+	// The indeterminate bar moves from left to right in the first half of
+	// the phase and back in the second half
+	pos := pb.phase
+	if pos > 1 {
+		pos = 2 - pos
+	}
+	bw := width * progressBarWidth
+	pb.bar.SetPosition((width-bw)*pos, 0)
+	pb.bar.SetSize(bw, height)
+}
@@ -13,16 +13,22 @@ import (
 
 // Root is the container and dispatcher of panel events
 type Root struct {
-	Panel                            // embedded panel
-	core.TimerManager                // embedded TimerManager
-	gs                *gls.GLS       // OpenGL state
-	win               window.IWindow // Window
-	stopPropagation   int            // stop event propagation bitmask
-	keyFocus          IPanel         // current child panel with key focus
-	mouseFocus        IPanel         // current child panel with mouse focus
-	scrollFocus       IPanel         // current child panel with scroll focus
-	modalPanel        IPanel         // current modal panel
-	targets           []IPanel       // preallocated list of target panels
+	Panel                                // embedded panel
+	core.TimerManager                    // embedded TimerManager
+	gs                *gls.GLS           // OpenGL state
+	win               window.IWindow     // Window
+	stopPropagation   int                // stop event propagation bitmask
+	keyFocus          IPanel             // current child panel with key focus
+	mouseFocus        IPanel             // current child panel with mouse focus
+	scrollFocus       IPanel             // current child panel with scroll focus
+	modalPanel        IPanel             // current modal panel
+	overlay           *overlay           // overlay layer for tooltips and popups (created on demand)
+	drag              *dragState         // pending or current drag and drop
+	focusVisible      bool               // key focus was moved by keyboard and is shown by the focus ring
+	scale             float32            // GUI scale factor of the panels
+	targets           []IPanel           // preallocated list of target panels
+	mouseEv           window.MouseEvent  // preallocated mouse event in GUI units
+	cursorEv          window.CursorEvent // preallocated cursor event in GUI units
 }
 
 // Types of event propagation stopping.
@@ -32,6 +38,9 @@ const (
 	StopAll = StopGUI | Stop3D // Stop event propagation
 )
 
+// roots contains the root panels which were not disposed
+var roots = map[*Root]bool{}
+
 // NewRoot creates and returns a pointer to a gui root panel for the specified window
 func NewRoot(gs *gls.GLS, win window.IWindow) *Root {
 
@@ -43,8 +52,14 @@ func NewRoot(gs *gls.GLS, win window.IWindow) *Root {
 	r.TimerManager.Initialize()
 
 	// Set the size of the root panel based on the window framebuffer size
-	width, height := win.FramebufferSize()
-	r.SetSize(float32(width), float32(height))
+	// and the GUI scale factor for the content scale of the window
+	// for windows which report it
+	if cs, ok := win.(window.IContentScaler); ok {
+		sx, _ := cs.ContentScale()
+		setContentScale(float32(sx))
+	}
+	r.scale = guiScale.value
+	r.FitWindow()
 
 	// For optimization, set this root panel as not renderable as in most cases
 	// it is used only as a container
@@ -53,9 +68,17 @@ func NewRoot(gs *gls.GLS, win window.IWindow) *Root {
 	// Subscribe to window events
 	r.SubscribeWin()
 	r.targets = []IPanel{}
+	roots[r] = true
 	return r
 }
 
+// Dispose releases the resources of this root panel
+func (r *Root) Dispose() {
+
+	delete(roots, r)
+	r.Panel.Dispose()
+}
+
 // SubscribeWin subscribes this root panel to window events
 func (r *Root) SubscribeWin() {
 
@@ -63,11 +86,15 @@ func (r *Root) SubscribeWin() {
 	r.win.Subscribe(window.OnKeyDown, r.onKey)
 	r.win.Subscribe(window.OnKeyRepeat, r.onKey)
 	r.win.Subscribe(window.OnChar, r.onChar)
+	r.win.Subscribe(window.OnCompositionStart, r.onChar)
+	r.win.Subscribe(window.OnCompositionUpdate, r.onChar)
+	r.win.Subscribe(window.OnCompositionCommit, r.onChar)
 	r.win.Subscribe(window.OnMouseUp, r.onMouse)
 	r.win.Subscribe(window.OnMouseDown, r.onMouse)
 	r.win.Subscribe(window.OnCursor, r.onCursor)
 	r.win.Subscribe(window.OnScroll, r.onScroll)
 	r.win.Subscribe(window.OnWindowSize, r.onWindowSize)
+	r.win.Subscribe(window.OnContentScale, r.onContentScale)
 	r.win.Subscribe(window.OnFrame, r.onFrame)
 }
 
@@ -99,18 +126,32 @@ func (r *Root) SetModal(ipan IPanel) {
 
 // SetKeyFocus sets the panel which will receive all keyboard events
 // Passing nil will remove the focus (if any)
+// Dispatches OnFocusLost to the previous focused panel and OnFocus to the new one.
 func (r *Root) SetKeyFocus(ipan IPanel) {
 
-	if r.keyFocus != nil {
+	prev := r.keyFocus
+	if prev != nil {
 		// If this panel is already in focus, nothing to do
 		if ipan != nil {
-			if r.keyFocus.GetPanel() == ipan.GetPanel() {
+			if prev.GetPanel() == ipan.GetPanel() {
 				return
 			}
 		}
-		r.keyFocus.LostKeyFocus()
+		prev.LostKeyFocus()
 	}
 	r.keyFocus = ipan
+	if prev != nil {
+		prev.GetPanel().Dispatch(OnFocusLost, nil)
+	}
+	if ipan != nil {
+		ipan.GetPanel().Dispatch(OnFocus, nil)
+	}
+}
+
+// KeyFocus returns the panel with the key focus or nil
+func (r *Root) KeyFocus() IPanel {
+
+	return r.keyFocus
 }
 
 // ClearKeyFocus clears the key focus panel (if any) without
@@ -218,24 +259,33 @@ func (r *Root) SetCursorDiagResize2() {
 // onKey is called when key events are received
 func (r *Root) onKey(evname string, ev interface{}) {
 
-	// If no panel has the key focus, nothing to do
-	if r.keyFocus == nil {
+	// Escape cancels the current drag
+	if r.drag != nil && r.drag.payload != nil {
+		if evname == OnKeyDown && ev.(*window.KeyEvent).Keycode == window.KeyEscape {
+			r.CancelDrag()
+		}
 		return
 	}
-	// Checks modal panel
-	if !r.canDispatch(r.keyFocus) {
+	// Escape closes the last open popup
+	if r.overlay != nil && evname == OnKeyDown && r.overlay.onKey(ev.(*window.KeyEvent)) {
 		return
 	}
-	// Dispatch window.KeyEvent to focused panel subscribers
 	r.stopPropagation = 0
-	r.keyFocus.GetPanel().Dispatch(evname, ev)
+	// Dispatch window.KeyEvent to focused panel subscribers
+	if r.keyFocus != nil && r.canDispatch(r.keyFocus) {
+		r.keyFocus.GetPanel().Dispatch(evname, ev)
+	}
+	// Tab moves the key focus unless the focused panel used it
+	if evname != OnKeyUp && (r.stopPropagation&StopGUI) == 0 {
+		r.focusKey(ev.(*window.KeyEvent))
+	}
 	// If requested, stop propagation of event outside the root gui
 	if (r.stopPropagation & Stop3D) != 0 {
 		r.win.CancelDispatch()
 	}
 }
 
-// onChar is called when char events are received
+// onChar is called when char and text composition events are received
 func (r *Root) onChar(evname string, ev interface{}) {
 
 	// If no panel has the key focus, nothing to do
@@ -246,7 +296,7 @@ func (r *Root) onChar(evname string, ev interface{}) {
 	if !r.canDispatch(r.keyFocus) {
 		return
 	}
-	// Dispatch window.CharEvent to focused panel subscribers
+	// Dispatch window.CharEvent or window.CompositionEvent to focused panel subscribers
 	r.stopPropagation = 0
 	r.keyFocus.GetPanel().Dispatch(evname, ev)
 	// If requested, stopj propagation of event outside the root gui
@@ -258,28 +308,40 @@ func (r *Root) onChar(evname string, ev interface{}) {
 // onMouse is called when mouse button events are received
 func (r *Root) onMouse(evname string, ev interface{}) {
 
-	mev := ev.(*window.MouseEvent)
-	r.sendPanels(mev.Xpos, mev.Ypos, evname, ev)
+	// Converts a copy of the event to GUI units, as other
+	// subscribers of the window receive the event in pixels
+	r.mouseEv = *ev.(*window.MouseEvent)
+	r.mouseEv.Xpos /= guiScale.value
+	r.mouseEv.Ypos /= guiScale.value
+	if evname == OnMouseDown {
+		r.focusVisible = false
+	}
+	r.sendPanels(r.mouseEv.Xpos, r.mouseEv.Ypos, evname, &r.mouseEv)
 }
 
 // onCursor is called when (mouse) cursor events are received
 func (r *Root) onCursor(evname string, ev interface{}) {
 
-	cev := ev.(*window.CursorEvent)
-	r.sendPanels(cev.Xpos, cev.Ypos, evname, ev)
+	r.cursorEv = *ev.(*window.CursorEvent)
+	r.cursorEv.Xpos /= guiScale.value
+	r.cursorEv.Ypos /= guiScale.value
+	r.sendPanels(r.cursorEv.Xpos, r.cursorEv.Ypos, evname, &r.cursorEv)
 }
 
 // sendPanel sends a mouse or cursor event to focused panel or panels
-// which contain the specified screen position
+// which contain the specified position in GUI units
 func (r *Root) sendPanels(x, y float32, evname string, ev interface{}) {
 
-	// Apply scale of window (for HiDPI support)
-	sX64, sY64 := r.Window().Scale()
-	x /= float32(sX64)
-	y /= float32(sY64)
+	// Dragged payloads take over the mouse
+	if r.drag != nil && r.dragEvent(x, y, evname, ev) {
+		return
+	}
 
 	// If there is panel with MouseFocus send only to this panel
 	if r.mouseFocus != nil {
+		if r.overlay != nil {
+			r.overlay.hideTooltip()
+		}
 		// Checks modal panel
 		if !r.canDispatch(r.mouseFocus) {
 			return
@@ -291,6 +353,11 @@ func (r *Root) sendPanels(x, y float32, evname string, ev interface{}) {
 		return
 	}
 
+	// Closes the popups outside of the mouse position
+	if r.overlay != nil && evname == OnMouseDown {
+		r.overlay.mouseDown(x, y)
+	}
+
 	// Clear list of panels which contains the mouse position
 	r.targets = r.targets[0:0]
 
@@ -338,6 +405,9 @@ func (r *Root) sendPanels(x, y float32, evname string, ev interface{}) {
 
 	// No panels found
 	if len(r.targets) == 0 {
+		if r.overlay != nil && evname == OnCursor {
+			r.overlay.hover(nil, x, y)
+		}
 		// If event is mouse click, removes the keyboard focus
 		if evname == OnMouseDown {
 			r.SetKeyFocus(nil)
@@ -378,6 +448,27 @@ func (r *Root) sendPanels(x, y float32, evname string, ev interface{}) {
 		}
 	}
 
+	// Shows the tooltip or context menu of the foremost panel
+	var target IPanel
+	for _, ipan := range r.targets {
+		if r.canDispatch(ipan) {
+			target = ipan
+			break
+		}
+	}
+	if evname == OnCursor {
+		r.overlayLayer().hover(target, x, y)
+	} else if mev, ok := ev.(*window.MouseEvent); ok && target != nil &&
+		evname == OnMouseDown && mev.Button == window.MouseButtonRight {
+		r.overlayLayer().contextMenu(target, x, y)
+	}
+
+	// Panels which captured the mouse are not dragged
+	if mev, ok := ev.(*window.MouseEvent); ok && target != nil && r.mouseFocus == nil &&
+		evname == OnMouseDown && mev.Button == window.MouseButtonLeft {
+		r.dragPending(target, x, y)
+	}
+
 	// Stops propagation of event outside the root gui
 	if (r.stopPropagation & Stop3D) != 0 {
 		r.win.CancelDispatch()
@@ -404,6 +495,8 @@ func (r *Root) onScroll(evname string, ev interface{}) {
 // onSize is called when window size events are received
 func (r *Root) onWindowSize(evname string, ev interface{}) {
 
+	r.FitWindow()
+
 	// Sends event only to immediate children
 	for _, ipan := range r.Children() {
 		ipan.(IPanel).GetPanel().Dispatch(evname, ev)
@@ -414,6 +507,12 @@ func (r *Root) onWindowSize(evname string, ev interface{}) {
 func (r *Root) onFrame(evname string, ev interface{}) {
 
 	r.TimerManager.ProcessTimers()
+	if r.scale != guiScale.value {
+		r.rescale()
+	}
+	if r.overlay != nil {
+		r.overlay.update()
+	}
 }
 
 // canDispatch returns if event can be dispatched to the specified panel
@@ -427,6 +526,10 @@ func (r *Root) canDispatch(ipan IPanel) bool {
 	if r.modalPanel == ipan {
 		return true
 	}
+	// Tooltips and popups are always above the modal panel
+	if r.overlay != nil && panelContains(r.overlay, ipan) {
+		return true
+	}
 
 	// Internal function to check panel children recursively
 	var checkChildren func(iparent IPanel) bool
@@ -0,0 +1,122 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gui
+
+import (
+	"github.com/sansebasko/engine/window"
+)
+
+// guiScale contains the scale factor of the GUI
+var guiScale = struct {
+	value   float32 // current scale factor
+	user    float32 // scale factor set by the user or 0 to use the content scale
+	content float32 // content scale of the window
+}{value: 1, content: 1}
+
+// SetScale sets the scale factor of the GUI, which is the number of framebuffer
+// pixels of a GUI unit. The positions and sizes of panels, the font sizes and the
+// mouse coordinates are in GUI units, so the GUI has the same physical size on all
+// displays and its text is rasterized at the resolution of the framebuffer.
+// The scale factor 0, which is the default, uses the content scale of the window,
+// which changes when it is moved to a monitor with another resolution.
+// Root panels are resized and their panels laid out again at the next frame.
+func SetScale(scale float32) {
+
+	guiScale.user = scale
+	updateScale()
+}
+
+// Scale returns the current scale factor of the GUI.
+func Scale() float32 {
+
+	return guiScale.value
+}
+
+// setContentScale sets the content scale of the window used as scale
+// factor of the GUI if it was not set by the user
+func setContentScale(scale float32) {
+
+	guiScale.content = scale
+	updateScale()
+}
+
+// updateScale updates the current scale factor of the GUI
+func updateScale() {
+
+	scale := guiScale.user
+	if scale <= 0 {
+		scale = guiScale.content
+	}
+	if scale <= 0 {
+		scale = 1
+	}
+	guiScale.value = scale
+}
+
+// FitWindow sets the size of the root panel to the size in GUI units
+// of the framebuffer of its window.
+func (r *Root) FitWindow() {
+
+	width, height := r.win.FramebufferSize()
+	r.SetSize(float32(width)/guiScale.value, float32(height)/guiScale.value)
+}
+
+// onContentScale is called when the content scale of the window changes
+func (r *Root) onContentScale(evname string, ev interface{}) {
+
+	sev := ev.(*window.ScaleEvent)
+	setContentScale(float32(sev.X))
+	if r.scale != guiScale.value {
+		r.rescale()
+	}
+}
+
+// rescale fits the root panel to the window at the current scale factor,
+// dispatches OnScale to all its panels, which rasterize their text again,
+// and recalculates their layouts
+func (r *Root) rescale() {
+
+	r.scale = guiScale.value
+	r.FitWindow()
+	dispatchScale(r)
+	reflow(r)
+}
+
+// dispatchScale dispatches OnScale to the specified panel
+// after dispatching it to its descendants
+func dispatchScale(ipan IPanel) {
+
+	p := ipan.GetPanel()
+	for _, child := range p.Children() {
+		if ichild, ok := child.(IPanel); ok {
+			dispatchScale(ichild)
+		}
+	}
+	p.Dispatch(OnScale, nil)
+}
+
+// rescaleAdded dispatches OnScale to the specified panel added to a root and
+// its descendants if the text of any of them was laid out at another scale factor
+func rescaleAdded(ipan IPanel) {
+
+	if scaleChanged(ipan) {
+		dispatchScale(ipan)
+	}
+}
+
+// scaleChanged returns if the text of the specified panel or any of
+// its descendants was laid out at another scale factor
+func scaleChanged(ipan IPanel) bool {
+
+	if t, ok := ipan.(interface{ TextScale() float32 }); ok && t.TextScale() != guiScale.value {
+		return true
+	}
+	for _, child := range ipan.GetPanel().Children() {
+		if ichild, ok := child.(IPanel); ok && scaleChanged(ichild) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,59 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gui
+
+import (
+	"testing"
+
+	"github.com/sansebasko/engine/window"
+)
+
+// Tests that the panels receive the positions of mouse and cursor events in
+// GUI units at the scale factor 2, while the window events stay in pixels
+func TestScaleEvents(t *testing.T) {
+
+	r := newTestRoot()
+	ed := NewEdit(100, "")
+	ed.SetPosition(10, 10)
+	ed.SetText("abcdefghij")
+	r.Add(ed)
+	s := NewHSlider(100, 20)
+	s.SetPosition(10, 50)
+	r.Add(s)
+	SetScale(2)
+	defer SetScale(0)
+	r.onFrame(window.OnFrame, nil)
+	r.UpdateMatrixWorld()
+	if w, h := r.Size(); w != 200 || h != 150 {
+		t.Fatalf("root size %vx%v, expected 200x150", w, h)
+	}
+
+	// Clicks the edit at the caret position of each column
+	for _, col := range []int{0, 3, 7, 10} {
+		x, _ := ed.Label.layout.Caret(col)
+		px := (ed.pospix.X + editMarginX + float32(x)/ed.Label.scale) * 2
+		mev := &window.MouseEvent{Xpos: px, Ypos: (ed.pospix.Y + 5) * 2, Button: window.MouseButtonLeft}
+		r.onMouse(OnMouseDown, mev)
+		r.onMouse(OnMouseUp, mev)
+		if ed.buf.pos != col {
+			t.Errorf("click at %v px moved the caret to column %d, expected %d", px, ed.buf.pos, col)
+		}
+		if mev.Xpos != px {
+			t.Errorf("window event position changed to %v, expected %v", mev.Xpos, px)
+		}
+	}
+
+	// Drags the slider by a quarter of its content width
+	s.SetValue(0)
+	x := s.pospix.X + 1
+	y := s.pospix.Y + 5
+	dx := s.ContentWidth() / 4
+	r.onMouse(OnMouseDown, &window.MouseEvent{Xpos: x * 2, Ypos: y * 2, Button: window.MouseButtonLeft})
+	r.onCursor(OnCursor, &window.CursorEvent{Xpos: (x + dx) * 2, Ypos: y * 2})
+	r.onMouse(OnMouseUp, &window.MouseEvent{Xpos: (x + dx) * 2, Ypos: y * 2, Button: window.MouseButtonLeft})
+	if v := s.Value(); v < 0.24 || v > 0.26 {
+		t.Errorf("slider value %v after the drag, expected 0.25", v)
+	}
+}
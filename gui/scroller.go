@@ -25,6 +25,7 @@ type Scroller struct {
 	corner        *Panel         // The optional corner panel (can be visible when scrollMode==Both, interlocking==None, corner=true)
 	cursorOver    bool           // Cursor is over the scroller
 	modKeyPressed bool           // Modifier key is pressed
+	hoverKeyFocus bool           // Takes the key focus while the cursor is over the scroller
 }
 
 // ScrollMode specifies which scroll directions are allowed.
@@ -103,6 +104,7 @@ func (s *Scroller) initialize(width, height float32, mode ScrollMode, target IPa
 	s.target = target
 	s.Panel.Add(s.target)
 	s.mode = mode
+	s.hoverKeyFocus = true
 
 	s.Subscribe(OnCursorEnter, s.onCursor)
 	s.Subscribe(OnCursorLeave, s.onCursor)
@@ -275,7 +277,71 @@ func (s *Scroller) HorizontalScrollbarBroadness() float32 {
 
 // ScrollTo scrolls the target panel such that the specified target point is centered on the scroller's view area
 func (s *Scroller) ScrollTo(x, y float32) {
-	// TODO
+
+	viewWidth, viewHeight := s.viewSize()
+	s.setScrollOffset(x-viewWidth/2, y-viewHeight/2)
+}
+
+// scrollIntoView scrolls the target panel the minimum necessary for the specified
+// rectangle of the target panel to be inside the scroller's view area
+func (s *Scroller) scrollIntoView(x0, y0, x1, y1 float32) {
+
+	viewWidth, viewHeight := s.viewSize()
+	offsetX, offsetY := s.scrollOffset()
+	if x1 > offsetX+viewWidth {
+		offsetX = x1 - viewWidth
+	}
+	if x0 < offsetX {
+		offsetX = x0
+	}
+	if y1 > offsetY+viewHeight {
+		offsetY = y1 - viewHeight
+	}
+	if y0 < offsetY {
+		offsetY = y0
+	}
+	s.setScrollOffset(offsetX, offsetY)
+}
+
+// viewSize returns the size of the area of the scroller where the target panel is visible
+func (s *Scroller) viewSize() (width, height float32) {
+
+	width = s.ContentWidth()
+	height = s.ContentHeight()
+	if (s.vscroll != nil) && s.vscroll.Visible() && !s.style.VerticalScrollbar.OverlapContent {
+		width -= s.vscroll.width
+	}
+	if (s.hscroll != nil) && s.hscroll.Visible() && !s.style.HorizontalScrollbar.OverlapContent {
+		height -= s.hscroll.height
+	}
+	return width, height
+}
+
+// scrollOffset returns the position of the target panel point shown at the top left of the view area
+func (s *Scroller) scrollOffset() (x, y float32) {
+
+	viewWidth, viewHeight := s.viewSize()
+	if (s.hscroll != nil) && s.hscroll.Visible() {
+		x = float32(s.hscroll.Value()) * (s.target.TotalWidth() - viewWidth)
+	}
+	if (s.vscroll != nil) && s.vscroll.Visible() {
+		y = float32(s.vscroll.Value()) * (s.target.TotalHeight() - viewHeight)
+	}
+	return x, y
+}
+
+// setScrollOffset scrolls the target panel such that the specified target point is shown
+// at the top left of the view area, as far as the scrollbars allow it
+func (s *Scroller) setScrollOffset(x, y float32) {
+
+	viewWidth, viewHeight := s.viewSize()
+	if (s.hscroll != nil) && s.hscroll.Visible() && s.target.TotalWidth() > viewWidth {
+		s.hscroll.SetValue(x / (s.target.TotalWidth() - viewWidth))
+	}
+	if (s.vscroll != nil) && s.vscroll.Visible() && s.target.TotalHeight() > viewHeight {
+		s.vscroll.SetValue(y / (s.target.TotalHeight() - viewHeight))
+	}
+	s.recalc()
 }
 
 // onCursor receives subscribed cursor events over the panel
@@ -284,11 +350,15 @@ func (s *Scroller) onCursor(evname string, ev interface{}) {
 	switch evname {
 	case OnCursorEnter:
 		s.root.SetScrollFocus(s)
-		s.root.SetKeyFocus(s)
+		if s.hoverKeyFocus {
+			s.root.SetKeyFocus(s)
+		}
 		s.cursorOver = true
 	case OnCursorLeave:
 		s.root.SetScrollFocus(nil)
-		s.root.SetKeyFocus(nil)
+		if s.hoverKeyFocus {
+			s.root.SetKeyFocus(nil)
+		}
 		s.cursorOver = false
 	}
 	s.root.StopPropagation(Stop3D)
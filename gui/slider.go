@@ -82,6 +82,9 @@ func newSlider(horiz bool, width, height float32) *Slider {
 	s.Panel.Subscribe(OnKeyRepeat, s.onKey)
 	s.Panel.Subscribe(OnResize, s.onResize)
 	s.Panel.Subscribe(OnEnable, func(evname string, ev interface{}) { s.update() })
+	s.Panel.Subscribe(OnFocus, func(evname string, ev interface{}) { s.update() })
+	s.Panel.Subscribe(OnFocusLost, func(evname string, ev interface{}) { s.update() })
+	s.Panel.SetFocusable(true)
 
 	// Initialize slider panel
 	s.slider.Initialize(0, 0)
@@ -247,6 +250,10 @@ func (s *Slider) onKey(evname string, ev interface{}) {
 			s.setPos(s.pos - delta)
 		case window.KeyRight:
 			s.setPos(s.pos + delta)
+		case window.KeyHome:
+			s.setPos(0)
+		case window.KeyEnd:
+			s.setPos(1)
 		default:
 			return
 		}
@@ -257,6 +264,10 @@ func (s *Slider) onKey(evname string, ev interface{}) {
 			s.setPos(s.pos - delta)
 		case window.KeyUp:
 			s.setPos(s.pos + delta)
+		case window.KeyHome:
+			s.setPos(0)
+		case window.KeyEnd:
+			s.setPos(1)
 		default:
 			return
 		}
@@ -281,6 +292,10 @@ func (s *Slider) update() {
 		s.applyStyle(&s.styles.Over)
 		return
 	}
+	if s.root != nil && s.root.HasKeyFocus(s) {
+		s.applyStyle(&s.styles.Focus)
+		return
+	}
 	s.applyStyle(&s.styles.Normal)
 }
 
@@ -0,0 +1,403 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gui
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/sansebasko/engine/gui/assets/icon"
+	"github.com/sansebasko/engine/window"
+)
+
+/***************************************
+
+ Spinner
+ +------------------------------+---+
+ |                              | ^ |
+ | Edit                         |---|
+ |                              | v |
+ +------------------------------+---+
+
+**/
+
+// Spinner is the GUI element for editing numeric values.
+// The value can be typed in its edit, stepped by clicking its arrows,
+// changed by dragging the arrows vertically, by the mouse wheel and by the up and down keys.
+// It dispatches OnChange when its value changes.
+type Spinner struct {
+	Panel                     // Embedded panel
+	edit       *Edit          // edit showing the value
+	arrows     Panel          // panel with the up and down arrows
+	up         *Label         // up arrow icon
+	down       *Label         // down arrow icon
+	styles     *SpinnerStyles // pointer to styles
+	value      float32        // current value
+	min        float32        // minimum value
+	max        float32        // maximum value
+	step       float32        // value step
+	format     string         // format of the value text
+	pressed    bool           // mouse button is pressed over the arrows
+	pressUp    bool           // mouse button was pressed over the up arrow
+	dragged    bool           // value changed by dragging the arrows
+	dragDist   float32        // distance dragged since the last step
+	posLast    float32        // last vertical position of the mouse cursor when dragging
+	cursorOver bool           // mouse is over the arrows
+	setting    bool           // edit text being set by the spinner
+}
+
+// SpinnerStyle contains the styling of a Spinner
+type SpinnerStyle BasicStyle
+
+// SpinnerStyles contains a SpinnerStyle for each valid GUI state
+type SpinnerStyles struct {
+	Normal   SpinnerStyle
+	Over     SpinnerStyle
+	Pressed  SpinnerStyle
+	Disabled SpinnerStyle
+}
+
+// spinnerDragStep is the number of pixels the arrows are dragged for each value step
+const spinnerDragStep = 4
+
+// NewSpinner creates and returns a pointer to a new spinner with the specified
+// width, value range and step. The initial value is the nearest valid value to 0.
+func NewSpinner(width, min, max, step float32) *Spinner {
+
+	s := new(Spinner)
+	s.styles = &StyleDefault().Spinner
+	s.min = min
+	s.max = max
+	s.step = step
+	s.format = "%g"
+
+	s.Panel.Initialize(width, 0)
+	s.Panel.Subscribe(OnResize, func(evname string, ev interface{}) { s.recalc() })
+	s.Panel.Subscribe(OnEnable, func(evname string, ev interface{}) {
+		s.edit.SetEnabled(s.Enabled())
+		s.update()
+	})
+
+	// Initialize edit
+	s.edit = NewEdit(0, "")
+	s.edit.Subscribe(OnChange, s.onEdit)
+	s.edit.Subscribe(OnKeyDown, s.onKey)
+	s.edit.Subscribe(OnKeyRepeat, s.onKey)
+	s.edit.Subscribe(OnMouseOut, func(evname string, ev interface{}) { s.setText() })
+	s.Panel.Add(s.edit)
+
+	// Initialize arrows panel
+	s.arrows.Initialize(0, 0)
+	s.arrows.Subscribe(OnMouseDown, s.onMouse)
+	s.arrows.Subscribe(OnMouseUp, s.onMouse)
+	s.arrows.Subscribe(OnCursor, s.onCursor)
+	s.arrows.Subscribe(OnCursorEnter, s.onCursor)
+	s.arrows.Subscribe(OnCursorLeave, s.onCursor)
+	s.arrows.Subscribe(OnScroll, s.onScroll)
+	s.up = NewIcon(icon.ArrowDropUp)
+	s.down = NewIcon(icon.ArrowDropDown)
+	s.arrows.Add(s.up)
+	s.arrows.Add(s.down)
+	s.Panel.Add(&s.arrows)
+
+	s.value = s.clamp(0)
+	s.setText()
+	s.recalc()
+	s.update()
+	return s
+}
+
+// SetStyles set the spinner styles overriding the default style
+func (s *Spinner) SetStyles(ss *SpinnerStyles) *Spinner {
+
+	s.styles = ss
+	s.update()
+	return s
+}
+
+// SetValue sets the value of the spinner limited to its range
+// and dispatches OnChange if the value changed.
+func (s *Spinner) SetValue(value float32) *Spinner {
+
+	s.setValue(value)
+	s.setText()
+	return s
+}
+
+// Value returns the current value of the spinner
+func (s *Spinner) Value() float32 {
+
+	return s.value
+}
+
+// SetRange sets the minimum and maximum values of the spinner
+func (s *Spinner) SetRange(min, max float32) *Spinner {
+
+	s.min = min
+	s.max = max
+	s.SetValue(s.value)
+	return s
+}
+
+// Range returns the minimum and maximum values of the spinner
+func (s *Spinner) Range() (float32, float32) {
+
+	return s.min, s.max
+}
+
+// SetStep sets the value step of the arrows, keys, wheel and dragging.
+// Values set by them are rounded to multiples of the step from the minimum value.
+func (s *Spinner) SetStep(step float32) *Spinner {
+
+	s.step = step
+	return s
+}
+
+// Step returns the value step of the spinner
+func (s *Spinner) Step() float32 {
+
+	return s.step
+}
+
+// SetFormat sets the fmt format of the value text (default = "%g")
+func (s *Spinner) SetFormat(format string) *Spinner {
+
+	s.format = format
+	s.setText()
+	return s
+}
+
+// Format returns the format of the value text
+func (s *Spinner) Format() string {
+
+	return s.format
+}
+
+// Edit returns the edit of the spinner
+func (s *Spinner) Edit() *Edit {
+
+	return s.edit
+}
+
+// clamp returns the specified value limited to the range of the spinner
+func (s *Spinner) clamp(value float32) float32 {
+
+	if value < s.min {
+		return s.min
+	}
+	if value > s.max {
+		return s.max
+	}
+	return value
+}
+
+// setValue sets the value without changing the edit text
+func (s *Spinner) setValue(value float32) {
+
+	value = s.clamp(value)
+	if value == s.value {
+		return
+	}
+	s.value = value
+	s.Dispatch(OnChange, nil)
+}
+
+// stepValue changes the value by the specified number of steps
+func (s *Spinner) stepValue(steps int) {
+
+	if s.step <= 0 {
+		return
+	}
+	// Rounds to a multiple of the step to avoid accumulating errors
+	n := math.Round(float64((s.value - s.min) / s.step))
+	s.SetValue(s.min + float32(n+float64(steps))*s.step)
+}
+
+// setText sets the edit text from the current value
+func (s *Spinner) setText() {
+
+	text := fmt.Sprintf(s.format, s.value)
+	if text == s.edit.Text() {
+		return
+	}
+	s.setting = true
+	s.edit.SetText(text)
+	s.setting = false
+}
+
+// onEdit process the changes of the edit text typed by the user
+func (s *Spinner) onEdit(evname string, ev interface{}) {
+
+	if s.setting {
+		return
+	}
+	// Incomplete or invalid values are kept until corrected or the edit loses the focus
+	v, err := strconv.ParseFloat(strings.TrimSpace(s.edit.Text()), 32)
+	if err != nil {
+		return
+	}
+	s.setValue(float32(v))
+}
+
+// onKey process subscribed key events
+func (s *Spinner) onKey(evname string, ev interface{}) {
+
+	kev := ev.(*window.KeyEvent)
+	switch kev.Keycode {
+	case window.KeyUp:
+		s.stepValue(1)
+	case window.KeyDown:
+		s.stepValue(-1)
+	case window.KeyPageUp:
+		s.stepValue(10)
+	case window.KeyPageDown:
+		s.stepValue(-10)
+	case window.KeyEnter, window.KeyKPEnter:
+		s.setText()
+	default:
+		return
+	}
+	s.root.StopPropagation(Stop3D)
+}
+
+// onMouse process subscribed mouse events over the arrows
+func (s *Spinner) onMouse(evname string, ev interface{}) {
+
+	mev := ev.(*window.MouseEvent)
+	if mev.Button != window.MouseButtonLeft {
+		return
+	}
+	switch evname {
+	case OnMouseDown:
+		s.pressed = true
+		s.pressUp = mev.Ypos < s.arrows.pospix.Y+s.arrows.height/2
+		s.posLast = mev.Ypos
+		s.dragged = false
+		s.dragDist = 0
+		s.root.SetMouseFocus(&s.arrows)
+		s.edit.setFocus()
+		s.setText()
+	case OnMouseUp:
+		// Steps the value if the mouse was not dragged
+		if s.pressed && !s.dragged {
+			if s.pressUp {
+				s.stepValue(1)
+			} else {
+				s.stepValue(-1)
+			}
+		}
+		s.pressed = false
+		if !s.cursorOver {
+			s.root.SetCursorNormal()
+		}
+		s.root.SetMouseFocus(nil)
+	default:
+		return
+	}
+	s.update()
+	s.root.StopPropagation(Stop3D)
+}
+
+// onCursor process subscribed cursor events over the arrows
+func (s *Spinner) onCursor(evname string, ev interface{}) {
+
+	switch evname {
+	case OnCursorEnter:
+		s.root.SetScrollFocus(&s.arrows)
+		s.cursorOver = true
+		s.update()
+	case OnCursorLeave:
+		s.root.SetScrollFocus(nil)
+		s.cursorOver = false
+		s.update()
+	case OnCursor:
+		if !s.pressed {
+			return
+		}
+		// Dragging up increases the value
+		cev := ev.(*window.CursorEvent)
+		s.root.SetCursorVResize()
+		s.dragDist += s.posLast - cev.Ypos
+		s.posLast = cev.Ypos
+		steps := int(s.dragDist / spinnerDragStep)
+		if steps != 0 {
+			s.dragged = true
+			s.dragDist -= float32(steps) * spinnerDragStep
+			s.stepValue(steps)
+		}
+	}
+	s.root.StopPropagation(Stop3D)
+}
+
+// onScroll process subscribed scroll events over the arrows
+func (s *Spinner) onScroll(evname string, ev interface{}) {
+
+	sev := ev.(*window.ScrollEvent)
+	if sev.Yoffset > 0 {
+		s.stepValue(1)
+	} else if sev.Yoffset < 0 {
+		s.stepValue(-1)
+	}
+	s.root.StopPropagation(Stop3D)
+}
+
+// update updates the spinner visual state
+func (s *Spinner) update() {
+
+	if !s.Enabled() {
+		s.applyStyle(&s.styles.Disabled)
+		return
+	}
+	if s.pressed {
+		s.applyStyle(&s.styles.Pressed)
+		return
+	}
+	if s.cursorOver {
+		s.applyStyle(&s.styles.Over)
+		return
+	}
+	s.applyStyle(&s.styles.Normal)
+}
+
+// applyStyle applies the specified spinner style to the arrows
+func (s *Spinner) applyStyle(ss *SpinnerStyle) {
+
+	s.arrows.ApplyStyle(&ss.PanelStyle)
+	s.up.SetColor4(&ss.FgColor)
+	s.down.SetColor4(&ss.FgColor)
+	s.recalc()
+}
+
+// recalc recalculates the dimensions and positions of the internal panels.
+func (s *Spinner) recalc() {
+
+	// The spinner height is the height of its edit
+	height := s.edit.Height()
+	if s.ContentHeight() != height {
+		s.SetContentHeight(height)
+	}
+	aw := float32(math.Round(float64(height) * 0.8))
+	ew := s.ContentWidth() - aw
+	if ew < 0 {
+		ew = 0
+	}
+	s.edit.setWidth(ew)
+	s.edit.SetPosition(0, 0)
+
+	// The arrow icons are centered in the top and bottom halves of the arrows panel
+	s.arrows.SetPosition(ew, 0)
+	s.arrows.SetSize(aw, height)
+	fsize := float64(s.arrows.ContentHeight())
+	if s.up.FontSize() != fsize {
+		s.up.SetFontSize(fsize)
+		s.down.SetFontSize(fsize)
+	}
+	cw := s.arrows.ContentWidth()
+	ch := s.arrows.ContentHeight()
+	s.up.SetPosition((cw-s.up.Width())/2, ch/4-s.up.Height()/2)
+	s.down.SetPosition((cw-s.down.Width())/2, 3*ch/4-s.down.Height()/2)
+}
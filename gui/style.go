@@ -19,6 +19,7 @@ type Style struct {
 	ToggleButton  ButtonStyles
 	CheckRadio    CheckRadioStyles
 	Edit          EditStyles
+	TextArea      EditStyles
 	ScrollBar     ScrollBarStyles
 	Slider        SliderStyles
 	Splitter      SplitterStyles
@@ -34,6 +35,14 @@ type Style struct {
 	Table         TableStyles
 	ImageButton   ImageButtonStyles
 	TabBar        TabBarStyles
+	Tooltip       TooltipStyle
+	FocusRing     FocusRingStyle
+	Drag          DragStyles
+	Spinner       SpinnerStyles
+	ProgressBar   ProgressBarStyles
+	ColorPicker   ColorPickerStyle
+	MessageBox    MessageBoxStyle
+	FileDialog    FileDialogStyle
 }
 
 // ColorStyle defines the main colors used.
@@ -125,6 +125,7 @@ func NewDarkStyle() *Style {
 		BgAlpha:     1.0,
 		FgColor:     s.Color.Text,
 		HolderColor: math32.Color4{0.4, 0.4, 0.4, 1},
+		SelColor:    s.Color.Highlight,
 	}
 	s.Edit.Over = s.Edit.Normal
 	s.Edit.Over.BgColor = s.Color.BgNormal
@@ -132,6 +133,9 @@ func NewDarkStyle() *Style {
 	s.Edit.Disabled = s.Edit.Normal
 	s.Edit.Disabled.FgColor = s.Color.TextDis
 
+	// TextArea styles
+	s.TextArea = s.Edit
+
 	// ScrollBar styles
 	s.ScrollBar = ScrollBarStyles{}
 	s.ScrollBar.Normal = ScrollBarStyle{}
@@ -351,6 +355,8 @@ func NewDarkStyle() *Style {
 	s.Table.RowOdd.BgColor = s.Color.BgMed
 	s.Table.RowSel = s.Table.RowEven
 	s.Table.RowSel.BgColor = s.Color.Select
+	s.Table.Cursor = s.Table.RowSel
+	s.Table.Cursor.BgColor = s.Color.Highlight
 	s.Table.Status = TableStatusStyle{}
 	s.Table.Status.Border = RectBounds{1, 0, 0, 0}
 	s.Table.Status.Padding = twoBounds
@@ -417,5 +423,80 @@ func NewDarkStyle() *Style {
 	s.TabBar.Tab.SelectionAdvance.Thickness = float32(3)
 	s.TabBar.Tab.SelectionAdvance.Color = borderColor
 
+	// Focus ring style
+	s.FocusRing = FocusRingStyle{}
+	s.FocusRing.Border = twoBounds
+	s.FocusRing.BorderColor = s.Color.Highlight
+	s.FocusRing.Offset = 1
+
+	// Tooltip style
+	s.Tooltip = TooltipStyle{}
+	s.Tooltip.Border = oneBounds
+	s.Tooltip.Padding = RectBounds{2, 4, 2, 4}
+	s.Tooltip.BorderColor = borderColor
+	s.Tooltip.BgColor = s.Color.BgOver
+	s.Tooltip.FgColor = s.Color.Text
+
+	// Drag style
+	s.Drag = DragStyles{}
+	s.Drag.Normal.Border = oneBounds
+	s.Drag.Normal.Padding = RectBounds{2, 4, 2, 4}
+	s.Drag.Normal.BorderColor = borderColor
+	s.Drag.Normal.BgColor = s.Color.BgOver
+	s.Drag.Normal.FgColor = s.Color.Text
+	s.Drag.Accept = s.Drag.Normal
+	s.Drag.Accept.BorderColor = s.Color.Highlight
+	s.Drag.Reject = s.Drag.Normal
+	s.Drag.Reject.FgColor = s.Color.TextDis
+	s.Drag.Indicator = s.Color.Highlight
+
+	// Spinner styles
+	s.Spinner = SpinnerStyles{}
+	s.Spinner.Normal.Border = RectBounds{1, 1, 1, 0}
+	s.Spinner.Normal.BorderColor = borderColor
+	s.Spinner.Normal.BgColor = s.Color.BgMed
+	s.Spinner.Normal.FgColor = s.Color.Text
+	s.Spinner.Over = s.Spinner.Normal
+	s.Spinner.Over.BgColor = s.Color.BgOver
+	s.Spinner.Pressed = s.Spinner.Over
+	s.Spinner.Pressed.FgColor = s.Color.Highlight
+	s.Spinner.Disabled = s.Spinner.Normal
+	s.Spinner.Disabled.FgColor = s.Color.TextDis
+
+	// ProgressBar styles
+	s.ProgressBar = ProgressBarStyles{}
+	s.ProgressBar.Normal.Border = oneBounds
+	s.ProgressBar.Normal.BorderColor = borderColor
+	s.ProgressBar.Normal.BgColor = s.Color.BgDark
+	s.ProgressBar.Normal.FgColor = s.Color.Highlight
+	s.ProgressBar.Disabled = s.ProgressBar.Normal
+	s.ProgressBar.Disabled.FgColor = s.Color.TextDis
+
+	// ColorPicker style
+	s.ColorPicker = ColorPickerStyle{}
+	s.ColorPicker.Padding = RectBounds{4, 4, 4, 4}
+	s.ColorPicker.BgColor = s.Color.BgNormal
+	s.ColorPicker.MarkerColor = s.Color.Text
+	s.ColorPicker.Spacing = 4
+	s.ColorPicker.BarWidth = 16
+
+	// MessageBox style
+	s.MessageBox = MessageBoxStyle{}
+	s.MessageBox.Spacing = 8
+	s.MessageBox.MinWidth = 240
+	s.MessageBox.ButtonWidth = 64
+	s.MessageBox.IconSize = 32
+	s.MessageBox.InfoColor = s.Color.Highlight
+	s.MessageBox.WarningColor = math32.Color4Name("Gold")
+	s.MessageBox.ErrorColor = math32.Color4Name("OrangeRed")
+	s.MessageBox.QuestionColor = s.Color.Highlight
+
+	// FileDialog style
+	s.FileDialog = FileDialogStyle{}
+	s.FileDialog.Spacing = 6
+	s.FileDialog.ButtonWidth = 64
+	s.FileDialog.FilterWidth = 160
+	s.FileDialog.FolderColor = math32.Color4Name("Gold")
+	s.FileDialog.FileColor = s.Color.Text
 	return s
 }
@@ -119,6 +119,7 @@ func NewLightStyle() *Style {
 		BgAlpha:     1.0,
 		FgColor:     fgColor,
 		HolderColor: math32.Color4{0.4, 0.4, 0.4, 1},
+		SelColor:    bgColor4Sel,
 	}
 	s.Edit.Over = s.Edit.Normal
 	s.Edit.Over.BgColor = bgColorOver
@@ -126,6 +127,9 @@ func NewLightStyle() *Style {
 	s.Edit.Disabled = s.Edit.Normal
 	s.Edit.Disabled.FgColor = fgColorDis
 
+	// TextArea styles
+	s.TextArea = s.Edit
+
 	// ScrollBar styles
 	s.ScrollBar = ScrollBarStyles{}
 	s.ScrollBar.Normal = ScrollBarStyle{}
@@ -345,6 +349,8 @@ func NewLightStyle() *Style {
 	s.Table.RowOdd.BgColor = math32.Color4{0.88, 0.88, 0.88, 1}
 	s.Table.RowSel = s.Table.RowEven
 	s.Table.RowSel.BgColor = math32.Color4{0.70, 0.70, 0.70, 1}
+	s.Table.Cursor = s.Table.RowSel
+	s.Table.Cursor.BgColor = math32.Color4{0.60, 0.70, 0.85, 1}
 	s.Table.Status = TableStatusStyle{}
 	s.Table.Status.Border = RectBounds{1, 0, 0, 0}
 	s.Table.Status.Padding = twoBounds
@@ -413,5 +419,82 @@ func NewLightStyle() *Style {
 	s.TabBar.Tab.SelectionAdvance.Thickness = float32(3)
 	s.TabBar.Tab.SelectionAdvance.Color = borderColor
 
+	// Focus ring style
+	s.FocusRing = FocusRingStyle{}
+	s.FocusRing.Border = twoBounds
+	s.FocusRing.BorderColor = math32.Color4Name("RoyalBlue")
+	s.FocusRing.Offset = 1
+
+	// Tooltip style
+	s.Tooltip = TooltipStyle{}
+	s.Tooltip.Border = oneBounds
+	s.Tooltip.Padding = RectBounds{2, 4, 2, 4}
+	s.Tooltip.BorderColor = borderColor
+	s.Tooltip.BgColor = math32.Color4{1, 1, 0.88, 1}
+	s.Tooltip.FgColor = math32.Color4{0, 0, 0, 1}
+
+	// Drag style
+	s.Drag = DragStyles{}
+	s.Drag.Normal.Border = oneBounds
+	s.Drag.Normal.Padding = RectBounds{2, 4, 2, 4}
+	s.Drag.Normal.BorderColor = borderColor
+	s.Drag.Normal.BgColor = bgColorOver
+	s.Drag.Normal.FgColor = fgColor
+	s.Drag.Accept = s.Drag.Normal
+	s.Drag.Accept.BorderColor = math32.Color4Name("RoyalBlue")
+	s.Drag.Reject = s.Drag.Normal
+	s.Drag.Reject.FgColor = fgColorDis
+	s.Drag.Indicator = math32.Color4Name("RoyalBlue")
+
+	// Spinner styles
+	s.Spinner = SpinnerStyles{}
+	s.Spinner.Normal.Border = RectBounds{1, 1, 1, 0}
+	s.Spinner.Normal.BorderColor = borderColor
+	s.Spinner.Normal.BgColor = bgColor
+	s.Spinner.Normal.FgColor = fgColor
+	s.Spinner.Over = s.Spinner.Normal
+	s.Spinner.Over.BgColor = bgColorOver
+	s.Spinner.Pressed = s.Spinner.Over
+	s.Spinner.Pressed.BgColor = bgColor4Sel
+	s.Spinner.Disabled = s.Spinner.Normal
+	s.Spinner.Disabled.BorderColor = borderColorDis
+	s.Spinner.Disabled.FgColor = fgColorDis
+
+	// ProgressBar styles
+	s.ProgressBar = ProgressBarStyles{}
+	s.ProgressBar.Normal.Border = oneBounds
+	s.ProgressBar.Normal.BorderColor = borderColor
+	s.ProgressBar.Normal.BgColor = math32.Color4{0.8, 0.8, 0.8, 1}
+	s.ProgressBar.Normal.FgColor = math32.Color4{0, 0.8, 0, 1}
+	s.ProgressBar.Disabled = s.ProgressBar.Normal
+	s.ProgressBar.Disabled.BorderColor = borderColorDis
+	s.ProgressBar.Disabled.FgColor = fgColorDis
+
+	// ColorPicker style
+	s.ColorPicker = ColorPickerStyle{}
+	s.ColorPicker.Padding = RectBounds{4, 4, 4, 4}
+	s.ColorPicker.BgColor = bgColor
+	s.ColorPicker.MarkerColor = fgColor
+	s.ColorPicker.Spacing = 4
+	s.ColorPicker.BarWidth = 16
+
+	// MessageBox style
+	s.MessageBox = MessageBoxStyle{}
+	s.MessageBox.Spacing = 8
+	s.MessageBox.MinWidth = 240
+	s.MessageBox.ButtonWidth = 64
+	s.MessageBox.IconSize = 32
+	s.MessageBox.InfoColor = math32.Color4Name("RoyalBlue")
+	s.MessageBox.WarningColor = math32.Color4Name("DarkOrange")
+	s.MessageBox.ErrorColor = math32.Color4Name("Red")
+	s.MessageBox.QuestionColor = math32.Color4Name("RoyalBlue")
+
+	// FileDialog style
+	s.FileDialog = FileDialogStyle{}
+	s.FileDialog.Spacing = 6
+	s.FileDialog.ButtonWidth = 64
+	s.FileDialog.FilterWidth = 160
+	s.FileDialog.FolderColor = math32.Color4Name("Goldenrod")
+	s.FileDialog.FileColor = fgColor
 	return s
 }
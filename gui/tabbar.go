@@ -25,6 +25,8 @@ type TabBar struct {
 	labelAlign               Align        // Label align of all tabs (one of AlignCenter, AlignLeft, AlignRight)
 	tabHeaderAlign           Align        // Tab header align (one of AlignTop, AlignBottom)
 	consistentTabHeaderWidth bool         // Consistent tab header width (true) or only as width as needed (false)
+	reorder                  bool         // Tabs can be reordered by dragging their headers
+	dragSub                  bool         // Subscribed to drag and drop events
 }
 
 // TabBarStyle describes the style of the TabBar
@@ -99,7 +101,12 @@ func NewTabBar(width, height float32) *TabBar {
 	tb.Subscribe(OnCursorEnter, tb.onCursor)
 	tb.Subscribe(OnCursorLeave, tb.onCursor)
 	tb.Subscribe(OnEnable, func(name string, ev interface{}) { tb.update() })
+	tb.Subscribe(OnFocus, func(name string, ev interface{}) { tb.update() })
+	tb.Subscribe(OnFocusLost, func(name string, ev interface{}) { tb.update() })
+	tb.Subscribe(OnKeyDown, tb.onKey)
+	tb.Subscribe(OnKeyRepeat, tb.onKey)
 	tb.Subscribe(OnResize, func(name string, ev interface{}) { tb.recalc() })
+	tb.SetFocusable(true)
 
 	tb.recalc()
 	tb.update()
@@ -297,6 +304,106 @@ func (tb *TabBar) Selected() int {
 	return tb.selected
 }
 
+// SetReorderable sets if the Tabs can be reordered by the user by dragging
+// their headers. OnReorder is dispatched after a Tab is moved.
+func (tb *TabBar) SetReorderable(state bool) {
+
+	tb.reorder = state
+	tb.SetDropTarget(state)
+	if !state {
+		tb.SetDragSource(nil)
+		return
+	}
+	tb.SetDragSource(tb.dragTab)
+	if !tb.dragSub {
+		tb.dragSub = true
+		tb.Subscribe(OnDragOver, tb.onDrag)
+		tb.Subscribe(OnDrop, tb.onDrag)
+	}
+}
+
+// moveTab moves the Tab at the specified source position to the specified
+// destination position shifting the Tabs between them
+func (tb *TabBar) moveTab(src, dest int) {
+
+	var selected *Tab
+	if tb.selected >= 0 {
+		selected = tb.tabs[tb.selected]
+	}
+	tab := tb.tabs[src]
+	if src < dest {
+		copy(tb.tabs[src:], tb.tabs[src+1:dest+1])
+	} else {
+		copy(tb.tabs[dest+1:], tb.tabs[dest:src])
+	}
+	tb.tabs[dest] = tab
+	if selected != nil {
+		tb.selected = tb.TabPosition(selected)
+	}
+	tb.recalc()
+}
+
+// dragTab is the drag source function of reorderable TabBars
+func (tb *TabBar) dragTab(x, y float32) *DragPayload {
+
+	for _, tab := range tb.tabs {
+		if !tab.header.Visible() || !tab.header.ContainsPosition(x, y) {
+			continue
+		}
+		if tab.iconClose.Visible() && tab.iconClose.ContainsPosition(x, y) {
+			return nil
+		}
+		return &DragPayload{Type: "gui.Tab", Data: tab, Text: tab.Label()}
+	}
+	return nil
+}
+
+// dropTab returns the position where a Tab dropped at the specified window position is inserted
+func (tb *TabBar) dropTab(x, y float32) int {
+
+	pos := 0
+	for ; pos < len(tb.tabs); pos++ {
+		header := &tb.tabs[pos].header
+		if !header.Visible() || x < header.pospix.X+header.width/2 {
+			break
+		}
+	}
+	return pos
+}
+
+// onDrag receives the drag and drop events of reorderable TabBars
+func (tb *TabBar) onDrag(evname string, ev interface{}) {
+
+	dev := ev.(*DragEvent)
+	tab, ok := dev.Payload.Data.(*Tab)
+	if !tb.reorder || !ok || !dragFrom(dev, tb) {
+		return
+	}
+	from := tb.TabPosition(tab)
+	if from < 0 {
+		return
+	}
+	to := tb.dropTab(dev.X, dev.Y)
+	switch evname {
+	case OnDragOver:
+		dev.Accept()
+		if to < len(tb.tabs) && tb.tabs[to].header.Visible() {
+			dev.indicateEdge(&tb.tabs[to].header, true, false)
+		} else if to > 0 {
+			dev.indicateEdge(&tb.tabs[to-1].header, true, true)
+		}
+	case OnDrop:
+		if to > from {
+			to--
+		}
+		if to == from {
+			return
+		}
+		tb.moveTab(from, to)
+		tb.Dispatch(OnReorder, &ReorderEvent{From: from, To: to})
+	}
+}
+
 // onCursor process subscribed cursor events
 func (tb *TabBar) onCursor(evname string, ev interface{}) {
 
@@ -313,6 +420,31 @@ func (tb *TabBar) onCursor(evname string, ev interface{}) {
 	tb.root.StopPropagation(StopAll)
 }
 
+// onKey process subscribed key events selecting the tabs
+func (tb *TabBar) onKey(evname string, ev interface{}) {
+
+	if len(tb.tabs) == 0 {
+		return
+	}
+	pos := tb.selected
+	switch ev.(*window.KeyEvent).Keycode {
+	case window.KeyLeft:
+		pos--
+	case window.KeyRight:
+		pos++
+	case window.KeyHome:
+		pos = 0
+	case window.KeyEnd:
+		pos = len(tb.tabs) - 1
+	default:
+		return
+	}
+	if pos >= 0 && pos < len(tb.tabs) {
+		tb.SetSelected(pos)
+	}
+	tb.root.StopPropagation(Stop3D)
+}
+
 // onListButtonMouse process subscribed MouseButton events over the list button
 func (tb *TabBar) onListButton(evname string, ev interface{}) {
 
@@ -461,6 +593,10 @@ func (tb *TabBar) update() {
 		tb.applyStyle(&tb.styles.Over)
 		return
 	}
+	if tb.root != nil && tb.root.HasKeyFocus(tb) {
+		tb.applyStyle(&tb.styles.Focus)
+		return
+	}
 	tb.applyStyle(&tb.styles.Normal)
 }
 
@@ -589,6 +725,7 @@ func (tab *Tab) onMouseHeader(evname string, ev interface{}) {
 		mev := ev.(*window.MouseEvent)
 		if mev.Button == window.MouseButtonLeft {
 			tab.tb.SetSelected(tab.tb.TabPosition(tab))
+			tab.header.root.SetKeyFocus(tab.tb)
 		} else {
 			tab.header.Dispatch(OnRightClick, ev)
 		}
@@ -55,6 +55,7 @@ const (
 	tableColMinWidth    = 16
 	tableErrInvRow      = "Invalid row index"
 	tableErrInvCol      = "Invalid column id"
+	tableErrModel       = "Rows of virtual tables are provided by the model"
 )
 
 //
@@ -62,38 +63,51 @@ const (
 // organized in rows and columns.
 //
 type Table struct {
-	Panel                       // Embedded panel
-	styles         *TableStyles // pointer to current styles
-	header         tableHeader  // table headers
-	rows           []*tableRow  // array of table rows
-	rowCursor      int          // index of row cursor
-	firstRow       int          // index of the first visible row
-	lastRow        int          // index of the last visible row
-	vscroll        *ScrollBar   // vertical scroll bar
-	statusPanel    Panel        // optional bottom status panel
-	statusLabel    *Label       // status label
-	scrollBarEvent bool         // do not update the scrollbar value in recalc() if true
-	resizerPanel   Panel        // resizer panel
-	resizeCol      int          // column being resized
-	resizerX       float32      // initial resizer x coordinate
-	resizing       bool         // dragging the column resizer
-	selType        TableSelType // table selection type
+	Panel                          // Embedded panel
+	styles         *TableStyles    // pointer to current styles
+	header         tableHeader     // table headers
+	rows           []*tableRow     // array of table rows
+	rowCursor      int             // index of row cursor
+	firstRow       int             // index of the first visible row
+	lastRow        int             // index of the last visible row
+	vscroll        *ScrollBar      // vertical scroll bar
+	statusPanel    Panel           // optional bottom status panel
+	statusLabel    *Label          // status label
+	scrollBarEvent bool            // do not update the scrollbar value in recalc() if true
+	resizerPanel   Panel           // resizer panel
+	resizeCol      int             // column being resized
+	resizerX       float32         // initial resizer x coordinate
+	resizing       bool            // dragging the column resizer
+	selType        TableSelType    // table selection type
+	reorder        bool            // rows can be reordered by dragging
+	dragSub        bool            // subscribed to drag and drop events
+	model          TableModel      // data model of virtual tables
+	mrows          []*tableRow     // recycled row panels of virtual tables
+	mindex         []int           // model row of each table row of sorted virtual tables
+	msel           map[int]bool    // selected model rows of virtual tables
+	mcount         int             // model row count when the table was last updated
+	msort          *tableSort      // last sort of virtual tables
+	colCursor      int             // index of the column of the current cell
+	edit           *tableEdit      // cell being edited
+	editSubs       map[*Panel]bool // editor panels subscribed by the table
 }
 
 // TableColumn describes a table column
 type TableColumn struct {
-	Id         string          // Column id used to reference the column. Must be unique
-	Header     string          // Column name shown in the table header
-	Width      float32         // Initial column width in pixels
-	Minwidth   float32         // Minimum width in pixels for this column
-	Hidden     bool            // Hidden flag
-	Align      Align           // Cell content alignment: AlignLeft|AlignCenter|AlignRight
-	Fill       bool            // Whether cell content fills the cell (default false, overrides Align if true)
-	Format     string          // Format string for formatting the columns' cells
-	FormatFunc TableFormatFunc // Format function (overrides Format string)
-	Expand     float32         // Column width expansion factor (0 for no expansion)
-	Sort       TableSortType   // Column sort type
-	Resize     bool            // Allow column to be resized by user
+	Id         string            // Column id used to reference the column. Must be unique
+	Header     string            // Column name shown in the table header
+	Width      float32           // Initial column width in pixels
+	Minwidth   float32           // Minimum width in pixels for this column
+	Hidden     bool              // Hidden flag
+	Align      Align             // Cell content alignment: AlignLeft|AlignCenter|AlignRight
+	Fill       bool              // Whether cell content fills the cell (default false, overrides Align if true)
+	Format     string            // Format string for formatting the columns' cells
+	FormatFunc TableFormatFunc   // Format function (overrides Format string)
+	Expand     float32           // Column width expansion factor (0 for no expansion)
+	Sort       TableSortType     // Column sort type
+	Resize     bool              // Allow column to be resized by user
+	Editor     TableEditor       // Optional editor of the column cells
+	Validate   TableValidateFunc // Optional validation function of the edited values
 }
 
 // TableCell describes a table cell.
@@ -133,6 +147,7 @@ type TableStyles struct {
 	RowSel  TableRowStyle
 	Status  TableStatusStyle
 	Resizer TableResizerStyle
+	Cursor  TableRowStyle // style of the current cell of tables with editable columns
 }
 
 // TableClickEvent describes a mouse click event over a table
@@ -157,23 +172,25 @@ type tableHeader struct {
 
 // tableColHeader is panel for a column header
 type tableColHeader struct {
-	Panel                      // header panel
-	label      *Label          // header label
-	ricon      *Label          // header right icon (sort direction)
-	id         string          // column id
-	width      float32         // initial column width
-	minWidth   float32         // minimum width
-	format     string          // column format string
-	formatFunc TableFormatFunc // column format function
-	align      Align           // column alignment
-	fill       bool            // column fill
-	expand     float32         // column expand factor
-	sort       TableSortType   // column sort type
-	resize     bool            // column can be resized by user
-	order      int             // row columns order
-	sorted     int             // current sorted status
-	xl         float32         // left border coordinate in pixels
-	xr         float32         // right border coordinate in pixels
+	Panel                        // header panel
+	label      *Label            // header label
+	ricon      *Label            // header right icon (sort direction)
+	id         string            // column id
+	width      float32           // initial column width
+	minWidth   float32           // minimum width
+	format     string            // column format string
+	formatFunc TableFormatFunc   // column format function
+	align      Align             // column alignment
+	fill       bool              // column fill
+	expand     float32           // column expand factor
+	sort       TableSortType     // column sort type
+	resize     bool              // column can be resized by user
+	editor     TableEditor       // column cells editor
+	validate   TableValidateFunc // column edited values validation function
+	order      int               // row columns order
+	sorted     int               // current sorted status
+	xl         float32           // left border coordinate in pixels
+	xr         float32           // right border coordinate in pixels
 }
 
 // tableRow is panel which contains an entire table row of cells
@@ -200,6 +217,8 @@ func NewTable(width, height float32, cols []TableColumn) (*Table, error) {
 	t.Panel.Initialize(width, height)
 	t.styles = &StyleDefault().Table
 	t.rowCursor = -1
+	t.colCursor = -1
+	t.editSubs = make(map[*Panel]bool)
 
 	// Initialize table header
 	t.header.Initialize(0, 0)
@@ -239,6 +258,8 @@ func NewTable(width, height float32, cols []TableColumn) (*Table, error) {
 		c.expand = cdesc.Expand
 		c.sort = cdesc.Sort
 		c.resize = cdesc.Resize
+		c.editor = cdesc.Editor
+		c.validate = cdesc.Validate
 		// Adds optional sort icon
 		if c.sort != TableSortNone {
 			c.ricon = NewIcon(string(tableSortedNoneIcon))
@@ -293,9 +314,11 @@ func NewTable(width, height float32, cols []TableColumn) (*Table, error) {
 	t.Panel.Subscribe(OnScroll, t.onScroll)
 	t.Panel.Subscribe(OnMouseUp, t.onMouse)
 	t.Panel.Subscribe(OnMouseDown, t.onMouse)
+	t.Panel.Subscribe(OnMouseOut, t.onMouseOut)
 	t.Panel.Subscribe(OnKeyDown, t.onKey)
 	t.Panel.Subscribe(OnKeyRepeat, t.onKey)
 	t.Panel.Subscribe(OnResize, t.onResize)
+	t.Panel.SetFocusable(true)
 	t.recalc()
 	return t, nil
 }
@@ -359,6 +382,9 @@ func (t *Table) ShowAllColumns() {
 // RowCount returns the current number of rows in the table
 func (t *Table) RowCount() int {
 
+	if t.model != nil {
+		return t.model.RowCount()
+	}
 	return len(t.rows)
 }
 
@@ -369,6 +395,9 @@ func (t *Table) RowCount() int {
 // If a row column is not found it is ignored
 func (t *Table) SetRows(values []map[string]interface{}) {
 
+	if t.model != nil {
+		panic(tableErrModel)
+	}
 	// Add missing rows
 	if len(values) > len(t.rows) {
 		count := len(values) - len(t.rows)
@@ -395,6 +424,9 @@ func (t *Table) SetRows(values []map[string]interface{}) {
 // the specified map indexed by column id.
 func (t *Table) SetRow(row int, values map[string]interface{}) {
 
+	if t.model != nil {
+		panic(tableErrModel)
+	}
 	if row < 0 || row >= len(t.rows) {
 		panic(tableErrInvRow)
 	}
@@ -406,6 +438,9 @@ func (t *Table) SetRow(row int, values map[string]interface{}) {
 // The function panics if the passed row or column id is invalid
 func (t *Table) SetCell(row int, colid string, value interface{}) {
 
+	if t.model != nil {
+		panic(tableErrModel)
+	}
 	if row < 0 || row >= len(t.rows) {
 		panic(tableErrInvRow)
 	}
@@ -512,6 +547,9 @@ func (t *Table) AddRow(values map[string]interface{}) {
 // InsertRow inserts the specified values in a new row at the specified index
 func (t *Table) InsertRow(row int, values map[string]interface{}) {
 
+	if t.model != nil {
+		panic(tableErrModel)
+	}
 	// Checks row index
 	if row < 0 || row > len(t.rows) {
 		panic(tableErrInvRow)
@@ -523,6 +561,9 @@ func (t *Table) InsertRow(row int, values map[string]interface{}) {
 
 // SwitchRows switches the specified rows
 func (t *Table) SwitchRows(row1, row2 int) {
+	if t.model != nil {
+		panic(tableErrModel)
+	}
 	// Checks row1 index
 	if row1 < 0 || row1 >= len(t.rows) {
 		panic(tableErrInvRow)
@@ -537,9 +578,69 @@ func (t *Table) SwitchRows(row1, row2 int) {
 	t.Dispatch(OnChange, nil)
 }
 
+// MoveRow moves the row at the specified source index to the specified destination index
+func (t *Table) MoveRow(src, dest int) {
+
+	if t.model != nil {
+		panic(tableErrModel)
+	}
+	// Checks src index
+	if src < 0 || src >= len(t.rows) {
+		panic(tableErrInvRow)
+	}
+	// Checks dest index
+	if dest < 0 || dest >= len(t.rows) {
+		panic(tableErrInvRow)
+	}
+	if src == dest {
+		return
+	}
+
+	trow := t.rows[src]
+	if src < dest {
+		copy(t.rows[src:], t.rows[src+1:dest+1])
+	} else {
+		copy(t.rows[dest+1:], t.rows[dest:src])
+	}
+	t.rows[dest] = trow
+
+	// Keeps the row cursor at the same row
+	switch {
+	case t.rowCursor == src:
+		t.rowCursor = dest
+	case src < dest && t.rowCursor > src && t.rowCursor <= dest:
+		t.rowCursor--
+	case src > dest && t.rowCursor >= dest && t.rowCursor < src:
+		t.rowCursor++
+	}
+	t.recalc()
+	t.Dispatch(OnChange, nil)
+}
+
+// SetReorderable sets if the rows of the table can be reordered by the user
+// by dragging them. OnReorder is dispatched after a row is moved.
+func (t *Table) SetReorderable(state bool) {
+
+	t.reorder = state
+	t.SetDropTarget(state)
+	if !state {
+		t.SetDragSource(nil)
+		return
+	}
+	t.SetDragSource(t.dragRow)
+	if !t.dragSub {
+		t.dragSub = true
+		t.Subscribe(OnDragOver, t.onDrag)
+		t.Subscribe(OnDrop, t.onDrag)
+	}
+}
+
 // RemoveRow removes from the specified row from the table
 func (t *Table) RemoveRow(row int) {
 
+	if t.model != nil {
+		panic(tableErrModel)
+	}
 	// Checks row index
 	if row < 0 || row >= len(t.rows) {
 		panic(tableErrInvRow)
@@ -556,12 +657,10 @@ func (t *Table) RemoveRow(row int) {
 // Clear removes all rows from the table
 func (t *Table) Clear() {
 
-	for ri := 0; ri < len(t.rows); ri++ {
-		trow := t.rows[ri]
-		t.Panel.Remove(trow)
-		trow.DisposeChildren(true)
-		trow.Dispose()
+	if t.model != nil {
+		panic(tableErrModel)
 	}
+	t.disposeRows(t.rows)
 	t.rows = nil
 	t.firstRow = 0
 	t.rowCursor = -1
@@ -573,6 +672,9 @@ func (t *Table) Clear() {
 // If no row is selected returns an empty slice
 func (t *Table) SelectedRows() []int {
 
+	if t.model != nil {
+		return t.selectedModelRows()
+	}
 	res := make([]int, 0)
 	for ri := 0; ri < len(t.rows); ri++ {
 		if t.rows[ri].selected {
@@ -609,8 +711,8 @@ func (t *Table) Rows(fi, li int) []map[string]interface{} {
 		panic(tableErrInvRow)
 	}
 	if li < 0 {
-		li = len(t.rows) - 1
-	} else if li < 0 || li >= len(t.rows) {
+		li = t.RowCount() - 1
+	} else if li < 0 || li >= t.RowCount() {
 		panic(tableErrInvRow)
 	}
 	if li < fi {
@@ -618,13 +720,7 @@ func (t *Table) Rows(fi, li int) []map[string]interface{} {
 	}
 	res := make([]map[string]interface{}, li-li+1)
 	for ri := fi; ri <= li; ri++ {
-		trow := t.rows[ri]
-		rmap := make(map[string]interface{})
-		for ci := 0; ci < len(t.header.cols); ci++ {
-			c := t.header.cols[ci]
-			rmap[c.id] = trow.cells[c.order].value
-		}
-		res = append(res, rmap)
+		res = append(res, t.Row(ri))
 	}
 	return res
 }
@@ -636,10 +732,13 @@ func (t *Table) Row(ri int) map[string]interface{} {
 		panic(tableErrInvRow)
 	}
 	res := make(map[string]interface{})
-	trow := t.rows[ri]
 	for ci := 0; ci < len(t.header.cols); ci++ {
 		c := t.header.cols[ci]
-		res[c.id] = trow.cells[c.order].value
+		if t.model != nil {
+			res[c.id] = t.model.Cell(ri, c.id)
+			continue
+		}
+		res[c.id] = t.rows[ri].cells[c.order].value
 	}
 	return res
 }
@@ -651,9 +750,12 @@ func (t *Table) Cell(col string, ri int) interface{} {
 	if c == nil {
 		panic(tableErrInvCol)
 	}
-	if ri < 0 || ri >= len(t.rows) {
+	if ri < 0 || ri >= t.RowCount() {
 		panic(tableErrInvRow)
 	}
+	if t.model != nil {
+		return t.model.Cell(ri, col)
+	}
 	trow := t.rows[ri]
 	return trow.cells[c.order].value
 }
@@ -667,6 +769,12 @@ func (t *Table) SortColumn(col string, asString bool, asc bool) {
 	if c == nil {
 		panic(tableErrInvCol)
 	}
+	if t.model != nil {
+		t.msort = &tableSort{col: col, asString: asString, asc: asc}
+		t.sortModel()
+		t.recalc()
+		return
+	}
 	if len(t.rows) < 2 {
 		return
 	}
@@ -709,6 +817,27 @@ func (t *Table) setCell(row int, colid string, value interface{}) {
 // insertRow is the internal version of InsertRow which does not call recalc()
 func (t *Table) insertRow(row int, values map[string]interface{}) {
 
+	trow := t.newRow(values)
+	t.Panel.Add(trow)
+
+	// Inserts tableRow in the table rows at the specified index
+	t.rows = append(t.rows, nil)
+	copy(t.rows[row+1:], t.rows[row:])
+	t.rows[row] = trow
+	t.updateRowStyle(row)
+
+	// Sets the new row values from the specified map
+	if values != nil {
+		t.SetRow(row, values)
+	}
+	t.recalcRow(row)
+}
+
+// newRow creates and returns a new row panel with cells for all columns.
+// The cells of the columns which have a panel value contain the panel
+// and the others a label.
+func (t *Table) newRow(values map[string]interface{}) *tableRow {
+
 	// Creates tableRow panel
 	trow := new(tableRow)
 	trow.Initialize(0, 0)
@@ -729,7 +858,7 @@ func (t *Table) insertRow(row int, values map[string]interface{}) {
 		}
 
 		value := reflect.ValueOf(v)
-		if value.CanInterface() {
+		if value.IsValid() && value.CanInterface() {
 			ipanel, ok := value.Interface().(IPanel)
 			if ok {
 				strct := value
@@ -758,19 +887,7 @@ func (t *Table) insertRow(row int, values map[string]interface{}) {
 		}
 		cell.Add(&cell.label)
 	}
-	t.Panel.Add(trow)
-
-	// Inserts tableRow in the table rows at the specified index
-	t.rows = append(t.rows, nil)
-	copy(t.rows[row+1:], t.rows[row:])
-	t.rows[row] = trow
-	t.updateRowStyle(row)
-
-	// Sets the new row values from the specified map
-	if values != nil {
-		t.SetRow(row, values)
-	}
-	t.recalcRow(row)
+	return trow
 }
 
 // ScrollDown scrolls the table the specified number of rows down if possible
@@ -833,6 +950,86 @@ func (t *Table) removeRow(row int) {
 	trow.Dispose()
 }
 
+// disposeRows removes the specified row panels from the table and disposes them
+func (t *Table) disposeRows(rows []*tableRow) {
+
+	for _, trow := range rows {
+		t.Panel.Remove(trow)
+		trow.DisposeChildren(true)
+		trow.Dispose()
+	}
+}
+
+// dragRow is the drag source function of reorderable tables
+func (t *Table) dragRow(x, y float32) *DragPayload {
+
+	// Dragging a column border resizes the column
+	if t.resizeCol >= 0 {
+		return nil
+	}
+	for ri := t.firstRow; ri < len(t.rows); ri++ {
+		trow := t.rows[ri]
+		if !trow.Visible() {
+			break
+		}
+		if !trow.ContainsPosition(x, y) {
+			continue
+		}
+		// Shows the text of the first visible column
+		var text string
+		for _, c := range t.header.cols {
+			if c.Visible() {
+				text = trow.cells[c.order].label.Text()
+				break
+			}
+		}
+		return &DragPayload{Type: "gui.TableRow", Data: ri, Text: text}
+	}
+	return nil
+}
+
+// dropRow returns the index where a row dropped at the specified window position is inserted
+func (t *Table) dropRow(x, y float32) int {
+
+	ri := t.firstRow
+	for ; ri < len(t.rows); ri++ {
+		trow := t.rows[ri]
+		if !trow.Visible() || y < trow.pospix.Y+trow.height/2 {
+			break
+		}
+	}
+	return ri
+}
+
+// onDrag receives the drag and drop events of reorderable tables
+func (t *Table) onDrag(evname string, ev interface{}) {
+
+	dev := ev.(*DragEvent)
+	from, ok := dev.Payload.Data.(int)
+	if !t.reorder || !ok || !dragFrom(dev, t) || from < 0 || from >= len(t.rows) {
+		return
+	}
+	to := t.dropRow(dev.X, dev.Y)
+	switch evname {
+	case OnDragOver:
+		dev.Accept()
+		if to < len(t.rows) && t.rows[to].Visible() {
+			dev.indicateEdge(&t.rows[to].Panel, false, false)
+		} else if to > t.firstRow {
+			dev.indicateEdge(&t.rows[to-1].Panel, false, true)
+		}
+	case OnDrop:
+		if to > from {
+			to--
+		}
+		if to == from {
+			return
+		}
+		t.MoveRow(from, to)
+		t.Dispatch(OnReorder, &ReorderEvent{From: from, To: to})
+	}
+}
+
 // onCursor process subscribed cursor events
 func (t *Table) onCursor(evname string, ev interface{}) {
 
@@ -889,6 +1086,16 @@ func (t *Table) onMouse(evname string, ev interface{}) {
 	t.root.SetKeyFocus(t)
 	switch evname {
 	case OnMouseDown:
+		// Clicks outside the editor of the cell being edited ends the edition
+		if t.edit != nil {
+			if overlayInside(t.edit.pan, e.Xpos, e.Ypos) {
+				return
+			}
+			if !t.EndEdit(true) {
+				t.root.StopPropagation(StopAll)
+				return
+			}
+		}
 		// If over a resizable column border, shows the resizer panel
 		if t.resizeCol >= 0 && e.Button == window.MouseButtonLeft {
 			t.resizing = true
@@ -907,12 +1114,16 @@ func (t *Table) onMouse(evname string, ev interface{}) {
 		var tce TableClickEvent
 		tce.MouseEvent = *e
 		t.findClick(&tce)
+		// Clicking the current cell of an editable column starts editing it
+		edit := false
+		if tce.Row >= 0 && !tce.Header && e.Button == window.MouseButtonLeft && t.cellEditable(tce.ColOrder) {
+			edit = e.Mods == 0 && tce.Row == t.rowCursor && tce.ColOrder == t.colCursor
+			t.colCursor = tce.ColOrder
+		}
 		// If row is clicked, selects it
 		if tce.Row >= 0 && e.Button == window.MouseButtonLeft {
 			if e.Mods == 0 {
-				for ri := 0; ri < len(t.rows); ri++ {
-					t.deselectRow(ri)
-				}
+				t.deselectAll()
 			}
 			if t.selType == TableSelMultiRow && e.Mods == window.ModShift && t.rowCursor >= 0 {
 				min := tce.Row
@@ -933,7 +1144,13 @@ func (t *Table) onMouse(evname string, ev interface{}) {
 			t.recalc()
 			t.Dispatch(OnChange, nil)
 		}
+		if edit {
+			t.beginEdit(tce.Row, tce.ColOrder)
+		}
 		// Creates and dispatch TableClickEvent for user's context menu
+		if tce.Row >= 0 {
+			tce.Row = t.modelRow(tce.Row)
+		}
 		t.Dispatch(OnTableClick, tce)
 	case OnMouseUp:
 		// If user was resizing a column, hides the resizer and
@@ -958,6 +1175,9 @@ func (t *Table) onMouse(evname string, ev interface{}) {
 func (t *Table) onKey(evname string, ev interface{}) {
 
 	kev := ev.(*window.KeyEvent)
+	if t.editable() && t.onCellKey(kev) {
+		return
+	}
 	if kev.Keycode == window.KeyUp && kev.Mods == 0 {
 		t.selPrev()
 	} else if kev.Keycode == window.KeyDown && kev.Mods == 0 {
@@ -1058,6 +1278,17 @@ func (t *Table) findClick(ev *TableClickEvent) {
 		rowy = t.header.Height()
 	}
 	theight := t.ContentHeight()
+	if t.model != nil {
+		rh := t.modelRowHeight()
+		if y < rowy || rh <= 0 {
+			return
+		}
+		ri := t.firstRow + int((y-rowy)/rh)
+		if ri < t.RowCount() && rowy+float32(ri-t.firstRow+1)*rh <= theight {
+			ev.Row = ri
+		}
+		return
+	}
 	for ri := t.firstRow; ri < len(t.rows); ri++ {
 		trow := t.rows[ri]
 		rowy += trow.height
@@ -1075,7 +1306,7 @@ func (t *Table) findClick(ev *TableClickEvent) {
 func (t *Table) selNext() {
 
 	// If selected row is last, nothing to do
-	if t.rowCursor == len(t.rows)-1 {
+	if t.rowCursor == t.RowCount()-1 {
 		return
 	}
 	// If no selected row, selects first visible row
@@ -1128,10 +1359,10 @@ func (t *Table) selPrev() {
 // nextPage shows the next page of rows and selects its first row
 func (t *Table) nextPage() {
 
-	if len(t.rows) == 0 {
+	if t.RowCount() == 0 {
 		return
 	}
-	if t.lastRow == len(t.rows)-1 {
+	if t.lastRow == t.RowCount()-1 {
 		t.rowCursor = t.lastRow
 		t.recalc()
 		t.Dispatch(OnChange, nil)
@@ -1163,7 +1394,7 @@ func (t *Table) prevPage() {
 // firstPage shows the first page of rows and selects the first row
 func (t *Table) firstPage() {
 
-	if len(t.rows) == 0 {
+	if t.RowCount() == 0 {
 		return
 	}
 	t.firstRow = 0
@@ -1175,12 +1406,12 @@ func (t *Table) firstPage() {
 // lastPage shows the last page of rows and selects the last row
 func (t *Table) lastPage() {
 
-	if len(t.rows) == 0 {
+	if t.RowCount() == 0 {
 		return
 	}
 	maxFirst := t.calcMaxFirst()
 	t.firstRow = maxFirst
-	t.rowCursor = len(t.rows) - 1
+	t.rowCursor = t.RowCount() - 1
 	t.recalc()
 	t.Dispatch(OnChange, nil)
 }
@@ -1189,6 +1420,10 @@ func (t *Table) lastPage() {
 // Should be used only when multi row selection is enabled
 func (t *Table) deselectRow(ri int) {
 
+	if t.model != nil {
+		t.setModelSel(t.modelRow(ri), false)
+		return
+	}
 	trow := t.rows[ri]
 	if trow.selected {
 		trow.selected = false
@@ -1201,6 +1436,9 @@ func (t *Table) SelectRows(rows ...int) bool {
 	if len(rows) == 0 {
 		return false
 	}
+	if t.model != nil {
+		return t.selectModelRows(rows)
+	}
 
 	// Deselect all no affected rows
 	ss := t.SelectedRows()
@@ -1239,6 +1477,10 @@ func (t *Table) SelectRows(rows ...int) bool {
 // Should be used only when multi row selection is enabled
 func (t *Table) selectRow(ri int) {
 
+	if t.model != nil {
+		t.setModelSel(t.modelRow(ri), true)
+		return
+	}
 	trow := t.rows[ri]
 	if !trow.selected {
 		trow.selected = true
@@ -1246,10 +1488,30 @@ func (t *Table) selectRow(ri int) {
 	}
 }
 
+// deselectAll deselects all the table rows
+func (t *Table) deselectAll() {
+
+	if t.model != nil {
+		if len(t.msel) > 0 {
+			t.msel = make(map[int]bool)
+			t.Dispatch(OnChange, nil)
+		}
+		return
+	}
+	for ri := 0; ri < len(t.rows); ri++ {
+		t.deselectRow(ri)
+	}
+}
+
 // toggleRowSel toggles the specified row selection state
 // Should be used only when multi row selection is enabled
 func (t *Table) toggleRowSel(ri int) {
 
+	if t.model != nil {
+		mi := t.modelRow(ri)
+		t.setModelSel(mi, !t.msel[mi])
+		return
+	}
 	trow := t.rows[ri]
 	trow.selected = !trow.selected
 	t.Dispatch(OnChange, nil)
@@ -1449,6 +1711,10 @@ func (t *Table) recalcStatus() {
 // - horizontal or vertical scroll position changed
 func (t *Table) recalc() {
 
+	if t.model != nil {
+		t.recalcModel()
+		return
+	}
 	// Get available row height for rows
 	starty, theight := t.rowsHeight()
 
@@ -1491,13 +1757,20 @@ func (t *Table) recalc() {
 	}
 	// Status panel must be on top of all the row panels
 	t.SetTopChild(&t.statusPanel)
+	t.placeEditor()
 }
 
 // recalcRow recalculates the positions and sizes of all cells of the specified row
 // Should be called when the row is created and column visibility or order is changed.
 func (t *Table) recalcRow(ri int) {
 
-	trow := t.rows[ri]
+	t.layoutRow(t.rows[ri], ri)
+}
+
+// layoutRow recalculates the positions and sizes of all cells of the
+// specified row panel showing the table row with the specified index
+func (t *Table) layoutRow(trow *tableRow, ri int) {
+
 	// Calculates and sets row height
 	maxheight := float32(0)
 	for ci := 0; ci < len(t.header.cols); ci++ {
@@ -1688,6 +1961,17 @@ func (t *Table) onVScrollBar(evname string, ev interface{}) {
 func (t *Table) calcMaxFirst() int {
 
 	_, total := t.rowsHeight()
+	if t.model != nil {
+		rh := t.modelRowHeight()
+		if rh <= 0 {
+			return 0
+		}
+		max := t.RowCount() - int(total/rh)
+		if max < 0 {
+			return 0
+		}
+		return max
+	}
 	ri := len(t.rows) - 1
 	if ri < 0 {
 		return 0
@@ -1710,7 +1994,13 @@ func (t *Table) calcMaxFirst() int {
 // updateRowStyle applies the correct style for the specified row
 func (t *Table) updateRowStyle(ri int) {
 
-	row := t.rows[ri]
+	t.styleRow(t.rows[ri], ri)
+}
+
+// styleRow applies the correct style for the specified row panel
+// showing the table row with the specified index
+func (t *Table) styleRow(row *tableRow, ri int) {
+
 	var trs TableRowStyle
 	if row.selected {
 		trs = t.styles.RowSel
@@ -1722,6 +2012,11 @@ func (t *Table) updateRowStyle(ri int) {
 		}
 	}
 	t.applyRowStyle(row, &trs)
+	// Current cell of tables with editable columns
+	if ri == t.rowCursor && t.editable() && t.colCursor >= 0 && t.colCursor < len(t.header.cols) {
+		cell := row.cells[t.header.cols[t.colCursor].order]
+		cell.ApplyStyle(&t.styles.Cursor.PanelStyle)
+	}
 }
 
 // applyHeaderStyle applies style to the specified table header
@@ -0,0 +1,700 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gui
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/sansebasko/engine/window"
+)
+
+// The cells of the table columns which have an editor can be edited by the user.
+// Tables with editable columns have a current cell which is moved with the Left and Right
+// keys and with Tab and Shift+Tab, besides the row cursor moved with the Up and Down keys.
+// Editing the current cell is started with Enter or F2 or by clicking it.
+// While editing, the editor panel is shown over the cell and receives the key focus.
+// Enter and the Up and Down keys end the edition and move the current cell to the next
+// or previous row, Tab and Shift+Tab end the edition and start editing the next or previous
+// editable cell, and Escape cancels the edition. Clicking outside the editor also ends it.
+// If the edited value is valid and different from the cell value it is set in the cell
+// and the table dispatches OnChange with a TableEditEvent.
+// Cells of virtual tables can be edited only if their model implements TableModelSetter.
+
+// OnTableEditError is the event generated when the value edited by the user is invalid.
+// Parameter is TableEditEvent
+const OnTableEditError = "onTableEditError"
+
+// TableEditor is the interface for the editors of table cells
+type TableEditor interface {
+	// EditorPanel returns the panel shown over the edited cell
+	EditorPanel() IPanel
+	// Begin starts editing the specified cell with the specified size in pixels
+	Begin(cell TableCell, width, height float32)
+	// Value returns the edited value or an error if it is invalid
+	Value() (interface{}, error)
+}
+
+// TableValidateFunc is the type of the functions which validate the values
+// edited by the user. The cell Value is the edited value.
+type TableValidateFunc func(cell TableCell) error
+
+// TableModelSetter is the interface for the models of virtual tables which
+// allow their cells to be edited
+type TableModelSetter interface {
+	SetCell(row int, colid string, value interface{}) // Sets the value of the cell at the specified row and column id
+}
+
+// TableEditEvent is the event dispatched by tables with OnChange when the user
+// changes the value of a cell and with OnTableEditError when the value is invalid
+type TableEditEvent struct {
+	Row int         // Row index
+	Col string      // Column id
+	Old interface{} // Previous cell value
+	New interface{} // Edited value
+	Err error       // Validation error (OnTableEditError)
+}
+
+// tableEdit describes the cell being edited
+type tableEdit struct {
+	trow *tableRow       // row of normal tables
+	mrow int             // model row of virtual tables
+	col  *tableColHeader // column
+	old  interface{}     // previous cell value
+	pan  IPanel          // editor panel
+}
+
+// SetColEditor sets the editor of the cells of the specified column.
+// A nil editor makes the column not editable.
+func (t *Table) SetColEditor(colid string, ed TableEditor) {
+
+	c := t.header.cmap[colid]
+	if c == nil {
+		panic(tableErrInvCol)
+	}
+	if t.edit != nil && t.edit.col == c {
+		t.CancelEdit()
+	}
+	c.editor = ed
+	t.recalc()
+}
+
+// SetColValidate sets the function which validates the values of the
+// cells of the specified column edited by the user
+func (t *Table) SetColValidate(colid string, f TableValidateFunc) {
+
+	c := t.header.cmap[colid]
+	if c == nil {
+		panic(tableErrInvCol)
+	}
+	c.validate = f
+}
+
+// BeginEdit starts editing the cell at the specified row and column.
+// Returns false if the cell cannot be edited.
+func (t *Table) BeginEdit(row int, colid string) bool {
+
+	for ci, c := range t.header.cols {
+		if c.id == colid {
+			return t.beginEdit(t.viewRow(row), ci)
+		}
+	}
+	panic(tableErrInvCol)
+}
+
+// Editing returns if a cell is being edited
+func (t *Table) Editing() bool {
+
+	return t.edit != nil
+}
+
+// EndEdit ends the edition of the current edited cell if any.
+// If commit is true the edited value is validated and set in the cell.
+// Returns false if the edited value is invalid and the edition continues.
+func (t *Table) EndEdit(commit bool) bool {
+
+	e := t.edit
+	if e == nil {
+		return true
+	}
+	vi := t.editRow()
+	row := t.modelRow(vi)
+	var value interface{}
+	if commit && vi >= 0 {
+		var err error
+		value, err = e.col.editor.Value()
+		if err == nil && e.col.validate != nil {
+			err = e.col.validate(TableCell{t, row, e.col.id, value})
+		}
+		if err != nil {
+			t.Dispatch(OnTableEditError, TableEditEvent{Row: row, Col: e.col.id, Old: e.old, New: value, Err: err})
+			return false
+		}
+	}
+
+	// Hides the editor and returns the key focus to the table
+	t.edit = nil
+	e.pan.GetPanel().SetVisible(false)
+	if t.root != nil {
+		t.root.SetKeyFocus(t)
+	}
+	if !commit || vi < 0 || reflect.DeepEqual(value, e.old) {
+		return true
+	}
+	if t.model != nil {
+		t.model.(TableModelSetter).SetCell(row, e.col.id, value)
+	} else {
+		t.setCell(vi, e.col.id, value)
+	}
+	t.recalc()
+	t.Dispatch(OnChange, TableEditEvent{Row: row, Col: e.col.id, Old: e.old, New: value})
+	return true
+}
+
+// CancelEdit cancels the edition of the current edited cell if any
+func (t *Table) CancelEdit() {
+
+	t.EndEdit(false)
+}
+
+// editable returns if this table has editable columns
+func (t *Table) editable() bool {
+
+	for ci := range t.header.cols {
+		if t.cellEditable(ci) {
+			return true
+		}
+	}
+	return false
+}
+
+// cellEditable returns if the cells of the column with the specified index can be edited
+func (t *Table) cellEditable(ci int) bool {
+
+	if ci < 0 || ci >= len(t.header.cols) {
+		return false
+	}
+	c := t.header.cols[ci]
+	if c.editor == nil || !c.Visible() {
+		return false
+	}
+	if t.model != nil {
+		_, ok := t.model.(TableModelSetter)
+		return ok
+	}
+	return true
+}
+
+// viewRow returns the table row which shows the specified model row of virtual tables
+func (t *Table) viewRow(mi int) int {
+
+	if t.mindex == nil {
+		return mi
+	}
+	for vi, i := range t.mindex {
+		if i == mi {
+			return vi
+		}
+	}
+	return -1
+}
+
+// editRow returns the index of the row of the edited cell or -1 if it no longer exists
+func (t *Table) editRow() int {
+
+	e := t.edit
+	if t.model != nil {
+		if e.mrow >= t.model.RowCount() {
+			return -1
+		}
+		return t.viewRow(e.mrow)
+	}
+	for ri, trow := range t.rows {
+		if trow == e.trow {
+			return ri
+		}
+	}
+	return -1
+}
+
+// rowPanel returns the panel which shows the specified visible row
+func (t *Table) rowPanel(ri int) *tableRow {
+
+	if t.model != nil {
+		return t.mrows[ri-t.firstRow]
+	}
+	return t.rows[ri]
+}
+
+// beginEdit starts editing the cell at the specified row and column indexes
+func (t *Table) beginEdit(ri, ci int) bool {
+
+	if ri < 0 || ri >= t.RowCount() || !t.cellEditable(ci) || !t.EndEdit(true) {
+		return false
+	}
+	c := t.header.cols[ci]
+	var value interface{}
+	if t.model != nil {
+		value = t.model.Cell(t.modelRow(ri), c.id)
+	} else {
+		value = t.rows[ri].cells[c.order].value
+	}
+	if _, ok := value.(IPanel); ok {
+		return false
+	}
+
+	// Makes the cell the current cell and scrolls to show it
+	t.rowCursor = ri
+	t.colCursor = ci
+	t.showRow(ri)
+	t.recalc()
+
+	// Adds the editor panel to the table
+	pan := c.editor.EditorPanel()
+	if !t.editSubs[pan.GetPanel()] {
+		t.editSubs[pan.GetPanel()] = true
+		pan.GetPanel().Subscribe(OnKeyDown, t.onEditKey)
+	}
+	if !panelContains(t, pan) {
+		t.Panel.Add(pan)
+	}
+	trow := t.rowPanel(ri)
+	cell := trow.cells[c.order]
+	t.edit = &tableEdit{trow: trow, mrow: t.modelRow(ri), col: c, old: value, pan: pan}
+	c.editor.Begin(TableCell{t, t.modelRow(ri), c.id, value}, cell.Width(), cell.Height())
+	t.placeEditor()
+	if t.root != nil {
+		t.root.SetKeyFocus(pan)
+	}
+	return true
+}
+
+// placeEditor sets the position of the editor over the edited cell
+// or hides it if the cell is not visible
+func (t *Table) placeEditor() {
+
+	e := t.edit
+	if e == nil {
+		return
+	}
+	ri := t.editRow()
+	if ri < 0 || !e.col.Visible() {
+		t.CancelEdit()
+		return
+	}
+	pan := e.pan.GetPanel()
+	if ri < t.firstRow || ri > t.lastRow {
+		pan.SetVisible(false)
+		return
+	}
+	trow := t.rowPanel(ri)
+	cell := trow.cells[e.col.order]
+	pan.SetPosition(cell.Position().X, trow.Position().Y+(trow.Height()-pan.Height())/2)
+	pan.SetVisible(true)
+	t.SetTopChild(e.pan)
+}
+
+// showRow scrolls the table if necessary to show the specified row
+func (t *Table) showRow(ri int) {
+
+	if ri < t.firstRow {
+		t.firstRow = ri
+	} else if ri > t.lastRow && t.lastRow >= t.firstRow {
+		t.firstRow += ri - t.lastRow
+	}
+	if max := t.calcMaxFirst(); t.firstRow > max {
+		t.firstRow = max
+	}
+}
+
+// setCursor sets the current cell
+func (t *Table) setCursor(ri, ci int) {
+
+	t.rowCursor = ri
+	t.colCursor = ci
+	t.showRow(ri)
+	t.recalc()
+	t.Dispatch(OnChange, nil)
+}
+
+// nextCell returns the row and column indexes of the editable cell after (dir = 1)
+// or before (dir = -1) the specified cell or -1 if not found
+func (t *Table) nextCell(ri, ci, dir int) (int, int) {
+
+	count := t.RowCount()
+	for {
+		ci += dir
+		if ci >= len(t.header.cols) {
+			ci = 0
+			ri++
+		} else if ci < 0 {
+			ci = len(t.header.cols) - 1
+			ri--
+		}
+		if ri < 0 || ri >= count {
+			return -1, -1
+		}
+		if t.cellEditable(ci) {
+			return ri, ci
+		}
+	}
+}
+
+// onCellKey receives the key events of tables with editable columns
+// which are not editing a cell. Returns true if the event was used.
+func (t *Table) onCellKey(kev *window.KeyEvent) bool {
+
+	ri := t.rowCursor
+	if ri < 0 {
+		ri = t.firstRow
+	}
+	switch {
+	case (kev.Keycode == window.KeyLeft || kev.Keycode == window.KeyRight) && kev.Mods == 0:
+		dir := 1
+		if kev.Keycode == window.KeyLeft {
+			dir = -1
+		}
+		for ci := t.colCursor + dir; ci >= 0 && ci < len(t.header.cols); ci += dir {
+			if t.cellEditable(ci) {
+				t.setCursor(ri, ci)
+				break
+			}
+		}
+	case kev.Keycode == window.KeyTab && (kev.Mods == 0 || kev.Mods == window.ModShift):
+		dir := 1
+		if kev.Mods == window.ModShift {
+			dir = -1
+		}
+		// Tab moves the key focus out of the table from its first and last cells
+		ri, ci := t.nextCell(ri, t.colCursor, dir)
+		if ri < 0 {
+			return false
+		}
+		t.setCursor(ri, ci)
+		t.root.StopPropagation(StopGUI)
+	case (kev.Keycode == window.KeyEnter || kev.Keycode == window.KeyF2) && kev.Mods == 0:
+		if !t.cellEditable(t.colCursor) {
+			ri, ci := t.nextCell(ri, -1, 1)
+			t.beginEdit(ri, ci)
+			break
+		}
+		t.beginEdit(ri, t.colCursor)
+	default:
+		return false
+	}
+	t.root.StopPropagation(Stop3D)
+	return true
+}
+
+// onEditKey receives the key events of the editor panels while editing cells
+func (t *Table) onEditKey(evname string, ev interface{}) {
+
+	if t.edit == nil {
+		return
+	}
+	kev := ev.(*window.KeyEvent)
+	ri, ci := t.editRow(), t.colCursor
+	switch kev.Keycode {
+	case window.KeyEscape:
+		t.CancelEdit()
+	case window.KeyEnter, window.KeyDown, window.KeyUp:
+		if kev.Mods != 0 || !t.EndEdit(true) {
+			return
+		}
+		if kev.Keycode == window.KeyUp {
+			ri--
+		} else {
+			ri++
+		}
+		if ri >= 0 && ri < t.RowCount() {
+			t.setCursor(ri, ci)
+		}
+	case window.KeyTab:
+		dir := 1
+		if kev.Mods == window.ModShift {
+			dir = -1
+		}
+		if !t.EndEdit(true) {
+			t.root.StopPropagation(StopGUI)
+			return
+		}
+		// Tab moves the key focus out of the table from its first and last cells
+		ri, ci := t.nextCell(ri, ci, dir)
+		if ri < 0 {
+			t.root.StopPropagation(Stop3D)
+			return
+		}
+		t.beginEdit(ri, ci)
+	default:
+		return
+	}
+	t.root.StopPropagation(StopAll)
+}
+
+// onMouseOut receives mouse button events outside of the table
+// to end the edition of the current cell
+func (t *Table) onMouseOut(evname string, ev interface{}) {
+
+	mev := ev.(*window.MouseEvent)
+	if t.edit != nil && !overlayInside(t.edit.pan, mev.Xpos, mev.Ypos) {
+		t.EndEdit(true)
+	}
+}
+
+// TableTextEditor is a table cell editor which edits the cell values as text.
+// Values of number and bool types are parsed from the text and keep their type.
+type TableTextEditor struct {
+	*Edit             // Embedded edit
+	old   interface{} // previous cell value
+}
+
+// NewTableTextEditor creates and returns a pointer to a new table text editor
+func NewTableTextEditor() *TableTextEditor {
+
+	ed := new(TableTextEditor)
+	ed.Edit = NewEdit(0, "")
+	ed.Edit.SetVisible(false)
+	return ed
+}
+
+// EditorPanel satisfies the TableEditor interface
+func (ed *TableTextEditor) EditorPanel() IPanel {
+
+	return ed.Edit
+}
+
+// Begin satisfies the TableEditor interface
+func (ed *TableTextEditor) Begin(cell TableCell, width, height float32) {
+
+	ed.old = cell.Value
+	ed.width = int(width - ed.MinWidth())
+	text := ""
+	if cell.Value != nil {
+		text = fmt.Sprint(cell.Value)
+	}
+	ed.SetText(text)
+	ed.SelectAll()
+	if ed.root != nil {
+		ed.setFocus()
+	}
+}
+
+// Value satisfies the TableEditor interface
+func (ed *TableTextEditor) Value() (interface{}, error) {
+
+	return parseTableValue(ed.Text(), ed.old)
+}
+
+// TableCheckEditor is a table cell editor which edits bool values with a check box.
+// The edition ends when the check box is toggled.
+type TableCheckEditor struct {
+	*CheckRadio           // Embedded check box
+	cell        TableCell // edited cell
+}
+
+// NewTableCheckEditor creates and returns a pointer to a new table check box editor
+func NewTableCheckEditor() *TableCheckEditor {
+
+	ed := new(TableCheckEditor)
+	ed.CheckRadio = NewCheckBox("")
+	ed.CheckRadio.SetVisible(false)
+	ed.CheckRadio.Subscribe(OnChange, func(evname string, ev interface{}) {
+		if ed.cell.Tab != nil && ed.cell.Tab.Editing() {
+			ed.cell.Tab.EndEdit(true)
+		}
+	})
+	return ed
+}
+
+// EditorPanel satisfies the TableEditor interface
+func (ed *TableCheckEditor) EditorPanel() IPanel {
+
+	return ed.CheckRadio
+}
+
+// Begin satisfies the TableEditor interface
+func (ed *TableCheckEditor) Begin(cell TableCell, width, height float32) {
+
+	ed.cell = TableCell{}
+	state, _ := cell.Value.(bool)
+	ed.SetValue(state)
+	ed.cell = cell
+}
+
+// Value satisfies the TableEditor interface
+func (ed *TableCheckEditor) Value() (interface{}, error) {
+
+	return ed.CheckRadio.Value(), nil
+}
+
+// TableDropDownEditor is a table cell editor which edits the cell values
+// by choosing one of the options of a drop down.
+// The edition ends when an option is chosen.
+type TableDropDownEditor struct {
+	*DropDown           // Embedded drop down
+	cell      TableCell // edited cell
+}
+
+// NewTableDropDownEditor creates and returns a pointer to a new table
+// drop down editor with the specified options
+func NewTableDropDownEditor(options ...string) *TableDropDownEditor {
+
+	ed := new(TableDropDownEditor)
+	ed.DropDown = NewDropDown(0, NewImageLabel(""))
+	for _, opt := range options {
+		ed.DropDown.Add(NewImageLabel(opt))
+	}
+	ed.DropDown.SetVisible(false)
+	ed.DropDown.Subscribe(OnChange, func(evname string, ev interface{}) {
+		if ed.cell.Tab != nil && ed.cell.Tab.Editing() {
+			ed.cell.Tab.EndEdit(true)
+		}
+	})
+	return ed
+}
+
+// EditorPanel satisfies the TableEditor interface
+func (ed *TableDropDownEditor) EditorPanel() IPanel {
+
+	return ed.DropDown
+}
+
+// Begin satisfies the TableEditor interface
+func (ed *TableDropDownEditor) Begin(cell TableCell, width, height float32) {
+
+	ed.cell = TableCell{}
+	ed.SetWidth(width)
+	text := fmt.Sprint(cell.Value)
+	for pos := 0; pos < ed.Len(); pos++ {
+		if ed.ItemAt(pos).Text() == text {
+			ed.SelectPos(pos)
+			break
+		}
+	}
+	ed.cell = cell
+}
+
+// Value satisfies the TableEditor interface
+func (ed *TableDropDownEditor) Value() (interface{}, error) {
+
+	sel := ed.Selected()
+	if sel == nil {
+		return ed.cell.Value, nil
+	}
+	return sel.Text(), nil
+}
+
+// TableSliderEditor is a table cell editor which edits number values
+// with a slider between a minimum and a maximum value
+type TableSliderEditor struct {
+	*Slider             // Embedded slider
+	min     float32     // minimum value
+	max     float32     // maximum value
+	old     interface{} // previous cell value
+}
+
+// NewTableSliderEditor creates and returns a pointer to a new table
+// slider editor with the specified minimum and maximum values
+func NewTableSliderEditor(min, max float32) *TableSliderEditor {
+
+	ed := new(TableSliderEditor)
+	ed.min = min
+	ed.max = max
+	ed.Slider = NewHSlider(0, 0)
+	ed.Slider.SetVisible(false)
+	ed.Slider.Subscribe(OnChange, func(evname string, ev interface{}) {
+		ed.SetText(fmt.Sprintf("%.4g", ed.value()))
+	})
+	return ed
+}
+
+// EditorPanel satisfies the TableEditor interface
+func (ed *TableSliderEditor) EditorPanel() IPanel {
+
+	return ed.Slider
+}
+
+// Begin satisfies the TableEditor interface
+func (ed *TableSliderEditor) Begin(cell TableCell, width, height float32) {
+
+	ed.old = cell.Value
+	ed.SetSize(width, height)
+	if ed.max != ed.min {
+		ed.SetValue((float32(cv2f64(cell.Value)) - ed.min) / (ed.max - ed.min))
+	}
+	ed.SetText(fmt.Sprintf("%.4g", ed.value()))
+	if ed.root != nil {
+		ed.root.SetKeyFocus(ed.Slider)
+	}
+}
+
+// Value satisfies the TableEditor interface
+func (ed *TableSliderEditor) Value() (interface{}, error) {
+
+	v := float64(ed.value())
+	if ed.old == nil {
+		return float32(v), nil
+	}
+	rv := reflect.ValueOf(ed.old)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		v = math.Round(v)
+	case reflect.Float32, reflect.Float64:
+	default:
+		return float32(v), nil
+	}
+	return reflect.ValueOf(v).Convert(rv.Type()).Interface(), nil
+}
+
+// value returns the current value of the slider between its minimum and maximum
+func (ed *TableSliderEditor) value() float32 {
+
+	return ed.min + ed.Slider.Value()*(ed.max-ed.min)
+}
+
+// parseTableValue parses the specified text as a value with the same type as the
+// specified previous value if it is a number or bool, otherwise returns the text
+func parseTableValue(text string, old interface{}) (interface{}, error) {
+
+	if old == nil {
+		return text, nil
+	}
+	rv := reflect.ValueOf(old)
+	res := reflect.New(rv.Type()).Elem()
+	text = strings.TrimSpace(text)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		v, err := strconv.ParseInt(text, 10, rv.Type().Bits())
+		if err != nil {
+			return nil, fmt.Errorf("Invalid integer: %q", text)
+		}
+		res.SetInt(v)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		v, err := strconv.ParseUint(text, 10, rv.Type().Bits())
+		if err != nil {
+			return nil, fmt.Errorf("Invalid unsigned integer: %q", text)
+		}
+		res.SetUint(v)
+	case reflect.Float32, reflect.Float64:
+		v, err := strconv.ParseFloat(text, rv.Type().Bits())
+		if err != nil {
+			return nil, fmt.Errorf("Invalid number: %q", text)
+		}
+		res.SetFloat(v)
+	case reflect.Bool:
+		v, err := strconv.ParseBool(text)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid boolean: %q", text)
+		}
+		res.SetBool(v)
+	default:
+		return text, nil
+	}
+	return res.Interface(), nil
+}
@@ -0,0 +1,286 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gui
+
+import (
+	"fmt"
+	"sort"
+)
+
+// A table with a model is a virtual table: its rows are not stored in the table
+// but fetched from the model only when they are visible, using a small number of
+// row panels which are recycled while scrolling. This allows tables with millions of rows.
+// All the rows of virtual tables have the height of a single line of text and their
+// cells show the formatted cell values as text.
+// The row indexes used by the methods and events of virtual tables are model row indexes,
+// including the indexes of the selected rows which are kept when the table sorts an
+// index of the model rows (the selection is removed when a TableModelSorter sorts them).
+
+// TableModel is the interface for the data models of virtual tables
+type TableModel interface {
+	RowCount() int                          // Returns the current number of rows
+	Cell(row int, colid string) interface{} // Returns the value of the cell at the specified row and column id
+}
+
+// TableModelSorter is the interface for table models which sort their own rows.
+// If the model of a virtual table implements this interface, it is used by the
+// table to sort its rows instead of sorting an index of the model rows.
+// As the table cannot know where the rows were moved, its selection is
+// removed when the model sorts its rows.
+type TableModelSorter interface {
+	SortRows(colid string, asString, asc bool) // Sorts the model rows by the specified column
+}
+
+// tableSort describes the last sort of a virtual table
+type tableSort struct {
+	col      string // column id
+	asString bool   // sort values as strings
+	asc      bool   // ascending order
+}
+
+// SetModel sets the data model of this table, making it a virtual table,
+// or returns it to a normal table if the model is nil.
+// All current rows and the selection are removed.
+// ModelChanged must be called when the rows of the model change.
+func (t *Table) SetModel(m TableModel) {
+
+	t.disposeRows(t.rows)
+	t.disposeRows(t.mrows)
+	t.rows = nil
+	t.mrows = nil
+	t.model = m
+	t.mindex = nil
+	t.msel = make(map[int]bool)
+	t.msort = nil
+	t.mcount = t.RowCount()
+	t.firstRow = 0
+	t.rowCursor = -1
+	t.recalc()
+	t.Dispatch(OnTableRowCount, nil)
+}
+
+// Model returns the data model of this virtual table or nil
+func (t *Table) Model() TableModel {
+
+	return t.model
+}
+
+// ModelChanged updates this virtual table after rows of its model changed.
+// A sorted table is sorted again, the selection of rows beyond the
+// new row count is removed and if the last row was visible the table is scrolled
+// to keep it visible, so appended rows are shown.
+func (t *Table) ModelChanged() {
+
+	if t.model == nil {
+		return
+	}
+	count := t.model.RowCount()
+	tail := t.mcount == 0 || t.lastRow >= t.mcount-1
+	for mi := range t.msel {
+		if mi >= count {
+			delete(t.msel, mi)
+		}
+	}
+	if t.rowCursor >= count {
+		t.rowCursor = count - 1
+	}
+	if t.msort != nil {
+		t.sortModel()
+	}
+	if tail {
+		t.firstRow = t.calcMaxFirst()
+	} else if max := t.calcMaxFirst(); t.firstRow > max {
+		t.firstRow = max
+	}
+	t.recalc()
+	if count != t.mcount {
+		t.mcount = count
+		t.Dispatch(OnTableRowCount, nil)
+	}
+}
+
+// modelRow returns the model row shown by the specified table row of a virtual table
+func (t *Table) modelRow(ri int) int {
+
+	if t.mindex != nil && ri < len(t.mindex) {
+		return t.mindex[ri]
+	}
+	return ri
+}
+
+// selectedModelRows returns the sorted indexes of the selected model rows
+func (t *Table) selectedModelRows() []int {
+
+	res := make([]int, 0, len(t.msel))
+	for mi := range t.msel {
+		res = append(res, mi)
+	}
+	sort.Ints(res)
+	return res
+}
+
+// selectModelRows is the virtual table version of SelectRows
+func (t *Table) selectModelRows(rows []int) bool {
+
+	count := t.model.RowCount()
+	sel := make(map[int]bool)
+	for i := len(rows) - 1; i >= 0; i-- {
+		if rows[i] < 0 || rows[i] >= count {
+			continue
+		}
+		sel[rows[i]] = true
+		// Only the last specified valid row is selected in single row mode
+		if t.selType == TableSelSingleRow {
+			break
+		}
+	}
+	t.msel = sel
+	t.recalc()
+	t.Dispatch(OnChange, nil)
+	return len(sel) > 0
+}
+
+// setModelSel sets the selection state of the specified model row
+func (t *Table) setModelSel(mi int, state bool) {
+
+	if t.msel[mi] == state {
+		return
+	}
+	if state {
+		t.msel[mi] = true
+	} else {
+		delete(t.msel, mi)
+	}
+	t.Dispatch(OnChange, nil)
+}
+
+// sortModel sorts the rows of a virtual table as specified by its last sort
+func (t *Table) sortModel() {
+
+	s := t.msort
+	if sorter, ok := t.model.(TableModelSorter); ok {
+		sorter.SortRows(s.col, s.asString, s.asc)
+		t.mindex = nil
+		if len(t.msel) > 0 {
+			t.msel = make(map[int]bool)
+			t.Dispatch(OnChange, nil)
+		}
+		return
+	}
+	c := t.header.cmap[s.col]
+	count := t.model.RowCount()
+	index := make([]int, count)
+	for i := range index {
+		index[i] = i
+	}
+	// The sort keys are calculated only once for each row
+	var less func(i, j int) bool
+	if s.asString {
+		keys := make([]string, count)
+		for i := range keys {
+			keys[i] = fmt.Sprintf(c.format, t.model.Cell(i, c.id))
+		}
+		less = func(i, j int) bool { return keys[index[i]] < keys[index[j]] }
+	} else {
+		keys := make([]float64, count)
+		for i := range keys {
+			keys[i] = cv2f64(t.model.Cell(i, c.id))
+		}
+		less = func(i, j int) bool { return keys[index[i]] < keys[index[j]] }
+	}
+	if s.asc {
+		sort.SliceStable(index, less)
+	} else {
+		sort.SliceStable(index, func(i, j int) bool { return less(j, i) })
+	}
+	t.mindex = index
+}
+
+// modelRowHeight returns the height of the rows of a virtual table
+func (t *Table) modelRowHeight() float32 {
+
+	if len(t.mrows) == 0 {
+		t.mrows = append(t.mrows, t.newModelRow())
+	}
+	trow := t.mrows[0]
+	height := float32(0)
+	for _, c := range t.header.cols {
+		if !c.Visible() {
+			continue
+		}
+		cell := trow.cells[c.order]
+		if h := cell.MinHeight() + cell.label.Height(); h > height {
+			height = h
+		}
+	}
+	return height + trow.MinHeight()
+}
+
+// newModelRow creates and returns a new recycled row panel of a virtual table
+func (t *Table) newModelRow() *tableRow {
+
+	trow := t.newRow(nil)
+	t.styleRow(trow, 0)
+	trow.SetVisible(false)
+	t.Panel.Add(trow)
+	return trow
+}
+
+// recalcModel is the virtual table version of recalc which shows the visible
+// model rows in the recycled row panels
+func (t *Table) recalcModel() {
+
+	starty, theight := t.rowsHeight()
+	count := t.model.RowCount()
+	rh := t.modelRowHeight()
+	t.setVScrollBar(rh > 0 && float32(count)*rh > theight)
+	t.recalcHeader()
+
+	// Sets the model rows in the row panels
+	py := starty
+	t.lastRow = t.firstRow - 1
+	used := 0
+	for ri := t.firstRow; ri < count && py <= starty+theight && rh > 0; ri++ {
+		if used == len(t.mrows) {
+			t.mrows = append(t.mrows, t.newModelRow())
+		}
+		trow := t.mrows[used]
+		used++
+		mi := t.modelRow(ri)
+		t.setModelRow(trow, mi)
+		t.layoutRow(trow, mi)
+		trow.SetPosition(0, py)
+		trow.SetVisible(true)
+		t.styleRow(trow, ri)
+		if py+trow.Height() <= starty+theight {
+			t.lastRow = ri
+		}
+		py += trow.height
+	}
+	// Hides the unused row panels
+	for _, trow := range t.mrows[used:] {
+		trow.SetVisible(false)
+	}
+	t.SetTopChild(&t.statusPanel)
+	t.placeEditor()
+}
+
+// setModelRow sets the cells of the specified row panel from the specified model row
+func (t *Table) setModelRow(trow *tableRow, mi int) {
+
+	for _, c := range t.header.cols {
+		cell := trow.cells[c.order]
+		cell.value = t.model.Cell(mi, c.id)
+		if c.formatFunc != nil {
+			continue
+		}
+		// Avoids rendering the label text again if it did not change
+		text := fmt.Sprintf(c.format, cell.value)
+		if text != cell.label.Text() {
+			cell.label.SetText(text)
+		}
+	}
+	trow.selected = t.msel[mi]
+}
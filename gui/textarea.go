@@ -0,0 +1,754 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gui
+
+import (
+	"github.com/sansebasko/engine/math32"
+	"github.com/sansebasko/engine/window"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// TextArea is a multi-line text edit GUI element with optional word wrap,
+// scrolling, text selection, clipboard operations and undo/redo history.
+// It uses the EditStyles from the TextArea field of the current style.
+type TextArea struct {
+	Scroller                  // Embedded scroller
+	MaxLength  int            // Maximum number of characters (0 for no limit)
+	label      *Label         // label which shows the text and is scrolled
+	buf        editBuffer     // text, selection and history
+	lines      []textAreaLine // visual lines of the text after word wrap
+	wrap       bool           // word wrap flag
+	readOnly   bool           // read only flag
+	focus      bool           // key focus flag
+	cursorOver bool           // cursor is over the text area
+	dragging   bool           // selecting text with the mouse
+	blinkID    int            // caret blink timer id
+	caretOn    bool           // caret blink state
+	goalX      int            // caret x coordinate kept when moving up and down (-1 if not set)
+	composing  bool           // an input method is composing text
+	preedit    string         // text being composed by the input method
+	preeditPos int            // position of the input method cursor in the composed text
+	styles     *EditStyles    // current styles
+	curStyle   *EditStyle     // currently applied style
+}
+
+// textAreaLine is a visual line of a TextArea containing the runes from start up to end
+type textAreaLine struct {
+	start int
+	end   int
+}
+
+const (
+	textAreaMarginX = 4
+)
+
+// NewTextArea creates and returns a pointer to a new text area with the specified size
+func NewTextArea(width, height float32) *TextArea {
+
+	ta := new(TextArea)
+	ta.label = NewLabel("")
+	ta.label.SetPaddings(0, 0, 0, 0)
+	ta.Scroller.initialize(width, height, ScrollVertical, ta.label)
+	ta.Scroller.hoverKeyFocus = false
+	ta.wrap = true
+	ta.goalX = -1
+	ta.styles = &StyleDefault().TextArea
+
+	ta.Subscribe(OnKeyDown, ta.onKey)
+	ta.Subscribe(OnKeyRepeat, ta.onKey)
+	ta.Subscribe(OnChar, ta.onChar)
+	ta.Subscribe(OnCompositionStart, ta.onComposition)
+	ta.Subscribe(OnCompositionUpdate, ta.onComposition)
+	ta.Subscribe(OnCompositionCommit, ta.onComposition)
+	ta.Subscribe(OnMouseDown, ta.onMouse)
+	ta.Subscribe(OnMouseUp, ta.onMouse)
+	ta.Subscribe(OnCursor, ta.onCursor)
+	ta.Subscribe(OnCursorEnter, ta.onCursor)
+	ta.Subscribe(OnCursorLeave, ta.onCursor)
+	ta.Subscribe(OnResize, func(evname string, ev interface{}) { ta.redraw(ta.focus) })
+	ta.Subscribe(OnScale, func(evname string, ev interface{}) { ta.redraw(ta.focus) })
+	ta.Subscribe(OnEnable, func(evname string, ev interface{}) { ta.update() })
+	ta.Subscribe(OnFocus, func(evname string, ev interface{}) { ta.setFocus() })
+	ta.SetFocusable(true)
+
+	ta.update()
+	return ta
+}
+
+// SetText sets the text of this text area and clears the undo history
+func (ta *TextArea) SetText(text string) *TextArea {
+
+	ta.buf.setText(strings.Replace(text, "\r", "", -1))
+	ta.goalX = -1
+	ta.redraw(ta.focus)
+	return ta
+}
+
+// Text returns the current text
+func (ta *TextArea) Text() string {
+
+	return ta.buf.String()
+}
+
+// AppendText appends the specified text at the end of the current text,
+// moves the cursor to the end and scrolls to show it.
+// It is useful for log and console output.
+func (ta *TextArea) AppendText(text string) {
+
+	ta.buf.setPos(ta.buf.len(), false)
+	ta.buf.insert(strings.Replace(text, "\r", "", -1), false)
+	ta.goalX = -1
+	ta.redraw(ta.focus)
+	ta.Dispatch(OnChange, nil)
+}
+
+// SetWrap sets if the lines longer than the text area width are wrapped
+// at word boundaries. If disabled, the text area also scrolls horizontally.
+func (ta *TextArea) SetWrap(state bool) {
+
+	ta.wrap = state
+	if state {
+		ta.Scroller.SetScrollMode(ScrollVertical)
+	} else {
+		ta.Scroller.SetScrollMode(ScrollBoth)
+	}
+	ta.redraw(ta.focus)
+}
+
+// Wrap returns if word wrap is enabled
+func (ta *TextArea) Wrap() bool {
+
+	return ta.wrap
+}
+
+// SetReadOnly sets if the text can only be selected and copied by the user
+func (ta *TextArea) SetReadOnly(state bool) {
+
+	ta.readOnly = state
+}
+
+// ReadOnly returns if the text area is read only
+func (ta *TextArea) ReadOnly() bool {
+
+	return ta.readOnly
+}
+
+// SetFontSize sets the point size of the font
+func (ta *TextArea) SetFontSize(size float64) *TextArea {
+
+	ta.label.SetFontSize(size)
+	ta.redraw(ta.focus)
+	return ta
+}
+
+// SetStyles sets the text area styles overriding the default style
+func (ta *TextArea) SetStyles(es *EditStyles) {
+
+	ta.styles = es
+	ta.update()
+}
+
+// LostKeyFocus satisfies the IPanel interface and is called by gui root
+// container when the panel loses the key focus
+func (ta *TextArea) LostKeyFocus() {
+
+	ta.focus = false
+	ta.composing = false
+	ta.preedit = ""
+	ta.update()
+	ta.root.ClearTimeout(ta.blinkID)
+}
+
+// CursorPos moves the cursor to the specified character position
+func (ta *TextArea) CursorPos(pos int) {
+
+	ta.moveCursor(pos, false)
+}
+
+// Cursor returns the character position of the cursor
+func (ta *TextArea) Cursor() int {
+
+	return ta.buf.pos
+}
+
+// CursorInput inserts the specified string at the current cursor position
+// replacing the selected text if any
+func (ta *TextArea) CursorInput(s string) {
+
+	ta.input(s, true)
+}
+
+// SetSelection selects the text between the specified start and end
+// character positions and moves the cursor to the end position
+func (ta *TextArea) SetSelection(start, end int) {
+
+	ta.buf.setSelection(start, end)
+	ta.goalX = -1
+	ta.redraw(ta.focus)
+}
+
+// Selection returns the start and end character positions of the selected text.
+// Both are equal to the cursor position if there is no selection.
+func (ta *TextArea) Selection() (start, end int) {
+
+	return ta.buf.selection()
+}
+
+// SelectedText returns the selected text
+func (ta *TextArea) SelectedText() string {
+
+	return ta.buf.selectedText()
+}
+
+// SelectAll selects all the text
+func (ta *TextArea) SelectAll() {
+
+	ta.SetSelection(0, ta.buf.len())
+}
+
+// Copy copies the selected text to the clipboard
+func (ta *TextArea) Copy() {
+
+	if ta.buf.hasSelection() && ta.root != nil {
+		setClipboardString(ta.root.Window(), ta.buf.selectedText())
+	}
+}
+
+// Cut copies the selected text to the clipboard and deletes it
+func (ta *TextArea) Cut() {
+
+	if ta.buf.hasSelection() && !ta.readOnly {
+		ta.Copy()
+		ta.erase(false)
+	}
+}
+
+// Paste inserts the text from the clipboard at the cursor position
+// replacing the selected text if any
+func (ta *TextArea) Paste() {
+
+	if ta.root == nil {
+		return
+	}
+	ta.input(strings.Replace(clipboardString(ta.root.Window()), "\r", "", -1), false)
+}
+
+// Undo undoes the last change to the text
+func (ta *TextArea) Undo() {
+
+	if !ta.readOnly && ta.buf.undoChange() {
+		ta.changed()
+	}
+}
+
+// Redo redoes the last undone change to the text
+func (ta *TextArea) Redo() {
+
+	if !ta.readOnly && ta.buf.redoChange() {
+		ta.changed()
+	}
+}
+
+// input inserts the specified string at the cursor position
+// if the resulting text does not exceed the maximum length
+func (ta *TextArea) input(s string, typed bool) {
+
+	if ta.readOnly {
+		return
+	}
+	if ta.MaxLength > 0 {
+		start, end := ta.buf.selection()
+		if ta.buf.len()-(end-start)+len([]rune(s)) > ta.MaxLength {
+			return
+		}
+	}
+	ta.buf.insert(s, typed)
+	ta.changed()
+}
+
+// erase deletes the selected text or the character after or before the cursor
+func (ta *TextArea) erase(forward bool) {
+
+	if !ta.readOnly && ta.buf.erase(forward) {
+		ta.changed()
+	}
+}
+
+// changed redraws the text after it was changed and dispatches OnChange
+func (ta *TextArea) changed() {
+
+	ta.goalX = -1
+	ta.redraw(ta.focus)
+	ta.Dispatch(OnChange, nil)
+}
+
+// moveCursor moves the cursor to the specified character position
+// extending the selection if specified
+func (ta *TextArea) moveCursor(pos int, extend bool) {
+
+	ta.buf.setPos(pos, extend)
+	ta.goalX = -1
+	ta.redraw(ta.focus)
+}
+
+// moveLines moves the cursor the specified number of visual lines up (negative) or down
+// keeping its horizontal position, extending the selection if specified
+func (ta *TextArea) moveLines(count int, extend bool) {
+
+	line, col := ta.linePos(ta.buf.pos)
+	if ta.goalX < 0 {
+		ta.goalX = ta.lineX(line, col)
+	}
+	goalX := ta.goalX
+	line += count
+	if line < 0 {
+		ta.buf.setPos(0, extend)
+	} else if line >= len(ta.lines) {
+		ta.buf.setPos(ta.buf.len(), extend)
+	} else {
+		ta.buf.setPos(ta.posAt(line, goalX), extend)
+	}
+	ta.goalX = goalX
+	ta.redraw(ta.focus)
+}
+
+// pageLines returns the number of visual lines which fit in the view area
+func (ta *TextArea) pageLines() int {
+
+	_, viewHeight := ta.Scroller.viewSize()
+	_, lineHeight := ta.label.font.LineBounds(0)
+	height := int(viewHeight * ta.label.scale)
+	if lineHeight <= 0 || height < lineHeight {
+		return 1
+	}
+	return height / lineHeight
+}
+
+// lineString returns the text of the specified visual line
+func (ta *TextArea) lineString(line int) string {
+
+	l := ta.lines[line]
+	return string(ta.buf.text[l.start:l.end])
+}
+
+// linePos returns the visual line and column of the specified character position
+func (ta *TextArea) linePos(pos int) (line, col int) {
+
+	line = sort.Search(len(ta.lines), func(i int) bool { return ta.lines[i].start > pos }) - 1
+	if line < 0 {
+		line = 0
+	}
+	return line, pos - ta.lines[line].start
+}
+
+// lineX returns the x coordinate in pixels of the text layout of the specified visual line and column
+func (ta *TextArea) lineX(line, col int) int {
+
+	s := []rune(ta.lineString(line))
+	if col > len(s) {
+		col = len(s)
+	}
+	width, _ := ta.label.font.MeasureText(string(s[:col]))
+	return width
+}
+
+// posAt returns the character position closest to the specified
+// x coordinate in pixels of the text layout of the specified visual line
+func (ta *TextArea) posAt(line, x int) int {
+
+	_, col := ta.label.font.TextPos(ta.lineString(line), x, 0)
+	return ta.lines[line].start + col
+}
+
+// posAtScreen returns the character position closest to the specified screen coordinates
+func (ta *TextArea) posAtScreen(x, y float32) int {
+
+	ta.setFontAttributes()
+	cx, cy := ta.label.ContentCoords(x, y)
+	if cy < 0 {
+		return 0
+	}
+	visual := make([]string, len(ta.lines))
+	for i := range ta.lines {
+		visual[i] = ta.lineString(i)
+	}
+	tx := int((cx - textAreaMarginX) * ta.label.scale)
+	line, _ := ta.label.font.TextPos(strings.Join(visual, "\n"), tx, int(cy*ta.label.scale))
+	return ta.posAt(line, tx)
+}
+
+// setFontAttributes sets the attributes of the shared font before measuring text
+func (ta *TextArea) setFontAttributes() {
+
+	ta.label.setFontAttributes()
+}
+
+// wrapWidth returns the maximum width in pixels of the visual lines
+func (ta *TextArea) wrapWidth() int {
+
+	width := ta.ContentWidth() - 2*textAreaMarginX
+	if !ta.Scroller.style.VerticalScrollbar.OverlapContent {
+		width -= ta.Scroller.style.VerticalScrollbar.Broadness
+	}
+	if width < 1 {
+		return 1
+	}
+	return int(width)
+}
+
+// wrapLines breaks the text into visual lines at the line breaks and,
+// if word wrap is enabled, at the last word boundary which fits the wrap width.
+func (ta *TextArea) wrapLines() {
+
+	ta.setFontAttributes()
+	maxWidth := int(float32(ta.wrapWidth()) * ta.label.scale)
+	text := ta.buf.text
+	width := func(start, end int) int {
+		w, _ := ta.label.font.MeasureText(string(text[start:end]))
+		return w
+	}
+	ta.lines = ta.lines[0:0]
+	for start := 0; start <= len(text); {
+		end := start
+		for end < len(text) && text[end] != '\n' {
+			end++
+		}
+		ls := start
+		for ta.wrap && end-ls > 1 && width(ls, end) > maxWidth {
+			// Finds the longest prefix which fits (at least one character)
+			fit := ls + 1 + sort.Search(end-ls-1, func(i int) bool { return width(ls, ls+i+2) > maxWidth })
+			// Breaks after the last space of the prefix, if any
+			brk := fit
+			for i := fit; i > ls; i-- {
+				if unicode.IsSpace(text[i-1]) {
+					brk = i
+					break
+				}
+			}
+			ta.lines = append(ta.lines, textAreaLine{ls, brk})
+			ls = brk
+		}
+		ta.lines = append(ta.lines, textAreaLine{ls, end})
+		start = end + 1
+	}
+}
+
+// redraw wraps and redraws the text showing the caret if specified
+// and scrolls the view to show the caret if the text area has the key focus
+func (ta *TextArea) redraw(caret bool) {
+
+	if ta.curStyle == nil {
+		return
+	}
+	ta.wrapLines()
+
+	// Builds the text of the visual lines
+	visual := make([]string, len(ta.lines))
+	maxWidth := 0
+	for i := range ta.lines {
+		visual[i] = ta.lineString(i)
+		if !ta.wrap {
+			w, _ := ta.label.font.MeasureText(visual[i])
+			if w > maxWidth {
+				maxWidth = w
+			}
+		}
+	}
+	maxWidth = int(math32.Ceil(float32(maxWidth) / ta.label.scale))
+	width := ta.wrapWidth()
+	if !ta.wrap && maxWidth+1 > width {
+		width = maxWidth + 1
+	}
+
+	// Draws the text with the caret and the selection
+	line, col := ta.linePos(ta.buf.pos)
+	caretLine := line
+	if !caret {
+		caretLine = -1
+	}
+	var sel *textSelection
+	if ta.preedit != "" {
+		// Shows the text being composed underlined at the cursor, without wrapping it
+		runes := []rune(visual[line])
+		visual[line] = string(runes[:col]) + ta.preedit + string(runes[col:])
+		count := len([]rune(ta.preedit))
+		sel = &textSelection{line1: line, col1: col, line2: line, col2: col + count, underline: true}
+		if ta.preeditPos >= 0 && ta.preeditPos <= count {
+			col += ta.preeditPos
+		} else {
+			col += count
+		}
+	} else if ta.focus && ta.buf.hasSelection() {
+		start, end := ta.buf.selection()
+		line1, col1 := ta.linePos(start)
+		line2, col2 := ta.linePos(end)
+		sel = &textSelection{line1: line1, col1: col1, line2: line2, col2: col2, color: ta.curStyle.SelColor}
+	}
+	ta.label.setTextCaret(strings.Join(visual, "\n"), textAreaMarginX, width+2*textAreaMarginX, caretLine, col, sel)
+	ta.Scroller.Update()
+
+	// Scrolls to show the caret
+	if ta.focus {
+		top, bottom := ta.label.font.LineBounds(line)
+		scale := ta.label.scale
+		x := float32(ta.lineX(line, col)) / scale
+		ta.Scroller.scrollIntoView(x, float32(top)/scale, x+2*textAreaMarginX, float32(bottom)/scale)
+		ta.setCompositionRect(line, col)
+	}
+}
+
+// setCompositionRect sets the rectangle of the caret at the specified line and
+// column in the window, next to which input methods show their candidate window
+func (ta *TextArea) setCompositionRect(line, col int) {
+
+	if ta.root == nil {
+		return
+	}
+	x, _ := ta.label.layout.Caret(ta.label.layout.Index(line, col))
+	top, bottom := ta.label.font.LineBounds(line)
+	scale := guiScale.value
+	px := ta.label.pospix.X + ta.label.content.X + float32(textAreaMarginX) + float32(x)/ta.label.scale
+	py := ta.label.pospix.Y + ta.label.content.Y + float32(top)/ta.label.scale
+	ta.root.setCompositionRect(int(px*scale), int(py*scale), 1, int(float32(bottom-top)/ta.label.scale*scale))
+}
+
+// onKey receives subscribed key events
+func (ta *TextArea) onKey(evname string, ev interface{}) {
+
+	// Keys are handled by the input method while it composes text
+	if !ta.focus || ta.composing {
+		return
+	}
+	kev := ev.(*window.KeyEvent)
+	switch editShortcutOf(kev) {
+	case editSelectAll:
+		ta.SelectAll()
+	case editCopy:
+		ta.Copy()
+	case editCut:
+		ta.Cut()
+	case editPaste:
+		ta.Paste()
+	case editUndo:
+		ta.Undo()
+	case editRedo:
+		ta.Redo()
+	default:
+		ta.setFontAttributes()
+		shift := kev.Mods&window.ModShift != 0
+		ctrl := kev.Mods&window.ModControl != 0
+		switch kev.Keycode {
+		case window.KeyLeft:
+			if ctrl {
+				ta.moveCursor(ta.buf.wordLeft(ta.buf.pos), shift)
+			} else if ta.buf.hasSelection() && !shift {
+				start, _ := ta.buf.selection()
+				ta.moveCursor(start, false)
+			} else {
+				ta.moveCursor(ta.buf.pos-1, shift)
+			}
+		case window.KeyRight:
+			if ctrl {
+				ta.moveCursor(ta.buf.wordRight(ta.buf.pos), shift)
+			} else if ta.buf.hasSelection() && !shift {
+				_, end := ta.buf.selection()
+				ta.moveCursor(end, false)
+			} else {
+				ta.moveCursor(ta.buf.pos+1, shift)
+			}
+		case window.KeyUp:
+			ta.moveLines(-1, shift)
+		case window.KeyDown:
+			ta.moveLines(1, shift)
+		case window.KeyPageUp:
+			ta.moveLines(-ta.pageLines(), shift)
+		case window.KeyPageDown:
+			ta.moveLines(ta.pageLines(), shift)
+		case window.KeyHome:
+			if ctrl {
+				ta.moveCursor(0, shift)
+			} else {
+				line, _ := ta.linePos(ta.buf.pos)
+				ta.moveCursor(ta.lines[line].start, shift)
+			}
+		case window.KeyEnd:
+			if ctrl {
+				ta.moveCursor(ta.buf.len(), shift)
+			} else {
+				line, _ := ta.linePos(ta.buf.pos)
+				end := ta.lines[line].end
+				// Keeps the cursor before the space where a wrapped line was broken
+				if line+1 < len(ta.lines) && ta.lines[line+1].start == end && end > ta.lines[line].start {
+					end--
+				}
+				ta.moveCursor(end, shift)
+			}
+		case window.KeyBackspace:
+			if ctrl && !ta.buf.hasSelection() {
+				ta.buf.setPos(ta.buf.wordLeft(ta.buf.pos), true)
+			}
+			ta.erase(false)
+		case window.KeyDelete:
+			if ctrl && !ta.buf.hasSelection() {
+				ta.buf.setPos(ta.buf.wordRight(ta.buf.pos), true)
+			}
+			ta.erase(true)
+		case window.KeyEnter, window.KeyKPEnter:
+			ta.input("\n", false)
+		default:
+			return
+		}
+	}
+	ta.root.StopPropagation(Stop3D)
+}
+
+// onChar receives subscribed char events
+func (ta *TextArea) onChar(evname string, ev interface{}) {
+
+	if !ta.focus {
+		return
+	}
+	cev := ev.(*window.CharEvent)
+	ta.CursorInput(string(cev.Char))
+}
+
+// onComposition receives subscribed text composition events of input methods
+func (ta *TextArea) onComposition(evname string, ev interface{}) {
+
+	if !ta.focus || ta.readOnly {
+		return
+	}
+	cev := ev.(*window.CompositionEvent)
+	switch evname {
+	case OnCompositionStart, OnCompositionUpdate:
+		// The composed text replaces the selected text
+		if !ta.composing && ta.buf.hasSelection() {
+			ta.erase(false)
+		}
+		ta.composing = true
+		ta.preedit = cev.Text
+		ta.preeditPos = cev.Cursor
+		ta.redraw(ta.focus)
+	case OnCompositionCommit:
+		ta.composing = false
+		ta.preedit = ""
+		if cev.Text != "" {
+			ta.input(cev.Text, true)
+		}
+		ta.redraw(ta.focus)
+	}
+	ta.root.StopPropagation(Stop3D)
+}
+
+// onMouse receives subscribed mouse events
+func (ta *TextArea) onMouse(evname string, ev interface{}) {
+
+	e := ev.(*window.MouseEvent)
+	if e.Button != window.MouseButtonLeft {
+		return
+	}
+
+	// Stops selecting text
+	if evname == OnMouseUp {
+		if ta.dragging {
+			ta.dragging = false
+			ta.root.SetMouseFocus(nil)
+		}
+		return
+	}
+
+	// Ignores clicks over the scrollbars
+	if (ta.vscroll != nil && ta.vscroll.Visible() && ta.vscroll.InsideBorders(e.Xpos, e.Ypos)) ||
+		(ta.hscroll != nil && ta.hscroll.Visible() && ta.hscroll.InsideBorders(e.Xpos, e.Ypos)) {
+		return
+	}
+
+	// Set key focus to this panel
+	ta.setFocus()
+
+	// Moves the cursor to the clicked position, extending the selection if shift is pressed,
+	// and starts selecting text while the mouse button is down
+	ta.moveCursor(ta.posAtScreen(e.Xpos, e.Ypos), e.Mods&window.ModShift != 0)
+	ta.dragging = true
+	ta.root.SetMouseFocus(ta)
+	ta.root.StopPropagation(Stop3D)
+}
+
+// setFocus sets the key focus to this text area and starts blinking the caret
+func (ta *TextArea) setFocus() {
+
+	ta.root.SetKeyFocus(ta)
+	if !ta.focus {
+		ta.focus = true
+		ta.blinkID = ta.root.SetInterval(750*time.Millisecond, nil, ta.blink)
+		ta.update()
+	}
+}
+
+// onCursor receives subscribed cursor events
+func (ta *TextArea) onCursor(evname string, ev interface{}) {
+
+	switch evname {
+	case OnCursor:
+		if ta.dragging {
+			cev := ev.(*window.CursorEvent)
+			ta.moveCursor(ta.posAtScreen(cev.Xpos, cev.Ypos), true)
+		}
+		return
+	case OnCursorEnter:
+		ta.root.SetCursorText()
+		ta.cursorOver = true
+	case OnCursorLeave:
+		ta.root.SetCursorNormal()
+		ta.cursorOver = false
+	}
+	ta.update()
+	ta.root.StopPropagation(Stop3D)
+}
+
+// blink blinks the caret
+func (ta *TextArea) blink(arg interface{}) {
+
+	if !ta.focus {
+		return
+	}
+	ta.caretOn = !ta.caretOn
+	ta.redraw(ta.caretOn)
+}
+
+// update updates the visual state
+func (ta *TextArea) update() {
+
+	if !ta.Enabled() {
+		ta.applyStyle(&ta.styles.Disabled)
+		return
+	}
+	if ta.cursorOver {
+		ta.applyStyle(&ta.styles.Over)
+		return
+	}
+	if ta.focus {
+		ta.applyStyle(&ta.styles.Focus)
+		return
+	}
+	ta.applyStyle(&ta.styles.Normal)
+}
+
+// applyStyle applies the specified style
+func (ta *TextArea) applyStyle(s *EditStyle) {
+
+	ta.curStyle = s
+	ta.SetBordersFrom(&s.Border)
+	ta.SetBordersColor4(&s.BorderColor)
+	ta.SetPaddingsFrom(&s.Paddings)
+	ta.Scroller.SetColor4(&s.BgColor)
+	ta.label.style.FgColor = s.FgColor
+	ta.label.style.BgColor = s.BgColor
+	ta.label.Panel.SetColor4(&s.BgColor)
+	ta.redraw(ta.focus)
+}
@@ -0,0 +1,644 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gui
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/sansebasko/engine/gui/assets/icon"
+	"github.com/sansebasko/engine/math32"
+	"github.com/sansebasko/engine/text"
+	"gopkg.in/yaml.v2"
+)
+
+// A theme is a description in YAML (or JSON) format of the changes to apply to
+// a base style. Its keys are the names of the Style fields in any case and
+// nested maps set the fields of the nested styles, for example:
+//
+//	base: dark                  # optional base theme: dark, light or theme file
+//	font: fonts/Roboto.ttf      # font file relative to the theme file or
+//	                            # list of files: [fonts/Roboto.ttf, fonts/NotoSansCJK.ttc]
+//	color:
+//	  highlight: "#4b6eaf"
+//	button:
+//	  normal:
+//	    bgcolor: steelblue      # color name with optional alpha: "steelblue 0.5"
+//	    bordercolor: [0, 0, 0]  # 3 or 4 color components
+//	    padding: 2 4 2 4        # 1 or 4 sizes
+//	  over:
+//	    bgcolor: $highlight     # reference to a field of the color section
+//	scroller:
+//	  verticalscrollbar:
+//	    position: left          # named constants of enumerated types
+//	folder:
+//	  normal:
+//	    icons: [ExpandMore, ExpandLess]
+
+// ThemeError is the error returned for invalid theme descriptions
+type ThemeError struct {
+	File string // theme file name (empty if parsed from a string)
+	Key  string // dot separated path of the invalid key
+	Msg  string // error message
+}
+
+// Error satisfies the error interface
+func (e *ThemeError) Error() string {
+
+	if e.File == "" {
+		return fmt.Sprintf("Theme key:%s -> %s", e.Key, e.Msg)
+	}
+	return fmt.Sprintf("Theme file:%s key:%s -> %s", e.File, e.Key, e.Msg)
+}
+
+// themeEnums maps enumerated types of style fields to their value names
+var themeEnums = map[reflect.Type]map[string]int64{
+	reflect.TypeOf(text.HintingNone): {
+		"none":     int64(text.HintingNone),
+		"vertical": int64(text.HintingVertical),
+		"full":     int64(text.HintingFull),
+	},
+	reflect.TypeOf(ScrollbarLeft): {
+		"left":   int64(ScrollbarLeft),
+		"right":  int64(ScrollbarRight),
+		"top":    int64(ScrollbarTop),
+		"bottom": int64(ScrollbarBottom),
+	},
+	reflect.TypeOf(ScrollbarInterlockingNone): {
+		"none":       int64(ScrollbarInterlockingNone),
+		"vertical":   int64(ScrollbarInterlockingVertical),
+		"horizontal": int64(ScrollbarInterlockingHorizontal),
+	},
+}
+
+var (
+	typeColor4     = reflect.TypeOf(math32.Color4{})
+	typeRectBounds = reflect.TypeOf(RectBounds{})
+	typeFont       = reflect.TypeOf((*text.Font)(nil))
+)
+
+// themeParser applies a theme description to a style
+type themeParser struct {
+	style   *Style          // style being changed
+	file    string          // theme file name
+	dir     string          // directory for relative file names
+	base    *Style          // base style if not specified in the description
+	visited map[string]bool // theme files being loaded
+}
+
+// ParseTheme parses a theme description in YAML or JSON format and returns
+// a new style with the changes of the theme applied to a copy of its base style.
+// The base style is specified by the "base" key of the description or
+// by the specified style, or is the current default style if nil.
+func ParseTheme(base *Style, desc string) (*Style, error) {
+
+	tp := &themeParser{base: base, visited: make(map[string]bool)}
+	return tp.parse(desc)
+}
+
+// LoadTheme reads and parses a theme file in YAML or JSON format.
+// Fonts and base theme files are relative to the directory of the theme file.
+// See ParseTheme.
+func LoadTheme(base *Style, fpath string) (*Style, error) {
+
+	tp := &themeParser{base: base, visited: make(map[string]bool)}
+	return tp.load(fpath)
+}
+
+// SetTheme copies the specified style to the default style and restyles the
+// panels of all the root panels which were not disposed, including their open
+// popups, and the specified panels, such as panels not attached to a root panel,
+// and all their descendants. The panels using the default styles and fonts
+// use the new ones. Labels whose font attributes and color were not changed
+// from the previous default label style use the new ones.
+func SetTheme(s *Style, panels ...IPanel) {
+
+	old := *defaultStyle
+	if s != defaultStyle {
+		newStyleCopier(defaultStyle, s).copy(reflect.ValueOf(defaultStyle).Elem(), reflect.ValueOf(s).Elem())
+	}
+	for r := range roots {
+		restyle(r, &old)
+	}
+	for _, ipan := range panels {
+		restyle(ipan, &old)
+	}
+}
+
+// Clone returns a copy of this style which shares only its fonts.
+// References between fields of this style are kept in the copy.
+func (s *Style) Clone() *Style {
+
+	c := new(Style)
+	newStyleCopier(c, s).copy(reflect.ValueOf(c).Elem(), reflect.ValueOf(s).Elem())
+	return c
+}
+
+// load reads and parses the specified theme file
+func (tp *themeParser) load(fpath string) (*Style, error) {
+
+	abs, err := filepath.Abs(fpath)
+	if err != nil {
+		return nil, err
+	}
+	if tp.visited[abs] {
+		return nil, &ThemeError{File: fpath, Key: "base", Msg: "Circular base theme"}
+	}
+	tp.visited[abs] = true
+	data, err := ioutil.ReadFile(fpath)
+	if err != nil {
+		return nil, err
+	}
+	tp.file = fpath
+	tp.dir = filepath.Dir(fpath)
+	return tp.parse(string(data))
+}
+
+// parse parses the specified theme description
+func (tp *themeParser) parse(desc string) (*Style, error) {
+
+	var mii map[interface{}]interface{}
+	err := yaml.Unmarshal([]byte(desc), &mii)
+	if err != nil {
+		return nil, &ThemeError{File: tp.file, Msg: err.Error()}
+	}
+	am, err := tp.stringMap(mii, "")
+	if err != nil {
+		return nil, err
+	}
+
+	// Gets the base style
+	base := tp.base
+	if v, ok := am["base"]; ok {
+		name, ok := v.(string)
+		if !ok {
+			return nil, tp.err("base", "Not a string")
+		}
+		switch name {
+		case "dark":
+			base = NewDarkStyle()
+		case "light":
+			base = NewLightStyle()
+		default:
+			btp := &themeParser{base: tp.base, visited: tp.visited}
+			base, err = btp.load(tp.path(name))
+			if err != nil {
+				return nil, err
+			}
+		}
+		delete(am, "base")
+	}
+	if base == nil {
+		base = StyleDefault()
+	}
+	tp.style = base.Clone()
+
+	// Sets the colors first as they can be referenced by other keys
+	sv := reflect.ValueOf(tp.style).Elem()
+	if v, ok := am["color"]; ok {
+		err = tp.set(sv.FieldByName("Color"), v, "color")
+		if err != nil {
+			return nil, err
+		}
+		delete(am, "color")
+	}
+	err = tp.set(sv, am, "")
+	if err != nil {
+		return nil, err
+	}
+	return tp.style, nil
+}
+
+// stringMap converts recursively the specified YAML map to a map with lower case string keys
+func (tp *themeParser) stringMap(mii map[interface{}]interface{}, key string) (map[string]interface{}, error) {
+
+	am := make(map[string]interface{})
+	for k, v := range mii {
+		ks, ok := k.(string)
+		if !ok {
+			return nil, tp.err(themeKey(key, fmt.Sprint(k)), "Keys must be strings")
+		}
+		ks = strings.ToLower(ks)
+		if vm, ok := v.(map[interface{}]interface{}); ok {
+			var err error
+			v, err = tp.stringMap(vm, themeKey(key, ks))
+			if err != nil {
+				return nil, err
+			}
+		}
+		am[ks] = v
+	}
+	return am, nil
+}
+
+// set sets the specified style value from the specified theme value
+func (tp *themeParser) set(v reflect.Value, value interface{}, key string) error {
+
+	if value == nil {
+		return tp.err(key, "Value not specified")
+	}
+
+	// Types with special formats
+	switch v.Type() {
+	case typeColor4:
+		c, err := tp.color(value)
+		if err != nil {
+			return tp.err(key, err.Error())
+		}
+		v.Set(reflect.ValueOf(c))
+		return nil
+	case typeRectBounds:
+		va, err := themeFloats(value, 1, 4)
+		if err != nil || len(va) == 2 || len(va) == 3 {
+			return tp.err(key, "Invalid bounds: must be 1 or 4 sizes")
+		}
+		if len(va) == 1 {
+			v.Set(reflect.ValueOf(RectBounds{va[0], va[0], va[0], va[0]}))
+		} else {
+			v.Set(reflect.ValueOf(RectBounds{va[0], va[1], va[2], va[3]}))
+		}
+		return nil
+	case typeFont:
+		// A list of font files is a family with fallbacks
+		var names []string
+		switch value := value.(type) {
+		case string:
+			names = []string{value}
+		case []interface{}:
+			for _, item := range value {
+				name, ok := item.(string)
+				if !ok {
+					return tp.err(key, "Not a font file name")
+				}
+				names = append(names, name)
+			}
+		}
+		if len(names) == 0 {
+			return tp.err(key, "Not a font file name")
+		}
+		var fonts []*text.Font
+		for _, name := range names {
+			font, err := text.NewFont(tp.path(name))
+			if err != nil {
+				return tp.err(key, err.Error())
+			}
+			fonts = append(fonts, font)
+		}
+		font := fonts[0]
+		if len(fonts) > 1 {
+			font = text.NewFontFamily(fonts[0], fonts[1:]...)
+		}
+		v.Set(reflect.ValueOf(font))
+		return nil
+	}
+	if names := themeEnums[v.Type()]; names != nil {
+		if name, ok := value.(string); ok {
+			ev, ok := names[strings.ToLower(name)]
+			if !ok {
+				return tp.err(key, fmt.Sprintf("Invalid value:%s", name))
+			}
+			v.SetInt(ev)
+			return nil
+		}
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		am, ok := value.(map[string]interface{})
+		if !ok {
+			return tp.err(key, "Not a map")
+		}
+		// Keys are sorted to always report the same error
+		var keys []string
+		for k := range am {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			f := v.FieldByNameFunc(func(name string) bool { return strings.EqualFold(name, k) })
+			if !f.IsValid() || !f.CanSet() {
+				return tp.err(themeKey(key, k), "Invalid key")
+			}
+			err := tp.set(f, am[k], themeKey(key, k))
+			if err != nil {
+				return err
+			}
+		}
+	case reflect.Ptr:
+		if v.Type().Elem().Kind() != reflect.Struct {
+			return tp.err(key, "Invalid key")
+		}
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		return tp.set(v.Elem(), value, key)
+	case reflect.Bool:
+		b, ok := value.(bool)
+		if !ok {
+			return tp.err(key, "Not a bool")
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, ok := value.(int)
+		if !ok {
+			return tp.err(key, "Not an int")
+		}
+		v.SetInt(int64(i))
+	case reflect.Float32, reflect.Float64:
+		switch fv := value.(type) {
+		case int:
+			v.SetFloat(float64(fv))
+		case float64:
+			v.SetFloat(fv)
+		default:
+			return tp.err(key, "Not a number")
+		}
+	case reflect.String:
+		s, ok := value.(string)
+		if !ok {
+			return tp.err(key, "Not a string")
+		}
+		v.SetString(s)
+	case reflect.Array:
+		// Arrays of icons
+		li, ok := value.([]interface{})
+		if !ok || len(li) != v.Len() || v.Type().Elem().Kind() != reflect.String {
+			return tp.err(key, fmt.Sprintf("Not a list of %d icons", v.Len()))
+		}
+		for i := 0; i < len(li); i++ {
+			cp, err := themeIcon(li[i])
+			if err != nil {
+				return tp.err(key, err.Error())
+			}
+			v.Index(i).SetString(cp)
+		}
+	default:
+		return tp.err(key, "Invalid key")
+	}
+	return nil
+}
+
+// color returns the color specified by a name with optional alpha, a hex value
+// in the form "#rrggbb" or "#rrggbbaa", 3 or 4 components or a reference to a
+// field of the color section of the style in the form "$name"
+func (tp *themeParser) color(value interface{}) (math32.Color4, error) {
+
+	if s, ok := value.(string); ok {
+		s = strings.TrimSpace(s)
+		// Reference to color style field
+		if strings.HasPrefix(s, "$") {
+			f := reflect.ValueOf(&tp.style.Color).Elem().FieldByNameFunc(func(name string) bool {
+				return strings.EqualFold(name, s[1:])
+			})
+			if !f.IsValid() {
+				return math32.Color4{}, fmt.Errorf("Invalid color reference:%s", s)
+			}
+			return f.Interface().(math32.Color4), nil
+		}
+		// Hex value
+		if strings.HasPrefix(s, "#") {
+			hex, err := strconv.ParseUint(s[1:], 16, 32)
+			if err != nil || (len(s) != 7 && len(s) != 9) {
+				return math32.Color4{}, fmt.Errorf("Invalid color hex value:%s", s)
+			}
+			if len(s) == 7 {
+				hex = hex<<8 | 0xFF
+			}
+			return math32.Color4{
+				float32(hex>>24&0xFF) / 255,
+				float32(hex>>16&0xFF) / 255,
+				float32(hex>>8&0xFF) / 255,
+				float32(hex&0xFF) / 255,
+			}, nil
+		}
+		// Color name and optional alpha
+		parts := strings.Fields(s)
+		if len(parts) == 1 || len(parts) == 2 {
+			c, ok := math32.IsColorName(parts[0])
+			if !ok {
+				return math32.Color4{}, fmt.Errorf("Invalid color name:%s", parts[0])
+			}
+			c4 := math32.Color4{c.R, c.G, c.B, 1}
+			if len(parts) == 2 {
+				a, err := strconv.ParseFloat(parts[1], 32)
+				if err != nil {
+					return math32.Color4{}, fmt.Errorf("Invalid alpha value:%s", parts[1])
+				}
+				c4.A = float32(a)
+			}
+			return c4, nil
+		}
+	}
+	va, err := themeFloats(value, 3, 4)
+	if err != nil {
+		return math32.Color4{}, fmt.Errorf("Invalid color: %v", err)
+	}
+	if len(va) == 3 {
+		return math32.Color4{va[0], va[1], va[2], 1}, nil
+	}
+	return math32.Color4{va[0], va[1], va[2], va[3]}, nil
+}
+
+// path returns the specified file name relative to the theme file directory
+func (tp *themeParser) path(name string) string {
+
+	if filepath.IsAbs(name) || tp.dir == "" {
+		return name
+	}
+	return filepath.Join(tp.dir, name)
+}
+
+// err returns a theme error for the specified key
+func (tp *themeParser) err(key, msg string) error {
+
+	return &ThemeError{File: tp.file, Key: key, Msg: msg}
+}
+
+// themeKey returns the path of the specified key in the specified parent path
+func themeKey(parent, key string) string {
+
+	if parent == "" {
+		return key
+	}
+	return parent + "." + key
+}
+
+// themeFloats returns the numbers of the specified list or string of
+// space or comma separated values
+func themeFloats(value interface{}, min, max int) ([]float32, error) {
+
+	var parts []interface{}
+	switch vt := value.(type) {
+	case int, float64:
+		parts = []interface{}{vt}
+	case []interface{}:
+		parts = vt
+	case string:
+		for _, f := range strings.FieldsFunc(vt, func(r rune) bool { return r == ',' || r == ' ' }) {
+			parts = append(parts, f)
+		}
+	}
+	if len(parts) < min || len(parts) > max {
+		return nil, fmt.Errorf("must have %d to %d values", min, max)
+	}
+	values := make([]float32, 0, len(parts))
+	for _, p := range parts {
+		switch pt := p.(type) {
+		case int:
+			values = append(values, float32(pt))
+		case float64:
+			values = append(values, float32(pt))
+		case string:
+			f, err := strconv.ParseFloat(pt, 32)
+			if err != nil {
+				return nil, fmt.Errorf("invalid number:%s", pt)
+			}
+			values = append(values, float32(f))
+		default:
+			return nil, fmt.Errorf("invalid number:%v", pt)
+		}
+	}
+	return values, nil
+}
+
+// themeIcon returns the icon with the specified name or hex codepoint
+func themeIcon(value interface{}) (string, error) {
+
+	name, ok := value.(string)
+	if !ok {
+		return "", fmt.Errorf("Icon is not a string")
+	}
+	if cp := icon.Codepoint(name); cp != "" {
+		return cp, nil
+	}
+	val, err := strconv.ParseUint(name, 16, 32)
+	if err != nil {
+		return "", fmt.Errorf("Invalid icon codepoint value/name:%s", name)
+	}
+	return string(rune(val)), nil
+}
+
+// styleAddr is the address of a style field
+type styleAddr struct {
+	ptr uintptr
+	typ reflect.Type
+}
+
+// styleCopier copies styles keeping the references between fields of the
+// source style and to shared styles as references between the same fields
+// and to shared copies in the destination style.
+// Other referenced styles are copied in place if already allocated in the
+// destination, so panels referencing them see the new values.
+type styleCopier struct {
+	fields    map[styleAddr]reflect.Value // destination values by source field or style address
+	dstFields map[styleAddr]bool          // addresses of destination fields
+}
+
+// newStyleCopier creates and returns a copier for the specified styles
+func newStyleCopier(dst, src *Style) *styleCopier {
+
+	sc := &styleCopier{make(map[styleAddr]reflect.Value), make(map[styleAddr]bool)}
+	sc.register(reflect.ValueOf(dst).Elem(), reflect.ValueOf(src).Elem())
+	return sc
+}
+
+// register saves the addresses of the struct fields of the specified styles
+func (sc *styleCopier) register(dst, src reflect.Value) {
+
+	for i := 0; i < src.NumField(); i++ {
+		df := dst.Field(i)
+		sf := src.Field(i)
+		if sf.Kind() != reflect.Struct {
+			continue
+		}
+		sc.fields[styleAddr{sf.Addr().Pointer(), sf.Type()}] = df
+		sc.dstFields[styleAddr{df.Addr().Pointer(), df.Type()}] = true
+		sc.register(df, sf)
+	}
+}
+
+// copy copies the specified source value to the destination value
+func (sc *styleCopier) copy(dst, src reflect.Value) {
+
+	switch src.Kind() {
+	case reflect.Struct:
+		for i := 0; i < src.NumField(); i++ {
+			sc.copy(dst.Field(i), src.Field(i))
+		}
+	case reflect.Ptr:
+		if src.IsNil() || src.Type() == typeFont || src.Elem().Kind() != reflect.Struct {
+			dst.Set(src)
+			return
+		}
+		if df, ok := sc.fields[styleAddr{src.Pointer(), src.Type().Elem()}]; ok {
+			dst.Set(df.Addr())
+			return
+		}
+		if dst.IsNil() || sc.dstFields[styleAddr{dst.Pointer(), dst.Type().Elem()}] {
+			dst.Set(reflect.New(src.Type().Elem()))
+		}
+		// Other references to the same source style use the same copy
+		sc.fields[styleAddr{src.Pointer(), src.Type().Elem()}] = dst.Elem()
+		sc.copy(dst.Elem(), src.Elem())
+	default:
+		dst.Set(src)
+	}
+}
+
+// restyle updates the specified panel and its descendants after
+// the default style changed from the specified old style
+func restyle(ipan IPanel, old *Style) {
+
+	for _, child := range ipan.GetPanel().Children() {
+		if ichild, ok := child.(IPanel); ok {
+			restyle(ichild, old)
+		}
+	}
+
+	switch p := ipan.(type) {
+	case *Label:
+		restyleLabel(p, old)
+		p.relayout()
+	case *Edit:
+		restyleLabel(&p.Label, old)
+	case *Splitter:
+		if reflect.DeepEqual(p.styles, old.Splitter) {
+			p.styles = defaultStyle.Splitter
+		}
+	case *TabBar:
+		if reflect.DeepEqual(p.styles, old.TabBar) {
+			p.styles = defaultStyle.TabBar
+		}
+	}
+	if u, ok := ipan.(interface{ update() }); ok {
+		u.update()
+	}
+	if r, ok := ipan.(interface{ recalc() }); ok {
+		r.recalc()
+	}
+}
+
+// restyleLabel sets the new default font, font attributes and color
+// of the specified label if it uses the old default ones
+func restyleLabel(l *Label, old *Style) {
+
+	switch l.font {
+	case old.Font:
+		l.font = defaultStyle.Font
+	case old.FontIcon:
+		l.font = defaultStyle.FontIcon
+	}
+	if l.style.FontAttributes == old.Label.FontAttributes {
+		l.style.FontAttributes = defaultStyle.Label.FontAttributes
+	}
+	if l.style.FgColor == old.Label.FgColor {
+		l.style.FgColor = defaultStyle.Label.FgColor
+	}
+}
@@ -5,6 +5,8 @@
 package gui
 
 import (
+	"fmt"
+
 	"github.com/sansebasko/engine/math32"
 	"github.com/sansebasko/engine/window"
 )
@@ -162,6 +164,209 @@ func (t *Tree) FindChild(child IPanel) (*TreeNode, int) {
 	return nil, -1
 }
 
+// Move moves the specified item of the tree, with its children if it is a node,
+// to the specified position in the specified parent node or in the top level
+// of the tree if the parent is nil. Nodes cannot be moved into themselves
+// or into their descendants.
+func (t *Tree) Move(item IPanel, parent *TreeNode, pos int) error {
+
+	oldPar, _ := t.parentOf(item)
+	if oldPar == nil && t.ItemPosition(item) < 0 {
+		return fmt.Errorf("Item not found in tree")
+	}
+	for n := parent; n != nil; n = n.parNode {
+		if n == item {
+			return fmt.Errorf("Cannot move a node into itself")
+		}
+	}
+	count := len(t.topItems())
+	if parent != nil {
+		count = parent.Len()
+	}
+	if oldPar == parent {
+		count--
+	}
+	if pos < 0 || pos > count {
+		return fmt.Errorf("Invalid tree position:%d", pos)
+	}
+	selected := t.Selected() == item
+
+	// Removes the item from its current parent
+	if oldPar != nil {
+		oldPar.Remove(item)
+	} else {
+		t.Remove(item)
+	}
+
+	// Inserts the item in the new parent
+	node, isNode := item.(*TreeNode)
+	if isNode {
+		node.parNode = parent
+	}
+	if parent != nil {
+		parent.InsertAt(pos, item)
+	} else {
+		lpos := t.List.Len()
+		if top := t.topItems(); pos < len(top) {
+			lpos = t.ItemPosition(top[pos])
+		}
+		if isNode {
+			node.insert(lpos)
+		} else {
+			t.List.InsertAt(lpos, item)
+		}
+	}
+	if lpos := t.ItemPosition(item); selected && lpos >= 0 {
+		t.setSelection(t.items[lpos].(*ListItem), true, true, false)
+	}
+	return nil
+}
+
+// SetReorderable sets if the items of the tree can be moved by the user by dragging
+// them before or after other items or into other nodes.
+// OnReorder is dispatched after an item is moved.
+func (t *Tree) SetReorderable(state bool) {
+
+	t.reorder = state
+	t.SetDropTarget(state)
+	if !state {
+		t.SetDragSource(nil)
+		return
+	}
+	t.SetDragSource(t.dragItem)
+	if !t.dragSub {
+		t.dragSub = true
+		t.Subscribe(OnDragOver, t.onDrag)
+		t.Subscribe(OnDrop, t.onDrag)
+	}
+}
+
+// parentOf returns the parent node of the specified item and its position
+// in the parent. If the item is at the top level of the tree returns nil and
+// its position in the top level.
+func (t *Tree) parentOf(item IPanel) (*TreeNode, int) {
+
+	par, pos := t.FindChild(item)
+	if par != nil {
+		return par, pos
+	}
+	for pos, curr := range t.topItems() {
+		if curr == item {
+			return nil, pos
+		}
+	}
+	return nil, -1
+}
+
+// topItems returns the items at the top level of the tree
+func (t *Tree) topItems() []IPanel {
+
+	var items []IPanel
+	for pos := 0; pos < t.List.Len(); pos++ {
+		item := t.List.ItemAt(pos)
+		if par, _ := t.FindChild(item); par == nil {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// dragItem is the drag source function of reorderable trees
+func (t *Tree) dragItem(x, y float32) *DragPayload {
+
+	pos := t.itemPosAt(x, y)
+	if pos < 0 {
+		return nil
+	}
+	item := t.ItemAt(pos)
+	return &DragPayload{Type: "gui.TreeItem", Data: item, Text: dragText(item)}
+}
+
+// dropPos returns the parent node and the position in it where the item of the specified
+// drag event is moved if dropped at the event position and sets the event indicator.
+// Returns false if the item cannot be dropped there.
+func (t *Tree) dropPos(dev *DragEvent, item IPanel) (*TreeNode, int, bool) {
+
+	lpos := t.itemPosAt(dev.X, dev.Y)
+	if lpos < 0 {
+		// Moves to the end of the top level when dropped after the last visible item
+		last := -1
+		for pos := t.first; pos < len(t.items) && t.items[pos].GetPanel().Visible(); pos++ {
+			last = pos
+		}
+		if last < 0 {
+			return nil, 0, false
+		}
+		dev.indicateEdge(t.items[last].GetPanel(), false, true)
+		return nil, len(t.topItems()), true
+	}
+
+	// Drops into a node when over its middle or at the bottom of an expanded node
+	litem := t.items[lpos].(*ListItem)
+	target := litem.item
+	rel := (dev.Y - litem.pospix.Y) / litem.height
+	pad := litem.padLeft
+	var par *TreeNode
+	var pos int
+	node, isNode := target.(*TreeNode)
+	if isNode && (rel >= 0.25 && rel < 0.75 || rel >= 0.75 && node.expanded && node.Len() > 0) {
+		par = node
+		pos = node.Len()
+		if rel >= 0.75 {
+			pos = 0
+		}
+		pad += t.styles.Padlevel
+		dev.indicateEdge(&litem.Panel, false, true)
+	} else {
+		par, pos = t.parentOf(target)
+		if rel >= 0.5 {
+			pos++
+		}
+		dev.indicateEdge(&litem.Panel, false, rel >= 0.5)
+	}
+	dev.rect.X += pad
+	dev.rect.Width -= pad
+	for n := par; n != nil; n = n.parNode {
+		if n == item {
+			return nil, 0, false
+		}
+	}
+	return par, pos, true
+}
+
+// onDrag receives the drag and drop events of reorderable trees
+func (t *Tree) onDrag(evname string, ev interface{}) {
+
+	dev := ev.(*DragEvent)
+	item, ok := dev.Payload.Data.(IPanel)
+	if !t.reorder || !ok || !dragFrom(dev, t) {
+		return
+	}
+	par, to, ok := t.dropPos(dev, item)
+	if !ok {
+		return
+	}
+	switch evname {
+	case OnDragOver:
+		dev.Accept()
+	case OnDrop:
+		oldPar, from := t.parentOf(item)
+		if oldPar == par && to > from {
+			to--
+		}
+		if oldPar == par && to == from {
+			return
+		}
+		if t.Move(item, par, to) != nil {
+			return
+		}
+		if par != nil {
+			par.SetExpanded(true)
+		}
+		t.Dispatch(OnReorder, &ReorderEvent{Item: item, From: from, To: to, Parent: par})
+	}
+}
+
 // onCursor receives subscribed cursor events over the tree
 func (t *Tree) onCursor(evname string, ev interface{}) {
 
@@ -177,22 +382,59 @@ func (t *Tree) onKey(evname string, ev interface{}) {
 	if item == nil {
 		return
 	}
-	// If item is not a tree node, dispatch event to item
+	kev := ev.(*window.KeyEvent)
 	node, ok := item.(*TreeNode)
+	// Left selects the parent node of items and collapsed nodes
+	if evname == OnKeyDown && kev.Keycode == window.KeyLeft && (!ok || !node.expanded) {
+		if par, _ := t.parentOf(item); par != nil {
+			t.selectItem(par)
+		}
+		t.root.StopPropagation(Stop3D)
+		return
+	}
+	// If item is not a tree node, dispatch event to item
 	if !ok {
 		item.SetRoot(t.root)
 		item.GetPanel().Dispatch(evname, ev)
 		return
 	}
-	// If not enter key pressed, ignore
-	kev := ev.(*window.KeyEvent)
-	if evname != OnKeyDown || kev.Keycode != window.KeyEnter {
+	if evname != OnKeyDown {
 		return
 	}
+	switch kev.Keycode {
 	// Toggles the expansion state of the node
-	node.expanded = !node.expanded
-	node.update()
-	node.updateItems()
+	case window.KeyEnter:
+		node.SetExpanded(!node.expanded)
+	// Collapses the node
+	case window.KeyLeft:
+		node.SetExpanded(false)
+	// Expands the node or selects its first item
+	case window.KeyRight:
+		if len(node.items) == 0 {
+			break
+		}
+		if !node.expanded {
+			node.SetExpanded(true)
+			break
+		}
+		t.selectItem(node.items[0])
+	default:
+		return
+	}
+	t.root.StopPropagation(Stop3D)
+}
+
+// selectItem selects the specified visible item of the tree
+func (t *Tree) selectItem(item IPanel) {
+
+	pos := t.List.ItemPosition(item)
+	if pos < 0 {
+		return
+	}
+	t.List.setSelection(t.List.items[pos].(*ListItem), true, false, true)
+	if !t.List.ItemVisible(pos) {
+		t.List.showItem(pos)
+	}
 }
 
 //
@@ -70,6 +70,14 @@ type WindowStyles struct {
 func NewWindow(width, height float32) *Window {
 
 	w := new(Window)
+	w.initialize(width, height)
+	return w
+}
+
+// initialize initializes this window with the specified dimensions.
+// It is used by types which embed a Window.
+func (w *Window) initialize(width, height float32) {
+
 	w.styles = &StyleDefault().Window
 
 	w.Panel.Initialize(width, height)
@@ -87,7 +95,6 @@ func NewWindow(width, height float32) *Window {
 
 	w.recalc()
 	w.update()
-	return w
 }
 
 // SetResizable sets whether the window is resizable.
@@ -0,0 +1,206 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fbx
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Token types of the ASCII file
+const (
+	tokEOF    = iota // end of data
+	tokKey           // record name followed by ':'
+	tokString        // quoted string
+	tokNumber        // number
+	tokWord          // unquoted word value (e.g. T, Y, CullingOff)
+	tokCount         // array count (e.g. *24)
+	tokComma         // ','
+	tokOpen          // '{'
+	tokClose         // '}'
+)
+
+// asciiToken is one token of the ASCII file
+type asciiToken struct {
+	kind  int    // token type
+	text  string // token text
+	start int    // offset of the token in the data
+}
+
+// asciiParser contains the state of the ASCII file parser
+type asciiParser struct {
+	data []byte     // file data
+	pos  int        // current position in data
+	tok  asciiToken // current token
+}
+
+// parseASCII parses the specified ASCII FBX data returning the root node and an error.
+func parseASCII(data []byte) (*Node, error) {
+
+	p := &asciiParser{data: data}
+	p.next()
+	root := &Node{}
+	for p.tok.kind != tokEOF {
+		n, err := p.parseNode()
+		if err != nil {
+			return nil, err
+		}
+		root.Children = append(root.Children, n)
+	}
+	return root, nil
+}
+
+// parseNode parses one record with its properties and nested records
+func (p *asciiParser) parseNode() (*Node, error) {
+
+	if p.tok.kind != tokKey {
+		return nil, formatError(p.tok.start, "record name expected, found %q", p.tok.text)
+	}
+	n := &Node{Name: p.tok.text}
+	p.next()
+
+	// Properties separated by commas, which may span several lines
+	count := -1
+	for p.tok.kind == tokString || p.tok.kind == tokNumber || p.tok.kind == tokWord || p.tok.kind == tokCount {
+		switch p.tok.kind {
+		case tokString:
+			n.Properties = append(n.Properties, p.tok.text)
+		case tokNumber:
+			n.Properties = append(n.Properties, parseNumber(p.tok.text))
+		case tokWord:
+			n.Properties = append(n.Properties, p.tok.text)
+		case tokCount:
+			// Each array value takes at least one byte of the remaining data
+			var err error
+			count, err = strconv.Atoi(p.tok.text)
+			if err != nil || count > len(p.data)-p.pos {
+				return nil, formatError(p.tok.start, "invalid array count: %q", p.tok.text)
+			}
+		}
+		p.next()
+		if p.tok.kind != tokComma {
+			break
+		}
+		p.next()
+	}
+
+	// Nested records
+	if p.tok.kind == tokOpen {
+		p.next()
+		for p.tok.kind != tokClose {
+			if p.tok.kind == tokEOF {
+				return nil, formatError(p.tok.start, "unexpected end of data in record %s", n.Name)
+			}
+			child, err := p.parseNode()
+			if err != nil {
+				return nil, err
+			}
+			n.Children = append(n.Children, child)
+		}
+		p.next()
+	}
+
+	// Arrays are written as "Name: *count { a: values }"
+	if count >= 0 {
+		a := n.Child("a")
+		arr := make([]float64, 0, len(a.Properties))
+		for i := range a.Properties {
+			arr = append(arr, a.Float(i))
+		}
+		n.Properties = append(n.Properties, arr)
+		n.Children = nil
+	}
+	return n, nil
+}
+
+// parseNumber converts the specified number text to int64 or float64
+func parseNumber(text string) interface{} {
+
+	if !strings.ContainsAny(text, ".eE") {
+		if v, err := strconv.ParseInt(text, 10, 64); err == nil {
+			return v
+		}
+	}
+	v, _ := strconv.ParseFloat(text, 64)
+	return v
+}
+
+// next reads the next token from the data
+func (p *asciiParser) next() {
+
+	// Skip blanks and comments
+	for p.pos < len(p.data) {
+		c := p.data[p.pos]
+		if c == ';' {
+			for p.pos < len(p.data) && p.data[p.pos] != '\n' {
+				p.pos++
+			}
+			continue
+		}
+		if c != ' ' && c != '\t' && c != '\r' && c != '\n' {
+			break
+		}
+		p.pos++
+	}
+	p.tok = asciiToken{start: p.pos}
+	if p.pos >= len(p.data) {
+		p.tok.kind = tokEOF
+		return
+	}
+
+	c := p.data[p.pos]
+	switch {
+	case c == ',':
+		p.tok.kind = tokComma
+		p.pos++
+	case c == '{':
+		p.tok.kind = tokOpen
+		p.pos++
+	case c == '}':
+		p.tok.kind = tokClose
+		p.pos++
+	case c == '"':
+		end := p.pos + 1
+		for end < len(p.data) && p.data[end] != '"' {
+			end++
+		}
+		p.tok.kind = tokString
+		p.tok.text = string(p.data[p.pos+1 : end])
+		p.pos = end + 1
+	case c == '*':
+		p.pos++
+		start := p.pos
+		for p.pos < len(p.data) && p.data[p.pos] >= '0' && p.data[p.pos] <= '9' {
+			p.pos++
+		}
+		p.tok.kind = tokCount
+		p.tok.text = string(p.data[start:p.pos])
+	default:
+		start := p.pos
+		for p.pos < len(p.data) {
+			c = p.data[p.pos]
+			if c == ',' || c == '{' || c == '}' || c == ':' || c == ';' || c == ' ' || c == '\t' || c == '\r' || c == '\n' {
+				break
+			}
+			p.pos++
+		}
+		// Guards against unexpected characters
+		if p.pos == start {
+			p.pos++
+			p.tok.kind = tokWord
+			p.tok.text = string(c)
+			return
+		}
+		p.tok.text = string(p.data[start:p.pos])
+		if p.pos < len(p.data) && p.data[p.pos] == ':' {
+			p.tok.kind = tokKey
+			p.pos++
+		} else if c0 := p.tok.text[0]; c0 == '-' || c0 == '+' || c0 == '.' || (c0 >= '0' && c0 <= '9') {
+			p.tok.kind = tokNumber
+		} else {
+			p.tok.kind = tokWord
+		}
+	}
+}
@@ -0,0 +1,297 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fbx
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"io"
+	"io/ioutil"
+	"math"
+	"strings"
+)
+
+// binaryParser contains the state of the binary file parser
+type binaryParser struct {
+	data    []byte // file data
+	pos     int    // current position in data
+	version int    // file format version
+	wide    bool   // record header fields are 64 bits (version >= 7500)
+}
+
+// parseBinary parses the specified binary FBX data returning the
+// root node, the file version and an error.
+func parseBinary(data []byte) (*Node, int, error) {
+
+	headerSize := len(binaryMagic) + 2 + 4
+	if len(data) < headerSize {
+		return nil, 0, formatError(0, "binary file too short")
+	}
+	p := &binaryParser{data: data}
+	p.version = int(binary.LittleEndian.Uint32(data[len(binaryMagic)+2:]))
+	p.wide = p.version >= 7500
+	p.pos = headerSize
+
+	root := &Node{}
+	for p.pos < len(p.data) {
+		n, err := p.parseNode()
+		if err != nil {
+			return nil, 0, err
+		}
+		// Null record marks the end of the top level records
+		if n == nil {
+			break
+		}
+		root.Children = append(root.Children, n)
+	}
+	return root, p.version, nil
+}
+
+// parseNode parses one node record and its nested records.
+// Returns nil for the null record which ends a list of records.
+func (p *binaryParser) parseNode() (*Node, error) {
+
+	start := p.pos
+	endOffset, err := p.readHeaderField()
+	if err != nil {
+		return nil, err
+	}
+	numProps, err := p.readHeaderField()
+	if err != nil {
+		return nil, err
+	}
+	if _, err = p.readHeaderField(); err != nil {
+		return nil, err
+	}
+	if p.pos >= len(p.data) {
+		return nil, formatError(start, "unexpected end of record")
+	}
+	nameLen := int(p.data[p.pos])
+	p.pos++
+	if endOffset == 0 {
+		return nil, nil
+	}
+	if int(endOffset) > len(p.data) || p.pos+nameLen > int(endOffset) {
+		return nil, formatError(start, "invalid record size")
+	}
+	n := &Node{Name: string(p.data[p.pos : p.pos+nameLen])}
+	p.pos += nameLen
+
+	// Each property takes at least one byte of the record
+	if numProps > uint64(int(endOffset)-p.pos) {
+		return nil, formatError(start, "invalid number of properties")
+	}
+	for i := 0; i < int(numProps); i++ {
+		v, err := p.parseProperty()
+		if err != nil {
+			return nil, err
+		}
+		n.Properties = append(n.Properties, v)
+	}
+
+	// Nested records until the end offset
+	for p.pos < int(endOffset) {
+		child, err := p.parseNode()
+		if err != nil {
+			return nil, err
+		}
+		if child == nil {
+			break
+		}
+		n.Children = append(n.Children, child)
+	}
+	if p.pos > int(endOffset) {
+		return nil, formatError(start, "record exceeds its end offset")
+	}
+	p.pos = int(endOffset)
+	return n, nil
+}
+
+// readHeaderField reads one record header field which
+// is 32 bits or 64 bits wide depending on the version.
+func (p *binaryParser) readHeaderField() (uint64, error) {
+
+	if p.wide {
+		if p.pos+8 > len(p.data) {
+			return 0, formatError(p.pos, "unexpected end of data")
+		}
+		v := binary.LittleEndian.Uint64(p.data[p.pos:])
+		p.pos += 8
+		return v, nil
+	}
+	if p.pos+4 > len(p.data) {
+		return 0, formatError(p.pos, "unexpected end of data")
+	}
+	v := binary.LittleEndian.Uint32(p.data[p.pos:])
+	p.pos += 4
+	return uint64(v), nil
+}
+
+// bytes returns the next n bytes of the data
+func (p *binaryParser) bytes(n int) ([]byte, error) {
+
+	if n < 0 || p.pos+n > len(p.data) {
+		return nil, formatError(p.pos, "unexpected end of data")
+	}
+	b := p.data[p.pos : p.pos+n]
+	p.pos += n
+	return b, nil
+}
+
+// parseProperty parses one record property
+func (p *binaryParser) parseProperty() (interface{}, error) {
+
+	tb, err := p.bytes(1)
+	if err != nil {
+		return nil, err
+	}
+	le := binary.LittleEndian
+	ptype := tb[0]
+	switch ptype {
+	case 'Y':
+		b, err := p.bytes(2)
+		if err != nil {
+			return nil, err
+		}
+		return int64(int16(le.Uint16(b))), nil
+	case 'C':
+		b, err := p.bytes(1)
+		if err != nil {
+			return nil, err
+		}
+		return b[0] != 0, nil
+	case 'I':
+		b, err := p.bytes(4)
+		if err != nil {
+			return nil, err
+		}
+		return int64(int32(le.Uint32(b))), nil
+	case 'F':
+		b, err := p.bytes(4)
+		if err != nil {
+			return nil, err
+		}
+		return float64(math.Float32frombits(le.Uint32(b))), nil
+	case 'D':
+		b, err := p.bytes(8)
+		if err != nil {
+			return nil, err
+		}
+		return math.Float64frombits(le.Uint64(b)), nil
+	case 'L':
+		b, err := p.bytes(8)
+		if err != nil {
+			return nil, err
+		}
+		return int64(le.Uint64(b)), nil
+	case 'S', 'R':
+		b, err := p.bytes(4)
+		if err != nil {
+			return nil, err
+		}
+		b, err = p.bytes(int(le.Uint32(b)))
+		if err != nil {
+			return nil, err
+		}
+		if ptype == 'R' {
+			return b, nil
+		}
+		return binaryString(b), nil
+	case 'f', 'd', 'l', 'i', 'b':
+		return p.parseArray(ptype)
+	}
+	return nil, formatError(p.pos-1, "invalid property type: %q", ptype)
+}
+
+// parseArray parses an array property which may be zlib compressed
+func (p *binaryParser) parseArray(ptype byte) (interface{}, error) {
+
+	hdr, err := p.bytes(12)
+	if err != nil {
+		return nil, err
+	}
+	le := binary.LittleEndian
+	count := int(le.Uint32(hdr[0:]))
+	encoding := le.Uint32(hdr[4:])
+	clen := int(le.Uint32(hdr[8:]))
+	raw, err := p.bytes(clen)
+	if err != nil {
+		return nil, err
+	}
+
+	elemSize := 4
+	switch ptype {
+	case 'd', 'l':
+		elemSize = 8
+	case 'b':
+		elemSize = 1
+	}
+	if encoding == 1 {
+		// Deflate compresses the data at most 1032 times
+		if count*elemSize > clen*1032 {
+			return nil, formatError(p.pos, "invalid array count")
+		}
+		zr, err := zlib.NewReader(bytes.NewReader(raw))
+		if err != nil {
+			return nil, err
+		}
+		// The data is read as it is decompressed so the
+		// array count of the file does not size the buffer
+		buf, err := ioutil.ReadAll(io.LimitReader(zr, int64(count*elemSize)))
+		zr.Close()
+		if err != nil {
+			return nil, err
+		}
+		raw = buf
+	}
+	if len(raw) < count*elemSize {
+		return nil, formatError(p.pos, "array data too short")
+	}
+
+	switch ptype {
+	case 'f':
+		arr := make([]float32, count)
+		for i := range arr {
+			arr[i] = math.Float32frombits(le.Uint32(raw[i*4:]))
+		}
+		return arr, nil
+	case 'd':
+		arr := make([]float64, count)
+		for i := range arr {
+			arr[i] = math.Float64frombits(le.Uint64(raw[i*8:]))
+		}
+		return arr, nil
+	case 'l':
+		arr := make([]int64, count)
+		for i := range arr {
+			arr[i] = int64(le.Uint64(raw[i*8:]))
+		}
+		return arr, nil
+	case 'i':
+		arr := make([]int32, count)
+		for i := range arr {
+			arr[i] = int32(le.Uint32(raw[i*4:]))
+		}
+		return arr, nil
+	default:
+		arr := make([]bool, count)
+		for i := range arr {
+			arr[i] = raw[i] != 0
+		}
+		return arr, nil
+	}
+}
+
+// binaryString converts a binary file string in the form "name\x00\x01class"
+// to the ASCII file form "class::name"
+func binaryString(b []byte) string {
+
+	s := string(b)
+	if idx := strings.Index(s, "\x00\x01"); idx >= 0 {
+		return s[idx+2:] + "::" + s[:idx]
+	}
+	return s
+}
@@ -0,0 +1,355 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package fbx implements a loader for Autodesk FBX files in both the
+// binary and the ASCII formats (version 7000 and later).
+// The decoded node records are converted into the engine's scene
+// graph: model hierarchy, meshes with multiple materials, skins and
+// animation stacks.
+package fbx
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// FBX contains the decoded node records of an FBX file and the
+// objects and connections needed to build the scene.
+type FBX struct {
+	Version  int                 // file format version (e.g. 7400)
+	Binary   bool                // indicates the file was in the binary format
+	Root     *Node               // root record containing all top level records
+	path     string              // directory used to load external textures
+	objects  map[int64]*object   // objects by id
+	children map[int64][]connect // connections by parent object id
+	parents  map[int64][]connect // connections by child object id
+	stacks   []*object           // animation stacks in file order
+}
+
+// Node is one node record of an FBX file
+type Node struct {
+	Name       string        // record name
+	Properties []interface{} // record properties
+	Children   []*Node       // nested records
+}
+
+// Property values of the node records have one of the following types:
+//	int64 for integer values (Y, I, L types)
+//	float64 for floating point values (F, D types)
+//	bool for boolean values (C type)
+//	string for strings (S type) with the binary "name\x00\x01class" form converted to "class::name"
+//	[]byte for raw data (R type)
+//	[]float32, []float64, []int32, []int64 or []bool for arrays
+// Arrays in ASCII files are always decoded as []float64.
+
+// object is one child record of the "Objects" record
+type object struct {
+	id       int64       // unique object id
+	class    string      // record name (Model, Geometry, Material, ...)
+	name     string      // object name without class prefix
+	subclass string      // object sub class (Mesh, LimbNode, Skin, ...)
+	node     *Node       // object record
+	cache    interface{} // object loaded from this record
+}
+
+// connect is a connection between two objects
+type connect struct {
+	child  int64  // id of the child (source) object
+	parent int64  // id of the parent (destination) object
+	prop   string // name of the parent property for object-property connections
+}
+
+// Binary file header magic
+const binaryMagic = "Kaydara FBX Binary  \x00"
+
+// Decode decodes the specified FBX file returning an FBX object and an error.
+func Decode(filename string) (*FBX, error) {
+
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return DecodeReader(f, filepath.Dir(filename))
+}
+
+// DecodeReader decodes the FBX data from the specified reader returning
+// an FBX object and an error. The path is the directory used to
+// load texture images referenced by relative file names.
+func DecodeReader(r io.Reader, path string) (*FBX, error) {
+
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	f := new(FBX)
+	f.path = path
+	if bytes.HasPrefix(data, []byte(binaryMagic)) {
+		f.Binary = true
+		f.Root, f.Version, err = parseBinary(data)
+	} else {
+		f.Root, err = parseASCII(data)
+		if err == nil {
+			f.Version = int(f.Root.Child("FBXHeaderExtension").Child("FBXVersion").Int(0))
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	if f.Version < 7000 {
+		return nil, fmt.Errorf("fbx: unsupported file version: %d", f.Version)
+	}
+	f.indexObjects()
+	return f, nil
+}
+
+// indexObjects builds the maps of objects and connections
+func (f *FBX) indexObjects() {
+
+	f.objects = make(map[int64]*object)
+	f.children = make(map[int64][]connect)
+	f.parents = make(map[int64][]connect)
+	f.stacks = make([]*object, 0)
+
+	for _, n := range f.Root.Child("Objects").Children {
+		if len(n.Properties) < 1 {
+			continue
+		}
+		ob := &object{id: n.Int(0), class: n.Name, node: n}
+		ob.name = objectName(n.String(1))
+		ob.subclass = n.String(2)
+		f.objects[ob.id] = ob
+		if ob.class == "AnimationStack" {
+			f.stacks = append(f.stacks, ob)
+		}
+	}
+
+	for _, n := range f.Root.Child("Connections").Children {
+		if n.Name != "C" || len(n.Properties) < 3 {
+			continue
+		}
+		c := connect{child: n.Int(1), parent: n.Int(2), prop: n.String(3)}
+		f.children[c.parent] = append(f.children[c.parent], c)
+		f.parents[c.child] = append(f.parents[c.child], c)
+	}
+}
+
+// objectName returns the name of an object removing the class prefix
+func objectName(name string) string {
+
+	if idx := strings.Index(name, "::"); idx >= 0 {
+		return name[idx+2:]
+	}
+	return name
+}
+
+// Child returns the first nested record with the specified name
+// or an empty record if not found, so calls can be chained.
+func (n *Node) Child(name string) *Node {
+
+	if n != nil {
+		for _, c := range n.Children {
+			if c.Name == name {
+				return c
+			}
+		}
+	}
+	return &Node{}
+}
+
+// ChildrenNamed returns all the nested records with the specified name.
+func (n *Node) ChildrenNamed(name string) []*Node {
+
+	res := make([]*Node, 0)
+	for _, c := range n.Children {
+		if c.Name == name {
+			res = append(res, c)
+		}
+	}
+	return res
+}
+
+// Int returns the property at the specified index as an integer
+// or 0 if not found or not a number.
+func (n *Node) Int(idx int) int64 {
+
+	if idx >= len(n.Properties) {
+		return 0
+	}
+	switch v := n.Properties[idx].(type) {
+	case int64:
+		return v
+	case float64:
+		return int64(v)
+	case bool:
+		if v {
+			return 1
+		}
+	}
+	return 0
+}
+
+// Float returns the property at the specified index as a float
+// or 0 if not found or not a number.
+func (n *Node) Float(idx int) float64 {
+
+	if idx >= len(n.Properties) {
+		return 0
+	}
+	switch v := n.Properties[idx].(type) {
+	case int64:
+		return float64(v)
+	case float64:
+		return v
+	}
+	return 0
+}
+
+// String returns the property at the specified index as a string
+// or an empty string if not found or not a string.
+func (n *Node) String(idx int) string {
+
+	if idx >= len(n.Properties) {
+		return ""
+	}
+	s, _ := n.Properties[idx].(string)
+	return s
+}
+
+// Floats returns the property at the specified index as a slice of floats.
+func (n *Node) Floats(idx int) []float64 {
+
+	if idx >= len(n.Properties) {
+		return nil
+	}
+	switch v := n.Properties[idx].(type) {
+	case []float64:
+		return v
+	case []float32:
+		res := make([]float64, len(v))
+		for i := range v {
+			res[i] = float64(v[i])
+		}
+		return res
+	case []int32:
+		res := make([]float64, len(v))
+		for i := range v {
+			res[i] = float64(v[i])
+		}
+		return res
+	case []int64:
+		res := make([]float64, len(v))
+		for i := range v {
+			res[i] = float64(v[i])
+		}
+		return res
+	}
+	return nil
+}
+
+// Ints returns the property at the specified index as a slice of integers.
+func (n *Node) Ints(idx int) []int64 {
+
+	if idx >= len(n.Properties) {
+		return nil
+	}
+	switch v := n.Properties[idx].(type) {
+	case []int64:
+		return v
+	case []int32:
+		res := make([]int64, len(v))
+		for i := range v {
+			res[i] = int64(v[i])
+		}
+		return res
+	case []float64:
+		res := make([]int64, len(v))
+		for i := range v {
+			res[i] = int64(v[i])
+		}
+		return res
+	case []float32:
+		res := make([]int64, len(v))
+		for i := range v {
+			res[i] = int64(v[i])
+		}
+		return res
+	}
+	return nil
+}
+
+// Prop70 returns the "P" record with the specified name from the
+// "Properties70" (or "Properties60") record of this node or nil if not found.
+// The value of the property starts at index 4 (3 for Properties60).
+func (n *Node) Prop70(name string) *Node {
+
+	props := n.Child("Properties70")
+	if props.Name == "" {
+		props = n.Child("Properties60")
+	}
+	for _, p := range props.Children {
+		if p.String(0) == name {
+			return p
+		}
+	}
+	return nil
+}
+
+// propValues returns the values of a Properties70 (or Properties60) property
+func propValues(p *Node) []interface{} {
+
+	first := 4
+	if p.Name == "Property" {
+		first = 3
+	}
+	if len(p.Properties) < first {
+		return nil
+	}
+	return p.Properties[first:]
+}
+
+// PropFloat returns the float value of the named property or the default if not found.
+func (n *Node) PropFloat(name string, def float64) float64 {
+
+	p := n.Prop70(name)
+	if p == nil {
+		return def
+	}
+	vals := &Node{Properties: propValues(p)}
+	return vals.Float(0)
+}
+
+// PropVector returns the 3 floats value of the named property or the default if not found.
+func (n *Node) PropVector(name string, def [3]float64) [3]float64 {
+
+	p := n.Prop70(name)
+	if p == nil {
+		return def
+	}
+	vals := &Node{Properties: propValues(p)}
+	return [3]float64{vals.Float(0), vals.Float(1), vals.Float(2)}
+}
+
+// PropString returns the string value of the named property or the default if not found.
+func (n *Node) PropString(name string, def string) string {
+
+	p := n.Prop70(name)
+	if p == nil {
+		return def
+	}
+	vals := &Node{Properties: propValues(p)}
+	return vals.String(0)
+}
+
+// formatError returns an error with the specified message and the byte offset
+func formatError(offset int, format string, args ...interface{}) error {
+
+	return fmt.Errorf("fbx: "+format+" at offset %d", append(args, offset)...)
+}
@@ -0,0 +1,150 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fbx
+
+import (
+	"bytes"
+	"encoding/binary"
+	"strings"
+	"testing"
+)
+
+// Tests parsing the records, properties and arrays of an ASCII file
+func TestParseASCII(t *testing.T) {
+
+	root, err := parseASCII([]byte(`; comment
+FBXHeaderExtension:  {
+	FBXVersion: 7300
+}
+Objects:  {
+	Geometry: 10, "Geometry::cube", "Mesh" {
+		Vertices: *6 {
+			a: 0,1.5,-2,
+			3,4e1,5
+		}
+	}
+}
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v := root.Child("FBXHeaderExtension").Child("FBXVersion").Int(0); v != 7300 {
+		t.Errorf("version %d, expected 7300", v)
+	}
+	geom := root.Child("Objects").Child("Geometry")
+	if geom.Int(0) != 10 || geom.String(1) != "Geometry::cube" || geom.String(2) != "Mesh" {
+		t.Errorf("geometry properties %v", geom.Properties)
+	}
+	verts := geom.Child("Vertices").Floats(0)
+	expected := []float64{0, 1.5, -2, 3, 40, 5}
+	if len(verts) != len(expected) {
+		t.Fatalf("vertices %v, expected %v", verts, expected)
+	}
+	for i := range verts {
+		if verts[i] != expected[i] {
+			t.Errorf("vertices %v, expected %v", verts, expected)
+			break
+		}
+	}
+}
+
+// binaryRecord returns a record of a binary file of version 7500 starting at the
+// specified offset with the specified number of properties and property data
+func binaryRecord(offset int, name string, numProps uint64, props []byte) []byte {
+
+	var b bytes.Buffer
+	end := offset + 25 + len(name) + len(props)
+	binary.Write(&b, binary.LittleEndian, uint64(end))
+	binary.Write(&b, binary.LittleEndian, numProps)
+	binary.Write(&b, binary.LittleEndian, uint64(len(props)))
+	b.WriteByte(byte(len(name)))
+	b.WriteString(name)
+	b.Write(props)
+	return b.Bytes()
+}
+
+// binaryFile returns a binary file of version 7500 with the specified top level record
+func binaryFile(name string, numProps uint64, props []byte) []byte {
+
+	var b bytes.Buffer
+	b.WriteString(binaryMagic)
+	b.Write([]byte{0x1A, 0})
+	binary.Write(&b, binary.LittleEndian, uint32(7500))
+	b.Write(binaryRecord(b.Len(), name, numProps, props))
+	b.Write(make([]byte, 25))
+	return b.Bytes()
+}
+
+// Tests parsing the properties of a binary file
+func TestParseBinary(t *testing.T) {
+
+	var props bytes.Buffer
+	props.WriteString("I")
+	binary.Write(&props, binary.LittleEndian, int32(-5))
+	props.WriteString("S")
+	binary.Write(&props, binary.LittleEndian, uint32(10))
+	props.WriteString("cube\x00\x01Mesh")
+	props.WriteString("d")
+	binary.Write(&props, binary.LittleEndian, []uint32{2, 0, 16})
+	binary.Write(&props, binary.LittleEndian, []float64{1.5, -2})
+
+	root, version, err := parseBinary(binaryFile("Node", 3, props.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if version != 7500 {
+		t.Errorf("version %d, expected 7500", version)
+	}
+	n := root.Child("Node")
+	if n.Int(0) != -5 || n.String(1) != "Mesh::cube" {
+		t.Errorf("properties %v", n.Properties)
+	}
+	if arr := n.Floats(2); len(arr) != 2 || arr[0] != 1.5 || arr[1] != -2 {
+		t.Errorf("array %v", arr)
+	}
+}
+
+// Tests that malformed files return errors instead of panicking or allocating their counts
+func TestParseMalformed(t *testing.T) {
+
+	ascii := []struct {
+		name string
+		data string
+	}{
+		{"record name", "1, 2"},
+		{"unclosed record", "A: {\nB: 1\n"},
+		{"array count overflow", "A: *99999999999999999999 {\na: 1\n}"},
+		{"array count", "A: *1000 {\na: 1\n}"},
+	}
+	for _, test := range ascii {
+		if _, err := parseASCII([]byte(test.data)); err == nil {
+			t.Errorf("%s: no error", test.name)
+		}
+	}
+
+	hugeArray := []byte("f")
+	hugeArray = append(hugeArray, 0xFF, 0xFF, 0xFF, 0x0F, 1, 0, 0, 0, 8, 0, 0, 0)
+	hugeArray = append(hugeArray, 0x78, 0x9C, 0, 0, 0, 0, 0, 0)
+	binaryTests := []struct {
+		name string
+		data []byte
+	}{
+		{"too short", []byte(binaryMagic)},
+		{"number of properties", binaryFile("A", 1<<62, nil)},
+		{"property type", binaryFile("A", 1, []byte("X"))},
+		{"string size", binaryFile("A", 1, []byte("S\xFF\xFF\xFF\x7F"))},
+		{"array data", binaryFile("A", 1, []byte("i\x10\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00"))},
+		{"compressed array count", binaryFile("A", 1, hugeArray)},
+		{"truncated", binaryFile("A", 0, nil)[:40]},
+	}
+	for _, test := range binaryTests {
+		if _, _, err := parseBinary(test.data); err == nil {
+			t.Errorf("%s: no error", test.name)
+		}
+	}
+	if _, err := DecodeReader(strings.NewReader("A: 1"), ""); err == nil {
+		t.Error("file without version: no error")
+	}
+}
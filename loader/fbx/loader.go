@@ -0,0 +1,947 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fbx
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/draw"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/sansebasko/engine/animation"
+	"github.com/sansebasko/engine/core"
+	"github.com/sansebasko/engine/geometry"
+	"github.com/sansebasko/engine/gls"
+	"github.com/sansebasko/engine/graphic"
+	"github.com/sansebasko/engine/material"
+	"github.com/sansebasko/engine/math32"
+	"github.com/sansebasko/engine/texture"
+)
+
+// Number of FBX time units per second
+const ticksPerSecond = 46186158000
+
+// Maximum number of bones influencing one vertex
+const maxInfluences = 4
+
+// skin contains the skinning data of one geometry
+type skin struct {
+	bones []int64          // ids of the bone models
+	ibms  []math32.Matrix4 // inverse bind matrices of the bones
+}
+
+// loadedGeometry is the cached result of loading a geometry object
+type loadedGeometry struct {
+	geom *geometry.Geometry
+	skin *skin
+}
+
+// LoadScene creates a parent Node which contains all the models
+// connected to the scene root with their meshes, skins and materials.
+func (f *FBX) LoadScene() (core.INode, error) {
+
+	log.Debug("Loading Scene")
+	scene := core.NewNode()
+	for _, c := range f.children[0] {
+		ob := f.objects[c.child]
+		if ob == nil || ob.class != "Model" {
+			continue
+		}
+		child, err := f.loadModel(ob)
+		if err != nil {
+			return nil, err
+		}
+		scene.Add(child)
+	}
+	return scene, nil
+}
+
+// loadModel creates and returns the node for the specified model object
+// and recursively for its children.
+func (f *FBX) loadModel(ob *object) (core.INode, error) {
+
+	if ob.cache != nil {
+		return ob.cache.(core.INode), nil
+	}
+	log.Debug("Loading Model %s (%s)", ob.name, ob.subclass)
+
+	var in core.INode
+	var sk *skin
+	geomOb := f.childOfClass(ob.id, "Geometry")
+	if geomOb != nil && geomOb.subclass == "Mesh" {
+		lg, err := f.loadGeometry(geomOb)
+		if err != nil {
+			return nil, err
+		}
+		geom := lg.geom
+
+		// Applies the geometric transform, which is not inherited by
+		// children, to a private copy of the shared geometry
+		var gm math32.Matrix4
+		if geometricMatrix(ob.node, &gm) {
+			own, err := f.buildGeometry(geomOb)
+			if err != nil {
+				return nil, err
+			}
+			geom = own.geom
+			geom.ApplyMatrix(&gm)
+		} else {
+			geom.Incref()
+		}
+
+		// Loads materials in connection order
+		mats := make([]material.IMaterial, 0)
+		for _, c := range f.children[ob.id] {
+			matOb := f.objects[c.child]
+			if matOb == nil || matOb.class != "Material" {
+				continue
+			}
+			mat, err := f.loadMaterial(matOb)
+			if err != nil {
+				return nil, err
+			}
+			mats = append(mats, mat)
+		}
+		if len(mats) == 0 {
+			mats = append(mats, material.NewStandard(&math32.Color{0.8, 0.8, 0.8}))
+		}
+
+		var mesh *graphic.Mesh
+		if geom.GroupCount() <= 1 {
+			matIdx := 0
+			if geom.GroupCount() == 1 {
+				matIdx = geom.GroupAt(0).Matindex
+			}
+			mesh = graphic.NewMesh(geom, meshMaterial(mats, matIdx))
+		} else {
+			mesh = graphic.NewMesh(geom, nil)
+			for i := 0; i < geom.GroupCount(); i++ {
+				matIdx := geom.GroupAt(i).Matindex
+				mesh.AddGroupMaterial(meshMaterial(mats, matIdx), i)
+			}
+		}
+		in = mesh
+		sk = lg.skin
+		if sk != nil {
+			in = graphic.NewRiggedMesh(mesh)
+		}
+	} else {
+		in = core.NewNode()
+	}
+
+	node := in.GetNode()
+	node.SetName(ob.name)
+	node.SetLoaderID(strconv.FormatInt(ob.id, 10))
+	var m math32.Matrix4
+	localMatrix(ob.node, &m)
+	node.SetMatrix(&m)
+
+	// Cache node before loading children and bones
+	ob.cache = in
+
+	for _, c := range f.children[ob.id] {
+		childOb := f.objects[c.child]
+		if childOb == nil || childOb.class != "Model" {
+			continue
+		}
+		child, err := f.loadModel(childOb)
+		if err != nil {
+			return nil, err
+		}
+		node.Add(child)
+	}
+
+	// Creates skeleton with the bone models
+	if rm, ok := in.(*graphic.RiggedMesh); ok {
+		skeleton := graphic.NewSkeleton()
+		for i, boneID := range sk.bones {
+			bone, err := f.loadModel(f.objects[boneID])
+			if err != nil {
+				return nil, err
+			}
+			skeleton.AddBone(bone.GetNode(), &sk.ibms[i])
+		}
+		rm.SetSkeleton(skeleton)
+	}
+	return in, nil
+}
+
+// meshMaterial returns the material of the specified index of a mesh group.
+// Exporters use -1 for polygons without material, which get the first one.
+func meshMaterial(mats []material.IMaterial, idx int) material.IMaterial {
+
+	if idx < 0 {
+		idx = 0
+	}
+	return mats[idx%len(mats)]
+}
+
+// childOfClass returns the first object connected to the specified
+// parent object with the specified class or nil if not found.
+func (f *FBX) childOfClass(parent int64, class string) *object {
+
+	for _, c := range f.children[parent] {
+		ob := f.objects[c.child]
+		if ob != nil && ob.class == class {
+			return ob
+		}
+	}
+	return nil
+}
+
+// loadGeometry loads the specified mesh geometry object which
+// may be shared by several models.
+func (f *FBX) loadGeometry(ob *object) (*loadedGeometry, error) {
+
+	if ob.cache != nil {
+		return ob.cache.(*loadedGeometry), nil
+	}
+	lg, err := f.buildGeometry(ob)
+	if err != nil {
+		return nil, err
+	}
+	ob.cache = lg
+	return lg, nil
+}
+
+// buildGeometry creates a new geometry from the specified mesh geometry object.
+// Polygons are triangulated and their vertices unrolled so each polygon
+// vertex may have its own normal, uv and color. Triangles are grouped by
+// material index.
+func (f *FBX) buildGeometry(ob *object) (*loadedGeometry, error) {
+
+	log.Debug("Loading Geometry %s", ob.name)
+
+	n := ob.node
+	verts := n.Child("Vertices").Floats(0)
+	polyIndices := n.Child("PolygonVertexIndex").Ints(0)
+	if verts == nil || polyIndices == nil {
+		return nil, fmt.Errorf("geometry %s without vertices", ob.name)
+	}
+
+	normals := newLayer(n.Child("LayerElementNormal"), "Normals", "NormalsIndex", 3)
+	uvs := newLayer(n.Child("LayerElementUV"), "UV", "UVIndex", 2)
+	var uvs2 *layer
+	if uvLayers := n.ChildrenNamed("LayerElementUV"); len(uvLayers) > 1 {
+		uvs2 = newLayer(uvLayers[1], "UV", "UVIndex", 2)
+	}
+	colors := newLayer(n.Child("LayerElementColor"), "Colors", "ColorIndex", 4)
+	materials := newLayer(n.Child("LayerElementMaterial"), "Materials", "", 1)
+	sk, weights := f.loadSkin(ob, len(verts)/3)
+
+	positions := math32.NewArrayF32(0, 0)
+	normalsArr := math32.NewArrayF32(0, 0)
+	uvsArr := math32.NewArrayF32(0, 0)
+	uvs2Arr := math32.NewArrayF32(0, 0)
+	colorsArr := math32.NewArrayF32(0, 0)
+	skinIndices := math32.NewArrayF32(0, 0)
+	skinWeights := math32.NewArrayF32(0, 0)
+	groups := make(map[int][]uint32)
+
+	polyStart := 0
+	polyIdx := 0
+	for i, pi := range polyIndices {
+		if pi >= 0 {
+			continue
+		}
+		// Negative index marks the last vertex of the polygon
+		first := uint32(positions.Size() / 3)
+		var faceNormal math32.Vector3
+		if normals == nil {
+			polygonNormal(verts, polyIndices[polyStart:i+1], &faceNormal)
+		}
+		for pv := polyStart; pv <= i; pv++ {
+			cp := int(polyIndices[pv])
+			if cp < 0 {
+				cp = ^cp
+			}
+			if 3*cp+2 >= len(verts) {
+				return nil, fmt.Errorf("geometry %s: invalid vertex index %d", ob.name, cp)
+			}
+			positions.Append(float32(verts[3*cp]), float32(verts[3*cp+1]), float32(verts[3*cp+2]))
+			if normals != nil {
+				normalsArr.Append(normals.value(pv, cp, polyIdx)...)
+			} else {
+				normalsArr.AppendVector3(&faceNormal)
+			}
+			if uvs != nil {
+				uvsArr.Append(uvs.value(pv, cp, polyIdx)...)
+			}
+			if uvs2 != nil {
+				uvs2Arr.Append(uvs2.value(pv, cp, polyIdx)...)
+			}
+			if colors != nil {
+				colorsArr.Append(colors.value(pv, cp, polyIdx)[:3]...)
+			}
+			if sk != nil {
+				skinIndices.Append(weights[cp].indices[:]...)
+				skinWeights.Append(weights[cp].weights[:]...)
+			}
+		}
+		matIdx := 0
+		if materials != nil {
+			matIdx = int(materials.value(polyStart, int(polyIndices[polyStart]), polyIdx)[0])
+		}
+		// Triangulates the polygon as a fan
+		count := uint32(i - polyStart + 1)
+		for v := uint32(1); v+1 < count; v++ {
+			groups[matIdx] = append(groups[matIdx], first, first+v, first+v+1)
+		}
+		polyStart = i + 1
+		polyIdx++
+	}
+
+	// Concatenates indices of all material groups
+	geom := geometry.NewGeometry()
+	matIndices := make([]int, 0, len(groups))
+	for mi := range groups {
+		matIndices = append(matIndices, mi)
+	}
+	sort.Ints(matIndices)
+	indices := math32.NewArrayU32(0, 0)
+	for _, mi := range matIndices {
+		geom.AddGroup(indices.Size(), len(groups[mi]), mi)
+		indices.Append(groups[mi]...)
+	}
+	geom.SetIndices(indices)
+	geom.AddVBO(gls.NewVBO(positions).AddAttrib(gls.VertexPosition))
+	geom.AddVBO(gls.NewVBO(normalsArr).AddAttrib(gls.VertexNormal))
+	if uvs != nil {
+		geom.AddVBO(gls.NewVBO(uvsArr).AddAttrib(gls.VertexTexcoord))
+	}
+	if uvs2 != nil {
+		geom.AddVBO(gls.NewVBO(uvs2Arr).AddAttrib(gls.VertexTexcoord2))
+	}
+	if colors != nil {
+		geom.AddVBO(gls.NewVBO(colorsArr).AddAttrib(gls.VertexColor))
+	}
+	if sk != nil {
+		geom.AddVBO(gls.NewVBO(skinIndices).AddAttrib(gls.SkinIndex))
+		geom.AddVBO(gls.NewVBO(skinWeights).AddAttrib(gls.SkinWeight))
+	}
+
+	return &loadedGeometry{geom: geom, skin: sk}, nil
+}
+
+// vertexWeights contains the bone influences of one control point
+type vertexWeights struct {
+	indices [maxInfluences]float32
+	weights [maxInfluences]float32
+}
+
+// loadSkin loads the skin deformer of the specified geometry object, if any,
+// returning the skin and the bone influences of each control point.
+func (f *FBX) loadSkin(geomOb *object, npoints int) (*skin, []vertexWeights) {
+
+	var skinOb *object
+	for _, c := range f.children[geomOb.id] {
+		ob := f.objects[c.child]
+		if ob != nil && ob.class == "Deformer" && ob.subclass == "Skin" {
+			skinOb = ob
+			break
+		}
+	}
+	if skinOb == nil {
+		return nil, nil
+	}
+
+	sk := new(skin)
+	weights := make([]vertexWeights, npoints)
+	for _, c := range f.children[skinOb.id] {
+		cluster := f.objects[c.child]
+		if cluster == nil || cluster.subclass != "Cluster" {
+			continue
+		}
+		bone := f.childOfClass(cluster.id, "Model")
+		if bone == nil {
+			continue
+		}
+		boneIdx := float32(len(sk.bones))
+		sk.bones = append(sk.bones, bone.id)
+
+		// Inverse bind matrix transforms from mesh space to bone space
+		var transform, link, ibm math32.Matrix4
+		toMatrix(cluster.node.Child("Transform").Floats(0), &transform)
+		toMatrix(cluster.node.Child("TransformLink").Floats(0), &link)
+		ibm.GetInverse(&link)
+		ibm.Multiply(&transform)
+		sk.ibms = append(sk.ibms, ibm)
+
+		// Keeps the strongest influences of each control point
+		cpIndices := cluster.node.Child("Indexes").Ints(0)
+		cpWeights := cluster.node.Child("Weights").Floats(0)
+		for i := 0; i < len(cpIndices) && i < len(cpWeights); i++ {
+			cp := int(cpIndices[i])
+			if cp < 0 || cp >= npoints {
+				continue
+			}
+			vw := &weights[cp]
+			w := float32(cpWeights[i])
+			min := 0
+			for j := 1; j < maxInfluences; j++ {
+				if vw.weights[j] < vw.weights[min] {
+					min = j
+				}
+			}
+			if w > vw.weights[min] {
+				vw.weights[min] = w
+				vw.indices[min] = boneIdx
+			}
+		}
+	}
+
+	// Normalizes weights
+	for i := range weights {
+		sum := float32(0)
+		for _, w := range weights[i].weights {
+			sum += w
+		}
+		if sum > 0 {
+			for j := range weights[i].weights {
+				weights[i].weights[j] /= sum
+			}
+		}
+	}
+	return sk, weights
+}
+
+// layer contains the data of one geometry layer element
+type layer struct {
+	data     []float64 // direct values
+	index    []int64   // indices into the direct values (nil for direct reference)
+	mapping  string    // mapping information type
+	itemSize int       // number of values per item
+	value    func(polyVertex, controlPoint, polygon int) []float32
+}
+
+// newLayer creates a layer from the specified layer element record.
+// Returns nil if the record is empty.
+func newLayer(n *Node, dataName, indexName string, itemSize int) *layer {
+
+	l := new(layer)
+	l.data = n.Child(dataName).Floats(0)
+	if l.data == nil {
+		return nil
+	}
+	l.itemSize = itemSize
+	l.mapping = n.Child("MappingInformationType").String(0)
+	if ref := n.Child("ReferenceInformationType").String(0); ref == "IndexToDirect" || ref == "Index" {
+		if indexName != "" {
+			l.index = n.Child(indexName).Ints(0)
+		}
+	}
+	item := make([]float32, itemSize)
+	l.value = func(polyVertex, controlPoint, polygon int) []float32 {
+		var idx int
+		switch l.mapping {
+		case "ByPolygonVertex":
+			idx = polyVertex
+		case "ByPolygon":
+			idx = polygon
+		case "AllSame":
+			idx = 0
+		default: // ByVertex, ByVertice, ByControlPoint
+			idx = controlPoint
+		}
+		if l.index != nil && idx < len(l.index) {
+			idx = int(l.index[idx])
+		}
+		for i := range item {
+			item[i] = 0
+			if pos := idx*itemSize + i; idx >= 0 && pos < len(l.data) {
+				item[i] = float32(l.data[pos])
+			}
+		}
+		return item
+	}
+	return l
+}
+
+// polygonNormal calculates the normal of the polygon with the specified vertex indices
+func polygonNormal(verts []float64, poly []int64, normal *math32.Vector3) {
+
+	var a, b, c math32.Vector3
+	vertex := func(i int, v *math32.Vector3) {
+		cp := int(poly[i])
+		if cp < 0 {
+			cp = ^cp
+		}
+		if 3*cp+2 < len(verts) {
+			v.Set(float32(verts[3*cp]), float32(verts[3*cp+1]), float32(verts[3*cp+2]))
+		}
+	}
+	normal.Set(0, 0, 0)
+	if len(poly) < 3 {
+		return
+	}
+	vertex(0, &a)
+	vertex(1, &b)
+	vertex(2, &c)
+	var ab math32.Vector3
+	normal.SubVectors(&c, &b)
+	ab.SubVectors(&a, &b)
+	normal.Cross(&ab)
+	normal.Normalize()
+}
+
+// loadMaterial loads the specified material object.
+// Materials with physically based properties are loaded as
+// material.Physical and other materials as material.Standard.
+func (f *FBX) loadMaterial(ob *object) (material.IMaterial, error) {
+
+	if ob.cache != nil {
+		return ob.cache.(material.IMaterial), nil
+	}
+	log.Debug("Loading Material %s", ob.name)
+	n := ob.node
+
+	color := func(name, factorName string, def [3]float64) math32.Color {
+		c := n.PropVector(name, def)
+		k := float32(n.PropFloat(factorName, 1))
+		return math32.Color{float32(c[0]) * k, float32(c[1]) * k, float32(c[2]) * k}
+	}
+	diffuse := color("DiffuseColor", "DiffuseFactor", [3]float64{0.8, 0.8, 0.8})
+	emissive := color("EmissiveColor", "EmissiveFactor", [3]float64{0, 0, 0})
+	opacity := float32(n.PropFloat("Opacity", 1))
+	if tf := n.Prop70("TransparencyFactor"); tf != nil && n.Prop70("Opacity") == nil {
+		opacity = 1 - float32(n.PropFloat("TransparencyFactor", 0))
+	}
+
+	// Textures connected to material properties
+	textures := make(map[string]*texture.Texture2D)
+	for _, c := range f.children[ob.id] {
+		texOb := f.objects[c.child]
+		if texOb == nil || texOb.class != "Texture" {
+			continue
+		}
+		tex := f.loadTexture(texOb)
+		if tex != nil {
+			textures[c.prop] = tex
+		}
+	}
+
+	var imat material.IMaterial
+	if isPhysical(n) {
+		pm := material.NewPhysical()
+		base := n.PropVector("Maya|baseColor", [3]float64{float64(diffuse.R), float64(diffuse.G), float64(diffuse.B)})
+		pm.SetBaseColorFactor(&math32.Color4{float32(base[0]), float32(base[1]), float32(base[2]), opacity})
+		pm.SetMetallicFactor(float32(n.PropFloat("Maya|metalness", n.PropFloat("Metalness", 0))))
+		pm.SetRoughnessFactor(float32(n.PropFloat("Maya|roughness", n.PropFloat("Roughness", 1))))
+		pm.SetEmissiveFactor(&emissive)
+		for _, name := range []string{"Maya|TEX_color_map", "Maya|baseColor", "DiffuseColor"} {
+			if tex := textures[name]; tex != nil {
+				pm.SetBaseColorMap(tex)
+				break
+			}
+		}
+		for _, name := range []string{"Maya|TEX_normal_map", "NormalMap", "Bump"} {
+			if tex := textures[name]; tex != nil {
+				pm.SetNormalMap(tex)
+				break
+			}
+		}
+		if tex := textures["EmissiveColor"]; tex != nil {
+			pm.SetEmissiveMap(tex)
+		}
+		if opacity < 1 {
+			pm.SetTransparent(true)
+		}
+		imat = pm
+	} else {
+		sm := material.NewStandard(&diffuse)
+		ambient := color("AmbientColor", "AmbientFactor", [3]float64{0, 0, 0})
+		sm.SetAmbientColor(&ambient)
+		specular := color("SpecularColor", "SpecularFactor", [3]float64{0.2, 0.2, 0.2})
+		sm.SetSpecularColor(&specular)
+		sm.SetShininess(float32(n.PropFloat("ShininessExponent", n.PropFloat("Shininess", 20))))
+		sm.SetEmissiveColor(&emissive)
+		sm.SetOpacity(opacity)
+		if opacity < 1 {
+			sm.SetTransparent(true)
+		}
+		if tex := textures["DiffuseColor"]; tex != nil {
+			sm.AddTexture(tex)
+		}
+		imat = sm
+	}
+	ob.cache = imat
+	return imat, nil
+}
+
+// Names of properties indicating a physically based material
+var physicalProps = []string{
+	"Maya|baseColor", "Maya|metalness", "Maya|roughness", "Maya|TEX_color_map",
+	"Metalness", "Roughness",
+}
+
+// isPhysical checks if the specified material record has physically based properties
+func isPhysical(n *Node) bool {
+
+	for _, name := range physicalProps {
+		if n.Prop70(name) != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// loadTexture loads the specified texture object from the embedded
+// video content or from the referenced image file.
+// Returns nil and logs a warning if the image could not be loaded.
+func (f *FBX) loadTexture(ob *object) *texture.Texture2D {
+
+	if ob.cache != nil {
+		return ob.cache.(*texture.Texture2D)
+	}
+	log.Debug("Loading Texture %s", ob.name)
+
+	var tex *texture.Texture2D
+	if video := f.childOfClass(ob.id, "Video"); video != nil {
+		var data []byte
+		if content := video.node.Child("Content"); len(content.Properties) > 0 {
+			data, _ = content.Properties[0].([]byte)
+		}
+		if len(data) > 0 {
+			img, _, err := image.Decode(bytes.NewReader(data))
+			if err != nil {
+				log.Warn("Texture %s: %v", ob.name, err)
+			} else {
+				rgba := image.NewRGBA(img.Bounds())
+				draw.Draw(rgba, rgba.Bounds(), img, img.Bounds().Min, draw.Src)
+				tex = texture.NewTexture2DFromRGBA(rgba)
+			}
+		}
+	}
+	if tex == nil {
+		fileName := ob.node.Child("FileName").String(0)
+		relName := ob.node.Child("RelativeFilename").String(0)
+		candidates := []string{fileName}
+		if relName != "" {
+			candidates = append(candidates, filepath.Join(f.path, filepath.FromSlash(strings.Replace(relName, "\\", "/", -1))))
+		}
+		if fileName != "" {
+			candidates = append(candidates, filepath.Join(f.path, filepath.Base(strings.Replace(fileName, "\\", "/", -1))))
+		}
+		for _, path := range candidates {
+			if path == "" {
+				continue
+			}
+			if _, err := os.Stat(path); err != nil {
+				continue
+			}
+			t, err := texture.NewTexture2DFromImage(path)
+			if err != nil {
+				log.Warn("Texture %s: %v", ob.name, err)
+				continue
+			}
+			tex = t
+			break
+		}
+		if tex == nil {
+			log.Warn("Texture %s: image not found: %s", ob.name, fileName)
+			return nil
+		}
+	}
+	ob.cache = tex
+	return tex
+}
+
+// AnimationNames returns the names of the animation stacks in file order.
+func (f *FBX) AnimationNames() []string {
+
+	names := make([]string, len(f.stacks))
+	for i, ob := range f.stacks {
+		names[i] = ob.name
+	}
+	return names
+}
+
+// LoadAnimationByName loads the animation stack with the specified name.
+func (f *FBX) LoadAnimationByName(animName string) (*animation.Animation, error) {
+
+	for i, ob := range f.stacks {
+		if ob.name == animName {
+			return f.LoadAnimation(i)
+		}
+	}
+	return nil, fmt.Errorf("could not find animation named %v", animName)
+}
+
+// LoadAnimation creates an Animation for the animation stack with the
+// specified index. Only the first animation layer of the stack is used.
+// The channels target the same nodes returned by LoadScene.
+func (f *FBX) LoadAnimation(animIdx int) (*animation.Animation, error) {
+
+	if animIdx < 0 || animIdx >= len(f.stacks) {
+		return nil, fmt.Errorf("invalid animation index")
+	}
+	stack := f.stacks[animIdx]
+	log.Debug("Loading Animation %s", stack.name)
+
+	anim := animation.NewAnimation()
+	anim.SetName(stack.name)
+	layerOb := f.childOfClass(stack.id, "AnimationLayer")
+	if layerOb == nil {
+		return anim, nil
+	}
+	for _, c := range f.children[layerOb.id] {
+		cn := f.objects[c.child]
+		if cn == nil || cn.class != "AnimationCurveNode" {
+			continue
+		}
+		// Finds the model property animated by this curve node
+		var model *object
+		var prop string
+		for _, pc := range f.parents[cn.id] {
+			if ob := f.objects[pc.parent]; ob != nil && ob.class == "Model" {
+				model, prop = ob, pc.prop
+				break
+			}
+		}
+		if model == nil {
+			continue
+		}
+		if prop != "Lcl Translation" && prop != "Lcl Rotation" && prop != "Lcl Scaling" {
+			log.Debug("Animated property not supported: %s", prop)
+			continue
+		}
+		node, err := f.loadModel(model)
+		if err != nil {
+			return nil, err
+		}
+		ch := f.loadChannel(cn, model, prop, node)
+		if ch != nil {
+			anim.AddChannel(ch)
+		}
+	}
+	return anim, nil
+}
+
+// curve contains the keys of one animation curve
+type curve struct {
+	times  []int64
+	values []float64
+}
+
+// eval evaluates the curve at the specified time using linear interpolation
+func (c *curve) eval(t int64) float64 {
+
+	n := len(c.times)
+	if n == 0 || len(c.values) < n {
+		return 0
+	}
+	i := sort.Search(n, func(i int) bool { return c.times[i] >= t })
+	if i == 0 {
+		return c.values[0]
+	}
+	if i == n {
+		return c.values[n-1]
+	}
+	if c.times[i] == t {
+		return c.values[i]
+	}
+	k := float64(t-c.times[i-1]) / float64(c.times[i]-c.times[i-1])
+	return c.values[i-1] + (c.values[i]-c.values[i-1])*k
+}
+
+// loadChannel creates an animation channel for the specified curve node
+// which animates the specified model property.
+func (f *FBX) loadChannel(cn *object, model *object, prop string, node core.INode) animation.IChannel {
+
+	// Default values from the model and curve node
+	var def [3]float64
+	switch prop {
+	case "Lcl Scaling":
+		def = model.node.PropVector(prop, [3]float64{1, 1, 1})
+	default:
+		def = model.node.PropVector(prop, [3]float64{0, 0, 0})
+	}
+	var curves [3]*curve
+	axes := [3]string{"d|X", "d|Y", "d|Z"}
+	for i, axis := range axes {
+		def[i] = cn.node.PropFloat(axis, def[i])
+	}
+	times := make([]int64, 0)
+	for _, c := range f.children[cn.id] {
+		ob := f.objects[c.child]
+		if ob == nil || ob.class != "AnimationCurve" {
+			continue
+		}
+		for i, axis := range axes {
+			if c.prop == axis {
+				curves[i] = &curve{ob.node.Child("KeyTime").Ints(0), ob.node.Child("KeyValueFloat").Floats(0)}
+				times = append(times, curves[i].times...)
+			}
+		}
+	}
+	if len(times) == 0 {
+		return nil
+	}
+
+	// Sorted unique key times of all curves
+	sort.Slice(times, func(i, j int) bool { return times[i] < times[j] })
+	unique := times[:1]
+	for _, t := range times[1:] {
+		if t != unique[len(unique)-1] {
+			unique = append(unique, t)
+		}
+	}
+
+	keyframes := math32.NewArrayF32(0, len(unique))
+	values := math32.NewArrayF32(0, 0)
+	order := int(model.node.PropFloat("RotationOrder", 0))
+	pre := model.node.PropVector("PreRotation", [3]float64{})
+	post := model.node.PropVector("PostRotation", [3]float64{})
+	var last math32.Quaternion
+	for k, t := range unique {
+		keyframes.Append(float32(float64(t) / ticksPerSecond))
+		var v [3]float64
+		for i := range v {
+			v[i] = def[i]
+			if curves[i] != nil {
+				v[i] = curves[i].eval(t)
+			}
+		}
+		if prop != "Lcl Rotation" {
+			values.Append(float32(v[0]), float32(v[1]), float32(v[2]))
+			continue
+		}
+		q := modelRotation(pre, v, post, order)
+		// Keeps quaternions in the same hemisphere for interpolation
+		if k > 0 && q.Dot(&last) < 0 {
+			q.Set(-q.X, -q.Y, -q.Z, -q.W)
+		}
+		last = q
+		values.Append(q.X, q.Y, q.Z, q.W)
+	}
+
+	var ch animation.IChannel
+	switch prop {
+	case "Lcl Translation":
+		ch = animation.NewPositionChannel(node)
+	case "Lcl Rotation":
+		ch = animation.NewRotationChannel(node)
+	default:
+		ch = animation.NewScaleChannel(node)
+	}
+	ch.SetBuffers(keyframes, values)
+	ch.SetInterpolationType(animation.LINEAR)
+	return ch
+}
+
+// eulerQuaternion returns the quaternion for the specified euler angles
+// in degrees using the specified FBX rotation order.
+func eulerQuaternion(deg [3]float64, order int) math32.Quaternion {
+
+	var q [3]math32.Quaternion
+	axes := [3]math32.Vector3{{1, 0, 0}, {0, 1, 0}, {0, 0, 1}}
+	for i := range q {
+		q[i].SetFromAxisAngle(&axes[i], float32(deg[i])*math32.Pi/180)
+	}
+	// The first axis of the order is applied first
+	var seq [3]int
+	switch order {
+	case 1: // XZY
+		seq = [3]int{0, 2, 1}
+	case 2: // YZX
+		seq = [3]int{1, 2, 0}
+	case 3: // YXZ
+		seq = [3]int{1, 0, 2}
+	case 4: // ZXY
+		seq = [3]int{2, 0, 1}
+	case 5: // ZYX
+		seq = [3]int{2, 1, 0}
+	default: // XYZ and spheric XYZ
+		seq = [3]int{0, 1, 2}
+	}
+	var res math32.Quaternion
+	res.MultiplyQuaternions(&q[seq[2]], &q[seq[1]])
+	res.Multiply(&q[seq[0]])
+	return res
+}
+
+// modelRotation returns the model rotation including the pre and post rotations
+func modelRotation(pre, rot, post [3]float64, order int) math32.Quaternion {
+
+	qpre := eulerQuaternion(pre, 0)
+	qrot := eulerQuaternion(rot, order)
+	qpost := eulerQuaternion(post, 0)
+	qpost.Inverse()
+	var q math32.Quaternion
+	q.MultiplyQuaternions(&qpre, &qrot)
+	q.Multiply(&qpost)
+	return q
+}
+
+// localMatrix calculates the local transform matrix of the specified model record:
+// T * Roff * Rp * Rpre * R * Rpost^-1 * Rp^-1 * Soff * Sp * S * Sp^-1
+func localMatrix(n *Node, m *math32.Matrix4) {
+
+	vec := func(name string, def float64) math32.Vector3 {
+		v := n.PropVector(name, [3]float64{def, def, def})
+		return math32.Vector3{float32(v[0]), float32(v[1]), float32(v[2])}
+	}
+	translate := func(v math32.Vector3) *math32.Matrix4 {
+		var t math32.Matrix4
+		t.MakeTranslation(v.X, v.Y, v.Z)
+		return &t
+	}
+
+	q := modelRotation(n.PropVector("PreRotation", [3]float64{}), n.PropVector("Lcl Rotation", [3]float64{}),
+		n.PropVector("PostRotation", [3]float64{}), int(n.PropFloat("RotationOrder", 0)))
+	var r, s math32.Matrix4
+	r.MakeRotationFromQuaternion(&q)
+	scale := vec("Lcl Scaling", 1)
+	s.MakeScale(scale.X, scale.Y, scale.Z)
+
+	rp := vec("RotationPivot", 0)
+	sp := vec("ScalingPivot", 0)
+	nrp := rp
+	nrp.Negate()
+	nsp := sp
+	nsp.Negate()
+
+	m.Identity()
+	m.Multiply(translate(vec("Lcl Translation", 0)))
+	m.Multiply(translate(vec("RotationOffset", 0)))
+	m.Multiply(translate(rp))
+	m.Multiply(&r)
+	m.Multiply(translate(nrp))
+	m.Multiply(translate(vec("ScalingOffset", 0)))
+	m.Multiply(translate(sp))
+	m.Multiply(&s)
+	m.Multiply(translate(nsp))
+}
+
+// geometricMatrix calculates the geometric transform of the specified
+// model record, which applies only to its geometry.
+// Returns false if the transform is the identity.
+func geometricMatrix(n *Node, m *math32.Matrix4) bool {
+
+	t := n.PropVector("GeometricTranslation", [3]float64{})
+	r := n.PropVector("GeometricRotation", [3]float64{})
+	s := n.PropVector("GeometricScaling", [3]float64{1, 1, 1})
+	if t == [3]float64{} && r == [3]float64{} && s == [3]float64{1, 1, 1} {
+		return false
+	}
+	q := eulerQuaternion(r, 0)
+	m.Compose(&math32.Vector3{float32(t[0]), float32(t[1]), float32(t[2])}, &q,
+		&math32.Vector3{float32(s[0]), float32(s[1]), float32(s[2])})
+	return true
+}
+
+// toMatrix converts the specified 16 values array to a matrix
+func toMatrix(vals []float64, m *math32.Matrix4) {
+
+	m.Identity()
+	for i := 0; i < 16 && i < len(vals); i++ {
+		m[i] = float32(vals[i])
+	}
+}
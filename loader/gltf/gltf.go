@@ -6,6 +6,8 @@
 package gltf
 
 import (
+	"encoding/json"
+
 	"github.com/sansebasko/engine/animation"
 	"github.com/sansebasko/engine/camera"
 	"github.com/sansebasko/engine/core"
@@ -22,6 +24,7 @@ const (
 	KhrMaterialsUnlit                 = "KHR_materials_unlit"
 	KhrMaterialsCommon                = "KHR_materials_common" // TODO this is officially part of glTF 1.0 (remove?)
 	KhrMaterialsPbrSpecularGlossiness = "KHR_materials_pbrSpecularGlossiness"
+	KhrTextureBasisu                  = "KHR_texture_basisu" // Only KTX2 images with GPU block formats, see texture.DecodeKTX2
 )
 
 // GLTF is the root object for a glTF asset.
@@ -335,10 +338,29 @@ type Target struct {
 // Texture represents a texture and its sampler.
 type Texture struct {
 	Sampler    *int                   // The index of the sampler used by this texture. When undefined, a sampler with REPEAT wrapping and AUTO filtering should be used. Not required.
-	Source     int                    // The index of the image used by this texture. Not required.
+	Source     int                    // The index of the image used by this texture (0 if undefined). Not required.
 	Name       string                 // The user-defined name of this object. Not required.
 	Extensions map[string]interface{} // Dictionary object with extension-specific objects. Not required. Not required.
 	Extras     interface{}            // Application-specific data. Not required. Not required.
+	hasSource  bool                   // Indicates if the source was defined
+}
+
+// UnmarshalJSON decodes a texture recording if its source is defined, because
+// textures with an image of the KHR_texture_basisu extension may have no fallback source.
+func (t *Texture) UnmarshalJSON(data []byte) error {
+
+	type texture Texture
+	var tex texture
+	if err := json.Unmarshal(data, &tex); err != nil {
+		return err
+	}
+	var source struct{ Source *int }
+	if err := json.Unmarshal(data, &source); err != nil {
+		return err
+	}
+	*t = Texture(tex)
+	t.hasSource = source.Source != nil
+	return nil
 }
 
 // TextureInfo is a reference to a texture.
@@ -730,16 +730,34 @@ func (g *GLTF) LoadTexture(texIdx int) (*texture.Texture2D, error) {
 	// NOTE: Textures can't be cached because they have their own uniforms
 	log.Debug("Loading Texture %d", texIdx)
 
+	// Loads the KTX2 image of the KHR_texture_basisu extension, using the
+	// image of the texture source as fallback if it could not be loaded.
+	// Basis Universal payloads (ETC1S and UASTC), which are the usual content
+	// of these images, cannot be transcoded and always use the fallback image.
+	var tex *texture.Texture2D
+	if extData, ok := texData.Extensions[KhrTextureBasisu]; ok {
+		var err error
+		tex, err = g.loadTextureBasisu(extData)
+		if err != nil {
+			if !texData.hasSource {
+				return nil, fmt.Errorf("%s image without fallback source: %v", KhrTextureBasisu, err)
+			}
+			log.Warn("Texture %d: %v (using fallback image)", texIdx, err)
+		}
+	}
+
 	// Load texture image
-	img, err := g.LoadImage(texData.Source)
-	if err != nil {
-		return nil, err
+	if tex == nil {
+		img, err := g.LoadImage(texData.Source)
+		if err != nil {
+			return nil, err
+		}
+		tex = texture.NewTexture2DFromRGBA(img)
 	}
-	tex := texture.NewTexture2DFromRGBA(img)
 
 	// Get sampler and apply texture parameters
 	if texData.Sampler != nil {
-		err = g.applySampler(*texData.Sampler, tex)
+		err := g.applySampler(*texData.Sampler, tex)
 		if err != nil {
 			return nil, err
 		}
@@ -748,6 +766,29 @@ func (g *GLTF) LoadTexture(texIdx int) (*texture.Texture2D, error) {
 	return tex, nil
 }
 
+// loadTextureBasisu loads the KTX2 image referenced by the KHR_texture_basisu texture extension.
+// Returns texture.ErrBasisUniversal for Basis Universal payloads, which are not supported.
+func (g *GLTF) loadTextureBasisu(extData interface{}) (*texture.Texture2D, error) {
+
+	ext, ok := extData.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("invalid %s extension", KhrTextureBasisu)
+	}
+	source, ok := ext["source"].(float64)
+	if !ok {
+		return nil, fmt.Errorf("%s extension without source", KhrTextureBasisu)
+	}
+	data, err := g.loadImageData(int(source))
+	if err != nil {
+		return nil, err
+	}
+	ci, err := texture.DecodeKTX2(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	return texture.NewTexture2DFromCompressed(ci), nil
+}
+
 // applySamplers applies the specified Sampler to the provided texture.
 func (g *GLTF) applySampler(samplerIdx int, tex *texture.Texture2D) error {
 
@@ -805,26 +846,25 @@ func (g *GLTF) LoadImage(imgIdx int) (*image.RGBA, error) {
 	}
 	log.Debug("Loading Image %d", imgIdx)
 
-	var data []byte
-	var err error
-	// If Uri is empty, load image from GLB binary chunk
-	if imgData.Uri == "" {
-		if imgData.BufferView == nil {
-			return nil, fmt.Errorf("image has empty URI and no BufferView")
-		}
-		data, err = g.loadBufferView(*imgData.BufferView)
-	} else if isDataURL(imgData.Uri) {
-		// Checks if image URI is data URL
-		data, err = loadDataURL(imgData.Uri)
-	} else {
-		// Load image data from file
-		data, err = g.loadFileBytes(imgData.Uri)
-	}
-
+	data, err := g.loadImageData(imgIdx)
 	if err != nil {
 		return nil, err
 	}
 
+	// Decompresses the base level of DDS, KTX and KTX2 images
+	if texture.IsCompressedContainer(data) {
+		ci, err := texture.DecodeCompressed(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		rgba, err := ci.RGBA(0)
+		if err != nil {
+			return nil, err
+		}
+		g.Images[imgIdx].cache = rgba
+		return rgba, nil
+	}
+
 	// Decodes image data
 	bb := bytes.NewBuffer(data)
 	img, _, err := image.Decode(bb)
@@ -845,6 +885,30 @@ func (g *GLTF) LoadImage(imgIdx int) (*image.RGBA, error) {
 	return rgba, nil
 }
 
+// loadImageData loads the encoded data of the image specified by the index of GLTF.Images
+// from the binary chunk, a data URI or an external file.
+func (g *GLTF) loadImageData(imgIdx int) ([]byte, error) {
+
+	if imgIdx < 0 || imgIdx >= len(g.Images) {
+		return nil, fmt.Errorf("invalid image index")
+	}
+	imgData := g.Images[imgIdx]
+
+	// If Uri is empty, load image from GLB binary chunk
+	if imgData.Uri == "" {
+		if imgData.BufferView == nil {
+			return nil, fmt.Errorf("image has empty URI and no BufferView")
+		}
+		return g.loadBufferView(*imgData.BufferView)
+	}
+	// Checks if image URI is data URL
+	if isDataURL(imgData.Uri) {
+		return loadDataURL(imgData.Uri)
+	}
+	// Load image data from file
+	return g.loadFileBytes(imgData.Uri)
+}
+
 // bytesToArrayU32 converts a byte array to ArrayU32.
 func (g *GLTF) bytesToArrayU32(data []byte, componentType, count int) (math32.ArrayU32, error) {
 
@@ -0,0 +1,180 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ply
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+
+	"github.com/sansebasko/engine/geometry"
+	"github.com/sansebasko/engine/gls"
+	"github.com/sansebasko/engine/math32"
+)
+
+// encodeAttrib describes one geometry attribute written as vertex properties
+type encodeAttrib struct {
+	names  []string         // property names for each attribute element
+	ptype  string           // property type
+	scale  float32          // scale applied to the values before writing
+	buffer *math32.ArrayF32 // VBO buffer
+	offset int              // attribute offset in the VBO item
+	stride int              // VBO stride
+}
+
+// Maps known attribute types to PLY property names
+var attribNames = map[gls.AttribType][]string{
+	gls.VertexPosition: {"x", "y", "z"},
+	gls.VertexNormal:   {"nx", "ny", "nz"},
+	gls.VertexColor:    {"red", "green", "blue"},
+	gls.VertexTexcoord: {"s", "t"},
+}
+
+// Encode writes the specified geometry to the specified writer in the PLY
+// format using the specified data format (FormatASCII, FormatBinaryLittleEndian
+// or FormatBinaryBigEndian).
+// Positions, normals, colors, texture coordinates and custom attributes are
+// written as vertex properties. Colors are written as unsigned bytes.
+// Custom attributes with more than one element are written as one property per
+// element named <name>_<index>, so they are decoded as separate scalar attributes.
+// If the geometry is not indexed, each three consecutive vertices form a face.
+func Encode(w io.Writer, igeom geometry.IGeometry, format string) error {
+
+	var order binary.ByteOrder
+	switch format {
+	case FormatASCII:
+	case FormatBinaryLittleEndian:
+		order = binary.LittleEndian
+	case FormatBinaryBigEndian:
+		order = binary.BigEndian
+	default:
+		return fmt.Errorf("invalid format: %s", format)
+	}
+
+	geom := igeom.GetGeometry()
+	if geom.VBO(gls.VertexPosition) == nil {
+		return errors.New("geometry has no vertex positions")
+	}
+
+	// Collects the attributes to write with positions first
+	attribs := make([]encodeAttrib, 0)
+	for _, atype := range []gls.AttribType{gls.VertexPosition, gls.VertexNormal, gls.VertexColor, gls.VertexTexcoord} {
+		vbo := geom.VBO(atype)
+		if vbo == nil {
+			continue
+		}
+		ea := encodeAttrib{names: attribNames[atype], ptype: "float", scale: 1,
+			buffer: vbo.Buffer(), offset: vbo.AttribOffset(atype), stride: vbo.Stride()}
+		if atype == gls.VertexColor {
+			ea.ptype = "uchar"
+			ea.scale = 255
+		}
+		attribs = append(attribs, ea)
+	}
+	for _, vbo := range geom.VBOs() {
+		for _, attrib := range vbo.Attributes() {
+			if attrib.Type != gls.Undefined {
+				continue
+			}
+			ea := encodeAttrib{ptype: "float", scale: 1,
+				buffer: vbo.Buffer(), offset: vbo.AttribOffsetName(attrib.Name), stride: vbo.Stride()}
+			if attrib.NumElements == 1 {
+				ea.names = []string{attrib.Name}
+			} else {
+				for i := 0; i < int(attrib.NumElements); i++ {
+					ea.names = append(ea.names, attrib.Name+"_"+strconv.Itoa(i))
+				}
+			}
+			attribs = append(attribs, ea)
+		}
+	}
+	count := attribs[0].buffer.Size() / attribs[0].stride
+
+	// Builds the faces vertex indices
+	indices := geom.Indices()
+	if !geom.Indexed() {
+		indices = math32.NewArrayU32(0, count)
+		for i := 0; i < count; i++ {
+			indices.Append(uint32(i))
+		}
+	}
+	nfaces := indices.Size() / 3
+
+	// Writes header
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "ply\nformat %s 1.0\ncomment g3n generated\n", format)
+	fmt.Fprintf(bw, "element vertex %d\n", count)
+	for _, ea := range attribs {
+		for _, name := range ea.names {
+			fmt.Fprintf(bw, "property %s %s\n", ea.ptype, name)
+		}
+	}
+	fmt.Fprintf(bw, "element face %d\n", nfaces)
+	fmt.Fprintf(bw, "property list uchar int vertex_indices\n")
+	fmt.Fprintf(bw, "end_header\n")
+
+	// Writes vertices
+	var buf [4]byte
+	for i := 0; i < count; i++ {
+		for _, ea := range attribs {
+			base := i*ea.stride + ea.offset
+			for j := range ea.names {
+				v := (*ea.buffer)[base+j] * ea.scale
+				if ea.ptype == "uchar" {
+					b := uint8(math32.Clamp(v+0.5, 0, 255))
+					if order == nil {
+						fmt.Fprintf(bw, "%d ", b)
+					} else {
+						bw.WriteByte(b)
+					}
+					continue
+				}
+				if order == nil {
+					fmt.Fprintf(bw, "%s ", strconv.FormatFloat(float64(v), 'g', -1, 32))
+				} else {
+					order.PutUint32(buf[:], math.Float32bits(v))
+					bw.Write(buf[:])
+				}
+			}
+		}
+		if order == nil {
+			bw.WriteString("\n")
+		}
+	}
+
+	// Writes faces
+	for i := 0; i < nfaces; i++ {
+		if order == nil {
+			fmt.Fprintf(bw, "3 %d %d %d\n", indices[3*i], indices[3*i+1], indices[3*i+2])
+			continue
+		}
+		bw.WriteByte(3)
+		for j := 0; j < 3; j++ {
+			order.PutUint32(buf[:], indices[3*i+j])
+			bw.Write(buf[:])
+		}
+	}
+	return bw.Flush()
+}
+
+// EncodeFile writes the specified geometry to the specified file in the PLY format.
+func EncodeFile(path string, igeom geometry.IGeometry, format string) error {
+
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	err = Encode(f, igeom, format)
+	if err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,525 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package ply implements a decoder and an encoder for PLY (polygon file format)
+// files in the ASCII and binary (little and big endian) formats.
+// Vertex properties other than positions, normals, colors and texture
+// coordinates are decoded into custom geometry attributes.
+package ply
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/sansebasko/engine/geometry"
+	"github.com/sansebasko/engine/gls"
+	"github.com/sansebasko/engine/graphic"
+	"github.com/sansebasko/engine/material"
+	"github.com/sansebasko/engine/math32"
+)
+
+// Decoder contains all decoded data from a PLY file
+type Decoder struct {
+	Format    string                     // data format (FormatASCII, FormatBinaryLittleEndian or FormatBinaryBigEndian)
+	Comments  []string                   // header comments
+	Elements  []Element                  // elements declared in the header
+	Positions math32.ArrayF32            // vertices positions
+	Normals   math32.ArrayF32            // vertices normals (may be empty)
+	Colors    math32.ArrayF32            // vertices RGB colors (may be empty)
+	Uvs       math32.ArrayF32            // vertices texture coordinates (may be empty)
+	Custom    map[string]math32.ArrayF32 // other scalar vertex properties by name
+	Indices   math32.ArrayU32            // triangulated faces vertex indices (may be empty)
+	Warnings  []string                   // warning messages
+	custom    []string                   // names of custom properties in header order
+}
+
+// Element describes one element declared in the PLY header
+type Element struct {
+	Name       string     // element name
+	Count      int        // number of element instances
+	Properties []Property // element properties
+}
+
+// Property describes one property of an element
+type Property struct {
+	Name      string // property name
+	Type      string // property type (or list items type)
+	CountType string // type of the list count ("" if the property is not a list)
+}
+
+// Data formats
+const (
+	FormatASCII              = "ascii"
+	FormatBinaryLittleEndian = "binary_little_endian"
+	FormatBinaryBigEndian    = "binary_big_endian"
+)
+
+// Maps property type names to their sizes in bytes
+var typeSizes = map[string]int{
+	"char": 1, "int8": 1, "uchar": 1, "uint8": 1,
+	"short": 2, "int16": 2, "ushort": 2, "uint16": 2,
+	"int": 4, "int32": 4, "uint": 4, "uint32": 4,
+	"float": 4, "float32": 4, "double": 8, "float64": 8,
+}
+
+// Decode decodes the specified PLY file returning a decoder
+// object and an error.
+func Decode(path string) (*Decoder, error) {
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return DecodeReader(f)
+}
+
+// DecodeReader decodes the PLY data from the specified reader
+// returning a decoder object and an error.
+func DecodeReader(r io.Reader) (*Decoder, error) {
+
+	dec := new(Decoder)
+	dec.Comments = make([]string, 0)
+	dec.Elements = make([]Element, 0)
+	dec.Positions = math32.NewArrayF32(0, 0)
+	dec.Normals = math32.NewArrayF32(0, 0)
+	dec.Colors = math32.NewArrayF32(0, 0)
+	dec.Uvs = math32.NewArrayF32(0, 0)
+	dec.Custom = make(map[string]math32.ArrayF32)
+	dec.Indices = math32.NewArrayU32(0, 0)
+	dec.Warnings = make([]string, 0)
+
+	br := bufio.NewReader(r)
+	err := dec.parseHeader(br)
+	if err != nil {
+		return nil, err
+	}
+
+	var vr valueReader
+	switch dec.Format {
+	case FormatASCII:
+		sc := bufio.NewScanner(br)
+		sc.Split(bufio.ScanWords)
+		vr = &asciiReader{sc}
+	case FormatBinaryLittleEndian:
+		vr = &binaryReader{br, binary.LittleEndian}
+	case FormatBinaryBigEndian:
+		vr = &binaryReader{br, binary.BigEndian}
+	}
+
+	for i := range dec.Elements {
+		el := &dec.Elements[i]
+		// Elements without properties have no data
+		if len(el.Properties) == 0 {
+			continue
+		}
+		switch el.Name {
+		case "vertex":
+			err = dec.parseVertices(el, vr)
+		case "face":
+			err = dec.parseFaces(el, vr)
+		default:
+			dec.appendWarn("element not supported: " + el.Name)
+			err = dec.skipElement(el, vr)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("element %s: %v", el.Name, err)
+		}
+	}
+	if err := dec.checkIndices(); err != nil {
+		return nil, err
+	}
+	return dec, nil
+}
+
+// CustomNames returns the names of the custom vertex properties in header order.
+func (dec *Decoder) CustomNames() []string {
+
+	return dec.custom
+}
+
+// NewGeometry creates and returns a geometry from the decoded vertices and faces.
+// Each custom vertex property is added as a VBO with a custom
+// attribute of the same name and size 1.
+func (dec *Decoder) NewGeometry() *geometry.Geometry {
+
+	geom := geometry.NewGeometry()
+	if dec.Indices.Size() > 0 {
+		geom.SetIndices(dec.Indices)
+	}
+	geom.AddVBO(gls.NewVBO(dec.Positions).AddAttrib(gls.VertexPosition))
+	if dec.Normals.Size() > 0 {
+		geom.AddVBO(gls.NewVBO(dec.Normals).AddAttrib(gls.VertexNormal))
+	}
+	if dec.Colors.Size() > 0 {
+		geom.AddVBO(gls.NewVBO(dec.Colors).AddAttrib(gls.VertexColor))
+	}
+	if dec.Uvs.Size() > 0 {
+		geom.AddVBO(gls.NewVBO(dec.Uvs).AddAttrib(gls.VertexTexcoord))
+	}
+	for _, name := range dec.custom {
+		geom.AddVBO(gls.NewVBO(dec.Custom[name]).AddCustomAttrib(name, 1))
+	}
+	return geom
+}
+
+// NewMesh creates and returns a mesh with the decoded geometry.
+// If the vertices have colors the mesh uses a basic material
+// which renders the vertex colors, otherwise a standard gray material.
+func (dec *Decoder) NewMesh() *graphic.Mesh {
+
+	geom := dec.NewGeometry()
+	if dec.Colors.Size() > 0 {
+		return graphic.NewMesh(geom, material.NewBasic())
+	}
+	return graphic.NewMesh(geom, material.NewStandard(&math32.Color{0.7, 0.7, 0.7}))
+}
+
+// NewPoints creates and returns a point cloud with the decoded vertices.
+// This is useful for scans which contain only vertices and no faces.
+func (dec *Decoder) NewPoints() *graphic.Points {
+
+	geom := dec.NewGeometry()
+	geom.SetIndices(math32.NewArrayU32(0, 0))
+	return graphic.NewPoints(geom, material.NewPoint(&math32.Color{1, 1, 1}))
+}
+
+// parseHeader parses the PLY header up to and including the "end_header" line
+func (dec *Decoder) parseHeader(br *bufio.Reader) error {
+
+	line, err := br.ReadString('\n')
+	if err != nil || strings.TrimSpace(line) != "ply" {
+		return errors.New("invalid PLY file signature")
+	}
+
+	var el *Element
+	for {
+		line, err = br.ReadString('\n')
+		if err != nil {
+			return errors.New("unexpected end of PLY header")
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		switch fields[0] {
+		case "format":
+			if len(fields) < 3 {
+				return errors.New("invalid format line")
+			}
+			switch fields[1] {
+			case FormatASCII, FormatBinaryLittleEndian, FormatBinaryBigEndian:
+				dec.Format = fields[1]
+			default:
+				return fmt.Errorf("invalid format: %s", fields[1])
+			}
+		case "comment", "obj_info":
+			dec.Comments = append(dec.Comments, strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(line), fields[0])))
+		case "element":
+			if len(fields) < 3 {
+				return errors.New("invalid element line")
+			}
+			count, err := strconv.Atoi(fields[2])
+			if err != nil {
+				return err
+			}
+			if count < 0 {
+				return fmt.Errorf("invalid element count: %d", count)
+			}
+			dec.Elements = append(dec.Elements, Element{Name: fields[1], Count: count})
+			el = &dec.Elements[len(dec.Elements)-1]
+		case "property":
+			if el == nil {
+				return errors.New("property declared before any element")
+			}
+			var prop Property
+			if len(fields) >= 5 && fields[1] == "list" {
+				prop = Property{Name: fields[4], Type: fields[3], CountType: fields[2]}
+				if typeSizes[prop.CountType] == 0 {
+					return fmt.Errorf("invalid property type: %s", prop.CountType)
+				}
+			} else if len(fields) >= 3 {
+				prop = Property{Name: fields[2], Type: fields[1]}
+			} else {
+				return errors.New("invalid property line")
+			}
+			if typeSizes[prop.Type] == 0 {
+				return fmt.Errorf("invalid property type: %s", prop.Type)
+			}
+			el.Properties = append(el.Properties, prop)
+		case "end_header":
+			if dec.Format == "" {
+				return errors.New("PLY header without format")
+			}
+			return nil
+		default:
+			dec.appendWarn("header keyword not supported: " + fields[0])
+		}
+	}
+}
+
+// Positions of the known vertex properties in the vertex item read by parseVertices
+const (
+	slotPosition = 0
+	slotNormal   = 3
+	slotColor    = 6
+	slotUv       = 9
+	slotCount    = 11
+	slotCustom   = -1
+)
+
+// Maps known vertex property names to their slots
+var vertexSlots = map[string]int{
+	"x": slotPosition, "y": slotPosition + 1, "z": slotPosition + 2,
+	"nx": slotNormal, "ny": slotNormal + 1, "nz": slotNormal + 2,
+	"red": slotColor, "green": slotColor + 1, "blue": slotColor + 2,
+	"r": slotColor, "g": slotColor + 1, "b": slotColor + 2,
+	"diffuse_red": slotColor, "diffuse_green": slotColor + 1, "diffuse_blue": slotColor + 2,
+	"s": slotUv, "t": slotUv + 1, "u": slotUv, "v": slotUv + 1,
+	"texture_u": slotUv, "texture_v": slotUv + 1, "texture_s": slotUv, "texture_t": slotUv + 1,
+}
+
+// parseVertices reads all the instances of the vertex element
+func (dec *Decoder) parseVertices(el *Element, vr valueReader) error {
+
+	// Maps each property to its slot in the vertex item
+	slots := make([]int, len(el.Properties))
+	scales := make([]float64, len(el.Properties))
+	hasNormals, hasColors, hasUvs := false, false, false
+	for i, prop := range el.Properties {
+		if prop.CountType != "" {
+			dec.appendWarn("vertex list property not supported: " + prop.Name)
+			continue
+		}
+		slot, ok := vertexSlots[prop.Name]
+		if !ok {
+			slots[i] = slotCustom
+			if _, ok := dec.Custom[prop.Name]; !ok {
+				// The header count is not trusted to preallocate the values
+				dec.custom = append(dec.custom, prop.Name)
+				dec.Custom[prop.Name] = math32.NewArrayF32(0, 0)
+			}
+			continue
+		}
+		slots[i] = slot
+		scales[i] = 1
+		switch {
+		case slot >= slotUv:
+			hasUvs = true
+		case slot >= slotColor:
+			hasColors = true
+			// Integer colors are normalized to the range [0,1]
+			if prop.Type == "uchar" || prop.Type == "uint8" {
+				scales[i] = 1.0 / 255
+			} else if prop.Type == "ushort" || prop.Type == "uint16" {
+				scales[i] = 1.0 / 65535
+			}
+		case slot >= slotNormal:
+			hasNormals = true
+		}
+	}
+
+	var item [slotCount]float32
+	for n := 0; n < el.Count; n++ {
+		for i, prop := range el.Properties {
+			if prop.CountType != "" {
+				if err := skipList(&prop, vr); err != nil {
+					return err
+				}
+				continue
+			}
+			v, err := vr.read(prop.Type)
+			if err != nil {
+				return err
+			}
+			if slots[i] == slotCustom {
+				arr := dec.Custom[prop.Name]
+				arr.Append(float32(v))
+				dec.Custom[prop.Name] = arr
+				continue
+			}
+			item[slots[i]] = float32(v * scales[i])
+		}
+		dec.Positions.Append(item[slotPosition : slotPosition+3]...)
+		if hasNormals {
+			dec.Normals.Append(item[slotNormal : slotNormal+3]...)
+		}
+		if hasColors {
+			dec.Colors.Append(item[slotColor : slotColor+3]...)
+		}
+		if hasUvs {
+			dec.Uvs.Append(item[slotUv : slotUv+2]...)
+		}
+	}
+	return nil
+}
+
+// parseFaces reads all the instances of the face element
+// triangulating polygons with more than 3 vertices.
+func (dec *Decoder) parseFaces(el *Element, vr valueReader) error {
+
+	var indices []uint32
+	for n := 0; n < el.Count; n++ {
+		for _, prop := range el.Properties {
+			if prop.CountType == "" {
+				if _, err := vr.read(prop.Type); err != nil {
+					return err
+				}
+				continue
+			}
+			if prop.Name != "vertex_indices" && prop.Name != "vertex_index" {
+				if err := skipList(&prop, vr); err != nil {
+					return err
+				}
+				continue
+			}
+			count, err := readCount(&prop, vr)
+			if err != nil {
+				return err
+			}
+			// The indices are read one by one so an invalid count
+			// fails at the end of the data instead of allocating it
+			indices = indices[:0]
+			for i := 0; i < count; i++ {
+				v, err := vr.read(prop.Type)
+				if err != nil {
+					return err
+				}
+				if v < 0 || v >= math.MaxUint32 {
+					return fmt.Errorf("invalid vertex index: %v", v)
+				}
+				indices = append(indices, uint32(v))
+			}
+			for i := 1; i < len(indices)-1; i++ {
+				dec.Indices.Append(indices[0], indices[i], indices[i+1])
+			}
+		}
+	}
+	return nil
+}
+
+// skipElement reads and discards all the instances of the specified element
+func (dec *Decoder) skipElement(el *Element, vr valueReader) error {
+
+	for n := 0; n < el.Count; n++ {
+		for _, prop := range el.Properties {
+			var err error
+			if prop.CountType != "" {
+				err = skipList(&prop, vr)
+			} else {
+				_, err = vr.read(prop.Type)
+			}
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// skipList reads and discards one list property value
+func skipList(prop *Property, vr valueReader) error {
+
+	count, err := readCount(prop, vr)
+	if err != nil {
+		return err
+	}
+	for i := 0; i < count; i++ {
+		if _, err := vr.read(prop.Type); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readCount reads the number of values of a list property
+func readCount(prop *Property, vr valueReader) (int, error) {
+
+	count, err := vr.read(prop.CountType)
+	if err != nil {
+		return 0, err
+	}
+	if count < 0 || count > math.MaxInt32 || count != math.Trunc(count) {
+		return 0, fmt.Errorf("invalid list count: %v", count)
+	}
+	return int(count), nil
+}
+
+// checkIndices returns an error if any face refers to a missing vertex
+func (dec *Decoder) checkIndices() error {
+
+	vertices := uint32(dec.Positions.Size() / 3)
+	for _, idx := range dec.Indices {
+		if idx >= vertices {
+			return fmt.Errorf("face vertex index %d out of range (%d vertices)", idx, vertices)
+		}
+	}
+	return nil
+}
+
+func (dec *Decoder) appendWarn(msg string) {
+
+	dec.Warnings = append(dec.Warnings, "ply: "+msg)
+}
+
+// valueReader is the interface for readers of property values
+type valueReader interface {
+	read(ptype string) (float64, error)
+}
+
+// asciiReader reads property values from the body of an ASCII file
+type asciiReader struct {
+	sc *bufio.Scanner
+}
+
+func (ar *asciiReader) read(ptype string) (float64, error) {
+
+	if !ar.sc.Scan() {
+		if err := ar.sc.Err(); err != nil {
+			return 0, err
+		}
+		return 0, io.ErrUnexpectedEOF
+	}
+	return strconv.ParseFloat(ar.sc.Text(), 64)
+}
+
+// binaryReader reads property values from the body of a binary file
+type binaryReader struct {
+	r     io.Reader
+	order binary.ByteOrder
+}
+
+func (br *binaryReader) read(ptype string) (float64, error) {
+
+	var buf [8]byte
+	b := buf[:typeSizes[ptype]]
+	if _, err := io.ReadFull(br.r, b); err != nil {
+		return 0, err
+	}
+	switch ptype {
+	case "char", "int8":
+		return float64(int8(b[0])), nil
+	case "uchar", "uint8":
+		return float64(b[0]), nil
+	case "short", "int16":
+		return float64(int16(br.order.Uint16(b))), nil
+	case "ushort", "uint16":
+		return float64(br.order.Uint16(b)), nil
+	case "int", "int32":
+		return float64(int32(br.order.Uint32(b))), nil
+	case "uint", "uint32":
+		return float64(br.order.Uint32(b)), nil
+	case "float", "float32":
+		return float64(math.Float32frombits(br.order.Uint32(b))), nil
+	default:
+		return math.Float64frombits(br.order.Uint64(b)), nil
+	}
+}
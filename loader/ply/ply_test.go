@@ -0,0 +1,108 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ply
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+// square is an ASCII PLY file with a quad face, vertex colors and a custom property
+const square = `ply
+format ascii 1.0
+comment test square
+element vertex 4
+property float x
+property float y
+property float z
+property uchar red
+property uchar green
+property uchar blue
+property float quality
+element face 1
+property list uchar int vertex_indices
+end_header
+0 0 0 255 0 0 0.5
+1 0 0 0 255 0 1
+1 1 0 0 0 255 1.5
+0 1 0 255 255 255 2
+4 0 1 2 3
+`
+
+// Tests decoding an ASCII file and the round trip of its geometry in all the formats
+func TestDecodeEncode(t *testing.T) {
+
+	dec, err := DecodeReader(strings.NewReader(square))
+	if err != nil {
+		t.Fatal(err)
+	}
+	check := func(format string, dec *Decoder) {
+		if n := dec.Positions.Size(); n != 12 {
+			t.Errorf("%s: %d position values, expected 12", format, n)
+		}
+		if n := dec.Colors.Size(); n != 12 || dec.Colors[0] != 1 || dec.Colors[4] != 1 {
+			t.Errorf("%s: colors %v", format, dec.Colors)
+		}
+		if n := dec.Indices.Size(); n != 6 {
+			t.Errorf("%s: %d indices, expected 6", format, n)
+		}
+		q := dec.Custom["quality"]
+		if len(dec.CustomNames()) != 1 || q.Size() != 4 || q[3] != 2 {
+			t.Errorf("%s: custom properties %v %v", format, dec.CustomNames(), q)
+		}
+	}
+	check("decoded", dec)
+	if len(dec.Comments) != 1 || dec.Comments[0] != "test square" {
+		t.Errorf("comments %q", dec.Comments)
+	}
+
+	for _, format := range []string{FormatASCII, FormatBinaryLittleEndian, FormatBinaryBigEndian} {
+		var buf bytes.Buffer
+		if err := Encode(&buf, dec.NewGeometry(), format); err != nil {
+			t.Fatalf("%s: %v", format, err)
+		}
+		dec2, err := DecodeReader(&buf)
+		if err != nil {
+			t.Fatalf("%s: %v", format, err)
+		}
+		check(format, dec2)
+	}
+}
+
+// Tests that malformed files return errors
+func TestDecodeMalformed(t *testing.T) {
+
+	header := "ply\nformat ascii 1.0\n"
+	tests := []struct {
+		name string
+		data string
+	}{
+		{"signature", "plx\n"},
+		{"no format", "ply\nelement vertex 1\nend_header\n"},
+		{"format", "ply\nformat text 1.0\nend_header\n"},
+		{"truncated header", header + "element vertex 1\n"},
+		{"negative count", header + "element vertex -1\nproperty float x\nend_header\n"},
+		{"huge count", header + "element vertex 100000000000000\nproperty float quality\nend_header\n1\n"},
+		{"property type", header + "element vertex 1\nproperty float128 x\nend_header\n"},
+		{"property before element", header + "property float x\nend_header\n"},
+		{"truncated data", header + "element vertex 2\nproperty float x\nend_header\n1\n"},
+		{"value", header + "element vertex 1\nproperty float x\nend_header\nabc\n"},
+		{"list count", header + "element face 1\nproperty list uchar int vertex_indices\nend_header\n-3 0 1 2\n"},
+		{"index", header + "element vertex 1\nproperty float x\nelement face 1\nproperty list uchar int vertex_indices\nend_header\n0\n3 0 1 2\n"},
+		{"binary", "ply\nformat binary_little_endian 1.0\nelement face 1\nproperty list uint int vertex_indices\nend_header\n\xff\xff\xff\x7f\x00"},
+	}
+	for _, test := range tests {
+		if _, err := DecodeReader(strings.NewReader(test.data)); err == nil {
+			t.Errorf("%s: no error", test.name)
+		}
+	}
+
+	// Elements without properties have no data
+	dec, err := DecodeReader(strings.NewReader(header + "element vertex 100000000000000\nend_header\n"))
+	if err != nil || dec.Positions.Size() != 0 {
+		t.Errorf("element without properties: %v", err)
+	}
+}
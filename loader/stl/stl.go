@@ -0,0 +1,434 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package stl implements a decoder and an encoder for STL (stereolithography)
+// files in both the ASCII and the binary formats.
+package stl
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/sansebasko/engine/geometry"
+	"github.com/sansebasko/engine/gls"
+	"github.com/sansebasko/engine/graphic"
+	"github.com/sansebasko/engine/material"
+	"github.com/sansebasko/engine/math32"
+)
+
+// Decoder contains all decoded data from an STL file
+type Decoder struct {
+	Name      string          // solid name (ASCII) or header text (binary)
+	Binary    bool            // indicates the decoded file was in the binary format
+	Positions math32.ArrayF32 // vertices positions (three vertices per facet)
+	Normals   math32.ArrayF32 // vertices normals (facet normal repeated for each vertex)
+	Colors    math32.ArrayF32 // vertices colors from binary facet attributes (may be empty)
+	Warnings  []string        // warning messages
+	line      uint            // current line number (ASCII only)
+}
+
+// Local constants
+const (
+	headerSize = 80     // size of the binary header in bytes
+	facetSize  = 50     // size of one binary facet record in bytes
+	colorValid = 0x8000 // facet attribute color valid bit
+	colorMask  = 0x1F   // mask of one 5 bits color component
+	blanks     = "\r\n\t "
+)
+
+// Decode decodes the specified STL file returning a decoder
+// object and an error.
+func Decode(path string) (*Decoder, error) {
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return DecodeReader(f)
+}
+
+// DecodeReader decodes the STL data from the specified reader
+// returning a decoder object and an error.
+// The format (ASCII or binary) is detected automatically.
+func DecodeReader(r io.Reader) (*Decoder, error) {
+
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	dec := new(Decoder)
+	dec.Positions = math32.NewArrayF32(0, 0)
+	dec.Normals = math32.NewArrayF32(0, 0)
+	dec.Colors = math32.NewArrayF32(0, 0)
+	dec.Warnings = make([]string, 0)
+
+	if isBinary(data) {
+		dec.Binary = true
+		err = dec.decodeBinary(data)
+	} else {
+		err = dec.decodeASCII(data)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return dec, nil
+}
+
+// NewGeometry creates and returns a geometry from the decoded facets.
+// The geometry is not indexed: each facet has its own three vertices.
+func (dec *Decoder) NewGeometry() *geometry.Geometry {
+
+	geom := geometry.NewGeometry()
+	geom.AddVBO(gls.NewVBO(dec.Positions).AddAttrib(gls.VertexPosition))
+	geom.AddVBO(gls.NewVBO(dec.Normals).AddAttrib(gls.VertexNormal))
+	if dec.Colors.Size() > 0 {
+		geom.AddVBO(gls.NewVBO(dec.Colors).AddAttrib(gls.VertexColor))
+	}
+	return geom
+}
+
+// NewMesh creates and returns a mesh with the decoded geometry.
+// If the file contained facet colors the mesh uses a basic material
+// which renders the vertex colors, otherwise a standard gray material.
+func (dec *Decoder) NewMesh() *graphic.Mesh {
+
+	geom := dec.NewGeometry()
+	if dec.Colors.Size() > 0 {
+		return graphic.NewMesh(geom, material.NewBasic())
+	}
+	return graphic.NewMesh(geom, material.NewStandard(&math32.Color{0.7, 0.7, 0.7}))
+}
+
+// isBinary checks if the specified data is a binary STL file.
+// ASCII files must start with "solid" but some binary exporters also
+// write "solid" at the start of the header, so the expected binary size
+// is checked first.
+func isBinary(data []byte) bool {
+
+	if len(data) >= headerSize+4 {
+		count := binary.LittleEndian.Uint32(data[headerSize:])
+		if uint64(len(data)) == uint64(headerSize+4)+uint64(count)*facetSize {
+			return true
+		}
+	}
+	trimmed := bytes.TrimLeft(data, blanks)
+	return !bytes.HasPrefix(trimmed, []byte("solid"))
+}
+
+// decodeBinary decodes a binary STL file
+func (dec *Decoder) decodeBinary(data []byte) error {
+
+	if len(data) < headerSize+4 {
+		return errors.New("binary STL file too short")
+	}
+	header := data[:headerSize]
+	dec.Name = strings.TrimRight(string(header), "\x00 ")
+	count := int(binary.LittleEndian.Uint32(data[headerSize:]))
+	if len(data) < headerSize+4+count*facetSize {
+		return fmt.Errorf("binary STL file truncated: %d facets expected", count)
+	}
+
+	// Materialise Magics stores the default color in the header as "COLOR=" followed
+	// by RGBA bytes and uses the opposite meaning of the valid bit and component order.
+	magics := false
+	var defColor math32.Color
+	if idx := bytes.Index(header, []byte("COLOR=")); idx >= 0 && idx+10 <= headerSize {
+		magics = true
+		defColor.Set(float32(header[idx+6])/255, float32(header[idx+7])/255, float32(header[idx+8])/255)
+	}
+
+	colors := math32.NewArrayF32(0, 0)
+	hasColor := false
+	var normal math32.Vector3
+	var color math32.Color
+	pos := headerSize + 4
+	for i := 0; i < count; i++ {
+		rec := data[pos : pos+facetSize]
+		normal.Set(readFloat(rec[0:]), readFloat(rec[4:]), readFloat(rec[8:]))
+		var verts [3]math32.Vector3
+		for j := 0; j < 3; j++ {
+			off := 12 + j*12
+			verts[j].Set(readFloat(rec[off:]), readFloat(rec[off+4:]), readFloat(rec[off+8:]))
+		}
+		dec.appendFacet(&normal, &verts)
+
+		// Decode facet color from the attribute byte count
+		attr := binary.LittleEndian.Uint16(rec[48:])
+		if magics {
+			if attr&colorValid == 0 {
+				color.Set(float32(attr&colorMask)/31, float32((attr>>5)&colorMask)/31, float32((attr>>10)&colorMask)/31)
+				hasColor = true
+			} else {
+				color = defColor
+			}
+		} else {
+			if attr&colorValid != 0 {
+				color.Set(float32((attr>>10)&colorMask)/31, float32((attr>>5)&colorMask)/31, float32(attr&colorMask)/31)
+				hasColor = true
+			} else {
+				color.Set(1, 1, 1)
+			}
+		}
+		colors.AppendColor(&color, &color, &color)
+		pos += facetSize
+	}
+	if hasColor || magics {
+		dec.Colors = colors
+	}
+	return nil
+}
+
+// decodeASCII decodes an ASCII STL file
+func (dec *Decoder) decodeASCII(data []byte) error {
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	dec.line = 0
+	var normal math32.Vector3
+	var verts [3]math32.Vector3
+	nverts := 0
+	inLoop := false
+	for scanner.Scan() {
+		dec.line++
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		switch fields[0] {
+		case "solid":
+			if len(fields) > 1 {
+				dec.Name = strings.Join(fields[1:], " ")
+			}
+		case "facet":
+			if len(fields) < 5 || fields[1] != "normal" {
+				return dec.formatError("'facet' without normal")
+			}
+			err := parseVector(fields[2:5], &normal)
+			if err != nil {
+				return dec.formatError(err.Error())
+			}
+			nverts = 0
+		case "outer":
+			inLoop = true
+		case "vertex":
+			if !inLoop {
+				return dec.formatError("'vertex' outside of loop")
+			}
+			if len(fields) < 4 {
+				return dec.formatError("'vertex' with less than 3 coordinates")
+			}
+			if nverts >= 3 {
+				return dec.formatError("facet with more than 3 vertices")
+			}
+			err := parseVector(fields[1:4], &verts[nverts])
+			if err != nil {
+				return dec.formatError(err.Error())
+			}
+			nverts++
+		case "endloop":
+			inLoop = false
+		case "endfacet":
+			if nverts != 3 {
+				return dec.formatError("facet with less than 3 vertices")
+			}
+			dec.appendFacet(&normal, &verts)
+		case "endsolid":
+		default:
+			dec.appendWarn("keyword not supported: " + fields[0])
+		}
+	}
+	return scanner.Err()
+}
+
+// appendFacet appends the specified facet vertices and normal.
+// If the normal is zero it is calculated from the vertices.
+func (dec *Decoder) appendFacet(normal *math32.Vector3, verts *[3]math32.Vector3) {
+
+	n := *normal
+	if n.LengthSq() == 0 {
+		faceNormal(&verts[0], &verts[1], &verts[2], &n)
+	}
+	dec.Positions.AppendVector3(&verts[0], &verts[1], &verts[2])
+	dec.Normals.AppendVector3(&n, &n, &n)
+}
+
+func (dec *Decoder) formatError(msg string) error {
+
+	return fmt.Errorf("%s in line:%d", msg, dec.line)
+}
+
+func (dec *Decoder) appendWarn(msg string) {
+
+	wline := fmt.Sprintf("stl(%d): %s", dec.line, msg)
+	dec.Warnings = append(dec.Warnings, wline)
+}
+
+// Encode writes the specified geometry to the specified writer in the
+// STL format using the specified solid name.
+// If the geometry has a color attribute and the binary format is
+// requested, the colors of the first vertex of each facet are stored
+// in the facet attributes using the VisCAM/SolidView convention.
+func Encode(w io.Writer, igeom geometry.IGeometry, name string, binaryFormat bool) error {
+
+	geom := igeom.GetGeometry()
+	positions := geom.VBO(gls.VertexPosition)
+	if positions == nil {
+		return errors.New("geometry has no vertex positions")
+	}
+	pos := newAttribReader(positions, gls.VertexPosition)
+	var col *attribReader
+	if vbo := geom.VBO(gls.VertexColor); vbo != nil {
+		col = newAttribReader(vbo, gls.VertexColor)
+	}
+
+	// Builds the list of facet vertex indices
+	indices := geom.Indices()
+	if !geom.Indexed() {
+		indices = math32.NewArrayU32(0, pos.count())
+		for i := 0; i < pos.count(); i++ {
+			indices.Append(uint32(i))
+		}
+	}
+	nfacets := indices.Size() / 3
+
+	bw := bufio.NewWriter(w)
+	var verts [3]math32.Vector3
+	var normal math32.Vector3
+	var color math32.Color
+	if binaryFormat {
+		var header [headerSize]byte
+		copy(header[:], name)
+		bw.Write(header[:])
+		binary.Write(bw, binary.LittleEndian, uint32(nfacets))
+	} else {
+		fmt.Fprintf(bw, "solid %s\n", name)
+	}
+	for i := 0; i < nfacets; i++ {
+		for j := 0; j < 3; j++ {
+			pos.vector3(int(indices[3*i+j]), &verts[j])
+		}
+		faceNormal(&verts[0], &verts[1], &verts[2], &normal)
+		if binaryFormat {
+			var rec [facetSize]byte
+			writeVector(rec[0:], &normal)
+			for j := 0; j < 3; j++ {
+				writeVector(rec[12+j*12:], &verts[j])
+			}
+			if col != nil {
+				col.color(int(indices[3*i]), &color)
+				attr := uint16(colorValid) |
+					uint16(math32.Clamp(color.R, 0, 1)*31)<<10 |
+					uint16(math32.Clamp(color.G, 0, 1)*31)<<5 |
+					uint16(math32.Clamp(color.B, 0, 1)*31)
+				binary.LittleEndian.PutUint16(rec[48:], attr)
+			}
+			bw.Write(rec[:])
+			continue
+		}
+		fmt.Fprintf(bw, "facet normal %s %s %s\n", formatFloat(normal.X), formatFloat(normal.Y), formatFloat(normal.Z))
+		fmt.Fprintf(bw, "  outer loop\n")
+		for j := 0; j < 3; j++ {
+			fmt.Fprintf(bw, "    vertex %s %s %s\n", formatFloat(verts[j].X), formatFloat(verts[j].Y), formatFloat(verts[j].Z))
+		}
+		fmt.Fprintf(bw, "  endloop\n")
+		fmt.Fprintf(bw, "endfacet\n")
+	}
+	if !binaryFormat {
+		fmt.Fprintf(bw, "endsolid %s\n", name)
+	}
+	return bw.Flush()
+}
+
+// EncodeFile writes the specified geometry to the specified file in the STL format.
+func EncodeFile(path string, igeom geometry.IGeometry, name string, binaryFormat bool) error {
+
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	err = Encode(f, igeom, name, binaryFormat)
+	if err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// attribReader reads the items of one attribute from a possibly interleaved VBO
+type attribReader struct {
+	buffer *math32.ArrayF32
+	offset int
+	stride int
+}
+
+func newAttribReader(vbo *gls.VBO, atype gls.AttribType) *attribReader {
+
+	return &attribReader{vbo.Buffer(), vbo.AttribOffset(atype), vbo.Stride()}
+}
+
+func (ar *attribReader) count() int {
+
+	return ar.buffer.Size() / ar.stride
+}
+
+func (ar *attribReader) vector3(idx int, v *math32.Vector3) {
+
+	ar.buffer.GetVector3(idx*ar.stride+ar.offset, v)
+}
+
+func (ar *attribReader) color(idx int, c *math32.Color) {
+
+	ar.buffer.GetColor(idx*ar.stride+ar.offset, c)
+}
+
+// faceNormal calculates the normal of the triangle with the specified vertices
+func faceNormal(a, b, c *math32.Vector3, normal *math32.Vector3) {
+
+	var ab math32.Vector3
+	normal.SubVectors(c, b)
+	ab.SubVectors(a, b)
+	normal.Cross(&ab)
+	normal.Normalize()
+}
+
+func parseVector(fields []string, v *math32.Vector3) error {
+
+	var vals [3]float32
+	for i, f := range fields {
+		val, err := strconv.ParseFloat(f, 32)
+		if err != nil {
+			return err
+		}
+		vals[i] = float32(val)
+	}
+	v.Set(vals[0], vals[1], vals[2])
+	return nil
+}
+
+func readFloat(b []byte) float32 {
+
+	return math.Float32frombits(binary.LittleEndian.Uint32(b))
+}
+
+func writeVector(b []byte, v *math32.Vector3) {
+
+	binary.LittleEndian.PutUint32(b[0:], math.Float32bits(v.X))
+	binary.LittleEndian.PutUint32(b[4:], math.Float32bits(v.Y))
+	binary.LittleEndian.PutUint32(b[8:], math.Float32bits(v.Z))
+}
+
+func formatFloat(v float32) string {
+
+	return strconv.FormatFloat(float64(v), 'e', -1, 32)
+}
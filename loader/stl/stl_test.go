@@ -0,0 +1,86 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package stl
+
+import (
+	"bytes"
+	"encoding/binary"
+	"strings"
+	"testing"
+)
+
+// triangle is an ASCII STL file with one facet without normal
+const triangle = `solid test part
+facet normal 0 0 0
+  outer loop
+    vertex 0 0 0
+    vertex 1 0 0
+    vertex 0 1 0
+  endloop
+endfacet
+endsolid test part
+`
+
+// Tests decoding an ASCII file and the round trip of its geometry in both formats
+func TestDecodeEncode(t *testing.T) {
+
+	dec, err := DecodeReader(strings.NewReader(triangle))
+	if err != nil {
+		t.Fatal(err)
+	}
+	check := func(format string, dec *Decoder) {
+		if dec.Name != "test part" {
+			t.Errorf("%s: name %q", format, dec.Name)
+		}
+		if n := dec.Positions.Size(); n != 9 || dec.Positions[3] != 1 || dec.Positions[7] != 1 {
+			t.Errorf("%s: positions %v", format, dec.Positions)
+		}
+		// The zero normal is calculated from the vertices
+		if n := dec.Normals.Size(); n != 9 || dec.Normals[2] != 1 {
+			t.Errorf("%s: normals %v", format, dec.Normals)
+		}
+	}
+	check("decoded", dec)
+
+	for _, binaryFormat := range []bool{false, true} {
+		var buf bytes.Buffer
+		if err := Encode(&buf, dec.NewGeometry(), "test part", binaryFormat); err != nil {
+			t.Fatal(err)
+		}
+		dec2, err := DecodeReader(&buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if dec2.Binary != binaryFormat {
+			t.Errorf("binary format %v decoded as %v", binaryFormat, dec2.Binary)
+		}
+		check("encoded", dec2)
+	}
+}
+
+// Tests that malformed files return errors
+func TestDecodeMalformed(t *testing.T) {
+
+	truncated := make([]byte, headerSize+4+facetSize)
+	binary.LittleEndian.PutUint32(truncated[headerSize:], 0xFFFFFFFF)
+	tests := []struct {
+		name string
+		data string
+	}{
+		{"binary too short", "abc"},
+		{"binary truncated", string(truncated)},
+		{"facet without normal", "solid\nfacet\n"},
+		{"normal", "solid\nfacet normal 0 x 0\n"},
+		{"vertex outside of loop", "solid\nfacet normal 0 0 1\nvertex 0 0 0\n"},
+		{"vertex coordinates", "solid\nfacet normal 0 0 1\nouter loop\nvertex 0 0\n"},
+		{"missing vertices", "solid\nfacet normal 0 0 1\nouter loop\nvertex 0 0 0\nendloop\nendfacet\n"},
+		{"extra vertices", "solid\nfacet normal 0 0 1\nouter loop\n" + strings.Repeat("vertex 0 0 0\n", 4)},
+	}
+	for _, test := range tests {
+		if _, err := DecodeReader(strings.NewReader(test.data)); err == nil {
+			t.Errorf("%s: no error", test.name)
+		}
+	}
+}
@@ -69,6 +69,7 @@ type Material struct {
 	wireframe   bool                 // Whether to render only the wireframe
 	lineWidth   float32              // Line width for lines and mesh wireframe
 	textures    []*texture.Texture2D // List of textures
+	envMap      *texture.TextureCube // Optional environment cube map used for reflections
 
 	polyOffsetFactor float32 // polygon offset factor
 	polyOffsetUnits  float32 // polygon offset units
@@ -109,6 +110,7 @@ func (mat *Material) Init() *Material {
 	mat.polyOffsetFactor = 0
 	mat.polyOffsetUnits = 0
 	mat.textures = make([]*texture.Texture2D, 0)
+	mat.envMap = nil
 
 	// Setup shader defines and add default values
 	mat.ShaderDefines = *gls.NewShaderDefines()
@@ -144,6 +146,9 @@ func (mat *Material) Dispose() {
 	for i := 0; i < len(mat.textures); i++ {
 		mat.textures[i].Dispose()
 	}
+	if mat.envMap != nil {
+		mat.envMap.Dispose()
+	}
 	mat.Init()
 }
 
@@ -324,6 +329,11 @@ func (mat *Material) RenderSetup(gs *gls.GLS) {
 		tex.RenderSetup(gs, slotIdx, uniIdx)
 		samplerCounts[samplerName] = uniIdx + 1
 	}
+
+	// The environment map uses the texture unit after the material textures
+	if mat.envMap != nil {
+		mat.envMap.RenderSetup(gs, len(mat.textures))
+	}
 }
 
 // AddTexture adds the specified Texture2d to the material
@@ -362,3 +372,25 @@ func (mat *Material) TextureCount() int {
 
 	return len(mat.textures)
 }
+
+// SetEnvMap sets the environment cube map reflected by the material
+// or removes it if nil. The environment map is used by the standard, phong
+// and physical shaders and its sampler uniform must be named "EnvMap".
+func (mat *Material) SetEnvMap(tex *texture.TextureCube) {
+
+	mat.envMap = tex
+	mat.ShaderDefines.Unset("HAS_ENVMAP")
+	mat.ShaderDefines.Unset("ENVMAP_SRGB")
+	if tex != nil {
+		mat.ShaderDefines.Set("HAS_ENVMAP", "")
+		if !tex.HDR() {
+			mat.ShaderDefines.Set("ENVMAP_SRGB", "")
+		}
+	}
+}
+
+// EnvMap returns the current environment cube map of the material or nil
+func (mat *Material) EnvMap() *texture.TextureCube {
+
+	return mat.envMap
+}
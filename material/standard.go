@@ -17,14 +17,16 @@ type Standard struct {
 	Material             // Embedded material
 	uni      gls.Uniform // Uniform location cache
 	udata    struct {    // Combined uniform data in 6 vec3:
-		ambient    math32.Color // Ambient color reflectivity
-		diffuse    math32.Color // Diffuse color reflectivity
-		specular   math32.Color // Specular color reflectivity
-		emissive   math32.Color // Emissive color
-		shininess  float32      // Specular shininess factor
-		opacity    float32      // Opacity
-		psize      float32      // Point size
-		protationZ float32      // Point rotation around Z axis
+		ambient      math32.Color // Ambient color reflectivity
+		diffuse      math32.Color // Diffuse color reflectivity
+		specular     math32.Color // Specular color reflectivity
+		emissive     math32.Color // Emissive color
+		shininess    float32      // Specular shininess factor
+		opacity      float32      // Opacity
+		psize        float32      // Point size
+		protationZ   float32      // Point rotation around Z axis
+		reflectivity float32      // Environment map reflectivity
+		_            float32      // Padding to complete the last vec3
 	}
 }
 
@@ -53,6 +55,7 @@ func (ms *Standard) Init(shader string, color *math32.Color) {
 	ms.SetEmissiveColor(&math32.Color{0, 0, 0})
 	ms.SetShininess(30.0)
 	ms.SetOpacity(1.0)
+	ms.SetReflectivity(0)
 }
 
 // AmbientColor returns the material ambient color reflectivity.
@@ -108,6 +111,21 @@ func (ms *Standard) SetOpacity(opacity float32) {
 	ms.udata.opacity = opacity
 }
 
+// SetReflectivity sets how much of the environment map is reflected,
+// from 0 (no reflection) to 1 (mirror). It is only used if the material
+// has an environment map. Default is 0, so an environment map
+// must be set together with a reflectivity to be visible.
+func (ms *Standard) SetReflectivity(reflectivity float32) {
+
+	ms.udata.reflectivity = reflectivity
+}
+
+// Reflectivity returns the current environment map reflectivity.
+func (ms *Standard) Reflectivity() float32 {
+
+	return ms.udata.reflectivity
+}
+
 // RenderSetup is called by the engine before drawing the object
 // which uses this material
 func (ms *Standard) RenderSetup(gs *gls.GLS) {
@@ -348,16 +348,12 @@ func (r *Renderer) renderScene(iscene core.INode, icam camera.ICamera) error {
 			pos := r.panel3D.GetPanel().Pospix()
 			width, height := r.panel3D.GetPanel().Size()
 
-			// Get scale of window (for HiDPI support)
-			sX64, sY64 := r.panel3D.Root().Window().Scale()
-			sX := float32(sX64)
-			sY := float32(sY64)
-
-			// Modify position and height of scissor according to window scale (for HiDPI support)
-			width *= sX
-			height *= sY
-			pos.X *= sX
-			pos.Y *= sY
+			// Converts the position and size of the panel from GUI units to framebuffer pixels
+			scale := gui.Scale()
+			width *= scale
+			height *= scale
+			pos.X *= scale
+			pos.Y *= scale
 
 			_, _, _, viewheight := r.gs.GetViewport()
 			r.gs.Enable(gls.SCISSOR_TEST)
@@ -542,17 +538,21 @@ func (r *Renderer) renderPanel(ipan gui.IPanel) error {
 	}
 	// If panel is renderable, renders it
 	if pan.Renderable() {
-		// Sets shader program for the panel's material
-		grmat := pan.GetGraphic().Materials()[0]
-		mat := grmat.IMaterial().GetMaterial()
-		r.specs.Name = mat.Shader()
-		r.specs.ShaderUnique = mat.ShaderUnique()
-		_, err := r.shaman.SetProgram(&r.specs)
-		if err != nil {
-			return err
+		// Panels such as labels have additional materials drawn over the panel quad
+		materials := pan.GetGraphic().Materials()
+		for i := 0; i < len(materials); i++ {
+			// Sets shader program for the panel's material
+			grmat := &materials[i]
+			mat := grmat.IMaterial().GetMaterial()
+			r.specs.Name = mat.Shader()
+			r.specs.ShaderUnique = mat.ShaderUnique()
+			_, err := r.shaman.SetProgram(&r.specs)
+			if err != nil {
+				return err
+			}
+			// Render this panel's graphic material
+			grmat.Render(r.gs, &r.rinfo)
 		}
-		// Render this panel's graphic material
-		grmat.Render(r.gs, &r.rinfo)
 		r.stats.Panels++
 	}
 	pan.SetChanged(false)
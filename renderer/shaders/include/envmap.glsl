@@ -0,0 +1,31 @@
+//
+// Environment map (cube texture) for reflections
+//
+#ifdef HAS_ENVMAP
+
+uniform samplerCube EnvMap;
+in mat3 EnvMatrix;
+
+// Returns the color of the environment map in the specified direction in camera coordinates.
+// Cube maps are sampled with the X coordinate of the world direction inverted.
+vec4 envMapColor(vec3 dir) {
+
+    vec3 w = EnvMatrix * dir;
+    return texture(EnvMap, vec3(-w.x, w.y, w.z));
+}
+
+// Returns the color of the environment map in the specified direction in camera coordinates
+// at the specified mipmap level.
+vec4 envMapColorLod(vec3 dir, float lod) {
+
+    vec3 w = EnvMatrix * dir;
+    return textureLod(EnvMap, vec3(-w.x, w.y, w.z), lod);
+}
+
+// Returns the index of the last mipmap level of the environment map
+float envMapMaxLevel() {
+
+    return log2(float(textureSize(EnvMap, 0).x));
+}
+
+#endif
@@ -0,0 +1,4 @@
+#ifdef HAS_ENVMAP
+    // The inverse of the view rotation converts camera directions to world directions
+    EnvMatrix = transpose(mat3(ModelViewMatrix) * inverse(mat3(ModelMatrix)));
+#endif
@@ -0,0 +1,6 @@
+#ifdef HAS_ENVMAP
+    // Model matrix used to calculate the rotation from camera to world coordinates
+    uniform mat4 ModelMatrix;
+    // Rotation from camera to world coordinates for sampling the environment map
+    out mat3 EnvMatrix;
+#endif
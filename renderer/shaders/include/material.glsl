@@ -13,6 +13,7 @@ uniform vec3 Material[6];
 #define MatOpacity          Material[4].y
 #define MatPointSize        Material[4].z
 #define MatPointRotationZ   Material[5].x
+#define MatReflectivity     Material[5].y
 
 #if MAT_TEXTURES > 0
     // Texture unit sampler array
@@ -11,6 +11,7 @@ in vec2 FragTexcoord;
 #include <lights>
 #include <material>
 #include <phong_model>
+#include <envmap>
 
 // Final fragment color
 out vec4 FragColor;
@@ -45,6 +46,12 @@ void main() {
     vec3 Ambdiff, Spec;
     phongModel(Position, fragNormal, CamDir, vec3(matAmbient), vec3(matDiffuse), Ambdiff, Spec);
 
+#ifdef HAS_ENVMAP
+    // Mixes the reflected environment color
+    vec3 reflectDir = reflect(-normalize(CamDir), normalize(fragNormal));
+    Ambdiff = mix(Ambdiff, envMapColor(reflectDir).rgb, MatReflectivity);
+#endif
+
     // Final fragment color
     FragColor = min(vec4(Ambdiff + Spec, matDiffuse.a), vec4(1.0));
 }
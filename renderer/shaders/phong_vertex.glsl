@@ -11,6 +11,7 @@ uniform mat4 MVP;
 #include <material>
 #include <morphtarget_vertex_declaration>
 #include <bones_vertex_declaration>
+#include <envmap_vertex_declaration>
 
 // Output variables for Fragment shader
 out vec4 Position;
@@ -38,6 +39,7 @@ void main() {
     }
 #endif
     FragTexcoord = texcoord;
+    #include <envmap_vertex>
     vec3 vPosition = VertexPosition;
     mat4 finalWorld = mat4(1.0);
     #include <morphtarget_vertex>
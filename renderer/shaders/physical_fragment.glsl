@@ -11,6 +11,8 @@
 //     https://github.com/KhronosGroup/glTF-WebGL-PBR/#environment-maps
 // [4] "An Inexpensive BRDF Model for Physically based Rendering" by Christophe Schlick
 //     https://www.cs.virginia.edu/~jdl/bib/appearance/analytic%20models/schlick94b.pdf
+// [5] Physically Based Shading on Mobile
+//     https://www.unrealengine.com/en-US/blog/physically-based-shading-on-mobile
 
 //#extension GL_EXT_shader_texture_lod: enable
 //#extension GL_OES_standard_derivatives : enable
@@ -53,6 +55,7 @@ uniform vec4 Material[3];
 #define uRoughnessFactor    Material[2].y
 
 #include <lights>
+#include <envmap>
 
 // Inputs from vertex shader
 in vec3 Position;       // Vertex position in camera coordinates.
@@ -140,32 +143,36 @@ vec3 getNormal()
     return n;
 }
 
-// Calculation of the lighting contribution from an optional Image Based Light source.
-// Precomputed Environment Maps are required uniform inputs and are computed as outlined in [1].
-// See our README.md on Environment Maps [3] for additional discussion.
-vec3 getIBLContribution(PBRInfo pbrInputs, PBRLightInfo pbrLight, vec3 n, vec3 reflection)
+// Calculation of the lighting contribution from the environment map used as Image Based Light source.
+// The mipmap levels of the environment map approximate the prefiltered radiance for increasing
+// roughness and its last level approximates the irradiance.
+// The scale and bias to F0 are approximated analytically as in [5] instead of using a BRDF lookup table.
+#ifdef HAS_ENVMAP
+vec3 getIBLContribution(PBRInfo pbrInputs, vec3 n, vec3 v)
 {
-    float mipCount = 9.0; // resolution of 512x512
-    float lod = (pbrInputs.perceptualRoughness * mipCount);
-    // retrieve a scale and bias to F0. See [1], Figure 3
-    vec3 brdf = vec3(0.5,0.5,0.5);//SRGBtoLINEAR(texture(u_brdfLUT, vec2(pbrLight.NdotV, 1.0 - pbrInputs.perceptualRoughness))).rgb;
-    vec3 diffuseLight = vec3(0.5,0.5,0.5);//SRGBtoLINEAR(textureCube(u_DiffuseEnvSampler, n)).rgb;
-
-//#ifdef USE_TEX_LOD
-//    vec3 specularLight = SRGBtoLINEAR(textureCubeLodEXT(u_SpecularEnvSampler, reflection, lod)).rgb;
-//#else
-    vec3 specularLight = vec3(0.5,0.5,0.5);//SRGBtoLINEAR(textureCube(u_SpecularEnvSampler, reflection)).rgb;
-//#endif
-
-    vec3 diffuse = diffuseLight * pbrInputs.diffuseColor;
-    vec3 specular = specularLight * (pbrInputs.specularColor * brdf.x + brdf.y);
-
-    // For presentation, this allows us to disable IBL terms
-//    diffuse *= u_ScaleIBLAmbient.x;
-//    specular *= u_ScaleIBLAmbient.y;
-
+    float NdotV = clamp(abs(dot(n, v)), 0.001, 1.0);
+    vec3 reflection = -normalize(reflect(v, n));
+    float maxLevel = envMapMaxLevel();
+    float lod = pbrInputs.perceptualRoughness * maxLevel;
+
+    // Retrieve a scale and bias to F0. See [1], Figure 3
+    const vec4 c0 = vec4(-1.0, -0.0275, -0.572, 0.022);
+    const vec4 c1 = vec4(1.0, 0.0425, 1.04, -0.04);
+    vec4 r = pbrInputs.perceptualRoughness * c0 + c1;
+    float a004 = min(r.x * r.x, exp2(-9.28 * NdotV)) * r.x + r.y;
+    vec2 brdf = vec2(-1.04, 1.04) * a004 + r.zw;
+
+    vec4 diffuseSample = envMapColorLod(n, maxLevel);
+    vec4 specularSample = envMapColorLod(reflection, lod);
+#ifdef ENVMAP_SRGB
+    diffuseSample = SRGBtoLINEAR(diffuseSample);
+    specularSample = SRGBtoLINEAR(specularSample);
+#endif
+    vec3 diffuse = diffuseSample.rgb * pbrInputs.diffuseColor;
+    vec3 specular = specularSample.rgb * (pbrInputs.specularColor * brdf.x + brdf.y);
     return diffuse + specular;
 }
+#endif
 
 // Basic Lambertian diffuse
 // Implementation from Lambert's Photometria https://archive.org/details/lambertsphotome00lambgoog
@@ -361,9 +368,9 @@ void main() {
 #endif
 
     // Calculate lighting contribution from image based lighting source (IBL)
-//#ifdef USE_IBL
-//    color += getIBLContribution(pbrInputs, n, reflection);
-//#endif
+#ifdef HAS_ENVMAP
+    color += getIBLContribution(pbrInputs, getNormal(), normalize(CamDir));
+#endif
 
     // Apply optional PBR terms for additional (optional) shading
 #ifdef HAS_OCCLUSIONMAP
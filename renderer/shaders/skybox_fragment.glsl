@@ -0,0 +1,16 @@
+//
+// Fragment shader for skybox
+//
+uniform samplerCube EnvMap;
+
+// Input from vertex shader
+in vec3 CubeDir;
+
+// Final fragment color
+out vec4 FragColor;
+
+void main() {
+
+    // Cube maps are sampled with the X coordinate inverted
+    FragColor = texture(EnvMap, vec3(-CubeDir.x, CubeDir.y, CubeDir.z));
+}
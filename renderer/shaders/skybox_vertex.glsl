@@ -0,0 +1,18 @@
+//
+// Vertex shader for skybox
+//
+#include <attributes>
+
+// Model uniforms
+uniform mat4 MVP;
+
+// Output direction for sampling the cube map
+out vec3 CubeDir;
+
+void main() {
+
+    CubeDir = VertexPosition;
+    vec4 pos = MVP * vec4(VertexPosition, 1.0);
+    // Sets the depth of the skybox to the far plane
+    gl_Position = pos.xyww;
+}
@@ -62,6 +62,53 @@ const include_bones_vertex_declaration_source = `#ifdef BONE_INFLUENCERS
 #endif
 `
 
+const include_envmap_source = `//
+// Environment map (cube texture) for reflections
+//
+#ifdef HAS_ENVMAP
+
+uniform samplerCube EnvMap;
+in mat3 EnvMatrix;
+
+// Returns the color of the environment map in the specified direction in camera coordinates.
+// Cube maps are sampled with the X coordinate of the world direction inverted.
+vec4 envMapColor(vec3 dir) {
+
+    vec3 w = EnvMatrix * dir;
+    return texture(EnvMap, vec3(-w.x, w.y, w.z));
+}
+
+// Returns the color of the environment map in the specified direction in camera coordinates
+// at the specified mipmap level.
+vec4 envMapColorLod(vec3 dir, float lod) {
+
+    vec3 w = EnvMatrix * dir;
+    return textureLod(EnvMap, vec3(-w.x, w.y, w.z), lod);
+}
+
+// Returns the index of the last mipmap level of the environment map
+float envMapMaxLevel() {
+
+    return log2(float(textureSize(EnvMap, 0).x));
+}
+
+#endif
+`
+
+const include_envmap_vertex_source = `#ifdef HAS_ENVMAP
+    // The inverse of the view rotation converts camera directions to world directions
+    EnvMatrix = transpose(mat3(ModelViewMatrix) * inverse(mat3(ModelMatrix)));
+#endif
+`
+
+const include_envmap_vertex_declaration_source = `#ifdef HAS_ENVMAP
+    // Model matrix used to calculate the rotation from camera to world coordinates
+    uniform mat4 ModelMatrix;
+    // Rotation from camera to world coordinates for sampling the environment map
+    out mat3 EnvMatrix;
+#endif
+`
+
 const include_lights_source = `//
 // Lights uniforms
 //
@@ -119,6 +166,7 @@ uniform vec3 Material[6];
 #define MatOpacity          Material[4].y
 #define MatPointSize        Material[4].z
 #define MatPointRotationZ   Material[5].x
+#define MatReflectivity     Material[5].y
 
 #if MAT_TEXTURES > 0
     // Texture unit sampler array
@@ -496,6 +544,7 @@ in vec2 FragTexcoord;
 #include <lights>
 #include <material>
 #include <phong_model>
+#include <envmap>
 
 // Final fragment color
 out vec4 FragColor;
@@ -530,6 +579,12 @@ void main() {
     vec3 Ambdiff, Spec;
     phongModel(Position, fragNormal, CamDir, vec3(matAmbient), vec3(matDiffuse), Ambdiff, Spec);
 
+#ifdef HAS_ENVMAP
+    // Mixes the reflected environment color
+    vec3 reflectDir = reflect(-normalize(CamDir), normalize(fragNormal));
+    Ambdiff = mix(Ambdiff, envMapColor(reflectDir).rgb, MatReflectivity);
+#endif
+
     // Final fragment color
     FragColor = min(vec4(Ambdiff + Spec, matDiffuse.a), vec4(1.0));
 }
@@ -549,6 +604,7 @@ uniform mat4 MVP;
 #include <material>
 #include <morphtarget_vertex_declaration>
 #include <bones_vertex_declaration>
+#include <envmap_vertex_declaration>
 
 // Output variables for Fragment shader
 out vec4 Position;
@@ -576,6 +632,7 @@ void main() {
     }
 #endif
     FragTexcoord = texcoord;
+    #include <envmap_vertex>
     vec3 vPosition = VertexPosition;
     mat4 finalWorld = mat4(1.0);
     #include <morphtarget_vertex>
@@ -599,6 +656,8 @@ const physical_fragment_source = `//
 //     https://github.com/KhronosGroup/glTF-WebGL-PBR/#environment-maps
 // [4] "An Inexpensive BRDF Model for Physically based Rendering" by Christophe Schlick
 //     https://www.cs.virginia.edu/~jdl/bib/appearance/analytic%20models/schlick94b.pdf
+// [5] Physically Based Shading on Mobile
+//     https://www.unrealengine.com/en-US/blog/physically-based-shading-on-mobile
 
 //#extension GL_EXT_shader_texture_lod: enable
 //#extension GL_OES_standard_derivatives : enable
@@ -641,6 +700,7 @@ uniform vec4 Material[3];
 #define uRoughnessFactor    Material[2].y
 
 #include <lights>
+#include <envmap>
 
 // Inputs from vertex shader
 in vec3 Position;       // Vertex position in camera coordinates.
@@ -728,32 +788,36 @@ vec3 getNormal()
     return n;
 }
 
-// Calculation of the lighting contribution from an optional Image Based Light source.
-// Precomputed Environment Maps are required uniform inputs and are computed as outlined in [1].
-// See our README.md on Environment Maps [3] for additional discussion.
-vec3 getIBLContribution(PBRInfo pbrInputs, PBRLightInfo pbrLight, vec3 n, vec3 reflection)
+// Calculation of the lighting contribution from the environment map used as Image Based Light source.
+// The mipmap levels of the environment map approximate the prefiltered radiance for increasing
+// roughness and its last level approximates the irradiance.
+// The scale and bias to F0 are approximated analytically as in [5] instead of using a BRDF lookup table.
+#ifdef HAS_ENVMAP
+vec3 getIBLContribution(PBRInfo pbrInputs, vec3 n, vec3 v)
 {
-    float mipCount = 9.0; // resolution of 512x512
-    float lod = (pbrInputs.perceptualRoughness * mipCount);
-    // retrieve a scale and bias to F0. See [1], Figure 3
-    vec3 brdf = vec3(0.5,0.5,0.5);//SRGBtoLINEAR(texture(u_brdfLUT, vec2(pbrLight.NdotV, 1.0 - pbrInputs.perceptualRoughness))).rgb;
-    vec3 diffuseLight = vec3(0.5,0.5,0.5);//SRGBtoLINEAR(textureCube(u_DiffuseEnvSampler, n)).rgb;
-
-//#ifdef USE_TEX_LOD
-//    vec3 specularLight = SRGBtoLINEAR(textureCubeLodEXT(u_SpecularEnvSampler, reflection, lod)).rgb;
-//#else
-    vec3 specularLight = vec3(0.5,0.5,0.5);//SRGBtoLINEAR(textureCube(u_SpecularEnvSampler, reflection)).rgb;
-//#endif
-
-    vec3 diffuse = diffuseLight * pbrInputs.diffuseColor;
-    vec3 specular = specularLight * (pbrInputs.specularColor * brdf.x + brdf.y);
-
-    // For presentation, this allows us to disable IBL terms
-//    diffuse *= u_ScaleIBLAmbient.x;
-//    specular *= u_ScaleIBLAmbient.y;
-
+    float NdotV = clamp(abs(dot(n, v)), 0.001, 1.0);
+    vec3 reflection = -normalize(reflect(v, n));
+    float maxLevel = envMapMaxLevel();
+    float lod = pbrInputs.perceptualRoughness * maxLevel;
+
+    // Retrieve a scale and bias to F0. See [1], Figure 3
+    const vec4 c0 = vec4(-1.0, -0.0275, -0.572, 0.022);
+    const vec4 c1 = vec4(1.0, 0.0425, 1.04, -0.04);
+    vec4 r = pbrInputs.perceptualRoughness * c0 + c1;
+    float a004 = min(r.x * r.x, exp2(-9.28 * NdotV)) * r.x + r.y;
+    vec2 brdf = vec2(-1.04, 1.04) * a004 + r.zw;
+
+    vec4 diffuseSample = envMapColorLod(n, maxLevel);
+    vec4 specularSample = envMapColorLod(reflection, lod);
+#ifdef ENVMAP_SRGB
+    diffuseSample = SRGBtoLINEAR(diffuseSample);
+    specularSample = SRGBtoLINEAR(specularSample);
+#endif
+    vec3 diffuse = diffuseSample.rgb * pbrInputs.diffuseColor;
+    vec3 specular = specularSample.rgb * (pbrInputs.specularColor * brdf.x + brdf.y);
     return diffuse + specular;
 }
+#endif
 
 // Basic Lambertian diffuse
 // Implementation from Lambert's Photometria https://archive.org/details/lambertsphotome00lambgoog
@@ -949,9 +1013,9 @@ void main() {
 #endif
 
     // Calculate lighting contribution from image based lighting source (IBL)
-//#ifdef USE_IBL
-//    color += getIBLContribution(pbrInputs, n, reflection);
-//#endif
+#ifdef HAS_ENVMAP
+    color += getIBLContribution(pbrInputs, getNormal(), normalize(CamDir));
+#endif
 
     // Apply optional PBR terms for additional (optional) shading
 #ifdef HAS_OCCLUSIONMAP
@@ -1016,6 +1080,7 @@ uniform mat4 MVP;
 
 #include <morphtarget_vertex_declaration>
 #include <bones_vertex_declaration>
+#include <envmap_vertex_declaration>
 
 // Output variables for Fragment shader
 out vec3 Position;
@@ -1043,6 +1108,7 @@ void main() {
     //     }
     // #endif
     FragTexcoord = texcoord;
+    #include <envmap_vertex>
 
     vec3 vPosition = VertexPosition;
     mat4 finalWorld = mat4(1.0);
@@ -1132,6 +1198,44 @@ void main() {
 
 `
 
+const skybox_fragment_source = `//
+// Fragment shader for skybox
+//
+uniform samplerCube EnvMap;
+
+// Input from vertex shader
+in vec3 CubeDir;
+
+// Final fragment color
+out vec4 FragColor;
+
+void main() {
+
+    // Cube maps are sampled with the X coordinate inverted
+    FragColor = texture(EnvMap, vec3(-CubeDir.x, CubeDir.y, CubeDir.z));
+}
+`
+
+const skybox_vertex_source = `//
+// Vertex shader for skybox
+//
+#include <attributes>
+
+// Model uniforms
+uniform mat4 MVP;
+
+// Output direction for sampling the cube map
+out vec3 CubeDir;
+
+void main() {
+
+    CubeDir = VertexPosition;
+    vec4 pos = MVP * vec4(VertexPosition, 1.0);
+    // Sets the depth of the skybox to the far plane
+    gl_Position = pos.xyww;
+}
+`
+
 const sprite_fragment_source = `//
 // Fragment shader for sprite
 //
@@ -1205,6 +1309,7 @@ const standard_fragment_source = `//
 // Fragment Shader template
 //
 #include <material>
+#include <envmap>
 
 // Inputs from Vertex shader
 in vec3 ColorFrontAmbdiff;
@@ -1212,6 +1317,9 @@ in vec3 ColorFrontSpec;
 in vec3 ColorBackAmbdiff;
 in vec3 ColorBackSpec;
 in vec2 FragTexcoord;
+#ifdef HAS_ENVMAP
+in vec3 EnvReflect;
+#endif
 
 // Output
 out vec4 FragColor;
@@ -1242,7 +1350,12 @@ void main() {
         colorAmbDiff = vec4(ColorBackAmbdiff, MatOpacity);
         colorSpec = vec4(ColorBackSpec, 0);
     }
-    FragColor = min(colorAmbDiff * texMixed + colorSpec, vec4(1));
+    vec4 color = colorAmbDiff * texMixed;
+#ifdef HAS_ENVMAP
+    // Mixes the reflected environment color
+    color.rgb = mix(color.rgb, envMapColor(normalize(EnvReflect)).rgb, MatReflectivity);
+#endif
+    FragColor = min(color + colorSpec, vec4(1));
 }
 
 `
@@ -1262,6 +1375,7 @@ uniform mat4 MVP;
 #include <phong_model>
 #include <morphtarget_vertex_declaration>
 #include <bones_vertex_declaration>
+#include <envmap_vertex_declaration>
 
 // Outputs for the fragment shader.
 out vec3 ColorFrontAmbdiff;
@@ -1269,6 +1383,9 @@ out vec3 ColorFrontSpec;
 out vec3 ColorBackAmbdiff;
 out vec3 ColorBackSpec;
 out vec2 FragTexcoord;
+#ifdef HAS_ENVMAP
+out vec3 EnvReflect;
+#endif
 
 void main() {
 
@@ -1287,6 +1404,12 @@ void main() {
     phongModel(Position,  Normal, camDir, MatAmbientColor, MatDiffuseColor, ColorFrontAmbdiff, ColorFrontSpec);
     phongModel(Position, -Normal, camDir, MatAmbientColor, MatDiffuseColor, ColorBackAmbdiff, ColorBackSpec);
 
+#ifdef HAS_ENVMAP
+    // Reflected view direction in camera coordinates
+    EnvReflect = reflect(-camDir, Normal);
+#endif
+    #include <envmap_vertex>
+
     vec2 texcoord = VertexTexcoord;
 #if MAT_TEXTURES > 0
     // Flips texture coordinate Y if requested.
@@ -1305,12 +1428,149 @@ void main() {
 
 `
 
+const text_fragment_source = `//
+// Fragment shader for text glyphs
+//
+
+// Texture uniforms
+uniform sampler2D	MatTexture;
+
+// Inputs from vertex shader
+in vec2 FragTexcoord;
+in vec2 FragPosition;
+
+// Input uniform
+uniform vec4 Text[2];
+#define Bounds			Text[0]		  // visible area in pixels from the top left of the text
+#define TextColor		Text[1]		  // text color
+
+// Output
+out vec4 FragColor;
+
+
+void main() {
+
+    // Discard fragment outside of received bounds
+    // Bounds[0] - xmin
+    // Bounds[1] - ymin
+    // Bounds[2] - xmax
+    // Bounds[3] - ymax
+    if (FragPosition.x <= Bounds[0] || FragPosition.x >= Bounds[2]) {
+        discard;
+    }
+    if (FragPosition.y <= Bounds[1] || FragPosition.y >= Bounds[3]) {
+        discard;
+    }
+
+    // The atlas keeps the glyph coverage in the alpha channel
+    vec2 size = vec2(textureSize(MatTexture, 0));
+    float coverage = texture(MatTexture, FragTexcoord / size).a;
+    FragColor = vec4(TextColor.rgb, TextColor.a * coverage);
+}
+
+`
+
+const text_sdf_fragment_source = `//
+// Fragment shader for signed distance field text
+//
+
+// Texture uniforms
+uniform sampler2D	MatTexture;
+
+// Inputs from vertex shader
+in vec2 FragTexcoord;
+
+// Input uniform
+uniform vec4 Text[3];
+#define TextColor		Text[0]		  // text color
+#define OutlineColor	Text[1]		  // outline color
+#define OutlineWidth	Text[2].x	  // outline width in distance units (0 to 0.5)
+
+// Output
+out vec4 FragColor;
+
+
+void main() {
+
+    // The atlas keeps the distance to the glyph outline in the alpha channel
+    // with 0.5 at the outline and greater values inside the glyph.
+    vec2 size = vec2(textureSize(MatTexture, 0));
+    float dist = texture(MatTexture, FragTexcoord / size).a;
+
+    // Antialiases the edges over about one screen pixel at any scale
+    float edge = 0.7 * fwidth(dist);
+    float alpha = smoothstep(0.5 - edge, 0.5 + edge, dist);
+    vec4 color = TextColor;
+
+    // The outline is drawn outside of the glyph outline
+    if (OutlineWidth > 0.0) {
+        float outer = 0.5 - OutlineWidth;
+        color = mix(OutlineColor, TextColor, alpha);
+        alpha = smoothstep(outer - edge, outer + edge, dist);
+    }
+    if (alpha <= 0.0) {
+        discard;
+    }
+    FragColor = vec4(color.rgb, color.a * alpha);
+}
+
+`
+
+const text_sdf_vertex_source = `//
+// Vertex shader for signed distance field text
+//
+#include <attributes>
+
+// Model uniforms
+uniform mat4 MVP;
+
+// Outputs for fragment shader
+out vec2 FragTexcoord;
+
+
+void main() {
+
+    // Texture coordinates are in atlas pixels
+    FragTexcoord = VertexTexcoord;
+    gl_Position = MVP * vec4(VertexPosition, 1.0);
+}
+
+`
+
+const text_vertex_source = `//
+// Vertex shader for text glyphs
+//
+#include <attributes>
+
+// Model uniforms
+uniform mat4 ModelMatrix;
+
+// Outputs for fragment shader
+out vec2 FragTexcoord;
+out vec2 FragPosition;
+
+
+void main() {
+
+    // Texture coordinates are in atlas pixels
+    FragTexcoord = VertexTexcoord;
+
+    // Vertex positions are in pixels from the top left of the text
+    FragPosition = VertexPosition.xy;
+    gl_Position = ModelMatrix * vec4(VertexPosition.xy, 0, 1);
+}
+
+`
+
 // Maps include name with its source code
 var includeMap = map[string]string{
 
 	"attributes":                      include_attributes_source,
 	"bones_vertex":                    include_bones_vertex_source,
 	"bones_vertex_declaration":        include_bones_vertex_declaration_source,
+	"envmap":                          include_envmap_source,
+	"envmap_vertex":                   include_envmap_vertex_source,
+	"envmap_vertex_declaration":       include_envmap_vertex_declaration_source,
 	"lights":                          include_lights_source,
 	"material":                        include_material_source,
 	"morphtarget_vertex":              include_morphtarget_vertex_source,
@@ -1333,10 +1593,16 @@ var shaderMap = map[string]string{
 	"physical_vertex":   physical_vertex_source,
 	"point_fragment":    point_fragment_source,
 	"point_vertex":      point_vertex_source,
+	"skybox_fragment":   skybox_fragment_source,
+	"skybox_vertex":     skybox_vertex_source,
 	"sprite_fragment":   sprite_fragment_source,
 	"sprite_vertex":     sprite_vertex_source,
 	"standard_fragment": standard_fragment_source,
 	"standard_vertex":   standard_vertex_source,
+	"text_fragment":     text_fragment_source,
+	"text_sdf_fragment": text_sdf_fragment_source,
+	"text_sdf_vertex":   text_sdf_vertex_source,
+	"text_vertex":       text_vertex_source,
 }
 
 // Maps program name with Proginfo struct with shaders names
@@ -1347,6 +1613,9 @@ var programMap = map[string]ProgramInfo{
 	"phong":    {"phong_vertex", "phong_fragment", ""},
 	"physical": {"physical_vertex", "physical_fragment", ""},
 	"point":    {"point_vertex", "point_fragment", ""},
+	"skybox":   {"skybox_vertex", "skybox_fragment", ""},
 	"sprite":   {"sprite_vertex", "sprite_fragment", ""},
 	"standard": {"standard_vertex", "standard_fragment", ""},
+	"text":     {"text_vertex", "text_fragment", ""},
+	"text_sdf": {"text_sdf_vertex", "text_sdf_fragment", ""},
 }
@@ -2,6 +2,7 @@
 // Fragment Shader template
 //
 #include <material>
+#include <envmap>
 
 // Inputs from Vertex shader
 in vec3 ColorFrontAmbdiff;
@@ -9,6 +10,9 @@ in vec3 ColorFrontSpec;
 in vec3 ColorBackAmbdiff;
 in vec3 ColorBackSpec;
 in vec2 FragTexcoord;
+#ifdef HAS_ENVMAP
+in vec3 EnvReflect;
+#endif
 
 // Output
 out vec4 FragColor;
@@ -39,6 +43,11 @@ void main() {
         colorAmbDiff = vec4(ColorBackAmbdiff, MatOpacity);
         colorSpec = vec4(ColorBackSpec, 0);
     }
-    FragColor = min(colorAmbDiff * texMixed + colorSpec, vec4(1));
+    vec4 color = colorAmbDiff * texMixed;
+#ifdef HAS_ENVMAP
+    // Mixes the reflected environment color
+    color.rgb = mix(color.rgb, envMapColor(normalize(EnvReflect)).rgb, MatReflectivity);
+#endif
+    FragColor = min(color + colorSpec, vec4(1));
 }
 
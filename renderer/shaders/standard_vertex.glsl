@@ -13,6 +13,7 @@ uniform mat4 MVP;
 #include <phong_model>
 #include <morphtarget_vertex_declaration>
 #include <bones_vertex_declaration>
+#include <envmap_vertex_declaration>
 
 // Outputs for the fragment shader.
 out vec3 ColorFrontAmbdiff;
@@ -20,6 +21,9 @@ out vec3 ColorFrontSpec;
 out vec3 ColorBackAmbdiff;
 out vec3 ColorBackSpec;
 out vec2 FragTexcoord;
+#ifdef HAS_ENVMAP
+out vec3 EnvReflect;
+#endif
 
 void main() {
 
@@ -38,6 +42,12 @@ void main() {
     phongModel(Position,  Normal, camDir, MatAmbientColor, MatDiffuseColor, ColorFrontAmbdiff, ColorFrontSpec);
     phongModel(Position, -Normal, camDir, MatAmbientColor, MatDiffuseColor, ColorBackAmbdiff, ColorBackSpec);
 
+#ifdef HAS_ENVMAP
+    // Reflected view direction in camera coordinates
+    EnvReflect = reflect(-camDir, Normal);
+#endif
+    #include <envmap_vertex>
+
     vec2 texcoord = VertexTexcoord;
 #if MAT_TEXTURES > 0
     // Flips texture coordinate Y if requested.
@@ -0,0 +1,40 @@
+//
+// Fragment shader for text glyphs
+//
+
+// Texture uniforms
+uniform sampler2D	MatTexture;
+
+// Inputs from vertex shader
+in vec2 FragTexcoord;
+in vec2 FragPosition;
+
+// Input uniform
+uniform vec4 Text[2];
+#define Bounds			Text[0]		  // visible area in pixels from the top left of the text
+#define TextColor		Text[1]		  // text color
+
+// Output
+out vec4 FragColor;
+
+
+void main() {
+
+    // Discard fragment outside of received bounds
+    // Bounds[0] - xmin
+    // Bounds[1] - ymin
+    // Bounds[2] - xmax
+    // Bounds[3] - ymax
+    if (FragPosition.x <= Bounds[0] || FragPosition.x >= Bounds[2]) {
+        discard;
+    }
+    if (FragPosition.y <= Bounds[1] || FragPosition.y >= Bounds[3]) {
+        discard;
+    }
+
+    // The atlas keeps the glyph coverage in the alpha channel
+    vec2 size = vec2(textureSize(MatTexture, 0));
+    float coverage = texture(MatTexture, FragTexcoord / size).a;
+    FragColor = vec4(TextColor.rgb, TextColor.a * coverage);
+}
+
@@ -0,0 +1,44 @@
+//
+// Fragment shader for signed distance field text
+//
+
+// Texture uniforms
+uniform sampler2D	MatTexture;
+
+// Inputs from vertex shader
+in vec2 FragTexcoord;
+
+// Input uniform
+uniform vec4 Text[3];
+#define TextColor		Text[0]		  // text color
+#define OutlineColor	Text[1]		  // outline color
+#define OutlineWidth	Text[2].x	  // outline width in distance units (0 to 0.5)
+
+// Output
+out vec4 FragColor;
+
+
+void main() {
+
+    // The atlas keeps the distance to the glyph outline in the alpha channel
+    // with 0.5 at the outline and greater values inside the glyph.
+    vec2 size = vec2(textureSize(MatTexture, 0));
+    float dist = texture(MatTexture, FragTexcoord / size).a;
+
+    // Antialiases the edges over about one screen pixel at any scale
+    float edge = 0.7 * fwidth(dist);
+    float alpha = smoothstep(0.5 - edge, 0.5 + edge, dist);
+    vec4 color = TextColor;
+
+    // The outline is drawn outside of the glyph outline
+    if (OutlineWidth > 0.0) {
+        float outer = 0.5 - OutlineWidth;
+        color = mix(OutlineColor, TextColor, alpha);
+        alpha = smoothstep(outer - edge, outer + edge, dist);
+    }
+    if (alpha <= 0.0) {
+        discard;
+    }
+    FragColor = vec4(color.rgb, color.a * alpha);
+}
+
@@ -0,0 +1,19 @@
+//
+// Vertex shader for signed distance field text
+//
+#include <attributes>
+
+// Model uniforms
+uniform mat4 MVP;
+
+// Outputs for fragment shader
+out vec2 FragTexcoord;
+
+
+void main() {
+
+    // Texture coordinates are in atlas pixels
+    FragTexcoord = VertexTexcoord;
+    gl_Position = MVP * vec4(VertexPosition, 1.0);
+}
+
@@ -0,0 +1,23 @@
+//
+// Vertex shader for text glyphs
+//
+#include <attributes>
+
+// Model uniforms
+uniform mat4 ModelMatrix;
+
+// Outputs for fragment shader
+out vec2 FragTexcoord;
+out vec2 FragPosition;
+
+
+void main() {
+
+    // Texture coordinates are in atlas pixels
+    FragTexcoord = VertexTexcoord;
+
+    // Vertex positions are in pixels from the top left of the text
+    FragPosition = VertexPosition.xy;
+    gl_Position = ModelMatrix * vec4(VertexPosition.xy, 0, 1);
+}
+
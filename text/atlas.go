@@ -6,112 +6,296 @@ package text
 
 import (
 	"bufio"
-	"fmt"
-	"github.com/sansebasko/engine/math32"
 	"image"
+	"image/draw"
 	"image/png"
 	"os"
-	"unicode/utf8"
+	"strings"
+
+	"github.com/golang/freetype/truetype"
+	"golang.org/x/image/font"
+	"golang.org/x/image/math/fixed"
 )
 
-// CharInfo contains the information to locate a character in an Atlas
-type CharInfo struct {
-	X      int // Position X in pixels in the sheet image from left to right
-	Y      int // Position Y in pixels in the sheet image from top to bottom
-	Width  int // Char width in pixels
-	Height int // Char heigh in pixels
-	// Normalized position of char in the image
-	OffsetX float32
-	OffsetY float32
-	RepeatX float32
-	RepeatY float32
+// Glyph contains the location of a rasterized glyph in an Atlas image and its metrics
+type Glyph struct {
+	X       int           // Position X in pixels in the atlas image from left to right
+	Y       int           // Position Y in pixels in the atlas image from top to bottom
+	Width   int           // Glyph image width in pixels (0 for blank glyphs)
+	Height  int           // Glyph image height in pixels (0 for blank glyphs)
+	OffsetX int           // Horizontal offset in pixels of the glyph image from the pen position
+	OffsetY int           // Vertical offset in pixels of the glyph image from the baseline
+	Advance fixed.Int26_6 // Horizontal advance of the pen position
+}
+
+// Quad is a glyph positioned in a text laid out by an Atlas
+type Quad struct {
+	X     int   // Position X in pixels of the glyph image from the left of the text
+	Y     int   // Position Y in pixels of the glyph image from the top of the text
+	Glyph Glyph // The glyph
 }
 
-// Atlas represents an image containing characters and the information about their location in the image
+// Atlas is a dynamic cache of the glyphs of a font face rasterized in a single image.
+// Glyphs are rasterized when they are first used and the image grows as needed,
+// keeping the location of the glyphs already in it.
+// When the image reaches its maximum size, the glyphs of text layouts are
+// rasterized in a new atlas page which is referenced by their LayoutGlyph.
+// Each Font shares one Atlas for each combination of size, resolution and hinting.
 type Atlas struct {
-	Chars   []CharInfo
-	Image   *image.RGBA
-	Height  int // Recommended vertical space between two lines of text
-	Ascent  int // Distance from the top of a line to its base line
-	Descent int // Distance from the bottom of a line to its baseline
+	Image   *image.RGBA        // White glyphs with coverage in the alpha channel
+	Height  int                // Recommended vertical space between two lines of text
+	Ascent  int                // Distance from the top of a line to its base line
+	Descent int                // Distance from the bottom of a line to its baseline
+	face    font.Face          // Font face used to rasterize the glyphs
+	glyphs  map[glyphKey]Glyph // Glyphs already rasterized
+	x       int                // Position X of the next glyph in the current shelf
+	y       int                // Position Y of the current shelf
+	shelf   int                // Height of the current shelf
+	version int                // Incremented each time the image changes
+	spread  int                // Maximum distance of signed distance field glyphs or 0
+	solid   Glyph              // Opaque square used to draw rectangles
+	gf      *glyphFont         // Fonts used to rasterize shaped glyphs by index
+	next    *Atlas             // Page of the glyphs which don't fit in the image or nil
+	full    bool               // A glyph did not fit in the image at its maximum size
+}
+
+// glyphKey identifies a glyph rasterized at a horizontal sub-pixel position
+type glyphKey struct {
+	r    rune           // character or -1 for glyphs identified by index
+	dot  fixed.Int26_6  // quantized fractional part of the pen position
+	font int            // font of the family of glyphs identified by index
+	gid  truetype.Index // glyph index in the font
+}
+
+const (
+	atlasSubPixels   = 4    // Horizontal sub-pixel positions of glyphs, as drawn by the font face
+	atlasInitialSize = 256  // Initial width and height of atlas images
+	atlasMaxSize     = 2048 // Maximum width and height of atlas images
+	atlasPadding     = 1    // Space between glyphs to avoid bleeding
+)
+
+// NewAtlas returns a pointer to a new empty Atlas for the current
+// point size, resolution and hinting of the specified font.
+// Normally the shared atlas returned by Font.Atlas() should be used instead.
+func NewAtlas(f *Font) *Atlas {
+
+	a := newAtlas(f.newFace(f.attrib.PointSize, f.attrib.DPI, f.attrib.Hinting), 0)
+	a.setFonts(f.fonts, f.attrib.PointSize, f.attrib.DPI, f.attrib.Hinting)
+	return a
 }
 
-// NewAtlas returns a pointer to a new Atlas object
-func NewAtlas(font *Font, first, last rune) *Atlas {
+// newAtlas returns a pointer to a new empty Atlas for the specified font face
+// and maximum distance of signed distance field glyphs or 0.
+func newAtlas(face font.Face, spread int) *Atlas {
 
 	a := new(Atlas)
-	a.Chars = make([]CharInfo, last+1)
+	a.face = face
+	a.spread = spread
+	a.glyphs = make(map[glyphKey]Glyph)
 
 	// Get font metrics
-	metrics := font.Metrics()
-	a.Height = int(metrics.Height >> 6)
-	a.Ascent = int(metrics.Ascent >> 6)
-	a.Descent = int(metrics.Descent >> 6)
-
-	const cols = 16
-	col := 0
-	encoded := make([]byte, 4)
-	line := []byte{}
-	lines := ""
-	maxWidth := 0
-	lastX := 0
-	lastY := a.Descent
-	nlines := 0
+	metrics := a.face.Metrics()
+	a.Height = metrics.Height.Ceil()
+	a.Ascent = metrics.Ascent.Round()
+	a.Descent = metrics.Descent.Ceil()
+
+	a.Image = image.NewRGBA(image.Rect(0, 0, atlasInitialSize, atlasInitialSize))
+	a.x = atlasPadding
+	a.y = atlasPadding
+	return a
+}
+
+// AddRange rasterizes the glyphs of the specified range of characters
+// which are not in the atlas yet.
+func (a *Atlas) AddRange(first, last rune) {
+
 	for code := first; code <= last; code++ {
-		// Encodes rune into UTF8 and appends to current line
-		count := utf8.EncodeRune(encoded, code)
-		line = append(line, encoded[:count]...)
-
-		// Measure current line
-		width, _ := font.MeasureText(string(line))
-
-		// Sets current code fields
-		cinfo := &a.Chars[code]
-		cinfo.X = lastX
-		cinfo.Y = lastY
-		cinfo.Width = width - lastX - 1
-		cinfo.Height += a.Height
-		lastX = width
-		fmt.Printf("%c: cinfo:%+v\n", code, cinfo)
-
-		// Checks end of the current line
-		col++
-		if col >= cols || code == last {
-			nlines++
-			lines += string(line) + "\n"
-			line = []byte{}
-			// Checks max width
-			if width > maxWidth {
-				maxWidth = width
+		a.Glyph(code)
+	}
+}
+
+// Glyph returns the glyph of the specified character rasterizing it if necessary.
+// If the atlas image cannot grow anymore, a new glyph is returned blank
+// and a warning is logged the first time.
+func (a *Atlas) Glyph(r rune) Glyph {
+
+	return a.glyph(r, 0)
+}
+
+// glyph returns the glyph of the specified character rasterized at the
+// specified quantized fractional pen position, rasterizing it if necessary.
+func (a *Atlas) glyph(r rune, dot fixed.Int26_6) Glyph {
+
+	key := glyphKey{r: r, dot: dot}
+	g, ok := a.glyphs[key]
+	if ok {
+		return g
+	}
+
+	dr, mask, maskp, advance, ok := a.face.Glyph(fixed.Point26_6{X: dot}, r)
+	g.Advance = advance
+	if !ok || dr.Empty() {
+		a.glyphs[key] = g
+		return g
+	}
+	// Distance fields extend beyond the glyph outline
+	sdr := dr.Inset(-a.spread)
+	x, y, ok := a.place(sdr.Dx(), sdr.Dy())
+	if !ok {
+		if !a.full {
+			a.full = true
+			log.Warn("Glyph atlas image is full: new glyphs are blank")
+		}
+		return g
+	}
+	g.X = x
+	g.Y = y
+	g.Width = sdr.Dx()
+	g.Height = sdr.Dy()
+	g.OffsetX = sdr.Min.X
+	g.OffsetY = sdr.Min.Y
+	rect := image.Rect(x, y, x+g.Width, y+g.Height)
+	if a.spread > 0 {
+		drawSDF(a.Image, rect, mask, maskp, a.spread)
+	} else {
+		draw.DrawMask(a.Image, rect, image.White, image.ZP, mask, maskp, draw.Over)
+	}
+	a.glyphs[key] = g
+	a.version++
+	return g
+}
+
+// Solid returns a small opaque white square in the atlas image which
+// can be used to draw solid rectangles, such as underlines, with the atlas texture.
+// Texture coordinates should be taken from the center of the square.
+func (a *Atlas) Solid() Glyph {
+
+	if a.solid.Width == 0 {
+		x, y, ok := a.place(3, 3)
+		if !ok {
+			return a.solid
+		}
+		a.solid = Glyph{X: x, Y: y, Width: 3, Height: 3}
+		draw.Draw(a.Image, image.Rect(x, y, x+3, y+3), image.White, image.ZP, draw.Src)
+		a.version++
+	}
+	return a.solid
+}
+
+// Kern returns the horizontal kerning adjustment between the specified characters
+func (a *Atlas) Kern(r0, r1 rune) fixed.Int26_6 {
+
+	return a.face.Kern(r0, r1)
+}
+
+// Spread returns the maximum distance in pixels encoded in the glyphs
+// of signed distance field atlases or 0 for coverage atlases.
+func (a *Atlas) Spread() int {
+
+	return a.spread
+}
+
+// Version returns a number which is incremented each time the atlas image changes.
+// It can be used to know when a texture created from the image must be updated.
+func (a *Atlas) Version() int {
+
+	return a.version
+}
+
+// Layout lays out the specified text and appends to the specified slice the quads
+// of its non blank glyphs, positioned as they would be drawn by Font.DrawText with
+// the specified line spacing. Returns the updated slice.
+// The supplied text string can contain line break escape sequences (\n).
+func (a *Atlas) Layout(text string, lineSpacing float64, quads []Quad) []Quad {
+
+	metrics := a.face.Metrics()
+	py := metrics.Ascent.Round()
+	lineHeight := (metrics.Ascent + metrics.Descent).Ceil()
+	lineGap := int((lineSpacing - float64(1)) * float64(lineHeight))
+	lines := strings.Split(text, "\n")
+	for i, s := range lines {
+		var dot fixed.Int26_6
+		prev := rune(-1)
+		for _, r := range s {
+			if prev >= 0 {
+				dot += a.face.Kern(prev, r)
 			}
-			if code == last {
-				break
+			// Quantizes the pen position as the font face does when drawing.
+			// Distance fields are not rasterized at sub-pixel positions.
+			pos := (dot + 32/atlasSubPixels) &^ (64/atlasSubPixels - 1)
+			if a.spread > 0 {
+				pos = fixed.I(dot.Round())
 			}
-			col = 0
-			lastX = 0
-			lastY += a.Height
+			g := a.glyph(r, pos&63)
+			if g.Width > 0 {
+				quads = append(quads, Quad{X: pos.Floor() + g.OffsetX, Y: py + g.OffsetY, Glyph: g})
+			}
+			dot += g.Advance
+			prev = r
+		}
+		py += lineHeight
+		if i > 1 {
+			py += lineGap
 		}
 	}
-	height := (nlines * a.Height) + a.Descent
-
-	// Draw atlas image
-	canvas := NewCanvas(maxWidth, height, &math32.Color4{1, 1, 1, 1})
-	canvas.DrawText(0, 0, lines, font)
-	a.Image = canvas.RGBA
-
-	// Calculate normalized char positions in the image
-	fWidth := float32(maxWidth)
-	fHeight := float32(height)
-	for i := 0; i < len(a.Chars); i++ {
-		char := &a.Chars[i]
-		char.OffsetX = float32(char.X) / fWidth
-		char.OffsetY = float32(char.Y) / fHeight
-		char.RepeatX = float32(char.Width) / fWidth
-		char.RepeatY = float32(char.Height) / fHeight
+	return quads
+}
+
+// place reserves an area of the specified size in the atlas image,
+// growing it if necessary, and returns its position.
+func (a *Atlas) place(width, height int) (x, y int, ok bool) {
+
+	for {
+		size := a.Image.Rect.Size()
+		// Starts a new shelf if the current one is full
+		if a.x+width+atlasPadding > size.X {
+			a.x = atlasPadding
+			a.y += a.shelf + atlasPadding
+			a.shelf = 0
+		}
+		if a.x+width+atlasPadding <= size.X && a.y+height+atlasPadding <= size.Y {
+			x, y = a.x, a.y
+			a.x += width + atlasPadding
+			if height > a.shelf {
+				a.shelf = height
+			}
+			return x, y, true
+		}
+		if !a.grow() {
+			return 0, 0, false
+		}
 	}
+}
 
-	a.SavePNG("atlas.png")
-	return a
+// page returns the atlas page where the glyphs which
+// don't fit in this atlas are rasterized, creating it if necessary
+func (a *Atlas) page() *Atlas {
+
+	if a.next == nil {
+		a.next = newAtlas(a.face, a.spread)
+		a.next.gf = a.gf
+		log.Debug("Glyph atlas image is full: adding a page")
+	}
+	return a.next
+}
+
+// grow doubles the smallest dimension of the atlas image keeping its contents.
+// Returns false if the image is already at its maximum size.
+func (a *Atlas) grow() bool {
+
+	size := a.Image.Rect.Size()
+	if size.X <= size.Y && size.X < atlasMaxSize {
+		size.X *= 2
+	} else if size.Y < atlasMaxSize {
+		size.Y *= 2
+	} else {
+		return false
+	}
+	img := image.NewRGBA(image.Rectangle{Max: size})
+	draw.Draw(img, a.Image.Rect, a.Image, image.ZP, draw.Src)
+	a.Image = img
+	a.version++
+	return true
 }
 
 // SavePNG saves the current atlas image as a PNG image file
@@ -0,0 +1,472 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package text
+
+import (
+	"sort"
+	"unicode"
+)
+
+// Direction is the base direction of the paragraphs of a text layout
+type Direction int
+
+// The base directions of text layouts
+const (
+	DirectionAuto = Direction(iota) // from the first strong character of each paragraph
+	DirectionLTR                    // left to right
+	DirectionRTL                    // right to left
+)
+
+// bidiClass is the bidirectional class of a character,
+// as defined by the Unicode bidirectional algorithm (UAX #9).
+type bidiClass int
+
+const (
+	bidiL   = bidiClass(iota) // left to right
+	bidiR                     // right to left
+	bidiAL                    // right to left Arabic
+	bidiEN                    // European number
+	bidiES                    // European number separator
+	bidiET                    // European number terminator
+	bidiAN                    // Arabic number
+	bidiCS                    // common number separator
+	bidiNSM                   // non spacing mark
+	bidiBN                    // boundary neutral
+	bidiB                     // paragraph separator
+	bidiS                     // segment separator
+	bidiWS                    // whitespace
+	bidiON                    // other neutrals
+)
+
+// bidiClasses maps the characters which are not classified by range to their bidirectional classes
+var bidiClasses = map[rune]bidiClass{
+	'\n': bidiB, '\r': bidiB, 0x1C: bidiB, 0x1D: bidiB, 0x1E: bidiB, 0x85: bidiB, 0x2029: bidiB,
+	'\t': bidiS, 0x0B: bidiS, 0x1F: bidiS,
+	' ': bidiWS, '\f': bidiWS, 0x1680: bidiWS, 0x2028: bidiWS, 0x205F: bidiWS, 0x3000: bidiWS,
+	0xB2: bidiEN, 0xB3: bidiEN, 0xB9: bidiEN,
+	0x066B: bidiAN, 0x066C: bidiAN,
+	'+': bidiES, '-': bidiES, 0x207A: bidiES, 0x207B: bidiES, 0x208A: bidiES, 0x208B: bidiES,
+	0x2212: bidiES, 0xFB29: bidiES, 0xFE62: bidiES, 0xFE63: bidiES, 0xFF0B: bidiES, 0xFF0D: bidiES,
+	'#': bidiET, '$': bidiET, '%': bidiET, 0xA2: bidiET, 0xA3: bidiET, 0xA4: bidiET, 0xA5: bidiET,
+	0xB0: bidiET, 0xB1: bidiET, 0x066A: bidiET, 0x2030: bidiET, 0x2031: bidiET, 0x2032: bidiET,
+	0x2033: bidiET, 0x2034: bidiET, 0x212E: bidiET, 0x2213: bidiET, 0xFF03: bidiET, 0xFF04: bidiET,
+	0xFF05: bidiET, 0xFFE0: bidiET, 0xFFE1: bidiET, 0xFFE5: bidiET, 0xFFE6: bidiET,
+	',': bidiCS, '.': bidiCS, '/': bidiCS, ':': bidiCS, 0xA0: bidiCS, 0x060C: bidiCS, 0x202F: bidiCS,
+	0x2044: bidiCS, 0xFE50: bidiCS, 0xFE52: bidiCS, 0xFE55: bidiCS, 0xFF0C: bidiCS, 0xFF0E: bidiCS,
+	0xFF0F: bidiCS, 0xFF1A: bidiCS,
+	0xAD: bidiBN, 0x180E: bidiBN, 0xFEFF: bidiBN,
+	0x200E: bidiL, 0x200F: bidiR, 0x061C: bidiAL,
+}
+
+// bidiClassOf returns the bidirectional class of the specified character.
+// Explicit embedding, override and isolate controls are treated as boundary neutrals.
+func bidiClassOf(r rune) bidiClass {
+
+	if c, ok := bidiClasses[r]; ok {
+		return c
+	}
+	switch {
+	case r >= '0' && r <= '9', r >= 0x06F0 && r <= 0x06F9, r >= 0x2070 && r <= 0x2079,
+		r >= 0x2080 && r <= 0x2089, r >= 0xFF10 && r <= 0xFF19:
+		return bidiEN
+	case r >= 0x0660 && r <= 0x0669, r >= 0x0600 && r <= 0x0605, r == 0x06DD:
+		return bidiAN
+	case r >= 0x20A0 && r <= 0x20CF:
+		return bidiET
+	case r >= 0x2000 && r <= 0x200A:
+		return bidiWS
+	case r >= 0x200B && r <= 0x200D, r >= 0x202A && r <= 0x202E, r >= 0x2060 && r <= 0x206F:
+		return bidiBN
+	case unicode.In(r, unicode.Mn, unicode.Me):
+		return bidiNSM
+	case r < 0x20, r >= 0x7F && r < 0xA0:
+		return bidiBN
+	case r >= 0x0590 && r <= 0x05FF, r >= 0x07C0 && r <= 0x085F, r >= 0xFB1D && r <= 0xFB4F,
+		r >= 0x10800 && r <= 0x10FFF, r >= 0x1E800 && r <= 0x1EDFF:
+		return bidiR
+	case r >= 0x0600 && r <= 0x07BF, r >= 0x0860 && r <= 0x08FF, r >= 0xFB50 && r <= 0xFDFF,
+		r >= 0xFE70 && r <= 0xFEFE, r >= 0x1EE00 && r <= 0x1EEFF:
+		return bidiAL
+	case unicode.In(r, unicode.P, unicode.S, unicode.Zs):
+		return bidiON
+	}
+	return bidiL
+}
+
+// bidiLevels returns the embedding level of each of the specified characters
+// and the level of its paragraph, resolved by the Unicode bidirectional algorithm
+// for paragraphs with the specified base direction.
+// Explicit embeddings, overrides and isolates are not supported: their
+// control characters are ignored as boundary neutrals.
+func bidiLevels(runes []rune, dir Direction) (levels, paras []uint8) {
+
+	levels = make([]uint8, len(runes))
+	paras = make([]uint8, len(runes))
+	types := make([]bidiClass, len(runes))
+	for i, r := range runes {
+		types[i] = bidiClassOf(r)
+	}
+	for start := 0; start < len(runes); {
+		end := start
+		for end < len(runes) && types[end] != bidiB {
+			end++
+		}
+		if end < len(runes) {
+			end++
+		}
+		bidiParagraph(runes[start:end], types[start:end], levels[start:end], paras[start:end], dir)
+		start = end
+	}
+	return levels, paras
+}
+
+// bidiParagraph resolves the levels of the specified characters of a paragraph with the specified types
+func bidiParagraph(runes []rune, types []bidiClass, levels, paras []uint8, dir Direction) {
+
+	// Paragraph level from the first strong character (rules P2 and P3)
+	var para uint8
+	switch dir {
+	case DirectionRTL:
+		para = 1
+	case DirectionAuto:
+		for _, t := range types {
+			if t == bidiL {
+				break
+			}
+			if t == bidiR || t == bidiAL {
+				para = 1
+				break
+			}
+		}
+	}
+	sos := bidiL
+	if para == 1 {
+		sos = bidiR
+	}
+	n := len(types)
+	orig := append([]bidiClass(nil), types...)
+
+	// W1: non spacing marks and boundary neutrals take the type of the previous character
+	prev := sos
+	for i, t := range types {
+		if t == bidiNSM || t == bidiBN {
+			types[i] = prev
+		}
+		prev = types[i]
+	}
+	// W2: European numbers after Arabic letters are Arabic numbers
+	strong := sos
+	for i, t := range types {
+		switch t {
+		case bidiL, bidiR, bidiAL:
+			strong = t
+		case bidiEN:
+			if strong == bidiAL {
+				types[i] = bidiAN
+			}
+		}
+	}
+	// W3: Arabic letters are right to left
+	for i, t := range types {
+		if t == bidiAL {
+			types[i] = bidiR
+		}
+	}
+	// W4: single separators between numbers of the same type
+	for i := 1; i < n-1; i++ {
+		a, b := types[i-1], types[i+1]
+		switch types[i] {
+		case bidiES:
+			if a == bidiEN && b == bidiEN {
+				types[i] = bidiEN
+			}
+		case bidiCS:
+			if a == b && (a == bidiEN || a == bidiAN) {
+				types[i] = a
+			}
+		}
+	}
+	// W5: terminators adjacent to European numbers
+	for i := 0; i < n; i++ {
+		if types[i] != bidiET {
+			continue
+		}
+		j := i
+		for j < n && types[j] == bidiET {
+			j++
+		}
+		if (i > 0 && types[i-1] == bidiEN) || (j < n && types[j] == bidiEN) {
+			for k := i; k < j; k++ {
+				types[k] = bidiEN
+			}
+		}
+		i = j - 1
+	}
+	// W6: remaining separators and terminators are neutrals
+	for i, t := range types {
+		if t == bidiES || t == bidiET || t == bidiCS {
+			types[i] = bidiON
+		}
+	}
+	// W7: European numbers after left to right text are left to right
+	strong = sos
+	for i, t := range types {
+		switch t {
+		case bidiL, bidiR:
+			strong = t
+		case bidiEN:
+			if strong == bidiL {
+				types[i] = bidiL
+			}
+		}
+	}
+	// N0: paired brackets
+	bidiBrackets(runes, types, orig, sos, para)
+
+	// N1 and N2: neutrals between characters of the same direction take
+	// that direction, the other neutrals the paragraph direction.
+	strongOf := func(t bidiClass) bidiClass {
+		if t == bidiL {
+			return bidiL
+		}
+		return bidiR
+	}
+	neutral := func(t bidiClass) bool {
+		return t == bidiB || t == bidiS || t == bidiWS || t == bidiON
+	}
+	for i := 0; i < n; i++ {
+		if !neutral(types[i]) {
+			continue
+		}
+		j := i
+		for j < n && neutral(types[j]) {
+			j++
+		}
+		before, after := sos, sos
+		if i > 0 {
+			before = strongOf(types[i-1])
+		}
+		if j < n {
+			after = strongOf(types[j])
+		}
+		dir := sos
+		if before == after {
+			dir = before
+		}
+		for k := i; k < j; k++ {
+			types[k] = dir
+		}
+		i = j - 1
+	}
+	// I1 and I2: implicit levels
+	for i, t := range types {
+		level := para
+		if para == 0 {
+			switch t {
+			case bidiR:
+				level++
+			case bidiAN, bidiEN:
+				level += 2
+			}
+		} else if t == bidiL || t == bidiEN || t == bidiAN {
+			level++
+		}
+		levels[i] = level
+		paras[i] = para
+	}
+}
+
+// bidiPairedBrackets maps the opening paired brackets to their closing brackets
+var bidiPairedBrackets = map[rune]rune{
+	'(': ')', '[': ']', '{': '}', 0x0F3A: 0x0F3B, 0x0F3C: 0x0F3D, 0x169B: 0x169C,
+	0x2045: 0x2046, 0x207D: 0x207E, 0x208D: 0x208E, 0x2308: 0x2309, 0x230A: 0x230B,
+	0x2329: 0x232A, 0x2768: 0x2769, 0x276A: 0x276B, 0x276C: 0x276D, 0x276E: 0x276F,
+	0x2770: 0x2771, 0x2772: 0x2773, 0x2774: 0x2775, 0x27C5: 0x27C6, 0x27E6: 0x27E7,
+	0x27E8: 0x27E9, 0x27EA: 0x27EB, 0x27EC: 0x27ED, 0x27EE: 0x27EF, 0x2983: 0x2984,
+	0x2985: 0x2986, 0x2987: 0x2988, 0x2989: 0x298A, 0x298B: 0x298C, 0x298D: 0x2990,
+	0x298F: 0x298E, 0x2991: 0x2992, 0x2993: 0x2994, 0x2995: 0x2996, 0x2997: 0x2998,
+	0x29D8: 0x29D9, 0x29DA: 0x29DB, 0x29FC: 0x29FD, 0x2E22: 0x2E23, 0x2E24: 0x2E25,
+	0x2E26: 0x2E27, 0x2E28: 0x2E29, 0x3008: 0x3009, 0x300A: 0x300B, 0x300C: 0x300D,
+	0x300E: 0x300F, 0x3010: 0x3011, 0x3014: 0x3015, 0x3016: 0x3017, 0x3018: 0x3019,
+	0x301A: 0x301B, 0xFE59: 0xFE5A, 0xFE5B: 0xFE5C, 0xFE5D: 0xFE5E, 0xFF08: 0xFF09,
+	0xFF3B: 0xFF3D, 0xFF5B: 0xFF5D, 0xFF5F: 0xFF60, 0xFF62: 0xFF63,
+}
+
+// bidiBracketMax is the maximum depth of nested brackets which are paired (rule BD16)
+const bidiBracketMax = 63
+
+// bidiBrackets resolves the types of the paired brackets of a paragraph with the
+// specified types after the weak types were resolved, the types of its characters
+// before resolving them and the specified start of sequence type and level (rule N0).
+func bidiBrackets(runes []rune, types, orig []bidiClass, sos bidiClass, para uint8) {
+
+	// Finds the bracket pairs, ordered by the position of their opening bracket (BD16)
+	type pair struct{ open, close int }
+	type opening struct {
+		close rune
+		pos   int
+	}
+	var pairs []pair
+	var stack []opening
+	canonical := func(r rune) rune {
+		switch r {
+		case 0x2329:
+			return 0x3008
+		case 0x232A:
+			return 0x3009
+		}
+		return r
+	}
+	for i, r := range runes {
+		if types[i] != bidiON {
+			continue
+		}
+		if close, ok := bidiPairedBrackets[r]; ok {
+			if len(stack) == bidiBracketMax {
+				break
+			}
+			stack = append(stack, opening{canonical(close), i})
+			continue
+		}
+		for k := len(stack) - 1; k >= 0; k-- {
+			if stack[k].close == canonical(r) {
+				pairs = append(pairs, pair{stack[k].pos, i})
+				stack = stack[:k]
+				break
+			}
+		}
+	}
+	sort.Slice(pairs, func(i, j int) bool { return pairs[i].open < pairs[j].open })
+
+	// Numbers are right to left in the resolution of brackets
+	strongOf := func(t bidiClass) bidiClass {
+		switch t {
+		case bidiL:
+			return bidiL
+		case bidiR, bidiEN, bidiAN:
+			return bidiR
+		}
+		return bidiON
+	}
+	embedding := bidiL
+	if para%2 == 1 {
+		embedding = bidiR
+	}
+	for _, p := range pairs {
+		// Direction of the strong types inside the brackets
+		dir := bidiON
+		for k := p.open + 1; k < p.close; k++ {
+			if s := strongOf(types[k]); s == embedding {
+				dir = embedding
+				break
+			} else if s != bidiON {
+				dir = s
+			}
+		}
+		if dir == bidiON {
+			continue
+		}
+		// Brackets with only the opposite direction inside take it
+		// if it is also the direction of the context before them
+		if dir != embedding {
+			before := sos
+			for k := p.open - 1; k >= 0; k-- {
+				if s := strongOf(types[k]); s != bidiON {
+					before = s
+					break
+				}
+			}
+			if before != dir {
+				dir = embedding
+			}
+		}
+		// Non spacing marks after the brackets take their type
+		for _, k := range []int{p.open, p.close} {
+			types[k] = dir
+			for k++; k < len(types) && orig[k] == bidiNSM; k++ {
+				types[k] = dir
+			}
+		}
+	}
+}
+
+// bidiResetLine resets to the paragraph level the levels of the separators of
+// the specified line of characters, of the whitespace before them and of the
+// whitespace at the end of the line (rule L1).
+func bidiResetLine(runes []rune, levels, paras []uint8) {
+
+	trailing := true
+	for i := len(runes) - 1; i >= 0; i-- {
+		switch bidiClassOf(runes[i]) {
+		case bidiS, bidiB:
+			levels[i] = paras[i]
+			trailing = true
+		case bidiWS, bidiBN:
+			if trailing {
+				levels[i] = paras[i]
+			}
+		default:
+			trailing = false
+		}
+	}
+}
+
+// bidiReorder returns the visual order of the characters of a line
+// with the specified levels, as indices in the line (rule L2).
+func bidiReorder(levels []uint8) []int {
+
+	order := make([]int, len(levels))
+	var max uint8
+	min := uint8(255)
+	for i, l := range levels {
+		order[i] = i
+		if l > max {
+			max = l
+		}
+		if l%2 == 1 && l < min {
+			min = l
+		}
+	}
+	// Reverses the sequences at each level from the highest to the lowest odd level
+	for level := max; level >= min && level > 0; level-- {
+		for i := 0; i < len(order); i++ {
+			if levels[order[i]] < level {
+				continue
+			}
+			j := i
+			for j < len(order) && levels[order[j]] >= level {
+				j++
+			}
+			for a, b := i, j-1; a < b; a, b = a+1, b-1 {
+				order[a], order[b] = order[b], order[a]
+			}
+			i = j
+		}
+	}
+	return order
+}
+
+// bidiMirrors maps the characters with a mirrored glyph to their mirror
+var bidiMirrors = map[rune]rune{
+	'(': ')', ')': '(', '<': '>', '>': '<', '[': ']', ']': '[', '{': '}', '}': '{',
+	0xAB: 0xBB, 0xBB: 0xAB, 0x2039: 0x203A, 0x203A: 0x2039, 0x2045: 0x2046, 0x2046: 0x2045,
+	0x207D: 0x207E, 0x207E: 0x207D, 0x208D: 0x208E, 0x208E: 0x208D,
+	0x2264: 0x2265, 0x2265: 0x2264, 0x2329: 0x232A, 0x232A: 0x2329,
+	0x3008: 0x3009, 0x3009: 0x3008, 0x300A: 0x300B, 0x300B: 0x300A, 0x300C: 0x300D, 0x300D: 0x300C,
+	0x300E: 0x300F, 0x300F: 0x300E, 0x3010: 0x3011, 0x3011: 0x3010,
+	0xFF08: 0xFF09, 0xFF09: 0xFF08, 0xFF3B: 0xFF3D, 0xFF3D: 0xFF3B, 0xFF5B: 0xFF5D, 0xFF5D: 0xFF5B,
+}
+
+// bidiMirror returns the mirror of the specified character drawn
+// at the specified level or the character itself.
+func bidiMirror(r rune, level uint8) rune {
+
+	if level%2 == 1 {
+		if m, ok := bidiMirrors[r]; ok {
+			return m
+		}
+	}
+	return r
+}
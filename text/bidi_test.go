@@ -0,0 +1,82 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package text
+
+import (
+	"testing"
+)
+
+// Tests the embedding levels resolved by the bidirectional algorithm,
+// with the expected levels of the characters as digits
+func TestBidiLevels(t *testing.T) {
+
+	tests := []struct {
+		text     string
+		dir      Direction
+		expected string
+	}{
+		{"", DirectionAuto, ""},
+		{"abc", DirectionAuto, "000"},
+		{"abc", DirectionRTL, "222"},
+		{"אבג", DirectionAuto, "111"},
+		{"אבג", DirectionLTR, "111"},
+		{"abc אבג def", DirectionAuto, "00001110000"},
+		{"אבג abc", DirectionAuto, "1111222"},
+		{"abc 123", DirectionAuto, "0000000"},
+		{"אבג 123", DirectionAuto, "1111222"},
+		{"abc אבג 123", DirectionAuto, "00001111222"},
+		{"سلام 12", DirectionAuto, "1111122"},
+		{"12.5%", DirectionRTL, "22222"},
+		{"אב\nab", DirectionAuto, "11100"},
+		{"ab́c", DirectionAuto, "0000"},
+		// Paired brackets (rule N0)
+		{"אב(גד[&ef]!)gh", DirectionRTL, "11111112211122"},
+		{"smith (fabrikam אבג) דהו", DirectionRTL, "222221122222222111111111"},
+		{"ab(c)", DirectionRTL, "22222"},
+		{"א(b)", DirectionLTR, "1000"},
+		{"a(א)", DirectionLTR, "0010"},
+		{"א(b", DirectionRTL, "112"},
+	}
+	for _, test := range tests {
+		levels, _ := bidiLevels([]rune(test.text), test.dir)
+		got := ""
+		for _, l := range levels {
+			got += string(rune('0' + l))
+		}
+		if got != test.expected {
+			t.Errorf("%q: levels %s, expected %s", test.text, got, test.expected)
+		}
+	}
+}
+
+// Tests the visual order of lines with the specified levels
+func TestBidiReorder(t *testing.T) {
+
+	tests := []struct {
+		levels   []uint8
+		expected []int
+	}{
+		{nil, []int{}},
+		{[]uint8{0, 0, 0}, []int{0, 1, 2}},
+		{[]uint8{1, 1, 1}, []int{2, 1, 0}},
+		{[]uint8{0, 1, 1, 0}, []int{0, 2, 1, 3}},
+		{[]uint8{1, 1, 2, 2, 1}, []int{4, 2, 3, 1, 0}},
+		{[]uint8{0, 0, 1, 1, 2, 2}, []int{0, 1, 4, 5, 3, 2}},
+		{[]uint8{2, 2, 0, 1}, []int{0, 1, 2, 3}},
+	}
+	for _, test := range tests {
+		order := bidiReorder(test.levels)
+		if len(order) != len(test.expected) {
+			t.Errorf("%v: order %v, expected %v", test.levels, order, test.expected)
+			continue
+		}
+		for i := range order {
+			if order[i] != test.expected[i] {
+				t.Errorf("%v: order %v, expected %v", test.levels, order, test.expected)
+				break
+			}
+		}
+	}
+}
@@ -3,4 +3,18 @@
 // license that can be found in the LICENSE file.
 
 // Package text implements text font support.
+//
+// Fonts are TrueType fonts (.ttf), TrueType collections (.ttc) or OpenType
+// fonts (.otf) with TrueType outlines. OpenType fonts with CFF outlines are not
+// supported. Glyphs are rasterized from their outlines in a single color, so the
+// colour glyphs of emoji fonts (COLR, CBDT, sbix or SVG tables) are drawn with
+// their monochrome outlines if the font has them, and fonts with only bitmap
+// glyphs, such as Noto Color Emoji, cannot be loaded.
+//
+// Text layouts order mixed left to right and right to left text with the implicit
+// rules of the Unicode bidirectional algorithm (UAX #9), including the resolution
+// of paired brackets. Explicit embeddings, overrides and isolates are not
+// supported: their control characters (U+202A to U+202E and U+2066 to U+2069)
+// are ignored, so the base direction of a text can only be set by its
+// paragraphs or by LayoutOptions.Direction.
 package text
@@ -0,0 +1,262 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package text
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	"io/ioutil"
+
+	"github.com/golang/freetype/truetype"
+	"golang.org/x/image/font"
+	"golang.org/x/image/math/fixed"
+)
+
+// NewFontFamily creates and returns a new font which draws each character with
+// the first of the specified fonts which has a glyph for it, with the attributes
+// and color of the first font. Characters which none of the fonts have are drawn
+// with the missing glyph of the first font.
+// The fonts can themselves be families, in which case their fallbacks are kept in order.
+func NewFontFamily(primary *Font, fallbacks ...*Font) *Font {
+
+	f := new(Font)
+	f.fonts = append(f.fonts, primary.fonts...)
+	f.attrib = primary.attrib
+	f.fg = primary.fg
+	f.bg = primary.bg
+	for _, fb := range fallbacks {
+		f.fonts = append(f.fonts, fb.fonts...)
+	}
+	f.face = f.newFace(f.attrib.PointSize, f.attrib.DPI, f.attrib.Hinting)
+	return f
+}
+
+// AddFallback adds the specified font, and its fallbacks if it is a family,
+// to the end of the fallbacks of this font used for the characters it lacks.
+// Glyph atlases previously returned by the font are no longer updated.
+func (f *Font) AddFallback(fb *Font) {
+
+	f.fonts = append(f.fonts, fb.fonts...)
+	f.face = f.newFace(f.attrib.PointSize, f.attrib.DPI, f.attrib.Hinting)
+	f.changed = false
+	f.atlases = nil
+	f.sdfAtlas = nil
+}
+
+// HasGlyph returns if the font or any of its fallbacks has a glyph for the specified character
+func (f *Font) HasGlyph(r rune) bool {
+
+	for _, fd := range f.fonts {
+		if fd.ttf.Index(r) != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// familyFont is a font of a family
+type familyFont struct {
+	ttf *truetype.Font // TrueType font
+	otl *otlFont       // OpenType layout tables of the font or nil
+}
+
+// newFace returns a font face with the specified options
+// which selects the glyph of each character from the font family.
+func (f *Font) newFace(size, dpi float64, hinting font.Hinting) font.Face {
+
+	opts := &truetype.Options{Size: size, DPI: dpi, Hinting: hinting}
+	if len(f.fonts) == 1 {
+		return truetype.NewFace(f.fonts[0].ttf, opts)
+	}
+	ff := new(familyFace)
+	for _, fd := range f.fonts {
+		ff.faces = append(ff.faces, truetype.NewFace(fd.ttf, opts))
+		ff.ttfs = append(ff.ttfs, fd.ttf)
+	}
+	return ff
+}
+
+// familyFace is a font face which draws each character with the
+// first of its faces whose font has a glyph for it.
+// The metrics of the family are the metrics of its first face.
+type familyFace struct {
+	faces []font.Face      // faces in order of preference
+	ttfs  []*truetype.Font // fonts of the faces
+}
+
+// face returns the face used for the specified character
+func (ff *familyFace) face(r rune) font.Face {
+
+	for i, ttf := range ff.ttfs {
+		if ttf.Index(r) != 0 {
+			return ff.faces[i]
+		}
+	}
+	return ff.faces[0]
+}
+
+// Close satisfies the font.Face interface
+func (ff *familyFace) Close() error {
+
+	for _, face := range ff.faces {
+		face.Close()
+	}
+	return nil
+}
+
+// Glyph satisfies the font.Face interface
+func (ff *familyFace) Glyph(dot fixed.Point26_6, r rune) (image.Rectangle, image.Image, image.Point, fixed.Int26_6, bool) {
+
+	return ff.face(r).Glyph(dot, r)
+}
+
+// GlyphBounds satisfies the font.Face interface
+func (ff *familyFace) GlyphBounds(r rune) (fixed.Rectangle26_6, fixed.Int26_6, bool) {
+
+	return ff.face(r).GlyphBounds(r)
+}
+
+// GlyphAdvance satisfies the font.Face interface
+func (ff *familyFace) GlyphAdvance(r rune) (fixed.Int26_6, bool) {
+
+	return ff.face(r).GlyphAdvance(r)
+}
+
+// Kern satisfies the font.Face interface.
+// There is no kerning between characters drawn with different faces.
+func (ff *familyFace) Kern(r0, r1 rune) fixed.Int26_6 {
+
+	face := ff.face(r0)
+	if face != ff.face(r1) {
+		return 0
+	}
+	return face.Kern(r0, r1)
+}
+
+// Metrics satisfies the font.Face interface
+func (ff *familyFace) Metrics() font.Metrics {
+
+	return ff.faces[0].Metrics()
+}
+
+// NewFontCollection creates and returns the fonts of the specified
+// TrueType collection (.ttc) or single font (.ttf or .otf) file.
+// Only fonts with TrueType outlines are supported: ErrCFF is returned for
+// OpenType fonts with CFF outlines and ErrNoOutlines for fonts with only
+// bitmap or colour glyphs, such as most colour emoji fonts.
+func NewFontCollection(fontFile string) ([]*Font, error) {
+
+	data, err := ioutil.ReadFile(fontFile)
+	if err != nil {
+		return nil, err
+	}
+	return NewFontCollectionFromData(data)
+}
+
+// NewFontCollectionFromData creates and returns the fonts of the
+// specified TrueType collection or single font data.
+func NewFontCollectionFromData(data []byte) ([]*Font, error) {
+
+	if !isCollection(data) {
+		f, err := NewFontFromData(data)
+		if err != nil {
+			return nil, err
+		}
+		return []*Font{f}, nil
+	}
+	count := int(binary.BigEndian.Uint32(data[8:]))
+	if len(data) < 12+4*count {
+		return nil, errors.New("text: invalid font collection")
+	}
+	fonts := make([]*Font, 0, count)
+	for i := 0; i < count; i++ {
+		fontData, err := collectionFont(data, i)
+		if err != nil {
+			return nil, err
+		}
+		f, err := NewFontFromData(fontData)
+		if err != nil {
+			return nil, err
+		}
+		fonts = append(fonts, f)
+	}
+	return fonts, nil
+}
+
+// isCollection returns if the specified font data is a TrueType collection
+func isCollection(data []byte) bool {
+
+	return len(data) >= 12 && string(data[:4]) == "ttcf"
+}
+
+// Errors returned for the fonts without TrueType outlines
+var (
+	ErrCFF        = errors.New("text: OpenType fonts with CFF outlines are not supported")
+	ErrNoOutlines = errors.New("text: fonts with only bitmap or colour glyphs are not supported")
+)
+
+// checkOutlines returns an error if the specified font data
+// is not a font with TrueType outlines which can be parsed
+func checkOutlines(data []byte) error {
+
+	if len(data) >= 4 && string(data[:4]) == "OTTO" {
+		return ErrCFF
+	}
+	if len(data) < 12 {
+		return nil
+	}
+	be := binary.BigEndian
+	tables := int(be.Uint16(data[4:]))
+	for i := 0; i < tables && 12+16*i+4 <= len(data); i++ {
+		if string(data[12+16*i:12+16*i+4]) == "glyf" {
+			return nil
+		}
+	}
+	return ErrNoOutlines
+}
+
+// collectionFont returns the data of the font with the specified index in a TrueType
+// collection as a standalone font. The tables of the fonts of a collection are shared
+// and referenced from the start of the collection, so they are copied after the table
+// directory of the font with their offsets updated.
+func collectionFont(data []byte, index int) ([]byte, error) {
+
+	be := binary.BigEndian
+	count := int(be.Uint32(data[8:]))
+	if index < 0 || index >= count || len(data) < 12+4*count {
+		return nil, fmt.Errorf("text: invalid font index %d in collection", index)
+	}
+	offset := int(be.Uint32(data[12+4*index:]))
+	if offset < 0 || offset+12 > len(data) {
+		return nil, errors.New("text: invalid font collection")
+	}
+	if string(data[offset:offset+4]) == "OTTO" {
+		return nil, ErrCFF
+	}
+	tables := int(be.Uint16(data[offset+4:]))
+	dirSize := 12 + 16*tables
+	if offset+dirSize > len(data) {
+		return nil, errors.New("text: invalid font collection")
+	}
+	out := make([]byte, dirSize)
+	copy(out, data[offset:offset+dirSize])
+	for i := 0; i < tables; i++ {
+		rec := out[12+16*i:]
+		start := int(be.Uint32(rec[8:]))
+		length := int(be.Uint32(rec[12:]))
+		if start < 0 || length < 0 || start+length > len(data) {
+			return nil, errors.New("text: invalid font collection")
+		}
+		be.PutUint32(rec[8:], uint32(len(out)))
+		out = append(out, data[start:start+length]...)
+		// Tables are aligned to 4 bytes
+		for len(out)%4 != 0 {
+			out = append(out, 0)
+		}
+	}
+	return out, nil
+}
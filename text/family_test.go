@@ -0,0 +1,32 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package text
+
+import (
+	"testing"
+)
+
+// Tests that malformed TrueType collections return errors instead of
+// panicking or allocating their font count
+func TestFontCollectionMalformed(t *testing.T) {
+
+	header := "ttcf\x00\x01\x00\x00"
+	tests := []struct {
+		name string
+		data string
+		err  error
+	}{
+		{"font count", header + "\xFF\xFF\xFF\xFF", nil},
+		{"font offset", header + "\x00\x00\x00\x01\x00\xFF\xFF\xFF", nil},
+		{"table directory", header + "\x00\x00\x00\x01\x00\x00\x00\x10\x00\x01\x00\x00\x00\x10\x00\x00\x00\x00\x00\x00", nil},
+		{"cff font", header + "\x00\x00\x00\x01\x00\x00\x00\x10OTTO\x00\x00\x00\x00\x00\x00\x00\x00", ErrCFF},
+	}
+	for _, test := range tests {
+		_, err := NewFontCollectionFromData([]byte(test.data))
+		if err == nil || test.err != nil && err != test.err {
+			t.Errorf("%s: error %v", test.name, err)
+		}
+	}
+}
@@ -16,15 +16,18 @@ import (
 	"strings"
 )
 
-// Font represents a TrueType font face.
+// Font represents a TrueType font face, optionally with fallback fonts
+// for the characters it lacks (see NewFontFamily).
 // Attributes must be set prior to drawing.
 type Font struct {
-	ttf     *truetype.Font // The TrueType font
-	face    font.Face      // The font face
-	attrib  FontAttributes // Internal attribute cache
-	fg      *image.Uniform // Text color cache
-	bg      *image.Uniform // Background color cache
-	changed bool           // Whether attributes have changed and the font face needs to be recreated
+	fonts    []*familyFont             // The TrueType font followed by the fallback fonts
+	face     font.Face                 // The font face
+	attrib   FontAttributes            // Internal attribute cache
+	fg       *image.Uniform            // Text color cache
+	bg       *image.Uniform            // Background color cache
+	changed  bool                      // Whether attributes have changed and the font face needs to be recreated
+	atlases  map[FontAttributes]*Atlas // Glyph atlases shared by size, resolution and hinting
+	sdfAtlas *Atlas                    // Signed distance field glyph atlas
 }
 
 // FontAttributes contains tunable attributes of a font.
@@ -54,8 +57,21 @@ func NewFont(ttfFile string) (*Font, error) {
 }
 
 // NewFontFromData creates and returns a new font object from the specified TTF data.
+// For TrueType collections, the first font of the collection is used.
+// Returns ErrCFF or ErrNoOutlines for fonts without TrueType outlines.
 func NewFontFromData(fontData []byte) (*Font, error) {
 
+	if isCollection(fontData) {
+		data, err := collectionFont(fontData, 0)
+		if err != nil {
+			return nil, err
+		}
+		fontData = data
+	}
+	if err := checkOutlines(fontData); err != nil {
+		return nil, err
+	}
+
 	// Parses the font data
 	ttf, err := truetype.Parse(fontData)
 	if err != nil {
@@ -63,7 +79,7 @@ func NewFontFromData(fontData []byte) (*Font, error) {
 	}
 
 	f := new(Font)
-	f.ttf = ttf
+	f.fonts = []*familyFont{{ttf: ttf, otl: parseOTL(fontData)}}
 
 	// Initialize with default values
 	f.attrib = FontAttributes{}
@@ -74,11 +90,7 @@ func NewFontFromData(fontData []byte) (*Font, error) {
 	f.SetColor(&math32.Color4{0, 0, 0, 1})
 
 	// Create font face
-	f.face = truetype.NewFace(f.ttf, &truetype.Options{
-		Size:    f.attrib.PointSize,
-		DPI:     f.attrib.DPI,
-		Hinting: f.attrib.Hinting,
-	})
+	f.face = f.newFace(f.attrib.PointSize, f.attrib.DPI, f.attrib.Hinting)
 
 	return f, nil
 }
@@ -157,15 +169,36 @@ func (f *Font) SetAttributes(fa *FontAttributes) {
 func (f *Font) updateFace() {
 
 	if f.changed {
-		f.face = truetype.NewFace(f.ttf, &truetype.Options{
-			Size:    f.attrib.PointSize,
-			DPI:     f.attrib.DPI,
-			Hinting: f.attrib.Hinting,
-		})
+		f.face = f.newFace(f.attrib.PointSize, f.attrib.DPI, f.attrib.Hinting)
 		f.changed = false
 	}
 }
 
+// Atlas returns the glyph atlas for the current point size, resolution and hinting of the font.
+// Atlases are created when first requested and shared by all users of the font.
+func (f *Font) Atlas() *Atlas {
+
+	return f.atlasFor(f.attrib)
+}
+
+// atlasFor returns the shared glyph atlas for the specified font attributes
+func (f *Font) atlasFor(attrib FontAttributes) *Atlas {
+
+	// The line spacing does not change the glyphs
+	key := attrib
+	key.LineSpacing = 0
+	a := f.atlases[key]
+	if a == nil {
+		if f.atlases == nil {
+			f.atlases = make(map[FontAttributes]*Atlas)
+		}
+		a = newAtlas(f.newFace(attrib.PointSize, attrib.DPI, attrib.Hinting), 0)
+		a.setFonts(f.fonts, attrib.PointSize, attrib.DPI, attrib.Hinting)
+		f.atlases[key] = a
+	}
+	return a
+}
+
 // MeasureText returns the minimum width and height in pixels necessary for an image to contain
 // the specified text. The supplied text string can contain line break escape sequences (\n).
 func (f *Font) MeasureText(text string) (int, int) {
@@ -235,6 +268,49 @@ func (f *Font) DrawTextOnImage(text string, x, y int, dst *image.RGBA) {
 	}
 }
 
+// LineBounds returns the top and bottom y coordinates (in pixels) of the
+// specified line of a multi-line text drawn by DrawTextOnImage at the origin.
+func (f *Font) LineBounds(line int) (top, bottom int) {
+
+	f.updateFace()
+	metrics := f.face.Metrics()
+	lineHeight := (metrics.Ascent + metrics.Descent).Ceil()
+	lineGap := int((f.attrib.LineSpacing - float64(1)) * float64(lineHeight))
+	top = line * lineHeight
+	if line > 2 {
+		top += (line - 2) * lineGap
+	}
+	return top, top + lineHeight
+}
+
+// TextPos returns the line and column of the character boundary which is closest
+// to the specified position (in pixels) relative to the origin of the text.
+// The supplied text string can contain line break escape sequences (\n).
+func (f *Font) TextPos(text string, x, y int) (line, col int) {
+
+	// Finds the line containing the y coordinate
+	lines := strings.Split(text, "\n")
+	for line = 0; line < len(lines)-1; line++ {
+		_, bottom := f.LineBounds(line)
+		if y < bottom {
+			break
+		}
+	}
+
+	// Finds the closest character boundary in the line
+	s := lines[line]
+	count := StrCount(s)
+	prev := 0
+	for col = 0; col < count; col++ {
+		width, _ := f.MeasureText(StrPrefix(s, col+1))
+		if x < (prev+width)/2 {
+			break
+		}
+		prev = width
+	}
+	return line, col
+}
+
 // Canvas is an image to draw on.
 type Canvas struct {
 	RGBA    *image.RGBA
@@ -329,6 +405,37 @@ func (c Canvas) DrawTextCaret(x, y int, text string, f *Font, line, col int) err
 	return nil
 }
 
+// DrawTextSelection fills the background of the text between the start and end
+// line/column positions with the specified color, as if the text was drawn at the
+// specified position (in pixels) of this canvas using the specified font.
+// It should be called before the text itself is drawn.
+// The supplied text string can contain line break escape sequences (\n).
+func (c Canvas) DrawTextSelection(x, y int, text string, f *Font, line1, col1, line2, col2 int, color *math32.Color4) {
+
+	src := image.NewUniform(Color4RGBA(color))
+	spaceWidth, _ := f.MeasureText(" ")
+	lines := strings.Split(text, "\n")
+	for l := line1; l <= line2 && l < len(lines); l++ {
+		s := lines[l]
+		start := 0
+		if l == line1 {
+			start = col1
+		}
+		end := StrCount(s)
+		if l == line2 {
+			end = col2
+		}
+		x1, _ := f.MeasureText(StrPrefix(s, start))
+		x2, _ := f.MeasureText(StrPrefix(s, end))
+		// Shows the selected line break
+		if l < line2 {
+			x2 += spaceWidth
+		}
+		top, bottom := f.LineBounds(l)
+		draw.Draw(c.RGBA, image.Rect(x+x1, y+top, x+x2, y+bottom), src, image.ZP, draw.Over)
+	}
+}
+
 // Color4RGBA converts a math32.Color4 to Go's color.RGBA.
 func Color4RGBA(c *math32.Color4) color.RGBA {
 
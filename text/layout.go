@@ -0,0 +1,878 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package text
+
+import (
+	"image"
+	"sort"
+
+	"github.com/golang/freetype/truetype"
+	"github.com/sansebasko/engine/math32"
+	"golang.org/x/image/math/fixed"
+)
+
+// Align specifies the horizontal alignment of the lines of a text layout
+type Align int
+
+// The horizontal alignments of text layouts
+const (
+	AlignLeft    = Align(iota) // lines start at the left of the layout
+	AlignCenter                // lines are centered in the layout
+	AlignRight                 // lines end at the right of the layout
+	AlignJustify               // wrapped lines are stretched to the layout width
+)
+
+// Span is a part of a rich text drawn with the same style
+type Span struct {
+	Text      string         // Text of the span
+	Color     *math32.Color4 // Color of the span or nil for the default text color
+	Size      float64        // Point size of the span or 0 for the point size of the font
+	Bold      bool           // Whether the span is drawn in bold
+	Underline bool           // Whether the span is underlined
+}
+
+// LayoutOptions contains the options of a text layout
+type LayoutOptions struct {
+	Width     int       // Width in pixels available for the lines or 0 for no limit
+	Wrap      bool      // Whether lines wider than Width are broken at the allowed positions
+	Align     Align     // Horizontal alignment of the lines in Width or in the widest line
+	MaxLines  int       // Maximum number of lines or 0 for no limit
+	Ellipsis  bool      // Whether truncated lines end with an ellipsis
+	Direction Direction // Base direction of the paragraphs
+}
+
+// Layout is a text laid out by a Font in lines of positioned glyphs.
+// Positions are in pixels from the top left of the text.
+// The glyphs of each line are in visual order from left to right.
+type Layout struct {
+	Spans      []Span        // Spans of the text
+	Glyphs     []LayoutGlyph // Glyphs of the lines in order
+	Lines      []LayoutLine  // Lines of the text
+	Underlines []LayoutRect  // Underlines of the underlined spans
+	Width      int           // Width of the layout
+	Height     int           // Height of the layout
+	Truncated  bool          // Whether lines or characters were left out to fit the options
+	runes      []rune        // Characters of the text
+}
+
+// LayoutGlyph is a shaped glyph positioned in a text layout.
+// A glyph can draw several characters, such as a ligature, and the characters
+// of a cluster, such as a base and its marks, can be drawn by several glyphs.
+// Blank characters have glyphs without image.
+type LayoutGlyph struct {
+	Quad          // Position of the glyph image and the glyph
+	Atlas  *Atlas // Atlas or atlas page of the glyph
+	Index  int    // Index in runes of the first character of the cluster of the glyph or -1 for an ellipsis
+	End    int    // Index in runes after the last character of the cluster of the glyph
+	Span   int    // Index of the span of the characters
+	Left   int    // Position X of the left of the glyph advance
+	Right  int    // Position X of the right of the glyph advance
+	Bold   bool   // Whether the glyph is drawn in bold
+	RTL    bool   // Whether the glyph is in right to left text
+	Rune   rune   // The first character of the cluster
+	Line   int    // Index of the line of the glyph
+	bounds bool   // Whether the glyph counts in the width of its line
+	parts  int    // Number of caret positions in the glyph
+}
+
+// LayoutLine is a line of a text layout
+type LayoutLine struct {
+	Start      int  // Index in runes of the first character of the line in the text
+	End        int  // Index in runes after the last character of the line, before its line break
+	GlyphStart int  // Index of the first glyph of the line in the layout glyphs
+	GlyphEnd   int  // Index after the last glyph of the line in the layout glyphs
+	X          int  // Position X of the left of the line
+	Width      int  // Width of the line
+	Top        int  // Position Y of the top of the line
+	Baseline   int  // Position Y of the baseline of the line
+	Bottom     int  // Position Y of the bottom of the line
+	RTL        bool // Whether the paragraph of the line is right to left
+}
+
+// LayoutRect is a rectangle drawn with the style of a span of a text layout
+type LayoutRect struct {
+	Rect image.Rectangle // Position of the rectangle
+	Span int             // Index of the span
+}
+
+// layoutItem is a shaped glyph of a line being laid out
+type layoutItem struct {
+	r     rune           // first character of the cluster of the glyph
+	index int            // index in the text of the first character of the cluster or -1
+	end   int            // index in the text after the last character of the cluster
+	span  int            // span of the characters
+	atlas *Atlas         // atlas of the span
+	font  int            // font of the atlas family
+	gid   truetype.Index // glyph index in the font
+	adv   fixed.Int26_6  // advance of the glyph
+	x, y  fixed.Int26_6  // offset of the glyph image from its pen position
+	level uint8          // bidirectional level of the characters
+}
+
+// lineRange is the range of glyphs of a line being broken
+type lineRange struct {
+	start, end int  // range of the line glyphs
+	hard       bool // whether the line ends with a line break
+}
+
+// ellipsis is the character which ends truncated lines
+const ellipsis = '…'
+
+// Layout lays out the specified text with the current attributes of the font and the
+// specified options, breaking its lines at the line breaks and at the positions allowed
+// by the Unicode line breaking rules if wrapping. Lines are placed as by DrawText.
+func (f *Font) Layout(text string, opts *LayoutOptions) *Layout {
+
+	return f.LayoutSpans([]Span{{Text: text}}, opts)
+}
+
+// LayoutSpans lays out the specified rich text spans with the
+// current attributes of the font and the specified options.
+// The characters are shaped with the OpenType layout tables of the fonts
+// and ordered by the Unicode bidirectional algorithm.
+func (f *Font) LayoutSpans(spans []Span, opts *LayoutOptions) *Layout {
+
+	f.updateFace()
+	tl := &Layout{Spans: spans}
+
+	// Flattens the text of the spans
+	var runes []rune
+	var spanOf []int
+	atlases := make([]*Atlas, len(spans))
+	for i := range spans {
+		atlases[i] = f.spanAtlas(&spans[i])
+		for _, r := range spans[i].Text {
+			runes = append(runes, r)
+			spanOf = append(spanOf, i)
+		}
+	}
+	tl.runes = runes
+	levels, paras := bidiLevels(runes, opts.Direction)
+	items := shapeItems(runes, spanOf, spans, atlases, levels)
+	base := f.Atlas()
+
+	// Breaks the lines and truncates them to the maximum number of lines
+	lines := breakLines(runes, items, opts)
+	if opts.MaxLines > 0 && len(lines) > opts.MaxLines {
+		lines = lines[:opts.MaxLines]
+		tl.Truncated = true
+	}
+
+	// Builds the glyphs of the lines, truncating them if necessary
+	lineItems := make([][]layoutItem, len(lines))
+	widths := make([]fixed.Int26_6, len(lines))
+	for i, lr := range lines {
+		li := items[lr.start:lr.end]
+		last := tl.Truncated && i == len(lines)-1
+		para := paraLevel(paras, itemRune(items, lr.start, len(runes)), opts.Direction)
+		if opts.Ellipsis && opts.Width > 0 && (last || itemsWidth(li, false) > fixed.I(opts.Width)) {
+			li = f.truncate(li, fixed.I(opts.Width), base, para)
+			tl.Truncated = true
+		} else if opts.Ellipsis && last {
+			li = f.truncate(li, -1, base, para)
+		}
+		lineItems[i] = li
+		// Trailing spaces of wrapped lines do not count
+		widths[i] = itemsWidth(li, !lr.hard)
+	}
+
+	// Width of the layout
+	for _, w := range widths {
+		if w.Ceil() > tl.Width {
+			tl.Width = w.Ceil()
+		}
+	}
+	if opts.Width > 0 {
+		tl.Width = opts.Width
+	}
+
+	// Positions the glyphs of the lines
+	metrics := base.face.Metrics()
+	lineGap := int((f.attrib.LineSpacing - float64(1)) * float64((metrics.Ascent + metrics.Descent).Ceil()))
+	py := 0
+	for i, lr := range lines {
+		li := lineItems[i]
+		// The height of the line is the height of its tallest span
+		ascent, descent := metrics.Ascent, metrics.Descent
+		for _, it := range li {
+			m := it.atlas.face.Metrics()
+			if m.Ascent > ascent {
+				ascent = m.Ascent
+			}
+			if m.Descent > descent {
+				descent = m.Descent
+			}
+		}
+		start := itemRune(items, lr.start, len(runes))
+		line := LayoutLine{Start: start, End: itemRune(items, lr.end, len(runes)), GlyphStart: len(tl.Glyphs), Top: py}
+		line.Baseline = py + ascent.Round()
+		line.Bottom = py + (ascent + descent).Ceil()
+		line.Width = widths[i].Ceil()
+		para := paraLevel(paras, start, opts.Direction)
+		line.RTL = para%2 == 1
+
+		// Orders the glyphs visually, with the trailing whitespace at the paragraph level
+		lineLevels := append([]uint8(nil), levels[line.Start:line.End]...)
+		bidiResetLine(runes[line.Start:line.End], lineLevels, paras[line.Start:line.End])
+		trailing := len(li)
+		for trailing > 0 && li[trailing-1].r == ' ' {
+			trailing--
+		}
+		itemLevels := make([]uint8, len(li))
+		for k, it := range li {
+			itemLevels[k] = para
+			if it.index >= 0 {
+				itemLevels[k] = lineLevels[it.index-line.Start]
+			}
+		}
+		order := bidiReorder(itemLevels)
+		visual := make([]layoutItem, len(li))
+		bounds := make([]bool, len(li))
+		for k, j := range order {
+			visual[k] = li[j]
+			visual[k].level = itemLevels[j]
+			bounds[k] = j < trailing
+		}
+
+		// Aligns the line
+		var extra fixed.Int26_6
+		switch opts.Align {
+		case AlignCenter:
+			line.X = (tl.Width - line.Width) / 2
+		case AlignRight:
+			line.X = tl.Width - line.Width
+		case AlignJustify:
+			spaces := 0
+			for k, it := range visual {
+				if it.r == ' ' && bounds[k] {
+					spaces++
+				}
+			}
+			// The last line of a truncated text is not justified
+			last := tl.Truncated && i == len(lines)-1
+			if !lr.hard && !last && opts.Wrap && spaces > 0 && opts.Width > line.Width {
+				extra = (fixed.I(opts.Width) - widths[i]) / fixed.Int26_6(spaces)
+				line.Width = opts.Width
+			}
+		}
+		f.placeLine(tl, &line, visual, bounds, !lr.hard, extra, i)
+		tl.Lines = append(tl.Lines, line)
+
+		py = line.Bottom
+		if i > 1 {
+			py += lineGap
+		}
+	}
+	tl.Height = py
+	return tl
+}
+
+// shapeItems returns the shaped glyphs of the specified characters in logical order.
+// The characters are shaped in runs of the same span, font, script and bidirectional
+// level, with the characters of right to left runs mirrored, and each line break is
+// an item without glyph.
+func shapeItems(runes []rune, spanOf []int, spans []Span, atlases []*Atlas, levels []uint8) []layoutItem {
+
+	// Font and script of each character. Combining characters have the font of their base
+	// and the characters common to all scripts the script of the text around them.
+	fonts := make([]int, len(runes))
+	scripts := make([]string, len(runes))
+	script := ""
+	for i, r := range runes {
+		if i > 0 && isCombining(r) {
+			fonts[i] = fonts[i-1]
+		} else {
+			fonts[i] = atlases[spanOf[i]].fontFor(r)
+		}
+		if t := scriptTags(r); t != nil {
+			if script == "" {
+				for k := 0; k < i; k++ {
+					scripts[k] = t[0]
+				}
+			}
+			script = t[0]
+		}
+		scripts[i] = script
+	}
+
+	var items []layoutItem
+	for s := 0; s < len(runes); {
+		span := spanOf[s]
+		atlas := atlases[span]
+		if isLineBreak(runes[s]) {
+			items = append(items, layoutItem{r: runes[s], index: s, end: s + 1, span: span, atlas: atlas, level: levels[s]})
+			s++
+			continue
+		}
+		e := s + 1
+		for e < len(runes) && !isLineBreak(runes[e]) && spanOf[e] == span && fonts[e] == fonts[s] &&
+			levels[e] == levels[s] && scripts[e] == scripts[s] {
+			e++
+		}
+		run := make([]rune, e-s)
+		for k := range run {
+			run[k] = bidiMirror(runes[s+k], levels[s])
+		}
+		glyphs := atlas.shape(fonts[s], run, levels[s]%2 == 1)
+
+		// Each cluster ends at the start of the next one
+		starts := []int{e - s}
+		for _, g := range glyphs {
+			starts = append(starts, g.cluster)
+		}
+		sort.Ints(starts)
+		for _, g := range glyphs {
+			it := layoutItem{
+				r:     runes[s+g.cluster],
+				index: s + g.cluster,
+				span:  span,
+				atlas: atlas,
+				font:  fonts[s],
+				gid:   g.gid,
+				adv:   g.adv,
+				x:     g.x,
+				y:     g.y,
+				level: levels[s],
+			}
+			it.end = s + starts[sort.SearchInts(starts, g.cluster+1)]
+			// Bold glyphs are drawn twice one pixel apart
+			if spans[span].Bold && it.adv != 0 {
+				it.adv += fixed.I(1)
+			}
+			items = append(items, it)
+		}
+		s = e
+	}
+	return items
+}
+
+// itemRune returns the index in the text of the first character of the glyph
+// with the specified index or the length of the text after the last glyph.
+func itemRune(items []layoutItem, index, count int) int {
+
+	if index < len(items) {
+		return items[index].index
+	}
+	return count
+}
+
+// paraLevel returns the level of the paragraph of the character with the
+// specified index, which can be the length of the text.
+func paraLevel(paras []uint8, index int, dir Direction) uint8 {
+
+	if index < len(paras) {
+		return paras[index]
+	}
+	if len(paras) > 0 {
+		return paras[len(paras)-1]
+	}
+	if dir == DirectionRTL {
+		return 1
+	}
+	return 0
+}
+
+// spanAtlas returns the glyph atlas of the specified span
+func (f *Font) spanAtlas(s *Span) *Atlas {
+
+	attrib := f.attrib
+	if s.Size > 0 {
+		attrib.PointSize = s.Size
+	}
+	return f.atlasFor(attrib)
+}
+
+// breakLines returns the lines of the specified glyphs of the characters.
+// Lines are only broken between clusters.
+func breakLines(runes []rune, items []layoutItem, opts *LayoutOptions) []lineRange {
+
+	var lines []lineRange
+	breaks := lineBreaks(runes)
+	width := fixed.I(opts.Width)
+	wrap := opts.Wrap && opts.Width > 0
+	start := 0
+	for {
+		var w fixed.Int26_6
+		brk := -1
+		i := start
+		end, next := len(items), len(items)
+		hard := false
+		for ; i < len(items); i++ {
+			it := &items[i]
+			if isLineBreak(it.r) {
+				end = i
+				next = i + 1
+				if it.r == '\r' && next < len(items) && items[next].r == '\n' {
+					next++
+				}
+				hard = true
+				break
+			}
+			w += it.adv
+			// Trailing spaces hang beyond the width
+			if wrap && w > width && it.r != ' ' {
+				first := i
+				for first > start && items[first-1].index == it.index {
+					first--
+				}
+				if brk > start {
+					end = brk
+				} else if first > start {
+					end = first
+				} else {
+					end = i + 1
+					for end < len(items) && items[end].index == it.index {
+						end++
+					}
+				}
+				next = end
+				break
+			}
+			last := i+1 == len(items) || items[i+1].index != it.index
+			if last && breaks[it.end-1] == breakAllowed {
+				brk = i + 1
+			}
+		}
+		lines = append(lines, lineRange{start, end, hard || next == len(items)})
+		if i == len(items) {
+			return lines
+		}
+		start = next
+		if start == len(items) && !hard {
+			return lines
+		}
+	}
+}
+
+// itemsWidth returns the width of the specified glyphs,
+// optionally excluding trailing spaces.
+func itemsWidth(items []layoutItem, trim bool) fixed.Int26_6 {
+
+	if trim {
+		for len(items) > 0 && items[len(items)-1].r == ' ' {
+			items = items[:len(items)-1]
+		}
+	}
+	var w fixed.Int26_6
+	for i := range items {
+		w += items[i].adv
+	}
+	return w
+}
+
+// truncate removes clusters from the end of the specified line until it fits the
+// specified width with an ellipsis and returns it ending with the ellipsis at the
+// specified paragraph level. A negative width only appends the ellipsis.
+func (f *Font) truncate(items []layoutItem, width fixed.Int26_6, base *Atlas, para uint8) []layoutItem {
+
+	res := make([]layoutItem, len(items), len(items)+1)
+	copy(res, items)
+	r := ellipsis
+	count := 1
+	if !f.HasGlyph(r) {
+		r = '.'
+		count = 3
+	}
+	for {
+		atlas, span := base, 0
+		if len(res) > 0 {
+			atlas, span = res[len(res)-1].atlas, res[len(res)-1].span
+		}
+		var ell []layoutItem
+		var w fixed.Int26_6
+		fi := atlas.fontFor(r)
+		for _, g := range atlas.shape(fi, []rune{r}, false) {
+			it := layoutItem{r: r, index: -1, end: -1, span: span, atlas: atlas, font: fi, gid: g.gid, adv: g.adv, x: g.x, y: g.y, level: para}
+			for k := 0; k < count; k++ {
+				ell = append(ell, it)
+				w += it.adv
+			}
+		}
+		trimmed := res
+		for len(trimmed) > 0 && trimmed[len(trimmed)-1].r == ' ' {
+			trimmed = trimmed[:len(trimmed)-1]
+		}
+		if width < 0 || len(trimmed) == 0 || itemsWidth(trimmed, false)+w <= width {
+			return append(trimmed, ell...)
+		}
+		// Removes the last cluster
+		k := len(trimmed) - 1
+		for k > 0 && trimmed[k-1].index == trimmed[k].index {
+			k--
+		}
+		res = trimmed[:k]
+	}
+}
+
+// placeLine positions the specified glyphs of a line in visual order, adding the
+// specified extra advance to the inner spaces. The glyphs which do not count in
+// the width of the line hang outside of it if the line is wrapped.
+func (f *Font) placeLine(tl *Layout, line *LayoutLine, items []layoutItem, bounds []bool, hang bool, extra fixed.Int26_6, index int) {
+
+	var dot fixed.Int26_6
+	if hang {
+		for i := 0; i < len(items) && !bounds[i]; i++ {
+			dot -= items[i].adv
+		}
+	}
+	under := -1
+	for i := range items {
+		it := &items[i]
+		// Quantizes the pen position as the font face does when drawing
+		pos := (dot + it.x + 32/atlasSubPixels) &^ (64/atlasSubPixels - 1)
+		g, page := it.atlas.glyphIndex(it.font, it.gid, pos&63)
+		lg := LayoutGlyph{
+			Quad:   Quad{X: line.X + pos.Floor() + g.OffsetX, Y: line.Baseline + it.y.Round() + g.OffsetY, Glyph: g},
+			Atlas:  page,
+			Index:  it.index,
+			End:    it.end,
+			Span:   it.span,
+			Left:   line.X + dot.Round(),
+			Bold:   tl.Spans[it.span].Bold,
+			RTL:    it.level%2 == 1,
+			Rune:   it.r,
+			Line:   index,
+			bounds: bounds[i],
+			parts:  1,
+		}
+		dot += it.adv
+		if it.r == ' ' && bounds[i] {
+			dot += extra
+		}
+		lg.Right = line.X + dot.Round()
+		tl.Glyphs = append(tl.Glyphs, lg)
+
+		// Underlines the consecutive glyphs of underlined spans
+		if tl.Spans[it.span].Underline && bounds[i] {
+			if under >= 0 && tl.Underlines[under].Span == it.span {
+				tl.Underlines[under].Rect.Max.X = lg.Right
+			} else {
+				m := it.atlas.face.Metrics()
+				size := (m.Ascent + m.Descent).Ceil()
+				thickness := (size + 8) / 16
+				if thickness < 1 {
+					thickness = 1
+				}
+				y := line.Baseline + (size+6)/12
+				tl.Underlines = append(tl.Underlines, LayoutRect{image.Rect(lg.Left, y, lg.Right, y+thickness), it.span})
+				under = len(tl.Underlines) - 1
+			}
+		} else {
+			under = -1
+		}
+	}
+	line.GlyphEnd = len(tl.Glyphs)
+
+	// The caret can be placed between the characters of ligatures
+	glyphs := tl.Glyphs[line.GlyphStart:line.GlyphEnd]
+	for i := range glyphs {
+		g := &glyphs[i]
+		if g.Index < 0 || g.End-g.Index < 2 {
+			continue
+		}
+		single := true
+		for j := range glyphs {
+			single = single && (j == i || glyphs[j].Index != g.Index)
+		}
+		if single {
+			g.parts = 0
+			for _, r := range tl.runes[g.Index:g.End] {
+				if !isCombining(r) {
+					g.parts++
+				}
+			}
+		}
+	}
+}
+
+// Hit returns the index in runes of the character boundary of the
+// text which is closest to the specified position in the layout.
+func (tl *Layout) Hit(x, y int) int {
+
+	if len(tl.Lines) == 0 {
+		return 0
+	}
+	n := len(tl.Lines) - 1
+	for i := range tl.Lines {
+		if y < tl.Lines[i].Bottom {
+			n = i
+			break
+		}
+	}
+	line := &tl.Lines[n]
+	for i := line.GlyphStart; i < line.GlyphEnd; i++ {
+		g := &tl.Glyphs[i]
+		if g.Index < 0 || x >= g.Right {
+			continue
+		}
+		// Closest boundary of the glyph parts
+		p := 0
+		if w := g.Right - g.Left; w > 0 && x > g.Left {
+			p = (2*(x-g.Left)*g.parts + w) / (2 * w)
+		}
+		if p >= g.parts {
+			if g.RTL {
+				return g.Index
+			}
+			return g.End
+		}
+		if g.RTL {
+			return tl.partIndex(g, g.parts-p)
+		}
+		return tl.partIndex(g, p)
+	}
+	// Beyond the right of the line is its start in right to left paragraphs
+	if line.RTL {
+		return line.Start
+	}
+	// The end of a wrapped line is before its trailing space
+	// as the boundary after it is at the start of the next line
+	if n < len(tl.Lines)-1 && tl.Lines[n+1].Start == line.End && line.End > line.Start {
+		return line.End - 1
+	}
+	return line.End
+}
+
+// partIndex returns the index in runes of the start of the specified part of a glyph
+func (tl *Layout) partIndex(g *LayoutGlyph, part int) int {
+
+	index := g.Index
+	for part > 0 && index < g.End {
+		index++
+		if index == g.End || !isCombining(tl.runes[index]) {
+			part--
+		}
+	}
+	return index
+}
+
+// Caret returns the position X and the line of the caret placed
+// before the character with the specified index in runes in the text.
+// The caret is at the left of left to right characters and at the right
+// of right to left characters.
+func (tl *Layout) Caret(index int) (x, line int) {
+
+	for i := range tl.Lines {
+		l := &tl.Lines[i]
+		if index > l.End && i < len(tl.Lines)-1 {
+			continue
+		}
+		// The boundary between two wrapped lines is at the start of the second
+		if index == l.End && i+1 < len(tl.Lines) && tl.Lines[i+1].Start == index {
+			continue
+		}
+		glyphs := tl.Glyphs[l.GlyphStart:l.GlyphEnd]
+		// Extent of the cluster of the character
+		var cluster *LayoutGlyph
+		left, right := 0, 0
+		for k := range glyphs {
+			g := &glyphs[k]
+			if g.Index < 0 || index < g.Index || index >= g.End {
+				continue
+			}
+			if cluster == nil {
+				cluster = g
+				left, right = g.Left, g.Right
+			}
+			if g.Left < left {
+				left = g.Left
+			}
+			if g.Right > right {
+				right = g.Right
+			}
+		}
+		if cluster != nil {
+			// Carets inside ligatures divide them in equal parts
+			part := 0
+			for k := cluster.Index; k < index; k++ {
+				if !isCombining(tl.runes[k+1]) {
+					part++
+				}
+			}
+			if cluster.parts > 1 {
+				part = part * (right - left) / cluster.parts
+			} else {
+				part = 0
+			}
+			if cluster.RTL {
+				return right - part, i
+			}
+			return left + part, i
+		}
+		// Characters left out are at the ellipsis
+		if index < l.End {
+			for _, g := range glyphs {
+				if g.Index < 0 {
+					if l.RTL {
+						return g.Right, i
+					}
+					return g.Left, i
+				}
+			}
+		}
+		// The end of the line is at the end of the paragraph direction
+		if len(glyphs) == 0 {
+			return l.X, i
+		}
+		x = glyphs[len(glyphs)-1].Right
+		if l.RTL {
+			x = glyphs[0].Left
+		}
+		return x, i
+	}
+	return 0, 0
+}
+
+// Selection returns the rectangles covering the glyphs of the characters of the
+// specified range of indices in runes, one for each visually contiguous part of
+// the range in each line, as the range can be split in bidirectional text.
+func (tl *Layout) Selection(start, end int) []image.Rectangle {
+
+	var rects []image.Rectangle
+	for i := range tl.Lines {
+		l := &tl.Lines[i]
+		last := -1
+		for k := l.GlyphStart; k < l.GlyphEnd; k++ {
+			g := &tl.Glyphs[k]
+			if g.Index < 0 || g.Index >= end || g.End <= start {
+				continue
+			}
+			x1, x2 := g.Left, g.Right
+			// Part of a ligature
+			if g.parts > 1 {
+				x1 = tl.partX(g, maxInt(start, g.Index))
+				x2 = tl.partX(g, minInt(end, g.End))
+				if x1 > x2 {
+					x1, x2 = x2, x1
+				}
+			}
+			if last >= 0 && rects[last].Max.X == x1 {
+				rects[last].Max.X = x2
+				continue
+			}
+			rects = append(rects, image.Rect(x1, l.Top, x2, l.Bottom))
+			last = len(rects) - 1
+		}
+	}
+	return rects
+}
+
+// partX returns the position X of the caret before the character with the
+// specified index in runes in a glyph, or after the glyph for its end index.
+func (tl *Layout) partX(g *LayoutGlyph, index int) int {
+
+	part := 0
+	for k := g.Index; k < index; k++ {
+		if k+1 == g.End || !isCombining(tl.runes[k+1]) {
+			part++
+		}
+	}
+	w := part * (g.Right - g.Left) / g.parts
+	if g.RTL {
+		return g.Right - w
+	}
+	return g.Left + w
+}
+
+// minInt returns the minimum of two integers
+func minInt(a, b int) int {
+
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// maxInt returns the maximum of two integers
+func maxInt(a, b int) int {
+
+	if a > b {
+		return a
+	}
+	return b
+}
+
+// Move returns the index in runes of the character boundary which is visually next
+// to the specified one in its line, on the right if dir is positive or on the left if
+// it is negative. Beyond the ends of the line, the boundary is the nearest one of the
+// previous or next line in the direction of the paragraph, or the specified one for
+// the first and last lines.
+func (tl *Layout) Move(index, dir int) int {
+
+	if len(tl.Lines) == 0 {
+		return index
+	}
+	_, n := tl.Caret(index)
+	l := &tl.Lines[n]
+
+	// Boundaries of the line in visual order
+	type boundary struct{ index, x int }
+	var bs []boundary
+	for b := l.Start; b <= l.End; b++ {
+		if !tl.isBoundary(l, b) {
+			continue
+		}
+		x, _ := tl.Caret(b)
+		bs = append(bs, boundary{b, x})
+	}
+	sort.SliceStable(bs, func(i, j int) bool { return bs[i].x < bs[j].x })
+	pos := 0
+	for pos < len(bs)-1 && bs[pos].index != index {
+		pos++
+	}
+	if dir > 0 {
+		pos++
+	} else {
+		pos--
+	}
+	if pos >= 0 && pos < len(bs) {
+		return bs[pos].index
+	}
+
+	// Continues in the previous or next line
+	if (dir > 0) != l.RTL {
+		if n+1 < len(tl.Lines) {
+			return tl.Lines[n+1].Start
+		}
+	} else if n > 0 {
+		prev := &tl.Lines[n-1]
+		if prev.End == l.Start && prev.End > prev.Start {
+			return prev.End - 1
+		}
+		return prev.End
+	}
+	return index
+}
+
+// isBoundary returns if the caret can be placed before the character
+// with the specified index in runes of the specified line.
+func (tl *Layout) isBoundary(l *LayoutLine, index int) bool {
+
+	if index == l.Start || index == l.End {
+		return true
+	}
+	for _, g := range tl.Glyphs[l.GlyphStart:l.GlyphEnd] {
+		if g.Index >= 0 && index > g.Index && index < g.End {
+			return g.parts > 1 && !isCombining(tl.runes[index])
+		}
+	}
+	return true
+}
+
+// Index returns the index in runes in the text of the character at the specified
+// column of the specified line, limited to the characters of the line.
+func (tl *Layout) Index(line, col int) int {
+
+	if len(tl.Lines) == 0 {
+		return 0
+	}
+	line = int(math32.Clamp(float32(line), 0, float32(len(tl.Lines)-1)))
+	l := &tl.Lines[line]
+	index := l.Start + col
+	if index > l.End {
+		index = l.End
+	}
+	return index
+}
@@ -0,0 +1,107 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package text
+
+import (
+	"testing"
+)
+
+// testFont returns the font used by the tests
+func testFont(t *testing.T) *Font {
+
+	f, err := NewFont("../gui/assets/fonts/FreeSans.ttf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.SetPointSize(14)
+	return f
+}
+
+// lineTexts returns the text of each line of the specified layout
+func lineTexts(tl *Layout, text string) []string {
+
+	runes := []rune(text)
+	var lines []string
+	for _, line := range tl.Lines {
+		lines = append(lines, string(runes[line.Start:line.End]))
+	}
+	return lines
+}
+
+// Tests wrapping, truncation and alignment of text layouts
+func TestLayout(t *testing.T) {
+
+	const text = "The quick brown fox jumps over the lazy dog (really) quickly!"
+	f := testFont(t)
+	tests := []struct {
+		name      string
+		text      string
+		opts      LayoutOptions
+		lines     []string
+		truncated bool
+	}{
+		{"no width", text, LayoutOptions{Wrap: true}, []string{text}, false},
+		{"newlines", "one\ntwo\n", LayoutOptions{}, []string{"one", "two", ""}, false},
+		{"wrap", text, LayoutOptions{Width: 100, Wrap: true},
+			[]string{"The quick ", "brown fox jumps ", "over the lazy ", "dog (really) ", "quickly!"}, false},
+		{"long word", "Supercalifragilisticexpialidocious", LayoutOptions{Width: 50, Wrap: true},
+			[]string{"Superc", "alifragili", "sticexpi", "alidocio", "us"}, false},
+		{"ideographs", "日本語のテキストは、どこでも改行できます。", LayoutOptions{Width: 40, Wrap: true},
+			[]string{"日本語", "のテキ", "スト", "は、ど", "こでも", "改行で", "きま", "す。"}, false},
+		{"max lines", text, LayoutOptions{Width: 100, Wrap: true, MaxLines: 2},
+			[]string{"The quick ", "brown fox jumps "}, true},
+		{"ellipsis", text, LayoutOptions{Width: 100, Wrap: true, MaxLines: 2, Ellipsis: true},
+			nil, true},
+		{"single line ellipsis", text, LayoutOptions{Width: 80, Ellipsis: true}, nil, true},
+		{"justify", text, LayoutOptions{Width: 100, Wrap: true, Align: AlignJustify},
+			[]string{"The quick ", "brown fox jumps ", "over the lazy ", "dog (really) ", "quickly!"}, false},
+	}
+	for _, test := range tests {
+		tl := f.Layout(test.text, &test.opts)
+		if tl.Truncated != test.truncated {
+			t.Errorf("%s: truncated is %v", test.name, tl.Truncated)
+		}
+		if test.lines != nil {
+			lines := lineTexts(tl, test.text)
+			if len(lines) != len(test.lines) {
+				t.Errorf("%s: lines %q, expected %q", test.name, lines, test.lines)
+				continue
+			}
+			for i := range lines {
+				if lines[i] != test.lines[i] {
+					t.Errorf("%s: lines %q, expected %q", test.name, lines, test.lines)
+					break
+				}
+			}
+		}
+		if test.opts.Width == 0 {
+			continue
+		}
+		for i, line := range tl.Lines {
+			if line.Width > test.opts.Width {
+				t.Errorf("%s: line %d width %d exceeds %d", test.name, i, line.Width, test.opts.Width)
+			}
+		}
+		// Truncated lines end with an ellipsis within the width
+		if test.opts.Ellipsis {
+			last := tl.Glyphs[len(tl.Glyphs)-1]
+			if last.Rune != '…' || last.Index != -1 || last.Right > test.opts.Width {
+				t.Errorf("%s: last glyph %q at %d is not an ellipsis", test.name, last.Rune, last.Right)
+			}
+		}
+		// Justified lines but the last end at the width, ignoring their trailing spaces
+		if test.opts.Align == AlignJustify {
+			for i, line := range tl.Lines[:len(tl.Lines)-1] {
+				j := line.GlyphEnd - 1
+				for j > line.GlyphStart && tl.Glyphs[j].Rune == ' ' {
+					j--
+				}
+				if right := tl.Glyphs[j].Right; right < test.opts.Width-2 || right > test.opts.Width {
+					t.Errorf("%s: line %d ends at %d", test.name, i, right)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,161 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package text
+
+import (
+	"unicode"
+)
+
+// breakClass is the line breaking class of a character,
+// a subset of the classes of the Unicode line breaking algorithm (UAX #14).
+type breakClass int
+
+const (
+	breakAL  = breakClass(iota) // alphabetic and other characters which do not break
+	breakNU                     // numbers
+	breakBK                     // mandatory breaks
+	breakCR                     // carriage return
+	breakLF                     // line feed
+	breakSP                     // spaces
+	breakZW                     // zero width space
+	breakZWJ                    // zero width joiner
+	breakCM                     // combining marks
+	breakGL                     // non breaking glue and word joiner
+	breakOP                     // opening punctuation
+	breakCL                     // closing punctuation
+	breakEX                     // exclamation, interrogation and infix separators
+	breakNS                     // characters which do not start lines
+	breakBA                     // break opportunity after
+	breakHY                     // hyphen
+	breakID                     // ideographs and emoji which break before and after
+)
+
+// breakKind is the kind of line break after a character
+type breakKind int
+
+const (
+	breakNone      = breakKind(iota) // lines cannot break after the character
+	breakAllowed                     // lines can break after the character
+	breakMandatory                   // lines must break after the character
+)
+
+// breakClasses maps the punctuation characters to their line breaking classes
+var breakClasses = map[rune]breakClass{
+	'\n': breakLF, '\r': breakCR, '\v': breakBK, '\f': breakBK, 0x85: breakBK,
+	0x2028: breakBK, 0x2029: breakBK,
+	' ': breakSP, 0x200B: breakZW, 0x200D: breakZWJ,
+	0xA0: breakGL, 0x202F: breakGL, 0x2007: breakGL, 0x2060: breakGL, 0xFEFF: breakGL,
+	'\t': breakBA, 0xAD: breakBA, 0x2010: breakBA, 0x2012: breakBA, 0x2013: breakBA,
+	'|': breakBA, 0x1680: breakBA, 0x2000: breakBA, 0x2002: breakBA, 0x2009: breakBA,
+	'-': breakHY,
+	'(': breakOP, '[': breakOP, '{': breakOP, 0xAB: breakOP, 0xA1: breakOP, 0xBF: breakOP,
+	0x2018: breakOP, 0x201C: breakOP, 0x3008: breakOP, 0x300A: breakOP, 0x300C: breakOP,
+	0x300E: breakOP, 0x3010: breakOP, 0xFF08: breakOP, 0xFF3B: breakOP, 0xFF5B: breakOP,
+	')': breakCL, ']': breakCL, '}': breakCL, 0xBB: breakCL, 0x2019: breakCL, 0x201D: breakCL,
+	0x3001: breakCL, 0x3002: breakCL, 0x3009: breakCL, 0x300B: breakCL, 0x300D: breakCL,
+	0x300F: breakCL, 0x3011: breakCL, 0xFF09: breakCL, 0xFF0C: breakCL, 0xFF0E: breakCL,
+	0xFF3D: breakCL, 0xFF5D: breakCL,
+	'!': breakEX, '?': breakEX, ',': breakEX, '.': breakEX, ':': breakEX, ';': breakEX,
+	'/': breakEX, 0xFF01: breakEX, 0xFF1F: breakEX, 0xFF1A: breakEX, 0xFF1B: breakEX,
+	0x2026: breakNS, 0x30FC: breakNS, 0x3005: breakNS, 0x303B: breakNS, 0x30FB: breakNS,
+	0x309D: breakNS, 0x309E: breakNS, 0x30FD: breakNS, 0x30FE: breakNS,
+}
+
+// smallKana are the small kana which do not start lines in Japanese text
+const smallKana = "ぁぃぅぇぉっゃゅょゎゕゖァィゥェォッャュョヮヵヶ"
+
+// classOf returns the line breaking class of the specified character
+func classOf(r rune) breakClass {
+
+	if c, ok := breakClasses[r]; ok {
+		return c
+	}
+	switch {
+	case r >= '0' && r <= '9':
+		return breakNU
+	case unicode.In(r, unicode.Mn, unicode.Me):
+		return breakCM
+	case unicode.In(r, unicode.Hiragana, unicode.Katakana):
+		for _, k := range smallKana {
+			if r == k {
+				return breakNS
+			}
+		}
+		return breakID
+	case unicode.In(r, unicode.Han, unicode.Hangul):
+		return breakID
+	case r >= 0x3000 && r <= 0x303F, r >= 0xFF00 && r <= 0xFF60:
+		return breakID
+	case r >= 0x2600 && r <= 0x27BF, r >= 0x1F000 && r <= 0x1FAFF:
+		return breakID
+	}
+	return breakAL
+}
+
+// lineBreaks returns the kind of line break after each of the specified characters.
+// It implements the main rules of the Unicode line breaking algorithm (UAX #14):
+// mandatory breaks, breaks after spaces and hyphens, breaks around ideographs and
+// the rules which keep punctuation with the words it belongs to.
+func lineBreaks(runes []rune) []breakKind {
+
+	kinds := make([]breakKind, len(runes))
+	classes := make([]breakClass, len(runes))
+	for i, r := range runes {
+		classes[i] = classOf(r)
+	}
+	// Class of the last character before the spaces preceding the current position
+	prev := breakAL
+	for i := 0; i < len(runes); i++ {
+		a := classes[i]
+		if a != breakSP {
+			prev = a
+		}
+		if a == breakBK || a == breakLF {
+			kinds[i] = breakMandatory
+			continue
+		}
+		if a == breakCR {
+			if i+1 < len(runes) && classes[i+1] == breakLF {
+				continue
+			}
+			kinds[i] = breakMandatory
+			continue
+		}
+		if i+1 == len(runes) {
+			break
+		}
+		b := classes[i+1]
+		switch {
+		// Never break before line breaks, spaces and combining marks
+		case b == breakBK || b == breakCR || b == breakLF || b == breakSP || b == breakZW:
+		case b == breakCM || b == breakZWJ || a == breakZWJ:
+		case a == breakZW:
+			kinds[i] = breakAllowed
+		// Glue and word joiners
+		case a == breakGL || b == breakGL:
+		// Closing punctuation stays with the preceding text
+		case b == breakCL || b == breakEX:
+		// Opening punctuation stays with the following text, even after spaces
+		case prev == breakOP:
+		case a == breakSP:
+			kinds[i] = breakAllowed
+		case b == breakNS || b == breakBA || b == breakHY:
+		case a == breakHY && b == breakNU:
+		case a == breakBA || a == breakHY:
+			kinds[i] = breakAllowed
+		case a == breakOP:
+		case a == breakID || b == breakID:
+			kinds[i] = breakAllowed
+		}
+	}
+	return kinds
+}
+
+// isLineBreak returns if the specified character is a mandatory line break
+func isLineBreak(r rune) bool {
+
+	c := classOf(r)
+	return c == breakBK || c == breakCR || c == breakLF
+}
@@ -0,0 +1,52 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package text
+
+import (
+	"testing"
+)
+
+// Tests the line break opportunities of texts, shown in the expected
+// results by "|" for allowed breaks and "!" for mandatory breaks
+func TestLineBreaks(t *testing.T) {
+
+	tests := []struct {
+		text     string
+		expected string
+	}{
+		{"", ""},
+		{"word", "word"},
+		{"hello world", "hello |world"},
+		{"a  b", "a  |b"},
+		{"well-known", "well-|known"},
+		{"a\nb", "a\n!b"},
+		{"a\r\nb", "a\r\n!b"},
+		{"(hi) there.", "(hi) |there."},
+		{"\"quote\" end", "\"quote\" |end"},
+		{"1,000.5 km", "1,000.5 |km"},
+		{"$100 off", "$100 |off"},
+		{"end!", "end!"},
+		{"a b", "a b"},
+		{"a​b", "a​|b"},
+		{"日本語。です", "日|本|語。|で|す"},
+	}
+	for _, test := range tests {
+		runes := []rune(test.text)
+		kinds := lineBreaks(runes)
+		got := ""
+		for i, r := range runes {
+			got += string(r)
+			switch kinds[i] {
+			case breakAllowed:
+				got += "|"
+			case breakMandatory:
+				got += "!"
+			}
+		}
+		if got != test.expected {
+			t.Errorf("%q: breaks %q, expected %q", test.text, got, test.expected)
+		}
+	}
+}
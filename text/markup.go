@@ -0,0 +1,165 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package text
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/sansebasko/engine/math32"
+)
+
+// ParseMarkup returns the spans of the specified rich text markup.
+// The markup is text with tags in square brackets which change the style
+// of the text until the corresponding closing tag:
+//
+//	[b]bold[/b]
+//	[u]underlined[/u]
+//	[color=red]named color[/color] [color=#ff000080]hex color with optional alpha[/color]
+//	[size=18]point size[/size]
+//
+// Tags can be nested. A double opening bracket "[[" is a literal bracket.
+// Unknown or unbalanced tags are kept as text.
+func ParseMarkup(markup string) []Span {
+
+	var spans []Span
+	var sb strings.Builder
+	var bold, underline int
+	var colors []*math32.Color4
+	var sizes []float64
+
+	// flush appends the text accumulated so far with the current style
+	flush := func() {
+		if sb.Len() == 0 {
+			return
+		}
+		s := Span{Text: sb.String(), Bold: bold > 0, Underline: underline > 0}
+		if len(colors) > 0 {
+			s.Color = colors[len(colors)-1]
+		}
+		if len(sizes) > 0 {
+			s.Size = sizes[len(sizes)-1]
+		}
+		spans = append(spans, s)
+		sb.Reset()
+	}
+
+	for len(markup) > 0 {
+		pos := strings.IndexByte(markup, '[')
+		if pos < 0 {
+			sb.WriteString(markup)
+			break
+		}
+		sb.WriteString(markup[:pos])
+		markup = markup[pos:]
+		if strings.HasPrefix(markup, "[[") {
+			sb.WriteByte('[')
+			markup = markup[2:]
+			continue
+		}
+		end := strings.IndexByte(markup, ']')
+		if end < 0 {
+			sb.WriteString(markup)
+			break
+		}
+		tag := markup[1:end]
+		name, value := tag, ""
+		if eq := strings.IndexByte(tag, '='); eq >= 0 {
+			name, value = tag[:eq], tag[eq+1:]
+		}
+		ok := true
+		switch name {
+		case "b", "u", "/b", "/u", "/color", "/size":
+			if value != "" {
+				ok = false
+			}
+		}
+		if ok {
+			switch name {
+			case "b":
+				flush()
+				bold++
+			case "/b":
+				ok = bold > 0
+				if ok {
+					flush()
+					bold--
+				}
+			case "u":
+				flush()
+				underline++
+			case "/u":
+				ok = underline > 0
+				if ok {
+					flush()
+					underline--
+				}
+			case "color":
+				var c *math32.Color4
+				c, ok = parseMarkupColor(value)
+				if ok {
+					flush()
+					colors = append(colors, c)
+				}
+			case "/color":
+				ok = len(colors) > 0
+				if ok {
+					flush()
+					colors = colors[:len(colors)-1]
+				}
+			case "size":
+				size, err := strconv.ParseFloat(value, 64)
+				ok = err == nil && size > 0
+				if ok {
+					flush()
+					sizes = append(sizes, size)
+				}
+			case "/size":
+				ok = len(sizes) > 0
+				if ok {
+					flush()
+					sizes = sizes[:len(sizes)-1]
+				}
+			default:
+				ok = false
+			}
+		}
+		if !ok {
+			sb.WriteString(markup[:end+1])
+		}
+		markup = markup[end+1:]
+	}
+	flush()
+	return spans
+}
+
+// parseMarkupColor parses a color name or a hexadecimal color with an optional alpha
+func parseMarkupColor(value string) (*math32.Color4, bool) {
+
+	if strings.HasPrefix(value, "#") {
+		hex := value[1:]
+		if len(hex) != 6 && len(hex) != 8 {
+			return nil, false
+		}
+		v, err := strconv.ParseUint(hex, 16, 32)
+		if err != nil {
+			return nil, false
+		}
+		if len(hex) == 6 {
+			v = v<<8 | 0xFF
+		}
+		return &math32.Color4{
+			R: float32(v>>24) / 255,
+			G: float32(v>>16&0xFF) / 255,
+			B: float32(v>>8&0xFF) / 255,
+			A: float32(v&0xFF) / 255,
+		}, true
+	}
+	c, ok := math32.IsColorName(value)
+	if !ok {
+		return nil, false
+	}
+	return &math32.Color4{R: c.R, G: c.G, B: c.B, A: 1}, true
+}
@@ -0,0 +1,55 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package text
+
+import (
+	"testing"
+
+	"github.com/sansebasko/engine/math32"
+)
+
+// Tests the spans of valid markup and that invalid tags are kept as text
+func TestParseMarkup(t *testing.T) {
+
+	red := &math32.Color4{R: 1, A: 1}
+	green := &math32.Color4{G: 1, A: float32(0x80) / 255}
+	tests := []struct {
+		markup   string
+		expected []Span
+	}{
+		{"", nil},
+		{"plain", []Span{{Text: "plain"}}},
+		{"a [b]bold[/b] c", []Span{{Text: "a "}, {Text: "bold", Bold: true}, {Text: " c"}}},
+		{"[b][u]both[/u][/b]", []Span{{Text: "both", Bold: true, Underline: true}}},
+		{"[color=#ff0000]red[/color]", []Span{{Text: "red", Color: red}}},
+		{"[color=red]red[/color]", []Span{{Text: "red", Color: red}}},
+		{"[color=#00ff0080]green[/color]", []Span{{Text: "green", Color: green}}},
+		{"[size=20]big[/size]", []Span{{Text: "big", Size: 20}}},
+		{"[[b]", []Span{{Text: "[b]"}}},
+		// Invalid tags are kept as text
+		{"[x]", []Span{{Text: "[x]"}}},
+		{"[/b]", []Span{{Text: "[/b]"}}},
+		{"[b=1]", []Span{{Text: "[b=1]"}}},
+		{"[color=nope]a", []Span{{Text: "[color=nope]a"}}},
+		{"[size=-2]a", []Span{{Text: "[size=-2]a"}}},
+		{"[size=big]a", []Span{{Text: "[size=big]a"}}},
+		{"a [b", []Span{{Text: "a [b"}}},
+		{"[u]a[/b][/u]", []Span{{Text: "a[/b]", Underline: true}}},
+	}
+	for _, test := range tests {
+		spans := ParseMarkup(test.markup)
+		if len(spans) != len(test.expected) {
+			t.Errorf("%q: %d spans, expected %d", test.markup, len(spans), len(test.expected))
+			continue
+		}
+		for i, s := range spans {
+			e := test.expected[i]
+			if s.Text != e.Text || s.Bold != e.Bold || s.Underline != e.Underline || s.Size != e.Size ||
+				(s.Color == nil) != (e.Color == nil) || (s.Color != nil && *s.Color != *e.Color) {
+				t.Errorf("%q: span %d is %+v, expected %+v", test.markup, i, s, e)
+			}
+		}
+	}
+}
@@ -0,0 +1,790 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package text
+
+import (
+	"sort"
+
+	"github.com/golang/freetype/truetype"
+)
+
+// otlFont contains the OpenType layout tables of a font
+// used to substitute and position the glyphs of shaped text.
+// See https://docs.microsoft.com/typography/opentype/spec/ for the table formats.
+type otlFont struct {
+	gsub       *otlTable // glyph substitution table or nil
+	gpos       *otlTable // glyph positioning table or nil
+	gdef       []byte    // glyph definition table or nil
+	glyphClass int       // offset of the glyph class definition in gdef or 0
+	markClass  int       // offset of the mark attachment class definition in gdef or 0
+	markSets   int       // offset of the mark glyph sets definition in gdef or 0
+	upem       int       // font design units per em
+	kern       bool      // whether the font has a kern table, used instead of GPOS kerning
+}
+
+// otlTable is a GSUB or GPOS table
+type otlTable struct {
+	data     []byte           // table data
+	scripts  int              // offset of the script list
+	features int              // offset of the feature list
+	lookups  int              // offset of the lookup list
+	cache    map[string][]int // lookups of the features already requested by scripts
+}
+
+// otlLookup is a lookup of a GSUB or GPOS table
+type otlLookup struct {
+	typ       int   // lookup type
+	flag      int   // lookup flags
+	markSet   int   // index of the mark filtering set
+	subtables []int // offsets of the subtables in the table data
+}
+
+// Lookup flags
+const (
+	otlIgnoreBase      = 0x0002
+	otlIgnoreLigatures = 0x0004
+	otlIgnoreMarks     = 0x0008
+	otlUseMarkSet      = 0x0010
+	otlMarkClassMask   = 0xFF00
+)
+
+// GDEF glyph classes
+const (
+	otlBase      = 1
+	otlLigature  = 2
+	otlMark      = 3
+	otlComponent = 4
+)
+
+// parseOTL returns the OpenType layout tables of the specified TrueType font data
+// or nil if the font has no substitution or positioning tables.
+func parseOTL(data []byte) *otlFont {
+
+	tables := make(map[string][]byte)
+	count := u16(data, 4)
+	for i := 0; i < count; i++ {
+		rec := 12 + 16*i
+		if rec+16 > len(data) {
+			break
+		}
+		off := int(u32(data, rec+8))
+		length := int(u32(data, rec+12))
+		if off < 0 || length < 0 || off+length > len(data) {
+			continue
+		}
+		tables[string(data[rec:rec+4])] = data[off : off+length]
+	}
+	o := new(otlFont)
+	o.gsub = newOTLTable(tables["GSUB"])
+	o.gpos = newOTLTable(tables["GPOS"])
+	if o.gsub == nil && o.gpos == nil {
+		return nil
+	}
+	if gdef := tables["GDEF"]; len(gdef) >= 12 {
+		o.gdef = gdef
+		o.glyphClass = u16(gdef, 4)
+		o.markClass = u16(gdef, 10)
+		if u16(gdef, 2) >= 2 && len(gdef) >= 14 {
+			o.markSets = u16(gdef, 12)
+		}
+	}
+	o.upem = u16(tables["head"], 18)
+	if o.upem == 0 {
+		o.upem = 2048
+	}
+	_, o.kern = tables["kern"]
+	return o
+}
+
+// newOTLTable returns the specified GSUB or GPOS table data or nil if it is invalid
+func newOTLTable(data []byte) *otlTable {
+
+	if len(data) < 10 {
+		return nil
+	}
+	return &otlTable{data: data, scripts: u16(data, 4), features: u16(data, 6), lookups: u16(data, 8)}
+}
+
+// u16 returns the big endian unsigned 16 bits integer at the specified offset or 0 if out of range
+func u16(b []byte, off int) int {
+
+	if off < 0 || off+2 > len(b) {
+		return 0
+	}
+	return int(b[off])<<8 | int(b[off+1])
+}
+
+// i16 returns the big endian signed 16 bits integer at the specified offset or 0 if out of range
+func i16(b []byte, off int) int {
+
+	return int(int16(u16(b, off)))
+}
+
+// u32 returns the big endian unsigned 32 bits integer at the specified offset or 0 if out of range
+func u32(b []byte, off int) uint32 {
+
+	if off < 0 || off+4 > len(b) {
+		return 0
+	}
+	return uint32(b[off])<<24 | uint32(b[off+1])<<16 | uint32(b[off+2])<<8 | uint32(b[off+3])
+}
+
+// featureLookups returns the indices of the lookups of the specified feature
+// for the first of the specified scripts found in the table, or for its default script.
+func (t *otlTable) featureLookups(scripts []string, feature string) []int {
+
+	d := t.data
+	langSys := 0
+	count := u16(d, t.scripts)
+	for _, script := range append(append([]string{}, scripts...), "DFLT") {
+		for i := 0; i < count; i++ {
+			rec := t.scripts + 2 + 6*i
+			if rec+6 > len(d) {
+				break
+			}
+			if string(d[rec:rec+4]) == script {
+				langSys = u16(d, t.scripts+u16(d, rec+4))
+				if langSys != 0 {
+					langSys += t.scripts + u16(d, rec+4)
+				}
+				break
+			}
+		}
+		if langSys != 0 {
+			break
+		}
+	}
+	if langSys == 0 {
+		return nil
+	}
+	var lookups []int
+	features := u16(d, langSys+4)
+	for i := 0; i < features; i++ {
+		index := u16(d, langSys+6+2*i)
+		rec := t.features + 2 + 6*index
+		if rec+6 > len(d) || string(d[rec:rec+4]) != feature {
+			continue
+		}
+		ft := t.features + u16(d, rec+4)
+		n := u16(d, ft+2)
+		for j := 0; j < n; j++ {
+			lookups = append(lookups, u16(d, ft+4+2*j))
+		}
+	}
+	return lookups
+}
+
+// lookup returns the lookup with the specified index
+func (t *otlTable) lookup(index int) otlLookup {
+
+	d := t.data
+	if index >= u16(d, t.lookups) {
+		return otlLookup{}
+	}
+	off := t.lookups + u16(d, t.lookups+2+2*index)
+	l := otlLookup{typ: u16(d, off), flag: u16(d, off+2)}
+	n := u16(d, off+4)
+	for i := 0; i < n; i++ {
+		l.subtables = append(l.subtables, off+u16(d, off+6+2*i))
+	}
+	if l.flag&otlUseMarkSet != 0 {
+		l.markSet = u16(d, off+6+2*n)
+	}
+	return l
+}
+
+// coverage returns the coverage index of the specified glyph
+// in the coverage table at the specified offset or -1.
+func coverage(d []byte, off int, g truetype.Index) int {
+
+	gid := int(g)
+	switch u16(d, off) {
+	case 1:
+		n := u16(d, off+2)
+		i := sort.Search(n, func(i int) bool { return u16(d, off+4+2*i) >= gid })
+		if i < n && u16(d, off+4+2*i) == gid {
+			return i
+		}
+	case 2:
+		n := u16(d, off+2)
+		i := sort.Search(n, func(i int) bool { return u16(d, off+4+6*i+2) >= gid })
+		if i < n {
+			rec := off + 4 + 6*i
+			if start := u16(d, rec); gid >= start {
+				return u16(d, rec+4) + gid - start
+			}
+		}
+	}
+	return -1
+}
+
+// classOfGlyph returns the class of the specified glyph in
+// the class definition table at the specified offset.
+func classOfGlyph(d []byte, off int, g truetype.Index) int {
+
+	gid := int(g)
+	switch u16(d, off) {
+	case 1:
+		start := u16(d, off+2)
+		if gid >= start && gid < start+u16(d, off+4) {
+			return u16(d, off+6+2*(gid-start))
+		}
+	case 2:
+		n := u16(d, off+2)
+		i := sort.Search(n, func(i int) bool { return u16(d, off+4+6*i+2) >= gid })
+		if i < n {
+			rec := off + 4 + 6*i
+			if gid >= u16(d, rec) {
+				return u16(d, rec+4)
+			}
+		}
+	}
+	return 0
+}
+
+// glyphClassOf returns the GDEF class of the specified glyph or 0
+func (o *otlFont) glyphClassOf(g truetype.Index) int {
+
+	if o.glyphClass == 0 {
+		return 0
+	}
+	return classOfGlyph(o.gdef, o.glyphClass, g)
+}
+
+// skip returns if the specified glyph is ignored by a lookup with the specified flags
+func (o *otlFont) skip(g *shapeGlyph, l *otlLookup) bool {
+
+	switch g.class {
+	case otlBase:
+		return l.flag&otlIgnoreBase != 0
+	case otlLigature:
+		return l.flag&otlIgnoreLigatures != 0
+	case otlMark:
+		if l.flag&otlIgnoreMarks != 0 {
+			return true
+		}
+		if l.flag&otlMarkClassMask != 0 && o.markClass != 0 {
+			if classOfGlyph(o.gdef, o.markClass, g.gid) != l.flag>>8 {
+				return true
+			}
+		}
+		if l.flag&otlUseMarkSet != 0 && o.markSets != 0 {
+			sets := o.markSets
+			if l.markSet >= u16(o.gdef, sets+2) {
+				return true
+			}
+			set := sets + int(u32(o.gdef, sets+4+4*l.markSet))
+			return coverage(o.gdef, set, g.gid) < 0
+		}
+	}
+	return false
+}
+
+// next returns the index of the first glyph from the specified one which is not
+// ignored by the specified lookup or -1
+func (o *otlFont) next(buf []shapeGlyph, i int, l *otlLookup) int {
+
+	for ; i < len(buf); i++ {
+		if !o.skip(&buf[i], l) {
+			return i
+		}
+	}
+	return -1
+}
+
+// prev returns the index of the last glyph before the specified one which is not
+// ignored by the specified lookup or -1
+func (o *otlFont) prev(buf []shapeGlyph, i int, l *otlLookup) int {
+
+	for i--; i >= 0; i-- {
+		if !o.skip(&buf[i], l) {
+			return i
+		}
+	}
+	return -1
+}
+
+// setGlyph sets the glyph index of a shaped glyph and its GDEF class
+func (o *otlFont) setGlyph(g *shapeGlyph, gid truetype.Index) {
+
+	g.gid = gid
+	if class := o.glyphClassOf(gid); class != 0 {
+		g.class = class
+	}
+}
+
+// substitute applies the specified GSUB lookups to the glyphs of the buffer
+// which have the specified feature mask and returns the new buffer.
+func (o *otlFont) substitute(buf []shapeGlyph, lookups []int, mask uint64) []shapeGlyph {
+
+	for _, index := range lookups {
+		l := o.gsub.lookup(index)
+		for i := 0; i < len(buf); {
+			if buf[i].mask&mask == 0 || o.skip(&buf[i], &l) {
+				i++
+				continue
+			}
+			var next int
+			buf, next = o.substituteAt(buf, i, &l, 0)
+			if next <= i {
+				next = i + 1
+			}
+			i = next
+		}
+	}
+	return buf
+}
+
+// substituteAt applies the specified GSUB lookup at the specified glyph of the buffer
+// and returns the new buffer and the index of the next glyph to process,
+// which is the specified glyph if no substitution was done.
+func (o *otlFont) substituteAt(buf []shapeGlyph, i int, l *otlLookup, depth int) ([]shapeGlyph, int) {
+
+	d := o.gsub.data
+	for _, st := range l.subtables {
+		typ := l.typ
+		// Extension subtables point to the actual subtable
+		if typ == 7 {
+			typ = u16(d, st+2)
+			st += int(u32(d, st+4))
+		}
+		cov := -1
+		if typ >= 1 && typ <= 4 {
+			cov = coverage(d, st+u16(d, st+2), buf[i].gid)
+		}
+		switch typ {
+		case 1: // Single substitution
+			if cov < 0 {
+				continue
+			}
+			if u16(d, st) == 1 {
+				o.setGlyph(&buf[i], truetype.Index(int(buf[i].gid)+i16(d, st+4)))
+			} else {
+				o.setGlyph(&buf[i], truetype.Index(u16(d, st+6+2*cov)))
+			}
+			return buf, i + 1
+		case 2: // Multiple substitution
+			if cov < 0 {
+				continue
+			}
+			seq := st + u16(d, st+6+2*cov)
+			n := u16(d, seq)
+			if n == 0 {
+				return append(buf[:i], buf[i+1:]...), i
+			}
+			out := make([]shapeGlyph, n)
+			for k := range out {
+				out[k] = buf[i]
+				o.setGlyph(&out[k], truetype.Index(u16(d, seq+2+2*k)))
+			}
+			buf = append(buf[:i], append(out, buf[i+1:]...)...)
+			return buf, i + n
+		case 3: // Alternate substitution uses the first alternate
+			if cov < 0 {
+				continue
+			}
+			set := st + u16(d, st+6+2*cov)
+			if u16(d, set) > 0 {
+				o.setGlyph(&buf[i], truetype.Index(u16(d, set+2)))
+			}
+			return buf, i + 1
+		case 4: // Ligature substitution
+			if cov < 0 {
+				continue
+			}
+			set := st + u16(d, st+6+2*cov)
+			for k := 0; k < u16(d, set); k++ {
+				lig := set + u16(d, set+2+2*k)
+				comps := u16(d, lig+2)
+				pos := []int{i}
+				j := i
+				for c := 1; c < comps; c++ {
+					j = o.next(buf, j+1, l)
+					if j < 0 || int(buf[j].gid) != u16(d, lig+4+2*(c-1)) {
+						pos = nil
+						break
+					}
+					pos = append(pos, j)
+				}
+				if pos == nil {
+					continue
+				}
+				// The ligature replaces the first component and the
+				// other components are removed, keeping skipped marks.
+				o.setGlyph(&buf[i], truetype.Index(u16(d, lig)))
+				buf[i].class = otlLigature
+				if c := o.glyphClassOf(buf[i].gid); c != 0 {
+					buf[i].class = c
+				}
+				for c := len(pos) - 1; c > 0; c-- {
+					if buf[pos[c]].cluster < buf[i].cluster {
+						buf[i].cluster = buf[pos[c]].cluster
+					}
+					buf[i].flags |= buf[pos[c]].flags
+					buf = append(buf[:pos[c]], buf[pos[c]+1:]...)
+				}
+				return buf, i + 1
+			}
+		case 5, 6: // Contextual and chained contextual substitution
+			if depth > 8 {
+				continue
+			}
+			if pos, records := o.matchContext(o.gsub, buf, i, l, st, typ == 6); pos != nil {
+				end := pos[len(pos)-1] + 1
+				for _, rec := range records {
+					seq, index := rec[0], rec[1]
+					if seq >= len(pos) {
+						continue
+					}
+					nl := o.gsub.lookup(index)
+					n := len(buf)
+					buf, _ = o.substituteAt(buf, pos[seq], &nl, depth+1)
+					// Shifts the positions after a change of the buffer length
+					delta := len(buf) - n
+					for k := seq + 1; k < len(pos); k++ {
+						pos[k] += delta
+					}
+					end += delta
+				}
+				return buf, end
+			}
+		}
+	}
+	return buf, i
+}
+
+// matchContext matches the input sequence of a contextual or chained contextual
+// subtable at the specified glyph and returns the positions of the matched input glyphs
+// and the lookup records (sequence index, lookup index) to apply or nil.
+func (o *otlFont) matchContext(t *otlTable, buf []shapeGlyph, i int, l *otlLookup, st int, chained bool) ([]int, [][2]int) {
+
+	d := t.data
+	format := u16(d, st)
+
+	// match checks a sequence of glyph values from position i forwards or backwards
+	match := func(start, count int, back bool, value func(k, gid int) bool) []int {
+		var pos []int
+		j := start
+		for k := 0; k < count; k++ {
+			if back {
+				j = o.prev(buf, j, l)
+			} else {
+				j = o.next(buf, j+1, l)
+			}
+			if j < 0 || !value(k, int(buf[j].gid)) {
+				return nil
+			}
+			pos = append(pos, j)
+		}
+		if pos == nil {
+			pos = []int{}
+		}
+		return pos
+	}
+	records := func(off, n int) [][2]int {
+		var recs [][2]int
+		for k := 0; k < n; k++ {
+			recs = append(recs, [2]int{u16(d, off+4*k), u16(d, off+4*k+2)})
+		}
+		return recs
+	}
+
+	switch format {
+	case 1, 2:
+		cov := coverage(d, st+u16(d, st+2), buf[i].gid)
+		if cov < 0 {
+			return nil, nil
+		}
+		var backDef, inDef, aheadDef int
+		setIndex := cov
+		sets := st + 6
+		if format == 2 {
+			if chained {
+				backDef, inDef, aheadDef = st+u16(d, st+4), st+u16(d, st+6), st+u16(d, st+8)
+				sets = st + 12
+			} else {
+				inDef = st + u16(d, st+4)
+				sets = st + 8
+			}
+			setIndex = classOfGlyph(d, inDef, buf[i].gid)
+		} else if chained {
+			sets = st + 6
+		}
+		if setIndex >= u16(d, sets-2) || u16(d, sets+2*setIndex) == 0 {
+			return nil, nil
+		}
+		set := st + u16(d, sets+2*setIndex)
+		valueOf := func(def int) func(off int) func(k, gid int) bool {
+			return func(off int) func(k, gid int) bool {
+				return func(k, gid int) bool {
+					v := u16(d, off+2*k)
+					if format == 1 {
+						return gid == v
+					}
+					return classOfGlyph(d, def, truetype.Index(gid)) == v
+				}
+			}
+		}
+		for r := 0; r < u16(d, set); r++ {
+			rule := set + u16(d, set+2+2*r)
+			off := rule
+			if chained {
+				nb := u16(d, off)
+				if match(i, nb, true, valueOf(backDef)(off+2)) == nil {
+					continue
+				}
+				off += 2 + 2*nb
+			}
+			ni := u16(d, off)
+			if !chained {
+				nr := u16(d, off+2)
+				in := match(i, ni-1, false, valueOf(inDef)(off+4))
+				if in == nil {
+					continue
+				}
+				return append([]int{i}, in...), records(off+4+2*(ni-1), nr)
+			}
+			in := match(i, ni-1, false, valueOf(inDef)(off+2))
+			if in == nil {
+				continue
+			}
+			pos := append([]int{i}, in...)
+			off += 2 + 2*(ni-1)
+			na := u16(d, off)
+			if match(pos[len(pos)-1], na, false, valueOf(aheadDef)(off+2)) == nil {
+				continue
+			}
+			off += 2 + 2*na
+			return pos, records(off+2, u16(d, off))
+		}
+	case 3:
+		covValue := func(off int) func(k, gid int) bool {
+			return func(k, gid int) bool {
+				return coverage(d, st+u16(d, off+2*k), truetype.Index(gid)) >= 0
+			}
+		}
+		off := st + 2
+		if !chained {
+			ni, nr := u16(d, off), u16(d, off+2)
+			if ni == 0 || coverage(d, st+u16(d, off+4), buf[i].gid) < 0 {
+				return nil, nil
+			}
+			in := match(i, ni-1, false, covValue(off+6))
+			if in == nil {
+				return nil, nil
+			}
+			return append([]int{i}, in...), records(off+4+2*ni, nr)
+		}
+		nb := u16(d, off)
+		if match(i, nb, true, covValue(off+2)) == nil {
+			return nil, nil
+		}
+		off += 2 + 2*nb
+		ni := u16(d, off)
+		if ni == 0 || coverage(d, st+u16(d, off+2), buf[i].gid) < 0 {
+			return nil, nil
+		}
+		in := match(i, ni-1, false, covValue(off+4))
+		if in == nil {
+			return nil, nil
+		}
+		pos := append([]int{i}, in...)
+		off += 2 + 2*ni
+		na := u16(d, off)
+		if match(pos[len(pos)-1], na, false, covValue(off+2)) == nil {
+			return nil, nil
+		}
+		off += 2 + 2*na
+		return pos, records(off+2, u16(d, off))
+	}
+	return nil, nil
+}
+
+// position applies the specified GPOS lookups to the glyphs
+// of the buffer which have the specified feature mask.
+func (o *otlFont) position(buf []shapeGlyph, lookups []int, mask uint64) {
+
+	for _, index := range lookups {
+		l := o.gpos.lookup(index)
+		for i := 0; i < len(buf); {
+			if buf[i].mask&mask == 0 || o.skip(&buf[i], &l) {
+				i++
+				continue
+			}
+			next := o.positionAt(buf, i, &l, 0)
+			if next <= i {
+				next = i + 1
+			}
+			i = next
+		}
+	}
+}
+
+// positionAt applies the specified GPOS lookup at the specified glyph of the buffer
+// and returns the index of the next glyph to process.
+func (o *otlFont) positionAt(buf []shapeGlyph, i int, l *otlLookup, depth int) int {
+
+	d := o.gpos.data
+	g := &buf[i]
+	for _, st := range l.subtables {
+		typ := l.typ
+		if typ == 9 {
+			typ = u16(d, st+2)
+			st += int(u32(d, st+4))
+		}
+		switch typ {
+		case 1: // Single adjustment
+			cov := coverage(d, st+u16(d, st+2), g.gid)
+			if cov < 0 {
+				continue
+			}
+			vf := u16(d, st+4)
+			if u16(d, st) == 1 {
+				o.addValue(g, d, st+6, vf)
+			} else {
+				o.addValue(g, d, st+8+cov*valueSize(vf), vf)
+			}
+			return i + 1
+		case 2: // Pair adjustment
+			cov := coverage(d, st+u16(d, st+2), g.gid)
+			if cov < 0 {
+				continue
+			}
+			j := o.next(buf, i+1, l)
+			if j < 0 {
+				return i + 1
+			}
+			vf1, vf2 := u16(d, st+4), u16(d, st+6)
+			s1, s2 := valueSize(vf1), valueSize(vf2)
+			rec := -1
+			if u16(d, st) == 1 {
+				set := st + u16(d, st+10+2*cov)
+				n := u16(d, set)
+				size := 2 + s1 + s2
+				second := int(buf[j].gid)
+				k := sort.Search(n, func(k int) bool { return u16(d, set+2+k*size) >= second })
+				if k < n && u16(d, set+2+k*size) == second {
+					rec = set + 2 + k*size + 2
+				}
+			} else {
+				c1 := classOfGlyph(d, st+u16(d, st+8), g.gid)
+				c2 := classOfGlyph(d, st+u16(d, st+10), buf[j].gid)
+				n1, n2 := u16(d, st+12), u16(d, st+14)
+				if c1 < n1 && c2 < n2 {
+					rec = st + 16 + (c1*n2+c2)*(s1+s2)
+				}
+			}
+			if rec < 0 {
+				continue
+			}
+			o.addValue(g, d, rec, vf1)
+			o.addValue(&buf[j], d, rec+s1, vf2)
+			if vf2 != 0 {
+				return j + 1
+			}
+			return j
+		case 4, 5, 6: // Mark to base, mark to ligature and mark to mark attachment
+			markCov := coverage(d, st+u16(d, st+2), g.gid)
+			if markCov < 0 {
+				continue
+			}
+			// Finds the glyph to attach to
+			j := i - 1
+			for ; j >= 0; j-- {
+				c := buf[j].class
+				if typ == 6 {
+					if !o.skip(&buf[j], l) {
+						break
+					}
+				} else if c != otlMark {
+					break
+				}
+			}
+			if j < 0 {
+				return i + 1
+			}
+			if typ == 6 && buf[j].class != otlMark {
+				return i + 1
+			}
+			baseCov := coverage(d, st+u16(d, st+4), buf[j].gid)
+			if baseCov < 0 {
+				continue
+			}
+			classes := u16(d, st+6)
+			marks := st + u16(d, st+8)
+			bases := st + u16(d, st+10)
+			if markCov >= u16(d, marks) {
+				continue
+			}
+			class := u16(d, marks+2+4*markCov)
+			markAnchor := marks + u16(d, marks+2+4*markCov+2)
+			var baseAnchor int
+			if typ == 5 {
+				// Attaches to the last component of the ligature
+				lig := bases + u16(d, bases+2+2*baseCov)
+				comps := u16(d, lig)
+				if comps == 0 {
+					continue
+				}
+				rec := lig + 2 + (comps-1)*classes*2 + class*2
+				if u16(d, rec) == 0 {
+					continue
+				}
+				baseAnchor = lig + u16(d, rec)
+			} else {
+				rec := bases + 2 + (baseCov*classes+class)*2
+				if u16(d, rec) == 0 {
+					continue
+				}
+				baseAnchor = bases + u16(d, rec)
+			}
+			g.attach = j
+			g.attachX = i16(d, baseAnchor+2) - i16(d, markAnchor+2)
+			g.attachY = i16(d, baseAnchor+4) - i16(d, markAnchor+4)
+			g.zeroAdvance = true
+			return i + 1
+		case 7, 8: // Contextual and chained contextual positioning
+			if depth > 8 {
+				continue
+			}
+			if pos, records := o.matchContext(o.gpos, buf, i, l, st, typ == 8); pos != nil {
+				for _, rec := range records {
+					if rec[0] < len(pos) {
+						nl := o.gpos.lookup(rec[1])
+						o.positionAt(buf, pos[rec[0]], &nl, depth+1)
+					}
+				}
+				return pos[len(pos)-1] + 1
+			}
+		}
+	}
+	return i + 1
+}
+
+// valueSize returns the size in bytes of a value record with the specified format
+func valueSize(format int) int {
+
+	n := 0
+	for f := format; f != 0; f >>= 1 {
+		n += f & 1
+	}
+	return 2 * n
+}
+
+// addValue adds the placement and advance of the value record at the specified offset
+func (o *otlFont) addValue(g *shapeGlyph, d []byte, off, format int) {
+
+	if format&0x1 != 0 {
+		g.xOffset += i16(d, off)
+		off += 2
+	}
+	if format&0x2 != 0 {
+		g.yOffset += i16(d, off)
+		off += 2
+	}
+	if format&0x4 != 0 {
+		g.xAdvance += i16(d, off)
+	}
+}
@@ -0,0 +1,185 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package text
+
+import (
+	"image"
+	"image/draw"
+
+	"github.com/golang/freetype/raster"
+	"github.com/golang/freetype/truetype"
+	"golang.org/x/image/font"
+	"golang.org/x/image/math/fixed"
+)
+
+// glyphFont contains the fonts of a family at the size of an atlas,
+// used to rasterize glyphs by their index in the fonts, as they are
+// selected by text shaping, instead of by character.
+type glyphFont struct {
+	fonts    []*familyFont              // fonts of the family
+	scale    fixed.Int26_6              // font size in pixels
+	hinting  font.Hinting               // font hinting
+	buf      truetype.GlyphBuf          // glyph outlines being loaded
+	advances map[glyphKey]fixed.Int26_6 // advances of the glyphs already loaded
+	raster   *raster.Rasterizer         // rasterizer of the glyph outlines
+}
+
+// setFonts sets the fonts of the atlas family rasterized at the specified
+// point size, resolution and hinting, which must be those of its face.
+func (a *Atlas) setFonts(fonts []*familyFont, size, dpi float64, hinting font.Hinting) {
+
+	a.gf = &glyphFont{
+		fonts:    fonts,
+		scale:    fixed.Int26_6(0.5 + size*dpi*64/72),
+		hinting:  hinting,
+		advances: make(map[glyphKey]fixed.Int26_6),
+	}
+}
+
+// advance returns the advance of the glyph with the specified index in the specified font
+func (a *Atlas) advance(fi int, gid truetype.Index) fixed.Int26_6 {
+
+	gf := a.gf
+	key := glyphKey{font: fi, gid: gid}
+	adv, ok := gf.advances[key]
+	if !ok {
+		if gf.buf.Load(gf.fonts[fi].ttf, gf.scale, gid, gf.hinting) == nil {
+			adv = gf.buf.AdvanceWidth
+		}
+		gf.advances[key] = adv
+	}
+	return adv
+}
+
+// kern returns the kerning of the kern table of the specified font
+// between the glyphs with the specified indices.
+func (a *Atlas) kern(fi int, g0, g1 truetype.Index) fixed.Int26_6 {
+
+	gf := a.gf
+	kern := gf.fonts[fi].ttf.Kern(gf.scale, g0, g1)
+	if gf.hinting != font.HintingNone {
+		kern = (kern + 32) &^ 63
+	}
+	return kern
+}
+
+// glyphIndex returns the glyph with the specified index in the specified font
+// of the atlas family rasterized at the specified quantized fractional pen position,
+// rasterizing it if necessary, as the font face would rasterize it.
+// Returns the glyph and the atlas page where it is rasterized.
+func (a *Atlas) glyphIndex(fi int, gid truetype.Index, dot fixed.Int26_6) (Glyph, *Atlas) {
+
+	key := glyphKey{r: -1, dot: dot, font: fi, gid: gid}
+	g, ok := a.glyphs[key]
+	if ok {
+		return g, a
+	}
+	// New glyphs are rasterized in the last page
+	if a.next != nil {
+		return a.next.glyphIndex(fi, gid, dot)
+	}
+	gf := a.gf
+	if gf.buf.Load(gf.fonts[fi].ttf, gf.scale, gid, gf.hinting) != nil {
+		a.glyphs[key] = g
+		return g, a
+	}
+	g.Advance = gf.buf.AdvanceWidth
+
+	// Integer pixel bounds of the glyph
+	b := gf.buf.Bounds
+	xmin := int(dot+b.Min.X) >> 6
+	ymin := int(-b.Max.Y) >> 6
+	xmax := int(dot+b.Max.X+0x3f) >> 6
+	ymax := int(-b.Min.Y+0x3f) >> 6
+	if xmin >= xmax || ymin >= ymax {
+		a.glyphs[key] = g
+		return g, a
+	}
+	width, height := xmax-xmin, ymax-ymin
+	x, y, ok := a.place(width, height)
+	if !ok {
+		// A glyph larger than an empty page is blank
+		if a.x == atlasPadding && a.y == atlasPadding {
+			return g, a
+		}
+		return a.page().glyphIndex(fi, gid, dot)
+	}
+
+	// Rasterizes the outline contours
+	if gf.raster == nil {
+		gf.raster = raster.NewRasterizer(width, height)
+	} else {
+		gf.raster.SetBounds(width, height)
+	}
+	gf.raster.Clear()
+	dx := dot - fixed.Int26_6(xmin<<6)
+	dy := -fixed.Int26_6(ymin << 6)
+	e0 := 0
+	for _, e1 := range gf.buf.Ends {
+		drawContour(gf.raster, gf.buf.Points[e0:e1], dx, dy)
+		e0 = e1
+	}
+	mask := image.NewAlpha(image.Rect(0, 0, width, height))
+	gf.raster.Rasterize(raster.NewAlphaSrcPainter(mask))
+
+	g.X = x
+	g.Y = y
+	g.Width = width
+	g.Height = height
+	g.OffsetX = xmin
+	g.OffsetY = ymin
+	draw.DrawMask(a.Image, image.Rect(x, y, x+width, y+height), image.White, image.ZP, mask, image.ZP, draw.Over)
+	a.glyphs[key] = g
+	a.version++
+	return g, a
+}
+
+// drawContour adds a glyph contour to the rasterizer, offset by the specified
+// position, converting its implicit on curve points as the font faces do.
+func drawContour(r *raster.Rasterizer, ps []truetype.Point, dx, dy fixed.Int26_6) {
+
+	if len(ps) == 0 {
+		return
+	}
+	// Points of the contour in pixels with Y going downwards
+	point := func(p truetype.Point) fixed.Point26_6 {
+		return fixed.Point26_6{X: dx + p.X, Y: dy - p.Y}
+	}
+	start := point(ps[0])
+	others := ps[1:]
+	if ps[0].Flags&0x01 == 0 {
+		last := point(ps[len(ps)-1])
+		if ps[len(ps)-1].Flags&0x01 != 0 {
+			start = last
+			others = ps[:len(ps)-1]
+		} else {
+			start = fixed.Point26_6{X: (start.X + last.X) / 2, Y: (start.Y + last.Y) / 2}
+			others = ps
+		}
+	}
+	r.Start(start)
+	q0, on0 := start, true
+	for _, p := range others {
+		q := point(p)
+		on := p.Flags&0x01 != 0
+		if on {
+			if on0 {
+				r.Add1(q)
+			} else {
+				r.Add2(q0, q)
+			}
+		} else if !on0 {
+			// Two consecutive off curve points imply an on curve point between them
+			mid := fixed.Point26_6{X: (q0.X + q.X) / 2, Y: (q0.Y + q.Y) / 2}
+			r.Add2(q0, mid)
+		}
+		q0, on0 = q, on
+	}
+	if on0 {
+		r.Add1(start)
+	} else {
+		r.Add2(q0, start)
+	}
+}
@@ -0,0 +1,172 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package text
+
+import (
+	"image"
+	"math"
+
+	"golang.org/x/image/font"
+)
+
+// Default parameters of the signed distance field atlases returned by Font.SDFAtlas()
+const (
+	SDFPointSize = 48 // Point size at 72 DPI at which the glyphs are rasterized
+	SDFSpread    = 6  // Maximum distance in pixels encoded around the glyph outlines
+)
+
+// sdfInf is the squared distance used for pixels without an edge
+const sdfInf = 1e20
+
+// NewSDFAtlas returns a pointer to a new empty Atlas whose glyphs are signed distance
+// fields generated from the glyphs of the specified font rasterized at the specified
+// point size at 72 DPI. Distances up to spread pixels from the glyph outlines are encoded
+// in the alpha channel of the atlas image, with 0.5 at the outline, greater values inside
+// the glyph and smaller values outside.
+// Normally the shared atlas returned by Font.SDFAtlas() should be used instead.
+func NewSDFAtlas(f *Font, size float64, spread int) *Atlas {
+
+	return newAtlas(f.newFace(size, 72, font.HintingNone), spread)
+}
+
+// SDFAtlas returns the signed distance field atlas of the font with glyphs
+// rasterized at SDFPointSize and distances up to SDFSpread pixels.
+// It is created when first requested and shared by all users of the font.
+// Signed distance field glyphs can be drawn at any scale keeping sharp outlines.
+func (f *Font) SDFAtlas() *Atlas {
+
+	if f.sdfAtlas == nil {
+		f.sdfAtlas = NewSDFAtlas(f, SDFPointSize, SDFSpread)
+	}
+	return f.sdfAtlas
+}
+
+// drawSDF draws in the specified rectangle of the destination image the signed
+// distance field of the glyph coverage mask starting at the specified point.
+// The rectangle includes a margin of spread pixels around the mask.
+func drawSDF(dst *image.RGBA, rect image.Rectangle, mask image.Image, mp image.Point, spread int) {
+
+	width := rect.Dx()
+	height := rect.Dy()
+	outer := make([]float64, width*height)
+	inner := make([]float64, width*height)
+
+	// Pixels fully outside the glyph have no distance to the outside and vice versa.
+	// Partially covered pixels have the outline at a distance from their center
+	// proportional to their coverage.
+	for i := range outer {
+		outer[i] = sdfInf
+	}
+	for y := spread; y < height-spread; y++ {
+		for x := spread; x < width-spread; x++ {
+			_, _, _, ma := mask.At(mp.X+x-spread, mp.Y+y-spread).RGBA()
+			cov := float64(ma) / 0xFFFF
+			idx := y*width + x
+			if cov >= 1 {
+				outer[idx] = 0
+				inner[idx] = sdfInf
+				continue
+			}
+			if cov <= 0 {
+				continue
+			}
+			d := 0.5 - cov
+			if d > 0 {
+				outer[idx] = d * d
+			} else {
+				outer[idx] = 0
+				inner[idx] = d * d
+			}
+		}
+	}
+	edt(outer, width, height)
+	edt(inner, width, height)
+
+	// Encodes the distances with the outline at 0.5
+	for y := 0; y < height; y++ {
+		off := dst.PixOffset(rect.Min.X, rect.Min.Y+y)
+		for x := 0; x < width; x++ {
+			idx := y*width + x
+			dist := math.Sqrt(outer[idx]) - math.Sqrt(inner[idx])
+			v := 0.5 - dist/float64(2*spread)
+			if v < 0 {
+				v = 0
+			} else if v > 1 {
+				v = 1
+			}
+			// White with premultiplied alpha
+			b := uint8(v*255 + 0.5)
+			dst.Pix[off+4*x] = b
+			dst.Pix[off+4*x+1] = b
+			dst.Pix[off+4*x+2] = b
+			dst.Pix[off+4*x+3] = b
+		}
+	}
+}
+
+// edt transforms the specified grid of squared distances to the features
+// into the squared euclidean distances to the nearest feature.
+// It uses the separable algorithm of Felzenszwalb and Huttenlocher.
+func edt(grid []float64, width, height int) {
+
+	n := width
+	if height > n {
+		n = height
+	}
+	f := make([]float64, n)
+	d := make([]float64, n)
+	v := make([]int, n)
+	z := make([]float64, n+1)
+
+	// Columns
+	for x := 0; x < width; x++ {
+		for y := 0; y < height; y++ {
+			f[y] = grid[y*width+x]
+		}
+		edt1d(f, d, v, z, height)
+		for y := 0; y < height; y++ {
+			grid[y*width+x] = d[y]
+		}
+	}
+	// Rows
+	for y := 0; y < height; y++ {
+		copy(f, grid[y*width:(y+1)*width])
+		edt1d(f, d, v, z, width)
+		copy(grid[y*width:(y+1)*width], d[:width])
+	}
+}
+
+// edt1d computes in d the one dimensional squared distance transform of the
+// first n values of f using the lower envelope of parabolas v and z.
+func edt1d(f, d []float64, v []int, z []float64, n int) {
+
+	k := 0
+	v[0] = 0
+	z[0] = -sdfInf
+	z[1] = sdfInf
+	for q := 1; q < n; q++ {
+		var s float64
+		for {
+			r := v[k]
+			s = ((f[q] + float64(q*q)) - (f[r] + float64(r*r))) / float64(2*q-2*r)
+			if s > z[k] || k == 0 {
+				break
+			}
+			k--
+		}
+		k++
+		v[k] = q
+		z[k] = s
+		z[k+1] = sdfInf
+	}
+	k = 0
+	for q := 0; q < n; q++ {
+		for z[k+1] < float64(q) {
+			k++
+		}
+		r := v[k]
+		d[q] = float64((q-r)*(q-r)) + f[r]
+	}
+}
@@ -0,0 +1,624 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package text
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/golang/freetype/truetype"
+	"golang.org/x/image/font"
+	"golang.org/x/image/math/fixed"
+)
+
+// shapeGlyph is a glyph of a run of text being shaped
+type shapeGlyph struct {
+	gid         truetype.Index // glyph index in the font
+	cluster     int            // index in the run of the first character of the cluster of the glyph
+	mask        uint64         // features which apply to the glyph
+	class       int            // GDEF glyph class
+	flags       int            // Indic syllable part of the glyph
+	xAdvance    int            // advance adjustment in font units
+	xOffset     int            // horizontal placement in font units
+	yOffset     int            // vertical placement in font units upwards
+	attach      int            // index of the glyph the mark is attached to or -1
+	attachX     int            // horizontal position of the mark from the attachment glyph in font units
+	attachY     int            // vertical position of the mark from the attachment glyph in font units
+	zeroAdvance bool           // whether the glyph is an attached mark without advance
+}
+
+// shapedGlyph is a glyph of a shaped run of text
+type shapedGlyph struct {
+	gid     truetype.Index // glyph index in the font
+	cluster int            // index in the run of the first character of the cluster of the glyph
+	adv     fixed.Int26_6  // advance of the glyph
+	x, y    fixed.Int26_6  // offset of the glyph image from its pen position, with Y downwards
+}
+
+// shapePlan contains the OpenType features applied to the glyphs of a script
+type shapePlan struct {
+	gsub [][]string // substitution features applied in stages, each stage in lookup order
+	gpos []string   // positioning features
+}
+
+// The shaping plans of the scripts
+var (
+	defaultPlan = shapePlan{
+		gsub: [][]string{{"ccmp", "locl"}, {"rlig", "calt", "liga", "clig"}},
+		gpos: []string{"kern", "mark", "mkmk"},
+	}
+	arabicPlan = shapePlan{
+		gsub: [][]string{{"ccmp", "locl"}, {"isol"}, {"fina"}, {"medi"}, {"init"}, {"rlig"}, {"calt"}, {"liga", "clig", "mset"}},
+		gpos: []string{"kern", "mark", "mkmk"},
+	}
+	indicPlan = shapePlan{
+		gsub: [][]string{{"locl"}, {"ccmp"}, {"nukt"}, {"akhn"}, {"rphf"}, {"rkrf"}, {"blwf"}, {"abvf"},
+			{"half"}, {"pstf"}, {"vatu"}, {"cjct"}, {"pres", "abvs", "blws", "psts", "haln", "calt", "clig"}},
+		gpos: []string{"kern", "dist", "abvm", "blwm", "mark", "mkmk"},
+	}
+)
+
+// shapeFeatures are the features known by shaping, whose positions are their mask bits
+var shapeFeatures = []string{
+	"ccmp", "locl", "rlig", "calt", "liga", "clig", "mset", "isol", "fina", "medi", "init",
+	"nukt", "akhn", "rphf", "rkrf", "blwf", "abvf", "half", "pstf", "vatu", "cjct",
+	"pres", "abvs", "blws", "psts", "haln", "kern", "mark", "mkmk", "dist", "abvm", "blwm",
+}
+
+// maskedFeatures are the features which only apply to the glyphs which have their mask
+var maskedFeatures = featureMask("isol", "fina", "medi", "init", "rphf", "blwf", "half", "pstf")
+
+// featureMask returns the mask of the specified features
+func featureMask(tags ...string) uint64 {
+
+	var mask uint64
+	for _, tag := range tags {
+		for i, f := range shapeFeatures {
+			if f == tag {
+				mask |= 1 << uint(i)
+			}
+		}
+	}
+	return mask
+}
+
+// scriptRanges maps the scripts to their OpenType script tags in order of preference
+var scriptRanges = []struct {
+	table *unicode.RangeTable
+	tags  []string
+}{
+	{unicode.Latin, []string{"latn"}},
+	{unicode.Greek, []string{"grek"}},
+	{unicode.Cyrillic, []string{"cyrl"}},
+	{unicode.Armenian, []string{"armn"}},
+	{unicode.Hebrew, []string{"hebr"}},
+	{unicode.Arabic, []string{"arab"}},
+	{unicode.Syriac, []string{"syrc"}},
+	{unicode.Thaana, []string{"thaa"}},
+	{unicode.Thai, []string{"thai"}},
+	{unicode.Lao, []string{"lao "}},
+	{unicode.Tibetan, []string{"tibt"}},
+	{unicode.Georgian, []string{"geor"}},
+	{unicode.Hangul, []string{"hang"}},
+	{unicode.Hiragana, []string{"kana"}},
+	{unicode.Katakana, []string{"kana"}},
+	{unicode.Han, []string{"hani"}},
+}
+
+// scriptUnknown are the script tags of the characters of other scripts
+var scriptUnknown = []string{"DFLT"}
+
+// scriptTags returns the OpenType script tags, in order of preference, of the script
+// of the specified character or nil for the characters common to all scripts.
+func scriptTags(r rune) []string {
+
+	if unicode.In(r, unicode.Common, unicode.Inherited) {
+		return nil
+	}
+	if b := indicBlockOf(r); b != nil {
+		return b.tags
+	}
+	for _, s := range scriptRanges {
+		if unicode.Is(s.table, r) {
+			return s.tags
+		}
+	}
+	return scriptUnknown
+}
+
+// isCombining returns if the specified character belongs to the cluster of the previous one
+func isCombining(r rune) bool {
+
+	return unicode.In(r, unicode.Mn, unicode.Me, unicode.Mc) || r == 0x200C || r == 0x200D ||
+		r >= 0xFE00 && r <= 0xFE0F
+}
+
+// fontFor returns the index of the font of the atlas family which draws the specified character
+func (a *Atlas) fontFor(r rune) int {
+
+	for i, fd := range a.gf.fonts {
+		if fd.ttf.Index(r) != 0 {
+			return i
+		}
+	}
+	return 0
+}
+
+// shape returns the glyphs of the specified characters of a single script drawn
+// with the specified font of the atlas family, in logical order, substituted and
+// positioned with the OpenType layout tables of the font if it has them.
+// Right to left glyphs are positioned for being drawn in reverse order.
+func (a *Atlas) shape(fi int, runes []rune, rtl bool) []shapedGlyph {
+
+	fd := a.gf.fonts[fi]
+	o := fd.otl
+	tags := scriptUnknown
+	for _, r := range runes {
+		if t := scriptTags(r); t != nil {
+			tags = t
+			break
+		}
+	}
+
+	// Maps the characters to glyphs, joining the combining characters to their cluster
+	buf := make([]shapeGlyph, len(runes))
+	for i, r := range runes {
+		g := &buf[i]
+		g.gid = fd.ttf.Index(r)
+		g.cluster = i
+		g.mask = ^maskedFeatures
+		g.attach = -1
+		g.class = otlBase
+		if unicode.In(r, unicode.Mn, unicode.Me) {
+			g.class = otlMark
+		}
+		if i > 0 && isCombining(r) {
+			g.cluster = buf[i-1].cluster
+		}
+		if o != nil {
+			if c := o.glyphClassOf(g.gid); c != 0 {
+				g.class = c
+			}
+		}
+	}
+
+	// Substitutes the glyphs
+	plan := &defaultPlan
+	indic := false
+	for i := range indicBlocks {
+		indic = indic || tags[0] == indicBlocks[i].tags[0]
+	}
+	switch {
+	case tags[0] == "arab" || tags[0] == "syrc":
+		plan = &arabicPlan
+		arabicJoining(runes, buf)
+	case indic:
+		plan = &indicPlan
+		buf = o.indicSyllables(tags, runes, buf)
+	}
+	if o != nil && o.gsub != nil {
+		for _, stage := range plan.gsub {
+			buf = o.applyStage(o.gsub, tags, stage, buf)
+		}
+		if indic {
+			indicFinal(buf)
+		}
+	}
+
+	// Positions the glyphs, using the kerning of the kern table of the font
+	// if it has one as the font faces do
+	kernTable := o == nil || o.gpos == nil || o.kern
+	if o != nil && o.gpos != nil {
+		features := plan.gpos
+		if kernTable {
+			features = features[1:]
+		}
+		o.applyStage(o.gpos, tags, features, buf)
+	}
+	upem := int64(fd.ttf.FUnitsPerEm())
+	if o != nil {
+		upem = int64(o.upem)
+	}
+	scale := func(v int) fixed.Int26_6 {
+		x := fixed.Int26_6((int64(v)*int64(a.gf.scale)*2 + upem) / (2 * upem))
+		if v < 0 {
+			x = -fixed.Int26_6((int64(-v)*int64(a.gf.scale)*2 + upem) / (2 * upem))
+		}
+		if a.gf.hinting != font.HintingNone {
+			x = (x + 32) &^ 63
+		}
+		return x
+	}
+	out := make([]shapedGlyph, len(buf))
+	prev := -1
+	for i := range buf {
+		g := &buf[i]
+		out[i] = shapedGlyph{gid: g.gid, cluster: g.cluster, x: scale(g.xOffset), y: -scale(g.yOffset)}
+		if g.zeroAdvance {
+			continue
+		}
+		out[i].adv = a.advance(fi, g.gid) + scale(g.xAdvance)
+		if g.class == otlMark {
+			continue
+		}
+		// The pairs of the kern table are in visual order
+		if kernTable && prev >= 0 {
+			if rtl {
+				out[i].adv += a.kern(fi, g.gid, buf[prev].gid)
+			} else {
+				out[prev].adv += a.kern(fi, buf[prev].gid, g.gid)
+			}
+		}
+		prev = i
+	}
+
+	// Positions the attached marks from the pen position of their base
+	origin := make([]fixed.Int26_6, len(out))
+	var pen fixed.Int26_6
+	for i := range out {
+		if rtl {
+			pen -= out[i].adv
+			origin[i] = pen
+		} else {
+			origin[i] = pen
+			pen += out[i].adv
+		}
+		if j := buf[i].attach; j >= 0 {
+			out[i].x += origin[j] + out[j].x + scale(buf[i].attachX) - origin[i]
+			out[i].y += out[j].y - scale(buf[i].attachY)
+		}
+	}
+	return out
+}
+
+// applyStage applies the lookups of the specified features of a GSUB or GPOS table
+// for the specified scripts to the glyphs of the buffer in lookup order and returns
+// the new buffer.
+func (o *otlFont) applyStage(t *otlTable, tags []string, features []string, buf []shapeGlyph) []shapeGlyph {
+
+	masks := make(map[int]uint64)
+	var lookups []int
+	for _, f := range features {
+		key := strings.Join(tags, ",") + "/" + f
+		indices, ok := t.cache[key]
+		if !ok {
+			indices = t.featureLookups(tags, f)
+			if t.cache == nil {
+				t.cache = make(map[string][]int)
+			}
+			t.cache[key] = indices
+		}
+		for _, l := range indices {
+			if masks[l] == 0 {
+				lookups = append(lookups, l)
+			}
+			masks[l] |= featureMask(f)
+		}
+	}
+	sort.Ints(lookups)
+	for _, l := range lookups {
+		if t == o.gsub {
+			buf = o.substitute(buf, []int{l}, masks[l])
+		} else {
+			o.position(buf, []int{l}, masks[l])
+		}
+	}
+	return buf
+}
+
+// Arabic joining types
+const (
+	joinU = iota // non joining
+	joinR        // right joining
+	joinD        // dual joining
+	joinC        // join causing
+	joinT        // transparent
+)
+
+// joiningRight are the ranges of the right joining Arabic letters
+var joiningRight = [][2]rune{
+	{0x0622, 0x0625}, {0x0627, 0x0627}, {0x0629, 0x0629}, {0x062F, 0x0632}, {0x0648, 0x0648},
+	{0x0671, 0x0673}, {0x0675, 0x0677}, {0x0688, 0x0699}, {0x06C0, 0x06C0}, {0x06C3, 0x06CB},
+	{0x06CD, 0x06CD}, {0x06CF, 0x06CF}, {0x06D2, 0x06D3}, {0x06D5, 0x06D5}, {0x06EE, 0x06EF},
+	{0x0710, 0x0710}, {0x0715, 0x0719}, {0x071E, 0x071E}, {0x0728, 0x0728}, {0x072A, 0x072A},
+	{0x072C, 0x072C}, {0x072F, 0x072F}, {0x0759, 0x075B}, {0x076B, 0x076C}, {0x0771, 0x0771},
+	{0x0773, 0x0774}, {0x0778, 0x0779},
+}
+
+// joiningType returns the Arabic joining type of the specified character
+func joiningType(r rune) int {
+
+	switch {
+	case r == 0x0640 || r == 0x200D || r == 0x07FA:
+		return joinC
+	case r == 0x200C, r == 0x0621, r == 0x0674:
+		return joinU
+	case unicode.In(r, unicode.Mn, unicode.Me, unicode.Cf):
+		return joinT
+	}
+	for _, rng := range joiningRight {
+		if r >= rng[0] && r <= rng[1] {
+			return joinR
+		}
+	}
+	switch {
+	case r >= 0x0620 && r <= 0x064A, r >= 0x066E && r <= 0x06D3, r == 0x06D5, r >= 0x06FA && r <= 0x06FC,
+		r == 0x06FF, r >= 0x0712 && r <= 0x072F, r >= 0x074D && r <= 0x077F, r >= 0x08A0 && r <= 0x08C7:
+		if unicode.IsLetter(r) {
+			return joinD
+		}
+	}
+	return joinU
+}
+
+// arabicJoining sets the masks of the isolated, initial, medial and final forms
+// of the specified Arabic characters from how they join with their neighbors.
+func arabicJoining(runes []rune, buf []shapeGlyph) {
+
+	types := make([]int, len(runes))
+	for i, r := range runes {
+		types[i] = joiningType(r)
+	}
+	prev := -1
+	for i, t := range types {
+		if t == joinT {
+			continue
+		}
+		next := i + 1
+		for next < len(types) && types[next] == joinT {
+			next++
+		}
+		joinsPrev := prev >= 0 && (types[prev] == joinD || types[prev] == joinC) && t != joinU
+		joinsNext := next < len(types) && (t == joinD || t == joinC) && types[next] != joinU
+		if t == joinD || t == joinR {
+			form := "isol"
+			switch {
+			case joinsPrev && joinsNext:
+				form = "medi"
+			case joinsPrev:
+				form = "fina"
+			case joinsNext:
+				form = "init"
+			}
+			buf[i].mask |= featureMask(form)
+		}
+		prev = i
+	}
+}
+
+// indicBlock is the Unicode block of an Indic script, whose characters
+// have the same categories at the same offsets in the block.
+type indicBlock struct {
+	base    rune     // first character of the block
+	tags    []string // OpenType script tags
+	preBase []rune   // offsets of the matras drawn before the base consonant
+	reph    bool     // whether the syllables starting with RA and a halant are drawn with a reph
+}
+
+// indicBlocks are the Indic scripts shaped with syllable reordering
+var indicBlocks = []indicBlock{
+	{0x0900, []string{"dev2", "deva"}, []rune{0x3F}, true},
+	{0x0980, []string{"bng2", "beng"}, []rune{0x3F, 0x47, 0x48}, true},
+	{0x0A00, []string{"gur2", "guru"}, []rune{0x3F}, false},
+	{0x0A80, []string{"gjr2", "gujr"}, []rune{0x3F}, true},
+	{0x0B00, []string{"ory2", "orya"}, []rune{0x47}, true},
+	{0x0B80, []string{"tml2", "taml"}, []rune{0x46, 0x47, 0x48}, false},
+	{0x0C00, []string{"tel2", "telu"}, nil, true},
+	{0x0C80, []string{"knd2", "knda"}, nil, true},
+	{0x0D00, []string{"mlm2", "mlym"}, []rune{0x46, 0x47, 0x48}, true},
+}
+
+// indicBlockOf returns the Indic block of the specified character or nil
+func indicBlockOf(r rune) *indicBlock {
+
+	if r < 0x0900 || r >= 0x0D80 {
+		return nil
+	}
+	return &indicBlocks[(r-0x0900)/0x80]
+}
+
+// Categories of the characters of Indic syllables
+const (
+	indicX    = iota // other
+	indicC           // consonant
+	indicV           // independent vowel
+	indicN           // nukta
+	indicH           // halant
+	indicM           // dependent vowel (matra)
+	indicSM          // vowel modifier and sign
+	indicZWJ         // zero width joiner
+	indicZWNJ        // zero width non joiner
+)
+
+// Parts of Indic syllables
+const (
+	indicFlagBase = 1 << iota // base consonant
+	indicFlagReph             // reph
+)
+
+// indicCategory returns the category of the specified character in Indic syllables
+func indicCategory(r rune) int {
+
+	switch r {
+	case 0x200D:
+		return indicZWJ
+	case 0x200C:
+		return indicZWNJ
+	}
+	if indicBlockOf(r) == nil {
+		return indicX
+	}
+	o := (r - 0x0900) % 0x80
+	switch {
+	case o >= 0x01 && o <= 0x03, o >= 0x51 && o <= 0x54, o == 0x70 || o == 0x71:
+		return indicSM
+	case o >= 0x04 && o <= 0x14, o == 0x60 || o == 0x61, o >= 0x72 && o <= 0x77:
+		return indicV
+	case o >= 0x15 && o <= 0x39, o >= 0x58 && o <= 0x5F, o >= 0x78 && o <= 0x7F && r < 0x0980:
+		return indicC
+	case o == 0x3C:
+		return indicN
+	case o == 0x4D:
+		return indicH
+	case o >= 0x3E && o <= 0x4C, o == 0x4E || o == 0x4F, o >= 0x55 && o <= 0x57, o == 0x62 || o == 0x63:
+		return indicM
+	}
+	return indicX
+}
+
+// indicSyllables finds the syllables of the specified Indic characters, whose glyphs are
+// in the buffer in the same order, reorders their pre-base matras and sets the masks of
+// their reph, half forms and below and post base forms. The glyphs of a syllable are
+// in a single cluster. Returns the new buffer.
+func (o *otlFont) indicSyllables(tags []string, runes []rune, buf []shapeGlyph) []shapeGlyph {
+
+	cats := make([]int, len(runes))
+	for i, r := range runes {
+		cats[i] = indicCategory(r)
+	}
+	for s := 0; s < len(runes); {
+		b := indicBlockOf(runes[s])
+		e := s + 1
+		// Consonants joined by halants, optionally with nuktas and joiners
+		if cats[s] == indicC {
+			for {
+				if e < len(runes) && cats[e] == indicN {
+					e++
+				}
+				if e < len(runes) && cats[e] == indicH {
+					k := e + 1
+					if k < len(runes) && (cats[k] == indicZWJ || cats[k] == indicZWNJ) {
+						k++
+					}
+					if k < len(runes) && cats[k] == indicC {
+						e = k + 1
+						continue
+					}
+					e = k
+				}
+				break
+			}
+		} else if cats[s] != indicV {
+			s = e
+			continue
+		}
+		// Vowel signs and modifiers
+		for e < len(runes) && (cats[e] == indicN || cats[e] == indicM || cats[e] == indicSM ||
+			cats[e] == indicH || cats[e] == indicZWJ) {
+			e++
+		}
+		for k := s; k < e; k++ {
+			buf[k].cluster = buf[s].cluster
+		}
+		if cats[s] != indicC || b == nil {
+			s = e
+			continue
+		}
+
+		// Reph from a leading RA and halant followed by a consonant
+		start := s
+		if b.reph && o != nil && e-s >= 3 && runes[s] == b.base+0x30 && cats[s+1] == indicH && cats[s+2] == indicC {
+			start = s + 2
+			buf[s].mask |= featureMask("rphf")
+			buf[s+1].mask |= featureMask("rphf")
+			buf[s].flags |= indicFlagReph
+			buf[s+1].flags |= indicFlagReph
+		}
+		// The base consonant is the last one which has no below or post base form
+		last := start
+		for k := start; k < e && cats[k] != indicM && cats[k] != indicSM; k++ {
+			if cats[k] == indicC {
+				last = k
+			}
+		}
+		base := last
+		for k := last; k > start; k-- {
+			if cats[k] != indicC {
+				continue
+			}
+			base = k
+			if cats[k-1] != indicH || !o.hasBelowForm(tags, buf[k-1].gid, buf[k].gid) {
+				break
+			}
+		}
+		buf[base].flags |= indicFlagBase
+		for k := start; k < base; k++ {
+			buf[k].mask |= featureMask("half")
+		}
+		for k := base + 1; k <= last+1 && k < e; k++ {
+			buf[k].mask |= featureMask("blwf", "pstf")
+		}
+		// Pre-base matras are moved to the start of the syllable, after the reph
+		for k := base + 1; k < e; k++ {
+			if cats[k] != indicM || !isPreBase(b, runes[k]) {
+				continue
+			}
+			g, c := buf[k], cats[k]
+			copy(buf[start+1:k+1], buf[start:k])
+			copy(cats[start+1:k+1], cats[start:k])
+			buf[start], cats[start] = g, c
+			start++
+		}
+		s = e
+	}
+	return buf
+}
+
+// isPreBase returns if the specified matra of an Indic block is drawn before the base consonant
+func isPreBase(b *indicBlock, r rune) bool {
+
+	for _, o := range b.preBase {
+		if r == b.base+o {
+			return true
+		}
+	}
+	return false
+}
+
+// hasBelowForm returns if the font has a below or post base form of the
+// consonant with the specified glyph following the specified halant.
+func (o *otlFont) hasBelowForm(tags []string, halant, consonant truetype.Index) bool {
+
+	if o == nil || o.gsub == nil {
+		return false
+	}
+	for _, pair := range [][2]truetype.Index{{halant, consonant}, {consonant, halant}} {
+		buf := []shapeGlyph{{gid: pair[0], attach: -1, mask: ^uint64(0)}, {gid: pair[1], attach: -1, mask: ^uint64(0)}}
+		buf = o.applyStage(o.gsub, tags, []string{"blwf", "pstf"}, buf)
+		if len(buf) != 2 || buf[0].gid != pair[0] || buf[1].gid != pair[1] {
+			return true
+		}
+	}
+	return false
+}
+
+// indicFinal moves the reph glyphs of the substituted Indic syllables after their base
+func indicFinal(buf []shapeGlyph) {
+
+	for s := 0; s < len(buf); {
+		e := s + 1
+		for e < len(buf) && buf[e].cluster == buf[s].cluster {
+			e++
+		}
+		// The reph is formed if a single glyph remains of the RA and halant
+		reph, base, count := -1, -1, 0
+		for k := s; k < e; k++ {
+			if buf[k].flags&indicFlagReph != 0 {
+				reph = k
+				count++
+			}
+			if buf[k].flags&indicFlagBase != 0 {
+				base = k
+			}
+		}
+		if count == 1 && base > reph && buf[reph].flags&indicFlagBase == 0 {
+			g := buf[reph]
+			copy(buf[reph:base], buf[reph+1:base+1])
+			buf[base] = g
+		}
+		s = e
+	}
+}
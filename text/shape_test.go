@@ -0,0 +1,75 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package text
+
+import (
+	"testing"
+)
+
+// Tests the joining forms of Arabic characters, with "-" for the characters without form
+func TestArabicJoining(t *testing.T) {
+
+	tests := []struct {
+		text     string
+		expected []string
+	}{
+		{"ب", []string{"isol"}},
+		{"بب", []string{"init", "fina"}},
+		{"ببب", []string{"init", "medi", "fina"}},
+		{"بسم ال", []string{"init", "medi", "fina", "-", "isol", "isol"}},
+		{"ابب", []string{"isol", "init", "fina"}},
+		{"باب", []string{"init", "fina", "isol"}},
+		{"ءب", []string{"-", "isol"}},
+		{"بِب", []string{"init", "-", "fina"}},
+		{"ـبـ", []string{"-", "medi", "-"}},
+		{"ب‍", []string{"init", "-"}},
+		{"ب‌ب", []string{"isol", "-", "isol"}},
+		{"بaب", []string{"isol", "-", "isol"}},
+	}
+	forms := []string{"isol", "init", "medi", "fina"}
+	for _, test := range tests {
+		runes := []rune(test.text)
+		buf := make([]shapeGlyph, len(runes))
+		arabicJoining(runes, buf)
+		for i := range buf {
+			form := "-"
+			for _, f := range forms {
+				if buf[i].mask&featureMask(f) != 0 {
+					form = f
+				}
+			}
+			if form != test.expected[i] {
+				t.Errorf("%q: form of character %d is %s, expected %s", test.text, i, form, test.expected[i])
+			}
+		}
+	}
+}
+
+// Tests that the lookups of malformed GSUB and GPOS tables with counts and
+// offsets out of range are not found instead of panicking
+func TestOTLMalformed(t *testing.T) {
+
+	// Header with the offsets of the script, feature and lookup lists
+	header := []byte{0, 1, 0, 0, 0, 10, 0, 10, 0, 10}
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"script count", append(header, 0xFF, 0xFF)},
+		{"script record", append(header, 0, 1, 'D', 'F', 'L')},
+		{"script offset", append(header, 0, 1, 'D', 'F', 'L', 'T', 0xFF, 0xFF)},
+		{"feature index", append(header, 0, 1, 'D', 'F', 'L', 'T', 0, 8, 0, 0, 0, 0, 0, 1, 0xFF, 0xFF)},
+	}
+	for _, test := range tests {
+		table := newOTLTable(test.data)
+		if table == nil {
+			t.Errorf("%s: invalid table", test.name)
+			continue
+		}
+		if lookups := table.featureLookups([]string{"latn"}, "liga"); len(lookups) != 0 {
+			t.Errorf("%s: lookups %v", test.name, lookups)
+		}
+	}
+}
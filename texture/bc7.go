@@ -0,0 +1,329 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package texture
+
+// CPU decoder of the BPTC unsigned normalized format (BC7).
+
+// bc7Mode describes the block layout of one BC7 mode
+type bc7Mode struct {
+	subsets        int  // number of subsets
+	partitionBits  uint // number of partition selection bits
+	rotationBits   uint // number of component rotation bits
+	indexSelBits   uint // number of index selection bits
+	colorBits      uint // number of bits of each color endpoint component
+	alphaBits      uint // number of bits of each alpha endpoint component
+	endpointPBits  bool // one p-bit per endpoint
+	sharedPBits    bool // one p-bit per subset
+	indexBits      uint // number of bits of each primary index
+	secondaryIndex uint // number of bits of each secondary index
+}
+
+var bc7Modes = [8]bc7Mode{
+	{3, 4, 0, 0, 4, 0, true, false, 3, 0},
+	{2, 6, 0, 0, 6, 0, false, true, 3, 0},
+	{3, 6, 0, 0, 5, 0, false, false, 2, 0},
+	{2, 6, 0, 0, 7, 0, true, false, 2, 0},
+	{1, 0, 2, 1, 5, 6, false, false, 2, 3},
+	{1, 0, 2, 0, 7, 8, false, false, 2, 2},
+	{1, 0, 0, 0, 7, 7, true, false, 4, 0},
+	{2, 6, 0, 0, 5, 5, true, false, 2, 0},
+}
+
+// Partition masks for two subsets: bit i is the subset of pixel i
+var bc7Partitions2 = [64]uint16{
+	0xCCCC, 0x8888, 0xEEEE, 0xECC8, 0xC880, 0xFEEC, 0xFEC8, 0xEC80,
+	0xC800, 0xFFEC, 0xFE80, 0xE800, 0xFFE8, 0xFF00, 0xFFF0, 0xF000,
+	0xF710, 0x008E, 0x7100, 0x08CE, 0x008C, 0x7310, 0x3100, 0x8CCE,
+	0x088C, 0x3110, 0x6666, 0x366C, 0x17E8, 0x0FF0, 0x718E, 0x399C,
+	0xAAAA, 0xF0F0, 0x5A5A, 0x33CC, 0x3C3C, 0x55AA, 0x9696, 0xA55A,
+	0x73CE, 0x13C8, 0x324C, 0x3BDC, 0x6996, 0xC33C, 0x9966, 0x0660,
+	0x0272, 0x04E4, 0x4E40, 0x2720, 0xC936, 0x936C, 0x39C6, 0x639C,
+	0x9336, 0x9CC6, 0x817E, 0xE718, 0xCCF0, 0x0FCC, 0x7744, 0xEE22,
+}
+
+// Partition tables for three subsets
+var bc7Partitions3 = [64][16]byte{
+	{0, 0, 1, 1, 0, 0, 1, 1, 0, 2, 2, 1, 2, 2, 2, 2},
+	{0, 0, 0, 1, 0, 0, 1, 1, 2, 2, 1, 1, 2, 2, 2, 1},
+	{0, 0, 0, 0, 2, 0, 0, 1, 2, 2, 1, 1, 2, 2, 1, 1},
+	{0, 2, 2, 2, 0, 0, 2, 2, 0, 0, 1, 1, 0, 1, 1, 1},
+	{0, 0, 0, 0, 0, 0, 0, 0, 1, 1, 2, 2, 1, 1, 2, 2},
+	{0, 0, 1, 1, 0, 0, 1, 1, 0, 0, 2, 2, 0, 0, 2, 2},
+	{0, 0, 2, 2, 0, 0, 2, 2, 1, 1, 1, 1, 1, 1, 1, 1},
+	{0, 0, 1, 1, 0, 0, 1, 1, 2, 2, 1, 1, 2, 2, 1, 1},
+	{0, 0, 0, 0, 0, 0, 0, 0, 1, 1, 1, 1, 2, 2, 2, 2},
+	{0, 0, 0, 0, 1, 1, 1, 1, 1, 1, 1, 1, 2, 2, 2, 2},
+	{0, 0, 0, 0, 1, 1, 1, 1, 2, 2, 2, 2, 2, 2, 2, 2},
+	{0, 0, 1, 2, 0, 0, 1, 2, 0, 0, 1, 2, 0, 0, 1, 2},
+	{0, 1, 1, 2, 0, 1, 1, 2, 0, 1, 1, 2, 0, 1, 1, 2},
+	{0, 1, 2, 2, 0, 1, 2, 2, 0, 1, 2, 2, 0, 1, 2, 2},
+	{0, 0, 1, 1, 0, 1, 1, 2, 1, 1, 2, 2, 1, 2, 2, 2},
+	{0, 0, 1, 1, 2, 0, 0, 1, 2, 2, 0, 0, 2, 2, 2, 0},
+	{0, 0, 0, 1, 0, 0, 1, 1, 0, 1, 1, 2, 1, 1, 2, 2},
+	{0, 1, 1, 1, 0, 0, 1, 1, 2, 0, 0, 1, 2, 2, 0, 0},
+	{0, 0, 0, 0, 1, 1, 2, 2, 1, 1, 2, 2, 1, 1, 2, 2},
+	{0, 0, 2, 2, 0, 0, 2, 2, 0, 0, 2, 2, 1, 1, 1, 1},
+	{0, 1, 1, 1, 0, 1, 1, 1, 0, 2, 2, 2, 0, 2, 2, 2},
+	{0, 0, 0, 1, 0, 0, 0, 1, 2, 2, 2, 1, 2, 2, 2, 1},
+	{0, 0, 0, 0, 0, 0, 1, 1, 0, 1, 2, 2, 0, 1, 2, 2},
+	{0, 0, 0, 0, 1, 1, 0, 0, 2, 2, 1, 0, 2, 2, 1, 0},
+	{0, 1, 2, 2, 0, 1, 2, 2, 0, 0, 1, 1, 0, 0, 0, 0},
+	{0, 0, 1, 2, 0, 0, 1, 2, 1, 1, 2, 2, 2, 2, 2, 2},
+	{0, 1, 1, 0, 1, 2, 2, 1, 1, 2, 2, 1, 0, 1, 1, 0},
+	{0, 0, 0, 0, 0, 1, 1, 0, 1, 2, 2, 1, 1, 2, 2, 1},
+	{0, 0, 2, 2, 1, 1, 0, 2, 1, 1, 0, 2, 0, 0, 2, 2},
+	{0, 1, 1, 0, 0, 1, 1, 0, 2, 0, 0, 2, 2, 2, 2, 2},
+	{0, 0, 1, 1, 0, 1, 2, 2, 0, 1, 2, 2, 0, 0, 1, 1},
+	{0, 0, 0, 0, 2, 0, 0, 0, 2, 2, 1, 1, 2, 2, 2, 1},
+	{0, 0, 0, 0, 0, 0, 0, 2, 1, 1, 2, 2, 1, 2, 2, 2},
+	{0, 2, 2, 2, 0, 0, 2, 2, 0, 0, 1, 2, 0, 0, 1, 1},
+	{0, 0, 1, 1, 0, 0, 1, 2, 0, 0, 2, 2, 0, 2, 2, 2},
+	{0, 1, 2, 0, 0, 1, 2, 0, 0, 1, 2, 0, 0, 1, 2, 0},
+	{0, 0, 0, 0, 1, 1, 1, 1, 2, 2, 2, 2, 0, 0, 0, 0},
+	{0, 1, 2, 0, 1, 2, 0, 1, 2, 0, 1, 2, 0, 1, 2, 0},
+	{0, 1, 2, 0, 2, 0, 1, 2, 1, 2, 0, 1, 0, 1, 2, 0},
+	{0, 0, 1, 1, 2, 2, 0, 0, 1, 1, 2, 2, 0, 0, 1, 1},
+	{0, 0, 1, 1, 1, 1, 2, 2, 2, 2, 0, 0, 0, 0, 1, 1},
+	{0, 1, 0, 1, 0, 1, 0, 1, 2, 2, 2, 2, 2, 2, 2, 2},
+	{0, 0, 0, 0, 0, 0, 0, 0, 2, 1, 2, 1, 2, 1, 2, 1},
+	{0, 0, 2, 2, 1, 1, 2, 2, 0, 0, 2, 2, 1, 1, 2, 2},
+	{0, 0, 2, 2, 0, 0, 1, 1, 0, 0, 2, 2, 0, 0, 1, 1},
+	{0, 2, 2, 0, 1, 2, 2, 1, 0, 2, 2, 0, 1, 2, 2, 1},
+	{0, 1, 0, 1, 2, 2, 2, 2, 2, 2, 2, 2, 0, 1, 0, 1},
+	{0, 0, 0, 0, 2, 1, 2, 1, 2, 1, 2, 1, 2, 1, 2, 1},
+	{0, 1, 0, 1, 0, 1, 0, 1, 0, 1, 0, 1, 2, 2, 2, 2},
+	{0, 2, 2, 2, 0, 1, 1, 1, 0, 2, 2, 2, 0, 1, 1, 1},
+	{0, 0, 0, 2, 1, 1, 1, 2, 0, 0, 0, 2, 1, 1, 1, 2},
+	{0, 0, 0, 0, 2, 1, 1, 2, 2, 1, 1, 2, 2, 1, 1, 2},
+	{0, 2, 2, 2, 0, 1, 1, 1, 0, 1, 1, 1, 0, 2, 2, 2},
+	{0, 0, 0, 2, 1, 1, 1, 2, 1, 1, 1, 2, 0, 0, 0, 2},
+	{0, 1, 1, 0, 0, 1, 1, 0, 0, 1, 1, 0, 2, 2, 2, 2},
+	{0, 0, 0, 0, 0, 0, 0, 0, 2, 1, 1, 2, 2, 1, 1, 2},
+	{0, 1, 1, 0, 0, 1, 1, 0, 2, 2, 2, 2, 2, 2, 2, 2},
+	{0, 0, 2, 2, 0, 0, 1, 1, 0, 0, 1, 1, 0, 0, 2, 2},
+	{0, 0, 2, 2, 1, 1, 2, 2, 1, 1, 2, 2, 0, 0, 2, 2},
+	{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 2, 1, 1, 2},
+	{0, 0, 0, 2, 0, 0, 0, 1, 0, 0, 0, 2, 0, 0, 0, 1},
+	{0, 2, 2, 2, 1, 2, 2, 2, 0, 2, 2, 2, 1, 2, 2, 2},
+	{0, 1, 0, 1, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2},
+	{0, 1, 1, 1, 2, 0, 1, 1, 2, 2, 0, 1, 2, 2, 2, 0},
+}
+
+// Anchor index of the second subset of two subset partitions
+var bc7Anchors2 = [64]byte{
+	15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15,
+	15, 2, 8, 2, 2, 8, 8, 15, 2, 8, 2, 2, 8, 8, 2, 2,
+	15, 15, 6, 8, 2, 8, 15, 15, 2, 8, 2, 2, 2, 15, 15, 6,
+	6, 2, 6, 8, 15, 15, 2, 2, 15, 15, 15, 15, 15, 2, 2, 15,
+}
+
+// Anchor indices of the second and third subsets of three subset partitions
+var bc7Anchors3 = [2][64]byte{
+	{
+		3, 3, 15, 15, 8, 3, 15, 15, 8, 8, 6, 6, 6, 5, 3, 3,
+		3, 3, 8, 15, 3, 3, 6, 10, 5, 8, 8, 6, 8, 5, 15, 15,
+		8, 15, 3, 5, 6, 10, 8, 15, 15, 3, 15, 5, 15, 15, 15, 15,
+		3, 15, 5, 5, 5, 8, 5, 10, 5, 10, 8, 13, 15, 12, 3, 3,
+	},
+	{
+		15, 8, 8, 3, 15, 15, 3, 8, 15, 15, 15, 15, 15, 15, 15, 8,
+		15, 8, 15, 3, 15, 8, 15, 8, 3, 15, 6, 10, 15, 15, 10, 8,
+		15, 3, 15, 10, 10, 8, 9, 10, 6, 15, 8, 15, 3, 6, 6, 8,
+		15, 3, 15, 15, 15, 15, 15, 15, 15, 15, 15, 15, 3, 15, 15, 8,
+	},
+}
+
+// Interpolation weights by index bits
+var bc7Weights = [5][]int{
+	2: {0, 21, 43, 64},
+	3: {0, 9, 18, 27, 37, 46, 55, 64},
+	4: {0, 4, 9, 13, 17, 21, 26, 30, 34, 38, 43, 47, 51, 55, 60, 64},
+}
+
+// bitReader reads bits of a block starting with the least significant bit
+type bitReader struct {
+	block []byte
+	pos   uint
+}
+
+// read returns the next n bits
+func (br *bitReader) read(n uint) int {
+
+	v := 0
+	for i := uint(0); i < n; i++ {
+		byteIdx := (br.pos + i) / 8
+		bit := (br.block[byteIdx] >> ((br.pos + i) % 8)) & 1
+		v |= int(bit) << i
+	}
+	br.pos += n
+	return v
+}
+
+// bc7Subset returns the subset of the specified pixel for the specified partition
+func bc7Subset(subsets, partition, pixel int) int {
+
+	switch subsets {
+	case 2:
+		return int(bc7Partitions2[partition]>>uint(pixel)) & 1
+	case 3:
+		return int(bc7Partitions3[partition][pixel])
+	}
+	return 0
+}
+
+// bc7IsAnchor returns if the specified pixel is the anchor of its subset
+// whose index is stored with one bit less.
+func bc7IsAnchor(subsets, partition, pixel int) bool {
+
+	if pixel == 0 {
+		return true
+	}
+	switch subsets {
+	case 2:
+		return pixel == int(bc7Anchors2[partition])
+	case 3:
+		return pixel == int(bc7Anchors3[0][partition]) || pixel == int(bc7Anchors3[1][partition])
+	}
+	return false
+}
+
+// decodeBC7 decodes a BC7 block into RGBA8
+func decodeBC7(block []byte, out []byte) {
+
+	// The mode is the number of zero bits before the first set bit
+	modeIdx := 0
+	for modeIdx < 8 && (block[0]>>uint(modeIdx))&1 == 0 {
+		modeIdx++
+	}
+	if modeIdx == 8 {
+		// Reserved mode decodes to transparent black
+		for i := range out[:64] {
+			out[i] = 0
+		}
+		return
+	}
+	mode := &bc7Modes[modeIdx]
+	br := &bitReader{block: block, pos: uint(modeIdx + 1)}
+	partition := br.read(mode.partitionBits)
+	rotation := br.read(mode.rotationBits)
+	indexSel := br.read(mode.indexSelBits)
+
+	// Reads endpoints: for each component, all endpoints of all subsets
+	var endpoints [6][4]int
+	numEndpoints := mode.subsets * 2
+	for c := 0; c < 3; c++ {
+		for e := 0; e < numEndpoints; e++ {
+			endpoints[e][c] = br.read(mode.colorBits)
+		}
+	}
+	if mode.alphaBits > 0 {
+		for e := 0; e < numEndpoints; e++ {
+			endpoints[e][3] = br.read(mode.alphaBits)
+		}
+	}
+
+	// Applies p-bits and expands endpoints to 8 bits
+	colorBits := mode.colorBits
+	alphaBits := mode.alphaBits
+	if mode.endpointPBits || mode.sharedPBits {
+		var pbits [6]int
+		if mode.endpointPBits {
+			for e := 0; e < numEndpoints; e++ {
+				pbits[e] = br.read(1)
+			}
+		} else {
+			for s := 0; s < mode.subsets; s++ {
+				p := br.read(1)
+				pbits[2*s] = p
+				pbits[2*s+1] = p
+			}
+		}
+		for e := 0; e < numEndpoints; e++ {
+			for c := 0; c < 4; c++ {
+				endpoints[e][c] = endpoints[e][c]<<1 | pbits[e]
+			}
+		}
+		colorBits++
+		if alphaBits > 0 {
+			alphaBits++
+		}
+	}
+	for e := 0; e < numEndpoints; e++ {
+		for c := 0; c < 3; c++ {
+			endpoints[e][c] = expandBits(endpoints[e][c], colorBits)
+		}
+		if alphaBits > 0 {
+			endpoints[e][3] = expandBits(endpoints[e][3], alphaBits)
+		} else {
+			endpoints[e][3] = 255
+		}
+	}
+
+	// Reads primary and secondary indices
+	var indices, indices2 [16]int
+	for i := 0; i < 16; i++ {
+		n := mode.indexBits
+		if bc7IsAnchor(mode.subsets, partition, i) {
+			n--
+		}
+		indices[i] = br.read(n)
+	}
+	if mode.secondaryIndex > 0 {
+		for i := 0; i < 16; i++ {
+			n := mode.secondaryIndex
+			if i == 0 {
+				n--
+			}
+			indices2[i] = br.read(n)
+		}
+	}
+
+	// Interpolates pixels
+	for i := 0; i < 16; i++ {
+		s := bc7Subset(mode.subsets, partition, i)
+		e0 := &endpoints[2*s]
+		e1 := &endpoints[2*s+1]
+		var px [4]int
+		if mode.secondaryIndex == 0 {
+			w := bc7Weights[mode.indexBits][indices[i]]
+			for c := 0; c < 4; c++ {
+				px[c] = bc7Interpolate(e0[c], e1[c], w)
+			}
+		} else {
+			colorIdx, colorW := indices[i], mode.indexBits
+			alphaIdx, alphaW := indices2[i], mode.secondaryIndex
+			if indexSel == 1 {
+				colorIdx, alphaIdx = alphaIdx, colorIdx
+				colorW, alphaW = alphaW, colorW
+			}
+			w := bc7Weights[colorW][colorIdx]
+			for c := 0; c < 3; c++ {
+				px[c] = bc7Interpolate(e0[c], e1[c], w)
+			}
+			px[3] = bc7Interpolate(e0[3], e1[3], bc7Weights[alphaW][alphaIdx])
+		}
+		// Component rotation swaps alpha with one of the color components
+		if rotation > 0 {
+			px[3], px[rotation-1] = px[rotation-1], px[3]
+		}
+		for c := 0; c < 4; c++ {
+			out[i*4+c] = byte(px[c])
+		}
+	}
+}
+
+// expandBits expands a value with the specified number of bits to 8 bits
+// replicating its most significant bits.
+func expandBits(v int, bits uint) int {
+
+	v <<= 8 - bits
+	return v | v>>bits
+}
+
+// bc7Interpolate interpolates two endpoint components with the specified weight
+func bc7Interpolate(e0, e1, w int) int {
+
+	return ((64-w)*e0 + w*e1 + 32) >> 6
+}
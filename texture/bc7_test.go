@@ -0,0 +1,108 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package texture
+
+import (
+	"testing"
+)
+
+// bitWriter builds a block writing bits starting with the least significant bit
+type bitWriter struct {
+	block [16]byte
+	pos   uint
+}
+
+// write writes the n least significant bits of v
+func (bw *bitWriter) write(v int, n uint) {
+
+	for i := uint(0); i < n; i++ {
+		if v&(1<<i) != 0 {
+			bw.block[bw.pos/8] |= 1 << (bw.pos % 8)
+		}
+		bw.pos++
+	}
+}
+
+// Tests decoding of hand encoded BC7 blocks
+func TestDecodeBC7(t *testing.T) {
+
+	tests := []struct {
+		name   string
+		encode func(bw *bitWriter)
+		pixel  func(i int) [4]byte
+	}{
+		{"mode 6 solid", func(bw *bitWriter) {
+			bw.write(1<<6, 7)
+			for c := 0; c < 4; c++ {
+				bw.write(0x40, 7)
+				bw.write(0x40, 7)
+			}
+			bw.write(1, 1)
+			bw.write(1, 1)
+			bw.write(0, 63)
+		}, func(i int) [4]byte { return [4]byte{129, 129, 129, 129} }},
+		{"mode 6 gradient", func(bw *bitWriter) {
+			bw.write(1<<6, 7)
+			for c := 0; c < 4; c++ {
+				bw.write(0, 7)
+				bw.write(127, 7)
+			}
+			bw.write(0, 1)
+			bw.write(1, 1)
+			bw.write(0, 3)
+			for i := 1; i < 16; i++ {
+				bw.write(i, 4)
+			}
+		}, func(i int) [4]byte {
+			v := byte((255*bc7Weights[4][i] + 32) >> 6)
+			return [4]byte{v, v, v, v}
+		}},
+		{"mode 1 two subsets", func(bw *bitWriter) {
+			bw.write(1<<1, 2)
+			bw.write(0, 6)
+			for _, v := range []int{63, 63, 0, 0, 0, 0, 0, 0, 0, 0, 63, 63} {
+				bw.write(v, 6)
+			}
+			bw.write(1, 1)
+			bw.write(0, 1)
+			bw.write(0, 46)
+		}, func(i int) [4]byte {
+			// Partition 0 puts the two right columns in the second subset
+			if i%4 >= 2 {
+				return [4]byte{0, 0, 253, 255}
+			}
+			return [4]byte{255, 2, 2, 255}
+		}},
+		{"mode 5 rotation", func(bw *bitWriter) {
+			bw.write(1<<5, 6)
+			bw.write(1, 2)
+			for _, v := range []int{0, 0, 127, 127, 0, 0} {
+				bw.write(v, 7)
+			}
+			bw.write(10, 8)
+			bw.write(10, 8)
+			bw.write(0, 62)
+		}, func(i int) [4]byte { return [4]byte{10, 255, 0, 0} }},
+		{"reserved mode", func(bw *bitWriter) {
+			bw.write(0, 8)
+		}, func(i int) [4]byte { return [4]byte{} }},
+	}
+	for _, test := range tests {
+		var bw bitWriter
+		test.encode(&bw)
+		if test.name != "reserved mode" && bw.pos != 128 {
+			t.Fatalf("%s: encoded %d bits", test.name, bw.pos)
+		}
+		out := make([]byte, 64)
+		decodeBC7(bw.block[:], out)
+		for i := 0; i < 16; i++ {
+			var got [4]byte
+			copy(got[:], out[i*4:])
+			if expected := test.pixel(i); got != expected {
+				t.Errorf("%s: pixel %d is %v, expected %v", test.name, i, got, expected)
+			}
+		}
+	}
+}
@@ -0,0 +1,173 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package texture
+
+import (
+	"encoding/binary"
+)
+
+// CPU decoders of the S3TC/RGTC block compressed formats (BC1 to BC5).
+// Each decoder converts one 4x4 block into 16 pixels in row order.
+
+// decodeBC1 decodes a BC1 (DXT1) block with 1 bit alpha into RGBA8
+func decodeBC1(block []byte, out []byte) {
+
+	decodeColorBlock(block, out, true, false)
+}
+
+// decodeBC1RGB decodes a BC1 (DXT1) block without alpha into RGBA8
+func decodeBC1RGB(block []byte, out []byte) {
+
+	decodeColorBlock(block, out, false, false)
+}
+
+// decodeBC2 decodes a BC2 (DXT3) block with explicit 4 bit alpha into RGBA8
+func decodeBC2(block []byte, out []byte) {
+
+	decodeColorBlock(block[8:], out, false, true)
+	for i := 0; i < 16; i++ {
+		a := (block[i/2] >> (4 * uint(i%2))) & 0x0F
+		out[i*4+3] = a<<4 | a
+	}
+}
+
+// decodeBC3 decodes a BC3 (DXT5) block with interpolated alpha into RGBA8
+func decodeBC3(block []byte, out []byte) {
+
+	decodeColorBlock(block[8:], out, false, true)
+	var alpha [16]byte
+	decodeChannelBlock(block[:8], alpha[:], 1)
+	for i := 0; i < 16; i++ {
+		out[i*4+3] = alpha[i]
+	}
+}
+
+// decodeBC4 decodes an unsigned BC4 (RGTC1) block into R8
+func decodeBC4(block []byte, out []byte) {
+
+	decodeChannelBlock(block, out, 1)
+}
+
+// decodeBC4Signed decodes a signed BC4 (RGTC1) block into R8 signed normalized
+func decodeBC4Signed(block []byte, out []byte) {
+
+	decodeSignedChannelBlock(block, out, 1)
+}
+
+// decodeBC5 decodes an unsigned BC5 (RGTC2) block into RG8
+func decodeBC5(block []byte, out []byte) {
+
+	decodeChannelBlock(block[:8], out, 2)
+	decodeChannelBlock(block[8:], out[1:], 2)
+}
+
+// decodeBC5Signed decodes a signed BC5 (RGTC2) block into RG8 signed normalized
+func decodeBC5Signed(block []byte, out []byte) {
+
+	decodeSignedChannelBlock(block[:8], out, 2)
+	decodeSignedChannelBlock(block[8:], out[1:], 2)
+}
+
+// decodeColorBlock decodes the 8 bytes color part of BC1, BC2 and BC3 blocks.
+// If alpha is set, the three color mode has a transparent black color.
+// If fourColors is set, the block is always decoded in the four colors mode.
+func decodeColorBlock(block []byte, out []byte, alpha, fourColors bool) {
+
+	c0 := binary.LittleEndian.Uint16(block[0:])
+	c1 := binary.LittleEndian.Uint16(block[2:])
+	indices := binary.LittleEndian.Uint32(block[4:])
+
+	var colors [4][4]int
+	colors[0] = rgb565(c0)
+	colors[1] = rgb565(c1)
+	if c0 > c1 || fourColors {
+		for i := 0; i < 3; i++ {
+			colors[2][i] = (2*colors[0][i] + colors[1][i]) / 3
+			colors[3][i] = (colors[0][i] + 2*colors[1][i]) / 3
+		}
+		colors[2][3] = 255
+		colors[3][3] = 255
+	} else {
+		for i := 0; i < 3; i++ {
+			colors[2][i] = (colors[0][i] + colors[1][i]) / 2
+		}
+		colors[2][3] = 255
+		if !alpha {
+			colors[3][3] = 255
+		}
+	}
+	for i := 0; i < 16; i++ {
+		c := colors[(indices>>(2*uint(i)))&0x03]
+		out[i*4] = byte(c[0])
+		out[i*4+1] = byte(c[1])
+		out[i*4+2] = byte(c[2])
+		out[i*4+3] = byte(c[3])
+	}
+}
+
+// rgb565 expands a 16 bit 5:6:5 color to an 8 bit per channel opaque color
+func rgb565(c uint16) [4]int {
+
+	r := int(c>>11) & 0x1F
+	g := int(c>>5) & 0x3F
+	b := int(c) & 0x1F
+	return [4]int{r<<3 | r>>2, g<<2 | g>>4, b<<3 | b>>2, 255}
+}
+
+// decodeChannelBlock decodes a single channel unsigned interpolated block
+// (BC3 alpha and BC4) writing each value at the specified stride.
+func decodeChannelBlock(block []byte, out []byte, stride int) {
+
+	var values [8]int
+	values[0] = int(block[0])
+	values[1] = int(block[1])
+	if values[0] > values[1] {
+		for i := 1; i < 7; i++ {
+			values[i+1] = ((7-i)*values[0] + i*values[1]) / 7
+		}
+	} else {
+		for i := 1; i < 5; i++ {
+			values[i+1] = ((5-i)*values[0] + i*values[1]) / 5
+		}
+		values[6] = 0
+		values[7] = 255
+	}
+	bits := uint64(block[2]) | uint64(block[3])<<8 | uint64(block[4])<<16 |
+		uint64(block[5])<<24 | uint64(block[6])<<32 | uint64(block[7])<<40
+	for i := 0; i < 16; i++ {
+		out[i*stride] = byte(values[(bits>>(3*uint(i)))&0x07])
+	}
+}
+
+// decodeSignedChannelBlock decodes a single channel signed interpolated
+// block (signed BC4) writing each value at the specified stride.
+func decodeSignedChannelBlock(block []byte, out []byte, stride int) {
+
+	var values [8]int
+	values[0] = int(int8(block[0]))
+	values[1] = int(int8(block[1]))
+	// -128 is interpreted as -127
+	for i := 0; i < 2; i++ {
+		if values[i] < -127 {
+			values[i] = -127
+		}
+	}
+	if values[0] > values[1] {
+		for i := 1; i < 7; i++ {
+			values[i+1] = ((7-i)*values[0] + i*values[1]) / 7
+		}
+	} else {
+		for i := 1; i < 5; i++ {
+			values[i+1] = ((5-i)*values[0] + i*values[1]) / 5
+		}
+		values[6] = -127
+		values[7] = 127
+	}
+	bits := uint64(block[2]) | uint64(block[3])<<8 | uint64(block[4])<<16 |
+		uint64(block[5])<<24 | uint64(block[6])<<32 | uint64(block[7])<<40
+	for i := 0; i < 16; i++ {
+		out[i*stride] = byte(int8(values[(bits>>(3*uint(i)))&0x07]))
+	}
+}
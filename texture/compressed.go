@@ -0,0 +1,339 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package texture
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"image"
+	"io"
+	"os"
+
+	"github.com/sansebasko/engine/gls"
+)
+
+// CompressedImage contains the mipmap levels of an image decoded from a
+// DDS, KTX or KTX2 container. The data of each level is kept as stored in
+// the container, which is either a GPU block compressed format or
+// uncompressed pixels.
+type CompressedImage struct {
+	Width      int      // width of the base level in pixels
+	Height     int      // height of the base level in pixels
+	IFormat    uint32   // OpenGL internal format
+	Format     uint32   // OpenGL pixel format of uncompressed data (0 for compressed data)
+	FormatType uint32   // OpenGL pixel type of uncompressed data (0 for compressed data)
+	Levels     [][]byte // data of each mipmap level starting with the base level
+}
+
+// blockFormat describes a block compressed format
+type blockFormat struct {
+	width  int // block width in pixels
+	height int // block height in pixels
+	size   int // block size in bytes
+	// decoder of one block into 16 pixels or nil if there is no CPU decoder
+	decode func(block []byte, out []byte)
+	// number of bytes per decoded pixel (4 for RGBA8, 1 for R8, 2 for RG8)
+	channels int
+	// format, pixel type and internal format of the decoded data
+	format, formatType, iformat uint32
+}
+
+// Block compressed formats by OpenGL internal format
+var blockFormats = map[uint32]*blockFormat{
+	gls.COMPRESSED_RGB_S3TC_DXT1_EXT:        {4, 4, 8, decodeBC1RGB, 4, gls.RGBA, gls.UNSIGNED_BYTE, gls.RGBA8},
+	gls.COMPRESSED_RGBA_S3TC_DXT1_EXT:       {4, 4, 8, decodeBC1, 4, gls.RGBA, gls.UNSIGNED_BYTE, gls.RGBA8},
+	gls.COMPRESSED_RGBA_S3TC_DXT3_EXT:       {4, 4, 16, decodeBC2, 4, gls.RGBA, gls.UNSIGNED_BYTE, gls.RGBA8},
+	gls.COMPRESSED_RGBA_S3TC_DXT5_EXT:       {4, 4, 16, decodeBC3, 4, gls.RGBA, gls.UNSIGNED_BYTE, gls.RGBA8},
+	gls.COMPRESSED_SRGB_S3TC_DXT1_EXT:       {4, 4, 8, decodeBC1RGB, 4, gls.RGBA, gls.UNSIGNED_BYTE, gls.SRGB8_ALPHA8},
+	gls.COMPRESSED_SRGB_ALPHA_S3TC_DXT1_EXT: {4, 4, 8, decodeBC1, 4, gls.RGBA, gls.UNSIGNED_BYTE, gls.SRGB8_ALPHA8},
+	gls.COMPRESSED_SRGB_ALPHA_S3TC_DXT3_EXT: {4, 4, 16, decodeBC2, 4, gls.RGBA, gls.UNSIGNED_BYTE, gls.SRGB8_ALPHA8},
+	gls.COMPRESSED_SRGB_ALPHA_S3TC_DXT5_EXT: {4, 4, 16, decodeBC3, 4, gls.RGBA, gls.UNSIGNED_BYTE, gls.SRGB8_ALPHA8},
+	gls.COMPRESSED_RED_RGTC1:                {4, 4, 8, decodeBC4, 1, gls.RED, gls.UNSIGNED_BYTE, gls.R8},
+	gls.COMPRESSED_SIGNED_RED_RGTC1:         {4, 4, 8, decodeBC4Signed, 1, gls.RED, gls.BYTE, gls.R8_SNORM},
+	gls.COMPRESSED_RG_RGTC2:                 {4, 4, 16, decodeBC5, 2, gls.RG, gls.UNSIGNED_BYTE, gls.RG8},
+	gls.COMPRESSED_SIGNED_RG_RGTC2:          {4, 4, 16, decodeBC5Signed, 2, gls.RG, gls.BYTE, gls.RG8_SNORM},
+	gls.COMPRESSED_RGBA_BPTC_UNORM:          {4, 4, 16, decodeBC7, 4, gls.RGBA, gls.UNSIGNED_BYTE, gls.RGBA8},
+	gls.COMPRESSED_SRGB_ALPHA_BPTC_UNORM:    {4, 4, 16, decodeBC7, 4, gls.RGBA, gls.UNSIGNED_BYTE, gls.SRGB8_ALPHA8},
+	gls.COMPRESSED_RGB_BPTC_SIGNED_FLOAT:    {4, 4, 16, nil, 0, 0, 0, 0},
+	gls.COMPRESSED_RGB_BPTC_UNSIGNED_FLOAT:  {4, 4, 16, nil, 0, 0, 0, 0},
+}
+
+// Block dimensions of the ASTC formats in OpenGL enumeration order
+var astcBlocks = [14][2]int{
+	{4, 4}, {5, 4}, {5, 5}, {6, 5}, {6, 6}, {8, 5}, {8, 6},
+	{8, 8}, {10, 5}, {10, 6}, {10, 8}, {10, 10}, {12, 10}, {12, 12},
+}
+
+func init() {
+
+	// ETC2 and EAC formats
+	for f := uint32(gls.COMPRESSED_R11_EAC); f <= gls.COMPRESSED_SRGB8_ALPHA8_ETC2_EAC; f++ {
+		size := 8
+		switch f {
+		case gls.COMPRESSED_RG11_EAC, gls.COMPRESSED_SIGNED_RG11_EAC,
+			gls.COMPRESSED_RGBA8_ETC2_EAC, gls.COMPRESSED_SRGB8_ALPHA8_ETC2_EAC:
+			size = 16
+		}
+		blockFormats[f] = &blockFormat{width: 4, height: 4, size: size}
+	}
+	// ASTC formats
+	for i, dim := range astcBlocks {
+		blockFormats[gls.COMPRESSED_RGBA_ASTC_4x4_KHR+uint32(i)] = &blockFormat{width: dim[0], height: dim[1], size: 16}
+		blockFormats[gls.COMPRESSED_SRGB8_ALPHA8_ASTC_4x4_KHR+uint32(i)] = &blockFormat{width: dim[0], height: dim[1], size: 16}
+	}
+}
+
+// Compressed returns if the image data is in a block compressed format.
+func (ci *CompressedImage) Compressed() bool {
+
+	return ci.Format == 0
+}
+
+// LevelSize returns the width and height in pixels of the specified mipmap level.
+func (ci *CompressedImage) LevelSize(level int) (int, int) {
+
+	w := ci.Width >> uint(level)
+	h := ci.Height >> uint(level)
+	if w < 1 {
+		w = 1
+	}
+	if h < 1 {
+		h = 1
+	}
+	return w, h
+}
+
+// Decompress decodes the specified mipmap level on the CPU returning the
+// pixel data with its OpenGL format, pixel type and internal format.
+// Uncompressed levels are returned as stored.
+// Returns an error if there is no CPU decoder for the image format.
+func (ci *CompressedImage) Decompress(level int) (data []byte, format, formatType, iformat uint32, err error) {
+
+	if level < 0 || level >= len(ci.Levels) {
+		return nil, 0, 0, 0, fmt.Errorf("invalid mipmap level: %d", level)
+	}
+	if !ci.Compressed() {
+		return ci.Levels[level], ci.Format, ci.FormatType, ci.IFormat, nil
+	}
+	bf := blockFormats[ci.IFormat]
+	if bf == nil || bf.decode == nil {
+		return nil, 0, 0, 0, fmt.Errorf("no CPU decoder for compressed format: 0x%X", ci.IFormat)
+	}
+
+	width, height := ci.LevelSize(level)
+	bw := (width + bf.width - 1) / bf.width
+	bh := (height + bf.height - 1) / bf.height
+	src := ci.Levels[level]
+	if len(src) < bw*bh*bf.size {
+		return nil, 0, 0, 0, fmt.Errorf("mipmap level %d data too short", level)
+	}
+	data = make([]byte, width*height*bf.channels)
+	pixels := make([]byte, 16*bf.channels)
+	for by := 0; by < bh; by++ {
+		for bx := 0; bx < bw; bx++ {
+			offset := (by*bw + bx) * bf.size
+			bf.decode(src[offset:offset+bf.size], pixels)
+			// Copies the block pixels which are inside the image
+			for y := 0; y < 4 && by*4+y < height; y++ {
+				for x := 0; x < 4 && bx*4+x < width; x++ {
+					dst := ((by*4+y)*width + bx*4 + x) * bf.channels
+					copy(data[dst:dst+bf.channels], pixels[(y*4+x)*bf.channels:])
+				}
+			}
+		}
+	}
+	return data, bf.format, bf.formatType, bf.iformat, nil
+}
+
+// Number of 8 bit channels of the pixel formats converted by RGBA
+var formatChannels = map[uint32]int{
+	gls.RGBA: 4, gls.BGRA: 4, gls.RGB: 3, gls.BGR: 3, gls.RG: 2, gls.RED: 1,
+}
+
+// RGBA decodes the specified mipmap level into an RGBA8 image.
+// Only 8 bit per channel formats are supported. Single and two channel
+// formats are expanded with red as gray and green in the green channel.
+func (ci *CompressedImage) RGBA(level int) (*image.RGBA, error) {
+
+	data, format, formatType, _, err := ci.Decompress(level)
+	if err != nil {
+		return nil, err
+	}
+	if formatType != gls.UNSIGNED_BYTE {
+		return nil, fmt.Errorf("cannot convert pixel type 0x%X to RGBA", formatType)
+	}
+	width, height := ci.LevelSize(level)
+	channels := formatChannels[format]
+	if channels == 0 {
+		return nil, fmt.Errorf("cannot convert pixel format 0x%X to RGBA", format)
+	}
+	if len(data) < width*height*channels {
+		return nil, fmt.Errorf("mipmap level %d data too short", level)
+	}
+	rgba := image.NewRGBA(image.Rect(0, 0, width, height))
+	pix := rgba.Pix
+	for i := 0; i < width*height; i++ {
+		switch format {
+		case gls.RGBA:
+			copy(pix[i*4:i*4+4], data[i*4:])
+		case gls.BGRA:
+			pix[i*4], pix[i*4+1], pix[i*4+2], pix[i*4+3] = data[i*4+2], data[i*4+1], data[i*4], data[i*4+3]
+		case gls.RGB:
+			pix[i*4], pix[i*4+1], pix[i*4+2], pix[i*4+3] = data[i*3], data[i*3+1], data[i*3+2], 255
+		case gls.BGR:
+			pix[i*4], pix[i*4+1], pix[i*4+2], pix[i*4+3] = data[i*3+2], data[i*3+1], data[i*3], 255
+		case gls.RG:
+			pix[i*4], pix[i*4+1], pix[i*4+2], pix[i*4+3] = data[i*2], data[i*2+1], 0, 255
+		case gls.RED:
+			pix[i*4], pix[i*4+1], pix[i*4+2], pix[i*4+3] = data[i], data[i], data[i], 255
+		}
+	}
+	return rgba, nil
+}
+
+// maxImageSize is the maximum width and height in pixels of the images decoded
+// from containers, which is larger than the texture size limits of OpenGL drivers
+// and small enough that the data size of any format does not overflow.
+const maxImageSize = 1 << 16
+
+// checkImageSize returns an error if the specified image size is out of range
+func checkImageSize(width, height int) error {
+
+	if width < 1 || height < 1 || width > maxImageSize || height > maxImageSize {
+		return fmt.Errorf("invalid image size: %dx%d", width, height)
+	}
+	return nil
+}
+
+// levelDataSize returns the number of bytes of the specified mipmap level
+// for the specified compressed internal format or uncompressed pixel size.
+func levelDataSize(iformat uint32, pixelSize, width, height int) int {
+
+	if bf := blockFormats[iformat]; bf != nil && pixelSize == 0 {
+		bw := (width + bf.width - 1) / bf.width
+		bh := (height + bf.height - 1) / bf.height
+		return bw * bh * bf.size
+	}
+	return width * height * pixelSize
+}
+
+// Magic numbers of the supported containers
+var (
+	ddsMagic  = []byte("DDS ")
+	ktxMagic  = []byte{0xAB, 'K', 'T', 'X', ' ', '1', '1', 0xBB, '\r', '\n', 0x1A, '\n'}
+	ktx2Magic = []byte{0xAB, 'K', 'T', 'X', ' ', '2', '0', 0xBB, '\r', '\n', 0x1A, '\n'}
+)
+
+// IsCompressedContainer returns if the specified data starts with the
+// magic number of a DDS, KTX or KTX2 container.
+func IsCompressedContainer(header []byte) bool {
+
+	return bytes.HasPrefix(header, ddsMagic) || bytes.HasPrefix(header, ktxMagic) || bytes.HasPrefix(header, ktx2Magic)
+}
+
+// DecodeCompressed decodes a DDS, KTX or KTX2 container from the specified
+// reader, detecting the container type by its magic number.
+func DecodeCompressed(r io.Reader) (*CompressedImage, error) {
+
+	br := bufio.NewReader(r)
+	header, _ := br.Peek(len(ktxMagic))
+	switch {
+	case bytes.HasPrefix(header, ddsMagic):
+		return DecodeDDS(br)
+	case bytes.HasPrefix(header, ktxMagic):
+		return DecodeKTX(br)
+	case bytes.HasPrefix(header, ktx2Magic):
+		return DecodeKTX2(br)
+	}
+	return nil, fmt.Errorf("not a DDS, KTX or KTX2 container")
+}
+
+// DecodeCompressedFile decodes the specified DDS, KTX or KTX2 file.
+func DecodeCompressedFile(filename string) (*CompressedImage, error) {
+
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return DecodeCompressed(file)
+}
+
+// isCompressedFile returns if the specified file is a DDS, KTX or KTX2 container
+func isCompressedFile(filename string) bool {
+
+	file, err := os.Open(filename)
+	if err != nil {
+		return false
+	}
+	defer file.Close()
+	header := make([]byte, len(ktxMagic))
+	n, _ := io.ReadFull(file, header)
+	return IsCompressedContainer(header[:n])
+}
+
+// NewTexture2DFromCompressed creates a new texture from the specified
+// compressed image. If the image contains mipmap levels they are
+// used instead of generating them.
+func NewTexture2DFromCompressed(ci *CompressedImage) *Texture2D {
+
+	t := newTexture2D()
+	t.SetCompressed(ci)
+	return t
+}
+
+// SetCompressed sets the texture data from the specified compressed image.
+// The data is uploaded in its compressed format if it is supported by the
+// OpenGL context, otherwise it is decompressed on the CPU.
+func (t *Texture2D) SetCompressed(ci *CompressedImage) {
+
+	t.width = int32(ci.Width)
+	t.height = int32(ci.Height)
+	t.iformat = int32(ci.IFormat)
+	t.format = ci.Format
+	t.formatType = ci.FormatType
+	t.data = nil
+	t.cimage = ci
+	t.RGBA = nil
+	t.updateData = true
+}
+
+// Default value of the TEXTURE_MAX_LEVEL parameter
+const defaultMaxLevel = 1000
+
+// uploadCompressed transfers the mipmap levels of the compressed image to OpenGL
+func (t *Texture2D) uploadCompressed(gs *gls.GLS) {
+
+	ci := t.cimage
+	maxLevel := int32(len(ci.Levels) - 1)
+	gs.PixelStorei(gls.UNPACK_ALIGNMENT, 1)
+	if ci.Compressed() && gs.CompressedFormatSupported(ci.IFormat) {
+		for level, data := range ci.Levels {
+			w, h := ci.LevelSize(level)
+			gs.CompressedTexImage2D(gls.TEXTURE_2D, int32(level), ci.IFormat, int32(w), int32(h), 0, data)
+		}
+	} else {
+		for level := range ci.Levels {
+			data, format, formatType, iformat, err := ci.Decompress(level)
+			if err != nil {
+				log.Error("Texture2D: %v", err)
+				maxLevel = int32(level - 1)
+				break
+			}
+			w, h := ci.LevelSize(level)
+			gs.TexImage2D(gls.TEXTURE_2D, int32(level), int32(iformat), int32(w), int32(h), 0, format, formatType, data)
+		}
+		// Generates the mipmaps of a single decompressed level
+		if len(ci.Levels) == 1 && maxLevel == 0 && t.genMipmap {
+			gs.GenerateMipmap(gls.TEXTURE_2D)
+			maxLevel = defaultMaxLevel
+		}
+	}
+	gs.PixelStorei(gls.UNPACK_ALIGNMENT, 4)
+	if maxLevel >= 0 {
+		gs.TexParameteri(gls.TEXTURE_2D, gls.TEXTURE_MAX_LEVEL, maxLevel)
+	}
+}
@@ -0,0 +1,141 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package texture
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+
+	"github.com/sansebasko/engine/gls"
+)
+
+// ddsFile returns a DDS file of the specified size with the specified FourCC
+// format or 32 bit RGBA pixels if the FourCC is empty, followed by the data
+func ddsFile(width, height uint32, fourCC string, data []byte) []byte {
+
+	hdr := make([]byte, 4+ddsHeaderSize)
+	copy(hdr, ddsMagic)
+	le := binary.LittleEndian
+	le.PutUint32(hdr[4:], ddsHeaderSize)
+	le.PutUint32(hdr[12:], height)
+	le.PutUint32(hdr[16:], width)
+	pf := hdr[4+72:]
+	le.PutUint32(pf[0:], 32)
+	if fourCC != "" {
+		le.PutUint32(pf[4:], ddsPixelFourCC)
+		copy(pf[8:], fourCC)
+	} else {
+		le.PutUint32(pf[4:], ddsPixelRGB|ddsPixelAlpha)
+		le.PutUint32(pf[12:], 32)
+		le.PutUint32(pf[16:], 0x000000FF)
+	}
+	return append(hdr, data...)
+}
+
+// ktx2File returns a KTX2 file of the specified size and Vulkan format
+// with one level with the specified offset, length and data
+func ktx2File(width, height, vkFormat uint32, offset, length uint64, data []byte) []byte {
+
+	hdr := make([]byte, ktx2HeaderSize+ktx2LevelIndexEntrySize)
+	copy(hdr, ktx2Magic)
+	le := binary.LittleEndian
+	le.PutUint32(hdr[12:], vkFormat)
+	le.PutUint32(hdr[20:], width)
+	le.PutUint32(hdr[24:], height)
+	le.PutUint32(hdr[36:], 1)
+	le.PutUint32(hdr[40:], 1)
+	le.PutUint64(hdr[ktx2HeaderSize:], offset)
+	le.PutUint64(hdr[ktx2HeaderSize+8:], length)
+	return append(hdr, data...)
+}
+
+// Tests decoding DDS and KTX2 containers into RGBA images
+func TestDecodeCompressed(t *testing.T) {
+
+	pixels := []byte{255, 0, 0, 255, 0, 255, 0, 255, 0, 0, 255, 255, 255, 255, 255, 0}
+	// BC1 block with white and black endpoints and all the pixels of the first color
+	bc1 := []byte{0xFF, 0xFF, 0, 0, 0, 0, 0, 0}
+	tests := []struct {
+		name    string
+		data    []byte
+		iformat uint32
+		width   int
+		pixel   [4]byte
+	}{
+		{"dds rgba", ddsFile(2, 2, "", pixels), gls.RGBA8, 2, [4]byte{255, 0, 0, 255}},
+		{"dds bc1", ddsFile(4, 4, "DXT1", bc1), gls.COMPRESSED_RGBA_S3TC_DXT1_EXT, 4, [4]byte{255, 255, 255, 255}},
+		{"ktx2 rgba", ktx2File(2, 2, 37, ktx2HeaderSize+ktx2LevelIndexEntrySize, 16, pixels), gls.RGBA8, 2, [4]byte{255, 0, 0, 255}},
+		{"ktx2 bc1", ktx2File(4, 4, 131, ktx2HeaderSize+ktx2LevelIndexEntrySize, 8, bc1), gls.COMPRESSED_RGB_S3TC_DXT1_EXT, 4, [4]byte{255, 255, 255, 255}},
+	}
+	for _, test := range tests {
+		ci, err := DecodeCompressed(bytes.NewReader(test.data))
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+		if ci.IFormat != test.iformat || ci.Width != test.width || len(ci.Levels) != 1 {
+			t.Errorf("%s: format 0x%X, width %d, %d levels", test.name, ci.IFormat, ci.Width, len(ci.Levels))
+			continue
+		}
+		img, err := ci.RGBA(0)
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+		var pixel [4]byte
+		copy(pixel[:], img.Pix)
+		if pixel != test.pixel {
+			t.Errorf("%s: first pixel %v, expected %v", test.name, pixel, test.pixel)
+		}
+	}
+}
+
+// Tests that containers with invalid sizes return errors instead of
+// allocating their sizes or decoding images without data
+func TestDecodeCompressedMalformed(t *testing.T) {
+
+	ktx := func(width, height, arrayElements, faces, imageSize uint32) []byte {
+		hdr := make([]byte, ktxHeaderSize+4)
+		copy(hdr, ktxMagic)
+		le := binary.LittleEndian
+		le.PutUint32(hdr[12:], ktxEndianness)
+		le.PutUint32(hdr[16:], gls.UNSIGNED_BYTE)
+		le.PutUint32(hdr[20:], 1)
+		le.PutUint32(hdr[24:], gls.RGBA)
+		le.PutUint32(hdr[28:], gls.RGBA8)
+		le.PutUint32(hdr[36:], width)
+		le.PutUint32(hdr[40:], height)
+		le.PutUint32(hdr[48:], arrayElements)
+		le.PutUint32(hdr[52:], faces)
+		le.PutUint32(hdr[ktxHeaderSize:], imageSize)
+		return hdr
+	}
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"dds header", ddsFile(1, 1, "", nil)[:100]},
+		{"dds size overflow", ddsFile(1<<31, 1<<31, "", nil)},
+		{"dds zero size", ddsFile(0, 4, "DXT1", make([]byte, 8))},
+		{"dds data", ddsFile(4, 4, "", make([]byte, 60))},
+		{"dds format", ddsFile(4, 4, "XXXX", make([]byte, 64))},
+		{"ktx size", ktx(1<<20, 1, 0, 0, 0)},
+		{"ktx faces overflow", ktx(1, 1, 1<<16, 1<<16, 0)},
+		{"ktx data", ktx(2, 2, 0, 0, 16)},
+		{"ktx2 size", ktx2File(1<<31, 1<<31, 37, 0, 0, nil)},
+		{"ktx2 offset overflow", ktx2File(1, 1, 37, 1<<63, 1<<63, make([]byte, 4))},
+		{"ktx2 data", ktx2File(2, 2, 37, ktx2HeaderSize+ktx2LevelIndexEntrySize, 8, make([]byte, 8))},
+	}
+	for _, test := range tests {
+		ci, err := DecodeCompressed(bytes.NewReader(test.data))
+		if err == nil {
+			_, err = ci.RGBA(0)
+		}
+		if err == nil {
+			t.Errorf("%s: no error", test.name)
+		}
+	}
+}
@@ -0,0 +1,475 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package texture
+
+import (
+	"fmt"
+	"image"
+	"math"
+
+	"github.com/sansebasko/engine/gls"
+)
+
+// Cube map faces in the OpenGL order
+const (
+	CubePositiveX = iota
+	CubeNegativeX
+	CubePositiveY
+	CubeNegativeY
+	CubePositiveZ
+	CubeNegativeZ
+)
+
+// TextureCube represents a cube map texture with six square faces.
+// It is sampled with a direction vector and is used for skyboxes
+// and environment reflections.
+// The faces follow the OpenGL cube map convention and are sampled with the
+// X coordinate of the world direction inverted, so images made for the
+// usual right handed skybox convention appear unmirrored.
+type TextureCube struct {
+	gs           *gls.GLS       // Pointer to OpenGL state
+	refcount     int            // Current number of references
+	texname      uint32         // Texture handle
+	magFilter    uint32         // magnification filter
+	minFilter    uint32         // minification filter
+	iformat      int32          // internal format
+	size         int32          // width and height of each face in pixels
+	format       uint32         // format of the pixel data
+	formatType   uint32         // type of the pixel data
+	updateData   bool           // texture data needs to be sent
+	updateParams bool           // texture parameters needs to be sent
+	genMipmap    bool           // generate mipmaps flag
+	data         [6]interface{} // pixel data of each face
+	uniUnit      gls.Uniform    // Texture unit uniform location cache
+}
+
+func newTextureCube() *TextureCube {
+
+	t := new(TextureCube)
+	t.refcount = 1
+	t.magFilter = gls.LINEAR
+	t.minFilter = gls.LINEAR_MIPMAP_LINEAR
+	t.updateParams = true
+	t.genMipmap = true
+	t.uniUnit.Init("EnvMap")
+	return t
+}
+
+// NewTextureCube creates and returns a pointer to a new TextureCube from six
+// image files in the order: +X, -X, +Y, -Y, +Z, -Z.
+// The images must be square and of the same size. Radiance HDR and OpenEXR
+// images are kept as half float faces.
+func NewTextureCube(files [6]string) (*TextureCube, error) {
+
+	var srcs [6]*cubeSource
+	for i, file := range files {
+		src, err := loadCubeSource(file)
+		if err != nil {
+			return nil, err
+		}
+		if src.width != src.height || (i > 0 && (src.width != srcs[0].width || src.isFloat() != srcs[0].isFloat())) {
+			return nil, fmt.Errorf("cube map face %s must be square and have the same size and type as the other faces", file)
+		}
+		srcs[i] = src
+	}
+	t := newTextureCube()
+	var faces [6]interface{}
+	for i, src := range srcs {
+		faces[i] = src.face(0, 0, src.width, false)
+	}
+	t.setFaces(srcs[0].width, srcs[0].channels, srcs[0].isFloat(), faces)
+	return t, nil
+}
+
+// NewTextureCubeFromRGBA creates and returns a pointer to a new TextureCube
+// from six square RGBA images of the same size in the order: +X, -X, +Y, -Y, +Z, -Z.
+func NewTextureCubeFromRGBA(faces [6]*image.RGBA) (*TextureCube, error) {
+
+	size := faces[0].Rect.Dx()
+	var data [6]interface{}
+	for i, rgba := range faces {
+		if rgba.Rect.Dx() != size || rgba.Rect.Dy() != size || rgba.Stride != size*4 {
+			return nil, fmt.Errorf("cube map face %d must be square and have the same size as the other faces", i)
+		}
+		data[i] = rgba.Pix
+	}
+	t := newTextureCube()
+	t.setFaces(size, 4, false, data)
+	return t, nil
+}
+
+// NewTextureCubeFromFloat creates and returns a pointer to a new TextureCube
+// from six square floating point images of the same size in the order:
+// +X, -X, +Y, -Y, +Z, -Z, using the specified internal format.
+func NewTextureCubeFromFloat(faces [6]*FloatImage, iformat int) (*TextureCube, error) {
+
+	size := faces[0].Width
+	var data [6]interface{}
+	for i, fi := range faces {
+		if fi.Width != size || fi.Height != size || fi.Channels != faces[0].Channels {
+			return nil, fmt.Errorf("cube map face %d must be square and have the same size as the other faces", i)
+		}
+		data[i] = fi.Pix
+	}
+	t := newTextureCube()
+	t.setFaces(size, faces[0].Channels, true, data)
+	t.iformat = int32(iformat)
+	return t, nil
+}
+
+// NewTextureCubeFromLayout creates and returns a pointer to a new TextureCube
+// from a single image file with the six faces in one of the layouts:
+// horizontal cross (4x3 faces), vertical cross (3x4 faces with -Z upside down),
+// horizontal strip (6x1 faces) or vertical strip (1x6 faces).
+// The layout is detected from the image aspect ratio.
+func NewTextureCubeFromLayout(imgfile string) (*TextureCube, error) {
+
+	src, err := loadCubeSource(imgfile)
+	if err != nil {
+		return nil, err
+	}
+	// Position of each face in face units and if it is rotated by 180 degrees
+	var layout [6][3]int
+	var size int
+	w, h := src.width, src.height
+	switch {
+	case w*3 == h*4 && w%4 == 0:
+		size = w / 4
+		layout = [6][3]int{{2, 1, 0}, {0, 1, 0}, {1, 0, 0}, {1, 2, 0}, {1, 1, 0}, {3, 1, 0}}
+	case w*4 == h*3 && w%3 == 0:
+		size = w / 3
+		layout = [6][3]int{{2, 1, 0}, {0, 1, 0}, {1, 0, 0}, {1, 2, 0}, {1, 1, 0}, {1, 3, 1}}
+	case w == h*6:
+		size = h
+		layout = [6][3]int{{0, 0, 0}, {1, 0, 0}, {2, 0, 0}, {3, 0, 0}, {4, 0, 0}, {5, 0, 0}}
+	case h == w*6:
+		size = w
+		layout = [6][3]int{{0, 0, 0}, {0, 1, 0}, {0, 2, 0}, {0, 3, 0}, {0, 4, 0}, {0, 5, 0}}
+	default:
+		return nil, fmt.Errorf("unknown cube map layout of %dx%d image: %s", w, h, imgfile)
+	}
+	var faces [6]interface{}
+	for i, l := range layout {
+		faces[i] = src.face(l[0]*size, l[1]*size, size, l[2] != 0)
+	}
+	t := newTextureCube()
+	t.setFaces(size, src.channels, src.isFloat(), faces)
+	return t, nil
+}
+
+// NewTextureCubeFromEquirect creates and returns a pointer to a new TextureCube
+// converting the specified equirectangular (latitude/longitude) panorama image
+// file into cube faces of the specified size. If size is 0 it is a quarter
+// of the panorama width.
+// The center of the panorama is mapped to the -Z direction.
+func NewTextureCubeFromEquirect(imgfile string, size int) (*TextureCube, error) {
+
+	src, err := loadCubeSource(imgfile)
+	if err != nil {
+		return nil, err
+	}
+	if size <= 0 {
+		size = src.width / 4
+	}
+	if size <= 0 {
+		return nil, fmt.Errorf("equirectangular image too small: %s", imgfile)
+	}
+
+	var faces [6]interface{}
+	values := make([]float32, src.channels)
+	for face := range faces {
+		out := make([]float32, size*size*src.channels)
+		for j := 0; j < size; j++ {
+			for i := 0; i < size; i++ {
+				x, y, z := cubeDirection(face, (float64(i)+0.5)/float64(size), (float64(j)+0.5)/float64(size))
+				// Cube faces are sampled with the X coordinate inverted
+				x = -x
+				lon := math.Atan2(x, -z)
+				lat := math.Asin(y / math.Sqrt(x*x+y*y+z*z))
+				u := lon/(2*math.Pi) + 0.5
+				v := 0.5 - lat/math.Pi
+				src.sample(u, v, values)
+				copy(out[(j*size+i)*src.channels:], values)
+			}
+		}
+		faces[face] = src.convert(out)
+	}
+	t := newTextureCube()
+	t.setFaces(size, src.channels, src.isFloat(), faces)
+	return t, nil
+}
+
+// cubeDirection returns the (not normalized) direction of the point with the
+// specified texture coordinates in the specified face, following the OpenGL
+// cube map convention where the first row of each face is at t = 0.
+func cubeDirection(face int, s, t float64) (x, y, z float64) {
+
+	a := 2*s - 1
+	b := 2*t - 1
+	switch face {
+	case CubePositiveX:
+		return 1, -b, -a
+	case CubeNegativeX:
+		return -1, -b, a
+	case CubePositiveY:
+		return a, 1, b
+	case CubeNegativeY:
+		return a, -1, -b
+	case CubePositiveZ:
+		return a, -b, 1
+	default:
+		return -a, -b, -1
+	}
+}
+
+// setFaces sets the pixel data of all the faces
+func (t *TextureCube) setFaces(size, channels int, float bool, faces [6]interface{}) {
+
+	t.size = int32(size)
+	t.data = faces
+	if float {
+		t.format = gls.RGB
+		t.iformat = gls.RGB16F
+		if channels == 4 {
+			t.format = gls.RGBA
+			t.iformat = gls.RGBA16F
+		}
+		t.formatType = gls.FLOAT
+	} else {
+		t.format = gls.RGBA
+		t.iformat = gls.RGBA8
+		t.formatType = gls.UNSIGNED_BYTE
+	}
+	t.updateData = true
+}
+
+// Incref increments the reference count for this texture
+// and returns a pointer to the texture.
+// It should be used when this texture is shared by another
+// material or skybox.
+func (t *TextureCube) Incref() *TextureCube {
+
+	t.refcount++
+	return t
+}
+
+// Dispose decrements this texture reference count and
+// if necessary releases OpenGL resources associated with this texture.
+func (t *TextureCube) Dispose() {
+
+	if t.refcount > 1 {
+		t.refcount--
+		return
+	}
+	if t.gs != nil {
+		t.gs.DeleteTextures(t.texname)
+		t.gs = nil
+	}
+}
+
+// Size returns the width and height of the faces in pixels
+func (t *TextureCube) Size() int {
+
+	return int(t.size)
+}
+
+// HDR returns if the faces have floating point values
+// instead of 8 bit sRGB values.
+func (t *TextureCube) HDR() bool {
+
+	return t.formatType == gls.FLOAT
+}
+
+// SetUniformName sets the name of the sampler uniform in the shader.
+// The default name is "EnvMap".
+func (t *TextureCube) SetUniformName(sampler string) {
+
+	t.uniUnit.Init(sampler)
+}
+
+// UniformName returns the name of the sampler uniform in the shader.
+func (t *TextureCube) UniformName() string {
+
+	return t.uniUnit.Name()
+}
+
+// SetMagFilter sets the filter to be applied when the texture element
+// covers more than on pixel. The default value is gls.Linear.
+func (t *TextureCube) SetMagFilter(magFilter uint32) {
+
+	t.magFilter = magFilter
+	t.updateParams = true
+}
+
+// SetMinFilter sets the filter to be applied when the texture element
+// covers less than on pixel. The default value is gls.LINEAR_MIPMAP_LINEAR.
+func (t *TextureCube) SetMinFilter(minFilter uint32) {
+
+	t.minFilter = minFilter
+	t.updateParams = true
+}
+
+// RenderSetup is called by the material or skybox render setup to bind
+// this texture to the specified texture unit.
+func (t *TextureCube) RenderSetup(gs *gls.GLS, slotIdx int) {
+
+	// One time initialization
+	if t.gs == nil {
+		t.texname = gs.GenTexture()
+		t.gs = gs
+	}
+
+	// Sets the texture unit for this texture
+	gs.ActiveTexture(uint32(gls.TEXTURE0 + slotIdx))
+	gs.BindTexture(gls.TEXTURE_CUBE_MAP, t.texname)
+
+	// Transfer the data of the faces to OpenGL if necessary
+	if t.updateData {
+		for face, data := range t.data {
+			gs.TexImage2D(
+				uint32(gls.TEXTURE_CUBE_MAP_POSITIVE_X+face),
+				0,
+				t.iformat,
+				t.size,
+				t.size,
+				0,
+				t.format,
+				t.formatType,
+				data,
+			)
+		}
+		if t.genMipmap {
+			gs.GenerateMipmap(gls.TEXTURE_CUBE_MAP)
+		}
+		gs.TexParameteri(gls.TEXTURE_CUBE_MAP, gls.TEXTURE_MAX_LEVEL, defaultMaxLevel)
+		t.updateData = false
+	}
+
+	// Sets texture parameters if needed
+	if t.updateParams {
+		gs.TexParameteri(gls.TEXTURE_CUBE_MAP, gls.TEXTURE_MAG_FILTER, int32(t.magFilter))
+		gs.TexParameteri(gls.TEXTURE_CUBE_MAP, gls.TEXTURE_MIN_FILTER, int32(t.minFilter))
+		gs.TexParameteri(gls.TEXTURE_CUBE_MAP, gls.TEXTURE_WRAP_S, gls.CLAMP_TO_EDGE)
+		gs.TexParameteri(gls.TEXTURE_CUBE_MAP, gls.TEXTURE_WRAP_T, gls.CLAMP_TO_EDGE)
+		gs.TexParameteri(gls.TEXTURE_CUBE_MAP, gls.TEXTURE_WRAP_R, gls.CLAMP_TO_EDGE)
+		t.updateParams = false
+	}
+
+	// Transfer texture unit uniform
+	gs.Uniform1i(t.uniUnit.Location(gs), int32(slotIdx))
+}
+
+// cubeSource is an image used to build cube map faces
+// with either RGBA8 or floating point pixels.
+type cubeSource struct {
+	width    int       // width in pixels
+	height   int       // height in pixels
+	channels int       // number of values per pixel
+	u8       []uint8   // RGBA8 pixels
+	f32      []float32 // floating point pixels
+}
+
+// loadCubeSource decodes the specified image file
+func loadCubeSource(imgfile string) (*cubeSource, error) {
+
+	if isFloatFile(imgfile) {
+		fi, err := DecodeFloatFile(imgfile)
+		if err != nil {
+			return nil, err
+		}
+		return &cubeSource{width: fi.Width, height: fi.Height, channels: fi.Channels, f32: fi.Pix}, nil
+	}
+	rgba, err := DecodeImage(imgfile)
+	if err != nil {
+		return nil, err
+	}
+	return &cubeSource{width: rgba.Rect.Dx(), height: rgba.Rect.Dy(), channels: 4, u8: rgba.Pix}, nil
+}
+
+// isFloat returns if the source has floating point pixels
+func (src *cubeSource) isFloat() bool {
+
+	return src.f32 != nil
+}
+
+// face returns a copy of the square region at the specified position,
+// optionally rotated by 180 degrees.
+func (src *cubeSource) face(x0, y0, size int, rotate bool) interface{} {
+
+	ch := src.channels
+	var u8 []uint8
+	var f32 []float32
+	for j := 0; j < size; j++ {
+		y := y0 + j
+		if rotate {
+			y = y0 + size - 1 - j
+		}
+		for i := 0; i < size; i++ {
+			x := x0 + i
+			if rotate {
+				x = x0 + size - 1 - i
+			}
+			offset := (y*src.width + x) * ch
+			if src.isFloat() {
+				f32 = append(f32, src.f32[offset:offset+ch]...)
+			} else {
+				u8 = append(u8, src.u8[offset:offset+ch]...)
+			}
+		}
+	}
+	if src.isFloat() {
+		return f32
+	}
+	return u8
+}
+
+// sample returns the bilinearly filtered pixel values at the specified
+// normalized coordinates, wrapping horizontally and clamping vertically.
+// RGBA8 values are normalized to the [0, 1] range.
+func (src *cubeSource) sample(u, v float64, out []float32) {
+
+	fx := u*float64(src.width) - 0.5
+	fy := v*float64(src.height) - 0.5
+	x0 := int(math.Floor(fx))
+	y0 := int(math.Floor(fy))
+	wx := float32(fx - float64(x0))
+	wy := float32(fy - float64(y0))
+	for c := range out {
+		out[c] = 0
+	}
+	for _, p := range [4][3]float32{{0, 0, (1 - wx) * (1 - wy)}, {1, 0, wx * (1 - wy)}, {0, 1, (1 - wx) * wy}, {1, 1, wx * wy}} {
+		x := (x0 + int(p[0])) % src.width
+		if x < 0 {
+			x += src.width
+		}
+		y := y0 + int(p[1])
+		if y < 0 {
+			y = 0
+		} else if y >= src.height {
+			y = src.height - 1
+		}
+		offset := (y*src.width + x) * src.channels
+		for c := range out {
+			if src.isFloat() {
+				out[c] += p[2] * src.f32[offset+c]
+			} else {
+				out[c] += p[2] * float32(src.u8[offset+c]) / 255
+			}
+		}
+	}
+}
+
+// convert converts sampled values to the pixel type of this source
+func (src *cubeSource) convert(values []float32) interface{} {
+
+	if src.isFloat() {
+		return values
+	}
+	u8 := make([]uint8, len(values))
+	for i, v := range values {
+		u8[i] = uint8(math.Min(math.Max(float64(v)*255+0.5, 0), 255))
+	}
+	return u8
+}
@@ -0,0 +1,158 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package texture
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"io/ioutil"
+
+	"github.com/sansebasko/engine/gls"
+)
+
+// DDS header flags and sizes
+const (
+	ddsHeaderSize     = 124
+	ddsDX10HeaderSize = 20
+	ddsFlagMipmaps    = 0x20000
+	ddsPixelFourCC    = 0x4
+	ddsPixelRGB       = 0x40
+	ddsPixelAlpha     = 0x1
+)
+
+// Maps DXGI formats of the DX10 header extension to OpenGL formats:
+// internal format, pixel format, pixel type and pixel size (0 for compressed)
+var dxgiFormats = map[uint32][4]uint32{
+	2:  {gls.RGBA32F, gls.RGBA, gls.FLOAT, 16},             // R32G32B32A32_FLOAT
+	10: {gls.RGBA16F, gls.RGBA, gls.HALF_FLOAT, 8},         // R16G16B16A16_FLOAT
+	28: {gls.RGBA8, gls.RGBA, gls.UNSIGNED_BYTE, 4},        // R8G8B8A8_UNORM
+	29: {gls.SRGB8_ALPHA8, gls.RGBA, gls.UNSIGNED_BYTE, 4}, // R8G8B8A8_UNORM_SRGB
+	49: {gls.RG8, gls.RG, gls.UNSIGNED_BYTE, 2},            // R8G8_UNORM
+	61: {gls.R8, gls.RED, gls.UNSIGNED_BYTE, 1},            // R8_UNORM
+	71: {gls.COMPRESSED_RGBA_S3TC_DXT1_EXT, 0, 0, 0},       // BC1_UNORM
+	72: {gls.COMPRESSED_SRGB_ALPHA_S3TC_DXT1_EXT, 0, 0, 0}, // BC1_UNORM_SRGB
+	74: {gls.COMPRESSED_RGBA_S3TC_DXT3_EXT, 0, 0, 0},       // BC2_UNORM
+	75: {gls.COMPRESSED_SRGB_ALPHA_S3TC_DXT3_EXT, 0, 0, 0}, // BC2_UNORM_SRGB
+	77: {gls.COMPRESSED_RGBA_S3TC_DXT5_EXT, 0, 0, 0},       // BC3_UNORM
+	78: {gls.COMPRESSED_SRGB_ALPHA_S3TC_DXT5_EXT, 0, 0, 0}, // BC3_UNORM_SRGB
+	80: {gls.COMPRESSED_RED_RGTC1, 0, 0, 0},                // BC4_UNORM
+	81: {gls.COMPRESSED_SIGNED_RED_RGTC1, 0, 0, 0},         // BC4_SNORM
+	83: {gls.COMPRESSED_RG_RGTC2, 0, 0, 0},                 // BC5_UNORM
+	84: {gls.COMPRESSED_SIGNED_RG_RGTC2, 0, 0, 0},          // BC5_SNORM
+	87: {gls.RGBA8, gls.BGRA, gls.UNSIGNED_BYTE, 4},        // B8G8R8A8_UNORM
+	91: {gls.SRGB8_ALPHA8, gls.BGRA, gls.UNSIGNED_BYTE, 4}, // B8G8R8A8_UNORM_SRGB
+	95: {gls.COMPRESSED_RGB_BPTC_UNSIGNED_FLOAT, 0, 0, 0},  // BC6H_UF16
+	96: {gls.COMPRESSED_RGB_BPTC_SIGNED_FLOAT, 0, 0, 0},    // BC6H_SF16
+	98: {gls.COMPRESSED_RGBA_BPTC_UNORM, 0, 0, 0},          // BC7_UNORM
+	99: {gls.COMPRESSED_SRGB_ALPHA_BPTC_UNORM, 0, 0, 0},    // BC7_UNORM_SRGB
+}
+
+// Maps legacy FourCC codes to OpenGL compressed formats
+var fourCCFormats = map[string]uint32{
+	"DXT1": gls.COMPRESSED_RGBA_S3TC_DXT1_EXT,
+	"DXT2": gls.COMPRESSED_RGBA_S3TC_DXT3_EXT,
+	"DXT3": gls.COMPRESSED_RGBA_S3TC_DXT3_EXT,
+	"DXT4": gls.COMPRESSED_RGBA_S3TC_DXT5_EXT,
+	"DXT5": gls.COMPRESSED_RGBA_S3TC_DXT5_EXT,
+	"ATI1": gls.COMPRESSED_RED_RGTC1,
+	"BC4U": gls.COMPRESSED_RED_RGTC1,
+	"BC4S": gls.COMPRESSED_SIGNED_RED_RGTC1,
+	"ATI2": gls.COMPRESSED_RG_RGTC2,
+	"BC5U": gls.COMPRESSED_RG_RGTC2,
+	"BC5S": gls.COMPRESSED_SIGNED_RG_RGTC2,
+}
+
+// DecodeDDS decodes a DirectDraw Surface (DDS) container from the specified reader.
+// Supports the BC1 to BC7 compressed formats, with the legacy FourCC or
+// the DX10 header, and the common uncompressed RGB(A) formats.
+// For cube maps and texture arrays only the first image is decoded.
+func DecodeDDS(r io.Reader) (*CompressedImage, error) {
+
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < len(ddsMagic)+ddsHeaderSize || string(data[:4]) != string(ddsMagic) {
+		return nil, fmt.Errorf("invalid DDS header")
+	}
+	le := binary.LittleEndian
+	hdr := data[4 : 4+ddsHeaderSize]
+	offset := 4 + ddsHeaderSize
+
+	ci := new(CompressedImage)
+	ci.Height = int(le.Uint32(hdr[8:]))
+	ci.Width = int(le.Uint32(hdr[12:]))
+	if err := checkImageSize(ci.Width, ci.Height); err != nil {
+		return nil, err
+	}
+	levels := 1
+	if le.Uint32(hdr[4:])&ddsFlagMipmaps != 0 && le.Uint32(hdr[24:]) > 0 {
+		levels = int(le.Uint32(hdr[24:]))
+	}
+
+	// Pixel format
+	pf := hdr[72:104]
+	pfFlags := le.Uint32(pf[4:])
+	fourCC := string(pf[8:12])
+	pixelSize := 0
+	switch {
+	case pfFlags&ddsPixelFourCC != 0 && fourCC == "DX10":
+		if len(data) < offset+ddsDX10HeaderSize {
+			return nil, fmt.Errorf("invalid DDS DX10 header")
+		}
+		dxgi := le.Uint32(data[offset:])
+		offset += ddsDX10HeaderSize
+		f, ok := dxgiFormats[dxgi]
+		if !ok {
+			return nil, fmt.Errorf("unsupported DDS DXGI format: %d", dxgi)
+		}
+		ci.IFormat, ci.Format, ci.FormatType, pixelSize = f[0], f[1], f[2], int(f[3])
+	case pfFlags&ddsPixelFourCC != 0:
+		f, ok := fourCCFormats[fourCC]
+		if !ok {
+			return nil, fmt.Errorf("unsupported DDS FourCC format: %q", fourCC)
+		}
+		ci.IFormat = f
+	case pfFlags&ddsPixelRGB != 0:
+		bitCount := le.Uint32(pf[12:])
+		redMask := le.Uint32(pf[16:])
+		pixelSize = int(bitCount / 8)
+		ci.FormatType = gls.UNSIGNED_BYTE
+		switch {
+		case bitCount == 32 && redMask == 0x000000FF:
+			ci.IFormat, ci.Format = gls.RGBA8, gls.RGBA
+		case bitCount == 32 && redMask == 0x00FF0000:
+			ci.IFormat, ci.Format = gls.RGBA8, gls.BGRA
+		case bitCount == 24 && redMask == 0x000000FF:
+			ci.IFormat, ci.Format = gls.RGB8, gls.RGB
+		case bitCount == 24 && redMask == 0x00FF0000:
+			ci.IFormat, ci.Format = gls.RGB8, gls.BGR
+		default:
+			return nil, fmt.Errorf("unsupported DDS RGB format: %d bits, red mask 0x%X", bitCount, redMask)
+		}
+		// Opaque 32 bit formats (X8R8G8B8) have no alpha
+		if bitCount == 32 && pfFlags&ddsPixelAlpha == 0 {
+			ci.IFormat = gls.RGB8
+		}
+	default:
+		return nil, fmt.Errorf("unsupported DDS pixel format flags: 0x%X", pfFlags)
+	}
+
+	// Reads mipmap levels of the first image
+	for level := 0; level < levels; level++ {
+		w, h := ci.LevelSize(level)
+		size := levelDataSize(ci.IFormat, pixelSize, w, h)
+		if size > len(data)-offset {
+			if level == 0 {
+				return nil, fmt.Errorf("DDS image data too short")
+			}
+			break
+		}
+		ci.Levels = append(ci.Levels, data[offset:offset+size])
+		offset += size
+	}
+	return ci, nil
+}
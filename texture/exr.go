@@ -0,0 +1,459 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package texture
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+)
+
+// OpenEXR version flags
+const (
+	exrFlagTiled     = 0x200
+	exrFlagNonImage  = 0x800
+	exrFlagMultipart = 0x1000
+)
+
+// OpenEXR compression methods
+const (
+	exrCompressionNone  = 0
+	exrCompressionRLE   = 1
+	exrCompressionZIPS  = 2
+	exrCompressionZIP   = 3
+	exrCompressionPXR24 = 5
+)
+
+// exrMaxRatio is the maximum ratio between the decompressed and the compressed
+// size of the blocks of each compression method: the zlib ratio, with 24 bit
+// floats for PXR24, and runs of 128 bytes in 2 bytes for RLE
+var exrMaxRatio = map[int]int{
+	exrCompressionNone:  1,
+	exrCompressionRLE:   64,
+	exrCompressionZIPS:  1032,
+	exrCompressionZIP:   1032,
+	exrCompressionPXR24: 1376,
+}
+
+// OpenEXR channel pixel types
+const (
+	exrPixelUint  = 0
+	exrPixelHalf  = 1
+	exrPixelFloat = 2
+)
+
+// exrChannel describes one channel of an OpenEXR image
+type exrChannel struct {
+	name      string
+	pixelType int32
+	xSampling int32
+	ySampling int32
+}
+
+// size returns the number of bytes of one value of the channel
+func (ch *exrChannel) size() int {
+
+	if ch.pixelType == exrPixelHalf {
+		return 2
+	}
+	return 4
+}
+
+// DecodeEXR decodes a single part scanline OpenEXR image from the specified
+// reader into a FloatImage with the R, G, B and optional A channels.
+// Luminance only images (Y channel) are expanded to RGB.
+// Supports the NONE, RLE, ZIPS, ZIP and PXR24 compression methods.
+func DecodeEXR(r io.Reader) (*FloatImage, error) {
+
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < 8 || !bytes.HasPrefix(data, exrMagic) {
+		return nil, fmt.Errorf("invalid EXR header")
+	}
+	le := binary.LittleEndian
+	version := le.Uint32(data[4:])
+	if version&0xFF != 2 {
+		return nil, fmt.Errorf("unsupported EXR version: %d", version&0xFF)
+	}
+	if version&(exrFlagTiled|exrFlagNonImage|exrFlagMultipart) != 0 {
+		return nil, fmt.Errorf("unsupported EXR file: only single part scanline images are supported")
+	}
+
+	// Reads header attributes
+	var channels []exrChannel
+	compression := -1
+	var dataWindow [4]int32
+	hasWindow := false
+	pos := 8
+	for {
+		name, err := exrString(data, &pos)
+		if err != nil {
+			return nil, err
+		}
+		if name == "" {
+			break
+		}
+		atype, err := exrString(data, &pos)
+		if err != nil {
+			return nil, err
+		}
+		if pos+4 > len(data) {
+			return nil, fmt.Errorf("invalid EXR attribute: %s", name)
+		}
+		size := int(int32(le.Uint32(data[pos:])))
+		pos += 4
+		if size < 0 || pos+size > len(data) {
+			return nil, fmt.Errorf("invalid EXR attribute size: %s", name)
+		}
+		value := data[pos : pos+size]
+		pos += size
+		switch {
+		case name == "channels" && atype == "chlist":
+			channels, err = exrChannels(value)
+			if err != nil {
+				return nil, err
+			}
+		case name == "compression" && atype == "compression" && size == 1:
+			compression = int(value[0])
+		case name == "dataWindow" && atype == "box2i" && size == 16:
+			for i := range dataWindow {
+				dataWindow[i] = int32(le.Uint32(value[i*4:]))
+			}
+			hasWindow = true
+		}
+	}
+	if len(channels) == 0 || compression < 0 || !hasWindow {
+		return nil, fmt.Errorf("invalid EXR header: missing required attributes")
+	}
+	for _, ch := range channels {
+		if ch.xSampling != 1 || ch.ySampling != 1 {
+			return nil, fmt.Errorf("unsupported EXR subsampled channel: %s", ch.name)
+		}
+	}
+	var linesPerBlock int
+	switch compression {
+	case exrCompressionNone, exrCompressionRLE, exrCompressionZIPS:
+		linesPerBlock = 1
+	case exrCompressionZIP, exrCompressionPXR24:
+		linesPerBlock = 16
+	default:
+		return nil, fmt.Errorf("unsupported EXR compression: %d", compression)
+	}
+
+	xMin, yMin := int(dataWindow[0]), int(dataWindow[1])
+	width := int(dataWindow[2]) - xMin + 1
+	height := int(dataWindow[3]) - yMin + 1
+	if width <= 0 || height <= 0 {
+		return nil, fmt.Errorf("invalid EXR data window")
+	}
+
+	// Maps the image channels to the output channels
+	dst := make([]int, len(channels))
+	outChannels := 3
+	hasColor := false
+	for i, ch := range channels {
+		dst[i] = -1
+		switch ch.name {
+		case "R":
+			dst[i] = 0
+			hasColor = true
+		case "G":
+			dst[i] = 1
+			hasColor = true
+		case "B":
+			dst[i] = 2
+			hasColor = true
+		case "A":
+			dst[i] = 3
+			outChannels = 4
+		}
+	}
+	gray := -1
+	if !hasColor {
+		for i, ch := range channels {
+			if ch.name == "Y" {
+				gray = i
+			}
+		}
+	}
+
+	// Line size and sum of channel sizes
+	pixelSize := 0
+	for _, ch := range channels {
+		pixelSize += ch.size()
+	}
+	lineSize := pixelSize * width
+
+	// The offset table and the block headers must fit in the data, and the
+	// data window must not be larger than the maximum decompressed size
+	// of the blocks, before allocating the image
+	if err := checkImageSize(width, height); err != nil {
+		return nil, err
+	}
+	blocks := (height + linesPerBlock - 1) / linesPerBlock
+	if blocks*16 > len(data)-pos {
+		return nil, fmt.Errorf("invalid EXR offset table")
+	}
+	if lineSize*height > (len(data)-pos-blocks*16)*exrMaxRatio[compression] {
+		return nil, fmt.Errorf("invalid EXR data window: data too short")
+	}
+	fi := NewFloatImage(width, height, outChannels)
+
+	// Reads the scanline blocks using the offset table
+	offsets := data[pos : pos+blocks*8]
+	for b := 0; b < blocks; b++ {
+		offset := le.Uint64(offsets[b*8:])
+		if offset+8 > uint64(len(data)) {
+			return nil, fmt.Errorf("invalid EXR block offset")
+		}
+		chunk := data[offset:]
+		y := int(int32(le.Uint32(chunk))) - yMin
+		size := int(int32(le.Uint32(chunk[4:])))
+		if y < 0 || y >= height || size < 0 || 8+size > len(chunk) {
+			return nil, fmt.Errorf("invalid EXR block: %d", b)
+		}
+		lines := linesPerBlock
+		if y+lines > height {
+			lines = height - y
+		}
+		block := chunk[8 : 8+size]
+		rawSize := lines * lineSize
+
+		// Blocks which would not be smaller when compressed are stored uncompressed
+		var raw []byte
+		if size == rawSize || compression == exrCompressionNone {
+			raw = block
+		} else {
+			switch compression {
+			case exrCompressionRLE:
+				raw, err = exrDecodeRLE(block, rawSize)
+			case exrCompressionZIPS, exrCompressionZIP:
+				raw, err = exrDecodeZIP(block, rawSize)
+			case exrCompressionPXR24:
+				raw, err = exrDecodePXR24(block, channels, width, lines)
+			}
+			if err != nil {
+				return nil, fmt.Errorf("EXR block %d: %v", b, err)
+			}
+		}
+		if len(raw) < rawSize {
+			return nil, fmt.Errorf("EXR block %d: data too short", b)
+		}
+
+		// Converts the channel values of each line
+		for l := 0; l < lines; l++ {
+			line := raw[l*lineSize:]
+			out := fi.Pix[(y+l)*width*outChannels:]
+			for ci, ch := range channels {
+				c := dst[ci]
+				if c < 0 && ci != gray {
+					line = line[width*ch.size():]
+					continue
+				}
+				for x := 0; x < width; x++ {
+					var v float32
+					switch ch.pixelType {
+					case exrPixelHalf:
+						v = halfToFloat32(le.Uint16(line[x*2:]))
+					case exrPixelFloat:
+						v = math.Float32frombits(le.Uint32(line[x*4:]))
+					default:
+						v = float32(le.Uint32(line[x*4:]))
+					}
+					if ci == gray {
+						out[x*outChannels] = v
+						out[x*outChannels+1] = v
+						out[x*outChannels+2] = v
+					} else {
+						out[x*outChannels+c] = v
+					}
+				}
+				line = line[width*ch.size():]
+			}
+		}
+	}
+	return fi, nil
+}
+
+// exrString reads a null terminated string at the specified position
+func exrString(data []byte, pos *int) (string, error) {
+
+	end := bytes.IndexByte(data[*pos:], 0)
+	if end < 0 {
+		return "", fmt.Errorf("invalid EXR header: unterminated string")
+	}
+	s := string(data[*pos : *pos+end])
+	*pos += end + 1
+	return s, nil
+}
+
+// exrChannels decodes the value of a channel list attribute
+func exrChannels(value []byte) ([]exrChannel, error) {
+
+	var channels []exrChannel
+	pos := 0
+	for {
+		name, err := exrString(value, &pos)
+		if err != nil {
+			return nil, err
+		}
+		if name == "" {
+			return channels, nil
+		}
+		if pos+16 > len(value) {
+			return nil, fmt.Errorf("invalid EXR channel list")
+		}
+		// Pixel type, linear flag and reserved bytes followed by the sampling
+		le := binary.LittleEndian
+		ch := exrChannel{
+			name:      name,
+			pixelType: int32(le.Uint32(value[pos:])),
+			xSampling: int32(le.Uint32(value[pos+8:])),
+			ySampling: int32(le.Uint32(value[pos+12:])),
+		}
+		if ch.pixelType < exrPixelUint || ch.pixelType > exrPixelFloat {
+			return nil, fmt.Errorf("invalid EXR channel pixel type: %d", ch.pixelType)
+		}
+		channels = append(channels, ch)
+		pos += 16
+	}
+}
+
+// exrDecodeRLE decompresses a RLE compressed block
+func exrDecodeRLE(src []byte, rawSize int) ([]byte, error) {
+
+	tmp := make([]byte, 0, rawSize)
+	for len(src) > 0 {
+		count := int(int8(src[0]))
+		if count < 0 {
+			// Literal bytes
+			if 1-count > len(src) {
+				return nil, fmt.Errorf("invalid RLE data")
+			}
+			tmp = append(tmp, src[1:1-count]...)
+			src = src[1-count:]
+		} else {
+			// Repeated byte
+			if len(src) < 2 {
+				return nil, fmt.Errorf("invalid RLE data")
+			}
+			for i := 0; i <= count; i++ {
+				tmp = append(tmp, src[1])
+			}
+			src = src[2:]
+		}
+		if len(tmp) > rawSize {
+			return nil, fmt.Errorf("RLE data overflow")
+		}
+	}
+	return exrUnpredict(tmp), nil
+}
+
+// exrDecodeZIP decompresses a ZIP or ZIPS compressed block
+func exrDecodeZIP(src []byte, rawSize int) ([]byte, error) {
+
+	zr, err := zlib.NewReader(bytes.NewReader(src))
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+	tmp := make([]byte, rawSize)
+	_, err = io.ReadFull(zr, tmp)
+	if err != nil {
+		return nil, err
+	}
+	return exrUnpredict(tmp), nil
+}
+
+// exrUnpredict reverses the delta predictor and the byte interleaving
+// applied by the RLE and ZIP compressors before compression.
+func exrUnpredict(tmp []byte) []byte {
+
+	for i := 1; i < len(tmp); i++ {
+		tmp[i] = tmp[i-1] + tmp[i] - 128
+	}
+	out := make([]byte, len(tmp))
+	half := (len(tmp) + 1) / 2
+	for i := range out {
+		if i%2 == 0 {
+			out[i] = tmp[i/2]
+		} else {
+			out[i] = tmp[half+i/2]
+		}
+	}
+	return out
+}
+
+// exrDecodePXR24 decompresses a PXR24 compressed block.
+// Each line of each channel is stored as delta encoded byte planes:
+// 2 planes for HALF, 3 planes for FLOAT (truncated to 24 bits) and 4 planes for UINT.
+func exrDecodePXR24(src []byte, channels []exrChannel, width, lines int) ([]byte, error) {
+
+	planes := 0
+	rawSize := 0
+	for _, ch := range channels {
+		switch ch.pixelType {
+		case exrPixelHalf:
+			planes += 2
+		case exrPixelFloat:
+			planes += 3
+		default:
+			planes += 4
+		}
+		rawSize += ch.size()
+	}
+	zr, err := zlib.NewReader(bytes.NewReader(src))
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+	tmp := make([]byte, planes*width*lines)
+	_, err = io.ReadFull(zr, tmp)
+	if err != nil {
+		return nil, err
+	}
+
+	le := binary.LittleEndian
+	out := make([]byte, rawSize*width*lines)
+	op := 0
+	for l := 0; l < lines; l++ {
+		for _, ch := range channels {
+			var pixel uint32
+			switch ch.pixelType {
+			case exrPixelHalf:
+				p0, p1 := tmp[:width], tmp[width:2*width]
+				tmp = tmp[2*width:]
+				for x := 0; x < width; x++ {
+					pixel += uint32(p0[x])<<8 | uint32(p1[x])
+					le.PutUint16(out[op:], uint16(pixel))
+					op += 2
+				}
+			case exrPixelFloat:
+				p0, p1, p2 := tmp[:width], tmp[width:2*width], tmp[2*width:3*width]
+				tmp = tmp[3*width:]
+				for x := 0; x < width; x++ {
+					pixel += uint32(p0[x])<<24 | uint32(p1[x])<<16 | uint32(p2[x])<<8
+					le.PutUint32(out[op:], pixel)
+					op += 4
+				}
+			default:
+				p0, p1, p2, p3 := tmp[:width], tmp[width:2*width], tmp[2*width:3*width], tmp[3*width:4*width]
+				tmp = tmp[4*width:]
+				for x := 0; x < width; x++ {
+					pixel += uint32(p0[x])<<24 | uint32(p1[x])<<16 | uint32(p2[x])<<8 | uint32(p3[x])
+					le.PutUint32(out[op:], pixel)
+					op += 4
+				}
+			}
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,180 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package texture
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"image"
+	"io"
+	"math"
+	"os"
+
+	"github.com/sansebasko/engine/gls"
+)
+
+// FloatImage is a high dynamic range image with float32 linear pixel values
+// decoded from a Radiance HDR or OpenEXR file.
+// Pixels are stored in row order from the top row with Channels values per pixel.
+type FloatImage struct {
+	Width    int       // width in pixels
+	Height   int       // height in pixels
+	Channels int       // number of values per pixel: 3 (RGB) or 4 (RGBA)
+	Pix      []float32 // pixel values
+}
+
+// NewFloatImage creates and returns a pointer to a new zeroed FloatImage
+// with the specified size and number of channels (3 or 4).
+func NewFloatImage(width, height, channels int) *FloatImage {
+
+	return &FloatImage{
+		Width:    width,
+		Height:   height,
+		Channels: channels,
+		Pix:      make([]float32, width*height*channels),
+	}
+}
+
+// At returns the values of the pixel at the specified position.
+// The returned slice shares the image data.
+func (fi *FloatImage) At(x, y int) []float32 {
+
+	i := (y*fi.Width + x) * fi.Channels
+	return fi.Pix[i : i+fi.Channels]
+}
+
+// RGBA returns a copy of this image converted to RGBA8.
+// Values are clamped to the [0, 1] range without tone mapping.
+func (fi *FloatImage) RGBA() *image.RGBA {
+
+	rgba := image.NewRGBA(image.Rect(0, 0, fi.Width, fi.Height))
+	for i := 0; i < fi.Width*fi.Height; i++ {
+		src := fi.Pix[i*fi.Channels : (i+1)*fi.Channels]
+		dst := rgba.Pix[i*4 : i*4+4]
+		dst[3] = 255
+		for c := 0; c < fi.Channels && c < 4; c++ {
+			v := src[c]
+			switch {
+			case v >= 1:
+				dst[c] = 255
+			case v > 0:
+				dst[c] = uint8(v*255 + 0.5)
+			default:
+				dst[c] = 0
+			}
+		}
+	}
+	return rgba
+}
+
+// Magic numbers of the supported floating point image files
+var (
+	hdrMagic = []byte("#?")
+	exrMagic = []byte{0x76, 0x2F, 0x31, 0x01}
+)
+
+// IsFloatImage returns if the specified file header is from one of the
+// supported floating point image formats: Radiance HDR or OpenEXR.
+func IsFloatImage(header []byte) bool {
+
+	return bytes.HasPrefix(header, hdrMagic) || bytes.HasPrefix(header, exrMagic)
+}
+
+// DecodeFloat decodes a Radiance HDR or OpenEXR image from the specified reader.
+func DecodeFloat(r io.Reader) (*FloatImage, error) {
+
+	br := bufio.NewReader(r)
+	header, _ := br.Peek(len(exrMagic))
+	switch {
+	case bytes.HasPrefix(header, hdrMagic):
+		return DecodeHDR(br)
+	case bytes.HasPrefix(header, exrMagic):
+		return DecodeEXR(br)
+	}
+	return nil, fmt.Errorf("unknown floating point image format")
+}
+
+// DecodeFloatFile decodes the specified Radiance HDR or OpenEXR image file.
+func DecodeFloatFile(filename string) (*FloatImage, error) {
+
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return DecodeFloat(file)
+}
+
+// isFloatFile returns if the specified file is a floating point image
+func isFloatFile(filename string) bool {
+
+	file, err := os.Open(filename)
+	if err != nil {
+		return false
+	}
+	defer file.Close()
+	header := make([]byte, len(exrMagic))
+	n, _ := io.ReadFull(file, header)
+	return IsFloatImage(header[:n])
+}
+
+// NewTexture2DFromFloat creates a new texture from the specified floating
+// point image using the specified internal format, which should be one of:
+// gls.RGB16F, gls.RGB32F, gls.RGBA16F or gls.RGBA32F.
+func NewTexture2DFromFloat(fi *FloatImage, iformat int) *Texture2D {
+
+	t := newTexture2D()
+	t.SetFromFloat(fi, iformat)
+	return t
+}
+
+// SetFromFloat sets the texture data from the specified floating point
+// image using the specified internal format.
+// The float32 pixels are converted by OpenGL to the internal format.
+func (t *Texture2D) SetFromFloat(fi *FloatImage, iformat int) {
+
+	format := gls.RGB
+	if fi.Channels == 4 {
+		format = gls.RGBA
+	}
+	t.SetData(fi.Width, fi.Height, format, gls.FLOAT, iformat, fi.Pix)
+	t.RGBA = nil
+}
+
+// floatFormat returns the default half float internal format for the specified image
+func floatFormat(fi *FloatImage) int {
+
+	if fi.Channels == 4 {
+		return gls.RGBA16F
+	}
+	return gls.RGB16F
+}
+
+// halfToFloat32 converts an IEEE 754 half precision value to float32
+func halfToFloat32(h uint16) float32 {
+
+	sign := uint32(h&0x8000) << 16
+	exp := uint32(h>>10) & 0x1F
+	mant := uint32(h & 0x3FF)
+	switch {
+	// Infinity and NaN
+	case exp == 0x1F:
+		return math.Float32frombits(sign | 0x7F800000 | mant<<13)
+	// Normalized
+	case exp != 0:
+		return math.Float32frombits(sign | (exp+112)<<23 | mant<<13)
+	// Zero
+	case mant == 0:
+		return math.Float32frombits(sign)
+	}
+	// Subnormal half values are normalized in float32
+	exp = 113
+	for mant&0x400 == 0 {
+		mant <<= 1
+		exp--
+	}
+	return math.Float32frombits(sign | exp<<23 | (mant&0x3FF)<<13)
+}
@@ -0,0 +1,140 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package texture
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io/ioutil"
+	"math"
+	"path/filepath"
+	"testing"
+)
+
+// Tests decoding of the reference HDR and EXR images in testdata.
+// Each image has a .f32 file with the expected little endian float32 pixels.
+func TestDecodeFloat(t *testing.T) {
+
+	tests := []struct {
+		file     string
+		width    int
+		height   int
+		channels int
+	}{
+		{"flat.hdr", 5, 3, 3},          // flat scanlines
+		{"rle.hdr", 23, 4, 3},          // new style run length encoding
+		{"oldrle.hdr", 12, 2, 3},       // old style run length encoding stored from the bottom
+		{"none_half.exr", 5, 3, 4},     // uncompressed RGBA half
+		{"none_y.exr", 4, 2, 3},        // uncompressed luminance float
+		{"rle_half.exr", 20, 3, 3},     // RLE RGB half with data window offset
+		{"zips_half.exr", 16, 3, 4},    // ZIPS RGBA half
+		{"zip_float.exr", 11, 20, 3},   // ZIP RGB float with partial last block
+		{"pxr24_mixed.exr", 12, 18, 3}, // PXR24 RG half and B float
+	}
+	for _, test := range tests {
+		fi, err := DecodeFloatFile(filepath.Join("testdata", test.file))
+		if err != nil {
+			t.Errorf("%s: %v", test.file, err)
+			continue
+		}
+		if fi.Width != test.width || fi.Height != test.height || fi.Channels != test.channels {
+			t.Errorf("%s: got %dx%dx%d, expected %dx%dx%d", test.file,
+				fi.Width, fi.Height, fi.Channels, test.width, test.height, test.channels)
+			continue
+		}
+		ref, err := ioutil.ReadFile(filepath.Join("testdata", test.file+".f32"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(ref) != len(fi.Pix)*4 {
+			t.Errorf("%s: got %d values, expected %d", test.file, len(fi.Pix), len(ref)/4)
+			continue
+		}
+		for i, v := range fi.Pix {
+			expected := binary.LittleEndian.Uint32(ref[i*4:])
+			if math.Float32bits(v) != expected {
+				t.Errorf("%s: value %d is %v, expected %v", test.file, i, v, math.Float32frombits(expected))
+				break
+			}
+		}
+	}
+}
+
+// Tests conversion of half precision values
+func TestHalfToFloat32(t *testing.T) {
+
+	tests := []struct {
+		half     uint16
+		expected uint32
+	}{
+		{0x0000, 0x00000000}, // +0
+		{0x8000, 0x80000000}, // -0
+		{0x3C00, 0x3F800000}, // 1
+		{0xC000, 0xC0000000}, // -2
+		{0x3555, 0x3EAAA000}, // 0.333251953125
+		{0x7BFF, 0x477FE000}, // 65504 (largest normal)
+		{0x0400, 0x38800000}, // 2^-14 (smallest normal)
+		{0x0001, 0x33800000}, // 2^-24 (smallest subnormal)
+		{0x03FF, 0x387FC000}, // largest subnormal
+		{0x7C00, 0x7F800000}, // +Inf
+		{0xFC00, 0xFF800000}, // -Inf
+		{0x7E00, 0x7FC00000}, // NaN
+	}
+	for _, test := range tests {
+		got := math.Float32bits(halfToFloat32(test.half))
+		if got != test.expected {
+			t.Errorf("half 0x%04X: got 0x%08X, expected 0x%08X", test.half, got, test.expected)
+		}
+	}
+}
+
+// exrFile returns an OpenEXR file with one float luminance channel,
+// the specified compression and data window, followed by the data
+func exrFile(compression byte, window [4]int32, data []byte) []byte {
+
+	var b bytes.Buffer
+	le := binary.LittleEndian
+	b.Write(exrMagic)
+	binary.Write(&b, le, uint32(2))
+	attr := func(name, atype string, value []byte) {
+		b.WriteString(name + "\x00" + atype + "\x00")
+		binary.Write(&b, le, uint32(len(value)))
+		b.Write(value)
+	}
+	var ch bytes.Buffer
+	ch.WriteString("Y\x00")
+	binary.Write(&ch, le, []uint32{exrPixelFloat, 0, 1, 1})
+	ch.WriteByte(0)
+	attr("channels", "chlist", ch.Bytes())
+	attr("compression", "compression", []byte{compression})
+	var box bytes.Buffer
+	binary.Write(&box, le, window)
+	attr("dataWindow", "box2i", box.Bytes())
+	b.WriteByte(0)
+	b.Write(data)
+	return b.Bytes()
+}
+
+// Tests that images with sizes larger than their data return errors
+// instead of allocating their sizes
+func TestDecodeFloatMalformed(t *testing.T) {
+
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"hdr size", []byte("#?RADIANCE\n\n-Y 100000000 +X 100000000\n")},
+		{"hdr data", []byte("#?RADIANCE\n\n-Y 60000 +X 60000\n\x02\x02\xEA\x60")},
+		{"exr size", exrFile(exrCompressionNone, [4]int32{0, 0, 1 << 30, 1 << 30}, make([]byte, 64))},
+		{"exr offset table", exrFile(exrCompressionZIP, [4]int32{0, 0, 0, 60000}, make([]byte, 64))},
+		{"exr data", exrFile(exrCompressionZIP, [4]int32{0, 0, 60000, 15}, make([]byte, 16+1000))},
+		{"exr block", exrFile(exrCompressionNone, [4]int32{0, 0, 0, 0}, make([]byte, 16))},
+	}
+	for _, test := range tests {
+		if _, err := DecodeFloat(bytes.NewReader(test.data)); err == nil {
+			t.Errorf("%s: no error", test.name)
+		}
+	}
+}
@@ -0,0 +1,193 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package texture
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+)
+
+// DecodeHDR decodes a Radiance HDR (RGBE) image from the specified reader
+// into a three channels FloatImage.
+// Supports flat, old style and new style run length encoded scanlines.
+func DecodeHDR(r io.Reader) (*FloatImage, error) {
+
+	br, ok := r.(*bufio.Reader)
+	if !ok {
+		br = bufio.NewReader(r)
+	}
+
+	// Reads the header lines up to the empty line
+	line, err := br.ReadString('\n')
+	if err != nil || !strings.HasPrefix(line, string(hdrMagic)) {
+		return nil, fmt.Errorf("invalid HDR header")
+	}
+	for {
+		line, err = br.ReadString('\n')
+		if err != nil {
+			return nil, fmt.Errorf("invalid HDR header: %v", err)
+		}
+		line = strings.TrimSpace(line)
+		if line == "" {
+			break
+		}
+		if strings.HasPrefix(line, "FORMAT=") && line != "FORMAT=32-bit_rle_rgbe" {
+			return nil, fmt.Errorf("unsupported HDR format: %s", line[len("FORMAT="):])
+		}
+	}
+
+	// Reads the resolution line: only images with horizontal scanlines are supported
+	line, err = br.ReadString('\n')
+	if err != nil {
+		return nil, fmt.Errorf("invalid HDR resolution: %v", err)
+	}
+	var ydir, xdir string
+	var width, height int
+	_, err = fmt.Sscanf(line, "%s %d %s %d", &ydir, &height, &xdir, &width)
+	if err != nil || width <= 0 || height <= 0 {
+		return nil, fmt.Errorf("invalid HDR resolution: %q", strings.TrimSpace(line))
+	}
+	if (ydir != "-Y" && ydir != "+Y") || xdir != "+X" {
+		return nil, fmt.Errorf("unsupported HDR orientation: %s %s", ydir, xdir)
+	}
+
+	if err := checkImageSize(width, height); err != nil {
+		return nil, err
+	}
+
+	// The pixels grow with each scanline read so that the header size of
+	// truncated files is not allocated
+	fi := &FloatImage{Width: width, Height: height, Channels: 3}
+	scanline := make([]byte, width*4)
+	row := make([]float32, width*3)
+	for y := 0; y < height; y++ {
+		err = readHDRScanline(br, scanline)
+		if err != nil {
+			return nil, fmt.Errorf("HDR scanline %d: %v", y, err)
+		}
+		for x := 0; x < width; x++ {
+			rgbeToFloat(scanline[x*4:x*4+4], row[x*3:x*3+3])
+		}
+		fi.Pix = append(fi.Pix, row...)
+	}
+	// Images stored from the bottom row are flipped
+	if ydir == "+Y" {
+		for y := 0; y < height/2; y++ {
+			top := fi.Pix[y*width*3 : (y+1)*width*3]
+			bottom := fi.Pix[(height-1-y)*width*3 : (height-y)*width*3]
+			for i := range top {
+				top[i], bottom[i] = bottom[i], top[i]
+			}
+		}
+	}
+	return fi, nil
+}
+
+// readHDRScanline reads one scanline of RGBE pixels
+func readHDRScanline(br *bufio.Reader, scanline []byte) error {
+
+	width := len(scanline) / 4
+	if width < 8 || width > 0x7FFF {
+		return readHDRFlat(br, scanline, 0)
+	}
+	_, err := io.ReadFull(br, scanline[:4])
+	if err != nil {
+		return err
+	}
+	// Scanlines not starting with the new style marker are flat or old style encoded
+	if scanline[0] != 2 || scanline[1] != 2 || scanline[2]&0x80 != 0 {
+		return readHDRFlat(br, scanline, 1)
+	}
+	if int(scanline[2])<<8|int(scanline[3]) != width {
+		return fmt.Errorf("invalid scanline width")
+	}
+
+	// New style: each component is run length encoded separately
+	for c := 0; c < 4; c++ {
+		for x := 0; x < width; {
+			count, err := br.ReadByte()
+			if err != nil {
+				return err
+			}
+			if count > 128 {
+				n := int(count) - 128
+				if x+n > width {
+					return fmt.Errorf("run length overflow")
+				}
+				v, err := br.ReadByte()
+				if err != nil {
+					return err
+				}
+				for ; n > 0; n-- {
+					scanline[x*4+c] = v
+					x++
+				}
+			} else {
+				n := int(count)
+				if n == 0 || x+n > width {
+					return fmt.Errorf("invalid literal count")
+				}
+				for ; n > 0; n-- {
+					v, err := br.ReadByte()
+					if err != nil {
+						return err
+					}
+					scanline[x*4+c] = v
+					x++
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// readHDRFlat reads the pixels of a flat or old style run length encoded
+// scanline starting at the specified pixel. The previous pixels must
+// have already been read.
+func readHDRFlat(br *bufio.Reader, scanline []byte, start int) error {
+
+	width := len(scanline) / 4
+	shift := uint(0)
+	for x := start; x < width; {
+		p := scanline[x*4 : x*4+4]
+		_, err := io.ReadFull(br, p)
+		if err != nil {
+			return err
+		}
+		// Old style run: repeats the previous pixel
+		if p[0] == 1 && p[1] == 1 && p[2] == 1 && x > 0 {
+			n := int(p[3]) << shift
+			if x+n > width {
+				return fmt.Errorf("run length overflow")
+			}
+			prev := scanline[(x-1)*4 : x*4]
+			for ; n > 0; n-- {
+				copy(scanline[x*4:x*4+4], prev)
+				x++
+			}
+			shift += 8
+			continue
+		}
+		shift = 0
+		x++
+	}
+	return nil
+}
+
+// rgbeToFloat converts a shared exponent RGBE pixel to float32 RGB
+func rgbeToFloat(rgbe []byte, rgb []float32) {
+
+	if rgbe[3] == 0 {
+		rgb[0], rgb[1], rgb[2] = 0, 0, 0
+		return
+	}
+	f := math.Ldexp(1, int(rgbe[3])-(128+8))
+	rgb[0] = float32(float64(rgbe[0]) * f)
+	rgb[1] = float32(float64(rgbe[1]) * f)
+	rgb[2] = float32(float64(rgbe[2]) * f)
+}
@@ -0,0 +1,297 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package texture
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+
+	"github.com/sansebasko/engine/gls"
+)
+
+// KTX and KTX2 container constants
+const (
+	ktx2SupercompressionNone     = 0
+	ktx2SupercompressionBasisLZ  = 1
+	ktx2SupercompressionZstd     = 2
+	ktx2SupercompressionZlib     = 3
+	ktx2HeaderSize               = 80
+	ktx2LevelIndexEntrySize      = 24
+	ktxHeaderSize                = 64
+	ktxEndianness                = 0x04030201
+	vkFormatUndefined            = 0
+	vkFormatASTC4x4UnormBlock    = 157
+	vkFormatASTC12x12SrgbBlock   = 184
+	ktx2ColorModelUASTC          = 166
+	ktx2DataFormatDescriptorSize = 24
+)
+
+// Maps Vulkan formats used by KTX2 to OpenGL formats:
+// internal format, pixel format, pixel type and pixel size (0 for compressed)
+var vkFormats = map[uint32][4]uint32{
+	9:   {gls.R8, gls.RED, gls.UNSIGNED_BYTE, 1},                  // R8_UNORM
+	16:  {gls.RG8, gls.RG, gls.UNSIGNED_BYTE, 2},                  // R8G8_UNORM
+	23:  {gls.RGB8, gls.RGB, gls.UNSIGNED_BYTE, 3},                // R8G8B8_UNORM
+	29:  {gls.SRGB8, gls.RGB, gls.UNSIGNED_BYTE, 3},               // R8G8B8_SRGB
+	37:  {gls.RGBA8, gls.RGBA, gls.UNSIGNED_BYTE, 4},              // R8G8B8A8_UNORM
+	43:  {gls.SRGB8_ALPHA8, gls.RGBA, gls.UNSIGNED_BYTE, 4},       // R8G8B8A8_SRGB
+	44:  {gls.RGBA8, gls.BGRA, gls.UNSIGNED_BYTE, 4},              // B8G8R8A8_UNORM
+	50:  {gls.SRGB8_ALPHA8, gls.BGRA, gls.UNSIGNED_BYTE, 4},       // B8G8R8A8_SRGB
+	97:  {gls.RGBA16F, gls.RGBA, gls.HALF_FLOAT, 8},               // R16G16B16A16_SFLOAT
+	109: {gls.RGBA32F, gls.RGBA, gls.FLOAT, 16},                   // R32G32B32A32_SFLOAT
+	131: {gls.COMPRESSED_RGB_S3TC_DXT1_EXT, 0, 0, 0},              // BC1_RGB_UNORM_BLOCK
+	132: {gls.COMPRESSED_SRGB_S3TC_DXT1_EXT, 0, 0, 0},             // BC1_RGB_SRGB_BLOCK
+	133: {gls.COMPRESSED_RGBA_S3TC_DXT1_EXT, 0, 0, 0},             // BC1_RGBA_UNORM_BLOCK
+	134: {gls.COMPRESSED_SRGB_ALPHA_S3TC_DXT1_EXT, 0, 0, 0},       // BC1_RGBA_SRGB_BLOCK
+	135: {gls.COMPRESSED_RGBA_S3TC_DXT3_EXT, 0, 0, 0},             // BC2_UNORM_BLOCK
+	136: {gls.COMPRESSED_SRGB_ALPHA_S3TC_DXT3_EXT, 0, 0, 0},       // BC2_SRGB_BLOCK
+	137: {gls.COMPRESSED_RGBA_S3TC_DXT5_EXT, 0, 0, 0},             // BC3_UNORM_BLOCK
+	138: {gls.COMPRESSED_SRGB_ALPHA_S3TC_DXT5_EXT, 0, 0, 0},       // BC3_SRGB_BLOCK
+	139: {gls.COMPRESSED_RED_RGTC1, 0, 0, 0},                      // BC4_UNORM_BLOCK
+	140: {gls.COMPRESSED_SIGNED_RED_RGTC1, 0, 0, 0},               // BC4_SNORM_BLOCK
+	141: {gls.COMPRESSED_RG_RGTC2, 0, 0, 0},                       // BC5_UNORM_BLOCK
+	142: {gls.COMPRESSED_SIGNED_RG_RGTC2, 0, 0, 0},                // BC5_SNORM_BLOCK
+	143: {gls.COMPRESSED_RGB_BPTC_UNSIGNED_FLOAT, 0, 0, 0},        // BC6H_UFLOAT_BLOCK
+	144: {gls.COMPRESSED_RGB_BPTC_SIGNED_FLOAT, 0, 0, 0},          // BC6H_SFLOAT_BLOCK
+	145: {gls.COMPRESSED_RGBA_BPTC_UNORM, 0, 0, 0},                // BC7_UNORM_BLOCK
+	146: {gls.COMPRESSED_SRGB_ALPHA_BPTC_UNORM, 0, 0, 0},          // BC7_SRGB_BLOCK
+	147: {gls.COMPRESSED_RGB8_ETC2, 0, 0, 0},                      // ETC2_R8G8B8_UNORM_BLOCK
+	148: {gls.COMPRESSED_SRGB8_ETC2, 0, 0, 0},                     // ETC2_R8G8B8_SRGB_BLOCK
+	149: {gls.COMPRESSED_RGB8_PUNCHTHROUGH_ALPHA1_ETC2, 0, 0, 0},  // ETC2_R8G8B8A1_UNORM_BLOCK
+	150: {gls.COMPRESSED_SRGB8_PUNCHTHROUGH_ALPHA1_ETC2, 0, 0, 0}, // ETC2_R8G8B8A1_SRGB_BLOCK
+	151: {gls.COMPRESSED_RGBA8_ETC2_EAC, 0, 0, 0},                 // ETC2_R8G8B8A8_UNORM_BLOCK
+	152: {gls.COMPRESSED_SRGB8_ALPHA8_ETC2_EAC, 0, 0, 0},          // ETC2_R8G8B8A8_SRGB_BLOCK
+	153: {gls.COMPRESSED_R11_EAC, 0, 0, 0},                        // EAC_R11_UNORM_BLOCK
+	154: {gls.COMPRESSED_SIGNED_R11_EAC, 0, 0, 0},                 // EAC_R11_SNORM_BLOCK
+	155: {gls.COMPRESSED_RG11_EAC, 0, 0, 0},                       // EAC_R11G11_UNORM_BLOCK
+	156: {gls.COMPRESSED_SIGNED_RG11_EAC, 0, 0, 0},                // EAC_R11G11_SNORM_BLOCK
+}
+
+// DecodeKTX decodes a KTX version 1 container from the specified reader.
+// Only the first array element and face are decoded.
+func DecodeKTX(r io.Reader) (*CompressedImage, error) {
+
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < ktxHeaderSize || !bytes.HasPrefix(data, ktxMagic) {
+		return nil, fmt.Errorf("invalid KTX header")
+	}
+
+	// Header fields may be in big endian order
+	var order binary.ByteOrder = binary.LittleEndian
+	if order.Uint32(data[12:]) != ktxEndianness {
+		order = binary.BigEndian
+		if order.Uint32(data[12:]) != ktxEndianness {
+			return nil, fmt.Errorf("invalid KTX endianness")
+		}
+	}
+	field := func(i int) uint32 { return order.Uint32(data[16+4*i:]) }
+	glType := field(0)
+	glTypeSize := field(1)
+	glFormat := field(2)
+	glInternalFormat := field(3)
+	width := field(5)
+	height := field(6)
+	arrayElements := field(8)
+	faces := field(9)
+	levels := field(10)
+	kvSize := field(11)
+
+	if field(7) > 1 {
+		return nil, fmt.Errorf("KTX 3D textures not supported")
+	}
+	ci := &CompressedImage{Width: int(width), Height: int(height), IFormat: glInternalFormat}
+	if height == 0 {
+		ci.Height = 1
+	}
+	if glType != 0 {
+		ci.Format = glFormat
+		ci.FormatType = glType
+	}
+	if levels == 0 {
+		levels = 1
+	}
+	if arrayElements == 0 {
+		arrayElements = 1
+	}
+	if faces == 0 {
+		faces = 1
+	}
+	if err := checkImageSize(ci.Width, ci.Height); err != nil {
+		return nil, err
+	}
+
+	offset := ktxHeaderSize + int(kvSize)
+	for level := 0; level < int(levels); level++ {
+		if offset+4 > len(data) {
+			return nil, fmt.Errorf("KTX image data too short")
+		}
+		// Size of the level except for non-array cube maps where it is the size of one face
+		imageSize := int(order.Uint32(data[offset:]))
+		offset += 4
+		faceSize := imageSize
+		levelSize := imageSize
+		if faces == 6 && arrayElements == 1 {
+			levelSize = 6 * ((imageSize + 3) &^ 3)
+		} else {
+			faceSize = imageSize / (int(arrayElements) * int(faces))
+		}
+		if levelSize > len(data)-offset {
+			return nil, fmt.Errorf("KTX image data too short")
+		}
+		levelData := data[offset : offset+faceSize]
+		// Swaps the bytes of multi byte pixel types in big endian files
+		if order == binary.BigEndian && glTypeSize > 1 {
+			levelData = swapBytes(levelData, int(glTypeSize))
+		}
+		ci.Levels = append(ci.Levels, levelData)
+		offset += (levelSize + 3) &^ 3
+	}
+	return ci, nil
+}
+
+// swapBytes returns a copy of the specified data with the byte order
+// of each element of the specified size reversed.
+func swapBytes(data []byte, size int) []byte {
+
+	res := make([]byte, len(data))
+	for i := 0; i+size <= len(data); i += size {
+		for j := 0; j < size; j++ {
+			res[i+j] = data[i+size-1-j]
+		}
+	}
+	return res
+}
+
+// ErrBasisUniversal is returned by DecodeKTX2 for Basis Universal payloads
+var ErrBasisUniversal = errors.New("KTX2 Basis Universal (ETC1S/UASTC) transcoding not supported")
+
+// DecodeKTX2 decodes a KTX version 2 container from the specified reader.
+// Supports block compressed and uncompressed Vulkan formats without
+// supercompression or with Zstandard or zlib supercompression.
+// Basis Universal payloads (BasisLZ/ETC1S and UASTC) are not transcoded and
+// return ErrBasisUniversal, so these files, which include most KTX2 images of
+// glTF models with the KHR_texture_basisu extension, must be converted to a
+// GPU block format such as BC7 or ASTC to be loaded. Only the first layer and face are decoded.
+func DecodeKTX2(r io.Reader) (*CompressedImage, error) {
+
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < ktx2HeaderSize || !bytes.HasPrefix(data, ktx2Magic) {
+		return nil, fmt.Errorf("invalid KTX2 header")
+	}
+	le := binary.LittleEndian
+	vkFormat := le.Uint32(data[12:])
+	width := le.Uint32(data[20:])
+	height := le.Uint32(data[24:])
+	depth := le.Uint32(data[28:])
+	layers := le.Uint32(data[32:])
+	faces := le.Uint32(data[36:])
+	levels := le.Uint32(data[40:])
+	scheme := le.Uint32(data[44:])
+	dfdOffset := le.Uint32(data[48:])
+
+	if depth > 1 {
+		return nil, fmt.Errorf("KTX2 3D textures not supported")
+	}
+	switch scheme {
+	case ktx2SupercompressionNone, ktx2SupercompressionZstd, ktx2SupercompressionZlib:
+	case ktx2SupercompressionBasisLZ:
+		return nil, ErrBasisUniversal
+	default:
+		return nil, fmt.Errorf("unsupported KTX2 supercompression scheme: %d", scheme)
+	}
+
+	ci := &CompressedImage{Width: int(width), Height: int(height)}
+	if height == 0 {
+		ci.Height = 1
+	}
+	if err := checkImageSize(ci.Width, ci.Height); err != nil {
+		return nil, err
+	}
+	pixelSize := 0
+	switch {
+	case vkFormat == vkFormatUndefined:
+		// Undefined format with the UASTC color model is a Basis Universal payload
+		if int(dfdOffset)+ktx2DataFormatDescriptorSize <= len(data) && data[dfdOffset+12] == ktx2ColorModelUASTC {
+			return nil, ErrBasisUniversal
+		}
+		return nil, fmt.Errorf("KTX2 undefined format not supported")
+	case vkFormat >= vkFormatASTC4x4UnormBlock && vkFormat <= vkFormatASTC12x12SrgbBlock:
+		// ASTC formats alternate between UNORM and SRGB for each block size
+		idx := (vkFormat - vkFormatASTC4x4UnormBlock) / 2
+		if (vkFormat-vkFormatASTC4x4UnormBlock)%2 == 0 {
+			ci.IFormat = gls.COMPRESSED_RGBA_ASTC_4x4_KHR + idx
+		} else {
+			ci.IFormat = gls.COMPRESSED_SRGB8_ALPHA8_ASTC_4x4_KHR + idx
+		}
+	default:
+		f, ok := vkFormats[vkFormat]
+		if !ok {
+			return nil, fmt.Errorf("unsupported KTX2 Vulkan format: %d", vkFormat)
+		}
+		ci.IFormat, ci.Format, ci.FormatType, pixelSize = f[0], f[1], f[2], int(f[3])
+	}
+
+	if levels == 0 {
+		levels = 1
+	}
+	if layers == 0 {
+		layers = 1
+	}
+	images := int(layers * faces)
+	if images == 0 {
+		images = 1
+	}
+	if ktx2HeaderSize+int(levels)*ktx2LevelIndexEntrySize > len(data) {
+		return nil, fmt.Errorf("invalid KTX2 level index")
+	}
+	for level := 0; level < int(levels); level++ {
+		entry := data[ktx2HeaderSize+level*ktx2LevelIndexEntrySize:]
+		offset := le.Uint64(entry[0:])
+		length := le.Uint64(entry[8:])
+		if offset > uint64(len(data)) || length > uint64(len(data))-offset {
+			return nil, fmt.Errorf("KTX2 level %d data out of range", level)
+		}
+		levelData := data[offset : offset+length]
+		// Decompresses only the data of the first layer and face
+		w, h := ci.LevelSize(level)
+		size := levelDataSize(ci.IFormat, pixelSize, w, h)
+		if scheme == ktx2SupercompressionZstd {
+			levelData, err = zstdDecompress(levelData, size)
+			if err != nil {
+				return nil, err
+			}
+		} else if scheme == ktx2SupercompressionZlib {
+			zr, err := zlib.NewReader(bytes.NewReader(levelData))
+			if err != nil {
+				return nil, err
+			}
+			levelData, err = ioutil.ReadAll(io.LimitReader(zr, int64(size)))
+			zr.Close()
+			if err != nil {
+				return nil, err
+			}
+		}
+		// Keeps only the first layer and face
+		if len(levelData) < size {
+			return nil, fmt.Errorf("KTX2 level %d data too short", level)
+		}
+		if images > 1 {
+			levelData = levelData[:size]
+		}
+		ci.Levels = append(ci.Levels, levelData)
+	}
+	return ci, nil
+}
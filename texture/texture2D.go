@@ -19,25 +19,26 @@ import (
 
 // Texture2D represents a texture
 type Texture2D struct {
-	gs           *gls.GLS    // Pointer to OpenGL state
-	refcount     int         // Current number of references
-	texname      uint32      // Texture handle
-	magFilter    uint32      // magnification filter
-	minFilter    uint32      // minification filter
-	wrapS        uint32      // wrap mode for s coordinate
-	wrapT        uint32      // wrap mode for t coordinate
-	iformat      int32       // internal format
-	width        int32       // texture width in pixels
-	height       int32       // texture height in pixels
-	format       uint32      // format of the pixel data
-	formatType   uint32      // type of the pixel data
-	updateData   bool        // texture data needs to be sent
-	updateParams bool        // texture parameters needs to be sent
-	genMipmap    bool        // generate mipmaps flag
-	data         interface{} // array with texture data
-	uniUnit      gls.Uniform // Texture unit uniform location cache
-	uniInfo      gls.Uniform // Texture info uniform location cache
-	udata        struct {    // Combined uniform data in 3 vec2:
+	gs           *gls.GLS         // Pointer to OpenGL state
+	refcount     int              // Current number of references
+	texname      uint32           // Texture handle
+	magFilter    uint32           // magnification filter
+	minFilter    uint32           // minification filter
+	wrapS        uint32           // wrap mode for s coordinate
+	wrapT        uint32           // wrap mode for t coordinate
+	iformat      int32            // internal format
+	width        int32            // texture width in pixels
+	height       int32            // texture height in pixels
+	format       uint32           // format of the pixel data
+	formatType   uint32           // type of the pixel data
+	updateData   bool             // texture data needs to be sent
+	updateParams bool             // texture parameters needs to be sent
+	genMipmap    bool             // generate mipmaps flag
+	data         interface{}      // array with texture data
+	cimage       *CompressedImage // compressed image data with mipmap levels
+	uniUnit      gls.Uniform      // Texture unit uniform location cache
+	uniInfo      gls.Uniform      // Texture info uniform location cache
+	udata        struct {         // Combined uniform data in 3 vec2:
 		offsetX float32
 		offsetY float32
 		repeatX float32
@@ -74,9 +75,29 @@ func newTexture2D() *Texture2D {
 
 // NewTexture2DFromImage creates and returns a pointer to a new Texture2D
 // using the specified image file as data.
-// Supported image formats are: PNG, JPEG and GIF.
+// Supported image formats are: PNG, JPEG, GIF and the DDS, KTX and KTX2
+// containers, whose mipmap levels are kept in their compressed format.
+// Radiance HDR and OpenEXR images are kept as half float textures.
 func NewTexture2DFromImage(imgfile string) (*Texture2D, error) {
 
+	// Compressed containers keep their own format and mipmaps
+	if isCompressedFile(imgfile) {
+		ci, err := DecodeCompressedFile(imgfile)
+		if err != nil {
+			return nil, err
+		}
+		return NewTexture2DFromCompressed(ci), nil
+	}
+
+	// High dynamic range images keep their floating point values
+	if isFloatFile(imgfile) {
+		fi, err := DecodeFloatFile(imgfile)
+		if err != nil {
+			return nil, err
+		}
+		return NewTexture2DFromFloat(fi, floatFormat(fi)), nil
+	}
+
 	// Decodes image file into RGBA8
 	rgba, err := DecodeImage(imgfile)
 	if err != nil {
@@ -161,6 +182,23 @@ func (t *Texture2D) GetUniformNames() (sampler, info string) {
 // SetImage sets a new image for this texture
 func (t *Texture2D) SetImage(imgfile string) error {
 
+	if isCompressedFile(imgfile) {
+		ci, err := DecodeCompressedFile(imgfile)
+		if err != nil {
+			return err
+		}
+		t.SetCompressed(ci)
+		return nil
+	}
+	if isFloatFile(imgfile) {
+		fi, err := DecodeFloatFile(imgfile)
+		if err != nil {
+			return err
+		}
+		t.SetFromFloat(fi, floatFormat(fi))
+		return nil
+	}
+
 	// Decodes image file into RGBA8
 	rgba, err := DecodeImage(imgfile)
 	if err != nil {
@@ -193,6 +231,7 @@ func (t *Texture2D) SetData(width, height int, format int, formatType, iformat i
 	t.formatType = uint32(formatType)
 	t.iformat = int32(iformat)
 	t.data = data
+	t.cimage = nil
 	t.updateData = true
 }
 
@@ -303,9 +342,26 @@ func (t *Texture2D) UpdateData() {
 }
 
 // DecodeImage reads and decodes the specified image file into RGBA8.
-// The supported image files are PNG, JPEG and GIF.
+// The supported image files are PNG, JPEG and GIF. The base level of
+// DDS, KTX and KTX2 containers is decompressed if possible and the values
+// of Radiance HDR and OpenEXR images are clamped.
 func DecodeImage(imgfile string) (*image.RGBA, error) {
 
+	if isCompressedFile(imgfile) {
+		ci, err := DecodeCompressedFile(imgfile)
+		if err != nil {
+			return nil, err
+		}
+		return ci.RGBA(0)
+	}
+	if isFloatFile(imgfile) {
+		fi, err := DecodeFloatFile(imgfile)
+		if err != nil {
+			return nil, err
+		}
+		return fi.RGBA(), nil
+	}
+
 	// Open image file
 	file, err := os.Open(imgfile)
 	if err != nil {
@@ -368,6 +424,10 @@ func (t *Texture2D) RenderSetup(gs *gls.GLS, slotIdx, uniIdx int) { // Could hav
 	gs.BindTexture(gls.TEXTURE_2D, t.texname)
 
 	// Transfer texture data to OpenGL if necessary
+	if t.updateData && t.cimage != nil {
+		t.uploadCompressed(gs)
+		t.updateData = false
+	}
 	if t.updateData {
 		gs.TexImage2D(
 			gls.TEXTURE_2D, // texture type
@@ -384,6 +444,7 @@ func (t *Texture2D) RenderSetup(gs *gls.GLS, slotIdx, uniIdx int) { // Could hav
 		if t.genMipmap {
 			gs.GenerateMipmap(gls.TEXTURE_2D)
 		}
+		gs.TexParameteri(gls.TEXTURE_2D, gls.TEXTURE_MAX_LEVEL, defaultMaxLevel)
 		// No data to send
 		t.updateData = false
 	}
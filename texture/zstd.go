@@ -0,0 +1,869 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package texture
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+// This file implements a decoder of Zstandard frames (RFC 8878), which is used
+// for the Zstandard supercompression of KTX2 containers. Dictionaries are not
+// supported and the content checksums are not verified.
+
+// Zstandard constants
+const (
+	zstdMagic          = 0xFD2FB528
+	zstdSkippableMagic = 0x184D2A50 // the 4 least significant bits are user defined
+	zstdMaxBlockSize   = 128 << 10
+	zstdMaxHuffmanBits = 11
+	zstdMaxHuffmanLog  = 6  // accuracy of the FSE table of the Huffman weights
+	zstdMaxLLLog       = 9  // maximum accuracy of the literals lengths FSE table
+	zstdMaxMLLog       = 9  // maximum accuracy of the match lengths FSE table
+	zstdMaxOFLog       = 8  // maximum accuracy of the offsets FSE table
+	zstdMaxLLCode      = 35 // maximum literals length code
+	zstdMaxMLCode      = 52 // maximum match length code
+	zstdMaxOFCode      = 31 // maximum offset code
+)
+
+// errZstd is returned for invalid or unsupported Zstandard data
+var errZstd = errors.New("invalid Zstandard data")
+
+// Predefined distributions of the literals lengths, match lengths and offsets codes
+var (
+	zstdLLDefault = []int16{4, 3, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 1, 1, 1, 2, 2, 2, 2, 2, 2, 2, 2, 2, 3, 2, 1, 1, 1, 1, 1, -1, -1, -1, -1}
+	zstdMLDefault = []int16{1, 4, 3, 2, 2, 2, 2, 2, 2, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, -1, -1, -1, -1, -1, -1, -1}
+	zstdOFDefault = []int16{1, 1, 1, 1, 1, 1, 2, 2, 2, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, -1, -1, -1, -1, -1}
+)
+
+// Baselines and numbers of extra bits of the literals length codes 16 to 35
+// and of the match length codes 32 to 52. Lower codes have no extra bits.
+var (
+	zstdLLBase = [...]uint32{16, 18, 20, 22, 24, 28, 32, 40, 48, 64, 128, 256, 512, 1024, 2048, 4096, 8192, 16384, 32768, 65536}
+	zstdLLBits = [...]uint8{1, 1, 1, 1, 2, 2, 3, 3, 4, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16}
+	zstdMLBase = [...]uint32{35, 37, 39, 41, 43, 47, 51, 59, 67, 83, 99, 131, 259, 515, 1027, 2051, 4099, 8195, 16387, 32771, 65539}
+	zstdMLBits = [...]uint8{1, 1, 1, 1, 2, 2, 3, 3, 4, 4, 5, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16}
+)
+
+// zstdDecompress decompresses the Zstandard frames of the specified data
+// returning at most the specified number of bytes.
+func zstdDecompress(src []byte, limit int) ([]byte, error) {
+
+	d := &zstdDecoder{out: make([]byte, 0, minInt(limit, 4*len(src))), limit: limit}
+	for len(src) > 0 && len(d.out) < limit {
+		if len(src) < 4 {
+			return nil, errZstd
+		}
+		magic := binary.LittleEndian.Uint32(src)
+		if magic&^0xF == zstdSkippableMagic {
+			if len(src) < 8 {
+				return nil, errZstd
+			}
+			size := uint64(binary.LittleEndian.Uint32(src[4:]))
+			if size > uint64(len(src)-8) {
+				return nil, errZstd
+			}
+			src = src[8+size:]
+			continue
+		}
+		if magic != zstdMagic {
+			return nil, errZstd
+		}
+		n, err := d.frame(src[4:])
+		if err != nil {
+			return nil, err
+		}
+		src = src[4+n:]
+	}
+	if len(d.out) > limit {
+		d.out = d.out[:limit]
+	}
+	return d.out, nil
+}
+
+// zstdDecoder contains the state of the decoder of the frames of a Zstandard stream
+type zstdDecoder struct {
+	out       []byte       // decompressed data of all the frames
+	limit     int          // number of bytes after which decoding stops
+	start     int          // offset of the current frame in the decompressed data
+	rep       [3]int       // repeated offsets
+	huffman   zstdHuffman  // Huffman table of the last compressed literals
+	ll        zstdFSETable // literals lengths table of the last sequences
+	ml        zstdFSETable // match lengths table of the last sequences
+	of        zstdFSETable // offsets table of the last sequences
+	literals  []byte       // literals of the current block
+	sequences []zstdSequence
+}
+
+// zstdSequence is one decoded sequence of a compressed block
+type zstdSequence struct {
+	ll, ml, offset int
+}
+
+// frame decodes one frame starting after its magic number
+// returning the number of bytes read.
+func (d *zstdDecoder) frame(src []byte) (int, error) {
+
+	if len(src) < 1 {
+		return 0, errZstd
+	}
+	desc := src[0]
+	fcsFlag := desc >> 6
+	single := desc&0x20 != 0
+	checksum := desc&0x04 != 0
+	dictFlag := desc & 0x03
+	if desc&0x08 != 0 {
+		return 0, errZstd
+	}
+	pos := 1
+	if !single {
+		pos++
+	}
+	if dictFlag != 0 {
+		dictSize := [4]int{0, 1, 2, 4}[dictFlag]
+		if pos+dictSize > len(src) {
+			return 0, errZstd
+		}
+		for i := 0; i < dictSize; i++ {
+			if src[pos+i] != 0 {
+				return 0, errors.New("Zstandard dictionaries not supported")
+			}
+		}
+		pos += dictSize
+	}
+	fcsSize := [4]int{0, 2, 4, 8}[fcsFlag]
+	if fcsFlag == 0 && single {
+		fcsSize = 1
+	}
+	pos += fcsSize
+	if pos > len(src) {
+		return 0, errZstd
+	}
+
+	// Blocks
+	d.start = len(d.out)
+	d.rep = [3]int{1, 4, 8}
+	d.huffman.bits = 0
+	d.ll.log, d.ml.log, d.of.log = -1, -1, -1
+	last := false
+	for !last && len(d.out) < d.limit {
+		if pos+3 > len(src) {
+			return 0, errZstd
+		}
+		hdr := int(src[pos]) | int(src[pos+1])<<8 | int(src[pos+2])<<16
+		pos += 3
+		last = hdr&1 != 0
+		size := hdr >> 3
+		switch (hdr >> 1) & 3 {
+		case 0:
+			if size > len(src)-pos {
+				return 0, errZstd
+			}
+			d.out = append(d.out, src[pos:pos+size]...)
+			pos += size
+		case 1:
+			if pos >= len(src) || size > zstdMaxBlockSize {
+				return 0, errZstd
+			}
+			for i := 0; i < size; i++ {
+				d.out = append(d.out, src[pos])
+			}
+			pos++
+		case 2:
+			if size > len(src)-pos || size > zstdMaxBlockSize {
+				return 0, errZstd
+			}
+			if err := d.block(src[pos : pos+size]); err != nil {
+				return 0, err
+			}
+			pos += size
+		default:
+			return 0, errZstd
+		}
+	}
+	if last && checksum {
+		if pos+4 > len(src) {
+			return 0, errZstd
+		}
+		pos += 4
+	}
+	return pos, nil
+}
+
+// block decodes one compressed block
+func (d *zstdDecoder) block(src []byte) error {
+
+	n, err := d.decodeLiterals(src)
+	if err != nil {
+		return err
+	}
+	if err := d.decodeSequences(src[n:]); err != nil {
+		return err
+	}
+
+	// Executes the sequences
+	lits := d.literals
+	for _, seq := range d.sequences {
+		if seq.ll > len(lits) {
+			return errZstd
+		}
+		d.out = append(d.out, lits[:seq.ll]...)
+		lits = lits[seq.ll:]
+		if seq.offset > len(d.out)-d.start {
+			return errZstd
+		}
+		// Copies byte by byte because the match may overlap its output
+		from := len(d.out) - seq.offset
+		for i := 0; i < seq.ml; i++ {
+			d.out = append(d.out, d.out[from+i])
+		}
+		if len(d.out) > d.limit+zstdMaxBlockSize {
+			return errZstd
+		}
+	}
+	d.out = append(d.out, lits...)
+	return nil
+}
+
+// decodeLiterals decodes the literals section of a compressed block
+// returning the size of the section.
+func (d *zstdDecoder) decodeLiterals(src []byte) (int, error) {
+
+	if len(src) < 1 {
+		return 0, errZstd
+	}
+	ltype := src[0] & 3
+	sizeFormat := (src[0] >> 2) & 3
+
+	// Raw and RLE literals
+	if ltype < 2 {
+		var size, hsize int
+		switch sizeFormat {
+		case 0, 2:
+			size, hsize = int(src[0]>>3), 1
+		case 1:
+			if len(src) < 2 {
+				return 0, errZstd
+			}
+			size, hsize = int(src[0]>>4)|int(src[1])<<4, 2
+		default:
+			if len(src) < 3 {
+				return 0, errZstd
+			}
+			size, hsize = int(src[0]>>4)|int(src[1])<<4|int(src[2])<<12, 3
+		}
+		if size > zstdMaxBlockSize {
+			return 0, errZstd
+		}
+		if ltype == 0 {
+			if size > len(src)-hsize {
+				return 0, errZstd
+			}
+			d.literals = append(d.literals[:0], src[hsize:hsize+size]...)
+			return hsize + size, nil
+		}
+		if hsize >= len(src) {
+			return 0, errZstd
+		}
+		d.literals = d.literals[:0]
+		for i := 0; i < size; i++ {
+			d.literals = append(d.literals, src[hsize])
+		}
+		return hsize + 1, nil
+	}
+
+	// Huffman compressed literals with a new or the previous table
+	var regen, comp, hsize int
+	streams := 4
+	switch sizeFormat {
+	case 0, 1:
+		if len(src) < 3 {
+			return 0, errZstd
+		}
+		if sizeFormat == 0 {
+			streams = 1
+		}
+		v := int(src[0]>>4) | int(src[1])<<4 | int(src[2])<<12
+		regen, comp, hsize = v&0x3FF, v>>10, 3
+	case 2:
+		if len(src) < 4 {
+			return 0, errZstd
+		}
+		v := int(src[0]>>4) | int(src[1])<<4 | int(src[2])<<12 | int(src[3])<<20
+		regen, comp, hsize = v&0x3FFF, v>>14, 4
+	default:
+		if len(src) < 5 {
+			return 0, errZstd
+		}
+		v := int(src[0]>>4) | int(src[1])<<4 | int(src[2])<<12 | int(src[3])<<20 | int(src[4])<<28
+		regen, comp, hsize = v&0x3FFFF, v>>18, 5
+	}
+	if regen > zstdMaxBlockSize || comp > len(src)-hsize {
+		return 0, errZstd
+	}
+	data := src[hsize : hsize+comp]
+	if ltype == 2 {
+		n, err := d.huffman.read(data)
+		if err != nil {
+			return 0, err
+		}
+		data = data[n:]
+	} else if d.huffman.bits == 0 {
+		return 0, errZstd
+	}
+
+	if cap(d.literals) < regen {
+		d.literals = make([]byte, regen)
+	}
+	d.literals = d.literals[:regen]
+	if streams == 1 {
+		if err := d.huffman.decode(data, d.literals); err != nil {
+			return 0, err
+		}
+		return hsize + comp, nil
+	}
+	if len(data) < 6 {
+		return 0, errZstd
+	}
+	var sizes [4]int
+	sizes[0] = int(binary.LittleEndian.Uint16(data[0:]))
+	sizes[1] = int(binary.LittleEndian.Uint16(data[2:]))
+	sizes[2] = int(binary.LittleEndian.Uint16(data[4:]))
+	sizes[3] = len(data) - 6 - sizes[0] - sizes[1] - sizes[2]
+	if sizes[3] < 0 {
+		return 0, errZstd
+	}
+	data = data[6:]
+	segment := (regen + 3) / 4
+	out := d.literals
+	for i := 0; i < 4; i++ {
+		n := segment
+		if i == 3 {
+			n = len(out)
+		}
+		if n > len(out) {
+			return 0, errZstd
+		}
+		if err := d.huffman.decode(data[:sizes[i]], out[:n]); err != nil {
+			return 0, err
+		}
+		data = data[sizes[i]:]
+		out = out[n:]
+	}
+	return hsize + comp, nil
+}
+
+// decodeSequences decodes the sequences section of a compressed block
+func (d *zstdDecoder) decodeSequences(src []byte) error {
+
+	d.sequences = d.sequences[:0]
+	if len(src) < 1 {
+		return errZstd
+	}
+	count := int(src[0])
+	pos := 1
+	if count >= 128 {
+		if len(src) < 2 {
+			return errZstd
+		}
+		if count < 255 {
+			count = (count-128)<<8 + int(src[1])
+			pos = 2
+		} else {
+			if len(src) < 3 {
+				return errZstd
+			}
+			count = int(src[1]) + int(src[2])<<8 + 0x7F00
+			pos = 3
+		}
+	}
+	if count == 0 {
+		return nil
+	}
+	if pos >= len(src) {
+		return errZstd
+	}
+	modes := src[pos]
+	pos++
+	if modes&3 != 0 {
+		return errZstd
+	}
+	tables := []struct {
+		table   *zstdFSETable
+		mode    byte
+		def     []int16
+		defLog  int
+		maxLog  int
+		maxCode int
+	}{
+		{&d.ll, modes >> 6, zstdLLDefault, 6, zstdMaxLLLog, zstdMaxLLCode},
+		{&d.of, (modes >> 4) & 3, zstdOFDefault, 5, zstdMaxOFLog, zstdMaxOFCode},
+		{&d.ml, (modes >> 2) & 3, zstdMLDefault, 6, zstdMaxMLLog, zstdMaxMLCode},
+	}
+	for _, t := range tables {
+		switch t.mode {
+		case 0:
+			t.table.build(t.def, t.defLog)
+		case 1:
+			if pos >= len(src) || int(src[pos]) > t.maxCode {
+				return errZstd
+			}
+			t.table.rle(src[pos])
+			pos++
+		case 2:
+			norm, log, n, err := zstdReadDistribution(src[pos:], t.maxCode, t.maxLog)
+			if err != nil {
+				return err
+			}
+			t.table.build(norm, log)
+			pos += n
+		default:
+			if t.table.log < 0 {
+				return errZstd
+			}
+		}
+	}
+
+	br, err := newZstdBackReader(src[pos:])
+	if err != nil {
+		return err
+	}
+	llState := br.read(uint(d.ll.log))
+	ofState := br.read(uint(d.of.log))
+	mlState := br.read(uint(d.ml.log))
+	for i := 0; i < count; i++ {
+		ofCode := d.of.entries[ofState].symbol
+		mlCode := d.ml.entries[mlState].symbol
+		llCode := d.ll.entries[llState].symbol
+		if ofCode > zstdMaxOFCode {
+			return errZstd
+		}
+
+		// Extra bits are read in the order offset, match length and literals length
+		ofValue := int(1)<<ofCode + int(br.read(uint(ofCode)))
+		ml := int(mlCode) + 3
+		if mlCode >= 32 {
+			ml = int(zstdMLBase[mlCode-32]) + int(br.read(uint(zstdMLBits[mlCode-32])))
+		}
+		ll := int(llCode)
+		if llCode >= 16 {
+			ll = int(zstdLLBase[llCode-16]) + int(br.read(uint(zstdLLBits[llCode-16])))
+		}
+
+		// Repeated offsets
+		var offset int
+		if ofValue > 3 {
+			offset = ofValue - 3
+			d.rep = [3]int{offset, d.rep[0], d.rep[1]}
+		} else {
+			idx := ofValue
+			if ll == 0 {
+				idx++
+			}
+			switch idx {
+			case 1:
+				offset = d.rep[0]
+			case 2:
+				offset = d.rep[1]
+				d.rep = [3]int{offset, d.rep[0], d.rep[2]}
+			case 3:
+				offset = d.rep[2]
+				d.rep = [3]int{offset, d.rep[0], d.rep[1]}
+			default:
+				offset = d.rep[0] - 1
+				if offset == 0 {
+					return errZstd
+				}
+				d.rep = [3]int{offset, d.rep[0], d.rep[1]}
+			}
+		}
+		d.sequences = append(d.sequences, zstdSequence{ll, ml, offset})
+
+		// States are updated in the order literals length, match length and offset
+		if i < count-1 {
+			llState = d.ll.next(llState, br)
+			mlState = d.ml.next(mlState, br)
+			ofState = d.of.next(ofState, br)
+		}
+		if br.pos < 0 {
+			return errZstd
+		}
+	}
+	if br.pos != 0 {
+		return errZstd
+	}
+	return nil
+}
+
+// zstdFSEEntry is one entry of a finite state entropy decoding table
+type zstdFSEEntry struct {
+	symbol uint8  // decoded symbol
+	bits   uint8  // number of bits of the next state
+	base   uint16 // base of the next state
+}
+
+// zstdFSETable is a finite state entropy decoding table
+type zstdFSETable struct {
+	log     int // accuracy log or -1 if undefined
+	entries []zstdFSEEntry
+}
+
+// rle sets the table to always decode the specified symbol
+func (t *zstdFSETable) rle(symbol byte) {
+
+	t.log = 0
+	t.entries = append(t.entries[:0], zstdFSEEntry{symbol: symbol})
+}
+
+// build builds the table from the specified normalized distribution, where
+// -1 is the probability of the symbols less probable than 1/2^log.
+func (t *zstdFSETable) build(norm []int16, log int) {
+
+	size := 1 << uint(log)
+	t.log = log
+	if cap(t.entries) < size {
+		t.entries = make([]zstdFSEEntry, size)
+	}
+	t.entries = t.entries[:size]
+	next := make([]int, len(norm))
+	high := size - 1
+	for s, p := range norm {
+		if p == -1 {
+			t.entries[high].symbol = uint8(s)
+			high--
+			next[s] = 1
+		} else {
+			next[s] = int(p)
+		}
+	}
+	step := size>>1 + size>>3 + 3
+	pos := 0
+	for s, p := range norm {
+		for i := 0; i < int(p); i++ {
+			t.entries[pos].symbol = uint8(s)
+			pos = (pos + step) & (size - 1)
+			for pos > high {
+				pos = (pos + step) & (size - 1)
+			}
+		}
+	}
+	for i := range t.entries {
+		e := &t.entries[i]
+		state := next[e.symbol]
+		next[e.symbol]++
+		bits := log - highBit(uint32(state))
+		e.bits = uint8(bits)
+		e.base = uint16(state<<uint(bits) - size)
+	}
+}
+
+// next returns the state following the specified state
+func (t *zstdFSETable) next(state uint64, br *zstdBackReader) uint64 {
+
+	e := t.entries[state]
+	return uint64(e.base) + br.read(uint(e.bits))
+}
+
+// zstdReadDistribution reads a normalized FSE distribution of symbols up to
+// the specified maximum and with an accuracy log up to the specified maximum,
+// returning the distribution, its accuracy log and the number of bytes read.
+func zstdReadDistribution(src []byte, maxSymbol, maxLog int) ([]int16, int, int, error) {
+
+	br := zstdForwardReader{data: src}
+	log := int(br.read(4)) + 5
+	if log > maxLog {
+		return nil, 0, 0, errZstd
+	}
+	remaining := 1<<uint(log) + 1
+	threshold := 1 << uint(log)
+	bits := uint(log + 1)
+	norm := make([]int16, 0, maxSymbol+1)
+	for remaining > 1 {
+		if len(norm) > maxSymbol {
+			return nil, 0, 0, errZstd
+		}
+		max := 2*threshold - 1 - remaining
+		var count int
+		if low := int(br.peek(bits - 1)); low < max {
+			count = low
+			br.skip(bits - 1)
+		} else {
+			count = int(br.peek(bits))
+			if count >= threshold {
+				count -= max
+			}
+			br.skip(bits)
+		}
+		count--
+		if count < 0 {
+			remaining--
+		} else {
+			remaining -= count
+		}
+		norm = append(norm, int16(count))
+		// Zero probabilities are followed by the number of repeated zeros
+		if count == 0 {
+			for {
+				repeat := int(br.read(2))
+				for i := 0; i < repeat; i++ {
+					norm = append(norm, 0)
+				}
+				if repeat != 3 {
+					break
+				}
+			}
+		}
+		for remaining < threshold && threshold > 1 {
+			bits--
+			threshold >>= 1
+		}
+		if br.pos > len(src)*8 {
+			return nil, 0, 0, errZstd
+		}
+	}
+	if remaining != 1 || len(norm) > maxSymbol+1 {
+		return nil, 0, 0, errZstd
+	}
+	return norm, log, (br.pos + 7) / 8, nil
+}
+
+// zstdHuffman is a Huffman decoding table of literals
+type zstdHuffman struct {
+	bits    int           // maximum number of bits of the codes or 0 if undefined
+	entries []zstdHufCode // entries indexed by the next bits of the stream
+}
+
+// zstdHufCode is one entry of a Huffman decoding table
+type zstdHufCode struct {
+	symbol byte // decoded symbol
+	bits   byte // number of bits of the code
+}
+
+// read reads the Huffman tree description returning the number of bytes read
+func (h *zstdHuffman) read(src []byte) (int, error) {
+
+	if len(src) < 1 {
+		return 0, errZstd
+	}
+	var weights []byte
+	n := int(src[0])
+	size := 0
+	if n >= 128 {
+		// Weights of 4 bits
+		count := n - 127
+		size = 1 + (count+1)/2
+		if size > len(src) {
+			return 0, errZstd
+		}
+		weights = make([]byte, count)
+		for i := range weights {
+			b := src[1+i/2]
+			if i%2 == 0 {
+				weights[i] = b >> 4
+			} else {
+				weights[i] = b & 0xF
+			}
+		}
+	} else {
+		// Weights compressed by two interleaved FSE states
+		size = 1 + n
+		if size > len(src) {
+			return 0, errZstd
+		}
+		norm, log, used, err := zstdReadDistribution(src[1:size], zstdMaxHuffmanBits, zstdMaxHuffmanLog)
+		if err != nil {
+			return 0, err
+		}
+		var t zstdFSETable
+		t.build(norm, log)
+		br, err := newZstdBackReader(src[1+used : size])
+		if err != nil {
+			return 0, err
+		}
+		s1 := br.read(uint(log))
+		s2 := br.read(uint(log))
+		for len(weights) < 255 {
+			weights = append(weights, t.entries[s1].symbol)
+			s1 = t.next(s1, br)
+			if br.pos < 0 {
+				weights = append(weights, t.entries[s2].symbol)
+				break
+			}
+			weights = append(weights, t.entries[s2].symbol)
+			s2 = t.next(s2, br)
+			if br.pos < 0 {
+				weights = append(weights, t.entries[s1].symbol)
+				break
+			}
+		}
+	}
+
+	// The weight of the last symbol completes the total to a power of 2
+	total := 0
+	for _, w := range weights {
+		if w > zstdMaxHuffmanBits {
+			return 0, errZstd
+		}
+		if w > 0 {
+			total += 1 << (w - 1)
+		}
+	}
+	if total == 0 || len(weights) > 255 {
+		return 0, errZstd
+	}
+	bits := highBit(uint32(total)) + 1
+	rest := 1<<uint(bits) - total
+	if bits > zstdMaxHuffmanBits || rest&(rest-1) != 0 {
+		return 0, errZstd
+	}
+	weights = append(weights, byte(highBit(uint32(rest))+1))
+
+	// Codes are assigned in increasing order of weight and symbol
+	var starts [zstdMaxHuffmanBits + 2]int
+	for _, w := range weights {
+		if w > 0 {
+			starts[w] += 1 << (w - 1)
+		}
+	}
+	next := 0
+	for w := 1; w < len(starts); w++ {
+		count := starts[w]
+		starts[w] = next
+		next += count
+	}
+	h.bits = bits
+	h.entries = make([]zstdHufCode, 1<<uint(bits))
+	for s, w := range weights {
+		if w == 0 {
+			continue
+		}
+		length := 1 << (w - 1)
+		code := zstdHufCode{symbol: byte(s), bits: byte(bits + 1 - int(w))}
+		for i := starts[w]; i < starts[w]+length; i++ {
+			h.entries[i] = code
+		}
+		starts[w] += length
+	}
+	return size, nil
+}
+
+// decode decodes one Huffman stream filling the specified output
+func (h *zstdHuffman) decode(src []byte, out []byte) error {
+
+	br, err := newZstdBackReader(src)
+	if err != nil {
+		return err
+	}
+	for i := range out {
+		code := h.entries[br.peek(uint(h.bits))]
+		br.pos -= int(code.bits)
+		out[i] = code.symbol
+	}
+	if br.pos != 0 {
+		return errZstd
+	}
+	return nil
+}
+
+// zstdBackReader reads a bit stream backwards from its end, which is marked
+// by the highest bit set of its last byte. Bits before the start are zero.
+type zstdBackReader struct {
+	data []byte
+	pos  int // number of unread bits, which is negative after reading past the start
+}
+
+// newZstdBackReader returns a reader of the specified backward bit stream
+func newZstdBackReader(data []byte) (*zstdBackReader, error) {
+
+	if len(data) == 0 || data[len(data)-1] == 0 {
+		return nil, errZstd
+	}
+	return &zstdBackReader{data: data, pos: (len(data)-1)*8 + highBit(uint32(data[len(data)-1]))}, nil
+}
+
+// read reads the specified number of bits, up to 56
+func (br *zstdBackReader) read(n uint) uint64 {
+
+	v := br.peek(n)
+	br.pos -= int(n)
+	return v
+}
+
+// peek returns the specified number of bits, up to 56, without reading them
+func (br *zstdBackReader) peek(n uint) uint64 {
+
+	if n == 0 {
+		return 0
+	}
+	lo := br.pos - int(n)
+	if lo < 0 {
+		if br.pos <= 0 {
+			return 0
+		}
+		return br.bits(0, uint(br.pos)) << uint(-lo)
+	}
+	return br.bits(lo, n)
+}
+
+// bits returns the specified number of bits starting at the specified bit position
+func (br *zstdBackReader) bits(lo int, n uint) uint64 {
+
+	var buf [8]byte
+	copy(buf[:], br.data[lo>>3:])
+	v := binary.LittleEndian.Uint64(buf[:]) >> uint(lo&7)
+	return v & (1<<n - 1)
+}
+
+// zstdForwardReader reads a bit stream starting with the least significant
+// bit of its first byte. Bits after the end are zero.
+type zstdForwardReader struct {
+	data []byte
+	pos  int // number of bits read
+}
+
+// peek returns the specified number of bits, up to 56, without reading them
+func (br *zstdForwardReader) peek(n uint) uint64 {
+
+	var buf [8]byte
+	if br.pos>>3 < len(br.data) {
+		copy(buf[:], br.data[br.pos>>3:])
+	}
+	v := binary.LittleEndian.Uint64(buf[:]) >> uint(br.pos&7)
+	return v & (1<<n - 1)
+}
+
+// skip skips the specified number of bits
+func (br *zstdForwardReader) skip(n uint) {
+
+	br.pos += int(n)
+}
+
+// read reads the specified number of bits, up to 56
+func (br *zstdForwardReader) read(n uint) uint64 {
+
+	v := br.peek(n)
+	br.skip(n)
+	return v
+}
+
+// highBit returns the position of the highest bit set of the specified value, which must not be 0
+func highBit(v uint32) int {
+
+	n := -1
+	for v != 0 {
+		v >>= 1
+		n++
+	}
+	return n
+}
+
+// minInt returns the smaller of the specified values
+func minInt(a, b int) int {
+
+	if a < b {
+		return a
+	}
+	return b
+}
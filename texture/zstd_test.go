@@ -0,0 +1,187 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package texture
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+// zstdRandom returns the generator of the pseudorandom numbers used to create the zst files of testdata
+func zstdRandom(seed uint32) func() uint32 {
+
+	return func() uint32 {
+		seed = seed*1103515245 + 12345
+		return seed
+	}
+}
+
+// zstdPattern returns the 256x256 RGBA image of the pattern files,
+// which has blocks of colors and some noise.
+func zstdPattern() []byte {
+
+	data := make([]byte, 256*256*4)
+	rnd := zstdRandom(1)
+	for i := range data {
+		r := rnd()
+		x, y := i/4%256, i/1024
+		switch i % 4 {
+		case 0:
+			data[i] = byte(x >> 3 << 3)
+		case 1:
+			data[i] = byte(y >> 3 << 3)
+		case 2:
+			if r>>24 != 0 {
+				data[i] = byte((x>>4 + y>>4) << 3)
+			} else {
+				data[i] = byte(r >> 16)
+			}
+		default:
+			data[i] = 255
+		}
+	}
+	return data
+}
+
+// zstdNoise returns the incompressible data of the noise file
+func zstdNoise() []byte {
+
+	data := make([]byte, 2000)
+	rnd := zstdRandom(7)
+	for i := range data {
+		data[i] = byte(rnd() >> 24)
+	}
+	return data
+}
+
+// zstdNibbles returns the specified number of random 2 bit values of the nibbles file
+func zstdNibbles(n int) []byte {
+
+	data := make([]byte, n)
+	rnd := zstdRandom(5)
+	for i := range data {
+		data[i] = byte(rnd() >> 30)
+	}
+	return data
+}
+
+// zstdTokens returns the data of the tokens file, which is a random sequence of 8 tokens of 3 bytes
+func zstdTokens() []byte {
+
+	rnd := zstdRandom(13)
+	tokens := make([][]byte, 8)
+	for i := range tokens {
+		tokens[i] = []byte{byte(rnd() >> 24), byte(rnd() >> 24), byte(rnd() >> 24)}
+	}
+	var data []byte
+	for len(data) < 30000 {
+		data = append(data, tokens[(rnd()>>24)%8]...)
+	}
+	return data[:30000]
+}
+
+// Tests decompressing files of the zstd tool with different compression levels and block types
+func TestZstdDecompress(t *testing.T) {
+
+	pattern := zstdPattern()
+	small := bytes.Repeat([]byte("Zstandard supercompression of KTX2 textures. "), 3)
+	tests := []struct {
+		file     string
+		expected []byte
+	}{
+		{"pattern19.zst", pattern},
+		{"pattern3_nocheck.zst", pattern},
+		{"noise.zst", zstdNoise()},
+		{"zero.zst", make([]byte, 300000)},
+		{"small.zst", append(small, zstdNibbles(60)...)},
+		{"nibbles.zst", zstdNibbles(20000)},
+		{"tokens.zst", zstdTokens()},
+	}
+	for _, test := range tests {
+		src, err := ioutil.ReadFile(filepath.Join("testdata", test.file))
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := zstdDecompress(src, len(test.expected))
+		if err != nil {
+			t.Errorf("%s: %v", test.file, err)
+			continue
+		}
+		if !bytes.Equal(data, test.expected) {
+			t.Errorf("%s: decompressed data differs", test.file)
+		}
+		// Decompression stops at the limit
+		data, err = zstdDecompress(src, 100)
+		if err != nil || !bytes.Equal(data, test.expected[:100]) {
+			t.Errorf("%s: limited decompression: %v", test.file, err)
+		}
+	}
+
+	// Skippable and concatenated frames
+	noise, err := ioutil.ReadFile(filepath.Join("testdata", "noise.zst"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	src := []byte{0x50, 0x2A, 0x4D, 0x18, 2, 0, 0, 0, 1, 2}
+	src = append(src, noise...)
+	src = append(src, noise...)
+	data, err := zstdDecompress(src, 4000)
+	if err != nil || !bytes.Equal(data, append(zstdNoise(), zstdNoise()...)) {
+		t.Errorf("concatenated frames: %v", err)
+	}
+}
+
+// Tests decoding a KTX2 container with Zstandard supercompression
+func TestDecodeKTX2Zstd(t *testing.T) {
+
+	src, err := ioutil.ReadFile(filepath.Join("testdata", "pattern19.zst"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	file := ktx2File(256, 256, 37, ktx2HeaderSize+ktx2LevelIndexEntrySize, uint64(len(src)), src)
+	binary.LittleEndian.PutUint32(file[44:], ktx2SupercompressionZstd)
+	ci, err := DecodeKTX2(bytes.NewReader(file))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ci.Levels) != 1 || !bytes.Equal(ci.Levels[0], zstdPattern()) {
+		t.Error("decompressed level differs")
+	}
+}
+
+// Tests that corrupted data returns errors instead of panicking
+func TestZstdMalformed(t *testing.T) {
+
+	src, err := ioutil.ReadFile(filepath.Join("testdata", "pattern19.zst"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"empty frame", []byte{0x28, 0xB5, 0x2F, 0xFD}},
+		{"magic", []byte{1, 2, 3, 4, 5, 6}},
+		{"skippable size", []byte{0x50, 0x2A, 0x4D, 0x18, 0xFF, 0xFF, 0xFF, 0xFF}},
+		{"truncated", src[:len(src)/2]},
+		{"reserved block type", append(append([]byte{}, src[:6]...), 0x07, 0, 0)},
+		{"dictionary", []byte{0x28, 0xB5, 0x2F, 0xFD, 0x01, 0x00, 0x01, 0x01, 0, 0}},
+	}
+	for _, test := range tests {
+		if _, err := zstdDecompress(test.data, 1<<20); err == nil {
+			t.Errorf("%s: no error", test.name)
+		}
+	}
+
+	// Corrupted bytes return errors or wrong data without panicking
+	for i := 4; i < len(src); i += 7 {
+		data := append([]byte{}, src...)
+		data[i] ^= 0x5A
+		zstdDecompress(data, 256*256*4)
+	}
+}
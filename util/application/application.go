@@ -52,18 +52,20 @@ type Application struct {
 	noglErrors        *bool                 // No OpenGL check errors options
 	cpuProfile        *string               // File to write cpu profile to
 	execTrace         *string               // File to write execution trace data to
+	guiScale          *float64              // GUI scale factor option
 }
 
 // Options defines initial options passed to the application creation function
 type Options struct {
-	Title       string // Initial window title
-	Height      int    // Initial window height (default is screen width)
-	Width       int    // Initial window width (default is screen height)
-	Fullscreen  bool   // Window full screen flag (default = false)
-	LogPrefix   string // Log prefix (default = "")
-	LogLevel    int    // Initial log level (default = DEBUG)
-	EnableFlags bool   // Enable command line flags (default = false)
-	TargetFPS   uint   // Desired frames per second rate (default = 60)
+	Title       string  // Initial window title
+	Height      int     // Initial window height (default is screen width)
+	Width       int     // Initial window width (default is screen height)
+	Fullscreen  bool    // Window full screen flag (default = false)
+	LogPrefix   string  // Log prefix (default = "")
+	LogLevel    int     // Initial log level (default = DEBUG)
+	EnableFlags bool    // Enable command line flags (default = false)
+	TargetFPS   uint    // Desired frames per second rate (default = 60)
+	GuiScale    float32 // GUI scale factor (default = 0 for the content scale of the window)
 }
 
 // appInstance contains the pointer to the single Application instance
@@ -88,8 +90,10 @@ func Create(ops Options) (*Application, error) {
 	app.noglErrors = new(bool)
 	app.cpuProfile = new(string)
 	app.execTrace = new(string)
+	app.guiScale = new(float64)
 	*app.swapInterval = -1
 	*app.targetFPS = 60
+	*app.guiScale = float64(ops.GuiScale)
 
 	// Options parameter overrides some options
 	if ops.TargetFPS != 0 {
@@ -105,6 +109,7 @@ func Create(ops Options) (*Application, error) {
 		app.noglErrors = flag.Bool("noglerrors", *app.noglErrors, "Do not check OpenGL errors at each call (may increase FPS)")
 		app.cpuProfile = flag.String("cpuprofile", *app.cpuProfile, "Activate cpu profiling writing profile to the specified file")
 		app.execTrace = flag.String("exectrace", *app.execTrace, "Activate execution tracer writing data to the specified file")
+		app.guiScale = flag.Float64("guiscale", *app.guiScale, "Sets the GUI scale factor (0 for the content scale of the window)")
 	}
 	flag.Parse()
 
@@ -200,6 +205,9 @@ func Create(ops Options) (*Application, error) {
 	app.scene = core.NewNode()
 
 	// Creates gui root panel
+	if *app.guiScale > 0 {
+		gui.SetScale(float32(*app.guiScale))
+	}
 	app.guiroot = gui.NewRoot(app.gl, app.win)
 	app.guiroot.SetColor(math32.NewColor("silver"))
 
@@ -589,6 +597,6 @@ func (app *Application) OnWindowResize() {
 
 	// Sets the GUI root panel size to the size of the framebuffer
 	if app.guiroot != nil {
-		app.guiroot.SetSize(float32(width), float32(height))
+		app.guiroot.FitWindow()
 	}
 }
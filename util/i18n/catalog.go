@@ -0,0 +1,224 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package i18n
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Catalog contains the translated messages of a locale by key
+type Catalog struct {
+	locale   string              // normalized locale of the messages
+	messages map[string]*message // messages by key
+}
+
+// message is a translated message with optional plural forms
+type message struct {
+	text  string            // text of messages without plural forms
+	forms map[Plural]string // texts of the plural forms or nil
+}
+
+// NewCatalog creates and returns a pointer to a new empty catalog
+// for the specified locale, such as "en" or "pt-BR".
+func NewCatalog(locale string) *Catalog {
+
+	c := new(Catalog)
+	c.locale = Normalize(locale)
+	c.messages = make(map[string]*message)
+	return c
+}
+
+// ParseCatalog parses a description of messages in YAML or JSON format
+// and returns a new catalog with them for the specified locale.
+func ParseCatalog(locale, desc string) (*Catalog, error) {
+
+	var mii map[interface{}]interface{}
+	err := yaml.Unmarshal([]byte(desc), &mii)
+	if err != nil {
+		return nil, err
+	}
+	c := NewCatalog(locale)
+	err = c.parse(mii, "")
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// LoadCatalog reads and parses a catalog file in YAML or JSON format.
+// The locale of the catalog is the name of the file without its
+// extension, for example "locales/pt-BR.yaml".
+func LoadCatalog(fpath string) (*Catalog, error) {
+
+	data, err := ioutil.ReadFile(fpath)
+	if err != nil {
+		return nil, err
+	}
+	base := filepath.Base(fpath)
+	c, err := ParseCatalog(strings.TrimSuffix(base, filepath.Ext(base)), string(data))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", fpath, err)
+	}
+	return c, nil
+}
+
+// Locale returns the normalized locale of this catalog
+func (c *Catalog) Locale() string {
+
+	return c.locale
+}
+
+// Keys returns the sorted keys of the messages of this catalog
+func (c *Catalog) Keys() []string {
+
+	keys := make([]string, 0, len(c.messages))
+	for key := range c.messages {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Has returns if this catalog contains the message with the specified key
+func (c *Catalog) Has(key string) bool {
+
+	_, ok := c.messages[key]
+	return ok
+}
+
+// Set sets the text of the message with the specified key
+func (c *Catalog) Set(key, text string) *Catalog {
+
+	c.messages[key] = &message{text: text}
+	return c
+}
+
+// SetPlural sets the texts of the plural forms of the message with the specified key.
+// The form PluralOther should always be specified.
+func (c *Catalog) SetPlural(key string, forms map[Plural]string) *Catalog {
+
+	m := &message{forms: make(map[Plural]string)}
+	for form, text := range forms {
+		m.forms[form] = text
+	}
+	m.text = m.forms[PluralOther]
+	c.messages[key] = m
+	return c
+}
+
+// Merge copies the messages of the specified catalog to this one,
+// replacing the messages with the same keys.
+func (c *Catalog) Merge(other *Catalog) *Catalog {
+
+	for key, m := range other.messages {
+		c.messages[key] = m
+	}
+	return c
+}
+
+// Text returns the text of the message with the specified key, with the plural
+// form of the specified count if it has plural forms, and if it was found.
+func (c *Catalog) Text(key string, count int) (string, bool) {
+
+	m, ok := c.messages[key]
+	if !ok {
+		return "", false
+	}
+	if m.forms == nil {
+		return m.text, true
+	}
+	if text, ok := m.forms[PluralRuleOf(c.locale)(count)]; ok {
+		return text, true
+	}
+	return m.text, true
+}
+
+// parse adds the messages of the specified parsed description
+// with the specified key prefix to this catalog
+func (c *Catalog) parse(mii map[interface{}]interface{}, prefix string) error {
+
+	for k, v := range mii {
+		key := prefix + fmt.Sprint(k)
+		switch vt := v.(type) {
+		case map[interface{}]interface{}:
+			if forms, ok := pluralForms(vt); ok {
+				c.SetPlural(key, forms)
+				continue
+			}
+			err := c.parse(vt, key+".")
+			if err != nil {
+				return err
+			}
+		case []interface{}:
+			return fmt.Errorf("Invalid message:%s", key)
+		case nil:
+			c.Set(key, "")
+		default:
+			c.Set(key, fmt.Sprint(vt))
+		}
+	}
+	return nil
+}
+
+// pluralForms returns the plural forms of the specified map
+// and if all its keys are names of plural forms
+func pluralForms(mii map[interface{}]interface{}) (map[Plural]string, bool) {
+
+	if len(mii) == 0 {
+		return nil, false
+	}
+	forms := make(map[Plural]string)
+	for k, v := range mii {
+		ks, ok := k.(string)
+		if !ok {
+			return nil, false
+		}
+		form, ok := pluralNames[strings.ToLower(ks)]
+		if !ok {
+			return nil, false
+		}
+		switch v.(type) {
+		case map[interface{}]interface{}, []interface{}:
+			return nil, false
+		}
+		forms[form] = fmt.Sprint(v)
+	}
+	return forms, true
+}
+
+// Normalize returns the specified locale with the conventional case
+// and separator, for example "pt-BR" for "pt_br".
+func Normalize(locale string) string {
+
+	parts := strings.FieldsFunc(locale, func(r rune) bool { return r == '-' || r == '_' })
+	for i, part := range parts {
+		switch {
+		case i == 0:
+			parts[i] = strings.ToLower(part)
+		case len(part) == 2:
+			parts[i] = strings.ToUpper(part)
+		case len(part) == 4:
+			parts[i] = strings.ToUpper(part[:1]) + strings.ToLower(part[1:])
+		default:
+			parts[i] = strings.ToLower(part)
+		}
+	}
+	return strings.Join(parts, "-")
+}
+
+// language returns the language of the specified normalized locale
+func language(locale string) string {
+
+	if pos := strings.IndexByte(locale, '-'); pos >= 0 {
+		return locale[:pos]
+	}
+	return locale
+}
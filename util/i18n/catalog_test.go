@@ -0,0 +1,115 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package i18n
+
+import (
+	"testing"
+)
+
+// Tests the messages translated by a localizer with catalogs parsed from
+// descriptions, including missing keys, placeholders and plural forms
+func TestLocalizerTr(t *testing.T) {
+
+	ru, err := ParseCatalog("ru", `
+file:
+  open: Открыть
+files:
+  one: "{count} файл"
+  few: "{count} файла"
+  many: "{count} файлов"
+greet: "Привет, {name}!"
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	en, err := ParseCatalog("en", `
+file:
+  open: Open
+  close: Close
+files:
+  one: "{count} file"
+  other: "{count} files"
+greet: "Hello, {name}!"
+braces: "{{name}} is {name}"
+spaces: "{ name } and {missing}"
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l := NewLocalizer("en")
+	l.AddCatalog(ru)
+	l.AddCatalog(en)
+
+	tests := []struct {
+		locale   string
+		key      string
+		args     Args
+		expected string
+	}{
+		{"en", "file.open", nil, "Open"},
+		{"ru", "file.open", nil, "Открыть"},
+		{"ru-RU", "file.open", nil, "Открыть"},
+		// Missing messages are taken from the fallback locale or are their keys
+		{"ru", "file.close", nil, "Close"},
+		{"ru", "file.missing", nil, "file.missing"},
+		{"de", "file.open", nil, "Open"},
+		// Placeholders
+		{"en", "greet", Args{"name": "Ana"}, "Hello, Ana!"},
+		{"en", "greet", nil, "Hello, {name}!"},
+		{"en", "greet", Args{"other": 1}, "Hello, {name}!"},
+		{"en", "braces", Args{"name": "x"}, "{name} is x"},
+		{"en", "spaces", Args{"name": 3}, "3 and {missing}"},
+		{"ru", "greet", Args{"name": "Иван"}, "Привет, Иван!"},
+		// Plural forms
+		{"en", "files", Args{"count": 1}, "1 file"},
+		{"en", "files", Args{"count": 0}, "0 files"},
+		{"ru", "files", Args{"count": 1}, "1 файл"},
+		{"ru", "files", Args{"count": 3}, "3 файла"},
+		{"ru", "files", Args{"count": 11}, "11 файлов"},
+		{"ru", "files", Args{"count": 21}, "21 файл"},
+		{"ru", "files", Args{"count": 0}, "0 файлов"},
+	}
+	for _, test := range tests {
+		l.SetLocale(test.locale)
+		if text := l.TrArgs(test.key, test.args); text != test.expected {
+			t.Errorf("%s %s %v: %q, expected %q", test.locale, test.key, test.args, text, test.expected)
+		}
+	}
+}
+
+// Tests the keys and locale of a parsed catalog
+func TestParseCatalog(t *testing.T) {
+
+	c, err := ParseCatalog("pt_br", `{"a": {"b": "B", "c": {"one": "1", "other": "N"}}, "d": "D"}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Locale() != "pt-BR" {
+		t.Errorf("locale %q, expected pt-BR", c.Locale())
+	}
+	keys := c.Keys()
+	expected := []string{"a.b", "a.c", "d"}
+	if len(keys) != len(expected) {
+		t.Fatalf("keys %q, expected %q", keys, expected)
+	}
+	for i := range keys {
+		if keys[i] != expected[i] {
+			t.Errorf("keys %q, expected %q", keys, expected)
+		}
+	}
+	if c.Has("a") || !c.Has("a.b") {
+		t.Error("Has failed")
+	}
+	if _, ok := c.Text("x", 0); ok {
+		t.Error("Text found a missing key")
+	}
+	// Portuguese uses the form one for 0
+	if text, _ := c.Text("a.c", 0); text != "1" {
+		t.Errorf("Text of 0 is %q, expected 1", text)
+	}
+	if _, err := ParseCatalog("en", "a: [1, 2"); err == nil {
+		t.Error("ParseCatalog accepted an invalid description")
+	}
+}
@@ -0,0 +1,26 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package i18n implements catalogs of translated messages per locale,
+// with plural forms and named placeholders, and the localizer which
+// translates messages to the current locale of the application.
+//
+// Catalogs are described in YAML (or JSON) format. The keys of nested maps
+// are joined by dots and maps whose keys are all plural form names
+// (zero, one, two, few, many and other) are messages with plural forms:
+//
+//	file:
+//	  open: Open...           # message "file.open"
+//	  save: Save
+//	files:
+//	  count:                  # message "files.count"
+//	    one: "{count} file"   # the plural form is selected by the "count" argument
+//	    other: "{count} files"
+//	greeting: "Hello {name}"  # "{{" and "}}" are literal braces
+//
+// Messages missing from the catalog of the current locale are searched in the
+// catalog of its language ("pt" for "pt-BR") and then in the catalogs of the
+// fallback locale. Messages missing from all of them are logged and their keys
+// are used as their text.
+package i18n
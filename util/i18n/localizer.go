@@ -0,0 +1,278 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package i18n
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/sansebasko/engine/core"
+)
+
+// OnLocaleChange is the event dispatched by a localizer when its locale changes.
+// The event parameter is the new locale.
+const OnLocaleChange = "i18n.OnLocaleChange"
+
+// CountArg is the name of the message argument which selects the plural form
+const CountArg = "count"
+
+// Args contains the values of the named placeholders of a message
+type Args map[string]interface{}
+
+// Localizer translates messages to its current locale using catalogs of messages
+type Localizer struct {
+	core.Dispatcher                     // Embedded event dispatcher
+	catalogs        map[string]*Catalog // catalogs by locale
+	locale          string              // current locale
+	fallback        string              // locale of the messages missing from the current locale
+	missing         map[string]bool     // missing messages already logged by locale and key
+}
+
+// Default is the localizer used by the package functions and by the GUI
+var Default = NewLocalizer("en")
+
+// NewLocalizer creates and returns a pointer to a new localizer
+// whose current and fallback locale is the specified locale.
+func NewLocalizer(fallback string) *Localizer {
+
+	l := new(Localizer)
+	l.Dispatcher.Initialize()
+	l.catalogs = make(map[string]*Catalog)
+	l.locale = Normalize(fallback)
+	l.fallback = l.locale
+	l.missing = make(map[string]bool)
+	return l
+}
+
+// AddCatalog adds the messages of the specified catalog to the catalog
+// of its locale in this localizer, replacing the messages with the same keys.
+func (l *Localizer) AddCatalog(c *Catalog) {
+
+	cur := l.catalogs[c.locale]
+	if cur == nil {
+		cur = NewCatalog(c.locale)
+		l.catalogs[c.locale] = cur
+	}
+	cur.Merge(c)
+	l.missing = make(map[string]bool)
+}
+
+// LoadDir loads all the catalog files in YAML or JSON format in the specified
+// directory, whose names are their locales, for example "locales/pt-BR.yaml".
+func (l *Localizer) LoadDir(dir string) error {
+
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	for _, fi := range files {
+		switch strings.ToLower(filepath.Ext(fi.Name())) {
+		case ".yaml", ".yml", ".json":
+		default:
+			continue
+		}
+		if fi.IsDir() {
+			continue
+		}
+		c, err := LoadCatalog(filepath.Join(dir, fi.Name()))
+		if err != nil {
+			return err
+		}
+		l.AddCatalog(c)
+	}
+	return nil
+}
+
+// Catalog returns the catalog of the specified locale or nil if not found
+func (l *Localizer) Catalog(locale string) *Catalog {
+
+	return l.catalogs[Normalize(locale)]
+}
+
+// Locales returns the sorted locales of the catalogs of this localizer
+func (l *Localizer) Locales() []string {
+
+	locales := make([]string, 0, len(l.catalogs))
+	for locale := range l.catalogs {
+		locales = append(locales, locale)
+	}
+	sort.Strings(locales)
+	return locales
+}
+
+// SetLocale sets the current locale of this localizer and
+// dispatches OnLocaleChange if it changed.
+func (l *Localizer) SetLocale(locale string) {
+
+	locale = Normalize(locale)
+	if locale == l.locale {
+		return
+	}
+	l.locale = locale
+	l.Dispatch(OnLocaleChange, locale)
+}
+
+// Locale returns the current locale of this localizer
+func (l *Localizer) Locale() string {
+
+	return l.locale
+}
+
+// SetFallback sets the locale whose catalog contains the
+// messages missing from the catalog of the current locale.
+func (l *Localizer) SetFallback(locale string) {
+
+	l.fallback = Normalize(locale)
+}
+
+// Fallback returns the fallback locale of this localizer
+func (l *Localizer) Fallback() string {
+
+	return l.fallback
+}
+
+// Tr returns the message with the specified key translated to the current locale
+func (l *Localizer) Tr(key string) string {
+
+	return l.TrArgs(key, nil)
+}
+
+// TrArgs returns the message with the specified key translated to the current locale,
+// with the plural form selected by the CountArg argument, if any, and its named
+// placeholders replaced by the values of the specified arguments.
+// The placeholders of missing arguments are kept.
+func (l *Localizer) TrArgs(key string, args Args) string {
+
+	count := 0
+	if v, ok := args[CountArg]; ok {
+		count = toInt(v)
+	}
+	text, ok := l.text(key, count)
+	if !ok {
+		mkey := l.locale + ":" + key
+		if !l.missing[mkey] {
+			l.missing[mkey] = true
+			log.Warn("Missing message:%s locale:%s", key, l.locale)
+		}
+		text = key
+	}
+	return format(text, args)
+}
+
+// text returns the text of the message with the specified key and count
+// from the catalogs of the current locale, its language and the fallback locale.
+func (l *Localizer) text(key string, count int) (string, bool) {
+
+	for _, locale := range []string{l.locale, language(l.locale), l.fallback, language(l.fallback)} {
+		if c := l.catalogs[locale]; c != nil {
+			if text, ok := c.Text(key, count); ok {
+				return text, true
+			}
+		}
+	}
+	return "", false
+}
+
+// format replaces the named placeholders of the specified text by the specified arguments
+func format(text string, args Args) string {
+
+	if strings.IndexAny(text, "{}") < 0 {
+		return text
+	}
+	var sb strings.Builder
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		// Escaped braces
+		if (c == '{' || c == '}') && i+1 < len(text) && text[i+1] == c {
+			sb.WriteByte(c)
+			i++
+			continue
+		}
+		if c == '{' {
+			end := strings.IndexByte(text[i:], '}')
+			if end > 0 {
+				name := strings.TrimSpace(text[i+1 : i+end])
+				if v, ok := args[name]; ok {
+					fmt.Fprint(&sb, v)
+					i += end
+					continue
+				}
+			}
+		}
+		sb.WriteByte(c)
+	}
+	return sb.String()
+}
+
+// toInt converts the specified count argument to an integer
+func toInt(v interface{}) int {
+
+	switch vt := v.(type) {
+	case int:
+		return vt
+	case int8:
+		return int(vt)
+	case int16:
+		return int(vt)
+	case int32:
+		return int(vt)
+	case int64:
+		return int(vt)
+	case uint:
+		return int(vt)
+	case uint8:
+		return int(vt)
+	case uint16:
+		return int(vt)
+	case uint32:
+		return int(vt)
+	case uint64:
+		return int(vt)
+	case float32:
+		return int(vt)
+	case float64:
+		return int(vt)
+	}
+	return 0
+}
+
+// SetLocale sets the current locale of the default localizer
+func SetLocale(locale string) {
+
+	Default.SetLocale(locale)
+}
+
+// Locale returns the current locale of the default localizer
+func Locale() string {
+
+	return Default.Locale()
+}
+
+// AddCatalog adds the messages of the specified catalog to the default localizer
+func AddCatalog(c *Catalog) {
+
+	Default.AddCatalog(c)
+}
+
+// LoadDir loads the catalog files of the specified directory to the default localizer
+func LoadDir(dir string) error {
+
+	return Default.LoadDir(dir)
+}
+
+// Tr returns the message with the specified key translated by the default localizer
+func Tr(key string) string {
+
+	return Default.Tr(key)
+}
+
+// TrArgs returns the message with the specified key and arguments translated by the default localizer
+func TrArgs(key string, args Args) string {
+
+	return Default.TrArgs(key, args)
+}
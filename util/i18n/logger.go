@@ -0,0 +1,12 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package i18n
+
+import (
+	"github.com/sansebasko/engine/util/logger"
+)
+
+// Package logger
+var log = logger.New("I18N", logger.Default)
@@ -0,0 +1,236 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package i18n
+
+// Plural is the plural form of a message selected by a count
+type Plural int
+
+// Plural forms, as named by the Unicode CLDR plural rules
+const (
+	PluralOther = Plural(iota)
+	PluralZero
+	PluralOne
+	PluralTwo
+	PluralFew
+	PluralMany
+)
+
+// PluralRule is the type of the functions which return
+// the plural form of a language for an integer count
+type PluralRule func(n int) Plural
+
+// pluralNames maps the names of the plural forms in catalogs to the plural forms
+var pluralNames = map[string]Plural{
+	"other": PluralOther,
+	"zero":  PluralZero,
+	"one":   PluralOne,
+	"two":   PluralTwo,
+	"few":   PluralFew,
+	"many":  PluralMany,
+}
+
+// String returns the name of the plural form
+func (p Plural) String() string {
+
+	for name, form := range pluralNames {
+		if form == p {
+			return name
+		}
+	}
+	return "other"
+}
+
+// pluralRules maps languages to their plural rules for integer counts.
+// Languages not in this map use the rule of English.
+var pluralRules = map[string]PluralRule{}
+
+func init() {
+
+	set := func(rule PluralRule, langs ...string) {
+		for _, lang := range langs {
+			pluralRules[lang] = rule
+		}
+	}
+	// No plural forms
+	set(func(n int) Plural {
+		return PluralOther
+	}, "id", "ja", "km", "ko", "lo", "ms", "my", "th", "vi", "zh")
+	// One for 0 and 1
+	set(func(n int) Plural {
+		if n == 0 || n == 1 {
+			return PluralOne
+		}
+		return PluralOther
+	}, "bn", "fa", "fr", "gu", "hi", "hy", "kn", "pt", "zu")
+	// East Slavic languages
+	set(func(n int) Plural {
+		switch {
+		case n%10 == 1 && n%100 != 11:
+			return PluralOne
+		case n%10 >= 2 && n%10 <= 4 && (n%100 < 12 || n%100 > 14):
+			return PluralFew
+		}
+		return PluralMany
+	}, "be", "ru", "uk")
+	// South Slavic languages
+	set(func(n int) Plural {
+		switch {
+		case n%10 == 1 && n%100 != 11:
+			return PluralOne
+		case n%10 >= 2 && n%10 <= 4 && (n%100 < 12 || n%100 > 14):
+			return PluralFew
+		}
+		return PluralOther
+	}, "bs", "hr", "sh", "sr")
+	set(func(n int) Plural {
+		switch {
+		case n == 1:
+			return PluralOne
+		case n%10 >= 2 && n%10 <= 4 && (n%100 < 12 || n%100 > 14):
+			return PluralFew
+		}
+		return PluralMany
+	}, "pl")
+	set(func(n int) Plural {
+		switch {
+		case n == 1:
+			return PluralOne
+		case n >= 2 && n <= 4:
+			return PluralFew
+		}
+		return PluralOther
+	}, "cs", "sk")
+	set(func(n int) Plural {
+		switch {
+		case n%10 == 1 && (n%100 < 11 || n%100 > 19):
+			return PluralOne
+		case n%10 >= 2 && (n%100 < 11 || n%100 > 19):
+			return PluralFew
+		}
+		return PluralOther
+	}, "lt")
+	set(func(n int) Plural {
+		switch {
+		case n%10 == 0 || n%100 >= 11 && n%100 <= 19:
+			return PluralZero
+		case n%10 == 1 && n%100 != 11:
+			return PluralOne
+		}
+		return PluralOther
+	}, "lv")
+	set(func(n int) Plural {
+		switch {
+		case n == 1:
+			return PluralOne
+		case n == 0 || n%100 >= 2 && n%100 <= 19:
+			return PluralFew
+		}
+		return PluralOther
+	}, "mo", "ro")
+	set(func(n int) Plural {
+		switch n % 100 {
+		case 1:
+			return PluralOne
+		case 2:
+			return PluralTwo
+		case 3, 4:
+			return PluralFew
+		}
+		return PluralOther
+	}, "sl")
+	set(func(n int) Plural {
+		switch {
+		case n%10 == 1 && n%100 != 11:
+			return PluralOne
+		}
+		return PluralOther
+	}, "is", "mk")
+	set(func(n int) Plural {
+		switch n {
+		case 1:
+			return PluralOne
+		case 2:
+			return PluralTwo
+		}
+		return PluralOther
+	}, "he", "iw")
+	set(func(n int) Plural {
+		switch {
+		case n == 0:
+			return PluralZero
+		case n == 1:
+			return PluralOne
+		case n == 2:
+			return PluralTwo
+		case n%100 >= 3 && n%100 <= 10:
+			return PluralFew
+		case n%100 >= 11:
+			return PluralMany
+		}
+		return PluralOther
+	}, "ar")
+	set(func(n int) Plural {
+		switch {
+		case n == 1:
+			return PluralOne
+		case n == 2:
+			return PluralTwo
+		case n >= 3 && n <= 6:
+			return PluralFew
+		case n >= 7 && n <= 10:
+			return PluralMany
+		}
+		return PluralOther
+	}, "ga")
+	set(func(n int) Plural {
+		switch n {
+		case 0:
+			return PluralZero
+		case 1:
+			return PluralOne
+		case 2:
+			return PluralTwo
+		case 3:
+			return PluralFew
+		case 6:
+			return PluralMany
+		}
+		return PluralOther
+	}, "cy")
+}
+
+// pluralOne is the plural rule of English and of most European languages
+func pluralOne(n int) Plural {
+
+	if n == 1 {
+		return PluralOne
+	}
+	return PluralOther
+}
+
+// SetPluralRule sets the plural rule of the specified language,
+// such as "pt", replacing its predefined rule if any.
+func SetPluralRule(lang string, rule PluralRule) {
+
+	pluralRules[language(Normalize(lang))] = rule
+}
+
+// PluralRuleOf returns the plural rule of the language of the specified locale.
+// Portuguese of Portugal ("pt-PT") uses the rule of English instead of the
+// rule of Portuguese. Negative counts have the plural form of their absolute value.
+func PluralRuleOf(locale string) PluralRule {
+
+	locale = Normalize(locale)
+	rule, ok := pluralRules[language(locale)]
+	if !ok || locale == "pt-PT" {
+		rule = pluralOne
+	}
+	return func(n int) Plural {
+		if n < 0 {
+			n = -n
+		}
+		return rule(n)
+	}
+}
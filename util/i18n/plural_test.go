@@ -0,0 +1,60 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package i18n
+
+import (
+	"testing"
+)
+
+// Tests the plural forms selected by the rules of several locales for the
+// counts where the forms of their languages change
+func TestPluralRuleOf(t *testing.T) {
+
+	const (
+		zero  = PluralZero
+		one   = PluralOne
+		two   = PluralTwo
+		few   = PluralFew
+		many  = PluralMany
+		other = PluralOther
+	)
+	counts := []int{0, 1, 2, 3, 4, 5, 10, 11, 12, 13, 14, 21, 22, 25, 100, 101, 102, 103, 111, 112}
+	tests := []struct {
+		locale   string
+		expected []Plural
+	}{
+		{"en", []Plural{other, one, other, other, other, other, other, other, other, other, other, other, other, other, other, other, other, other, other, other}},
+		{"ja", []Plural{other, other, other, other, other, other, other, other, other, other, other, other, other, other, other, other, other, other, other, other}},
+		{"fr", []Plural{one, one, other, other, other, other, other, other, other, other, other, other, other, other, other, other, other, other, other, other}},
+		{"pt-BR", []Plural{one, one, other, other, other, other, other, other, other, other, other, other, other, other, other, other, other, other, other, other}},
+		{"pt_PT", []Plural{other, one, other, other, other, other, other, other, other, other, other, other, other, other, other, other, other, other, other, other}},
+		{"ru", []Plural{many, one, few, few, few, many, many, many, many, many, many, one, few, many, many, one, few, few, many, many}},
+		{"pl", []Plural{many, one, few, few, few, many, many, many, many, many, many, many, few, many, many, many, few, few, many, many}},
+		{"cs", []Plural{other, one, few, few, few, other, other, other, other, other, other, other, other, other, other, other, other, other, other, other}},
+		{"ar", []Plural{zero, one, two, few, few, few, few, many, many, many, many, many, many, many, other, other, other, few, many, many}},
+		{"ar-EG", []Plural{zero, one, two, few, few, few, few, many, many, many, many, many, many, many, other, other, other, few, many, many}},
+	}
+	for _, test := range tests {
+		rule := PluralRuleOf(test.locale)
+		for i, n := range counts {
+			if p := rule(n); p != test.expected[i] {
+				t.Errorf("%s: plural of %d is %v, expected %v", test.locale, n, p, test.expected[i])
+			}
+		}
+	}
+}
+
+// Tests that negative counts select the plural forms of their absolute values
+func TestPluralNegative(t *testing.T) {
+
+	for _, locale := range []string{"en", "fr", "ru", "pl", "cs", "ar"} {
+		rule := PluralRuleOf(locale)
+		for _, n := range []int{1, 2, 5, 11, 21, 22, 103} {
+			if rule(-n) != rule(n) {
+				t.Errorf("%s: plural of %d is %v, expected %v", locale, -n, rule(-n), rule(n))
+			}
+		}
+	}
+}
@@ -13,6 +13,7 @@ import (
 	"github.com/sansebasko/engine/gui/assets"
 	"image"
 	_ "image/png"
+	"math"
 	"os"
 )
 
@@ -46,6 +47,8 @@ type glfwWindow struct {
 	lastHeight      int
 	scaleX          float64
 	scaleY          float64
+	contentX        float64
+	contentY        float64
 
 	// Events
 	keyEv    KeyEvent
@@ -55,6 +58,7 @@ type glfwWindow struct {
 	sizeEv   SizeEvent
 	cursorEv CursorEvent
 	scrollEv ScrollEvent
+	scaleEv  ScaleEvent
 }
 
 // glfw manager singleton
@@ -203,6 +207,7 @@ func (m *glfwManager) CreateWindow(width, height int, title string, fullscreen b
 	fbw, fbh := w.FramebufferSize()
 	w.scaleX = float64(fbw) / float64(width)
 	w.scaleY = float64(fbh) / float64(height)
+	w.contentX, w.contentY = w.contentScale()
 
 	// Set key callback to dispatch event
 	win.SetKeyCallback(func(x *glfw.Window, key glfw.Key, scancode int, action glfw.Action, mods glfw.ModifierKey) {
@@ -267,6 +272,20 @@ func (m *glfwManager) CreateWindow(width, height int, title string, fullscreen b
 		w.scaleX = float64(fbw) / float64(width)
 		w.scaleY = float64(fbh) / float64(height)
 		w.Dispatch(OnWindowSize, &w.sizeEv)
+		w.updateContentScale()
+	})
+
+	// Set framebuffer size callback to update the scale, which changes
+	// without the window size when it is moved to a monitor with another resolution
+	win.SetFramebufferSizeCallback(func(x *glfw.Window, fbw int, fbh int) {
+
+		width, height := x.GetSize()
+		if width == 0 || height == 0 {
+			return
+		}
+		w.scaleX = float64(fbw) / float64(width)
+		w.scaleY = float64(fbh) / float64(height)
+		w.updateContentScale()
 	})
 
 	// Set window position event callback to dispatch event
@@ -276,6 +295,7 @@ func (m *glfwManager) CreateWindow(width, height int, title string, fullscreen b
 		w.posEv.Xpos = xpos
 		w.posEv.Ypos = ypos
 		w.Dispatch(OnWindowPos, &w.posEv)
+		w.updateContentScale()
 	})
 
 	// Set window cursor position event callback to dispatch event
@@ -375,6 +395,67 @@ func (w *glfwWindow) Scale() (x float64, y float64) {
 	return w.scaleX, w.scaleY
 }
 
+// ContentScale returns this window's content scale factor, which is the ratio between
+// the size in framebuffer pixels of the user interface recommended for its monitor and
+// its size at 96 DPI. It is the window scale on platforms where the framebuffer has
+// more pixels than the window, such as macOS with Retina displays, and is otherwise
+// estimated from the resolution of the monitor which contains the window center.
+func (w *glfwWindow) ContentScale() (x float64, y float64) {
+
+	return w.contentX, w.contentY
+}
+
+// contentScale calculates the content scale of this window
+func (w *glfwWindow) contentScale() (x float64, y float64) {
+
+	if w.scaleX > 1 || w.scaleY > 1 {
+		return w.scaleX, w.scaleY
+	}
+	wx, wy := w.win.GetPos()
+	width, height := w.win.GetSize()
+	cx := wx + width/2
+	cy := wy + height/2
+	for _, mon := range glfw.GetMonitors() {
+		mx, my := mon.GetPos()
+		vmode := mon.GetVideoMode()
+		if vmode == nil || cx < mx || cx >= mx+vmode.Width || cy < my || cy >= my+vmode.Height {
+			continue
+		}
+		// Some monitors report their aspect ratio or nothing as physical size
+		widthMM, _ := mon.GetPhysicalSize()
+		if widthMM < 100 {
+			break
+		}
+		dpi := float64(vmode.Width) * 25.4 / float64(widthMM)
+		scale := math.Floor(dpi/96*4+0.5) / 4
+		if scale > 1 {
+			return scale, scale
+		}
+		break
+	}
+	return 1, 1
+}
+
+// updateContentScale updates the content scale of this window and
+// dispatches OnContentScale if it changed
+func (w *glfwWindow) updateContentScale() {
+
+	// The scale is not updated while the window is minimized
+	if width, height := w.win.GetSize(); width == 0 || height == 0 {
+		return
+	}
+	x, y := w.contentScale()
+	if x == w.contentX && y == w.contentY {
+		return
+	}
+	w.contentX = x
+	w.contentY = y
+	w.scaleEv.W = w
+	w.scaleEv.X = x
+	w.scaleEv.Y = y
+	w.Dispatch(OnContentScale, &w.scaleEv)
+}
+
 // Size returns this window's size in screen coordinates
 func (w *glfwWindow) Size() (width int, height int) {
 
@@ -463,3 +544,20 @@ func (w *glfwWindow) SetCursorPos(xpos, ypos float64) {
 
 	w.win.SetCursorPos(xpos, ypos)
 }
+
+// ClipboardString returns the contents of the system clipboard
+// if it contains or is convertible to a UTF-8 encoded string
+func (w *glfwWindow) ClipboardString() string {
+
+	s, err := w.win.GetClipboardString()
+	if err != nil {
+		return ""
+	}
+	return s
+}
+
+// SetClipboardString sets the system clipboard to the specified UTF-8 encoded string
+func (w *glfwWindow) SetClipboardString(s string) {
+
+	w.win.SetClipboardString(s)
+}
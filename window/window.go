@@ -47,6 +47,26 @@ type IWindow interface {
 	Destroy()
 }
 
+// IClipboard is the interface of the windows with access to the system clipboard
+type IClipboard interface {
+	ClipboardString() string
+	SetClipboardString(s string)
+}
+
+// IContentScaler is the interface of the windows which report the content
+// scale of their monitor, which is dispatched in OnContentScale events
+type IContentScaler interface {
+	ContentScale() (x float64, y float64)
+}
+
+// IComposer is the interface of the windows which dispatch the text composition
+// events of input methods and can place their candidate window
+type IComposer interface {
+	// SetCompositionRect sets the rectangle, in the coordinates of the cursor events,
+	// of the text being composed, next to which the input method shows its candidate window
+	SetCompositionRect(x, y, width, height int)
+}
+
 // Key corresponds to a keyboard key.
 type Key int
 
@@ -265,6 +285,16 @@ const (
 	OnMouseDown  = "win.OnMouseDown"
 	OnScroll     = "win.OnScroll"
 	OnFrame      = "win.OnFrame"
+
+	// Text composition events of input methods (CompositionEvent).
+	// The GLFW window does not dispatch them because GLFW 3.2 only reports the
+	// text committed by input methods, as OnChar events. Window implementations
+	// with access to the input method of their platform dispatch them.
+	OnCompositionStart  = "win.OnCompositionStart"  // Input method starts composing text
+	OnCompositionUpdate = "win.OnCompositionUpdate" // Input method changes the text being composed
+	OnCompositionCommit = "win.OnCompositionCommit" // Input method ends composing text and commits it
+
+	OnContentScale = "win.OnContentScale" // Content scale of the window changed (ScaleEvent)
 )
 
 // PosEvent describes a windows position changed event
@@ -281,6 +311,14 @@ type SizeEvent struct {
 	Height int
 }
 
+// ScaleEvent describes a window content scale changed event,
+// such as when the window is moved to a monitor with another resolution
+type ScaleEvent struct {
+	W IWindow
+	X float64
+	Y float64
+}
+
 // KeyEvent describes a window key event
 type KeyEvent struct {
 	W        IWindow
@@ -297,6 +335,17 @@ type CharEvent struct {
 	Mods ModifierKey
 }
 
+// CompositionEvent describes a text composition event of an input method.
+// The text of OnCompositionStart and OnCompositionUpdate is the text being
+// composed (preedit text), which is not part of the input yet, and the text of
+// OnCompositionCommit is the text to input, which is empty if the composition
+// was canceled. Committed text is not dispatched as OnChar events.
+type CompositionEvent struct {
+	W      IWindow
+	Text   string // Text being composed or committed
+	Cursor int    // Position of the input method cursor in the text in characters
+}
+
 // MouseEvent describes a mouse event over the window
 type MouseEvent struct {
 	W      IWindow